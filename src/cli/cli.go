@@ -3,6 +3,7 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"os"
 
 	"github.com/Gustrb/jbm/src/core"
 )
@@ -37,6 +38,26 @@ func (cli *CLI) DumpUsage() {
 
 	fmt.Println(" The arguments after the main class, -jar <jarfile>, -m or --module")
 	fmt.Println(" <module>/<mainclass> are specified as the arguments for the main class.")
+
+	fmt.Println()
+	fmt.Println(" where options include:")
+	fmt.Println("    -cp <class search path of directories and zip/jar files>")
+	fmt.Println("    -classpath <class search path of directories and zip/jar files>")
+	fmt.Println("    --class-path <class search path of directories and zip/jar files>")
+	fmt.Printf("\t\t  A %c separated list of directories, JAR archives,\n", os.PathListSeparator)
+	fmt.Println("\t\t  and ZIP archives to search for class files.")
+	fmt.Println("    -p <module path>")
+	fmt.Println("    --module-path <module path>...")
+	fmt.Printf("\t\t  A %c separated list of elements, each element is a file path\n", os.PathListSeparator)
+	fmt.Println("\t\t  to a module or a directory containing modules. Each module is either")
+	fmt.Println("\t\t  a modular JAR, a JMOD file or an exploded-module directory.")
+	fmt.Println("    --add-modules <module name>[,<module name>...]")
+	fmt.Println("\t\t  root modules to resolve in addition to the initial module.")
+	fmt.Println("\t\t  <module name> can also be ALL-MODULE-PATH.")
+	fmt.Println("    --java-home <directory of a JDK>")
+	fmt.Println("\t\t  Loads the platform classes from the lib/modules image of the JDK.")
+	fmt.Println("    --class-stats")
+	fmt.Println("\t\t  Prints the hits, misses and parse time of the class cache on exit.")
 }
 
 func (cli *CLI) validateArguments() error {
@@ -54,9 +75,29 @@ func (cli *CLI) Run() error {
 	}
 
 	if err := core.RunJBM(cli.Arguments); err != nil {
-		fmt.Println(err)
+		var exit *core.ExitError
+		var uncaught *core.UncaughtException
+
+		switch {
+		case errors.As(err, &uncaught):
+			uncaught.PrintStackTrace(os.Stderr)
+		case !errors.As(err, &exit):
+			fmt.Println(err)
+		}
+
 		return err
 	}
 
 	return nil
 }
+
+// ExitCode returns the exit status of the process for an error returned by `Run`, which is the code passed
+// to `System.exit` when the program called it, and 1 for any other error.
+func ExitCode(err error) int {
+	var exit *core.ExitError
+	if errors.As(err, &exit) {
+		return exit.Code
+	}
+
+	return 1
+}
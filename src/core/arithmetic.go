@@ -0,0 +1,193 @@
+package core
+
+import "math"
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-2.html#jvms-2.8
+
+// intArithmetic applies a binary int instruction, shift distances only use their five lowest bits.
+func intArithmetic(op uint8, v1 int32, v2 int32) (int32, error) {
+	switch op {
+	case OP_IADD:
+		return v1 + v2, nil
+	case OP_ISUB:
+		return v1 - v2, nil
+	case OP_IMUL:
+		return v1 * v2, nil
+	case OP_IDIV, OP_IREM:
+		if v2 == 0 {
+			return 0, NewJavaError(ArithmeticException, "/ by zero")
+		}
+
+		// the overflow of MinInt32 / -1 gives MinInt32, just like Java
+		if op == OP_IDIV {
+			return v1 / v2, nil
+		}
+
+		return v1 % v2, nil
+	case OP_ISHL:
+		return v1 << (v2 & 0x1f), nil
+	case OP_ISHR:
+		return v1 >> (v2 & 0x1f), nil
+	case OP_IUSHR:
+		return int32(uint32(v1) >> (v2 & 0x1f)), nil
+	case OP_IAND:
+		return v1 & v2, nil
+	case OP_IOR:
+		return v1 | v2, nil
+	}
+
+	return v1 ^ v2, nil
+}
+
+// compareInt compares two ints with the condition of an if instruction, given as its offset from ifeq:
+// eq, ne, lt, ge, gt and le.
+func compareInt(cond uint8, v1 int32, v2 int32) bool {
+	switch cond {
+	case 0:
+		return v1 == v2
+	case 1:
+		return v1 != v2
+	case 2:
+		return v1 < v2
+	case 3:
+		return v1 >= v2
+	case 4:
+		return v1 > v2
+	}
+
+	return v1 <= v2
+}
+
+// longArithmetic applies a binary long instruction, other than the shifts.
+func longArithmetic(op uint8, v1 int64, v2 int64) (int64, error) {
+	switch op {
+	case OP_LADD:
+		return v1 + v2, nil
+	case OP_LSUB:
+		return v1 - v2, nil
+	case OP_LMUL:
+		return v1 * v2, nil
+	case OP_LDIV, OP_LREM:
+		if v2 == 0 {
+			return 0, NewJavaError(ArithmeticException, "/ by zero")
+		}
+
+		if op == OP_LDIV {
+			return v1 / v2, nil
+		}
+
+		return v1 % v2, nil
+	case OP_LAND:
+		return v1 & v2, nil
+	case OP_LOR:
+		return v1 | v2, nil
+	}
+
+	return v1 ^ v2, nil
+}
+
+// longShift shifts a long, the distance only uses its six lowest bits.
+func longShift(op uint8, v int64, distance int32) int64 {
+	distance &= 0x3f
+
+	switch op {
+	case OP_LSHL:
+		return v << distance
+	case OP_LSHR:
+		return v >> distance
+	}
+
+	return int64(uint64(v) >> distance)
+}
+
+// floatArithmetic applies a binary float instruction, following IEEE 754 with round to nearest like Java.
+// The remainder truncates the quotient, like C's fmod, instead of rounding it as IEEE 754 does.
+func floatArithmetic(op uint8, v1 float32, v2 float32) float32 {
+	switch op {
+	case OP_FADD:
+		return v1 + v2
+	case OP_FSUB:
+		return v1 - v2
+	case OP_FMUL:
+		return v1 * v2
+	case OP_FDIV:
+		return v1 / v2
+	}
+
+	// the remainder of two floats is exact, so computing it with doubles gives the same result
+	return float32(math.Mod(float64(v1), float64(v2)))
+}
+
+// doubleArithmetic applies a binary double instruction, see `floatArithmetic`.
+func doubleArithmetic(op uint8, v1 float64, v2 float64) float64 {
+	switch op {
+	case OP_DADD:
+		return v1 + v2
+	case OP_DSUB:
+		return v1 - v2
+	case OP_DMUL:
+		return v1 * v2
+	case OP_DDIV:
+		return v1 / v2
+	}
+
+	return math.Mod(v1, v2)
+}
+
+// compareLong implements lcmp.
+func compareLong(v1 int64, v2 int64) int32 {
+	switch {
+	case v1 > v2:
+		return 1
+	case v1 < v2:
+		return -1
+	}
+
+	return 0
+}
+
+// compareFloating implements fcmpl, fcmpg, dcmpl and dcmpg, they only differ when a value is NaN: the
+// `g` variants push 1 and the `l` variants push -1.
+func compareFloating(v1 float64, v2 float64, nanGreater bool) int32 {
+	switch {
+	case v1 > v2:
+		return 1
+	case v1 < v2:
+		return -1
+	case v1 == v2:
+		return 0
+	case nanGreater:
+		return 1
+	}
+
+	return -1
+}
+
+// doubleToInt converts a double to an int rounding towards zero, NaN is 0 and the values out of range
+// saturate to the minimum or maximum int. It also implements f2i, since floats convert to doubles exactly.
+func doubleToInt(v float64) int32 {
+	switch {
+	case v != v:
+		return 0
+	case v >= math.MaxInt32:
+		return math.MaxInt32
+	case v <= math.MinInt32:
+		return math.MinInt32
+	}
+
+	return int32(v)
+}
+
+// doubleToLong converts a double to a long like `doubleToInt` does.
+func doubleToLong(v float64) int64 {
+	switch {
+	case v != v:
+		return 0
+	case v >= math.MaxInt64:
+		return math.MaxInt64
+	case v <= math.MinInt64:
+		return math.MinInt64
+	}
+
+	return int64(v)
+}
@@ -0,0 +1,324 @@
+package core
+
+import (
+	"strings"
+	"sync"
+)
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-5.html#jvms-5.3.3
+
+// MaxArrayDimensions is the maximum number of dimensions of an array type (JVMS 4.4.1).
+const MaxArrayDimensions = 255
+
+// newArrayTypes are the names of the array classes of the atype operand of the newarray instruction.
+var newArrayTypes = map[uint8]string{4: "[Z", 5: "[C", 6: "[F", 7: "[D", 8: "[B", 9: "[S", 10: "[I", 11: "[J"}
+
+// IsArray tells if the class is an array class.
+func (c *Class) IsArray() bool {
+	return strings.HasPrefix(c.Name, "[")
+}
+
+// ElementType returns the class of the elements of an array class once all its dimensions are removed, or
+// nil when they are of a primitive type. It is the class itself for classes that are not arrays.
+func (c *Class) ElementType() *Class {
+	class := c
+	for class.ComponentType != nil {
+		class = class.ComponentType
+	}
+
+	if class.IsArray() {
+		return nil
+	}
+
+	return class
+}
+
+// descriptor returns the field descriptor of the class, the descriptor of its instances.
+func (c *Class) descriptor() string {
+	if c.IsArray() {
+		return c.Name
+	}
+
+	return "L" + c.Name + ";"
+}
+
+// ArrayClass returns the class of the arrays whose components are instances of this class.
+func (c *Class) ArrayClass() (*Class, error) {
+	return c.Loader.LoadClass("[" + c.descriptor())
+}
+
+// createArrayClass creates an array class (JVMS 5.3.3). The component type is loaded by this loader and
+// the array class is defined by the defining loader of the component type, or by the bootstrap loader for
+// arrays of primitive types.
+//
+// Array classes extend java/lang/Object, implement java/lang/Cloneable and java/io/Serializable and are
+// initialized from the start, as they have no initializer to run.
+func (l *ClassLoader) createArrayClass(name string, state *loadState) (*Class, error) {
+	dimensions := len(name) - len(strings.TrimLeft(name, "["))
+	if dimensions > MaxArrayDimensions || fieldDescriptorLength(name) != len(name) {
+		return nil, NewJavaError(ClassNotFoundException, "%s", javaName(name))
+	}
+
+	bootstrap := l.bootstrap()
+	defining, flags := bootstrap, uint16(ACC_PUBLIC)
+
+	var component *Class
+	if elem := name[1:]; elem[0] == 'L' || elem[0] == '[' {
+		componentName := elem
+		if elem[0] == 'L' {
+			componentName = elem[1 : len(elem)-1]
+		}
+
+		var err error
+		if component, err = l.loadClass(componentName, state); err != nil {
+			return nil, err
+		}
+
+		defining, flags = component.Loader, component.AccessFlags&(ACC_PUBLIC|ACC_PRIVATE|ACC_PROTECTED)
+	}
+
+	if defining != l {
+		return defining.loadClass(name, state)
+	}
+
+	supers := make([]*Class, 3)
+	for i, super := range []string{"java/lang/Object", "java/lang/Cloneable", "java/io/Serializable"} {
+		class, err := bootstrap.loadClass(super, state)
+		if err != nil {
+			return nil, err
+		}
+
+		supers[i] = class
+	}
+
+	class := &Class{
+		Name:          name,
+		Loader:        l,
+		Super:         supers[0],
+		Interfaces:    supers[1:],
+		AccessFlags:   flags | ACC_FINAL | ACC_ABSTRACT,
+		ComponentType: component,
+	}
+
+	class.init.cond = sync.NewCond(&class.init.mu)
+	class.init.state = ClassInitialized
+	class.buildMethodTables()
+
+	return class, nil
+}
+
+// NewArray allocates an array of the given array class with every component set to its default value.
+//
+// The components are kept in a Go slice of the type of the components: booleans and bytes in a []int8,
+// chars in a []uint16, shorts in a []int16, ints in a []int32, longs in a []int64, floats in a []float32,
+// doubles in a []float64 and references in a []*Object.
+func NewArray(class *Class, length int) *Object {
+	var elements any
+
+	switch class.Name[1] {
+	case 'Z', 'B':
+		elements = make([]int8, length)
+	case 'C':
+		elements = make([]uint16, length)
+	case 'S':
+		elements = make([]int16, length)
+	case 'I':
+		elements = make([]int32, length)
+	case 'J':
+		elements = make([]int64, length)
+	case 'F':
+		elements = make([]float32, length)
+	case 'D':
+		elements = make([]float64, length)
+	default:
+		elements = make([]*Object, length)
+	}
+
+	return &Object{Class: class, Elements: elements}
+}
+
+// ArrayLength returns the number of components of an array, it is 0 for objects that are not arrays.
+func (o *Object) ArrayLength() int {
+	switch elements := o.Elements.(type) {
+	case []int8:
+		return len(elements)
+	case []uint16:
+		return len(elements)
+	case []int16:
+		return len(elements)
+	case []int32:
+		return len(elements)
+	case []int64:
+		return len(elements)
+	case []float32:
+		return len(elements)
+	case []float64:
+		return len(elements)
+	case []*Object:
+		return len(elements)
+	}
+
+	return 0
+}
+
+// newArray resolves the array class of a newarray or anewarray instruction and allocates an array of it
+// with the length on the operand stack.
+func (t *Thread) newArray(f *Frame, op uint8, operand uint16) (*Object, error) {
+	var class *Class
+	var err error
+
+	if op == OP_NEWARRAY {
+		name, ok := newArrayTypes[uint8(operand)]
+		if !ok {
+			return nil, NewJavaError(VerifyError, "Bad array type %d in method %s at pc %d", operand, f.Method, f.PC)
+		}
+
+		class, err = f.Method.Class.Loader.LoadClass(name)
+	} else {
+		class, err = f.Method.Class.ConstantPool.ResolveClass(operand)
+		if err == nil {
+			class, err = class.ArrayClass()
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	length := f.popInt()
+	if length < 0 {
+		return nil, NewJavaError(NegativeArraySizeException, "%d", length)
+	}
+
+	return NewArray(class, int(length)), nil
+}
+
+// newMultiArray resolves the array class of a multianewarray instruction and allocates an array of it
+// with the given number of dimensions, whose lengths are on the operand stack. The dimensions after the
+// first one of length zero are not allocated.
+func (t *Thread) newMultiArray(f *Frame, index uint16, dimensions int) (*Object, error) {
+	class, err := f.Method.Class.ConstantPool.ResolveClass(index)
+	if err != nil {
+		return nil, err
+	}
+
+	if dimensions < 1 || dimensions > strings.IndexFunc(class.Name, func(r rune) bool { return r != '[' }) {
+		return nil, NewJavaError(VerifyError, "Bad dimensions %d for %s in method %s at pc %d", dimensions, class.Name, f.Method, f.PC)
+	}
+
+	lengths := f.popSlots(dimensions)
+	for _, length := range lengths {
+		if length.Num < 0 {
+			return nil, NewJavaError(NegativeArraySizeException, "%d", length.Num)
+		}
+	}
+
+	var allocate func(class *Class, lengths []Slot) *Object
+	allocate = func(class *Class, lengths []Slot) *Object {
+		array := NewArray(class, int(lengths[0].Num))
+		if len(lengths) > 1 {
+			for i, elements := 0, array.Elements.([]*Object); i < len(elements); i++ {
+				elements[i] = allocate(class.ComponentType, lengths[1:])
+			}
+		}
+
+		return array
+	}
+
+	return allocate(class, lengths), nil
+}
+
+// arrayIndex checks that the array is not null and that the index is within its bounds.
+func arrayIndex(array *Object, index int32) (int, error) {
+	if array == nil {
+		return 0, &JavaError{ClassName: NullPointerException}
+	}
+
+	if length := array.ArrayLength(); index < 0 || int(index) >= length {
+		return 0, NewJavaError(ArrayIndexOutOfBoundsException, "Index %d out of bounds for length %d", index, length)
+	}
+
+	return int(index), nil
+}
+
+// arrayLoad executes an array load instruction, pushing the component of the array at the index on the
+// operand stack. As bytecode is not verified, the instruction is assumed to match the type of the array.
+func arrayLoad(f *Frame) error {
+	index := f.popInt()
+	array := f.popRef()
+
+	i, err := arrayIndex(array, index)
+	if err != nil {
+		return err
+	}
+
+	switch elements := array.Elements.(type) {
+	case []int8:
+		f.pushInt(int32(elements[i]))
+	case []uint16:
+		f.pushInt(int32(elements[i]))
+	case []int16:
+		f.pushInt(int32(elements[i]))
+	case []int32:
+		f.pushInt(elements[i])
+	case []int64:
+		f.pushLong(elements[i])
+	case []float32:
+		f.pushFloat(elements[i])
+	case []float64:
+		f.pushDouble(elements[i])
+	case []*Object:
+		f.pushRef(elements[i])
+	}
+
+	return nil
+}
+
+// arrayStore executes an array store instruction, setting the component of the array at the index to the
+// value on the operand stack. Values stored in boolean arrays are truncated to their lowest bit, and
+// references must be instances of the component type of the array, or it fails with an ArrayStoreException.
+func arrayStore(f *Frame, op uint8) error {
+	slots := 1
+	if op == OP_LASTORE || op == OP_DASTORE {
+		slots = 2
+	}
+
+	value := f.popSlots(slots)
+	index := f.popInt()
+	array := f.popRef()
+
+	i, err := arrayIndex(array, index)
+	if err != nil {
+		return err
+	}
+
+	switch elements := array.Elements.(type) {
+	case []int8:
+		if array.Class.Name == "[Z" {
+			elements[i] = int8(value[0].Num & 1)
+		} else {
+			elements[i] = int8(value[0].Num)
+		}
+	case []uint16:
+		elements[i] = uint16(value[0].Num)
+	case []int16:
+		elements[i] = int16(value[0].Num)
+	case []int32:
+		elements[i] = value[0].Num
+	case []int64:
+		elements[i] = SlotsLong(value)
+	case []float32:
+		elements[i] = SlotFloat(value[0])
+	case []float64:
+		elements[i] = SlotsDouble(value)
+	case []*Object:
+		ref := value[0].Ref
+		if ref != nil && !ref.IsInstanceOf(array.Class.ComponentType) {
+			return NewJavaError(ArrayStoreException, "%s", ref.Class.JavaName())
+		}
+
+		elements[i] = ref
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package core_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+func TestShouldCreateArrayClassesInTheLoaderOfTheirElementType(t *testing.T) {
+	dir := t.TempDir()
+	writeClassFile(t, dir, "Foo", minimalClassFile("Foo"))
+
+	loaders := newTestLoaders(t, dir)
+
+	ints, err := loaders.Application.LoadClass("[I")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if bootstrapInts, _ := loaders.Bootstrap.LoadClass("[I"); ints != bootstrapInts || ints.Loader != loaders.Bootstrap {
+		t.Errorf("Expected arrays of primitive types to be defined by the bootstrap loader, got %v", ints)
+	}
+
+	if ints.Super.Name != "java/lang/Object" || len(ints.Interfaces) != 2 ||
+		ints.Interfaces[0].Name != "java/lang/Cloneable" || ints.Interfaces[1].Name != "java/io/Serializable" {
+		t.Errorf("Expected arrays to extend Object and implement Cloneable and Serializable, got %v %v", ints.Super, ints.Interfaces)
+	}
+
+	if !ints.IsInitialized() || ints.ComponentType != nil || ints.ElementType() != nil {
+		t.Errorf("Expected an initialized array of a primitive type, got %v", ints)
+	}
+
+	matrix, err := loaders.Application.LoadClass("[[LFoo;")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	foo, _ := loaders.Application.LoadClass("Foo")
+	if matrix.Loader != loaders.Application || matrix.ComponentType.Name != "[LFoo;" || matrix.ElementType() != foo {
+		t.Errorf("Expected an array of arrays of Foo defined by the application loader, got %v", matrix)
+	}
+
+	if matrix.JavaName() != "[[LFoo;" || matrix.AccessFlags != core.ACC_PUBLIC|core.ACC_FINAL|core.ACC_ABSTRACT {
+		t.Errorf("Expected the name and access flags of an array class, got %s %#x", matrix.JavaName(), matrix.AccessFlags)
+	}
+
+	for _, name := range []string{"[X", "[LFoo", "[", strings.Repeat("[", 256) + "I"} {
+		if _, err := loaders.Application.LoadClass(name); !core.IsJavaError(err, core.ClassNotFoundException) {
+			t.Errorf("Expected %q to be an invalid array class, got %v", name, err)
+		}
+	}
+}
+
+func TestShouldCheckTheSubclassesOfArrayClasses(t *testing.T) {
+	dir := t.TempDir()
+	writeClassFile(t, dir, "Foo", minimalClassFile("Foo"))
+
+	loaders := newTestLoaders(t, dir)
+
+	load := func(name string) *core.Class {
+		class, err := loaders.Application.LoadClass(name)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		return class
+	}
+
+	for _, test := range []struct {
+		class    string
+		super    string
+		expected bool
+	}{
+		{"[LFoo;", "[Ljava/lang/Object;", true},
+		{"[[LFoo;", "[[Ljava/lang/Object;", true},
+		{"[[LFoo;", "[Ljava/lang/Object;", true},
+		{"[[LFoo;", "java/lang/Cloneable", true},
+		{"[I", "java/io/Serializable", true},
+		{"[I", "[Ljava/lang/Object;", false},
+		{"[I", "[J", false},
+		{"[Ljava/lang/Object;", "[LFoo;", false},
+		{"Foo", "[LFoo;", false},
+	} {
+		if actual := load(test.class).IsSubclassOf(load(test.super)); actual != test.expected {
+			t.Errorf("Expected %s to be a subclass of %s to be %v", test.class, test.super, test.expected)
+		}
+	}
+}
+
+func TestShouldAllocateAndAccessArrays(t *testing.T) {
+	dir := t.TempDir()
+	writeClassFile(t, dir, "Foo", minimalClassFile("Foo"))
+
+	b := newTestClassBuilder()
+	grid := b.class("[[J")
+	foos := b.class("Foo")
+	static := core.ACC_PUBLIC | core.ACC_STATIC
+
+	writeClassFile(t, dir, "Arrays", b.build(core.ACC_PUBLIC, "Arrays", "java/lang/Object", nil, nil,
+		[][]byte{
+			b.member(static, "ints", "(I)[I", b.code(1, 1, core.OP_ILOAD_0, core.OP_NEWARRAY, 10, core.OP_ARETURN)),
+			b.member(static, "foos", "(I)[LFoo;", b.code(1, 1, concat([]byte{core.OP_ILOAD_0, core.OP_ANEWARRAY}, u2(foos), []byte{core.OP_ARETURN})...)),
+			b.member(static, "grid", "(II)[[J", b.code(2, 2, concat([]byte{core.OP_ILOAD_0, core.OP_ILOAD_1, core.OP_MULTIANEWARRAY}, u2(grid), []byte{2, core.OP_ARETURN})...)),
+			b.member(static, "length", "([I)I", b.code(1, 1, core.OP_ALOAD_0, core.OP_ARRAYLENGTH, core.OP_IRETURN)),
+			b.member(static, "get", "([II)I", b.code(2, 2, core.OP_ALOAD_0, core.OP_ILOAD_1, core.OP_IALOAD, core.OP_IRETURN)),
+			b.member(static, "put", "([JIJ)V", b.code(4, 4, core.OP_ALOAD_0, core.OP_ILOAD_1, core.OP_LLOAD_2, core.OP_LASTORE, core.OP_RETURN)),
+			b.member(static, "putBoolean", "([ZII)V", b.code(3, 3, core.OP_ALOAD_0, core.OP_ILOAD_1, core.OP_ILOAD_2, core.OP_BASTORE, core.OP_RETURN)),
+			b.member(static, "putObject", "([Ljava/lang/Object;Ljava/lang/Object;)V", b.code(3, 2, core.OP_ALOAD_0, core.OP_ICONST_0, core.OP_ALOAD_1, core.OP_AASTORE, core.OP_RETURN)),
+		},
+		nil,
+	))
+
+	loaders := newTestLoaders(t, dir)
+
+	class, err := loaders.Application.LoadClass("Arrays")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ret, err := invokeSlots(t, class, "ints", "(I)[I", []core.Slot{{Num: 3}})
+	if err != nil || ret[0].Ref.Class.Name != "[I" || ret[0].Ref.ArrayLength() != 3 {
+		t.Fatalf("Expected an array of 3 ints, got %v (%v)", ret, err)
+	}
+
+	ints := ret[0].Ref
+	ints.Elements.([]int32)[2] = 42
+
+	if value, err := invokeSlots(t, class, "get", "([II)I", []core.Slot{{Ref: ints}, {Num: 2}}); err != nil || value[0].Num != 42 {
+		t.Errorf("Expected the third int to be 42, got %v (%v)", value, err)
+	}
+
+	if length, err := invokeSlots(t, class, "length", "([I)I", []core.Slot{{Ref: ints}}); err != nil || length[0].Num != 3 {
+		t.Errorf("Expected the length to be 3, got %v (%v)", length, err)
+	}
+
+	_, err = invokeSlots(t, class, "get", "([II)I", []core.Slot{{Ref: ints}, {Num: 3}})
+	if err == nil || err.Error() != "java.lang.ArrayIndexOutOfBoundsException: Index 3 out of bounds for length 3" {
+		t.Errorf("Expected an ArrayIndexOutOfBoundsException, got %v", err)
+	}
+
+	_, err = invokeSlots(t, class, "get", "([II)I", []core.Slot{{}, {Num: 0}})
+	if !core.IsJavaError(err, core.NullPointerException) {
+		t.Errorf("Expected a NullPointerException, got %v", err)
+	}
+
+	_, err = invokeSlots(t, class, "ints", "(I)[I", []core.Slot{{Num: -1}})
+	if err == nil || err.Error() != "java.lang.NegativeArraySizeException: -1" {
+		t.Errorf("Expected a NegativeArraySizeException, got %v", err)
+	}
+
+	longs, _ := loaders.Application.LoadClass("[J")
+	array := core.NewArray(longs, 2)
+	if _, err := invokeSlots(t, class, "put", "([JIJ)V", []core.Slot{{Ref: array}, {Num: 1}}, core.LongSlots(-5)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if elements := array.Elements.([]int64); elements[0] != 0 || elements[1] != -5 {
+		t.Errorf("Expected the longs to be [0 -5], got %v", elements)
+	}
+
+	booleans, _ := loaders.Application.LoadClass("[Z")
+	array = core.NewArray(booleans, 1)
+	for _, test := range []struct{ value, expected int32 }{{3, 1}, {2, 0}} {
+		if _, err := invokeSlots(t, class, "putBoolean", "([ZII)V", []core.Slot{{Ref: array}, {Num: 0}, {Num: test.value}}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if stored := array.Elements.([]int8)[0]; int32(stored) != test.expected {
+			t.Errorf("Expected storing %d in a boolean array to store %d, got %d", test.value, test.expected, stored)
+		}
+	}
+
+	ret, err = invokeSlots(t, class, "foos", "(I)[LFoo;", []core.Slot{{Num: 1}})
+	if err != nil || ret[0].Ref.Class.Name != "[LFoo;" {
+		t.Fatalf("Expected an array of Foo, got %v (%v)", ret, err)
+	}
+
+	object, _ := loaders.Application.LoadClass("java/lang/Object")
+	_, err = invokeSlots(t, class, "putObject", "([Ljava/lang/Object;Ljava/lang/Object;)V", []core.Slot{ret[0], {Ref: core.NewObject(object)}})
+	if err == nil || err.Error() != "java.lang.ArrayStoreException: java.lang.Object" {
+		t.Errorf("Expected an ArrayStoreException, got %v", err)
+	}
+
+	ret, err = invokeSlots(t, class, "grid", "(II)[[J", []core.Slot{{Num: 2}, {Num: 3}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	rows := ret[0].Ref.Elements.([]*core.Object)
+	if len(rows) != 2 || rows[0] == rows[1] || rows[1].Class.Name != "[J" || rows[1].ArrayLength() != 3 {
+		t.Errorf("Expected 2 distinct rows of 3 longs, got %v", rows)
+	}
+
+	ret, err = invokeSlots(t, class, "grid", "(II)[[J", []core.Slot{{Num: 0}, {Num: 3}})
+	if err != nil || ret[0].Ref.ArrayLength() != 0 {
+		t.Errorf("Expected an empty grid, got %v (%v)", ret, err)
+	}
+
+	_, err = invokeSlots(t, class, "grid", "(II)[[J", []core.Slot{{Num: 0}, {Num: -2}})
+	if err == nil || err.Error() != "java.lang.NegativeArraySizeException: -2" {
+		t.Errorf("Expected a NegativeArraySizeException, got %v", err)
+	}
+}
@@ -0,0 +1,542 @@
+package core
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Spec: https://github.com/openjdk/jdk21u/blob/master/src/hotspot/share/runtime/threads.cpp
+
+// Names of the classes the JDK boots with.
+const (
+	threadClass      = "java/lang/Thread"
+	threadGroupClass = "java/lang/ThreadGroup"
+)
+
+// bootClasses are the classes initialized before the thread of the program exists, in the order HotSpot
+// initializes them.
+var bootClasses = []string{stringClass, systemClass, classClass, threadGroupClass, threadClass}
+
+// initializeSystem boots the class library of the JDK on the thread, which becomes the main thread: it
+// creates the system and main thread groups and the java/lang/Thread of the thread, and runs the three
+// phases of `System` initialization, which set up the system properties, the standard streams, the module
+// system and the system class loader.
+//
+// The built-in class library does not need booting, nothing is done when `System` has no `initPhase1`.
+func (t *Thread) initializeSystem(boot *ClassLoader, properties map[string]string) error {
+	system, err := boot.LoadClass(systemClass)
+	if err != nil {
+		return err
+	}
+
+	if system.DeclaredMethod("initPhase1", "()V") == nil {
+		return nil
+	}
+
+	boot.properties = properties
+
+	classes := make(map[string]*Class)
+	for _, name := range bootClasses {
+		class, err := boot.LoadClass(name)
+		if err != nil {
+			return err
+		}
+
+		if err := class.Initialize(t); err != nil {
+			return err
+		}
+
+		classes[name] = class
+	}
+
+	if err := t.createMainThread(classes[threadGroupClass], classes[threadClass]); err != nil {
+		return err
+	}
+
+	for _, name := range []string{moduleClass, unsafeConstantsClass, unsafeClass} {
+		class, err := boot.LoadClass(name)
+		if err != nil {
+			return err
+		}
+
+		if err := class.Initialize(t); err != nil {
+			return err
+		}
+	}
+
+	if _, err := t.callStatic(system, "initPhase1", "()V"); err != nil {
+		return err
+	}
+
+	// the exceptions the runtime raises are initialized upfront, so raising them does not run Java code
+	for _, name := range []string{OutOfMemoryError, NullPointerException, ClassCastException, ArrayStoreException, ArithmeticException} {
+		class, err := boot.LoadClass(name)
+		if err == nil {
+			err = class.Initialize(t)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	// the module system is initialized with neither printing nor tracing
+	ret, err := t.callStatic(system, "initPhase2", "(ZZ)I", Slot{Num: 1}, Slot{Num: 0})
+	if err != nil {
+		return err
+	}
+
+	if ret[0].Num != 0 {
+		return NewJavaError(InternalError, "the module system could not be initialized")
+	}
+
+	_, err = t.callStatic(system, "initPhase3", "()V")
+
+	return err
+}
+
+// createMainThread creates the system thread group, the main thread group inside it, and the java/lang/Thread
+// of the thread in the main group, with the constructors HotSpot uses.
+func (t *Thread) createMainThread(groupClass *Class, threadClass *Class) error {
+	systemGroup := NewObject(groupClass)
+	if _, err := t.callMethod(systemGroup, "<init>", "()V"); err != nil {
+		return err
+	}
+
+	name, err := NewString(groupClass.Loader, MainThreadName)
+	if err != nil {
+		return err
+	}
+
+	mainGroup := NewObject(groupClass)
+	if _, err := t.callMethod(mainGroup, "<init>", "(Ljava/lang/ThreadGroup;Ljava/lang/String;)V", Slot{Ref: systemGroup}, Slot{Ref: name}); err != nil {
+		return err
+	}
+
+	// the thread is alive, and the current thread, before its constructor runs
+	t.setObject(NewObject(threadClass))
+	setField(t.object, "eetop", "J", LongSlots(eetops.Add(1))...)
+
+	if _, err := t.callMethod(t.object, "<init>", "(Ljava/lang/ThreadGroup;Ljava/lang/String;)V", Slot{Ref: mainGroup}, Slot{Ref: name}); err != nil {
+		return err
+	}
+
+	setThreadStatus(t.object, threadStatusRunnable)
+
+	return nil
+}
+
+// callStatic invokes the static method of the class with the given name and descriptor.
+func (t *Thread) callStatic(class *Class, name string, descriptor string, args ...Slot) ([]Slot, error) {
+	method := class.DeclaredMethod(name, descriptor)
+	if method == nil || !method.IsStatic() {
+		return nil, NewJavaError(NoSuchMethodError, "'%s'", MethodSignature(class.Name, name, descriptor))
+	}
+
+	return t.Invoke(method, args)
+}
+
+// unsafeConstantsClass is the internal name of the class whose constants describe the machine to Unsafe.
+const unsafeConstantsClass = "jdk/internal/misc/UnsafeConstants"
+
+// unsafeConstants are the values the VM injects into the static fields of UnsafeConstants once it is
+// initialized, for a 64-bit little-endian machine that allows unaligned accesses.
+var unsafeConstants = map[string]Slot{
+	"ADDRESS_SIZE0":              {Num: 8},
+	"PAGE_SIZE":                  {Num: int32(os.Getpagesize())},
+	"BIG_ENDIAN":                 {Num: 0},
+	"UNALIGNED_ACCESS":           {Num: 1},
+	"DATA_CACHE_LINE_FLUSH_SIZE": {Num: 0},
+}
+
+// injectUnsafeConstants sets the static fields of UnsafeConstants, whose static initializer only gives them
+// values the compiler can not fold.
+func injectUnsafeConstants(class *Class) {
+	for _, field := range class.Fields {
+		if value, ok := unsafeConstants[field.Name]; ok && field.IsStatic() {
+			class.StaticValues[field.Slot] = value
+		}
+	}
+}
+
+// vmProperties returns the properties the VM sets, the ones given on the command line included, as the
+// pairs of keys and values `SystemProps.Raw.vmProperties` returns.
+func vmProperties(boot *ClassLoader) map[string]string {
+	properties := map[string]string{
+		"java.vm.specification.name":    "Java Virtual Machine Specification",
+		"java.vm.specification.vendor":  "Oracle Corporation",
+		"java.vm.specification.version": "21",
+		"java.vm.name":                  "jbm",
+		"java.vm.vendor":                "jbm",
+		"java.vm.version":               "21",
+		"java.vm.info":                  "interpreted mode",
+		"jdk.debug":                     "release",
+		"sun.nio.MaxDirectMemorySize":   "-1",
+		"sun.java.launcher":             "SUN_STANDARD",
+	}
+
+	for key, value := range boot.properties {
+		properties[key] = value
+	}
+
+	return properties
+}
+
+// platformProperties are the values of the properties of the platform, by the name of the static field of
+// `SystemProps.Raw` with their index in the array `platformProperties` returns.
+func platformProperties() map[string]string {
+	tmp := os.TempDir()
+	dir, _ := os.Getwd()
+	home, _ := os.UserHomeDir()
+
+	name := os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+
+	arch := runtime.GOARCH
+	if arch == "arm64" {
+		arch = "aarch64"
+	}
+
+	return map[string]string{
+		"_file_separator_NDX":       string(filepath.Separator),
+		"_path_separator_NDX":       string(filepath.ListSeparator),
+		"_line_separator_NDX":       "\n",
+		"_java_io_tmpdir_NDX":       tmp,
+		"_os_name_NDX":              osName(),
+		"_os_arch_NDX":              arch,
+		"_os_version_NDX":           "",
+		"_user_dir_NDX":             dir,
+		"_user_home_NDX":            home,
+		"_user_name_NDX":            name,
+		"_sun_jnu_encoding_NDX":     "UTF-8",
+		"_native_encoding_NDX":      "UTF-8",
+		"_stdout_encoding_NDX":      "UTF-8",
+		"_stderr_encoding_NDX":      "UTF-8",
+		"_sun_unicode_encoding_NDX": "UnicodeLittle",
+		"_sun_cpu_endian_NDX":       "little",
+		"_sun_arch_data_model_NDX":  "64",
+	}
+}
+
+// osName returns the value of the `os.name` property, the names the JDK gives to the operating systems.
+func osName() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "Mac OS X"
+	case "windows":
+		return "Windows"
+	}
+
+	return strings.ToUpper(runtime.GOOS[:1]) + runtime.GOOS[1:]
+}
+
+// mapLibraryName returns the file name of the native library with the given name, the way the operating
+// system names shared libraries.
+func mapLibraryName(name string) string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "lib" + name + ".dylib"
+	case "windows":
+		return name + ".dll"
+	}
+
+	return "lib" + name + ".so"
+}
+
+// newStringArray returns a String[] with the given strings, nil ones become null.
+func newStringArray(loader *ClassLoader, strs []*string) (*Object, error) {
+	class, err := loader.bootstrap().LoadClass("[Ljava/lang/String;")
+	if err != nil {
+		return nil, err
+	}
+
+	array := NewArray(class, len(strs))
+	elements := array.Elements.([]*Object)
+
+	for i, s := range strs {
+		if s == nil {
+			continue
+		}
+
+		if elements[i], err = NewString(loader, *s); err != nil {
+			return nil, err
+		}
+	}
+
+	return array, nil
+}
+
+// noNative is the native method of the JDK that has nothing to do in jbm.
+func noNative(call *NativeCall) ([]Slot, error) {
+	return nil, nil
+}
+
+// falseNative is the native method of the JDK that always returns false in jbm.
+func falseNative(call *NativeCall) ([]Slot, error) {
+	return boolSlots(false), nil
+}
+
+// bootNatives are the native methods of the JDK its boot needs, by class.
+var bootNatives = map[string]map[string]NativeMethod{
+	systemClass: {
+		"registerNatives()V":              noNative,
+		"setIn0(Ljava/io/InputStream;)V":  systemSetStream("in", "Ljava/io/InputStream;"),
+		"setOut0(Ljava/io/PrintStream;)V": systemSetStream("out", "Ljava/io/PrintStream;"),
+		"setErr0(Ljava/io/PrintStream;)V": systemSetStream("err", "Ljava/io/PrintStream;"),
+		"mapLibraryName(Ljava/lang/String;)Ljava/lang/String;": func(call *NativeCall) ([]Slot, error) {
+			if call.Ref(0) == nil {
+				return nil, &JavaError{ClassName: NullPointerException}
+			}
+
+			return call.returnString(utf16Chars(mapLibraryName(call.String(0))))
+		},
+	},
+	"jdk/internal/util/SystemProps$Raw": {
+		"vmProperties()[Ljava/lang/String;": func(call *NativeCall) ([]Slot, error) {
+			properties := vmProperties(call.Method.Class.Loader.bootstrap())
+
+			keys := make([]string, 0, len(properties))
+			for key := range properties {
+				keys = append(keys, key)
+			}
+
+			sort.Strings(keys)
+
+			pairs := make([]*string, 0, 2*len(keys))
+			for _, key := range keys {
+				key, value := key, properties[key]
+				pairs = append(pairs, &key, &value)
+			}
+
+			array, err := newStringArray(call.Method.Class.Loader, pairs)
+
+			return []Slot{{Ref: array}}, err
+		},
+		"platformProperties()[Ljava/lang/String;": func(call *NativeCall) ([]Slot, error) {
+			class := call.Method.Class
+
+			length := class.DeclaredField("FIXED_LENGTH", "I")
+			if length == nil {
+				return nil, NewJavaError(InternalError, "%s does not have FIXED_LENGTH", class.JavaName())
+			}
+
+			values := make([]*string, class.StaticValues[length.Slot].Num)
+			for name, value := range platformProperties() {
+				if field := class.DeclaredField(name, "I"); field != nil && field.IsStatic() {
+					value := value
+					values[class.StaticValues[field.Slot].Num] = &value
+				}
+			}
+
+			array, err := newStringArray(class.Loader, values)
+
+			return []Slot{{Ref: array}}, err
+		},
+	},
+	"java/lang/Runtime": {
+		"availableProcessors()I": func(call *NativeCall) ([]Slot, error) {
+			return []Slot{{Num: int32(runtime.NumCPU())}}, nil
+		},
+		"freeMemory()J":  runtimeMemory(func(stats *runtime.MemStats) uint64 { return stats.HeapIdle }),
+		"totalMemory()J": runtimeMemory(func(stats *runtime.MemStats) uint64 { return stats.HeapSys }),
+		"maxMemory()J":   runtimeMemory(func(stats *runtime.MemStats) uint64 { return stats.Sys }),
+		"gc()V": func(call *NativeCall) ([]Slot, error) {
+			runtime.GC()
+			return nil, nil
+		},
+	},
+	"jdk/internal/misc/VM": {
+		"initialize()V": noNative,
+		// there are no user-defined loaders with Java objects, see `forName0`
+		"latestUserDefinedLoader0()Ljava/lang/ClassLoader;": func(call *NativeCall) ([]Slot, error) {
+			return []Slot{{}}, nil
+		},
+		"getNanoTimeAdjustment(J)J": func(call *NativeCall) ([]Slot, error) {
+			return LongSlots(time.Now().UnixNano() - call.Long(0)*int64(time.Second)), nil
+		},
+		"getRuntimeArguments()[Ljava/lang/String;": func(call *NativeCall) ([]Slot, error) {
+			array, err := newStringArray(call.Method.Class.Loader, nil)
+			return []Slot{{Ref: array}}, err
+		},
+		"getuid()J":  vmID(os.Getuid),
+		"geteuid()J": vmID(os.Geteuid),
+		"getgid()J":  vmID(os.Getgid),
+		"getegid()J": vmID(os.Getegid),
+	},
+	"jdk/internal/misc/CDS": {
+		"isDumpingClassList0()Z": falseNative,
+		"isDumpingArchive0()Z":   falseNative,
+		"isSharingEnabled0()Z":   falseNative,
+		"getRandomSeedForDumping()J": func(call *NativeCall) ([]Slot, error) {
+			return LongSlots(0), nil
+		},
+		"initializeFromArchive(Ljava/lang/Class;)V":                              noNative,
+		"defineArchivedModules(Ljava/lang/ClassLoader;Ljava/lang/ClassLoader;)V": noNative,
+		"logLambdaFormInvoker(Ljava/lang/String;)V":                              noNative,
+		"dumpClassList(Ljava/lang/String;)V":                                     noNative,
+		"dumpDynamicArchive(Ljava/lang/String;)V":                                noNative,
+	},
+	"jdk/internal/misc/Signal": {
+		"findSignal0(Ljava/lang/String;)I": func(call *NativeCall) ([]Slot, error) {
+			if number, ok := signals[call.String(0)]; ok {
+				return []Slot{{Num: number}}, nil
+			}
+
+			return []Slot{{Num: -1}}, nil
+		},
+		// signals are not delivered to Java handlers, the previous handler is the default one
+		"handle0(IJ)J": func(call *NativeCall) ([]Slot, error) {
+			return LongSlots(0), nil
+		},
+		"raise0(I)V": noNative,
+	},
+	"jdk/internal/misc/ScopedMemoryAccess": {
+		"registerNatives()V": noNative,
+		// there are no threads accessing the memory of a closed scope to wait for
+		"closeScope0(Ljdk/internal/foreign/MemorySessionImpl;)Z": func(call *NativeCall) ([]Slot, error) {
+			return boolSlots(true), nil
+		},
+	},
+	"jdk/internal/reflect/Reflection": {
+		"getCallerClass()Ljava/lang/Class;": reflectionGetCallerClass,
+		"getClassAccessFlags(Ljava/lang/Class;)I": func(call *NativeCall) ([]Slot, error) {
+			return []Slot{{Num: int32(mirroredClass(call.Ref(0)).AccessFlags)}}, nil
+		},
+		"areNestMates(Ljava/lang/Class;Ljava/lang/Class;)Z": func(call *NativeCall) ([]Slot, error) {
+			return boolSlots(mirroredClass(call.Ref(0)) == mirroredClass(call.Ref(1))), nil
+		},
+	},
+	"jdk/internal/loader/BootLoader": {
+		"setBootLoaderUnnamedModule0(Ljava/lang/Module;)V": setBootLoaderUnnamedModule0,
+	},
+	stackTraceElementClass: {
+		"initStackTraceElements([Ljava/lang/StackTraceElement;Ljava/lang/Object;I)V": initStackTraceElements,
+	},
+	"java/lang/StringUTF16": {
+		"isBigEndian()Z": falseNative,
+	},
+	"java/io/FileDescriptor": {
+		"initIDs()V": noNative,
+		"getHandle(I)J": func(call *NativeCall) ([]Slot, error) {
+			return LongSlots(-1), nil
+		},
+		"getAppend(I)Z": falseNative,
+		"close0()V":     noNative,
+	},
+	"java/io/FileInputStream": {
+		"initIDs()V": noNative,
+	},
+	"java/io/FileOutputStream": {
+		"initIDs()V":         noNative,
+		"writeBytes([BIIZ)V": fileOutputStreamWriteBytes,
+	},
+}
+
+// signals are the numbers of the signals `Signal` can handle, by name.
+var signals = map[string]int32{
+	"HUP": 1, "INT": 2, "QUIT": 3, "ILL": 4, "TRAP": 5, "ABRT": 6, "BUS": 7, "FPE": 8, "KILL": 9, "USR1": 10,
+	"SEGV": 11, "USR2": 12, "PIPE": 13, "ALRM": 14, "TERM": 15, "CHLD": 17, "CONT": 18, "STOP": 19, "TSTP": 20,
+}
+
+// systemSetStream returns the native method that sets the static field of `System` with the given name, which
+// is final, so the JDK can not set it itself.
+func systemSetStream(name string, descriptor string) NativeMethod {
+	return func(call *NativeCall) ([]Slot, error) {
+		class := call.Method.Class
+		if field := class.DeclaredField(name, descriptor); field != nil {
+			class.StaticValues[field.Slot] = Slot{Ref: call.Ref(0)}
+		}
+
+		return nil, nil
+	}
+}
+
+// runtimeMemory returns the native method of `Runtime` that reports an amount of memory of the Go heap.
+func runtimeMemory(amount func(stats *runtime.MemStats) uint64) NativeMethod {
+	return func(call *NativeCall) ([]Slot, error) {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+
+		return LongSlots(int64(amount(&stats))), nil
+	}
+}
+
+// vmID returns the native method of `VM` that returns an id of the user or group of the process.
+func vmID(id func() int) NativeMethod {
+	return func(call *NativeCall) ([]Slot, error) {
+		return LongSlots(int64(id())), nil
+	}
+}
+
+// reflectionGetCallerClass returns the class of the method that invoked the method calling getCallerClass,
+// skipping the frames of reflective invocations. Native methods have no frames, so the innermost frame is
+// the one of the method calling getCallerClass.
+func reflectionGetCallerClass(call *NativeCall) ([]Slot, error) {
+	frames := call.Thread.frames
+	if len(frames) < 2 {
+		return []Slot{{}}, nil
+	}
+
+	for i := len(frames) - 2; i >= 0; i-- {
+		method := frames[i].Method
+		if method.Class.Name == "java/lang/reflect/Method" && method.Name == "invoke" ||
+			strings.HasPrefix(method.Class.Name, "jdk/internal/reflect/") {
+			continue
+		}
+
+		return mirrorSlots(method.Class)
+	}
+
+	return []Slot{{}}, nil
+}
+
+// fileOutputStreamWriteBytes writes the bytes of a FileOutputStream to the file of its descriptor, only
+// the standard output and error are supported.
+func fileOutputStreamWriteBytes(call *NativeCall) ([]Slot, error) {
+	b, off, length := call.Ref(0), int(call.Int(1)), int(call.Int(2))
+	if b == nil {
+		return nil, &JavaError{ClassName: NullPointerException}
+	}
+
+	elements := b.Elements.([]int8)
+	if off < 0 || length < 0 || off+length > len(elements) {
+		return nil, &JavaError{ClassName: IndexOutOfBoundsException}
+	}
+
+	fd := getField(call.This(), "fd", "Ljava/io/FileDescriptor;").Ref
+	if fd == nil {
+		return nil, NewJavaError(IOException, "Stream Closed")
+	}
+
+	buf := make([]byte, length)
+	for i := range buf {
+		buf[i] = byte(elements[off+i])
+	}
+
+	switch getField(fd, "fd", "I").Num {
+	case 1:
+		_, err := SystemOut.Write(buf)
+		return nil, ioError(err)
+	case 2:
+		_, err := SystemErr.Write(buf)
+		return nil, ioError(err)
+	}
+
+	return nil, NewJavaError(IOException, "Bad file descriptor")
+}
+
+// ioError returns the IOException of an error of a Go writer.
+func ioError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return NewJavaError(IOException, "%s", err.Error())
+}
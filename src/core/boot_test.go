@@ -0,0 +1,206 @@
+package core_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// writeBootClasses writes the classes the JDK boots with, reduced to what the boot uses: System records the
+// phases run, the current thread and the properties of the VM.
+func writeBootClasses(t *testing.T, dir string) {
+	b := newTestClassBuilder()
+	phase := b.fieldref("java/lang/System", "phase", "I")
+	thread := b.fieldref("java/lang/System", "thread", "Ljava/lang/Thread;")
+	props := b.fieldref("java/lang/System", "props", "[Ljava/lang/String;")
+	currentThread := b.methodref("java/lang/Thread", "currentThread", "()Ljava/lang/Thread;")
+	vmProperties := b.methodref("jdk/internal/util/SystemProps$Raw", "vmProperties", "()[Ljava/lang/String;")
+
+	// phase = phase * 10 + n
+	nextPhase := func(n byte) []byte {
+		return concat([]byte{core.OP_GETSTATIC}, u2(phase), []byte{core.OP_BIPUSH, 10, core.OP_IMUL, core.OP_BIPUSH, n, core.OP_IADD, core.OP_PUTSTATIC}, u2(phase))
+	}
+
+	static := core.ACC_PUBLIC | core.ACC_STATIC
+	writeClassFile(t, dir, "java/lang/System", b.build(core.ACC_PUBLIC, "java/lang/System", "java/lang/Object", nil,
+		[][]byte{b.member(static, "phase", "I"), b.member(static, "thread", "Ljava/lang/Thread;"), b.member(static, "props", "[Ljava/lang/String;")},
+		[][]byte{
+			b.member(static, "initPhase1", "()V", b.code(3, 0, concat(
+				[]byte{core.OP_INVOKESTATIC}, u2(currentThread), []byte{core.OP_PUTSTATIC}, u2(thread),
+				[]byte{core.OP_INVOKESTATIC}, u2(vmProperties), []byte{core.OP_PUTSTATIC}, u2(props),
+				nextPhase(1), []byte{core.OP_RETURN},
+			)...)),
+			b.member(static, "initPhase2", "(ZZ)I", b.code(3, 2, concat(nextPhase(2), []byte{core.OP_ICONST_0, core.OP_IRETURN})...)),
+			b.member(static, "initPhase3", "()V", b.code(3, 0, concat(nextPhase(3), []byte{core.OP_RETURN})...)),
+			b.member(static|core.ACC_NATIVE, "mapLibraryName", "(Ljava/lang/String;)Ljava/lang/String;"),
+		},
+		nil,
+	))
+
+	b = newTestClassBuilder()
+	name := b.fieldref("java/lang/Thread", "name", "Ljava/lang/String;")
+	writeClassFile(t, dir, "java/lang/Thread", b.build(core.ACC_PUBLIC, "java/lang/Thread", "java/lang/Object", nil,
+		[][]byte{b.member(0, "eetop", "J"), b.member(0, "name", "Ljava/lang/String;")},
+		[][]byte{
+			b.member(core.ACC_PUBLIC, "<init>", "(Ljava/lang/ThreadGroup;Ljava/lang/String;)V", b.code(2, 3, concat(
+				[]byte{core.OP_ALOAD_0, core.OP_ALOAD_2, core.OP_PUTFIELD}, u2(name), []byte{core.OP_RETURN},
+			)...)),
+			b.member(static|core.ACC_NATIVE, "currentThread", "()Ljava/lang/Thread;"),
+		},
+		nil,
+	))
+
+	b = newTestClassBuilder()
+	writeClassFile(t, dir, "java/lang/ThreadGroup", b.build(core.ACC_PUBLIC, "java/lang/ThreadGroup", "java/lang/Object", nil, nil,
+		[][]byte{
+			b.member(0, "<init>", "()V", b.code(0, 1, core.OP_RETURN)),
+			b.member(core.ACC_PUBLIC, "<init>", "(Ljava/lang/ThreadGroup;Ljava/lang/String;)V", b.code(0, 3, core.OP_RETURN)),
+		},
+		nil,
+	))
+
+	b = newTestClassBuilder()
+	addressSize := b.fieldref("jdk/internal/misc/UnsafeConstants", "ADDRESS_SIZE0", "I")
+	writeClassFile(t, dir, "jdk/internal/misc/UnsafeConstants", b.build(core.ACC_FINAL, "jdk/internal/misc/UnsafeConstants", "java/lang/Object", nil,
+		[][]byte{b.member(core.ACC_STATIC|core.ACC_FINAL, "ADDRESS_SIZE0", "I")},
+		[][]byte{
+			b.member(core.ACC_STATIC, "<clinit>", "()V", b.code(1, 0, concat([]byte{core.OP_ICONST_0, core.OP_PUTSTATIC}, u2(addressSize), []byte{core.OP_RETURN})...)),
+		},
+		nil,
+	))
+
+	b = newTestClassBuilder()
+	writeClassFile(t, dir, "jdk/internal/util/SystemProps$Raw", b.build(core.ACC_PUBLIC, "jdk/internal/util/SystemProps$Raw", "java/lang/Object", nil, nil,
+		[][]byte{b.member(static|core.ACC_NATIVE, "vmProperties", "()[Ljava/lang/String;")},
+		nil,
+	))
+
+	for _, name := range []string{"java/lang/Module", "jdk/internal/misc/Unsafe"} {
+		writeClassFile(t, dir, name, minimalClassFile(name))
+	}
+}
+
+func TestShouldBootTheClassLibraryBeforeRunningTheMainClass(t *testing.T) {
+	bootDir, appDir := t.TempDir(), t.TempDir()
+	writeBootClasses(t, bootDir)
+
+	b := newTestClassBuilder()
+	writeClassFile(t, appDir, "Main", b.build(core.ACC_PUBLIC, "Main", "java/lang/Object", nil, nil,
+		[][]byte{b.member(core.ACC_PUBLIC|core.ACC_STATIC, "main", "([Ljava/lang/String;)V", b.code(0, 1, core.OP_RETURN))},
+		nil,
+	))
+
+	app, err := core.ParseClassPath(appDir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	loaders := core.NewClassLoaders(core.NewClassPath(&core.DirClassPathEntry{Dir: bootDir}), nil, app)
+	t.Cleanup(func() { loaders.Close() })
+
+	if err := core.ExecuteMainClass(loaders.Application, "Main"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	system, err := loaders.Bootstrap.LoadClass("java/lang/System")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if phase := system.StaticValues[system.DeclaredField("phase", "I").Slot].Num; phase != 123 {
+		t.Errorf("Expected the three phases to run in order, got %d", phase)
+	}
+
+	thread := system.StaticValues[system.DeclaredField("thread", "Ljava/lang/Thread;").Slot].Ref
+	if thread == nil || core.GoString(thread.Fields[2].Ref) != "main" || core.SlotsLong(thread.Fields[0:2]) == 0 {
+		t.Errorf("Expected the current thread to be the alive main thread, got %v", thread)
+	}
+
+	props := map[string]string{}
+	if array := system.StaticValues[system.DeclaredField("props", "[Ljava/lang/String;").Slot].Ref; array != nil {
+		elements := array.Elements.([]*core.Object)
+		for i := 0; i+1 < len(elements); i += 2 {
+			props[core.GoString(elements[i])] = core.GoString(elements[i+1])
+		}
+	}
+
+	if props["java.vm.name"] != "jbm" {
+		t.Errorf("Expected the properties of the VM, got %v", props)
+	}
+
+	constants, err := loaders.Bootstrap.LoadClass("jdk/internal/misc/UnsafeConstants")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if size := constants.StaticValues[constants.DeclaredField("ADDRESS_SIZE0", "I").Slot].Num; size != 8 {
+		t.Errorf("Expected the address size to be injected, got %d", size)
+	}
+}
+
+func TestShouldMapLibraryNamesLikeTheOperatingSystem(t *testing.T) {
+	bootDir := t.TempDir()
+	writeBootClasses(t, bootDir)
+
+	loaders := core.NewClassLoaders(core.NewClassPath(&core.DirClassPathEntry{Dir: bootDir}), nil, nil)
+	t.Cleanup(func() { loaders.Close() })
+
+	system, err := loaders.Bootstrap.LoadClass("java/lang/System")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := map[string]string{"darwin": "libnet.dylib", "windows": "net.dll"}[runtime.GOOS]
+	if expected == "" {
+		expected = "libnet.so"
+	}
+
+	ret, err := invokeSlots(t, system, "mapLibraryName", "(Ljava/lang/String;)Ljava/lang/String;", []core.Slot{javaString(t, loaders, "net")})
+	if err != nil || core.GoString(ret[0].Ref) != expected {
+		t.Errorf("Expected %q, got %v (%v)", expected, ret, err)
+	}
+}
+
+// TestShouldRunHelloWorldOnTheClassLibraryOfTheJDK boots the class library of the JDK in JAVA_HOME, it is skipped
+// when it is not set.
+func TestShouldRunHelloWorldOnTheClassLibraryOfTheJDK(t *testing.T) {
+	javaHome := os.Getenv("JAVA_HOME")
+	if javaHome == "" {
+		t.Skip("JAVA_HOME is not set")
+	}
+
+	if _, err := os.Stat(filepath.Join(javaHome, "lib", "modules")); err != nil {
+		t.Skipf("JAVA_HOME does not have a lib/modules image: %v", err)
+	}
+
+	dir := t.TempDir()
+
+	b := newTestClassBuilder()
+	out := b.fieldref("java/lang/System", "out", "Ljava/io/PrintStream;")
+	hello := b.str("Hello, World!")
+	println := b.methodref("java/io/PrintStream", "println", "(Ljava/lang/String;)V")
+
+	writeClassFile(t, dir, "Main", b.build(core.ACC_PUBLIC, "Main", "java/lang/Object", nil, nil,
+		[][]byte{b.member(core.ACC_PUBLIC|core.ACC_STATIC, "main", "([Ljava/lang/String;)V", b.code(2, 1, concat(
+			[]byte{core.OP_GETSTATIC}, u2(out), []byte{core.OP_LDC, byte(hello), core.OP_INVOKEVIRTUAL}, u2(println), []byte{core.OP_RETURN},
+		)...))},
+		nil,
+	))
+
+	var stdout bytes.Buffer
+	previous := core.SystemOut
+	core.SystemOut = &stdout
+	t.Cleanup(func() { core.SystemOut = previous })
+
+	if err := core.RunJBM([]string{"--java-home", javaHome, "-cp", dir, "Main"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if stdout.String() != "Hello, World!\n" {
+		t.Errorf("Expected the program to print Hello, World!, got %q", stdout.String())
+	}
+}
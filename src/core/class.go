@@ -0,0 +1,394 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Class is a class or interface loaded by a class loader.
+//
+// Two classes with the same name are different classes when they are defined by different loaders.
+type Class struct {
+	// Name is the internal name of the class (e.g. java/lang/Object).
+	Name string
+	// File is the parsed class file that defines the class, it is nil for array classes.
+	File *ClassFile
+	// Loader is the defining loader of the class.
+	Loader *ClassLoader
+	// Super is the direct superclass, it is nil for java/lang/Object and module-info.
+	Super *Class
+	// Interfaces are the direct superinterfaces, in the order they are declared.
+	Interfaces []*Class
+	// AccessFlags are the access flags of the class file.
+	AccessFlags uint16
+	// Fields are the fields declared by the class, in the order they appear in the class file.
+	Fields []*Field
+	// Methods are the methods declared by the class, in the order they appear in the class file.
+	Methods []*Method
+	// StaticValues hold the values of the static fields, indexed by `Field.Slot`.
+	StaticValues []Slot
+	// InstanceSlots is the number of slots of an instance, including the fields declared by the superclasses.
+	InstanceSlots int
+	// ConstantPool resolves the symbolic references of the class file.
+	ConstantPool *RuntimeConstantPool
+	// ComponentType is the class of the components of an array class, it is nil for arrays of primitive
+	// types and for classes that are not arrays.
+	ComponentType *Class
+
+	init classInit
+	// mirror is the java/lang/Class object of the class, nil until it is first asked for, see `Mirror`.
+	mirror   *Object
+	mirrorMu sync.Mutex
+	// primitive tells if the class is the one of a primitive type, see `primitiveClass`.
+	primitive bool
+	// vtable and itables select the methods of invokevirtual and invokeinterface, see `buildMethodTables`.
+	vtable  []*Method
+	itables map[*Class][]*Method
+}
+
+// IsInterface tells if the class is an interface.
+func (c *Class) IsInterface() bool {
+	return c.AccessFlags&ACC_INTERFACE != 0
+}
+
+// prepare creates the fields and methods of the class, laying out its static and instance fields, and
+// its runtime constant pool. The static fields are set to their default values, or to their ConstantValue.
+func (c *Class) prepare() error {
+	fields, methods, err := newMembers(c)
+	if err != nil {
+		return NewJavaError(ClassFormatError, "%s in class file %s", err, c.Name)
+	}
+
+	staticSlots, instanceSlots := 0, 0
+	if c.Super != nil {
+		instanceSlots = c.Super.InstanceSlots
+	}
+
+	for _, field := range fields {
+		if field.IsStatic() {
+			field.Slot = staticSlots
+			staticSlots += DescriptorSlots(field.Descriptor)
+		} else {
+			field.Slot = instanceSlots
+			instanceSlots += DescriptorSlots(field.Descriptor)
+		}
+	}
+
+	c.Fields, c.Methods = fields, methods
+	c.StaticValues = make([]Slot, staticSlots)
+	c.InstanceSlots = instanceSlots
+	c.ConstantPool = newRuntimeConstantPool(c)
+	c.init.cond = sync.NewCond(&c.init.mu)
+	c.buildMethodTables()
+
+	return c.setConstantValues()
+}
+
+// DeclaredField returns the field declared by the class with the given name and descriptor, or nil.
+func (c *Class) DeclaredField(name string, descriptor string) *Field {
+	for _, field := range c.Fields {
+		if field.Name == name && field.Descriptor == descriptor {
+			return field
+		}
+	}
+
+	return nil
+}
+
+// DeclaredMethod returns the method declared by the class with the given name and descriptor, or nil.
+func (c *Class) DeclaredMethod(name string, descriptor string) *Method {
+	for _, method := range c.Methods {
+		if method.Name == name && method.Descriptor == descriptor {
+			return method
+		}
+	}
+
+	return nil
+}
+
+// PackageName returns the internal name of the package of the class, empty for the unnamed package.
+func (c *Class) PackageName() string {
+	if i := strings.LastIndexByte(c.Name, '/'); i >= 0 {
+		return c.Name[:i]
+	}
+
+	return ""
+}
+
+// SamePackage tells if both classes are in the same run-time package, which is the same package defined
+// by the same loader.
+func (c *Class) SamePackage(other *Class) bool {
+	return c.Loader == other.Loader && c.PackageName() == other.PackageName()
+}
+
+// NestHost returns the internal name of the host of the nest the class belongs to, which is the class
+// itself unless it has a NestHost attribute.
+func (c *Class) NestHost() string {
+	if c.File == nil {
+		return c.Name
+	}
+
+	attr, ok := c.File.FindAttribute(c.File.Attributes, "NestHost")
+	if !ok || len(attr.Info) < 2 {
+		return c.Name
+	}
+
+	host, err := c.File.ClassName(uint16(attr.Info[0])<<8 | uint16(attr.Info[1]))
+	if err != nil {
+		return c.Name
+	}
+
+	return host
+}
+
+// SourceFile returns the name of the source file the class was compiled from, from its SourceFile attribute,
+// or an empty string when it has none.
+func (c *Class) SourceFile() string {
+	if c.File == nil {
+		return ""
+	}
+
+	attr, ok := c.File.FindAttribute(c.File.Attributes, "SourceFile")
+	if !ok || len(attr.Info) != 2 {
+		return ""
+	}
+
+	name, err := c.File.Utf8(uint16(attr.Info[0])<<8 | uint16(attr.Info[1]))
+	if err != nil {
+		return ""
+	}
+
+	return name
+}
+
+// CanAccess tells if the class can access the given class, which must be public or in the same package.
+// An array class is accessible when its element type is.
+func (c *Class) CanAccess(other *Class) bool {
+	if other.IsArray() {
+		element := other.ElementType()
+		return element == nil || c.CanAccess(element)
+	}
+
+	return other.AccessFlags&ACC_PUBLIC != 0 || c.SamePackage(other)
+}
+
+// CanAccessMember tells if the class can access a member with the given access flags declared by the
+// given class (JVMS 5.4.4), private members are accessible by the classes of the same nest.
+func (c *Class) CanAccessMember(declaring *Class, flags uint16) bool {
+	switch {
+	case flags&ACC_PUBLIC != 0:
+		return true
+	case flags&ACC_PRIVATE != 0:
+		return c == declaring || (c.Loader == declaring.Loader && c.NestHost() == declaring.NestHost())
+	case flags&ACC_PROTECTED != 0:
+		return c.SamePackage(declaring) || c.IsSubclassOf(declaring)
+	}
+
+	return c.SamePackage(declaring)
+}
+
+// IsSubclassOf tells if the class is the given class or extends or implements it, directly or not.
+//
+// An array class is a subclass of the array classes whose components are of a superclass of its component
+// type, when they are references, and of java/lang/Object, java/lang/Cloneable and java/io/Serializable.
+func (c *Class) IsSubclassOf(other *Class) bool {
+	if c == other {
+		return true
+	}
+
+	if c.IsArray() && other.IsArray() {
+		return c.ComponentType != nil && other.ComponentType != nil && c.ComponentType.IsSubclassOf(other.ComponentType)
+	}
+
+	if c.Super != nil && c.Super.IsSubclassOf(other) {
+		return true
+	}
+
+	for _, iface := range c.Interfaces {
+		if iface.IsSubclassOf(other) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// JavaName returns the binary name of the class, as returned by `Class.getName` (e.g. java.lang.Object).
+func (c *Class) JavaName() string {
+	return javaName(c.Name)
+}
+
+// String returns the binary name of the class followed by its defining loader.
+func (c *Class) String() string {
+	return c.JavaName() + " (" + c.Loader.String() + ")"
+}
+
+// ModuleName returns the name of the module of the class, empty for the unnamed module.
+//
+// Until classes keep the module they are defined in, the classes of the bootstrap loader are considered to
+// be in java.base and every other class in the unnamed module of its loader.
+func (c *Class) ModuleName() string {
+	if c.Loader.IsBootstrap() {
+		return "java.base"
+	}
+
+	return ""
+}
+
+// moduleDescription describes where the class is in error messages, e.g. `unnamed module of loader 'app'`.
+func (c *Class) moduleDescription() string {
+	module := "unnamed module"
+	if name := c.ModuleName(); name != "" {
+		module = "module " + name
+	}
+
+	return module + " of loader " + c.Loader.String()
+}
+
+// classCastMessage is the message of the ClassCastException thrown when casting an instance of a class to
+// another one, the same as the reference implementation.
+func classCastMessage(from *Class, to *Class) string {
+	fromModule, toModule := from.moduleDescription(), to.moduleDescription()
+
+	where := fmt.Sprintf("%s is in %s; %s is in %s", from.JavaName(), fromModule, to.JavaName(), toModule)
+	if fromModule == toModule {
+		where = fmt.Sprintf("%s and %s are in %s", from.JavaName(), to.JavaName(), fromModule)
+	}
+
+	return fmt.Sprintf("class %s cannot be cast to class %s (%s)", from.JavaName(), to.JavaName(), where)
+}
+
+// javaName converts an internal name to a binary name (e.g. java/lang/Object to java.lang.Object).
+func javaName(name string) string {
+	return strings.ReplaceAll(name, "/", ".")
+}
+
+// accessName returns how the reference implementation names the access of a member in error messages.
+func accessName(flags uint16) string {
+	switch {
+	case flags&ACC_PRIVATE != 0:
+		return "private "
+	case flags&ACC_PROTECTED != 0:
+		return "protected "
+	}
+
+	return ""
+}
+
+// lookupField looks up a field in the class, then in its superinterfaces and then in its superclass (JVMS 5.4.3.2).
+func (c *Class) lookupField(name string, descriptor string) *Field {
+	if field := c.DeclaredField(name, descriptor); field != nil {
+		return field
+	}
+
+	for _, iface := range c.Interfaces {
+		if field := iface.lookupField(name, descriptor); field != nil {
+			return field
+		}
+	}
+
+	if c.Super != nil {
+		return c.Super.lookupField(name, descriptor)
+	}
+
+	return nil
+}
+
+// lookupMethod looks up a method in the class and its superclasses, and then the maximally-specific
+// superinterface methods (JVMS 5.4.3.3).
+func (c *Class) lookupMethod(name string, descriptor string) *Method {
+	for class := c; class != nil; class = class.Super {
+		if method := class.DeclaredMethod(name, descriptor); method != nil {
+			return method
+		}
+	}
+
+	return selectMaximallySpecific(c.maximallySpecificMethods(name, descriptor))
+}
+
+// lookupInterfaceMethod looks up a method in the interface, then the public instance methods of
+// java/lang/Object, the superclass of every interface, and then the maximally-specific superinterface
+// methods (JVMS 5.4.3.4).
+func (c *Class) lookupInterfaceMethod(name string, descriptor string) *Method {
+	if method := c.DeclaredMethod(name, descriptor); method != nil {
+		return method
+	}
+
+	if c.Super != nil {
+		method := c.Super.DeclaredMethod(name, descriptor)
+		if method != nil && method.AccessFlags&ACC_PUBLIC != 0 && !method.IsStatic() {
+			return method
+		}
+	}
+
+	return selectMaximallySpecific(c.maximallySpecificMethods(name, descriptor))
+}
+
+// maximallySpecificMethods returns the instance methods with the given name and descriptor declared by the
+// superinterfaces of the class that are not overridden by another one of them.
+func (c *Class) maximallySpecificMethods(name string, descriptor string) []*Method {
+	candidates := []*Method{}
+	seen := make(map[*Class]bool)
+
+	var visit func(class *Class)
+	visit = func(class *Class) {
+		for _, iface := range class.Interfaces {
+			if seen[iface] {
+				continue
+			}
+
+			seen[iface] = true
+			if method := iface.DeclaredMethod(name, descriptor); method != nil && !method.IsPrivate() && !method.IsStatic() {
+				candidates = append(candidates, method)
+			}
+
+			visit(iface)
+		}
+	}
+
+	for class := c; class != nil; class = class.Super {
+		visit(class)
+	}
+
+	specific := []*Method{}
+	for _, method := range candidates {
+		overridden := false
+		for _, other := range candidates {
+			if other.Class != method.Class && other.Class.IsSubclassOf(method.Class) {
+				overridden = true
+				break
+			}
+		}
+
+		if !overridden {
+			specific = append(specific, method)
+		}
+	}
+
+	return specific
+}
+
+// selectMaximallySpecific picks the only non-abstract method when there is exactly one, otherwise any of
+// the methods, or nil if there is none.
+func selectMaximallySpecific(methods []*Method) *Method {
+	var concrete *Method
+	count := 0
+
+	for _, method := range methods {
+		if !method.IsAbstract() {
+			concrete = method
+			count++
+		}
+	}
+
+	if count == 1 {
+		return concrete
+	}
+
+	if len(methods) > 0 {
+		return methods[0]
+	}
+
+	return nil
+}
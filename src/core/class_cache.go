@@ -0,0 +1,286 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ClassCache keeps the classes loaded and the class files parsed by a hierarchy of class loaders, keyed by
+// loader and name. It is safe to use from multiple goroutines.
+//
+// Concurrent requests for the same class are deduplicated: the first one loads the class while the others
+// wait for it, and the class files of superclasses are parsed ahead of time on their own goroutines.
+type ClassCache struct {
+	mu     sync.Mutex
+	loads  map[classKey]*loadEntry
+	parsed map[classKey]*parseEntry
+
+	// prefetching tracks the goroutines parsing class files ahead of time, so the class paths are not closed
+	// while they read them, see `ClassLoader.Close`.
+	prefetching sync.WaitGroup
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	parses    atomic.Uint64
+	parseTime atomic.Int64
+}
+
+// ClassCacheStats are the counters of a class cache.
+type ClassCacheStats struct {
+	// Hits is the number of requests for a class that was already loaded or being loaded by another request.
+	Hits uint64
+	// Misses is the number of requests that had to load the class.
+	Misses uint64
+	// Parsed is the number of class files parsed.
+	Parsed uint64
+	// ParseTime is the time spent reading and parsing class files, summed over all goroutines.
+	ParseTime time.Duration
+}
+
+func (s ClassCacheStats) String() string {
+	return fmt.Sprintf("hits: %d, misses: %d, parsed: %d, parse time: %s", s.Hits, s.Misses, s.Parsed, s.ParseTime)
+}
+
+type classKey struct {
+	loader *ClassLoader
+	name   string
+}
+
+// loadEntry is a class being loaded or already loaded, done is closed when the load finishes.
+type loadEntry struct {
+	done  chan struct{}
+	owner *loadState
+	class *Class
+	err   error
+}
+
+// parseEntry is a class file being parsed or already parsed, done is closed when the parse finishes.
+type parseEntry struct {
+	done chan struct{}
+	cf   *ClassFile
+	err  error
+}
+
+// loadState is the state of a request for a class, it is shared by all the classes loaded to fulfill it
+// (the class itself, its superclasses and superinterfaces).
+type loadState struct {
+	// waitingOn is the entry the request is waiting for, used to detect circular hierarchies.
+	waitingOn *loadEntry
+}
+
+// NewClassCache creates an empty cache.
+func NewClassCache() *ClassCache {
+	return &ClassCache{loads: make(map[classKey]*loadEntry), parsed: make(map[classKey]*parseEntry)}
+}
+
+// Stats returns the counters of the cache.
+func (c *ClassCache) Stats() ClassCacheStats {
+	return ClassCacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Parsed:    c.parses.Load(),
+		ParseTime: time.Duration(c.parseTime.Load()),
+	}
+}
+
+// loaded returns the class if it has already been loaded successfully.
+func (c *ClassCache) loaded(key classKey) *Class {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.loads[key]
+	if !ok {
+		return nil
+	}
+
+	select {
+	case <-entry.done:
+		return entry.class
+	default:
+		return nil
+	}
+}
+
+// begin returns the entry of the class, when owned is true the entry was just created and the caller must
+// load the class and `complete` it, otherwise the entry is already completed.
+//
+// It fails with a ClassCircularityError when waiting for the entry would wait for the request itself.
+func (c *ClassCache) begin(key classKey, state *loadState) (*loadEntry, bool, error) {
+	c.mu.Lock()
+
+	entry, ok := c.loads[key]
+	if !ok {
+		entry = &loadEntry{done: make(chan struct{}), owner: state}
+		c.loads[key] = entry
+		c.mu.Unlock()
+		c.misses.Add(1)
+
+		return entry, true, nil
+	}
+
+	c.hits.Add(1)
+
+	select {
+	case <-entry.done:
+		c.mu.Unlock()
+		return entry, false, nil
+	default:
+	}
+
+	// follow the entries each request is waiting for, reaching this request means it would wait for itself
+	for waiting := entry; waiting != nil; waiting = waiting.owner.waitingOn {
+		if waiting.owner == state {
+			c.mu.Unlock()
+			return nil, false, NewJavaError(ClassCircularityError, "%s", key.name)
+		}
+	}
+
+	state.waitingOn = entry
+	c.mu.Unlock()
+
+	<-entry.done
+
+	c.mu.Lock()
+	state.waitingOn = nil
+	c.mu.Unlock()
+
+	return entry, false, nil
+}
+
+// reserve creates the entry of a class that is about to be defined, it fails with a LinkageError if the
+// loader already has such class.
+func (c *ClassCache) reserve(key classKey, state *loadState) (*loadEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.loads[key]; ok {
+		return nil, NewJavaError(
+			LinkageError, "loader %s attempted duplicate class definition for %s.", key.loader, javaName(key.name),
+		)
+	}
+
+	entry := &loadEntry{done: make(chan struct{}), owner: state}
+	c.loads[key] = entry
+
+	return entry, nil
+}
+
+// complete finishes the load of a class, waking up the requests waiting for it. Failed loads are removed
+// so they can be retried.
+//
+// The class files of the class prefetched in the loader and its parents are evicted, when one of the loaders
+// defined the class the others are not needed anymore.
+func (c *ClassCache) complete(key classKey, entry *loadEntry, class *Class, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.class, entry.err = class, err
+	if err != nil && c.loads[key] == entry {
+		delete(c.loads, key)
+	}
+
+	for loader := key.loader; loader != nil; loader = loader.Parent {
+		delete(c.parsed, classKey{loader, key.name})
+	}
+
+	close(entry.done)
+}
+
+// parse reads and parses the class file of the class from the class path of the loader, the same class
+// file is parsed only once even if requested concurrently.
+func (c *ClassCache) parse(key classKey) (*ClassFile, error) {
+	c.mu.Lock()
+
+	entry, ok := c.parsed[key]
+	if !ok {
+		return c.parseLocked(key)
+	}
+
+	c.mu.Unlock()
+	<-entry.done
+
+	return entry.cf, entry.err
+}
+
+// prefetch parses the class file of a class that is not being loaded yet by the given initiating loader, so it
+// is ready when it is. It returns false when the class path of the loader does not have the class.
+func (c *ClassCache) prefetch(key classKey, initiating *ClassLoader) bool {
+	c.mu.Lock()
+
+	if _, ok := c.loads[classKey{initiating, key.name}]; ok {
+		c.mu.Unlock()
+		return true
+	}
+
+	if _, ok := c.loads[key]; ok {
+		c.mu.Unlock()
+		return true
+	}
+
+	entry, ok := c.parsed[key]
+	if !ok {
+		_, err := c.parseLocked(key)
+		return !errors.Is(err, ErrClassNotFound)
+	}
+
+	c.mu.Unlock()
+	<-entry.done
+
+	return !errors.Is(entry.err, ErrClassNotFound)
+}
+
+// parseLocked creates the entry of the class file and parses it, it is called with the lock held and
+// releases it.
+func (c *ClassCache) parseLocked(key classKey) (*ClassFile, error) {
+	entry := &parseEntry{done: make(chan struct{})}
+	c.parsed[key] = entry
+	c.mu.Unlock()
+
+	entry.cf, entry.err = c.read(key)
+	close(entry.done)
+
+	return entry.cf, entry.err
+}
+
+// take returns the parsed class file like `parse`, removing it from the cache since it is about to be defined.
+func (c *ClassCache) take(key classKey) (*ClassFile, error) {
+	cf, err := c.parse(key)
+
+	c.mu.Lock()
+	delete(c.parsed, key)
+	c.mu.Unlock()
+
+	return cf, err
+}
+
+func (c *ClassCache) read(key classKey) (*ClassFile, error) {
+	if key.loader.ClassPath == nil {
+		return nil, fmt.Errorf("%w: %s", ErrClassNotFound, key.name)
+	}
+
+	start := time.Now()
+	defer func() { c.parseTime.Add(int64(time.Since(start))) }()
+
+	content, _, err := key.loader.ClassPath.ReadClass(key.name)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.parseBytes(key.name, content)
+}
+
+// parseBytes parses the bytes of a class file, counting it in the stats.
+func (c *ClassCache) parseBytes(name string, content []byte) (*ClassFile, error) {
+	c.parses.Add(1)
+
+	cf, err := ClassFileFromReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, NewJavaError(ClassFormatError, "%s: %w", name, err)
+	}
+
+	return &cf, nil
+}
@@ -0,0 +1,143 @@
+package core_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+func TestShouldDeduplicateConcurrentLoadsOfTheSameClass(t *testing.T) {
+	dir := t.TempDir()
+	writeClassFile(t, dir, "com/acme/Shared", minimalClassFile("com/acme/Shared"))
+
+	loaders := newTestLoaders(t, dir)
+
+	classes := make([]*core.Class, 32)
+	errs := make([]error, len(classes))
+
+	var wg sync.WaitGroup
+	for i := range classes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			classes[i], errs[i] = loaders.Application.LoadClass("com/acme/Shared")
+		}(i)
+	}
+
+	wg.Wait()
+
+	for i := range classes {
+		if errs[i] != nil || classes[i] != classes[0] {
+			t.Fatalf("Expected every goroutine to get the same class, got %v, %v", classes[i], errs[i])
+		}
+	}
+
+	// com/acme/Shared is parsed once, together with the synthetic java/lang/Object
+	stats := loaders.Application.Cache.Stats()
+	if stats.Parsed != 1 {
+		t.Errorf("Expected the class to be parsed once, got %d", stats.Parsed)
+	}
+
+	if stats.Hits < uint64(len(classes)-1) || stats.Misses == 0 {
+		t.Errorf("Expected hits for the repeated requests, got %v", stats)
+	}
+}
+
+func TestShouldLoadClassesInParallel(t *testing.T) {
+	dir := t.TempDir()
+	iface := core.ACC_PUBLIC | core.ACC_INTERFACE | core.ACC_ABSTRACT
+	writeClassFile(t, dir, "com/acme/Service", classFileWithHierarchy(iface, "com/acme/Service", "java/lang/Object"))
+
+	names := []string{}
+	for i := 0; i < 50; i++ {
+		name := fmt.Sprintf("com/acme/Impl%d", i)
+		writeClassFile(t, dir, name, classFileWithHierarchy(core.ACC_PUBLIC, name, "java/lang/Object", "com/acme/Service"))
+		names = append(names, name)
+	}
+
+	loaders := newTestLoaders(t, dir)
+
+	classes, err := loaders.Application.LoadClasses(names)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	service := loaders.Application.FindLoadedClass("com/acme/Service")
+	for i, class := range classes {
+		if class.Name != names[i] || len(class.Interfaces) != 1 || class.Interfaces[0] != service {
+			t.Fatalf("Expected %s implementing the shared com/acme/Service, got %v", names[i], class)
+		}
+	}
+
+	stats := loaders.Application.Cache.Stats()
+	if stats.Parsed != uint64(len(names)+1) {
+		t.Errorf("Expected %d classes to be parsed, got %d", len(names)+1, stats.Parsed)
+	}
+
+	if _, err := loaders.Application.LoadClasses([]string{"com/acme/Impl0", "com/acme/Missing"}); !core.IsJavaError(err, core.ClassNotFoundException) {
+		t.Errorf("Expected ClassNotFoundException, got %v", err)
+	}
+}
+
+func TestShouldEvictPrefetchedClassFilesDefinedByAParent(t *testing.T) {
+	dir := t.TempDir()
+	// the bootstrap loader defines java/lang/Cloneable, the one of the class path is prefetched but never used
+	writeClassFile(t, dir, "java/lang/Cloneable", minimalClassFile("java/lang/Cloneable"))
+	writeClassFile(t, dir, "com/acme/Copy", classFileWithHierarchy(core.ACC_PUBLIC, "com/acme/Copy", "java/lang/Object", "java/lang/Cloneable"))
+
+	loaders := newTestLoaders(t, dir)
+	loaders.Application.Prefetch("java/lang/Cloneable")
+
+	class, err := loaders.Application.LoadClass("com/acme/Copy")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if class.Interfaces[0].Loader != loaders.Bootstrap {
+		t.Errorf("Expected java/lang/Cloneable to be defined by the bootstrap loader, got %s", class.Interfaces[0].Loader)
+	}
+
+	if parsed := loaders.Application.Cache.ParsedClassFiles(); parsed != 0 {
+		t.Errorf("Expected the prefetched class files to be evicted, got %d", parsed)
+	}
+}
+
+func TestShouldDetectCircularHierarchiesLoadedConcurrently(t *testing.T) {
+	dir := t.TempDir()
+	writeClassFile(t, dir, "A", classFileWithHierarchy(core.ACC_PUBLIC, "A", "B"))
+	writeClassFile(t, dir, "B", classFileWithHierarchy(core.ACC_PUBLIC, "B", "A"))
+
+	for i := 0; i < 20; i++ {
+		loaders := newTestLoaders(t, dir)
+
+		_, err := loaders.Application.LoadClasses([]string{"A", "B"})
+		if !core.IsJavaError(err, core.ClassCircularityError) {
+			t.Fatalf("Expected ClassCircularityError, got %v", err)
+		}
+	}
+}
+
+func TestShouldRetryFailedLoads(t *testing.T) {
+	dir := t.TempDir()
+	loaders := newTestLoaders(t, dir)
+
+	if _, err := loaders.Application.LoadClass("Later"); !core.IsJavaError(err, core.ClassNotFoundException) {
+		t.Fatalf("Expected ClassNotFoundException, got %v", err)
+	}
+
+	// the class path is read again, since failed loads are not cached
+	writeClassFile(t, dir, "Later", minimalClassFile("Later"))
+
+	if _, err := loaders.Application.LoadClass("Later"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestShouldParseTheClassStatsOption(t *testing.T) {
+	ctx, err := core.NewExecutionContext([]string{"--class-stats", "Main"})
+	if err != nil || !ctx.ClassStats || ctx.MainClass != "Main" {
+		t.Errorf("Expected the class stats to be enabled, got %v, %v", ctx, err)
+	}
+}
@@ -21,7 +21,10 @@ const (
 	CONSTANT_Utf8               uint8 = 1
 	CONSTANT_MethodHandle       uint8 = 15
 	CONSTANT_MethodType         uint8 = 16
+	CONSTANT_Dynamic            uint8 = 17
 	CONSTANT_InvokeDynamic      uint8 = 18
+	CONSTANT_Module             uint8 = 19
+	CONSTANT_Package            uint8 = 20
 )
 
 // MagicNumber is the magic number of a Java class file. It is always 0xCAFEBABE.
@@ -36,6 +39,34 @@ const (
 	ACC_SYNTHETIC  uint16 = 0x1000
 	ACC_ANNOTATION uint16 = 0x2000
 	ACC_ENUM       uint16 = 0x4000
+	ACC_MODULE     uint16 = 0x8000
+)
+
+// Access flags that are only valid for fields and methods, some of them share the value of a class flag.
+const (
+	ACC_PRIVATE      uint16 = 0x0002
+	ACC_PROTECTED    uint16 = 0x0004
+	ACC_STATIC       uint16 = 0x0008
+	ACC_SYNCHRONIZED uint16 = 0x0020
+	ACC_VOLATILE     uint16 = 0x0040
+	ACC_BRIDGE       uint16 = 0x0040
+	ACC_TRANSIENT    uint16 = 0x0080
+	ACC_VARARGS      uint16 = 0x0080
+	ACC_NATIVE       uint16 = 0x0100
+	ACC_STRICT       uint16 = 0x0800
+)
+
+// Kinds of method handles, the ReferenceKind of a CONSTANT_MethodHandle_info structure.
+const (
+	REF_getField         uint8 = 1
+	REF_getStatic        uint8 = 2
+	REF_putField         uint8 = 3
+	REF_putStatic        uint8 = 4
+	REF_invokeVirtual    uint8 = 5
+	REF_invokeStatic     uint8 = 6
+	REF_invokeSpecial    uint8 = 7
+	REF_newInvokeSpecial uint8 = 8
+	REF_invokeInterface  uint8 = 9
 )
 
 // ConstantPoolInfo represents an element inside the `ConstantPool`
@@ -83,10 +114,49 @@ type StringInfo struct {
 	StringIndex uint16
 }
 
+// Numeric32BitsInfo represents a CONSTANT_Integer_info or CONSTANT_Float_info structure in the constant pool.
 type Numeric32BitsInfo struct {
+	// Value holds the bytes of the constant, a float is stored in IEEE 754 single format.
 	Value uint32
 }
 
+// Numeric64BitsInfo represents a CONSTANT_Long_info or CONSTANT_Double_info structure in the constant pool.
+//
+// These constants take two entries in the constant pool, the entry right after them is
+// kept as an empty placeholder, so indexes still match the ones used by the bytecode.
+type Numeric64BitsInfo struct {
+	// Value holds the bytes of the constant, a double is stored in IEEE 754 double format.
+	Value uint64
+}
+
+// MethodHandleInfo represents a CONSTANT_MethodHandle_info structure in the constant pool.
+type MethodHandleInfo struct {
+	// ReferenceKind denotes the kind of the method handle, it goes from 1 (REF_getField) to 9 (REF_invokeInterface).
+	ReferenceKind uint8
+	// ReferenceIndex is the index of a field, method or interface method reference in the constant pool.
+	ReferenceIndex uint16
+}
+
+// MethodTypeInfo represents a CONSTANT_MethodType_info structure in the constant pool.
+type MethodTypeInfo struct {
+	// DescriptorIndex is the index of a UTF-8 entry in the constant pool that represents a method descriptor.
+	DescriptorIndex uint16
+}
+
+// DynamicInfo represents a CONSTANT_Dynamic_info or CONSTANT_InvokeDynamic_info structure in the constant pool.
+type DynamicInfo struct {
+	// BootstrapMethodAttrIndex is an index into the bootstrap_methods array of the BootstrapMethods attribute.
+	BootstrapMethodAttrIndex uint16
+	// NameAndTypeIndex is the index of a CONSTANT_NameAndType_info structure in the constant pool.
+	NameAndTypeIndex uint16
+}
+
+// ModuleInfo represents a CONSTANT_Module_info or CONSTANT_Package_info structure in the constant pool.
+type ModuleInfo struct {
+	// NameIndex is the index of a UTF-8 entry in the constant pool that represents the name of the module or package.
+	NameIndex uint16
+}
+
 // UTF8Info represents a CONSTANT_Utf8_info structure in the constant pool.
 // It is used to represent a string value.
 type UTF8Info struct {
@@ -182,7 +252,10 @@ var (
 		CONSTANT_Utf8:               "CONSTANT_Utf8",
 		CONSTANT_MethodHandle:       "CONSTANT_MethodHandle",
 		CONSTANT_MethodType:         "CONSTANT_MethodType",
+		CONSTANT_Dynamic:            "CONSTANT_Dynamic",
 		CONSTANT_InvokeDynamic:      "CONSTANT_InvokeDynamic",
+		CONSTANT_Module:             "CONSTANT_Module",
+		CONSTANT_Package:            "CONSTANT_Package",
 	}
 )
 
@@ -218,11 +291,16 @@ func (c *ClassFile) ValidateMagicNumber() error {
 }
 
 func (c *ClassFile) ValidateAccessFlags() error {
-	validFlags := ACC_PUBLIC | ACC_FINAL | ACC_SUPER | ACC_INTERFACE | ACC_ABSTRACT | ACC_SYNTHETIC | ACC_ANNOTATION | ACC_ENUM
+	validFlags := ACC_PUBLIC | ACC_FINAL | ACC_SUPER | ACC_INTERFACE | ACC_ABSTRACT | ACC_SYNTHETIC | ACC_ANNOTATION | ACC_ENUM | ACC_MODULE
 	if c.AccessFlags&^validFlags != 0 {
 		return fmt.Errorf("invalid access flags: 0x%x", c.AccessFlags)
 	}
 
+	// If the ACC_MODULE flag is set in the access_flags item, then no other flag in the access_flags item may be set.
+	if c.AccessFlags&ACC_MODULE != 0 && c.AccessFlags != ACC_MODULE {
+		return fmt.Errorf("module must not have any other flag set")
+	}
+
 	// If the ACC_INTERFACE flag is set, the ACC_ABSTRACT flag must also be set, and the ACC_FINAL, ACC_SUPER,
 	// and ACC_ENUM flags set must not be set.
 	if c.AccessFlags&ACC_INTERFACE != 0 {
@@ -271,12 +349,80 @@ func (c *ClassFile) ValidateThisClass() error {
 	return nil
 }
 
+// ExecuteClassFile defines the class of the class file in a new application loader, with empty class paths,
+// and executes it.
 func ExecuteClassFile(reader *bytes.Reader) error {
-	_, err := ClassFileFromReader(reader)
+	content := make([]byte, reader.Len())
+	if _, err := reader.Read(content); err != nil {
+		return err
+	}
+
+	loaders := NewClassLoaders(NewClassPath(), nil, NewClassPath())
+	defer loaders.Close()
 
+	class, err := loaders.Application.DefineClass("", content)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return ExecuteClass(class)
+}
+
+// Utf8 returns the string of the CONSTANT_Utf8_info entry at the given constant pool index.
+func (c *ClassFile) Utf8(index uint16) (string, error) {
+	if index == 0 || int(index) > len(c.ConstantPool) {
+		return "", fmt.Errorf("invalid constant pool index: %d", index)
+	}
+
+	info, ok := c.ConstantPool[index-1].Info.(UTF8Info)
+	if !ok {
+		return "", fmt.Errorf("constant pool entry %d should be a CONSTANT_Utf8_info", index)
+	}
+
+	return string(info.Bytes), nil
+}
+
+// ClassName returns the internal name of the CONSTANT_Class_info entry at the given constant pool index.
+func (c *ClassFile) ClassName(index uint16) (string, error) {
+	if index == 0 || int(index) > len(c.ConstantPool) {
+		return "", fmt.Errorf("invalid constant pool index: %d", index)
+	}
+
+	info, ok := c.ConstantPool[index-1].Info.(ClassInfo)
+	if !ok {
+		return "", fmt.Errorf("constant pool entry %d should be a CONSTANT_Class_info", index)
+	}
+
+	return c.Utf8(info.NameIndex)
+}
+
+// ModuleName returns the name of the CONSTANT_Module_info or CONSTANT_Package_info entry at the given
+// constant pool index.
+func (c *ClassFile) ModuleName(index uint16) (string, error) {
+	if index == 0 || int(index) > len(c.ConstantPool) {
+		return "", fmt.Errorf("invalid constant pool index: %d", index)
+	}
+
+	info, ok := c.ConstantPool[index-1].Info.(ModuleInfo)
+	if !ok {
+		return "", fmt.Errorf("constant pool entry %d should be a CONSTANT_Module_info or CONSTANT_Package_info", index)
+	}
+
+	return c.Utf8(info.NameIndex)
+}
+
+// FindAttribute returns the first attribute of the list with the given name.
+func (c *ClassFile) FindAttribute(attributes []AttributeInfo, name string) (AttributeInfo, bool) {
+	for _, attr := range attributes {
+		if attrName, err := c.Utf8(attr.AttributeNameIndex); err == nil && attrName == name {
+			return attr, true
+		}
+	}
+
+	return AttributeInfo{}, false
+}
+
+// Name returns the internal name of the class defined by the class file (e.g. java/lang/Object).
+func (c *ClassFile) Name() (string, error) {
+	return c.ClassName(c.ThisClass)
 }
@@ -0,0 +1,176 @@
+package core_test
+
+import (
+	"encoding/binary"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// testClassBuilder assembles class files for the tests, the constant pool entries are created on demand
+// and deduplicated.
+type testClassBuilder struct {
+	pool    []byte
+	count   uint16
+	entries map[string]uint16
+}
+
+func newTestClassBuilder() *testClassBuilder {
+	return &testClassBuilder{count: 1, entries: make(map[string]uint16)}
+}
+
+func u2(v uint16) []byte {
+	return binary.BigEndian.AppendUint16(nil, v)
+}
+
+func u4(v uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, v)
+}
+
+func u8(v uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, v)
+}
+
+// entry adds a constant pool entry, reusing an equal one if it already exists.
+func (b *testClassBuilder) entry(tag uint8, info []byte) uint16 {
+	key := string(append([]byte{tag}, info...))
+	if index, ok := b.entries[key]; ok {
+		return index
+	}
+
+	index := b.count
+	b.pool = append(b.pool, tag)
+	b.pool = append(b.pool, info...)
+	b.entries[key] = index
+	b.count++
+
+	if tag == core.CONSTANT_Long || tag == core.CONSTANT_Double {
+		b.count++
+	}
+
+	return index
+}
+
+func (b *testClassBuilder) utf8(s string) uint16 {
+	return b.entry(core.CONSTANT_Utf8, append(u2(uint16(len(s))), s...))
+}
+
+func (b *testClassBuilder) class(name string) uint16 {
+	return b.entry(core.CONSTANT_Class, u2(b.utf8(name)))
+}
+
+func (b *testClassBuilder) module(name string) uint16 {
+	return b.entry(core.CONSTANT_Module, u2(b.utf8(name)))
+}
+
+func (b *testClassBuilder) pkg(name string) uint16 {
+	return b.entry(core.CONSTANT_Package, u2(b.utf8(name)))
+}
+
+func (b *testClassBuilder) str(s string) uint16 {
+	return b.entry(core.CONSTANT_String, u2(b.utf8(s)))
+}
+
+func (b *testClassBuilder) nameAndType(name string, descriptor string) uint16 {
+	return b.entry(core.CONSTANT_NameAndType, append(u2(b.utf8(name)), u2(b.utf8(descriptor))...))
+}
+
+func (b *testClassBuilder) fieldref(class string, name string, descriptor string) uint16 {
+	return b.entry(core.CONSTANT_Fieldref, append(u2(b.class(class)), u2(b.nameAndType(name, descriptor))...))
+}
+
+func (b *testClassBuilder) methodref(class string, name string, descriptor string) uint16 {
+	return b.entry(core.CONSTANT_Methodref, append(u2(b.class(class)), u2(b.nameAndType(name, descriptor))...))
+}
+
+func (b *testClassBuilder) interfaceMethodref(class string, name string, descriptor string) uint16 {
+	return b.entry(core.CONSTANT_InterfaceMethodref, append(u2(b.class(class)), u2(b.nameAndType(name, descriptor))...))
+}
+
+func (b *testClassBuilder) methodHandle(kind uint8, ref uint16) uint16 {
+	return b.entry(core.CONSTANT_MethodHandle, append([]byte{kind}, u2(ref)...))
+}
+
+func (b *testClassBuilder) methodType(descriptor string) uint16 {
+	return b.entry(core.CONSTANT_MethodType, u2(b.utf8(descriptor)))
+}
+
+func (b *testClassBuilder) invokeDynamic(bootstrap uint16, name string, descriptor string) uint16 {
+	return b.entry(core.CONSTANT_InvokeDynamic, append(u2(bootstrap), u2(b.nameAndType(name, descriptor))...))
+}
+
+// bootstrapMethods builds a BootstrapMethods attribute, each method is its method handle followed by its
+// static arguments.
+func (b *testClassBuilder) bootstrapMethods(methods ...[]uint16) []byte {
+	info := u2(uint16(len(methods)))
+	for _, method := range methods {
+		info = append(info, u2(method[0])...)
+		info = append(info, u2(uint16(len(method)-1))...)
+		for _, arg := range method[1:] {
+			info = append(info, u2(arg)...)
+		}
+	}
+
+	return b.attribute("BootstrapMethods", info)
+}
+
+// member builds a field_info or method_info structure, they have the same layout.
+func (b *testClassBuilder) member(access uint16, name string, descriptor string, attributes ...[]byte) []byte {
+	out := append(u2(access), u2(b.utf8(name))...)
+	out = append(out, u2(b.utf8(descriptor))...)
+	out = append(out, u2(uint16(len(attributes)))...)
+	for _, attr := range attributes {
+		out = append(out, attr...)
+	}
+
+	return out
+}
+
+// attribute builds an attribute with the given name and content.
+func (b *testClassBuilder) attribute(name string, info []byte) []byte {
+	attr := append(u2(b.utf8(name)), u4(uint32(len(info)))...)
+	return append(attr, info...)
+}
+
+// code builds a Code attribute with the given bytecode and no exception handlers.
+func (b *testClassBuilder) code(maxStack uint16, maxLocals uint16, bytecode ...byte) []byte {
+	info := append(u2(maxStack), u2(maxLocals)...)
+	info = append(info, u4(uint32(len(bytecode)))...)
+	info = append(info, bytecode...)
+	info = append(info, 0, 0, 0, 0)
+
+	return b.attribute("Code", info)
+}
+
+// build writes the class file, fields, methods and attributes are already encoded.
+func (b *testClassBuilder) build(access uint16, this string, super string, interfaces []string, fields [][]byte, methods [][]byte, attributes [][]byte) []byte {
+	thisIndex := b.class(this)
+	superIndex := uint16(0)
+	if super != "" {
+		superIndex = b.class(super)
+	}
+
+	interfaceIndexes := []uint16{}
+	for _, name := range interfaces {
+		interfaceIndexes = append(interfaceIndexes, b.class(name))
+	}
+
+	out := []byte{0xCA, 0xFE, 0xBA, 0xBE, 0x00, 0x00, 0x00, 0x41}
+	out = append(out, u2(b.count)...)
+	out = append(out, b.pool...)
+	out = append(out, u2(access)...)
+	out = append(out, u2(thisIndex)...)
+	out = append(out, u2(superIndex)...)
+	out = append(out, u2(uint16(len(interfaceIndexes)))...)
+	for _, index := range interfaceIndexes {
+		out = append(out, u2(index)...)
+	}
+
+	for _, list := range [][][]byte{fields, methods, attributes} {
+		out = append(out, u2(uint16(len(list)))...)
+		for _, item := range list {
+			out = append(out, item...)
+		}
+	}
+
+	return out
+}
@@ -13,6 +13,18 @@ func (c *ConstantPoolInfo) String() string {
 		return fmt.Sprintf("StringInfo{ StringIndex: %d }", c.Info.(StringInfo).StringIndex)
 	case CONSTANT_Integer, CONSTANT_Float:
 		return fmt.Sprintf("Numeric32BitsInfo{ Value: %d }", c.Info.(Numeric32BitsInfo).Value)
+	case CONSTANT_Long, CONSTANT_Double:
+		return fmt.Sprintf("Numeric64BitsInfo{ Value: %d }", c.Info.(Numeric64BitsInfo).Value)
+	case CONSTANT_MethodHandle:
+		return fmt.Sprintf("MethodHandleInfo{ ReferenceKind: %d, ReferenceIndex: %d }",
+			c.Info.(MethodHandleInfo).ReferenceKind, c.Info.(MethodHandleInfo).ReferenceIndex)
+	case CONSTANT_MethodType:
+		return fmt.Sprintf("MethodTypeInfo{ DescriptorIndex: %d }", c.Info.(MethodTypeInfo).DescriptorIndex)
+	case CONSTANT_Dynamic, CONSTANT_InvokeDynamic:
+		return fmt.Sprintf("DynamicInfo{ BootstrapMethodAttrIndex: %d, NameAndTypeIndex: %d }",
+			c.Info.(DynamicInfo).BootstrapMethodAttrIndex, c.Info.(DynamicInfo).NameAndTypeIndex)
+	case CONSTANT_Module, CONSTANT_Package:
+		return fmt.Sprintf("ModuleInfo{ NameIndex: %d }", c.Info.(ModuleInfo).NameIndex)
 	case CONSTANT_NameAndType:
 		return fmt.Sprintf("NameAndTypeInfo{ NameIndex: %d, DescriptorIndex: %d }",
 			c.Info.(NameAndTypeInfo).NameIndex, c.Info.(NameAndTypeInfo).DescriptorIndex)
@@ -57,6 +57,11 @@ func ClassFileFromReader(reader *bytes.Reader) (ClassFile, error) {
 		}
 
 		classFile.ConstantPool[i] = cpInfo
+
+		// longs and doubles take two slots, the second one is left as an empty placeholder
+		if cpInfo.Tag == CONSTANT_Long || cpInfo.Tag == CONSTANT_Double {
+			i++
+		}
 	}
 
 	accessFlags, err := bigEndianReader.ReadUint16()
@@ -171,6 +176,10 @@ func (c *ClassFile) constantPoolFromReader(reader *utils.BigEndianReader) (Const
 		return c.readConstantPoolNumeric32BitsInfo(reader, tag)
 	}
 
+	if tag == CONSTANT_Long || tag == CONSTANT_Double {
+		return c.readConstantPoolNumeric64BitsInfo(reader, tag)
+	}
+
 	if tag == CONSTANT_NameAndType {
 		return c.readConstantPoolNameAndTypeInfo(reader)
 	}
@@ -179,6 +188,22 @@ func (c *ClassFile) constantPoolFromReader(reader *utils.BigEndianReader) (Const
 		return c.readConstantPoolUTF8Info(reader)
 	}
 
+	if tag == CONSTANT_MethodHandle {
+		return c.readConstantPoolMethodHandleInfo(reader)
+	}
+
+	if tag == CONSTANT_MethodType {
+		return c.readConstantPoolMethodTypeInfo(reader)
+	}
+
+	if tag == CONSTANT_Dynamic || tag == CONSTANT_InvokeDynamic {
+		return c.readConstantPoolDynamicInfo(reader, tag)
+	}
+
+	if tag == CONSTANT_Module || tag == CONSTANT_Package {
+		return c.readConstantPoolModuleInfo(reader, tag)
+	}
+
 	return cpInfo, fmt.Errorf("invalid constant pool tag: %d", tag)
 }
 
@@ -247,6 +272,91 @@ func (c *ClassFile) readConstantPoolNumeric32BitsInfo(reader *utils.BigEndianRea
 	return cpInfo, nil
 }
 
+func (c *ClassFile) readConstantPoolNumeric64BitsInfo(reader *utils.BigEndianReader, tag uint8) (ConstantPoolInfo, error) {
+	cpInfo := ConstantPoolInfo{
+		Tag: tag,
+	}
+
+	value, err := reader.ReadUint64()
+	if err != nil {
+		return cpInfo, err
+	}
+
+	cpInfo.Info = Numeric64BitsInfo{value}
+
+	return cpInfo, nil
+}
+
+func (c *ClassFile) readConstantPoolMethodHandleInfo(reader *utils.BigEndianReader) (ConstantPoolInfo, error) {
+	cpInfo := ConstantPoolInfo{
+		Tag: CONSTANT_MethodHandle,
+	}
+
+	referenceKind, err := reader.ReadUint8()
+	if err != nil {
+		return cpInfo, err
+	}
+
+	referenceIndex, err := reader.ReadUint16()
+	if err != nil {
+		return cpInfo, err
+	}
+
+	cpInfo.Info = MethodHandleInfo{referenceKind, referenceIndex}
+
+	return cpInfo, nil
+}
+
+func (c *ClassFile) readConstantPoolMethodTypeInfo(reader *utils.BigEndianReader) (ConstantPoolInfo, error) {
+	cpInfo := ConstantPoolInfo{
+		Tag: CONSTANT_MethodType,
+	}
+
+	descriptorIndex, err := reader.ReadUint16()
+	if err != nil {
+		return cpInfo, err
+	}
+
+	cpInfo.Info = MethodTypeInfo{descriptorIndex}
+
+	return cpInfo, nil
+}
+
+func (c *ClassFile) readConstantPoolDynamicInfo(reader *utils.BigEndianReader, tag uint8) (ConstantPoolInfo, error) {
+	cpInfo := ConstantPoolInfo{
+		Tag: tag,
+	}
+
+	bootstrapMethodAttrIndex, err := reader.ReadUint16()
+	if err != nil {
+		return cpInfo, err
+	}
+
+	nameAndTypeIndex, err := reader.ReadUint16()
+	if err != nil {
+		return cpInfo, err
+	}
+
+	cpInfo.Info = DynamicInfo{bootstrapMethodAttrIndex, nameAndTypeIndex}
+
+	return cpInfo, nil
+}
+
+func (c *ClassFile) readConstantPoolModuleInfo(reader *utils.BigEndianReader, tag uint8) (ConstantPoolInfo, error) {
+	cpInfo := ConstantPoolInfo{
+		Tag: tag,
+	}
+
+	nameIndex, err := reader.ReadUint16()
+	if err != nil {
+		return cpInfo, err
+	}
+
+	cpInfo.Info = ModuleInfo{nameIndex}
+
+	return cpInfo, nil
+}
+
 func (c *ClassFile) readConstantPoolNameAndTypeInfo(reader *utils.BigEndianReader) (ConstantPoolInfo, error) {
 	cpInfo := ConstantPoolInfo{
 		Tag: CONSTANT_NameAndType,
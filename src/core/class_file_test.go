@@ -165,3 +165,29 @@ func TestItShouldFailIfTheConstantPoolSizeIsNotValid(t *testing.T) {
 		t.Errorf("Expected ErrInvalidConstantPoolSize, got %v", err)
 	}
 }
+
+func TestItShouldReadLongConstantsAsTwoEntries(t *testing.T) {
+	b := minimalClassFile("Constants")
+	// replace the constant pool count and append a long right after the existing entries
+	b[9] = 0x07
+	long := []byte{core.CONSTANT_Long, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x2A}
+	cpEnd := len(b) - 14
+	b = append(b[:cpEnd], append(long, b[cpEnd:]...)...)
+
+	cf, err := core.ClassFileFromReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(cf.ConstantPool) != 6 {
+		t.Fatalf("Expected constant pool of size 6, got %d", len(cf.ConstantPool))
+	}
+
+	if cf.ConstantPool[4].String() != "Numeric64BitsInfo{ Value: 42 }" {
+		t.Errorf("Expected the long constant, got %s", cf.ConstantPool[4].String())
+	}
+
+	if cf.ConstantPool[5].Tag != 0 {
+		t.Errorf("Expected the entry after a long to be empty, got tag %d", cf.ConstantPool[5].Tag)
+	}
+}
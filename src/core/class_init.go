@@ -0,0 +1,295 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-5.html#jvms-5.5
+
+// InitState is the initialization state of a class.
+type InitState int
+
+const (
+	// ClassLinked is a class that is prepared but not initialized yet.
+	ClassLinked InitState = iota
+	// ClassBeingInitialized is a class whose initialization is in progress in some thread.
+	ClassBeingInitialized
+	// ClassInitialized is a class that is ready for use.
+	ClassInitialized
+	// ClassErroneous is a class whose initialization failed, it can not be used.
+	ClassErroneous
+)
+
+// classInit keeps the initialization state of a class, guarded by its own lock.
+type classInit struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	state InitState
+	// thread is the thread initializing the class, while it is being initialized.
+	thread *Thread
+	// err is the exception that made the class erroneous.
+	err error
+}
+
+// InitState returns the initialization state of the class.
+func (c *Class) InitState() InitState {
+	c.init.mu.Lock()
+	defer c.init.mu.Unlock()
+
+	return c.init.state
+}
+
+// IsInitialized tells if the class has been successfully initialized.
+func (c *Class) IsInitialized() bool {
+	return c.InitState() == ClassInitialized
+}
+
+// Initialize initializes the class in the given thread, following the procedure of JVMS 5.5: the superclass
+// and the superinterfaces that declare default methods are initialized first and then `<clinit>` is run,
+// exactly once even if several threads initialize the class at the same time.
+//
+// A class whose initialization fails becomes erroneous, it fails with an ExceptionInInitializerError
+// (unless `<clinit>` threw an Error) and with a NoClassDefFoundError on the next attempts.
+func (c *Class) Initialize(t *Thread) error {
+	init := &c.init
+
+	init.mu.Lock()
+	for init.state == ClassBeingInitialized && init.thread != t {
+		init.cond.Wait()
+	}
+
+	switch init.state {
+	case ClassBeingInitialized, ClassInitialized:
+		// a recursive request from the thread initializing the class, or an initialized class
+		init.mu.Unlock()
+		return nil
+	case ClassErroneous:
+		err := init.err
+		init.mu.Unlock()
+
+		return &JavaError{ClassName: NoClassDefFoundError, Message: "Could not initialize class " + c.JavaName(), Cause: err}
+	}
+
+	init.state, init.thread = ClassBeingInitialized, t
+	init.mu.Unlock()
+
+	if err := c.setStringConstants(); err != nil {
+		c.finishInitialization(ClassErroneous, err)
+		return err
+	}
+
+	if err := c.initializeSupers(t); err != nil {
+		c.finishInitialization(ClassErroneous, err)
+		return err
+	}
+
+	if clinit := c.DeclaredMethod("<clinit>", "()V"); clinit != nil && clinit.IsStatic() {
+		if _, err := t.Invoke(clinit, nil); err != nil {
+			err = c.wrapInitializerError(t, err)
+			c.finishInitialization(ClassErroneous, initializationCause(t, err))
+
+			return err
+		}
+	}
+
+	// the VM gives the constants of the machine to Unsafe once their class is initialized
+	if c.Name == unsafeConstantsClass && c.Loader.Parent == nil {
+		injectUnsafeConstants(c)
+	}
+
+	c.finishInitialization(ClassInitialized, nil)
+
+	return nil
+}
+
+// initializeSupers initializes the superclass and the superinterfaces that declare default methods, which
+// is only done for classes, interfaces do not initialize their superinterfaces.
+func (c *Class) initializeSupers(t *Thread) error {
+	if c.IsInterface() {
+		return nil
+	}
+
+	if c.Super != nil {
+		if err := c.Super.Initialize(t); err != nil {
+			return err
+		}
+	}
+
+	seen := make(map[*Class]bool)
+
+	var visit func(class *Class) error
+	visit = func(class *Class) error {
+		for _, iface := range class.Interfaces {
+			if seen[iface] {
+				continue
+			}
+
+			seen[iface] = true
+
+			// the superinterfaces of an interface come before it (JVMS 5.5 step 7)
+			if err := visit(iface); err != nil {
+				return err
+			}
+
+			if iface.declaresDefaultMethods() {
+				if err := iface.Initialize(t); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	return visit(c)
+}
+
+// declaresDefaultMethods tells if the interface declares a non-abstract instance method.
+func (c *Class) declaresDefaultMethods() bool {
+	for _, method := range c.Methods {
+		if !method.IsAbstract() && !method.IsStatic() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Class) finishInitialization(state InitState, err error) {
+	c.init.mu.Lock()
+	defer c.init.mu.Unlock()
+
+	c.init.state, c.init.thread, c.init.err = state, nil, err
+	c.init.cond.Broadcast()
+}
+
+// wrapInitializerError wraps an exception thrown by `<clinit>` in an ExceptionInInitializerError, unless it
+// is already an Error.
+func (c *Class) wrapInitializerError(t *Thread, err error) error {
+	var javaErr *JavaError
+	if !errors.As(err, &javaErr) {
+		return err
+	}
+
+	isError, checkErr := t.isError(c.Loader, javaErr)
+	if checkErr != nil {
+		return checkErr
+	}
+
+	if isError {
+		return err
+	}
+
+	return &JavaError{ClassName: ExceptionInInitializerError, Cause: err}
+}
+
+// initializationCause is the cause of the NoClassDefFoundError thrown when using an erroneous class, an
+// ExceptionInInitializerError that tells which exception made the class erroneous and in which thread.
+func initializationCause(t *Thread, err error) error {
+	var javaErr *JavaError
+	if errors.As(err, &javaErr) && javaErr.ClassName == ExceptionInInitializerError && javaErr.Cause != nil {
+		errors.As(javaErr.Cause, &javaErr)
+	}
+
+	name := "?"
+	if javaErr != nil {
+		name = javaName(javaErr.ClassName)
+	}
+
+	return &JavaError{
+		ClassName: ExceptionInInitializerError,
+		Message:   fmt.Sprintf("Exception %s [in thread \"%s\"]", name, t.Name),
+		Cause:     err,
+	}
+}
+
+// setConstantValues sets the static fields that have a ConstantValue attribute, which is done when the
+// class is prepared.
+//
+// String constants are left null until the class is initialized, see `setStringConstants`.
+func (c *Class) setConstantValues() error {
+	for _, field := range c.Fields {
+		if !field.IsStatic() {
+			continue
+		}
+
+		attr, ok := c.File.FindAttribute(field.Info.Attributes, "ConstantValue")
+		if !ok {
+			continue
+		}
+
+		if len(attr.Info) != 2 {
+			return NewJavaError(ClassFormatError, "Invalid ConstantValue field attribute length %d in class file %s", len(attr.Info), c.Name)
+		}
+
+		index := uint16(attr.Info[0])<<8 | uint16(attr.Info[1])
+		if index == 0 || int(index) > len(c.File.ConstantPool) {
+			return NewJavaError(ClassFormatError, "Bad initial value index %d in ConstantValue attribute in class file %s", index, c.Name)
+		}
+
+		entry := c.File.ConstantPool[index-1]
+		if !constantMatchesDescriptor(entry.Tag, field.Descriptor) {
+			return NewJavaError(ClassFormatError, "Inconsistent constant value type in class file %s", c.Name)
+		}
+
+		values := c.StaticValues[field.Slot:]
+
+		switch info := entry.Info.(type) {
+		case Numeric32BitsInfo:
+			values[0].Num = int32(info.Value)
+		case Numeric64BitsInfo:
+			values[0].Num, values[1].Num = int32(info.Value>>32), int32(info.Value)
+		}
+	}
+
+	return nil
+}
+
+// setStringConstants sets the static fields that have a String ConstantValue attribute to their interned
+// strings, which is done when the class is initialized, before its superclass, as JVMS 5.5 says. The
+// attributes were checked by `setConstantValues`.
+func (c *Class) setStringConstants() error {
+	for _, field := range c.Fields {
+		if !field.IsStatic() {
+			continue
+		}
+
+		attr, ok := c.File.FindAttribute(field.Info.Attributes, "ConstantValue")
+		if !ok {
+			continue
+		}
+
+		index := uint16(attr.Info[0])<<8 | uint16(attr.Info[1])
+		if c.File.ConstantPool[index-1].Tag != CONSTANT_String {
+			continue
+		}
+
+		str, err := c.ConstantPool.resolveString(index)
+		if err != nil {
+			return err
+		}
+
+		c.StaticValues[field.Slot].Ref = str
+	}
+
+	return nil
+}
+
+func constantMatchesDescriptor(tag uint8, descriptor string) bool {
+	switch descriptor {
+	case "I", "S", "C", "B", "Z":
+		return tag == CONSTANT_Integer
+	case "F":
+		return tag == CONSTANT_Float
+	case "J":
+		return tag == CONSTANT_Long
+	case "D":
+		return tag == CONSTANT_Double
+	case "Ljava/lang/String;":
+		return tag == CONSTANT_String
+	}
+
+	return false
+}
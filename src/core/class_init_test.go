@@ -0,0 +1,256 @@
+package core_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+var clinitInterface = core.ACC_PUBLIC | core.ACC_INTERFACE | core.ACC_ABSTRACT
+
+// classWithClinit builds a class with a static initializer and the given extra methods.
+func classWithClinit(access uint16, name string, super string, interfaces []string, methods ...string) []byte {
+	b := newTestClassBuilder()
+
+	members := [][]byte{b.member(core.ACC_STATIC, "<clinit>", "()V")}
+	for _, method := range methods {
+		members = append(members, b.member(core.ACC_PUBLIC, method, "()V"))
+	}
+
+	return b.build(access, name, super, interfaces, nil, members, nil)
+}
+
+// recordClinit makes every method execution record the name of its class, calling the given function
+// for each one of them.
+func recordClinit(t *testing.T, run func(thread *core.Thread, class string) error) *[]string {
+	var mu sync.Mutex
+	order := []string{}
+
+	restore := core.SetExecuteMethod(func(thread *core.Thread, method *core.Method, args []core.Slot) ([]core.Slot, error) {
+		mu.Lock()
+		order = append(order, method.Class.Name)
+		mu.Unlock()
+
+		if run != nil {
+			return nil, run(thread, method.Class.Name)
+		}
+
+		return nil, nil
+	})
+
+	t.Cleanup(restore)
+
+	return &order
+}
+
+func TestShouldInitializeSuperclassesAndDefaultInterfacesFirst(t *testing.T) {
+	dir := t.TempDir()
+	writeClassFile(t, dir, "Super", classWithClinit(core.ACC_PUBLIC, "Super", "java/lang/Object", nil))
+	writeClassFile(t, dir, "WithDefault", classWithClinit(clinitInterface, "WithDefault", "java/lang/Object", nil, "greet"))
+	writeClassFile(t, dir, "Plain", classWithClinit(clinitInterface, "Plain", "java/lang/Object", []string{"WithDefault"}))
+	writeClassFile(t, dir, "Sub", classWithClinit(core.ACC_PUBLIC, "Sub", "Super", []string{"Plain"}))
+
+	order := recordClinit(t, nil)
+	loaders := newTestLoaders(t, dir)
+
+	sub, err := loaders.Application.LoadClass("Sub")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if sub.IsInitialized() || sub.InitState() != core.ClassLinked {
+		t.Errorf("Expected Sub not to be initialized when loaded")
+	}
+
+	if err := sub.Initialize(core.NewThread("main")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(*order) != 3 || (*order)[0] != "Super" || (*order)[1] != "WithDefault" || (*order)[2] != "Sub" {
+		t.Errorf("Expected Super, WithDefault and Sub to be initialized in order, got %v", *order)
+	}
+
+	plain := loaders.Application.FindLoadedClass("Plain")
+	if plain.IsInitialized() || !sub.IsInitialized() || !sub.Super.IsInitialized() {
+		t.Errorf("Expected Plain, without default methods, not to be initialized")
+	}
+
+	// interfaces do not initialize their superinterfaces
+	*order = nil
+	if err := plain.Initialize(core.NewThread("main")); err != nil || len(*order) != 1 || (*order)[0] != "Plain" {
+		t.Errorf("Expected only Plain to be initialized, got %v, %v", *order, err)
+	}
+}
+
+func TestShouldInitializeDefaultSuperinterfacesBeforeTheirSubinterfaces(t *testing.T) {
+	dir := t.TempDir()
+	writeClassFile(t, dir, "Base", classWithClinit(clinitInterface, "Base", "java/lang/Object", nil, "greet"))
+	writeClassFile(t, dir, "Derived", classWithClinit(clinitInterface, "Derived", "java/lang/Object", []string{"Base"}, "wave"))
+	writeClassFile(t, dir, "Impl", classWithClinit(core.ACC_PUBLIC, "Impl", "java/lang/Object", []string{"Derived"}))
+
+	order := recordClinit(t, nil)
+
+	impl, err := newTestLoaders(t, dir).Application.LoadClass("Impl")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := impl.Initialize(core.NewThread("main")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(*order) != 3 || (*order)[0] != "Base" || (*order)[1] != "Derived" || (*order)[2] != "Impl" {
+		t.Errorf("Expected Base, Derived and Impl to be initialized in order, got %v", *order)
+	}
+}
+
+func TestShouldRunTheStaticInitializerOnceAcrossThreads(t *testing.T) {
+	dir := t.TempDir()
+	writeClassFile(t, dir, "Slow", classWithClinit(core.ACC_PUBLIC, "Slow", "java/lang/Object", nil))
+
+	var runs atomic.Int32
+	recordClinit(t, func(thread *core.Thread, class string) error {
+		runs.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	loaders := newTestLoaders(t, dir)
+	slow, _ := loaders.Application.LoadClass("Slow")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := slow.Initialize(core.NewThread("worker")); err != nil || !slow.IsInitialized() {
+				t.Errorf("Expected Slow to be initialized when Initialize returns, got %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if runs.Load() != 1 {
+		t.Errorf("Expected <clinit> to run once, got %d", runs.Load())
+	}
+}
+
+func TestShouldAllowRecursiveInitializationFromTheSameThread(t *testing.T) {
+	dir := t.TempDir()
+	writeClassFile(t, dir, "Recursive", classWithClinit(core.ACC_PUBLIC, "Recursive", "java/lang/Object", nil))
+
+	loaders := newTestLoaders(t, dir)
+	recursive, _ := loaders.Application.LoadClass("Recursive")
+
+	recordClinit(t, func(thread *core.Thread, class string) error {
+		if recursive.InitState() != core.ClassBeingInitialized {
+			t.Errorf("Expected Recursive to be being initialized, got %v", recursive.InitState())
+		}
+
+		return recursive.Initialize(thread)
+	})
+
+	if err := recursive.Initialize(core.NewThread("main")); err != nil || !recursive.IsInitialized() {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestShouldMarkClassesThatFailToInitializeAsErroneous(t *testing.T) {
+	dir := t.TempDir()
+	writeClassFile(t, dir, "Bad", classWithClinit(core.ACC_PUBLIC, "Bad", "java/lang/Object", nil))
+	writeClassFile(t, dir, "BadChild", classWithClinit(core.ACC_PUBLIC, "BadChild", "Bad", nil))
+	writeClassFile(t, dir, "Fatal", classWithClinit(core.ACC_PUBLIC, "Fatal", "java/lang/Object", nil))
+
+	boom := core.NewJavaError("java/lang/RuntimeException", "boom")
+	oom := core.NewJavaError("java/lang/OutOfMemoryError", "Java heap space")
+
+	recordClinit(t, func(thread *core.Thread, class string) error {
+		switch class {
+		case "Bad":
+			return boom
+		case "Fatal":
+			return oom
+		}
+
+		return nil
+	})
+
+	loaders := newTestLoaders(t, dir)
+	main := core.NewThread("main")
+
+	bad, _ := loaders.Application.LoadClass("Bad")
+
+	err := bad.Initialize(main)
+	if !core.IsJavaError(err, core.ExceptionInInitializerError) || !errors.Is(err, boom) {
+		t.Errorf("Expected ExceptionInInitializerError caused by boom, got %v", err)
+	}
+
+	if bad.InitState() != core.ClassErroneous {
+		t.Errorf("Expected Bad to be erroneous, got %v", bad.InitState())
+	}
+
+	err = bad.Initialize(main)
+	if err == nil || err.Error() != "java.lang.NoClassDefFoundError: Could not initialize class Bad" {
+		t.Fatalf("Expected NoClassDefFoundError, got %v", err)
+	}
+
+	cause := errors.Unwrap(err)
+	if cause == nil || cause.Error() != `java.lang.ExceptionInInitializerError: Exception java.lang.RuntimeException [in thread "main"]` {
+		t.Errorf("Expected the cause to tell the original exception, got %v", cause)
+	}
+
+	child, _ := loaders.Application.LoadClass("BadChild")
+	if err := child.Initialize(main); !core.IsJavaError(err, core.NoClassDefFoundError) || child.InitState() != core.ClassErroneous {
+		t.Errorf("Expected BadChild to fail with its superclass, got %v", err)
+	}
+
+	fatal, _ := loaders.Application.LoadClass("Fatal")
+	if err := fatal.Initialize(main); err != oom {
+		t.Errorf("Expected errors not to be wrapped, got %v", err)
+	}
+}
+
+func TestShouldSetConstantValuesWhenPreparing(t *testing.T) {
+	dir := t.TempDir()
+
+	b := newTestClassBuilder()
+	constant := func(access uint16, name string, descriptor string, index uint16) []byte {
+		return b.member(access, name, descriptor, b.attribute("ConstantValue", u2(index)))
+	}
+
+	static := core.ACC_STATIC | core.ACC_FINAL
+	fields := [][]byte{
+		constant(static, "ANSWER", "I", b.entry(core.CONSTANT_Integer, u4(42))),
+		constant(static, "BIG", "J", b.entry(core.CONSTANT_Long, append(u4(1), u4(2)...))),
+		constant(static, "NEGATIVE", "S", b.entry(core.CONSTANT_Integer, u4(0xFFFFFFFF))),
+		constant(core.ACC_FINAL, "IGNORED", "I", b.entry(core.CONSTANT_Integer, u4(7))),
+	}
+
+	writeClassFile(t, dir, "Constants", b.build(core.ACC_PUBLIC, "Constants", "java/lang/Object", nil, fields, nil, nil))
+
+	b = newTestClassBuilder()
+	wrong := b.member(static, "WRONG", "J", b.attribute("ConstantValue", u2(b.entry(core.CONSTANT_Integer, u4(1)))))
+	writeClassFile(t, dir, "Wrong", b.build(core.ACC_PUBLIC, "Wrong", "java/lang/Object", nil, [][]byte{wrong}, nil, nil))
+
+	loaders := newTestLoaders(t, dir)
+
+	constants, err := loaders.Application.LoadClass("Constants")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	values := constants.StaticValues
+	if len(values) != 4 || values[0].Num != 42 || values[1].Num != 1 || values[2].Num != 2 || values[3].Num != -1 {
+		t.Errorf("Expected the constant values to be set, got %v", values)
+	}
+
+	if _, err := loaders.Application.LoadClass("Wrong"); !core.IsJavaError(err, core.ClassFormatError) {
+		t.Errorf("Expected ClassFormatError, got %v", err)
+	}
+}
@@ -0,0 +1,388 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Names of the built-in class loaders, the same ones used by the reference implementation.
+const (
+	BootstrapLoaderName   = "bootstrap"
+	PlatformLoaderName    = "platform"
+	ApplicationLoaderName = "app"
+)
+
+// MaxMajorVersion is the highest class file version supported, the one of the `DefaultRelease`.
+const MaxMajorVersion = DefaultRelease + 44
+
+// ClassLoader loads classes by name and defines classes from class file bytes.
+//
+// Loaders form a hierarchy where each one first delegates to its parent and only looks up the class
+// itself when the parent can not find it. The bootstrap loader is the root of the hierarchy, and all the
+// loaders of a hierarchy share the same `ClassCache`, so a loader is safe to use from multiple goroutines.
+type ClassLoader struct {
+	// Name identifies the loader in error messages, it can be empty for user-defined loaders.
+	Name string
+	// Parent is the loader that is asked first for a class, it is only nil for the bootstrap loader.
+	Parent *ClassLoader
+	// ClassPath is where the loader looks up the classes it defines, it can be nil for loaders whose
+	// classes are only defined with `DefineClass`.
+	ClassPath *ClassPath
+	// Cache keeps the classes this loader is an initiating loader of, both the ones it defined and the
+	// ones its parents loaded for it.
+	Cache *ClassCache
+
+	// primitives are the classes of the primitive types and void, by descriptor, only the bootstrap loader
+	// has them, see `primitiveClass`.
+	primitives   map[string]*Class
+	primitivesMu sync.Mutex
+	// properties are the system properties given to the JDK when it boots, only the bootstrap loader has
+	// them, see `initializeSystem`.
+	properties map[string]string
+	// interned are the java/lang/String objects of the string literals and of `String.intern`, by their
+	// chars, only the bootstrap loader has them so they are the same across the VM, see `internString`.
+	interned sync.Map
+	// modules are the java/lang/Module objects of the packages the loader defines, by internal package name,
+	// unnamedModule is the one of the other packages and pendingModules are the mirrors waiting for their
+	// module. Only the bootstrap loader has them, see `defineModule0`.
+	modules        map[string]*Object
+	unnamedModule  *Object
+	pendingModules []*Object
+	modulesMu      sync.Mutex
+	// configuration has the modules resolved from the module path, whose accesses are checked when the
+	// classes are resolved. Only the bootstrap loader has it, see `checkModuleAccess`.
+	configuration *Configuration
+}
+
+// NewBootstrapClassLoader creates the root loader, which loads the platform classes from the boot class path.
+//
+// When the boot class path does not have the classes the runtime needs, like java/lang/Object or the throwables
+// it raises, minimal ones are defined so programs can be loaded without a JDK (see `syntheticClassFile`).
+func NewBootstrapClassLoader(boot *ClassPath) *ClassLoader {
+	return NewClassLoader(BootstrapLoaderName, nil, boot)
+}
+
+// NewClassLoader creates a loader that delegates to the given parent and then looks up the classes in the
+// given class path. A nil parent creates a bootstrap loader with its own cache, otherwise the cache of the
+// parent is shared.
+func NewClassLoader(name string, parent *ClassLoader, cp *ClassPath) *ClassLoader {
+	cache := NewClassCache()
+	if parent != nil {
+		cache = parent.Cache
+	}
+
+	return &ClassLoader{Name: name, Parent: parent, ClassPath: cp, Cache: cache}
+}
+
+// IsBootstrap tells if the loader is the root of its hierarchy.
+func (l *ClassLoader) IsBootstrap() bool {
+	return l.Parent == nil
+}
+
+// bootstrap returns the root of the hierarchy of the loader.
+func (l *ClassLoader) bootstrap() *ClassLoader {
+	for l.Parent != nil {
+		l = l.Parent
+	}
+
+	return l
+}
+
+// String returns the name of the loader quoted, the way it appears in error messages.
+func (l *ClassLoader) String() string {
+	if l.Name == "" {
+		return fmt.Sprintf("%p", l)
+	}
+
+	return "'" + l.Name + "'"
+}
+
+// FindLoadedClass returns the class with the given internal name if this loader is an initiating loader
+// of it, or nil otherwise.
+func (l *ClassLoader) FindLoadedClass(name string) *Class {
+	return l.Cache.loaded(classKey{l, name})
+}
+
+// LoadClass returns the class with the given internal name (e.g. com/acme/Main), first delegating to the
+// parent loader and then looking it up in the class path of this loader.
+//
+// When no loader in the hierarchy has the class, it fails with a ClassNotFoundException.
+func (l *ClassLoader) LoadClass(name string) (*Class, error) {
+	return l.loadClass(name, &loadState{})
+}
+
+// LoadClasses loads the classes with the given internal names in parallel, returning them in the same
+// order. It fails with the error of the first class, in order, that can not be loaded.
+func (l *ClassLoader) LoadClasses(names []string) ([]*Class, error) {
+	classes := make([]*Class, len(names))
+	errs := make([]error, len(names))
+
+	// the goroutines mostly wait for the filesystem, so there can be a few more than processors
+	limit := make(chan struct{}, 2*runtime.GOMAXPROCS(0))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		limit <- struct{}{}
+
+		go func(i int, name string) {
+			defer func() { <-limit; wg.Done() }()
+			classes[i], errs[i] = l.LoadClass(name)
+		}(i, name)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return classes, nil
+}
+
+func (l *ClassLoader) loadClass(name string, state *loadState) (*Class, error) {
+	key := classKey{l, name}
+
+	entry, owned, err := l.Cache.begin(key, state)
+	if err != nil {
+		return nil, err
+	}
+
+	if !owned {
+		return entry.class, entry.err
+	}
+
+	var class *Class
+	if strings.HasPrefix(name, "[") {
+		class, err = l.createArrayClass(name, state)
+	} else {
+		class, err = l.delegate(name, state)
+	}
+
+	l.Cache.complete(key, entry, class, err)
+
+	return class, err
+}
+
+// delegate asks the parent for the class and, if it does not find it, defines the class itself.
+func (l *ClassLoader) delegate(name string, state *loadState) (*Class, error) {
+	if l.Parent != nil {
+		class, err := l.Parent.loadClass(name, state)
+		if !IsJavaError(err, ClassNotFoundException) {
+			return class, err
+		}
+	}
+
+	return l.findClass(name, state)
+}
+
+// findClass reads the class from the class path of this loader and defines it.
+func (l *ClassLoader) findClass(name string, state *loadState) (*Class, error) {
+	cf, err := l.Cache.take(classKey{l, name})
+
+	if errors.Is(err, ErrClassNotFound) && l.IsBootstrap() {
+		if synthetic := syntheticClassFile(name); synthetic != nil {
+			return l.defineClassFile(name, synthetic, state)
+		}
+	}
+
+	if errors.Is(err, ErrClassNotFound) {
+		return nil, NewJavaError(ClassNotFoundException, "%s", javaName(name))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return l.defineClassFile(name, cf, state)
+}
+
+// prefetch parses the class file of a class that is about to be loaded, in the first loader of the hierarchy,
+// from the bootstrap one, that has it. It runs on its own goroutine, which `Close` waits for.
+func (l *ClassLoader) prefetch(name string) {
+	defer l.Cache.prefetching.Done()
+
+	loaders := []*ClassLoader{}
+	for loader := l; loader != nil; loader = loader.Parent {
+		loaders = append(loaders, loader)
+	}
+
+	for i := len(loaders) - 1; i >= 0; i-- {
+		if loaders[i].ClassPath != nil && l.Cache.prefetch(classKey{loaders[i], name}, l) {
+			return
+		}
+	}
+}
+
+// DefineClass parses the class file and defines the class in this loader, loading its superclass and
+// superinterfaces through this loader. It is what `ClassLoader.defineClass` does.
+//
+// The name is the expected internal name of the class, when it is empty the name in the class file is used.
+// It fails with a LinkageError when this loader already has a class with the same name.
+func (l *ClassLoader) DefineClass(name string, content []byte) (*Class, error) {
+	cf, err := l.Cache.parseBytes(name, content)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		if name, err = cf.Name(); err != nil {
+			return nil, NewJavaError(ClassFormatError, "%w", err)
+		}
+	}
+
+	state := &loadState{}
+	key := classKey{l, name}
+
+	entry, err := l.Cache.reserve(key, state)
+	if err != nil {
+		return nil, err
+	}
+
+	class, err := l.defineClassFile(name, cf, state)
+	l.Cache.complete(key, entry, class, err)
+
+	return class, err
+}
+
+func (l *ClassLoader) defineClassFile(name string, cf *ClassFile, state *loadState) (*Class, error) {
+	actual, err := cf.Name()
+	if err != nil {
+		return nil, NewJavaError(ClassFormatError, "%s: %w", name, err)
+	}
+
+	if actual != name {
+		return nil, NewJavaError(NoClassDefFoundError, "%s (wrong name: %s)", name, actual)
+	}
+
+	if cf.MajorVersion > MaxMajorVersion {
+		return nil, NewJavaError(
+			UnsupportedClassVersionError,
+			"%s has been compiled by a more recent version of the Java Runtime (class file version %d.%d), "+
+				"this version of the Java Runtime only recognizes class file versions up to %d.0",
+			javaName(name), cf.MajorVersion, cf.MinorVersion, MaxMajorVersion,
+		)
+	}
+
+	// only the bootstrap loader can define the classes of the java.* packages
+	if !l.IsBootstrap() && strings.HasPrefix(name, "java/") {
+		return nil, NewJavaError(SecurityException, "Prohibited package name: %s", javaName(name[:strings.LastIndex(name, "/")]))
+	}
+
+	// the superinterfaces are parsed in the background while the superclass is loaded
+	for _, index := range cf.Interfaces {
+		if iface, err := cf.ClassName(index); err == nil {
+			l.Cache.prefetching.Add(1)
+			go l.prefetch(iface)
+		}
+	}
+
+	return l.linkHierarchy(name, cf, state)
+}
+
+// linkHierarchy creates the class, loading its superclass and superinterfaces, and prepares it.
+func (l *ClassLoader) linkHierarchy(name string, cf *ClassFile, state *loadState) (*Class, error) {
+	class := &Class{Name: name, File: cf, Loader: l, AccessFlags: cf.AccessFlags}
+
+	if cf.SuperClass == 0 {
+		if name != "java/lang/Object" && cf.AccessFlags&ACC_MODULE == 0 {
+			return nil, NewJavaError(ClassFormatError, "Invalid superclass index 0 in class file %s", name)
+		}
+	} else {
+		super, err := l.resolveSuper(cf, cf.SuperClass, state)
+		if err != nil {
+			return nil, err
+		}
+
+		if super.IsInterface() {
+			return nil, NewJavaError(
+				IncompatibleClassChangeError, "class %s has interface %s as super class", class.JavaName(), super.JavaName(),
+			)
+		}
+
+		if super.AccessFlags&ACC_FINAL != 0 {
+			return nil, NewJavaError(VerifyError, "Cannot inherit from final class")
+		}
+
+		class.Super = super
+	}
+
+	for _, index := range cf.Interfaces {
+		iface, err := l.resolveSuper(cf, index, state)
+		if err != nil {
+			return nil, err
+		}
+
+		if !iface.IsInterface() {
+			return nil, NewJavaError(
+				IncompatibleClassChangeError,
+				"class %s can not implement %s, because it is not an interface", class.JavaName(), iface.JavaName(),
+			)
+		}
+
+		class.Interfaces = append(class.Interfaces, iface)
+	}
+
+	if err := class.prepare(); err != nil {
+		return nil, err
+	}
+
+	return class, nil
+}
+
+// resolveSuper loads the superclass or superinterface at the given constant pool index.
+func (l *ClassLoader) resolveSuper(cf *ClassFile, index uint16, state *loadState) (*Class, error) {
+	name, err := cf.ClassName(index)
+	if err != nil {
+		return nil, NewJavaError(ClassFormatError, "%w", err)
+	}
+
+	class, err := l.loadClass(name, state)
+	if IsJavaError(err, ClassNotFoundException) {
+		return nil, &JavaError{ClassName: NoClassDefFoundError, Message: name, Cause: err}
+	}
+
+	return class, err
+}
+
+// Close closes the class path of the loader, once the class files being prefetched have been read.
+func (l *ClassLoader) Close() error {
+	l.Cache.prefetching.Wait()
+
+	if l.ClassPath == nil {
+		return nil
+	}
+
+	return l.ClassPath.Close()
+}
+
+// ClassLoaders are the built-in class loaders: the bootstrap loader, which loads the platform classes,
+// the platform loader and the application loader, which loads the classes of the modules and class path
+// of the application.
+type ClassLoaders struct {
+	Bootstrap   *ClassLoader
+	Platform    *ClassLoader
+	Application *ClassLoader
+}
+
+// NewClassLoaders creates the built-in class loaders with the class paths of each one, any of them can be nil.
+func NewClassLoaders(boot *ClassPath, platform *ClassPath, app *ClassPath) *ClassLoaders {
+	bootstrap := NewBootstrapClassLoader(boot)
+	platformLoader := NewClassLoader(PlatformLoaderName, bootstrap, platform)
+
+	return &ClassLoaders{
+		Bootstrap:   bootstrap,
+		Platform:    platformLoader,
+		Application: NewClassLoader(ApplicationLoaderName, platformLoader, app),
+	}
+}
+
+// Close closes the class paths of the built-in loaders.
+func (l *ClassLoaders) Close() error {
+	return errors.Join(l.Application.Close(), l.Platform.Close(), l.Bootstrap.Close())
+}
@@ -0,0 +1,234 @@
+package core_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// classFileWithHierarchy builds a class file with the given access flags, superclass and interfaces.
+func classFileWithHierarchy(access uint16, name string, super string, interfaces ...string) []byte {
+	return newTestClassBuilder().build(access, name, super, interfaces, nil, nil, nil)
+}
+
+func newTestLoaders(t *testing.T, app string) *core.ClassLoaders {
+	cp, err := core.ParseClassPath(app)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	loaders := core.NewClassLoaders(nil, nil, cp)
+	t.Cleanup(func() { loaders.Close() })
+
+	return loaders
+}
+
+func TestShouldDelegateToTheParentLoaderFirst(t *testing.T) {
+	parentDir, childDir := t.TempDir(), t.TempDir()
+	writeClassFile(t, parentDir, "com/acme/Foo", minimalClassFile("com/acme/Foo"))
+	writeClassFile(t, childDir, "com/acme/Foo", minimalClassFile("com/acme/Foo"))
+	writeClassFile(t, childDir, "com/acme/Bar", minimalClassFile("com/acme/Bar"))
+
+	loaders := newTestLoaders(t, parentDir)
+	child := core.NewClassLoader("child", loaders.Application, core.NewClassPath(&core.DirClassPathEntry{Dir: childDir}))
+
+	foo, err := child.LoadClass("com/acme/Foo")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if foo.Loader != loaders.Application {
+		t.Errorf("Expected com/acme/Foo to be defined by the parent, got %v", foo.Loader)
+	}
+
+	if child.FindLoadedClass("com/acme/Foo") != foo || loaders.Application.FindLoadedClass("com/acme/Foo") != foo {
+		t.Errorf("Expected both loaders to be initiating loaders of com/acme/Foo")
+	}
+
+	bar, err := child.LoadClass("com/acme/Bar")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if bar.Loader != child || loaders.Application.FindLoadedClass("com/acme/Bar") != nil {
+		t.Errorf("Expected com/acme/Bar to be defined by the child only, got %v", bar)
+	}
+
+	object, _ := loaders.Application.LoadClass("java/lang/Object")
+	if bar.Super != object || object.Loader != loaders.Bootstrap {
+		t.Errorf("Expected java/lang/Object to be the superclass, defined by the bootstrap loader, got %v", bar.Super)
+	}
+}
+
+func TestShouldIsolateClassesOfSiblingLoaders(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	writeJarFile(t, filepath.Join(first, "plugin.jar"), map[string][]byte{"Plugin.class": minimalClassFile("Plugin")})
+	writeClassFile(t, second, "Plugin", minimalClassFile("Plugin"))
+
+	loaders := newTestLoaders(t, t.TempDir())
+
+	cp, err := core.ParseClassPath(filepath.Join(first, "plugin.jar"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	a := core.NewClassLoader("a", loaders.Application, cp)
+	b := core.NewClassLoader("b", loaders.Application, core.NewClassPath(&core.DirClassPathEntry{Dir: second}))
+	defer a.Close()
+
+	pluginA, errA := a.LoadClass("Plugin")
+	pluginB, errB := b.LoadClass("Plugin")
+	if errA != nil || errB != nil {
+		t.Fatalf("Expected no error, got %v and %v", errA, errB)
+	}
+
+	if pluginA == pluginB || pluginA.Loader != a || pluginB.Loader != b {
+		t.Errorf("Expected two distinct classes, got %v and %v", pluginA, pluginB)
+	}
+
+	again, _ := a.LoadClass("Plugin")
+	if again != pluginA {
+		t.Errorf("Expected the same class to be returned when loading it again")
+	}
+
+	if pluginA.Super != pluginB.Super {
+		t.Errorf("Expected both classes to share java/lang/Object")
+	}
+}
+
+func TestShouldDefineClassesFromBytes(t *testing.T) {
+	loaders := newTestLoaders(t, t.TempDir())
+	loader := core.NewClassLoader("", loaders.Application, nil)
+
+	class, err := loader.DefineClass("", minimalClassFile("com/acme/Generated"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if class.Name != "com/acme/Generated" || class.JavaName() != "com.acme.Generated" || class.Loader != loader {
+		t.Errorf("Expected com/acme/Generated defined by the loader, got %v", class)
+	}
+
+	loaded, err := loader.LoadClass("com/acme/Generated")
+	if err != nil || loaded != class {
+		t.Errorf("Expected the defined class to be loaded, got %v, %v", loaded, err)
+	}
+
+	_, err = loader.DefineClass("com/acme/Generated", minimalClassFile("com/acme/Generated"))
+	if !core.IsJavaError(err, core.LinkageError) {
+		t.Errorf("Expected LinkageError, got %v", err)
+	}
+
+	_, err = loader.DefineClass("com/acme/Other", minimalClassFile("com/acme/Generated"))
+	if !core.IsJavaError(err, core.NoClassDefFoundError) || err.Error() != "java.lang.NoClassDefFoundError: com/acme/Other (wrong name: com/acme/Generated)" {
+		t.Errorf("Expected NoClassDefFoundError, got %v", err)
+	}
+
+	_, err = loader.DefineClass("", []byte{0xCA, 0xFE})
+	if !core.IsJavaError(err, core.ClassFormatError) {
+		t.Errorf("Expected ClassFormatError, got %v", err)
+	}
+
+	_, err = loader.DefineClass("", minimalClassFile("java/lang/Evil"))
+	if err == nil || err.Error() != "java.lang.SecurityException: Prohibited package name: java.lang" {
+		t.Errorf("Expected SecurityException, got %v", err)
+	}
+}
+
+func TestShouldFailToLoadMissingClasses(t *testing.T) {
+	dir := t.TempDir()
+	writeClassFile(t, dir, "com/acme/Child", classFileWithHierarchy(core.ACC_PUBLIC, "com/acme/Child", "com/acme/Missing"))
+
+	loaders := newTestLoaders(t, dir)
+
+	_, err := loaders.Application.LoadClass("com/acme/Nope")
+	if err == nil || err.Error() != "java.lang.ClassNotFoundException: com.acme.Nope" {
+		t.Errorf("Expected ClassNotFoundException, got %v", err)
+	}
+
+	_, err = loaders.Application.LoadClass("com/acme/Child")
+	if !core.IsJavaError(err, core.NoClassDefFoundError) || !core.IsJavaError(err, core.ClassNotFoundException) {
+		t.Errorf("Expected NoClassDefFoundError caused by ClassNotFoundException, got %v", err)
+	}
+
+	if err.Error() != "java.lang.NoClassDefFoundError: com/acme/Missing" {
+		t.Errorf("Expected 'java.lang.NoClassDefFoundError: com/acme/Missing', got %v", err)
+	}
+
+	if loaders.Application.FindLoadedClass("com/acme/Child") != nil {
+		t.Errorf("Expected com/acme/Child not to be loaded")
+	}
+}
+
+func TestShouldCheckTheClassHierarchy(t *testing.T) {
+	dir := t.TempDir()
+	iface := core.ACC_PUBLIC | core.ACC_INTERFACE | core.ACC_ABSTRACT
+
+	writeClassFile(t, dir, "A", classFileWithHierarchy(core.ACC_PUBLIC, "A", "B"))
+	writeClassFile(t, dir, "B", classFileWithHierarchy(core.ACC_PUBLIC, "B", "A"))
+	writeClassFile(t, dir, "Iface", classFileWithHierarchy(iface, "Iface", "java/lang/Object"))
+	writeClassFile(t, dir, "Final", classFileWithHierarchy(core.ACC_PUBLIC|core.ACC_FINAL, "Final", "java/lang/Object"))
+	writeClassFile(t, dir, "ExtendsIface", classFileWithHierarchy(core.ACC_PUBLIC, "ExtendsIface", "Iface"))
+	writeClassFile(t, dir, "ExtendsFinal", classFileWithHierarchy(core.ACC_PUBLIC, "ExtendsFinal", "Final"))
+	writeClassFile(t, dir, "ImplementsClass", classFileWithHierarchy(core.ACC_PUBLIC, "ImplementsClass", "java/lang/Object", "Final"))
+	writeClassFile(t, dir, "Impl", classFileWithHierarchy(core.ACC_PUBLIC, "Impl", "java/lang/Object", "Iface"))
+
+	loaders := newTestLoaders(t, dir)
+
+	if _, err := loaders.Application.LoadClass("A"); !core.IsJavaError(err, core.ClassCircularityError) {
+		t.Errorf("Expected ClassCircularityError, got %v", err)
+	}
+
+	_, err := loaders.Application.LoadClass("ExtendsIface")
+	if err == nil || err.Error() != "java.lang.IncompatibleClassChangeError: class ExtendsIface has interface Iface as super class" {
+		t.Errorf("Expected IncompatibleClassChangeError, got %v", err)
+	}
+
+	if _, err := loaders.Application.LoadClass("ExtendsFinal"); !core.IsJavaError(err, core.VerifyError) {
+		t.Errorf("Expected VerifyError, got %v", err)
+	}
+
+	_, err = loaders.Application.LoadClass("ImplementsClass")
+	if err == nil || err.Error() != "java.lang.IncompatibleClassChangeError: class ImplementsClass can not implement Final, because it is not an interface" {
+		t.Errorf("Expected IncompatibleClassChangeError, got %v", err)
+	}
+
+	impl, err := loaders.Application.LoadClass("Impl")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(impl.Interfaces) != 1 || !impl.Interfaces[0].IsInterface() || !impl.IsSubclassOf(impl.Interfaces[0]) {
+		t.Errorf("Expected Impl to implement Iface, got %v", impl.Interfaces)
+	}
+}
+
+func TestShouldRejectNewerClassFileVersions(t *testing.T) {
+	content := minimalClassFile("Future")
+	content[7] = core.MaxMajorVersion + 1
+
+	loaders := newTestLoaders(t, t.TempDir())
+
+	_, err := loaders.Application.DefineClass("Future", content)
+	if !core.IsJavaError(err, core.UnsupportedClassVersionError) {
+		t.Errorf("Expected UnsupportedClassVersionError, got %v", err)
+	}
+}
+
+func TestShouldDefineASyntheticObjectWithoutABootClassPath(t *testing.T) {
+	loader := core.NewBootstrapClassLoader(nil)
+
+	object, err := loader.LoadClass("java/lang/Object")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if object.Super != nil || !loader.IsBootstrap() || object.Loader != loader {
+		t.Errorf("Expected java/lang/Object without superclass, got %v", object)
+	}
+
+	if _, err := loader.LoadClass("java/util/Optional"); !core.IsJavaError(err, core.ClassNotFoundException) {
+		t.Errorf("Expected ClassNotFoundException, got %v", err)
+	}
+}
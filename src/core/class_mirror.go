@@ -0,0 +1,296 @@
+package core
+
+import "sync"
+
+// Spec: https://docs.oracle.com/en/java/javase/21/docs/api/java.base/java/lang/Class.html
+
+// classClass is the internal name of the class of the objects that represent classes and interfaces.
+const classClass = "java/lang/Class"
+
+// Mirror returns the java/lang/Class object that represents the class, loaded by the bootstrap loader of its
+// loader. It is created the first time it is asked for, so `ldc`, `Object.getClass` and the synchronized static
+// methods of the class all get the same object.
+func (c *Class) Mirror() (*Object, error) {
+	c.mirrorMu.Lock()
+	defer c.mirrorMu.Unlock()
+
+	if c.mirror != nil {
+		return c.mirror, nil
+	}
+
+	bootstrap := c.Loader.bootstrap()
+
+	class, err := bootstrap.LoadClass(classClass)
+	if err != nil {
+		return nil, err
+	}
+
+	mirror := NewObject(class)
+	mirror.state = c
+
+	if c.IsArray() {
+		component := c.ComponentType
+		if component == nil {
+			component = bootstrap.primitiveClass(c.Name[1:])
+		}
+
+		componentMirror, err := component.Mirror()
+		if err != nil {
+			return nil, err
+		}
+
+		if field := class.DeclaredField("componentType", "Ljava/lang/Class;"); field != nil {
+			mirror.Fields[field.Slot].Ref = componentMirror
+		}
+	}
+
+	c.setMirrorModule(mirror)
+	c.mirror = mirror
+
+	return mirror, nil
+}
+
+// mirroredClass returns the class a java/lang/Class object represents.
+func mirroredClass(mirror *Object) *Class {
+	class, _ := mirror.state.(*Class)
+	return class
+}
+
+// IsPrimitive tells if the class is the one of a primitive type, or of void.
+func (c *Class) IsPrimitive() bool {
+	return c.primitive
+}
+
+// primitiveClass returns the class of the primitive type, or of void, with the given descriptor. They are
+// only known to the bootstrap loader, which defines them the first time they are asked for.
+func (l *ClassLoader) primitiveClass(descriptor string) *Class {
+	l.primitivesMu.Lock()
+	defer l.primitivesMu.Unlock()
+
+	if class, ok := l.primitives[descriptor]; ok {
+		return class
+	}
+
+	class := &Class{Name: TypeName(descriptor), Loader: l, AccessFlags: ACC_PUBLIC | ACC_FINAL | ACC_ABSTRACT, primitive: true}
+	class.init.cond = sync.NewCond(&class.init.mu)
+	class.init.state = ClassInitialized
+
+	if l.primitives == nil {
+		l.primitives = make(map[string]*Class)
+	}
+
+	l.primitives[descriptor] = class
+
+	return class
+}
+
+// primitiveDescriptors are the descriptors of the primitive types and of void, by name.
+var primitiveDescriptors = map[string]string{
+	"boolean": "Z", "byte": "B", "char": "C", "short": "S", "int": "I", "long": "J", "float": "F", "double": "D", "void": "V",
+}
+
+// mirrorSlots returns the slot of the java/lang/Class object of a class, or of null when there is no class,
+// returned by a native method.
+func mirrorSlots(class *Class) ([]Slot, error) {
+	if class == nil {
+		return []Slot{{}}, nil
+	}
+
+	mirror, err := class.Mirror()
+
+	return []Slot{{Ref: mirror}}, err
+}
+
+func init() {
+	addLibraryClasses(&libraryClass{
+		name:   classClass,
+		super:  objectClass,
+		access: ACC_PUBLIC | ACC_FINAL,
+		methods: []libraryMethod{
+			publicMethod("getName", "()Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+				return call.returnString(utf16Chars(mirroredClass(call.This()).JavaName()))
+			}),
+			publicMethod("toString", "()Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+				class := mirroredClass(call.This())
+
+				kind := "class "
+				switch {
+				case class.IsPrimitive():
+					kind = ""
+				case class.IsInterface():
+					kind = "interface "
+				}
+
+				return call.returnString(utf16Chars(kind + class.JavaName()))
+			}),
+			publicMethod("getComponentType", "()Ljava/lang/Class;", func(call *NativeCall) ([]Slot, error) {
+				class := mirroredClass(call.This())
+				if !class.IsArray() {
+					return []Slot{{}}, nil
+				}
+
+				if class.ComponentType == nil {
+					return mirrorSlots(class.Loader.bootstrap().primitiveClass(class.Name[1:]))
+				}
+
+				return mirrorSlots(class.ComponentType)
+			}),
+			publicMethod("desiredAssertionStatus", "()Z", func(call *NativeCall) ([]Slot, error) {
+				return boolSlots(false), nil
+			}),
+			publicMethod("isArray", "()Z", classIsArray),
+			publicMethod("isInterface", "()Z", classIsInterface),
+			publicMethod("isPrimitive", "()Z", classIsPrimitive),
+			publicMethod("isInstance", "(Ljava/lang/Object;)Z", classIsInstance),
+			publicMethod("isAssignableFrom", "(Ljava/lang/Class;)Z", classIsAssignableFrom),
+			publicMethod("getSuperclass", "()Ljava/lang/Class;", classGetSuperclass),
+			publicMethod("getModifiers", "()I", classGetModifiers),
+		},
+	})
+}
+
+// classNatives are the native methods of java/lang/Class of the JDK that the built-in class library does not
+// have, the others are registered with the built-in java/lang/Class.
+var classNatives = map[string]NativeMethod{
+	"registerNatives()V": func(call *NativeCall) ([]Slot, error) {
+		return nil, nil
+	},
+	"getPrimitiveClass(Ljava/lang/String;)Ljava/lang/Class;": func(call *NativeCall) ([]Slot, error) {
+		descriptor, ok := primitiveDescriptors[call.String(0)]
+		if !ok {
+			return []Slot{{}}, nil
+		}
+
+		return mirrorSlots(call.Method.Class.Loader.bootstrap().primitiveClass(descriptor))
+	},
+	// assertions are disabled, `-ea` is not supported
+	"desiredAssertionStatus0(Ljava/lang/Class;)Z": func(call *NativeCall) ([]Slot, error) {
+		return boolSlots(false), nil
+	},
+	"isHidden()Z": func(call *NativeCall) ([]Slot, error) {
+		return boolSlots(false), nil
+	},
+	// initClassName caches the name in the `name` field, like HotSpot does
+	"initClassName()Ljava/lang/String;": func(call *NativeCall) ([]Slot, error) {
+		obj := call.This()
+
+		name, err := call.NewString(mirroredClass(obj).JavaName())
+		if err != nil {
+			return nil, err
+		}
+
+		if field := obj.Class.DeclaredField("name", "Ljava/lang/String;"); field != nil {
+			obj.Fields[field.Slot].Ref = name
+		}
+
+		return []Slot{{Ref: name}}, nil
+	},
+	"getInterfaces0()[Ljava/lang/Class;": func(call *NativeCall) ([]Slot, error) {
+		class := mirroredClass(call.This())
+
+		mirrors := make([]*Object, len(class.Interfaces))
+		for i, iface := range class.Interfaces {
+			mirror, err := iface.Mirror()
+			if err != nil {
+				return nil, err
+			}
+
+			mirrors[i] = mirror
+		}
+
+		arrayClass, err := call.This().Class.ArrayClass()
+		if err != nil {
+			return nil, err
+		}
+
+		array := NewArray(arrayClass, len(mirrors))
+		copy(array.Elements.([]*Object), mirrors)
+
+		return []Slot{{Ref: array}}, nil
+	},
+	// forName0 loads the class with the loader of the class asking for it, since class loaders have no Java object
+	"forName0(Ljava/lang/String;ZLjava/lang/ClassLoader;Ljava/lang/Class;)Ljava/lang/Class;": func(call *NativeCall) ([]Slot, error) {
+		if call.Ref(0) == nil {
+			return nil, &JavaError{ClassName: NullPointerException}
+		}
+
+		name := call.String(0)
+
+		loader := call.Method.Class.Loader.bootstrap()
+		if call.Ref(2) != nil && call.Ref(3) != nil {
+			loader = mirroredClass(call.Ref(3)).Loader
+		}
+
+		class, err := loader.LoadClass(BinaryNameToInternal(name))
+		if IsJavaError(err, ClassNotFoundException) {
+			return nil, NewJavaError(ClassNotFoundException, "%s", name)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if call.Bool(1) {
+			if err := class.Initialize(call.Thread); err != nil {
+				return nil, err
+			}
+		}
+
+		return mirrorSlots(class)
+	},
+}
+
+func classIsArray(call *NativeCall) ([]Slot, error) {
+	return boolSlots(mirroredClass(call.This()).IsArray()), nil
+}
+
+func classIsInterface(call *NativeCall) ([]Slot, error) {
+	return boolSlots(mirroredClass(call.This()).IsInterface()), nil
+}
+
+func classIsPrimitive(call *NativeCall) ([]Slot, error) {
+	return boolSlots(mirroredClass(call.This()).IsPrimitive()), nil
+}
+
+func classIsInstance(call *NativeCall) ([]Slot, error) {
+	obj := call.Ref(0)
+	return boolSlots(obj != nil && obj.IsInstanceOf(mirroredClass(call.This()))), nil
+}
+
+// classIsAssignableFrom tells if the class is the given class, or a superclass or superinterface of it. The
+// classes of primitive types are only assignable from themselves.
+func classIsAssignableFrom(call *NativeCall) ([]Slot, error) {
+	if call.Ref(0) == nil {
+		return nil, &JavaError{ClassName: NullPointerException}
+	}
+
+	class, other := mirroredClass(call.This()), mirroredClass(call.Ref(0))
+	if class.IsPrimitive() || other.IsPrimitive() {
+		return boolSlots(class == other), nil
+	}
+
+	return boolSlots(other.IsSubclassOf(class)), nil
+}
+
+// classGetSuperclass returns the superclass of the class, or null for java/lang/Object, interfaces and
+// the classes of primitive types.
+func classGetSuperclass(call *NativeCall) ([]Slot, error) {
+	class := mirroredClass(call.This())
+	if class.IsInterface() {
+		return []Slot{{}}, nil
+	}
+
+	return mirrorSlots(class.Super)
+}
+
+// classGetModifiers returns the access flags of the class that are modifiers of the Java language, the ones
+// of its InnerClasses entry are not looked up.
+func classGetModifiers(call *NativeCall) ([]Slot, error) {
+	flags := mirroredClass(call.This()).AccessFlags
+	return []Slot{{Num: int32(flags &^ ACC_SUPER)}}, nil
+}
+
+// objectGetClass returns the java/lang/Class object of the class of the object.
+func objectGetClass(call *NativeCall) ([]Slot, error) {
+	return mirrorSlots(call.This().Class)
+}
@@ -0,0 +1,53 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+func TestShouldShareTheClassObjectOfAClass(t *testing.T) {
+	b := newTestClassBuilder()
+	self := b.class("Mirrors")
+	ints := b.class("[I")
+	getClass := b.methodref("java/lang/Object", "getClass", "()Ljava/lang/Class;")
+	getComponentType := b.methodref("java/lang/Class", "getComponentType", "()Ljava/lang/Class;")
+
+	class := defineTestClass(t, b.build(core.ACC_PUBLIC, "Mirrors", "java/lang/Object", nil, nil,
+		[][]byte{
+			b.member(core.ACC_PUBLIC|core.ACC_STATIC, "self", "()Ljava/lang/Class;", b.code(1, 0,
+				core.OP_LDC, byte(self), core.OP_ARETURN,
+			)),
+			b.member(core.ACC_PUBLIC|core.ACC_STATIC, "of", "(Ljava/lang/Object;)Ljava/lang/Class;", b.code(1, 1, concat(
+				[]byte{core.OP_ALOAD_0, core.OP_INVOKEVIRTUAL}, u2(getClass), []byte{core.OP_ARETURN},
+			)...)),
+			b.member(core.ACC_PUBLIC|core.ACC_STATIC, "intClass", "()Ljava/lang/Class;", b.code(1, 0, concat(
+				[]byte{core.OP_LDC, byte(ints), core.OP_INVOKEVIRTUAL}, u2(getComponentType), []byte{core.OP_ARETURN},
+			)...)),
+		},
+		nil,
+	))
+
+	mirror, err := class.Mirror()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if ret, err := invokeSlots(t, class, "self", "()Ljava/lang/Class;"); err != nil || ret[0].Ref != mirror {
+		t.Errorf("Expected ldc to push the class object of the class, got %v (%v)", ret, err)
+	}
+
+	if ret, err := invokeSlots(t, class, "of", "(Ljava/lang/Object;)Ljava/lang/Class;", []core.Slot{{Ref: core.NewObject(class)}}); err != nil || ret[0].Ref != mirror {
+		t.Errorf("Expected getClass to return the class object of the class, got %v (%v)", ret, err)
+	}
+
+	ret, err := invokeSlots(t, class, "intClass", "()Ljava/lang/Class;")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	name, err := core.NewThread(core.MainThreadName).Invoke(ret[0].Ref.Class.DeclaredMethod("getName", "()Ljava/lang/String;"), ret)
+	if err != nil || core.GoString(name[0].Ref) != "int" {
+		t.Errorf("Expected the component type of int[] to be int, got %v (%v)", name, err)
+	}
+}
@@ -0,0 +1,246 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var ErrClassNotFound = errors.New("class not found")
+
+// ClassPathEntry is a place where classes can be looked up, like a directory or a jar file.
+type ClassPathEntry interface {
+	// ReadClass reads the bytes of the class with the given internal name (e.g. com/acme/Main).
+	//
+	// If the entry does not have the class, the returned error wraps `ErrClassNotFound`.
+	ReadClass(name string) ([]byte, error)
+	// Close releases any resource held by the entry.
+	Close() error
+	// String returns the path of the entry, as it was given in the class path.
+	String() string
+}
+
+// DirClassPathEntry is a directory containing classes laid out by package, so com/acme/Main is
+// read from <dir>/com/acme/Main.class.
+type DirClassPathEntry struct {
+	Dir string
+}
+
+func (e *DirClassPathEntry) ReadClass(name string) ([]byte, error) {
+	path := filepath.Join(e.Dir, filepath.FromSlash(name)+".class")
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrClassNotFound, name)
+	}
+
+	return content, err
+}
+
+func (e *DirClassPathEntry) Close() error {
+	return nil
+}
+
+func (e *DirClassPathEntry) String() string {
+	return e.Dir
+}
+
+// JarClassPathEntry is a jar file in the class path.
+type JarClassPathEntry struct {
+	Jar *JarFile
+}
+
+func (e *JarClassPathEntry) ReadClass(name string) ([]byte, error) {
+	if !e.Jar.HasEntry(name + ".class") {
+		return nil, fmt.Errorf("%w: %s", ErrClassNotFound, name)
+	}
+
+	return e.Jar.ReadClass(name)
+}
+
+func (e *JarClassPathEntry) Close() error {
+	return e.Jar.Close()
+}
+
+func (e *JarClassPathEntry) String() string {
+	return e.Jar.Path
+}
+
+// ClassPath is an ordered list of entries where classes are looked up.
+type ClassPath struct {
+	Entries []ClassPathEntry
+}
+
+// NewClassPath creates a class path with the given entries.
+func NewClassPath(entries ...ClassPathEntry) *ClassPath {
+	return &ClassPath{Entries: entries}
+}
+
+// ParseClassPath creates a class path from a list of paths separated by the OS path list separator,
+// the same format used by `-cp` and the `CLASSPATH` environment variable.
+//
+// A path ending with `*` is expanded to all the jar files of that directory, paths that do not exist
+// are ignored and the jars listed in the `Class-Path` attribute of a jar manifest are added right
+// after it.
+func ParseClassPath(classPath string) (*ClassPath, error) {
+	return ParseClassPathWithRelease(classPath, DefaultRelease)
+}
+
+// ParseClassPathWithRelease works like `ParseClassPath`, but the jars select their versioned entries
+// according to the given runtime release.
+func ParseClassPathWithRelease(classPath string, release int) (*ClassPath, error) {
+	cp := NewClassPath()
+	seen := make(map[string]bool)
+
+	for _, path := range strings.Split(classPath, string(os.PathListSeparator)) {
+		// an empty path means the current directory
+		if path == "" {
+			path = "."
+		}
+
+		paths, err := expandClassPathWildcard(path)
+		if err != nil {
+			cp.Close()
+			return nil, err
+		}
+
+		for _, p := range paths {
+			cp.addPath(p, release, seen)
+		}
+	}
+
+	return cp, nil
+}
+
+// expandClassPathWildcard expands a `dir/*` path to all the jar files in `dir`, other paths are returned as is.
+func expandClassPathWildcard(path string) ([]string, error) {
+	if filepath.Base(path) != "*" {
+		return []string{path}, nil
+	}
+
+	dir := filepath.Dir(path)
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	jars := []string{}
+	for _, f := range files {
+		if !f.IsDir() && strings.EqualFold(filepath.Ext(f.Name()), ".jar") {
+			jars = append(jars, filepath.Join(dir, f.Name()))
+		}
+	}
+
+	sort.Strings(jars)
+
+	return jars, nil
+}
+
+// addPath adds the directory or jar file in the path to the class path, following the `Class-Path`
+// attribute of jar files. Like the JDK, the paths that do not exist or are not jar files are ignored.
+func (cp *ClassPath) addPath(path string, release int, seen map[string]bool) {
+	clean := filepath.Clean(path)
+	if seen[clean] {
+		return
+	}
+
+	seen[clean] = true
+
+	info, err := os.Stat(clean)
+	if err != nil {
+		return
+	}
+
+	if info.IsDir() {
+		cp.Entries = append(cp.Entries, &DirClassPathEntry{Dir: clean})
+		return
+	}
+
+	jar, err := OpenJarFileWithRelease(clean, release)
+	if err != nil {
+		return
+	}
+
+	cp.Entries = append(cp.Entries, &JarClassPathEntry{Jar: jar})
+
+	for _, dependency := range jar.ClassPath() {
+		cp.addPath(dependency, release, seen)
+	}
+}
+
+// Add appends entries to the end of the class path.
+func (cp *ClassPath) Add(entries ...ClassPathEntry) {
+	cp.Entries = append(cp.Entries, entries...)
+}
+
+// ReadClass reads the bytes of the class with the given internal name from the first entry that has it.
+func (cp *ClassPath) ReadClass(name string) ([]byte, ClassPathEntry, error) {
+	for _, entry := range cp.Entries {
+		content, err := entry.ReadClass(name)
+		if errors.Is(err, ErrClassNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return nil, entry, err
+		}
+
+		return content, entry, nil
+	}
+
+	return nil, nil, fmt.Errorf("%w: %s", ErrClassNotFound, name)
+}
+
+// FindClass reads and parses the class with the given internal name (e.g. com/acme/Main).
+//
+// It fails if the class file found defines a class with a different name.
+func (cp *ClassPath) FindClass(name string) (ClassFile, error) {
+	content, entry, err := cp.ReadClass(name)
+	if err != nil {
+		return ClassFile{}, err
+	}
+
+	cf, err := ClassFileFromReader(bytes.NewReader(content))
+	if err != nil {
+		return cf, fmt.Errorf("error reading class %s from %s: %w", name, entry, err)
+	}
+
+	actual, err := cf.Name()
+	if err != nil {
+		return cf, err
+	}
+
+	if actual != name {
+		return cf, fmt.Errorf("%s (wrong name: %s)", name, actual)
+	}
+
+	return cf, nil
+}
+
+// Close closes all the entries of the class path.
+func (cp *ClassPath) Close() error {
+	var errs []error
+	for _, entry := range cp.Entries {
+		errs = append(errs, entry.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// String returns the class path in the same format accepted by `ParseClassPath`.
+func (cp *ClassPath) String() string {
+	paths := make([]string, len(cp.Entries))
+	for i, entry := range cp.Entries {
+		paths[i] = entry.String()
+	}
+
+	return strings.Join(paths, string(os.PathListSeparator))
+}
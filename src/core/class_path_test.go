@@ -0,0 +1,254 @@
+package core_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// writeClassFile writes the class in its package directory under the given root.
+func writeClassFile(t *testing.T, root string, name string, content []byte) {
+	path := filepath.Join(root, filepath.FromSlash(name)+".class")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Error creating package directory: %v", err)
+	}
+
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatalf("Error writing class file: %v", err)
+	}
+}
+
+func TestShouldFindClassesInDirectories(t *testing.T) {
+	dir := t.TempDir()
+	writeClassFile(t, dir, "com/acme/Foo", minimalClassFile("com/acme/Foo"))
+
+	cp, err := core.ParseClassPath(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	defer cp.Close()
+
+	cf, err := cp.FindClass("com/acme/Foo")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if name, _ := cf.Name(); name != "com/acme/Foo" {
+		t.Errorf("Expected class com/acme/Foo, got %s", name)
+	}
+
+	if _, err := cp.FindClass("com/acme/Bar"); !errors.Is(err, core.ErrClassNotFound) {
+		t.Errorf("Expected ErrClassNotFound, got %v", err)
+	}
+}
+
+func TestShouldFailIfTheClassFileHasTheWrongName(t *testing.T) {
+	dir := t.TempDir()
+	writeClassFile(t, dir, "com/acme/Foo", minimalClassFile("com/acme/Bar"))
+
+	cp, _ := core.ParseClassPath(dir)
+	defer cp.Close()
+
+	if _, err := cp.FindClass("com/acme/Foo"); err == nil || err.Error() != "com/acme/Foo (wrong name: com/acme/Bar)" {
+		t.Errorf("Expected wrong name error, got %v", err)
+	}
+}
+
+func TestShouldSearchTheClassPathEntriesInOrder(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	writeClassFile(t, second, "Foo", minimalClassFile("Foo"))
+	writeJarFile(t, filepath.Join(first, "foo.jar"), map[string][]byte{"Foo.class": minimalClassFile("Foo")})
+
+	sep := string(os.PathListSeparator)
+	cp, err := core.ParseClassPath(filepath.Join(first, "missing") + sep + filepath.Join(first, "foo.jar") + sep + second)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	defer cp.Close()
+
+	if len(cp.Entries) != 2 {
+		t.Fatalf("Expected the missing entry to be ignored, got %d entries", len(cp.Entries))
+	}
+
+	_, entry, err := cp.ReadClass("Foo")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if entry.String() != filepath.Join(first, "foo.jar") {
+		t.Errorf("Expected Foo to be read from the jar, got %s", entry)
+	}
+}
+
+func TestShouldExpandClassPathWildcards(t *testing.T) {
+	dir := t.TempDir()
+	writeJarFile(t, filepath.Join(dir, "b.jar"), map[string][]byte{"B.class": minimalClassFile("B")})
+	writeJarFile(t, filepath.Join(dir, "a.JAR"), map[string][]byte{"A.class": minimalClassFile("A")})
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a jar"), 0o644)
+
+	cp, err := core.ParseClassPath(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	defer cp.Close()
+
+	if len(cp.Entries) != 2 {
+		t.Fatalf("Expected 2 jars, got %d entries", len(cp.Entries))
+	}
+
+	for _, name := range []string{"A", "B"} {
+		if _, err := cp.FindClass(name); err != nil {
+			t.Errorf("Expected to find %s, got %v", name, err)
+		}
+	}
+}
+
+func TestShouldFollowTheClassPathOfJarManifests(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "lib"), 0o755)
+	writeJarFile(t, filepath.Join(dir, "lib", "dep.jar"), map[string][]byte{"Dep.class": minimalClassFile("Dep")})
+	writeJarFile(t, filepath.Join(dir, "app.jar"), map[string][]byte{
+		"META-INF/MANIFEST.MF": []byte("Manifest-Version: 1.0\nClass-Path: lib/dep.jar\n"),
+	})
+
+	cp, err := core.ParseClassPath(filepath.Join(dir, "app.jar"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	defer cp.Close()
+
+	if _, err := cp.FindClass("Dep"); err != nil {
+		t.Errorf("Expected to find Dep through the manifest class path, got %v", err)
+	}
+}
+
+func TestShouldParseTheClassPathOptions(t *testing.T) {
+	t.Setenv("CLASSPATH", "from-env")
+
+	for _, option := range []string{"-cp", "-classpath", "--class-path"} {
+		ctx, err := core.NewExecutionContext([]string{option, "lib", "com.acme.Main", "arg1", "-cp"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if ctx.ClassPath != "lib" {
+			t.Errorf("Expected class path lib, got %s", ctx.ClassPath)
+		}
+
+		if ctx.Type != "class" || ctx.MainClass != "com/acme/Main" {
+			t.Errorf("Expected main class com/acme/Main, got %s %s", ctx.Type, ctx.MainClass)
+		}
+
+		if len(ctx.Arguments) != 2 || ctx.Arguments[0] != "arg1" || ctx.Arguments[1] != "-cp" {
+			t.Errorf("Expected the program arguments to be kept, got %v", ctx.Arguments)
+		}
+	}
+
+	ctx, _ := core.NewExecutionContext([]string{"--class-path=lib", "Main"})
+	if ctx.ClassPath != "lib" {
+		t.Errorf("Expected class path lib, got %s", ctx.ClassPath)
+	}
+
+	ctx, _ = core.NewExecutionContext([]string{"Main"})
+	if ctx.ClassPath != "from-env" {
+		t.Errorf("Expected class path from the CLASSPATH variable, got %s", ctx.ClassPath)
+	}
+}
+
+func TestShouldParseTheJarOption(t *testing.T) {
+	ctx, err := core.NewExecutionContext([]string{"-jar", "app.jar", "arg1"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if ctx.Type != "jar" || ctx.Filepath != "app.jar" {
+		t.Errorf("Expected to run app.jar, got %s %s", ctx.Type, ctx.Filepath)
+	}
+
+	if len(ctx.Arguments) != 1 || ctx.Arguments[0] != "arg1" {
+		t.Errorf("Expected the program arguments to be kept, got %v", ctx.Arguments)
+	}
+
+	// the class path options are ignored with -jar
+	ctx, _ = core.NewExecutionContext([]string{"-cp", "lib", "--jar", "app.jar"})
+	if ctx.Type != "jar" {
+		t.Errorf("Expected --jar to run a jar, got %s", ctx.Type)
+	}
+
+	if classPath := ctx.SystemProperties()["java.class.path"]; classPath != "app.jar" {
+		t.Errorf("Expected java.class.path to be the jar, got %s", classPath)
+	}
+
+	if _, err := core.NewExecutionContext([]string{"-cp"}); err == nil {
+		t.Errorf("Expected error for -cp without a value")
+	}
+}
+
+func TestShouldRunTheMainClassOfAJar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.jar")
+	writeJarFile(t, path, map[string][]byte{
+		"META-INF/MANIFEST.MF": []byte("Manifest-Version: 1.0\nMain-Class: com.acme.Main\n"),
+		"com/acme/Main.class":  mainClassFile("com/acme/Main"),
+	})
+
+	if err := core.RunJBM([]string{"-jar", path}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	writeJarFile(t, path, map[string][]byte{"com/acme/Main.class": minimalClassFile("com/acme/Main")})
+
+	if err := core.RunJBM([]string{"-jar", path}); err == nil || err.Error() != "no main manifest attribute, in "+path {
+		t.Errorf("Expected missing main class error, got %v", err)
+	}
+}
+
+func TestShouldParseTheMultiReleaseVersionProperty(t *testing.T) {
+	ctx, err := core.NewExecutionContext([]string{"-Djdk.util.jar.version=11", "-Dfoo=bar", "Main"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if ctx.Release != 11 {
+		t.Errorf("Expected release 11, got %d", ctx.Release)
+	}
+
+	if ctx.Properties["foo"] != "bar" {
+		t.Errorf("Expected property foo to be bar, got %s", ctx.Properties["foo"])
+	}
+
+	if ctx, _ := core.NewExecutionContext([]string{"Main"}); ctx.Release != core.DefaultRelease {
+		t.Errorf("Expected the default release, got %d", ctx.Release)
+	}
+}
+
+func TestShouldIgnoreClassPathEntriesThatAreNotJarFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeClassFile(t, dir, "Foo", minimalClassFile("Foo"))
+
+	notes := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(notes, []byte("not a jar"), 0o644); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	cp, err := core.ParseClassPath(notes + string(os.PathListSeparator) + dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	defer cp.Close()
+
+	if len(cp.Entries) != 1 {
+		t.Fatalf("Expected the file that is not a jar to be ignored, got %d entries", len(cp.Entries))
+	}
+
+	if _, entry, err := cp.ReadClass("Foo"); err != nil || entry.String() != dir {
+		t.Errorf("Expected Foo to be read from %s, got %v, %v", dir, entry, err)
+	}
+}
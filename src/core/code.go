@@ -0,0 +1,282 @@
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/Gustrb/jbm/src/utils"
+)
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-4.html#jvms-4.7.3
+
+// Code is the Code attribute of a method, it has the bytecode of the method and the sizes of its frames.
+type Code struct {
+	// MaxStack is the maximum depth of the operand stack of the method.
+	MaxStack uint16
+	// MaxLocals is the number of local variables of the method, including its parameters.
+	MaxLocals uint16
+	// Bytecode are the instructions of the method.
+	Bytecode []byte
+	// ExceptionTable are the exception handlers of the method, in the order they are searched.
+	ExceptionTable []ExceptionHandler
+	// Attributes are the attributes of the code, like LineNumberTable.
+	Attributes []AttributeInfo
+	// LineNumbers map the bytecode to the lines of the source file, from the LineNumberTable attributes.
+	LineNumbers []LineNumber
+	// LocalVariables are the names of the local variables in the source file, from the LocalVariableTable
+	// attributes.
+	LocalVariables []LocalVariable
+
+	// instructions tell which pcs start an instruction, and badPC is the pc of the first instruction that can
+	// not be interpreted, or -1, see `checkInstructions`.
+	instructions []bool
+	badPC        int
+	badReason    string
+	checked      sync.Once
+}
+
+// LineNumber is an entry of a LineNumberTable attribute, the line of the source file where the instructions
+// starting at the pc come from.
+type LineNumber struct {
+	StartPC uint16
+	Line    uint16
+}
+
+// LocalVariable is an entry of a LocalVariableTable attribute, a local variable of the source file that is
+// in the local at the index for the Length bytes of code starting at StartPC.
+type LocalVariable struct {
+	StartPC    uint16
+	Length     uint16
+	Name       string
+	Descriptor string
+	Index      uint16
+}
+
+// ExceptionHandler is an entry of the exception table of a method.
+type ExceptionHandler struct {
+	// StartPC and EndPC are the range of the bytecode covered by the handler, EndPC is exclusive.
+	StartPC uint16
+	EndPC   uint16
+	// HandlerPC is where the handler starts.
+	HandlerPC uint16
+	// CatchType is the constant pool index of the class of the exceptions caught, 0 catches everything.
+	CatchType uint16
+}
+
+// parseCode parses the content of a Code attribute of the class file.
+func parseCode(cf *ClassFile, info []byte) (*Code, error) {
+	reader := utils.NewBigEndianReaderFromReader(bytes.NewReader(info))
+	code := &Code{}
+
+	var err error
+	if code.MaxStack, err = reader.ReadUint16(); err != nil {
+		return nil, err
+	}
+
+	if code.MaxLocals, err = reader.ReadUint16(); err != nil {
+		return nil, err
+	}
+
+	length, err := reader.ReadUint32()
+	if err != nil {
+		return nil, err
+	}
+
+	if length == 0 || length >= 65536 {
+		return nil, fmt.Errorf("invalid code length %d", length)
+	}
+
+	if code.Bytecode, err = reader.ReadBytes(int(length)); err != nil {
+		return nil, err
+	}
+
+	handlersCount, err := reader.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+
+	code.ExceptionTable = make([]ExceptionHandler, handlersCount)
+	for i := range code.ExceptionTable {
+		handler := &code.ExceptionTable[i]
+
+		for _, value := range []*uint16{&handler.StartPC, &handler.EndPC, &handler.HandlerPC, &handler.CatchType} {
+			if *value, err = reader.ReadUint16(); err != nil {
+				return nil, err
+			}
+		}
+
+		if handler.StartPC >= handler.EndPC || uint32(handler.EndPC) > length || uint32(handler.HandlerPC) >= length {
+			return nil, fmt.Errorf("invalid exception table entry %d", i)
+		}
+	}
+
+	attributesCount, err := reader.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+
+	code.Attributes = make([]AttributeInfo, attributesCount)
+	for i := range code.Attributes {
+		if code.Attributes[i], err = cf.attributeInfoFromReader(reader); err != nil {
+			return nil, err
+		}
+
+		switch name, _ := cf.Utf8(code.Attributes[i].AttributeNameIndex); name {
+		case "LineNumberTable":
+			if err := code.parseLineNumbers(code.Attributes[i].Info); err != nil {
+				return nil, err
+			}
+		case "LocalVariableTable":
+			if err := code.parseLocalVariables(cf, code.Attributes[i].Info); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return code, nil
+}
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-4.html#jvms-4.7.12
+
+// parseLineNumbers adds the entries of a LineNumberTable attribute to the line numbers of the code.
+func (c *Code) parseLineNumbers(info []byte) error {
+	if len(info) < 2 || len(info) != 2+4*int(binary.BigEndian.Uint16(info)) {
+		return fmt.Errorf("invalid LineNumberTable attribute length %d", len(info))
+	}
+
+	for entry := info[2:]; len(entry) > 0; entry = entry[4:] {
+		number := LineNumber{StartPC: binary.BigEndian.Uint16(entry), Line: binary.BigEndian.Uint16(entry[2:])}
+		if int(number.StartPC) >= len(c.Bytecode) {
+			return fmt.Errorf("invalid pc %d in LineNumberTable", number.StartPC)
+		}
+
+		c.LineNumbers = append(c.LineNumbers, number)
+	}
+
+	return nil
+}
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-4.html#jvms-4.7.13
+
+// parseLocalVariables adds the entries of a LocalVariableTable attribute to the local variables of the code.
+func (c *Code) parseLocalVariables(cf *ClassFile, info []byte) error {
+	if len(info) < 2 || len(info) != 2+10*int(binary.BigEndian.Uint16(info)) {
+		return fmt.Errorf("invalid LocalVariableTable attribute length %d", len(info))
+	}
+
+	for entry := info[2:]; len(entry) > 0; entry = entry[10:] {
+		variable := LocalVariable{
+			StartPC: binary.BigEndian.Uint16(entry),
+			Length:  binary.BigEndian.Uint16(entry[2:]),
+			Index:   binary.BigEndian.Uint16(entry[8:]),
+		}
+
+		if int(variable.StartPC)+int(variable.Length) > len(c.Bytecode) || variable.Index >= c.MaxLocals {
+			return fmt.Errorf("invalid local variable %d at pc %d in LocalVariableTable", variable.Index, variable.StartPC)
+		}
+
+		var err error
+		if variable.Name, err = cf.Utf8(binary.BigEndian.Uint16(entry[4:])); err != nil {
+			return err
+		}
+
+		if variable.Descriptor, err = cf.Utf8(binary.BigEndian.Uint16(entry[6:])); err != nil {
+			return err
+		}
+
+		c.LocalVariables = append(c.LocalVariables, variable)
+	}
+
+	return nil
+}
+
+// LocalVariable returns the name of the local variable of the source file in the local at the given index
+// when the instruction at the pc executes, or an empty string when the code does not have it.
+func (c *Code) LocalVariable(pc int, index int) string {
+	for _, variable := range c.LocalVariables {
+		if int(variable.Index) == index && pc >= int(variable.StartPC) && pc < int(variable.StartPC)+int(variable.Length) {
+			return variable.Name
+		}
+	}
+
+	return ""
+}
+
+// LineNumber returns the line of the source file of the instruction at the given pc, or -1 when the code has
+// no line numbers. Like the reference implementation, it is the line of the entry starting at the pc, otherwise
+// the one of the closest entry before it.
+func (c *Code) LineNumber(pc int) int {
+	line, best := -1, -1
+
+	for _, number := range c.LineNumbers {
+		start := int(number.StartPC)
+		if start == pc {
+			return int(number.Line)
+		}
+
+		if start < pc && start >= best {
+			line, best = int(number.Line), start
+		}
+	}
+
+	return line
+}
+
+// checkInstructions checks, once, that every instruction of the bytecode is complete and only uses the locals
+// of the frame, which the interpreter relies on. It returns which pcs start an instruction, and the pc of the
+// first instruction that is not valid with the reason, or -1.
+func (c *Code) checkInstructions() ([]bool, int, string) {
+	c.checked.Do(func() {
+		c.instructions, c.badPC = make([]bool, len(c.Bytecode)), -1
+
+		for pc := 0; pc < len(c.Bytecode); {
+			length := instructionLength(c.Bytecode, pc)
+			if length == 0 {
+				c.badPC, c.badReason = pc, "truncated or unknown instruction"
+				return
+			}
+
+			if index, slots, ok := localOperand(c.Bytecode, pc); ok && index+slots > int(c.MaxLocals) {
+				c.badPC, c.badReason = pc, fmt.Sprintf("local %d out of the %d locals of the frame", index+slots-1, c.MaxLocals)
+				return
+			}
+
+			c.instructions[pc] = true
+			pc += length
+		}
+	})
+
+	return c.instructions, c.badPC, c.badReason
+}
+
+// localOperand returns the first local used by the complete instruction at pc and the number of locals it
+// uses, or false when it does not use locals.
+func localOperand(code []byte, pc int) (index int, slots int, ok bool) {
+	switch op := code[pc]; {
+	case op >= OP_ILOAD && op <= OP_ALOAD:
+		return int(code[pc+1]), valueSlots(op - OP_ILOAD), true
+	case op >= OP_ILOAD_0 && op <= OP_ALOAD_3:
+		return int(op-OP_ILOAD_0) % 4, valueSlots((op - OP_ILOAD_0) / 4), true
+	case op >= OP_ISTORE && op <= OP_ASTORE:
+		return int(code[pc+1]), valueSlots(op - OP_ISTORE), true
+	case op >= OP_ISTORE_0 && op <= OP_ASTORE_3:
+		return int(op-OP_ISTORE_0) % 4, valueSlots((op - OP_ISTORE_0) / 4), true
+	case op == OP_IINC || op == OP_RET:
+		return int(code[pc+1]), 1, true
+	case op == OP_WIDE:
+		index := int(readU2(code, pc+2))
+
+		switch wide := code[pc+1]; {
+		case wide >= OP_ILOAD && wide <= OP_ALOAD:
+			return index, valueSlots(wide - OP_ILOAD), true
+		case wide >= OP_ISTORE && wide <= OP_ASTORE:
+			return index, valueSlots(wide - OP_ISTORE), true
+		case wide == OP_IINC || wide == OP_RET:
+			return index, 1, true
+		}
+	}
+
+	return 0, 0, false
+}
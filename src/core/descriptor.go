@@ -0,0 +1,147 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-4.html#jvms-4.3
+
+// ParseMethodDescriptor splits a method descriptor, like `(ILjava/lang/String;)V`, into the field
+// descriptors of its parameters and its return descriptor.
+func ParseMethodDescriptor(descriptor string) ([]string, string, error) {
+	if !strings.HasPrefix(descriptor, "(") {
+		return nil, "", fmt.Errorf("invalid method descriptor: %s", descriptor)
+	}
+
+	params := []string{}
+	rest := descriptor[1:]
+
+	for !strings.HasPrefix(rest, ")") {
+		n := fieldDescriptorLength(rest)
+		if n == 0 {
+			return nil, "", fmt.Errorf("invalid method descriptor: %s", descriptor)
+		}
+
+		params = append(params, rest[:n])
+		rest = rest[n:]
+	}
+
+	ret := rest[1:]
+	if ret == "" || (ret != "V" && fieldDescriptorLength(ret) != len(ret)) {
+		return nil, "", fmt.Errorf("invalid method descriptor: %s", descriptor)
+	}
+
+	return params, ret, nil
+}
+
+// fieldDescriptorLength returns the length of the field descriptor at the start of s, or 0 if there is none.
+func fieldDescriptorLength(s string) int {
+	i := 0
+	for i < len(s) && s[i] == '[' {
+		i++
+	}
+
+	if i >= len(s) {
+		return 0
+	}
+
+	switch s[i] {
+	case 'B', 'C', 'D', 'F', 'I', 'J', 'S', 'Z':
+		return i + 1
+	case 'L':
+		end := strings.IndexByte(s[i:], ';')
+		if end <= 1 {
+			return 0
+		}
+
+		return i + end + 1
+	}
+
+	return 0
+}
+
+// DescriptorSlots returns the number of slots a value of the given field descriptor takes in the locals, the
+// operand stack and the fields: long and double take two, void takes none and everything else takes one.
+func DescriptorSlots(descriptor string) int {
+	switch descriptor {
+	case "J", "D":
+		return 2
+	case "V":
+		return 0
+	}
+
+	return 1
+}
+
+// ParameterSlots returns the number of slots taken by the parameters of a method descriptor, not counting `this`.
+func ParameterSlots(descriptor string) (int, error) {
+	params, _, err := ParseMethodDescriptor(descriptor)
+	if err != nil {
+		return 0, err
+	}
+
+	slots := 0
+	for _, param := range params {
+		slots += DescriptorSlots(param)
+	}
+
+	return slots, nil
+}
+
+// TypeName returns the Java source name of a field descriptor, e.g. `int[]` for `[I` and
+// `java.lang.String` for `Ljava/lang/String;`.
+func TypeName(descriptor string) string {
+	dimensions := 0
+	for dimensions < len(descriptor) && descriptor[dimensions] == '[' {
+		dimensions++
+	}
+
+	name := descriptor[dimensions:]
+	switch name {
+	case "B":
+		name = "byte"
+	case "C":
+		name = "char"
+	case "D":
+		name = "double"
+	case "F":
+		name = "float"
+	case "I":
+		name = "int"
+	case "J":
+		name = "long"
+	case "S":
+		name = "short"
+	case "Z":
+		name = "boolean"
+	case "V":
+		name = "void"
+	default:
+		name = javaName(strings.TrimSuffix(strings.TrimPrefix(name, "L"), ";"))
+	}
+
+	return name + strings.Repeat("[]", dimensions)
+}
+
+// MethodSignature returns the method the way the reference implementation shows it in error messages,
+// e.g. `void com.acme.Main.main(java.lang.String[])`.
+func MethodSignature(className string, name string, descriptor string) string {
+	return methodSignature(javaName(className)+"."+name, descriptor)
+}
+
+// methodSignature returns the return type, the given name and the parameter types of a method descriptor,
+// e.g. `void main(java.lang.String[])`.
+func methodSignature(name string, descriptor string) string {
+	params, ret, err := ParseMethodDescriptor(descriptor)
+	if err != nil {
+		return name + descriptor
+	}
+
+	types := make([]string, len(params))
+	for i, param := range params {
+		types[i] = TypeName(param)
+	}
+
+	return fmt.Sprintf("%s %s(%s)", TypeName(ret), name, strings.Join(types, ", "))
+}
@@ -0,0 +1,48 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+func TestShouldParseMethodDescriptors(t *testing.T) {
+	params, ret, err := core.ParseMethodDescriptor("(IJ[[Ljava/lang/String;D)V")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(params) != 4 || params[0] != "I" || params[1] != "J" || params[2] != "[[Ljava/lang/String;" || params[3] != "D" || ret != "V" {
+		t.Errorf("Expected the parameters and return type, got %v %s", params, ret)
+	}
+
+	if slots, _ := core.ParameterSlots("(IJ[[Ljava/lang/String;D)V"); slots != 6 {
+		t.Errorf("Expected 6 slots, got %d", slots)
+	}
+
+	for _, invalid := range []string{"", "I", "(I", "(Q)V", "(L;)V", "()", "()II", "([)V"} {
+		if _, _, err := core.ParseMethodDescriptor(invalid); err == nil {
+			t.Errorf("Expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestShouldFormatDescriptorsAsJavaTypes(t *testing.T) {
+	expected := map[string]string{
+		"I":                   "int",
+		"Z":                   "boolean",
+		"[[J":                 "long[][]",
+		"Ljava/lang/String;":  "java.lang.String",
+		"[Ljava/lang/Object;": "java.lang.Object[]",
+	}
+
+	for descriptor, name := range expected {
+		if actual := core.TypeName(descriptor); actual != name {
+			t.Errorf("Expected %s for %s, got %s", name, descriptor, actual)
+		}
+	}
+
+	if s := core.MethodSignature("com/acme/Main", "main", "([Ljava/lang/String;)V"); s != "void com.acme.Main.main(java.lang.String[])" {
+		t.Errorf("Expected the method signature, got %s", s)
+	}
+}
@@ -0,0 +1,144 @@
+package core
+
+import "errors"
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-2.html#jvms-2.10
+
+// catchException looks up the handler of the exception thrown by the instruction at the pc of the frame,
+// in the order of the exception table of the method. The handler catches the exception when its range covers
+// the pc and the exception is an instance of its catch type, or it has none, as `finally` blocks do.
+//
+// When a handler catches the exception, the operand stack is cleared, the throwable is pushed and the frame
+// continues at the handler. Otherwise the error is returned so it unwinds the frame, as are the errors that
+// are not Java throwables, like an ExitError.
+func (t *Thread) catchException(f *Frame, err error) error {
+	thrown, ok := err.(*JavaError)
+	if !ok {
+		return err
+	}
+
+	pool := f.Method.Class.ConstantPool
+
+	for _, handler := range f.Method.Code.ExceptionTable {
+		if f.PC < int(handler.StartPC) || f.PC >= int(handler.EndPC) {
+			continue
+		}
+
+		obj, err := t.throwable(f.Method.Class.Loader, thrown)
+		if err != nil {
+			return err
+		}
+
+		if handler.CatchType != 0 {
+			class, err := pool.ResolveClass(handler.CatchType)
+			if err != nil {
+				return err
+			}
+
+			if !obj.IsInstanceOf(class) {
+				continue
+			}
+		}
+
+		f.clearStack()
+		f.pushRef(obj)
+		f.PC = int(handler.HandlerPC)
+
+		return nil
+	}
+
+	return err
+}
+
+// throwable returns the throwable object of the error. The errors raised by the runtime get an instance of
+// their class, loaded by the bootstrap loader of the given loader, the first time it is needed.
+//
+// The instance is created by the constructor of the class that takes the message, since the throwables of the
+// class library keep their message and stack trace in fields it sets. It keeps the stack trace of the frame that
+// raised the error rather than the one of the constructor.
+func (t *Thread) throwable(loader *ClassLoader, err *JavaError) (*Object, error) {
+	if err.Object != nil {
+		return err.Object, nil
+	}
+
+	class, loadErr := loader.bootstrap().LoadClass(err.ClassName)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+
+	obj := NewObject(class)
+	obj.thrown = err
+
+	if constructor := class.DeclaredMethod("<init>", "(Ljava/lang/String;)V"); constructor != nil {
+		var message *Object
+		if err.Message != "" {
+			if message, loadErr = NewString(loader, err.Message); loadErr != nil {
+				return nil, loadErr
+			}
+		}
+
+		trace := err.StackTrace
+		if _, initErr := t.Invoke(constructor, []Slot{{Ref: obj}, {Ref: message}}); initErr != nil {
+			return nil, initErr
+		}
+
+		setStackTrace(obj, trace)
+	}
+
+	err.Object = obj
+
+	var thrownCause *JavaError
+	if cause := throwableField(class, "cause", "Ljava/lang/Throwable;"); cause != nil && errors.As(err.Cause, &thrownCause) {
+		causeObj, causeErr := t.throwable(loader, thrownCause)
+		if causeErr != nil {
+			return nil, causeErr
+		}
+
+		obj.Fields[cause.Slot].Ref = causeObj
+	}
+
+	return obj, nil
+}
+
+// isError tells if the throwable of the error is an instance of java/lang/Error, which programs are not
+// expected to catch.
+func (t *Thread) isError(loader *ClassLoader, err *JavaError) (bool, error) {
+	obj, objErr := t.throwable(loader, err)
+	if objErr != nil {
+		return false, objErr
+	}
+
+	class, loadErr := loader.bootstrap().LoadClass("java/lang/Error")
+	if loadErr != nil {
+		return false, loadErr
+	}
+
+	return obj.IsInstanceOf(class), nil
+}
+
+// thrownError returns the error that throws the throwable object, the same one every time it is thrown so
+// the runtime keeps what it knows about it, like its message when the runtime raised it, or its stack trace
+// and cause.
+func (o *Object) thrownError() *JavaError {
+	if o.thrown == nil {
+		o.thrown = &JavaError{ClassName: o.Class.Name, Object: o}
+	}
+
+	return o.thrown
+}
+
+// freshError returns a copy of a Java error without its throwable object and its stack trace, for errors that
+// are kept to be thrown again, like the errors of resolving a constant pool entry, so each throw gets its own
+// throwable.
+func freshError(err error) error {
+	var thrown *JavaError
+	if !errors.As(err, &thrown) || thrown != err {
+		return err
+	}
+
+	fresh := *thrown
+	fresh.Object = nil
+	fresh.StackTrace = nil
+
+	return &fresh
+}
@@ -0,0 +1,281 @@
+package core_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// handler is an entry of the exception table of a method.
+func handler(start uint16, end uint16, pc uint16, catchType uint16) []byte {
+	return concat(u2(start), u2(end), u2(pc), u2(catchType))
+}
+
+// codeWithHandlers builds a Code attribute with an exception table and the given attributes.
+func codeWithHandlers(b *testClassBuilder, maxStack uint16, maxLocals uint16, bytecode []byte, handlers [][]byte, attributes ...[]byte) []byte {
+	info := concat(u2(maxStack), u2(maxLocals), u4(uint32(len(bytecode))), bytecode, u2(uint16(len(handlers))))
+	for _, h := range handlers {
+		info = append(info, h...)
+	}
+
+	info = append(info, u2(uint16(len(attributes)))...)
+	for _, attr := range attributes {
+		info = append(info, attr...)
+	}
+
+	return b.attribute("Code", info)
+}
+
+// writeBoomClass writes Boom, a RuntimeException with a constructor without parameters and one with a cause.
+func writeBoomClass(t *testing.T, dir string) {
+	b := newTestClassBuilder()
+	init := b.methodref("java/lang/RuntimeException", "<init>", "()V")
+	initCause := b.methodref("java/lang/RuntimeException", "<init>", "(Ljava/lang/Throwable;)V")
+
+	writeClassFile(t, dir, "Boom", b.build(core.ACC_PUBLIC, "Boom", "java/lang/RuntimeException", nil, nil,
+		[][]byte{
+			b.member(core.ACC_PUBLIC, "<init>", "()V", b.code(1, 1, concat([]byte{core.OP_ALOAD_0, core.OP_INVOKESPECIAL}, u2(init), []byte{core.OP_RETURN})...)),
+			b.member(core.ACC_PUBLIC, "<init>", "(Ljava/lang/Throwable;)V",
+				b.code(2, 2, concat([]byte{core.OP_ALOAD_0, core.OP_ALOAD_1, core.OP_INVOKESPECIAL}, u2(initCause), []byte{core.OP_RETURN})...),
+			),
+		},
+		nil,
+	))
+}
+
+// writeExceptionClasses writes Boom and Calls, whose methods throw and catch exceptions.
+func writeExceptionClasses(t *testing.T, dir string) {
+	writeBoomClass(t, dir)
+
+	b := newTestClassBuilder()
+	static := core.ACC_PUBLIC | core.ACC_STATIC
+
+	arithmetic, npe := b.class("java/lang/ArithmeticException"), b.class("java/lang/NullPointerException")
+	runtime, throwable := b.class("java/lang/RuntimeException"), b.class("java/lang/Throwable")
+	boom, boomInit := b.class("Boom"), b.methodref("Boom", "<init>", "()V")
+	thrower, rethrow := b.methodref("Calls", "thrower", "()V"), b.methodref("Calls", "rethrow", "()I")
+	finallyRan := b.fieldref("Calls", "finallyRan", "I")
+
+	divide := []byte{core.OP_ILOAD_0, core.OP_ILOAD_1, core.OP_IDIV, core.OP_IRETURN, core.OP_POP, core.OP_ICONST_M1, core.OP_IRETURN}
+
+	throw := concat([]byte{core.OP_NEW}, u2(boom), []byte{core.OP_DUP, core.OP_INVOKESPECIAL}, u2(boomInit), []byte{core.OP_ATHROW})
+
+	// the handler of the whole method, like a finally block, sets finallyRan and throws the exception again
+	finally := concat(
+		[]byte{core.OP_INVOKESTATIC}, u2(thrower), []byte{core.OP_ICONST_0, core.OP_IRETURN},
+		[]byte{core.OP_ASTORE_0, core.OP_ICONST_1, core.OP_PUTSTATIC}, u2(finallyRan), []byte{core.OP_ALOAD_0, core.OP_ATHROW},
+	)
+
+	// the NullPointerException handler comes first but does not catch a Boom
+	outer := concat(
+		[]byte{core.OP_INVOKESTATIC}, u2(rethrow), []byte{core.OP_IRETURN},
+		[]byte{core.OP_POP, core.OP_BIPUSH, 42, core.OP_IRETURN},
+		[]byte{core.OP_POP, core.OP_BIPUSH, 7, core.OP_IRETURN},
+	)
+
+	npeCode := []byte{core.OP_ACONST_NULL, core.OP_ARRAYLENGTH, core.OP_POP, core.OP_ACONST_NULL, core.OP_ARETURN, core.OP_ARETURN}
+
+	writeClassFile(t, dir, "Calls", b.build(core.ACC_PUBLIC, "Calls", "java/lang/Object", nil,
+		[][]byte{b.member(static, "finallyRan", "I")},
+		[][]byte{
+			b.member(static, "divide", "(II)I", codeWithHandlers(b, 2, 2, divide, [][]byte{handler(0, 4, 4, arithmetic)})),
+			b.member(static, "thrower", "()V", b.code(2, 0, throw...)),
+			b.member(static, "rethrow", "()I", codeWithHandlers(b, 1, 1, finally, [][]byte{handler(0, 5, 5, 0)})),
+			b.member(static, "outer", "()I", codeWithHandlers(b, 1, 0, outer, [][]byte{handler(0, 4, 8, npe), handler(0, 4, 4, runtime)})),
+			b.member(static, "npe", "()Ljava/lang/Object;", codeWithHandlers(b, 1, 0, npeCode, [][]byte{handler(0, 5, 5, throwable)})),
+			b.member(static, "throwNull", "()V", b.code(1, 0, core.OP_ACONST_NULL, core.OP_ATHROW)),
+		},
+		nil,
+	))
+}
+
+func TestShouldCatchExceptionsInTheHandlersOfTheExceptionTable(t *testing.T) {
+	dir := t.TempDir()
+	writeExceptionClasses(t, dir)
+
+	calls, err := newTestLoaders(t, dir).Application.LoadClass("Calls")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result, err := invokeInt(t, calls, "divide", "(II)I", 6, 3); err != nil || result != 2 {
+		t.Errorf("Expected 6 / 3 to be 2, got %d (%v)", result, err)
+	}
+
+	if result, err := invokeInt(t, calls, "divide", "(II)I", 1, 0); err != nil || result != -1 {
+		t.Errorf("Expected the ArithmeticException to be caught, got %d (%v)", result, err)
+	}
+
+	if result, err := invokeInt(t, calls, "outer", "()I"); err != nil || result != 42 {
+		t.Errorf("Expected the Boom to be caught as a RuntimeException, got %d (%v)", result, err)
+	}
+
+	if calls.StaticValues[0].Num != 1 {
+		t.Errorf("Expected the finally handler to run, got %d", calls.StaticValues[0].Num)
+	}
+
+	ret, err := invokeSlots(t, calls, "npe", "()Ljava/lang/Object;")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if obj := ret[0].Ref; obj == nil || obj.Class.Name != core.NullPointerException || !obj.Class.Loader.IsBootstrap() {
+		t.Errorf("Expected the handler to get a NullPointerException object, got %v", obj)
+	}
+}
+
+func TestShouldUnwindTheFramesOfUncaughtExceptions(t *testing.T) {
+	dir := t.TempDir()
+	writeExceptionClasses(t, dir)
+
+	calls, err := newTestLoaders(t, dir).Application.LoadClass("Calls")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	_, err = invokeSlots(t, calls, "rethrow", "()I")
+
+	var thrown *core.JavaError
+	if !errors.As(err, &thrown) || thrown.ClassName != "Boom" || thrown.Object == nil || thrown.Object.Class.Name != "Boom" {
+		t.Fatalf("Expected the Boom to be thrown, got %v", err)
+	}
+
+	if err.Error() != "Boom" {
+		t.Errorf("Expected the exception to be shown as Boom, got %q", err.Error())
+	}
+
+	_, err = invokeSlots(t, calls, "throwNull", "()V")
+	if !core.IsJavaError(err, core.NullPointerException) {
+		t.Errorf("Expected a NullPointerException, got %v", err)
+	}
+}
+
+func TestShouldReportExceptionsThatTerminateTheMainThread(t *testing.T) {
+	dir := t.TempDir()
+
+	b := newTestClassBuilder()
+	main := b.member(core.ACC_PUBLIC|core.ACC_STATIC, "main", "([Ljava/lang/String;)V", b.code(2, 1, core.OP_ICONST_1, core.OP_ICONST_0, core.OP_IDIV, core.OP_RETURN))
+	writeClassFile(t, dir, "Main", b.build(core.ACC_PUBLIC, "Main", "java/lang/Object", nil, nil, [][]byte{main}, nil))
+
+	class, err := newTestLoaders(t, dir).Application.LoadClass("Main")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	err = core.ExecuteClass(class)
+
+	var uncaught *core.UncaughtException
+	if !errors.As(err, &uncaught) || uncaught.Thread != core.MainThreadName {
+		t.Fatalf("Expected an uncaught exception in the main thread, got %v", err)
+	}
+
+	if err.Error() != `Exception in thread "main" java.lang.ArithmeticException: / by zero` {
+		t.Errorf("Expected the uncaught exception to be reported, got %q", err.Error())
+	}
+}
+
+// writeLibraryThrowables writes a java/lang/Throwable that keeps its message and stack trace in fields, like the
+// one of the class library, with the subclasses the runtime raises when dividing by zero.
+func writeLibraryThrowables(t *testing.T, dir string) {
+	b := newTestClassBuilder()
+	object := b.methodref("java/lang/Object", "<init>", "()V")
+	fill := b.methodref(core.Throwable, "fillInStackTrace", "(I)Ljava/lang/Throwable;")
+	message := b.fieldref(core.Throwable, "detailMessage", "Ljava/lang/String;")
+	stackTrace := b.fieldref(core.Throwable, "stackTrace", "[Ljava/lang/StackTraceElement;")
+	unassigned := b.fieldref(core.Throwable, "UNASSIGNED_STACK", "[Ljava/lang/StackTraceElement;")
+	element := b.class("java/lang/StackTraceElement")
+
+	writeClassFile(t, dir, core.Throwable, b.build(core.ACC_PUBLIC, core.Throwable, "java/lang/Object", nil,
+		[][]byte{
+			b.member(core.ACC_PRIVATE|core.ACC_STATIC|core.ACC_FINAL, "UNASSIGNED_STACK", "[Ljava/lang/StackTraceElement;"),
+			b.member(core.ACC_PRIVATE, "detailMessage", "Ljava/lang/String;"),
+			b.member(core.ACC_PRIVATE, "stackTrace", "[Ljava/lang/StackTraceElement;"),
+			b.member(core.ACC_PRIVATE|core.ACC_TRANSIENT, "backtrace", "Ljava/lang/Object;"),
+			b.member(core.ACC_PRIVATE|core.ACC_TRANSIENT, "depth", "I"),
+		},
+		[][]byte{
+			b.member(core.ACC_STATIC, "<clinit>", "()V", b.code(1, 0, concat(
+				[]byte{core.OP_ICONST_0, core.OP_ANEWARRAY}, u2(element), []byte{core.OP_PUTSTATIC}, u2(unassigned), []byte{core.OP_RETURN},
+			)...)),
+			b.member(core.ACC_PUBLIC, "<init>", "(Ljava/lang/String;)V", b.code(2, 2, concat(
+				[]byte{core.OP_ALOAD_0, core.OP_INVOKESPECIAL}, u2(object),
+				[]byte{core.OP_ALOAD_0, core.OP_GETSTATIC}, u2(unassigned), []byte{core.OP_PUTFIELD}, u2(stackTrace),
+				[]byte{core.OP_ALOAD_0, core.OP_ALOAD_1, core.OP_PUTFIELD}, u2(message),
+				[]byte{core.OP_ALOAD_0, core.OP_ICONST_0, core.OP_INVOKEVIRTUAL}, u2(fill), []byte{core.OP_POP, core.OP_RETURN},
+			)...)),
+			b.member(core.ACC_PUBLIC, "getMessage", "()Ljava/lang/String;", b.code(1, 1, concat([]byte{core.OP_ALOAD_0, core.OP_GETFIELD}, u2(message), []byte{core.OP_ARETURN})...)),
+			b.member(core.ACC_PRIVATE|core.ACC_NATIVE, "fillInStackTrace", "(I)Ljava/lang/Throwable;"),
+		},
+		nil,
+	))
+
+	for _, class := range []struct{ name, super string }{
+		{"java/lang/Exception", core.Throwable}, {"java/lang/RuntimeException", "java/lang/Exception"}, {core.ArithmeticException, "java/lang/RuntimeException"},
+	} {
+		b := newTestClassBuilder()
+		init := b.methodref(class.super, "<init>", "(Ljava/lang/String;)V")
+
+		writeClassFile(t, dir, class.name, b.build(core.ACC_PUBLIC, class.name, class.super, nil, nil,
+			[][]byte{b.member(core.ACC_PUBLIC, "<init>", "(Ljava/lang/String;)V", b.code(2, 2, concat([]byte{core.OP_ALOAD_0, core.OP_ALOAD_1, core.OP_INVOKESPECIAL}, u2(init), []byte{core.OP_RETURN})...))},
+			nil,
+		))
+	}
+}
+
+func TestShouldConstructTheThrowablesTheRuntimeRaises(t *testing.T) {
+	bootDir, appDir := t.TempDir(), t.TempDir()
+	writeLibraryThrowables(t, bootDir)
+
+	b := newTestClassBuilder()
+	arithmetic := b.class(core.ArithmeticException)
+	divide := []byte{core.OP_ILOAD_0, core.OP_ILOAD_1, core.OP_IDIV, core.OP_POP, core.OP_ACONST_NULL, core.OP_ARETURN, core.OP_ARETURN}
+
+	writeClassFile(t, appDir, "Calls", b.build(core.ACC_PUBLIC, "Calls", "java/lang/Object", nil, nil,
+		[][]byte{b.member(core.ACC_PUBLIC|core.ACC_STATIC, "divide", "(II)Ljava/lang/Throwable;", codeWithHandlers(b, 2, 2, divide, [][]byte{handler(0, 6, 6, arithmetic)}))},
+		nil,
+	))
+
+	app, err := core.ParseClassPath(appDir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	loaders := core.NewClassLoaders(core.NewClassPath(&core.DirClassPathEntry{Dir: bootDir}), nil, app)
+	t.Cleanup(func() { loaders.Close() })
+
+	calls, err := loaders.Application.LoadClass("Calls")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ret, err := invokeSlots(t, calls, "divide", "(II)Ljava/lang/Throwable;", []core.Slot{{Num: 1}, {Num: 0}})
+	if err != nil || ret[0].Ref == nil {
+		t.Fatalf("Expected the ArithmeticException to be caught, got %v", err)
+	}
+
+	obj := ret[0].Ref
+	throwable := obj.Class.Super.Super.Super
+
+	message, err := invokeSlots(t, throwable, "getMessage", "()Ljava/lang/String;", []core.Slot{{Ref: obj}})
+	if err != nil || core.GoString(message[0].Ref) != "/ by zero" {
+		t.Errorf("Expected getMessage to return the message of the runtime, got %v (%v)", message, err)
+	}
+
+	field := func(name string, descriptor string) core.Slot {
+		return obj.Fields[throwable.DeclaredField(name, descriptor).Slot]
+	}
+
+	unassigned := throwable.StaticValues[throwable.DeclaredField("UNASSIGNED_STACK", "[Ljava/lang/StackTraceElement;").Slot].Ref
+	if stackTrace := field("stackTrace", "[Ljava/lang/StackTraceElement;").Ref; unassigned == nil || stackTrace != unassigned {
+		t.Errorf("Expected the stack trace to be UNASSIGNED_STACK until it is asked for, got %v", stackTrace)
+	}
+
+	if trace := obj.ThrownError().StackTrace; len(trace) != 1 || trace[0].MethodName != "divide" {
+		t.Errorf("Expected the stack trace of the frame that divided by zero, got %v", trace)
+	}
+
+	if backtrace, depth := field("backtrace", "Ljava/lang/Object;").Ref, field("depth", "I").Num; backtrace != obj || depth != 1 {
+		t.Errorf("Expected the backtrace to hold the frame that divided by zero, got %v and %d", backtrace, depth)
+	}
+}
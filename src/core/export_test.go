@@ -0,0 +1,36 @@
+package core
+
+// SetExecuteMethod replaces how methods are executed, returning a function that restores it.
+func SetExecuteMethod(execute func(t *Thread, method *Method, args []Slot) ([]Slot, error)) func() {
+	previous := executeMethod
+	executeMethod = execute
+
+	return func() { executeMethod = previous }
+}
+
+// SystemProperties returns the system properties the JDK boots with.
+func (ctx *ExecutionContext) SystemProperties() map[string]string {
+	return ctx.systemProperties()
+}
+
+// ParsedClassFiles waits for the class files being prefetched and returns the number of parsed class files
+// waiting to be defined.
+func (c *ClassCache) ParsedClassFiles() int {
+	c.prefetching.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.parsed)
+}
+
+// Prefetch parses the class file of the class ahead of time, like the loader does for superinterfaces.
+func (l *ClassLoader) Prefetch(name string) {
+	l.Cache.prefetching.Add(1)
+	l.prefetch(name)
+}
+
+// ThrownError returns the error that throws the throwable object.
+func (o *Object) ThrownError() *JavaError {
+	return o.thrownError()
+}
@@ -0,0 +1,148 @@
+package core
+
+import "math"
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-2.html#jvms-2.6
+
+// Frame is the activation of a method, it has the local variables and the operand stack of the method,
+// sized from the max_locals and max_stack of its code.
+type Frame struct {
+	// Method is the method being executed.
+	Method *Method
+	// Locals are the local variables, the arguments come first.
+	Locals []Slot
+	// Stack is the operand stack, only the slots below `sp` are in use.
+	Stack []Slot
+	// PC is the offset of the instruction being executed in the bytecode.
+	PC int
+
+	sp int
+}
+
+// newFrame creates the frame of a method with the given arguments copied to its first locals.
+func newFrame(method *Method, args []Slot) *Frame {
+	code := method.Code
+
+	frame := &Frame{
+		Method: method,
+		Locals: make([]Slot, code.MaxLocals),
+		Stack:  make([]Slot, code.MaxStack),
+	}
+
+	copy(frame.Locals, args)
+
+	return frame
+}
+
+// badBytecode is the panic raised by the operand stack when the bytecode pushes more slots than the max_stack
+// of its code or pops more than it pushed, the interpreter reports it as a VerifyError.
+type badBytecode string
+
+func (f *Frame) push(slot Slot) {
+	if f.sp == len(f.Stack) {
+		panic(badBytecode("operand stack overflow"))
+	}
+
+	f.Stack[f.sp] = slot
+	f.sp++
+}
+
+func (f *Frame) pop() Slot {
+	if f.sp == 0 {
+		panic(badBytecode("operand stack underflow"))
+	}
+
+	f.sp--
+	slot := f.Stack[f.sp]
+	f.Stack[f.sp] = Slot{}
+
+	return slot
+}
+
+// peek returns the slot at the given depth of the stack, 0 is the top.
+func (f *Frame) peek(depth int) Slot {
+	if depth >= f.sp {
+		panic(badBytecode("operand stack underflow"))
+	}
+
+	return f.Stack[f.sp-1-depth]
+}
+
+func (f *Frame) pushInt(value int32) {
+	f.push(Slot{Num: value})
+}
+
+func (f *Frame) popInt() int32 {
+	return f.pop().Num
+}
+
+func (f *Frame) pushLong(value int64) {
+	f.push(Slot{Num: int32(value >> 32)})
+	f.push(Slot{Num: int32(value)})
+}
+
+func (f *Frame) popLong() int64 {
+	low, high := f.pop(), f.pop()
+	return int64(high.Num)<<32 | int64(uint32(low.Num))
+}
+
+func (f *Frame) pushFloat(value float32) {
+	f.push(FloatSlot(value))
+}
+
+func (f *Frame) popFloat() float32 {
+	return SlotFloat(f.pop())
+}
+
+func (f *Frame) pushDouble(value float64) {
+	f.pushLong(int64(math.Float64bits(value)))
+}
+
+func (f *Frame) popDouble() float64 {
+	return math.Float64frombits(uint64(f.popLong()))
+}
+
+// load2 pushes the two slots of the long or double in the local at the given index.
+func (f *Frame) load2(index int) {
+	f.push(f.Locals[index])
+	f.push(f.Locals[index+1])
+}
+
+// store2 pops a long or double into the local at the given index.
+func (f *Frame) store2(index int) {
+	f.Locals[index+1] = f.pop()
+	f.Locals[index] = f.pop()
+}
+
+func (f *Frame) pushRef(ref *Object) {
+	f.push(Slot{Ref: ref})
+}
+
+func (f *Frame) popRef() *Object {
+	return f.pop().Ref
+}
+
+// clearStack pops every slot of the operand stack.
+func (f *Frame) clearStack() {
+	clear(f.Stack[:f.sp])
+	f.sp = 0
+}
+
+// popSlots pops the given number of slots, returning them in the order they were pushed.
+func (f *Frame) popSlots(n int) []Slot {
+	if n > f.sp {
+		panic(badBytecode("operand stack underflow"))
+	}
+
+	slots := make([]Slot, n)
+	f.sp -= n
+	copy(slots, f.Stack[f.sp:f.sp+n])
+	clear(f.Stack[f.sp : f.sp+n])
+
+	return slots
+}
+
+// StackDepth returns the number of slots in use in the operand stack.
+func (f *Frame) StackDepth() int {
+	return f.sp
+}
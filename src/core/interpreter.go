@@ -0,0 +1,716 @@
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-6.html
+
+// execute runs the method in a new frame of the thread, holding the monitor of its object or class while it
+// runs when it is synchronized.
+func (t *Thread) execute(method *Method, args []Slot) (ret []Slot, err error) {
+	if method.AccessFlags&ACC_SYNCHRONIZED != 0 {
+		obj, err := synchronizedObject(method, args)
+		if err == nil {
+			err = t.monitorEnter(obj)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			if exitErr := t.monitorExit(obj); exitErr != nil && err == nil {
+				ret, err = nil, exitErr
+			}
+		}()
+	}
+
+	switch {
+	case method.IsAbstract():
+		return nil, NewJavaError(AbstractMethodError, "'%s'", method)
+	case method.IsNative():
+		return t.invokeNative(method, args)
+	case method.Code == nil:
+		return nil, NewJavaError(
+			ClassFormatError, "Absent Code attribute in method that is not native or abstract in class file %s", method.Class.Name,
+		)
+	}
+
+	if len(t.frames) >= MaxStackDepth {
+		return nil, &JavaError{ClassName: StackOverflowError}
+	}
+
+	frame := newFrame(method, args)
+
+	t.frames = append(t.frames, frame)
+	defer func() { t.frames = t.frames[:len(t.frames)-1] }()
+
+	return t.run(frame)
+}
+
+// run interprets the bytecode of the frame until the method returns. The exceptions thrown by its instructions
+// get the stack trace of the thread, unless they already have one, and continue at the handler that catches
+// them, the ones that are not caught are returned to unwind the frame.
+func (t *Thread) run(f *Frame) ([]Slot, error) {
+	for {
+		ret, err := t.interpret(f)
+		if err == nil {
+			return ret, nil
+		}
+
+		if thrown, ok := err.(*JavaError); ok && thrown.StackTrace == nil {
+			thrown.StackTrace = stackTrace(t.frames)
+
+			// the NullPointerExceptions raised by the instructions explain what is null
+			if thrown.ClassName == NullPointerException && thrown.Message == "" {
+				thrown.Message = nullPointerMessage(f.Method, f.PC)
+			}
+		}
+
+		if err := t.catchException(f, err); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// interpret runs the instructions of the frame from its pc until the method returns or an instruction throws,
+// leaving the pc at the instruction that threw.
+//
+// Bytecode is not verified, so the instructions that are truncated, use a local that does not exist, are
+// jumped into or overflow the operand stack are reported as a VerifyError, see `checkInstructions` and
+// `badBytecode`. Any other panic is a bug of the VM and is not recovered.
+func (t *Thread) interpret(f *Frame) (ret []Slot, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			reason, ok := r.(badBytecode)
+			if !ok {
+				panic(r)
+			}
+
+			ret, err = nil, NewJavaError(VerifyError, "Bad bytecode in method %s at pc %d: %s", f.Method, f.PC, reason)
+		}
+	}()
+
+	code := f.Method.Code.Bytecode
+
+	instructions, badPC, badReason := f.Method.Code.checkInstructions()
+	if badPC >= 0 {
+		return nil, NewJavaError(VerifyError, "Bad bytecode in method %s at pc %d: %s", f.Method, badPC, badReason)
+	}
+
+	for {
+		pc := f.PC
+		if pc < 0 || pc >= len(instructions) || !instructions[pc] {
+			return nil, NewJavaError(VerifyError, "Bad bytecode in method %s at pc %d: no instruction starts there", f.Method, pc)
+		}
+
+		op := code[pc]
+		next := pc + 1
+
+		switch op {
+		case OP_NOP:
+		case OP_ACONST_NULL:
+			f.pushRef(nil)
+		case OP_ICONST_M1, OP_ICONST_0, OP_ICONST_1, OP_ICONST_2, OP_ICONST_3, OP_ICONST_4, OP_ICONST_5:
+			f.pushInt(int32(op) - int32(OP_ICONST_0))
+		case OP_LCONST_0, OP_LCONST_1:
+			f.pushLong(int64(op - OP_LCONST_0))
+		case OP_FCONST_0, OP_FCONST_1, OP_FCONST_2:
+			f.pushFloat(float32(op - OP_FCONST_0))
+		case OP_DCONST_0, OP_DCONST_1:
+			f.pushDouble(float64(op - OP_DCONST_0))
+		case OP_BIPUSH:
+			f.pushInt(int32(int8(code[pc+1])))
+			next = pc + 2
+		case OP_SIPUSH:
+			f.pushInt(int32(readS2(code, pc+1)))
+			next = pc + 3
+		case OP_LDC:
+			if err := t.ldc(f, uint16(code[pc+1])); err != nil {
+				return nil, err
+			}
+
+			next = pc + 2
+		case OP_LDC_W:
+			if err := t.ldc(f, readU2(code, pc+1)); err != nil {
+				return nil, err
+			}
+
+			next = pc + 3
+		case OP_LDC2_W:
+			if err := t.ldc2(f, readU2(code, pc+1)); err != nil {
+				return nil, err
+			}
+
+			next = pc + 3
+
+		case OP_ILOAD, OP_FLOAD, OP_ALOAD:
+			f.push(f.Locals[code[pc+1]])
+			next = pc + 2
+		case OP_LLOAD, OP_DLOAD:
+			f.load2(int(code[pc+1]))
+			next = pc + 2
+		case OP_ILOAD_0, OP_ILOAD_1, OP_ILOAD_2, OP_ILOAD_3:
+			f.push(f.Locals[op-OP_ILOAD_0])
+		case OP_LLOAD_0, OP_LLOAD_1, OP_LLOAD_2, OP_LLOAD_3:
+			f.load2(int(op - OP_LLOAD_0))
+		case OP_FLOAD_0, OP_FLOAD_1, OP_FLOAD_2, OP_FLOAD_3:
+			f.push(f.Locals[op-OP_FLOAD_0])
+		case OP_DLOAD_0, OP_DLOAD_1, OP_DLOAD_2, OP_DLOAD_3:
+			f.load2(int(op - OP_DLOAD_0))
+		case OP_ALOAD_0, OP_ALOAD_1, OP_ALOAD_2, OP_ALOAD_3:
+			f.push(f.Locals[op-OP_ALOAD_0])
+		case OP_ISTORE, OP_FSTORE, OP_ASTORE:
+			f.Locals[code[pc+1]] = f.pop()
+			next = pc + 2
+		case OP_LSTORE, OP_DSTORE:
+			f.store2(int(code[pc+1]))
+			next = pc + 2
+		case OP_ISTORE_0, OP_ISTORE_1, OP_ISTORE_2, OP_ISTORE_3:
+			f.Locals[op-OP_ISTORE_0] = f.pop()
+		case OP_LSTORE_0, OP_LSTORE_1, OP_LSTORE_2, OP_LSTORE_3:
+			f.store2(int(op - OP_LSTORE_0))
+		case OP_FSTORE_0, OP_FSTORE_1, OP_FSTORE_2, OP_FSTORE_3:
+			f.Locals[op-OP_FSTORE_0] = f.pop()
+		case OP_DSTORE_0, OP_DSTORE_1, OP_DSTORE_2, OP_DSTORE_3:
+			f.store2(int(op - OP_DSTORE_0))
+		case OP_ASTORE_0, OP_ASTORE_1, OP_ASTORE_2, OP_ASTORE_3:
+			f.Locals[op-OP_ASTORE_0] = f.pop()
+
+		case OP_POP:
+			f.pop()
+		case OP_POP2:
+			f.pop()
+			f.pop()
+		case OP_DUP:
+			f.push(f.peek(0))
+		case OP_DUP_X1:
+			v1, v2 := f.pop(), f.pop()
+			f.push(v1)
+			f.push(v2)
+			f.push(v1)
+		case OP_DUP_X2:
+			v1, v2, v3 := f.pop(), f.pop(), f.pop()
+			f.push(v1)
+			f.push(v3)
+			f.push(v2)
+			f.push(v1)
+		case OP_DUP2:
+			v1, v2 := f.peek(0), f.peek(1)
+			f.push(v2)
+			f.push(v1)
+		case OP_DUP2_X1:
+			v1, v2, v3 := f.pop(), f.pop(), f.pop()
+			f.push(v2)
+			f.push(v1)
+			f.push(v3)
+			f.push(v2)
+			f.push(v1)
+		case OP_DUP2_X2:
+			v1, v2, v3, v4 := f.pop(), f.pop(), f.pop(), f.pop()
+			f.push(v2)
+			f.push(v1)
+			f.push(v4)
+			f.push(v3)
+			f.push(v2)
+			f.push(v1)
+		case OP_SWAP:
+			v1, v2 := f.pop(), f.pop()
+			f.push(v1)
+			f.push(v2)
+
+		case OP_IADD, OP_ISUB, OP_IMUL, OP_IDIV, OP_IREM, OP_ISHL, OP_ISHR, OP_IUSHR, OP_IAND, OP_IOR, OP_IXOR:
+			v2, v1 := f.popInt(), f.popInt()
+
+			result, err := intArithmetic(op, v1, v2)
+			if err != nil {
+				return nil, err
+			}
+
+			f.pushInt(result)
+		case OP_LADD, OP_LSUB, OP_LMUL, OP_LDIV, OP_LREM, OP_LAND, OP_LOR, OP_LXOR:
+			v2, v1 := f.popLong(), f.popLong()
+
+			result, err := longArithmetic(op, v1, v2)
+			if err != nil {
+				return nil, err
+			}
+
+			f.pushLong(result)
+		case OP_LSHL, OP_LSHR, OP_LUSHR:
+			distance := f.popInt()
+			f.pushLong(longShift(op, f.popLong(), distance))
+		case OP_FADD, OP_FSUB, OP_FMUL, OP_FDIV, OP_FREM:
+			v2, v1 := f.popFloat(), f.popFloat()
+			f.pushFloat(floatArithmetic(op, v1, v2))
+		case OP_DADD, OP_DSUB, OP_DMUL, OP_DDIV, OP_DREM:
+			v2, v1 := f.popDouble(), f.popDouble()
+			f.pushDouble(doubleArithmetic(op, v1, v2))
+		case OP_INEG:
+			f.pushInt(-f.popInt())
+		case OP_LNEG:
+			f.pushLong(-f.popLong())
+		case OP_FNEG:
+			f.pushFloat(-f.popFloat())
+		case OP_DNEG:
+			f.pushDouble(-f.popDouble())
+		case OP_IINC:
+			index := code[pc+1]
+			f.Locals[index].Num += int32(int8(code[pc+2]))
+			next = pc + 3
+		case OP_I2L:
+			f.pushLong(int64(f.popInt()))
+		case OP_I2F:
+			f.pushFloat(float32(f.popInt()))
+		case OP_I2D:
+			f.pushDouble(float64(f.popInt()))
+		case OP_L2I:
+			f.pushInt(int32(f.popLong()))
+		case OP_L2F:
+			f.pushFloat(float32(f.popLong()))
+		case OP_L2D:
+			f.pushDouble(float64(f.popLong()))
+		case OP_F2I:
+			f.pushInt(doubleToInt(float64(f.popFloat())))
+		case OP_F2L:
+			f.pushLong(doubleToLong(float64(f.popFloat())))
+		case OP_F2D:
+			f.pushDouble(float64(f.popFloat()))
+		case OP_D2I:
+			f.pushInt(doubleToInt(f.popDouble()))
+		case OP_D2L:
+			f.pushLong(doubleToLong(f.popDouble()))
+		case OP_D2F:
+			f.pushFloat(float32(f.popDouble()))
+		case OP_I2B:
+			f.pushInt(int32(int8(f.popInt())))
+		case OP_I2C:
+			f.pushInt(int32(uint16(f.popInt())))
+		case OP_I2S:
+			f.pushInt(int32(int16(f.popInt())))
+
+		case OP_LCMP:
+			v2, v1 := f.popLong(), f.popLong()
+			f.pushInt(compareLong(v1, v2))
+		case OP_FCMPL, OP_FCMPG:
+			v2, v1 := f.popFloat(), f.popFloat()
+			f.pushInt(compareFloating(float64(v1), float64(v2), op == OP_FCMPG))
+		case OP_DCMPL, OP_DCMPG:
+			v2, v1 := f.popDouble(), f.popDouble()
+			f.pushInt(compareFloating(v1, v2, op == OP_DCMPG))
+
+		case OP_IFEQ, OP_IFNE, OP_IFLT, OP_IFGE, OP_IFGT, OP_IFLE:
+			if compareInt(op-OP_IFEQ, f.popInt(), 0) {
+				next = pc + int(readS2(code, pc+1))
+			} else {
+				next = pc + 3
+			}
+		case OP_IF_ICMPEQ, OP_IF_ICMPNE, OP_IF_ICMPLT, OP_IF_ICMPGE, OP_IF_ICMPGT, OP_IF_ICMPLE:
+			v2, v1 := f.popInt(), f.popInt()
+			if compareInt(op-OP_IF_ICMPEQ, v1, v2) {
+				next = pc + int(readS2(code, pc+1))
+			} else {
+				next = pc + 3
+			}
+		case OP_IF_ACMPEQ, OP_IF_ACMPNE:
+			v2, v1 := f.popRef(), f.popRef()
+			if (v1 == v2) == (op == OP_IF_ACMPEQ) {
+				next = pc + int(readS2(code, pc+1))
+			} else {
+				next = pc + 3
+			}
+		case OP_IFNULL, OP_IFNONNULL:
+			if (f.popRef() == nil) == (op == OP_IFNULL) {
+				next = pc + int(readS2(code, pc+1))
+			} else {
+				next = pc + 3
+			}
+		case OP_GOTO:
+			next = pc + int(readS2(code, pc+1))
+		case OP_GOTO_W:
+			next = pc + int(readS4(code, pc+1))
+		case OP_TABLESWITCH:
+			next = pc + tableSwitch(code, pc, f.popInt())
+		case OP_LOOKUPSWITCH:
+			next = pc + lookupSwitch(code, pc, f.popInt())
+
+		case OP_IRETURN, OP_FRETURN, OP_ARETURN:
+			return []Slot{f.pop()}, nil
+		case OP_LRETURN, OP_DRETURN:
+			return f.popSlots(2), nil
+		case OP_RETURN:
+			return nil, nil
+		case OP_ATHROW:
+			obj := f.popRef()
+			if obj == nil {
+				return nil, &JavaError{ClassName: NullPointerException}
+			}
+
+			return nil, obj.thrownError()
+
+		case OP_GETSTATIC:
+			field, err := t.resolveField(f, readU2(code, pc+1), true, false)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, slot := range loadField(field, field.Class.StaticValues) {
+				f.push(slot)
+			}
+
+			next = pc + 3
+		case OP_PUTSTATIC:
+			field, err := t.resolveField(f, readU2(code, pc+1), true, true)
+			if err != nil {
+				return nil, err
+			}
+
+			storeField(field, field.Class.StaticValues, f.popSlots(DescriptorSlots(field.Descriptor)))
+			next = pc + 3
+		case OP_GETFIELD:
+			field, err := t.resolveField(f, readU2(code, pc+1), false, false)
+			if err != nil {
+				return nil, err
+			}
+
+			obj := f.popRef()
+			if obj == nil {
+				return nil, &JavaError{ClassName: NullPointerException}
+			}
+
+			for _, slot := range loadField(field, obj.Fields) {
+				f.push(slot)
+			}
+
+			next = pc + 3
+		case OP_PUTFIELD:
+			field, err := t.resolveField(f, readU2(code, pc+1), false, true)
+			if err != nil {
+				return nil, err
+			}
+
+			values := f.popSlots(DescriptorSlots(field.Descriptor))
+
+			obj := f.popRef()
+			if obj == nil {
+				return nil, &JavaError{ClassName: NullPointerException}
+			}
+
+			storeField(field, obj.Fields, values)
+			next = pc + 3
+		case OP_INVOKEVIRTUAL:
+			if err := t.invokeVirtual(f, readU2(code, pc+1)); err != nil {
+				return nil, err
+			}
+
+			next = pc + 3
+		case OP_INVOKESPECIAL:
+			if err := t.invokeSpecial(f, readU2(code, pc+1)); err != nil {
+				return nil, err
+			}
+
+			next = pc + 3
+		case OP_INVOKESTATIC:
+			if err := t.invokeStatic(f, readU2(code, pc+1)); err != nil {
+				return nil, err
+			}
+
+			next = pc + 3
+		case OP_INVOKEINTERFACE:
+			if err := t.invokeInterface(f, readU2(code, pc+1)); err != nil {
+				return nil, err
+			}
+
+			next = pc + 5
+		case OP_INVOKEDYNAMIC:
+			if err := t.invokeDynamic(f, readU2(code, pc+1)); err != nil {
+				return nil, err
+			}
+
+			next = pc + 5
+
+		case OP_NEW:
+			obj, err := t.newObject(f, readU2(code, pc+1))
+			if err != nil {
+				return nil, err
+			}
+
+			f.pushRef(obj)
+			next = pc + 3
+		case OP_NEWARRAY:
+			array, err := t.newArray(f, op, uint16(code[pc+1]))
+			if err != nil {
+				return nil, err
+			}
+
+			f.pushRef(array)
+			next = pc + 2
+		case OP_ANEWARRAY:
+			array, err := t.newArray(f, op, readU2(code, pc+1))
+			if err != nil {
+				return nil, err
+			}
+
+			f.pushRef(array)
+			next = pc + 3
+		case OP_MULTIANEWARRAY:
+			array, err := t.newMultiArray(f, readU2(code, pc+1), int(code[pc+3]))
+			if err != nil {
+				return nil, err
+			}
+
+			f.pushRef(array)
+			next = pc + 4
+		case OP_ARRAYLENGTH:
+			array := f.popRef()
+			if array == nil {
+				return nil, &JavaError{ClassName: NullPointerException}
+			}
+
+			f.pushInt(int32(array.ArrayLength()))
+		case OP_IALOAD, OP_LALOAD, OP_FALOAD, OP_DALOAD, OP_AALOAD, OP_BALOAD, OP_CALOAD, OP_SALOAD:
+			if err := arrayLoad(f); err != nil {
+				return nil, err
+			}
+		case OP_IASTORE, OP_LASTORE, OP_FASTORE, OP_DASTORE, OP_AASTORE, OP_BASTORE, OP_CASTORE, OP_SASTORE:
+			if err := arrayStore(f, op); err != nil {
+				return nil, err
+			}
+		case OP_INSTANCEOF:
+			// the class is only resolved when the object is not null
+			if obj := f.popRef(); obj == nil {
+				f.pushInt(0)
+			} else {
+				class, err := f.Method.Class.ConstantPool.ResolveClass(readU2(code, pc+1))
+				if err != nil {
+					return nil, err
+				}
+
+				if obj.IsInstanceOf(class) {
+					f.pushInt(1)
+				} else {
+					f.pushInt(0)
+				}
+			}
+
+			next = pc + 3
+		case OP_CHECKCAST:
+			if obj := f.peek(0).Ref; obj != nil {
+				class, err := f.Method.Class.ConstantPool.ResolveClass(readU2(code, pc+1))
+				if err != nil {
+					return nil, err
+				}
+
+				if !obj.IsInstanceOf(class) {
+					return nil, &JavaError{ClassName: ClassCastException, Message: classCastMessage(obj.Class, class)}
+				}
+			}
+
+			next = pc + 3
+		case OP_MONITORENTER:
+			if err := t.monitorEnter(f.popRef()); err != nil {
+				return nil, err
+			}
+		case OP_MONITOREXIT:
+			if err := t.monitorExit(f.popRef()); err != nil {
+				return nil, err
+			}
+
+		case OP_WIDE:
+			if next, err = t.wide(f, code, pc); err != nil {
+				return nil, err
+			}
+
+		default:
+			return nil, fmt.Errorf("unsupported instruction %s (0x%02x) in method %s at pc %d", OpcodeNames[op], op, f.Method, pc)
+		}
+
+		f.PC = next
+	}
+}
+
+// tableSwitch returns the branch offset of a tableswitch instruction for the key, its operands start at
+// the first offset of the code multiple of four after the opcode.
+func tableSwitch(code []byte, pc int, key int32) int {
+	at := (pc + 4) &^ 3
+
+	low, high := readS4(code, at+4), readS4(code, at+8)
+	if key < low || key > high {
+		return int(readS4(code, at))
+	}
+
+	return int(readS4(code, at+12+4*int(int64(key)-int64(low))))
+}
+
+// lookupSwitch returns the branch offset of a lookupswitch instruction for the key.
+func lookupSwitch(code []byte, pc int, key int32) int {
+	at := (pc + 4) &^ 3
+
+	pairs := int(readS4(code, at+4))
+	for i := 0; i < pairs; i++ {
+		pair := at + 8 + 8*i
+		if readS4(code, pair) == key {
+			return int(readS4(code, pair+4))
+		}
+	}
+
+	return int(readS4(code, at))
+}
+
+// wide executes the instruction modified by a wide instruction at pc, which takes a two bytes local index,
+// and returns the offset of the next instruction.
+func (t *Thread) wide(f *Frame, code []byte, pc int) (int, error) {
+	op, index := code[pc+1], readU2(code, pc+2)
+
+	switch op {
+	case OP_ILOAD, OP_FLOAD, OP_ALOAD:
+		f.push(f.Locals[index])
+	case OP_LLOAD, OP_DLOAD:
+		f.load2(int(index))
+	case OP_ISTORE, OP_FSTORE, OP_ASTORE:
+		f.Locals[index] = f.pop()
+	case OP_LSTORE, OP_DSTORE:
+		f.store2(int(index))
+	case OP_IINC:
+		f.Locals[index].Num += int32(readS2(code, pc+4))
+		return pc + 6, nil
+	default:
+		return 0, NewJavaError(VerifyError, "Bad instruction wide %s in method %s at pc %d", OpcodeNames[op], f.Method, pc)
+	}
+
+	return pc + 4, nil
+}
+
+// ldc pushes an int, float, string or class constant of the constant pool, a class constant is the
+// java/lang/Class object of the class.
+func (t *Thread) ldc(f *Frame, index uint16) error {
+	entry, err := f.Method.Class.ConstantPool.entry(
+		index, CONSTANT_Integer, CONSTANT_Float, CONSTANT_String, CONSTANT_Class, CONSTANT_MethodType, CONSTANT_MethodHandle, CONSTANT_Dynamic,
+	)
+	if err != nil {
+		return err
+	}
+
+	if entry.Tag == CONSTANT_String {
+		str, err := f.Method.Class.ConstantPool.resolveString(index)
+		if err != nil {
+			return err
+		}
+
+		f.pushRef(str)
+
+		return nil
+	}
+
+	if entry.Tag == CONSTANT_Class {
+		class, err := f.Method.Class.ConstantPool.ResolveClass(index)
+		if err != nil {
+			return err
+		}
+
+		mirror, err := class.Mirror()
+		if err != nil {
+			return err
+		}
+
+		f.pushRef(mirror)
+
+		return nil
+	}
+
+	info, ok := entry.Info.(Numeric32BitsInfo)
+	if !ok {
+		return NewJavaError(InternalError, "ldc of a %s is not supported, in method %s", Tags[entry.Tag], f.Method)
+	}
+
+	f.pushInt(int32(info.Value))
+
+	return nil
+}
+
+// ldc2 pushes a long or double constant of the constant pool.
+func (t *Thread) ldc2(f *Frame, index uint16) error {
+	entry, err := f.Method.Class.ConstantPool.entry(index, CONSTANT_Long, CONSTANT_Double)
+	if err != nil {
+		return err
+	}
+
+	f.pushLong(int64(entry.Info.(Numeric64BitsInfo).Value))
+
+	return nil
+}
+
+// resolveField resolves the field of a getstatic, putstatic, getfield or putfield instruction, checking that
+// it is static or not as the instruction expects and that final fields are only set by the initializers
+// of their class. The class of a static field is initialized.
+func (t *Thread) resolveField(f *Frame, index uint16, static bool, put bool) (*Field, error) {
+	field, err := f.Method.Class.ConstantPool.ResolveField(index)
+	if err != nil {
+		return nil, err
+	}
+
+	kind, initializer := "static", "<clinit>"
+	if !static {
+		kind, initializer = "non-static", "<init>"
+	}
+
+	if field.IsStatic() != static {
+		return nil, NewJavaError(IncompatibleClassChangeError, "Expected %s field %s.%s", kind, field.Class.JavaName(), field.Name)
+	}
+
+	if put && field.AccessFlags&ACC_FINAL != 0 {
+		if field.Class != f.Method.Class {
+			return nil, NewJavaError(
+				IllegalAccessError, "Update to %s final field %s.%s attempted from a different class (%s) than the field's declaring class",
+				kind, field.Class.JavaName(), field.Name, f.Method.Class.JavaName(),
+			)
+		}
+
+		if f.Method.Name != initializer {
+			return nil, NewJavaError(
+				IllegalAccessError, "Update to %s final field %s.%s attempted from a different method (%s) than the initializer method %s ",
+				kind, field.Class.JavaName(), field.Name, f.Method.Name, initializer,
+			)
+		}
+	}
+
+	if static {
+		if err := field.Class.Initialize(t); err != nil {
+			return nil, err
+		}
+	}
+
+	return field, nil
+}
+
+// newObject resolves the class of a new instruction, initializes it and allocates an instance of it.
+func (t *Thread) newObject(f *Frame, index uint16) (*Object, error) {
+	class, err := f.Method.Class.ConstantPool.ResolveClass(index)
+	if err != nil {
+		return nil, err
+	}
+
+	if class.AccessFlags&(ACC_INTERFACE|ACC_ABSTRACT) != 0 {
+		return nil, NewJavaError(InstantiationError, "%s", class.JavaName())
+	}
+
+	if err := class.Initialize(t); err != nil {
+		return nil, err
+	}
+
+	return NewObject(class), nil
+}
+
+func readU2(code []byte, at int) uint16 {
+	return binary.BigEndian.Uint16(code[at:])
+}
+
+func readS2(code []byte, at int) int16 {
+	return int16(readU2(code, at))
+}
+
+func readS4(code []byte, at int) int32 {
+	return int32(binary.BigEndian.Uint32(code[at:]))
+}
@@ -0,0 +1,529 @@
+package core_test
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// defineTestClass defines the class in a new application loader.
+func defineTestClass(t *testing.T, content []byte) *core.Class {
+	class, err := newTestLoaders(t, t.TempDir()).Application.DefineClass("", content)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	return class
+}
+
+// invokeSlots invokes a static method of the class with the given argument slots.
+func invokeSlots(t *testing.T, class *core.Class, name string, descriptor string, args ...[]core.Slot) ([]core.Slot, error) {
+	method := class.DeclaredMethod(name, descriptor)
+	if method == nil {
+		t.Fatalf("Expected %s to declare %s%s", class.Name, name, descriptor)
+	}
+
+	slots := []core.Slot{}
+	for _, arg := range args {
+		slots = append(slots, arg...)
+	}
+
+	return core.NewThread(core.MainThreadName).Invoke(method, slots)
+}
+
+// invokeInt invokes a static method of the class that takes and returns ints.
+func invokeInt(t *testing.T, class *core.Class, name string, descriptor string, args ...int32) (int32, error) {
+	slots := make([][]core.Slot, len(args))
+	for i, arg := range args {
+		slots[i] = []core.Slot{{Num: arg}}
+	}
+
+	ret, err := invokeSlots(t, class, name, descriptor, slots...)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(ret) != 1 {
+		t.Fatalf("Expected one slot to be returned, got %v", ret)
+	}
+
+	return ret[0].Num, nil
+}
+
+// concat joins pieces of bytecode.
+func concat(pieces ...[]byte) []byte {
+	out := []byte{}
+	for _, piece := range pieces {
+		out = append(out, piece...)
+	}
+
+	return out
+}
+
+func s4(v int32) []byte {
+	return u4(uint32(v))
+}
+
+func TestShouldRunTheMainMethodAndExitWithTheSystemExitCode(t *testing.T) {
+	b := newTestClassBuilder()
+	fib := b.methodref("Fibonacci", "fib", "(I)I")
+	exit := b.methodref("java/lang/System", "exit", "(I)V")
+
+	fibCode := concat(
+		[]byte{core.OP_ILOAD_0, core.OP_ICONST_2, core.OP_IF_ICMPGE, 0, 5, core.OP_ILOAD_0, core.OP_IRETURN},
+		[]byte{core.OP_ILOAD_0, core.OP_ICONST_1, core.OP_ISUB, core.OP_INVOKESTATIC}, u2(fib),
+		[]byte{core.OP_ILOAD_0, core.OP_ICONST_2, core.OP_ISUB, core.OP_INVOKESTATIC}, u2(fib),
+		[]byte{core.OP_IADD, core.OP_IRETURN},
+	)
+
+	mainCode := concat(
+		[]byte{core.OP_BIPUSH, 10, core.OP_INVOKESTATIC}, u2(fib),
+		[]byte{core.OP_INVOKESTATIC}, u2(exit),
+		[]byte{core.OP_RETURN},
+	)
+
+	class := defineTestClass(t, b.build(core.ACC_PUBLIC|core.ACC_SUPER, "Fibonacci", "java/lang/Object", nil, nil, [][]byte{
+		b.member(core.ACC_STATIC, "fib", "(I)I", b.code(3, 1, fibCode...)),
+		b.member(core.ACC_PUBLIC|core.ACC_STATIC, "main", "([Ljava/lang/String;)V", b.code(1, 1, mainCode...)),
+	}, nil))
+
+	err := core.ExecuteClass(class)
+
+	var exitErr *core.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Expected the program to call System.exit, got %v", err)
+	}
+
+	if exitErr.Code != 55 {
+		t.Errorf("Expected exit code 55, got %d", exitErr.Code)
+	}
+}
+
+func TestShouldGiveMainAnEmptyArrayOfArguments(t *testing.T) {
+	b := newTestClassBuilder()
+	exit := b.methodref("java/lang/System", "exit", "(I)V")
+
+	// System.exit(args.length + 1)
+	class := defineTestClass(t, b.build(core.ACC_PUBLIC|core.ACC_SUPER, "Args", "java/lang/Object", nil, nil, [][]byte{
+		b.member(core.ACC_PUBLIC|core.ACC_STATIC, "main", "([Ljava/lang/String;)V", b.code(2, 1, concat(
+			[]byte{core.OP_ALOAD_0, core.OP_ARRAYLENGTH, core.OP_ICONST_1, core.OP_IADD, core.OP_INVOKESTATIC}, u2(exit), []byte{core.OP_RETURN},
+		)...)),
+	}, nil))
+
+	var exitErr *core.ExitError
+	if err := core.ExecuteClass(class); !errors.As(err, &exitErr) || exitErr.Code != 1 {
+		t.Errorf("Expected the program to exit with 1, got %v", err)
+	}
+}
+
+func TestShouldFailWhenThereIsNoMainMethod(t *testing.T) {
+	class := defineTestClass(t, minimalClassFile("NoMain"))
+
+	if err := core.ExecuteClass(class); err == nil || !strings.HasPrefix(err.Error(), "main method not found in class NoMain") {
+		t.Errorf("Expected main method not found error, got %v", err)
+	}
+}
+
+func TestShouldApplyIntArithmeticWithJavaSemantics(t *testing.T) {
+	b := newTestClassBuilder()
+
+	ops := map[string]byte{
+		"add": core.OP_IADD, "sub": core.OP_ISUB, "mul": core.OP_IMUL, "div": core.OP_IDIV, "rem": core.OP_IREM,
+		"shl": core.OP_ISHL, "shr": core.OP_ISHR, "ushr": core.OP_IUSHR, "and": core.OP_IAND, "or": core.OP_IOR, "xor": core.OP_IXOR,
+	}
+
+	methods := [][]byte{}
+	for name, op := range ops {
+		methods = append(methods, b.member(core.ACC_STATIC, name, "(II)I", b.code(2, 2, core.OP_ILOAD_0, core.OP_ILOAD_1, op, core.OP_IRETURN)))
+	}
+
+	for name, op := range map[string]byte{"neg": core.OP_INEG, "i2b": core.OP_I2B, "i2c": core.OP_I2C, "i2s": core.OP_I2S} {
+		methods = append(methods, b.member(core.ACC_STATIC, name, "(I)I", b.code(1, 1, core.OP_ILOAD_0, op, core.OP_IRETURN)))
+	}
+
+	class := defineTestClass(t, b.build(core.ACC_PUBLIC, "Calc", "java/lang/Object", nil, nil, methods, nil))
+
+	tests := []struct {
+		name     string
+		args     []int32
+		expected int32
+	}{
+		{"add", []int32{math.MaxInt32, 1}, math.MinInt32},
+		{"sub", []int32{math.MinInt32, 1}, math.MaxInt32},
+		{"mul", []int32{65536, 65536}, 0},
+		{"div", []int32{-7, 2}, -3},
+		{"div", []int32{math.MinInt32, -1}, math.MinInt32},
+		{"rem", []int32{-7, 2}, -1},
+		{"rem", []int32{math.MinInt32, -1}, 0},
+		{"shl", []int32{1, 33}, 2},
+		{"shr", []int32{-8, 1}, -4},
+		{"ushr", []int32{-1, 28}, 15},
+		{"and", []int32{12, 10}, 8},
+		{"or", []int32{12, 10}, 14},
+		{"xor", []int32{12, 10}, 6},
+		{"neg", []int32{math.MinInt32}, math.MinInt32},
+		{"i2b", []int32{200}, -56},
+		{"i2c", []int32{-1}, 65535},
+		{"i2s", []int32{40000}, -25536},
+	}
+
+	for _, test := range tests {
+		descriptor := "(II)I"
+		if len(test.args) == 1 {
+			descriptor = "(I)I"
+		}
+
+		result, err := invokeInt(t, class, test.name, descriptor, test.args...)
+		if err != nil || result != test.expected {
+			t.Errorf("Expected %s%v to be %d, got %d (%v)", test.name, test.args, test.expected, result, err)
+		}
+	}
+
+	_, err := invokeInt(t, class, "div", "(II)I", 1, 0)
+	if !core.IsJavaError(err, core.ArithmeticException) || err.Error() != "java.lang.ArithmeticException: / by zero" {
+		t.Errorf("Expected an ArithmeticException, got %v", err)
+	}
+
+	if _, err := invokeInt(t, class, "rem", "(II)I", 1, 0); !core.IsJavaError(err, core.ArithmeticException) {
+		t.Errorf("Expected an ArithmeticException, got %v", err)
+	}
+}
+
+func TestShouldApplyLongFloatAndDoubleArithmeticWithJavaSemantics(t *testing.T) {
+	b := newTestClassBuilder()
+	longConstant := b.entry(core.CONSTANT_Long, u8(0x123456789abcdef0))
+	doubleConstant := b.entry(core.CONSTANT_Double, u8(math.Float64bits(2.5)))
+
+	binary := func(name string, descriptor string, load byte, op byte, ret byte) []byte {
+		second := load + 1
+		if load == core.OP_LLOAD_0 || load == core.OP_DLOAD_0 {
+			second = load + 2
+		}
+
+		if descriptor == "(JI)J" {
+			second = core.OP_ILOAD_2
+		}
+
+		return b.member(core.ACC_STATIC, name, descriptor, b.code(4, 4, load, second, op, ret))
+	}
+
+	unary := func(name string, descriptor string, load byte, op byte, ret byte) []byte {
+		return b.member(core.ACC_STATIC, name, descriptor, b.code(2, 2, load, op, ret))
+	}
+
+	class := defineTestClass(t, b.build(core.ACC_PUBLIC, "Wide", "java/lang/Object", nil, nil, [][]byte{
+		binary("ldiv", "(JJ)J", core.OP_LLOAD_0, core.OP_LDIV, core.OP_LRETURN),
+		binary("lrem", "(JJ)J", core.OP_LLOAD_0, core.OP_LREM, core.OP_LRETURN),
+		binary("lshl", "(JI)J", core.OP_LLOAD_0, core.OP_LSHL, core.OP_LRETURN),
+		binary("lshr", "(JI)J", core.OP_LLOAD_0, core.OP_LSHR, core.OP_LRETURN),
+		binary("lushr", "(JI)J", core.OP_LLOAD_0, core.OP_LUSHR, core.OP_LRETURN),
+		binary("lcmp", "(JJ)I", core.OP_LLOAD_0, core.OP_LCMP, core.OP_IRETURN),
+		binary("frem", "(FF)F", core.OP_FLOAD_0, core.OP_FREM, core.OP_FRETURN),
+		binary("fcmpl", "(FF)I", core.OP_FLOAD_0, core.OP_FCMPL, core.OP_IRETURN),
+		binary("fcmpg", "(FF)I", core.OP_FLOAD_0, core.OP_FCMPG, core.OP_IRETURN),
+		binary("drem", "(DD)D", core.OP_DLOAD_0, core.OP_DREM, core.OP_DRETURN),
+		binary("dcmpl", "(DD)I", core.OP_DLOAD_0, core.OP_DCMPL, core.OP_IRETURN),
+		binary("dcmpg", "(DD)I", core.OP_DLOAD_0, core.OP_DCMPG, core.OP_IRETURN),
+		b.member(core.ACC_STATIC, "square", "(J)J", b.code(4, 2, core.OP_LLOAD_0, core.OP_DUP2, core.OP_LMUL, core.OP_LRETURN)),
+		unary("f2i", "(F)I", core.OP_FLOAD_0, core.OP_F2I, core.OP_IRETURN),
+		unary("d2l", "(D)J", core.OP_DLOAD_0, core.OP_D2L, core.OP_LRETURN),
+		unary("d2f", "(D)F", core.OP_DLOAD_0, core.OP_D2F, core.OP_FRETURN),
+		unary("l2f", "(J)F", core.OP_LLOAD_0, core.OP_L2F, core.OP_FRETURN),
+		unary("l2i", "(J)I", core.OP_LLOAD_0, core.OP_L2I, core.OP_IRETURN),
+		unary("i2l", "(I)J", core.OP_ILOAD_0, core.OP_I2L, core.OP_LRETURN),
+		unary("dneg", "(D)D", core.OP_DLOAD_0, core.OP_DNEG, core.OP_DRETURN),
+		b.member(core.ACC_STATIC, "longConstant", "()J", b.code(2, 0, concat([]byte{core.OP_LDC2_W}, u2(longConstant), []byte{core.OP_LRETURN})...)),
+		b.member(core.ACC_STATIC, "doubleConstant", "()D", b.code(2, 0, concat([]byte{core.OP_LDC2_W}, u2(doubleConstant), []byte{core.OP_DRETURN})...)),
+	}, nil))
+
+	long := func(name string, descriptor string, args ...[]core.Slot) int64 {
+		ret, err := invokeSlots(t, class, name, descriptor, args...)
+		if err != nil || len(ret) != 2 {
+			t.Fatalf("Expected %s to return a long, got %v (%v)", name, ret, err)
+		}
+
+		return core.SlotsLong(ret)
+	}
+
+	integer := func(v int32) []core.Slot {
+		return []core.Slot{{Num: v}}
+	}
+
+	float := func(v float32) []core.Slot {
+		return []core.Slot{core.FloatSlot(v)}
+	}
+
+	nan := float32(math.NaN())
+
+	longTests := []struct {
+		name     string
+		args     [][]core.Slot
+		expected int64
+	}{
+		{"ldiv", [][]core.Slot{core.LongSlots(math.MinInt64), core.LongSlots(-1)}, math.MinInt64},
+		{"lrem", [][]core.Slot{core.LongSlots(math.MinInt64), core.LongSlots(-1)}, 0},
+		{"lrem", [][]core.Slot{core.LongSlots(-7000000000), core.LongSlots(3)}, -1},
+		{"lshl", [][]core.Slot{core.LongSlots(1), integer(65)}, 2},
+		{"lshr", [][]core.Slot{core.LongSlots(math.MinInt64), integer(63)}, -1},
+		{"lushr", [][]core.Slot{core.LongSlots(-1), integer(-1)}, 1},
+		{"square", [][]core.Slot{core.LongSlots(3000000000)}, 9000000000000000000},
+		{"d2l", [][]core.Slot{core.DoubleSlots(1e19)}, math.MaxInt64},
+		{"d2l", [][]core.Slot{core.DoubleSlots(-1e19)}, math.MinInt64},
+		{"d2l", [][]core.Slot{core.DoubleSlots(math.NaN())}, 0},
+		{"d2l", [][]core.Slot{core.DoubleSlots(-2.9)}, -2},
+		{"i2l", [][]core.Slot{integer(-1)}, -1},
+		{"longConstant", nil, 0x123456789abcdef0},
+	}
+
+	for _, test := range longTests {
+		descriptor := ""
+		for _, method := range class.Methods {
+			if method.Name == test.name {
+				descriptor = method.Descriptor
+			}
+		}
+
+		if result := long(test.name, descriptor, test.args...); result != test.expected {
+			t.Errorf("Expected %s%v to be %d, got %d", test.name, test.args, test.expected, result)
+		}
+	}
+
+	if _, err := invokeSlots(t, class, "ldiv", "(JJ)J", core.LongSlots(1), core.LongSlots(0)); !core.IsJavaError(err, core.ArithmeticException) {
+		t.Errorf("Expected an ArithmeticException, got %v", err)
+	}
+
+	intTests := []struct {
+		name       string
+		descriptor string
+		args       [][]core.Slot
+		expected   int32
+	}{
+		{"lcmp", "(JJ)I", [][]core.Slot{core.LongSlots(-1), core.LongSlots(1 << 32)}, -1},
+		{"fcmpl", "(FF)I", [][]core.Slot{float(nan), float(1)}, -1},
+		{"fcmpg", "(FF)I", [][]core.Slot{float(nan), float(1)}, 1},
+		{"fcmpl", "(FF)I", [][]core.Slot{float(2), float(1)}, 1},
+		{"dcmpl", "(DD)I", [][]core.Slot{core.DoubleSlots(1), core.DoubleSlots(math.NaN())}, -1},
+		{"dcmpg", "(DD)I", [][]core.Slot{core.DoubleSlots(1), core.DoubleSlots(math.NaN())}, 1},
+		{"dcmpg", "(DD)I", [][]core.Slot{core.DoubleSlots(0), core.DoubleSlots(math.Copysign(0, -1))}, 0},
+		{"f2i", "(F)I", [][]core.Slot{float(nan)}, 0},
+		{"f2i", "(F)I", [][]core.Slot{float(3e10)}, math.MaxInt32},
+		{"f2i", "(F)I", [][]core.Slot{float(-3e10)}, math.MinInt32},
+		{"f2i", "(F)I", [][]core.Slot{float(-1.9)}, -1},
+		{"l2i", "(J)I", [][]core.Slot{core.LongSlots(0x100000001)}, 1},
+	}
+
+	for _, test := range intTests {
+		ret, err := invokeSlots(t, class, test.name, test.descriptor, test.args...)
+		if err != nil || len(ret) != 1 || ret[0].Num != test.expected {
+			t.Errorf("Expected %s%v to be %d, got %v (%v)", test.name, test.args, test.expected, ret, err)
+		}
+	}
+
+	floatTests := []struct {
+		name       string
+		descriptor string
+		args       [][]core.Slot
+		expected   uint64
+	}{
+		{"frem", "(FF)F", [][]core.Slot{float(5.5), float(-2)}, uint64(math.Float32bits(1.5))},
+		{"frem", "(FF)F", [][]core.Slot{float(-5.5), float(2)}, uint64(math.Float32bits(-1.5))},
+		{"d2f", "(D)F", [][]core.Slot{core.DoubleSlots(1e40)}, uint64(math.Float32bits(float32(math.Inf(1))))},
+		{"l2f", "(J)F", [][]core.Slot{core.LongSlots(math.MaxInt64)}, 0x5f000000},
+		{"drem", "(DD)D", [][]core.Slot{core.DoubleSlots(1), core.DoubleSlots(math.Inf(1))}, math.Float64bits(1)},
+		{"dneg", "(D)D", [][]core.Slot{core.DoubleSlots(0)}, 0x8000000000000000},
+		{"doubleConstant", "()D", nil, math.Float64bits(2.5)},
+	}
+
+	for _, test := range floatTests {
+		ret, err := invokeSlots(t, class, test.name, test.descriptor, test.args...)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		bits := uint64(math.Float32bits(core.SlotFloat(ret[0])))
+		if len(ret) == 2 {
+			bits = math.Float64bits(core.SlotsDouble(ret))
+		}
+
+		if bits != test.expected {
+			t.Errorf("Expected %s%v to be %x, got %x", test.name, test.args, test.expected, bits)
+		}
+	}
+
+	ret, err := invokeSlots(t, class, "drem", "(DD)D", core.DoubleSlots(1), core.DoubleSlots(0))
+	if err != nil || !math.IsNaN(core.SlotsDouble(ret)) {
+		t.Errorf("Expected the remainder of a division by zero to be NaN, got %v (%v)", ret, err)
+	}
+}
+
+func TestShouldBranchWithSwitches(t *testing.T) {
+	b := newTestClassBuilder()
+
+	// the cases return 10, 20 and 30, and the default case returns -1
+	cases := []byte{core.OP_BIPUSH, 10, core.OP_IRETURN, core.OP_BIPUSH, 20, core.OP_IRETURN, core.OP_BIPUSH, 30, core.OP_IRETURN, core.OP_ICONST_M1, core.OP_IRETURN}
+
+	// the switch is at pc 1, padded to pc 4, and the cases start at pc 28
+	table := concat([]byte{core.OP_ILOAD_0, core.OP_TABLESWITCH, 0, 0}, s4(36), s4(0), s4(2), s4(27), s4(30), s4(33), cases)
+	lookup := concat([]byte{core.OP_ILOAD_0, core.OP_LOOKUPSWITCH, 0, 0}, s4(36), s4(2), s4(-5), s4(27), s4(1000), s4(33), cases)
+
+	// tables at the ends of the int range, the cases start at pc 24
+	maxTable := concat([]byte{core.OP_ILOAD_0, core.OP_TABLESWITCH, 0, 0}, s4(32), s4(math.MaxInt32-1), s4(math.MaxInt32), s4(23), s4(26), cases)
+	minTable := concat([]byte{core.OP_ILOAD_0, core.OP_TABLESWITCH, 0, 0}, s4(32), s4(math.MinInt32), s4(math.MinInt32+1), s4(23), s4(26), cases)
+
+	class := defineTestClass(t, b.build(core.ACC_PUBLIC, "Switch", "java/lang/Object", nil, nil, [][]byte{
+		b.member(core.ACC_STATIC, "table", "(I)I", b.code(1, 1, table...)),
+		b.member(core.ACC_STATIC, "lookup", "(I)I", b.code(1, 1, lookup...)),
+		b.member(core.ACC_STATIC, "maxTable", "(I)I", b.code(1, 1, maxTable...)),
+		b.member(core.ACC_STATIC, "minTable", "(I)I", b.code(1, 1, minTable...)),
+	}, nil))
+
+	for key, expected := range map[int32]int32{0: 10, 1: 20, 2: 30, -1: -1, 3: -1} {
+		if result, err := invokeInt(t, class, "table", "(I)I", key); err != nil || result != expected {
+			t.Errorf("Expected tableswitch of %d to return %d, got %d (%v)", key, expected, result, err)
+		}
+	}
+
+	for key, expected := range map[int32]int32{-5: 10, 1000: 30, 0: -1, 1: -1} {
+		if result, err := invokeInt(t, class, "lookup", "(I)I", key); err != nil || result != expected {
+			t.Errorf("Expected lookupswitch of %d to return %d, got %d (%v)", key, expected, result, err)
+		}
+	}
+
+	for _, test := range []struct {
+		method   string
+		key      int32
+		expected int32
+	}{
+		{"maxTable", math.MaxInt32 - 1, 10}, {"maxTable", math.MaxInt32, 20}, {"maxTable", math.MinInt32, -1}, {"maxTable", 0, -1},
+		{"minTable", math.MinInt32, 10}, {"minTable", math.MinInt32 + 1, 20}, {"minTable", math.MaxInt32, -1}, {"minTable", 0, -1},
+	} {
+		if result, err := invokeInt(t, class, test.method, "(I)I", test.key); err != nil || result != test.expected {
+			t.Errorf("Expected %s of %d to return %d, got %d (%v)", test.method, test.key, test.expected, result, err)
+		}
+	}
+}
+
+func TestShouldThrowAnInternalErrorForUnsupportedConstants(t *testing.T) {
+	b := newTestClassBuilder()
+	methodType := b.methodType("()V")
+	internalError := b.class(core.InternalError)
+
+	// the handler of the second method catches the InternalError and returns 1
+	ldc := []byte{core.OP_LDC, byte(methodType), core.OP_POP, core.OP_ICONST_0, core.OP_IRETURN, core.OP_POP, core.OP_ICONST_1, core.OP_IRETURN}
+
+	class := defineTestClass(t, b.build(core.ACC_PUBLIC, "Constants", "java/lang/Object", nil, nil, [][]byte{
+		b.member(core.ACC_STATIC, "methodType", "()I", b.code(1, 0, ldc...)),
+		b.member(core.ACC_STATIC, "caught", "()I", codeWithHandlers(b, 1, 0, ldc, [][]byte{handler(0, 5, 5, internalError)})),
+	}, nil))
+
+	if _, err := invokeInt(t, class, "methodType", "()I"); !core.IsJavaError(err, core.InternalError) {
+		t.Errorf("Expected ldc of a MethodType to throw an InternalError, got %v", err)
+	}
+
+	if result, err := invokeInt(t, class, "caught", "()I"); err != nil || result != 1 {
+		t.Errorf("Expected the InternalError to be caught, got %d (%v)", result, err)
+	}
+}
+
+func TestShouldLoopWithLocalsAndStaticFields(t *testing.T) {
+	b := newTestClassBuilder()
+	total := b.fieldref("Loop", "total", "I")
+
+	// for (int i = 1; i <= n; i++) total += i; return total;
+	sum := concat(
+		[]byte{core.OP_ICONST_1, core.OP_ISTORE_1},
+		[]byte{core.OP_ILOAD_1, core.OP_ILOAD_0, core.OP_IF_ICMPGT, 0, 17},
+		[]byte{core.OP_GETSTATIC}, u2(total),
+		[]byte{core.OP_ILOAD_1, core.OP_IADD, core.OP_PUTSTATIC}, u2(total),
+		[]byte{core.OP_IINC, 1, 1, core.OP_GOTO, 0xff, 0xf0},
+		[]byte{core.OP_WIDE, core.OP_ILOAD, 0, 1, core.OP_POP},
+		[]byte{core.OP_GETSTATIC}, u2(total),
+		[]byte{core.OP_IRETURN},
+	)
+
+	// total starts at 1000 when the class is initialized
+	clinit := concat([]byte{core.OP_SIPUSH, 0x03, 0xe8, core.OP_PUTSTATIC}, u2(total), []byte{core.OP_RETURN})
+
+	class := defineTestClass(t, b.build(core.ACC_PUBLIC, "Loop", "java/lang/Object", nil,
+		[][]byte{b.member(core.ACC_STATIC, "total", "I")},
+		[][]byte{
+			b.member(core.ACC_STATIC, "<clinit>", "()V", b.code(1, 0, clinit...)),
+			b.member(core.ACC_STATIC, "sum", "(I)I", b.code(2, 2, sum...)),
+		}, nil))
+
+	if err := class.Initialize(core.NewThread(core.MainThreadName)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result, err := invokeInt(t, class, "sum", "(I)I", 10); err != nil || result != 1055 {
+		t.Errorf("Expected the sum to be 1055, got %d (%v)", result, err)
+	}
+}
+
+func TestShouldReportBadBytecodeAsAVerifyError(t *testing.T) {
+	b := newTestClassBuilder()
+	crash := b.methodref("Bad", "crash", "()I")
+
+	static := core.ACC_STATIC
+	class := defineTestClass(t, b.build(core.ACC_PUBLIC, "Bad", "java/lang/Object", nil, nil, [][]byte{
+		b.member(static, "underflow", "()I", b.code(1, 0, core.OP_IADD, core.OP_IRETURN)),
+		b.member(static, "local", "()I", b.code(1, 1, core.OP_ILOAD, 3, core.OP_IRETURN)),
+		b.member(static, "truncated", "()I", b.code(1, 0, core.OP_SIPUSH, 1)),
+		b.member(static, "fallOff", "()I", b.code(1, 0, core.OP_ICONST_1)),
+		b.member(static, "jumpInside", "()I", b.code(1, 0, core.OP_GOTO, 0, 4, core.OP_SIPUSH, 0, 1, core.OP_IRETURN)),
+		b.member(static|core.ACC_NATIVE, "crash", "()I"),
+		b.member(static, "callCrash", "()I", b.code(1, 0, concat([]byte{core.OP_INVOKESTATIC}, u2(crash), []byte{core.OP_IRETURN})...)),
+	}, nil))
+
+	for _, test := range []struct {
+		method string
+		reason string
+	}{
+		{"underflow", "at pc 0: operand stack underflow"},
+		{"local", "at pc 0: local 3 out of the 1 locals of the frame"},
+		{"truncated", "at pc 0: truncated or unknown instruction"},
+		{"fallOff", "at pc 1: no instruction starts there"},
+		{"jumpInside", "at pc 4: no instruction starts there"},
+	} {
+		if _, err := invokeInt(t, class, test.method, "()I"); !core.IsJavaError(err, core.VerifyError) || !strings.HasSuffix(err.Error(), test.reason) {
+			t.Errorf("Expected %s to fail with a VerifyError %q, got %v", test.method, test.reason, err)
+		}
+	}
+
+	// the panics of the VM itself are not blamed on the bytecode
+	registerNative(t, "Bad", "crash", "()I", func(call *core.NativeCall) ([]core.Slot, error) {
+		var obj *core.Object
+		return []core.Slot{{Num: int32(len(obj.Fields))}}, nil
+	})
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("Expected the panic of the native method to reach the caller")
+		}
+	}()
+
+	_, err := invokeInt(t, class, "callCrash", "()I")
+	t.Errorf("Expected a panic, got %v", err)
+}
+
+func TestShouldThrowWhenTheStackOverflows(t *testing.T) {
+	b := newTestClassBuilder()
+	recurse := b.methodref("Recursive", "recurse", "()V")
+
+	class := defineTestClass(t, b.build(core.ACC_PUBLIC, "Recursive", "java/lang/Object", nil, nil, [][]byte{
+		b.member(core.ACC_STATIC, "recurse", "()V", b.code(0, 0, concat([]byte{core.OP_INVOKESTATIC}, u2(recurse), []byte{core.OP_RETURN})...)),
+		b.member(core.ACC_STATIC, "overflow", "()I", b.code(1, 0, core.OP_ICONST_1, core.OP_ICONST_2, core.OP_IRETURN)),
+	}, nil))
+
+	_, err := core.NewThread(core.MainThreadName).Invoke(class.DeclaredMethod("recurse", "()V"), nil)
+	if !core.IsJavaError(err, core.StackOverflowError) {
+		t.Errorf("Expected a StackOverflowError, got %v", err)
+	}
+
+	if _, err := invokeInt(t, class, "overflow", "()I"); !core.IsJavaError(err, core.VerifyError) {
+		t.Errorf("Expected a VerifyError when the operand stack overflows, got %v", err)
+	}
+}
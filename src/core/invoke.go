@@ -0,0 +1,174 @@
+package core
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-6.html#jvms-6.5.invokevirtual
+
+// invokeStatic resolves the method of an invokestatic instruction, initializes its class and invokes it
+// with the arguments on the operand stack, pushing its return value.
+func (t *Thread) invokeStatic(f *Frame, index uint16) error {
+	pool := f.Method.Class.ConstantPool
+
+	entry, err := pool.entry(index, CONSTANT_Methodref, CONSTANT_InterfaceMethodref)
+	if err != nil {
+		return err
+	}
+
+	method, err := resolveInvoked(pool, index, entry.Tag)
+	if err != nil {
+		return err
+	}
+
+	if !method.IsStatic() {
+		return NewJavaError(IncompatibleClassChangeError, "Expected static method '%s'", method)
+	}
+
+	if err := method.Class.Initialize(t); err != nil {
+		return err
+	}
+
+	return t.invokeAndPush(f, method, f.popSlots(method.ArgSlots))
+}
+
+// invokeSpecial resolves the method of an invokespecial instruction, used for initializers, private
+// methods and super calls, and invokes it on the object on the operand stack.
+//
+// The method is looked up from the class of the resolved method, except for super calls, where the resolved
+// method is declared by a superclass of the current class and the lookup starts from the direct superclass
+// of the current class. Class files are treated as if they have ACC_SUPER, like the JVM does since Java SE 8.
+func (t *Thread) invokeSpecial(f *Frame, index uint16) error {
+	pool := f.Method.Class.ConstantPool
+
+	entry, err := pool.entry(index, CONSTANT_Methodref, CONSTANT_InterfaceMethodref)
+	if err != nil {
+		return err
+	}
+
+	resolved, err := resolveInvoked(pool, index, entry.Tag)
+	if err != nil {
+		return err
+	}
+
+	if resolved.IsStatic() {
+		return NewJavaError(IncompatibleClassChangeError, "Expecting non-static method '%s'", resolved)
+	}
+
+	args := f.popSlots(resolved.ArgSlots)
+	if args[0].Ref == nil {
+		return &JavaError{ClassName: NullPointerException}
+	}
+
+	current := f.Method.Class
+
+	class, err := pool.ResolveClass(entry.Info.(ConstantPoolIndexableInfo).ClassIndex)
+	if err != nil {
+		return err
+	}
+
+	if resolved.Name != "<init>" && !class.IsInterface() && class != current && current.IsSubclassOf(class) {
+		class = current.Super
+	}
+
+	method, err := class.lookupSpecial(resolved.Name, resolved.Descriptor)
+	if err != nil {
+		return err
+	}
+
+	if method == nil || method.IsAbstract() {
+		return abstractMethodError(args[0].Ref.Class, resolved)
+	}
+
+	return t.invokeAndPush(f, method, args)
+}
+
+// invokeVirtual resolves the method of an invokevirtual instruction and invokes the method selected for the
+// class of the object on the operand stack.
+func (t *Thread) invokeVirtual(f *Frame, index uint16) error {
+	resolved, err := f.Method.Class.ConstantPool.ResolveMethod(index)
+	if err != nil {
+		return err
+	}
+
+	if resolved.IsStatic() {
+		return NewJavaError(IncompatibleClassChangeError, "Expecting non-static method '%s'", resolved)
+	}
+
+	args := f.popSlots(resolved.ArgSlots)
+	if args[0].Ref == nil {
+		return &JavaError{ClassName: NullPointerException}
+	}
+
+	method, err := args[0].Ref.Class.SelectMethod(resolved)
+	if err != nil {
+		return err
+	}
+
+	return t.invokeAndPush(f, method, args)
+}
+
+// invokeInterface resolves the interface method of an invokeinterface instruction and invokes the method
+// selected for the class of the object on the operand stack, which must implement the interface and be public.
+func (t *Thread) invokeInterface(f *Frame, index uint16) error {
+	pool := f.Method.Class.ConstantPool
+
+	resolved, err := pool.ResolveInterfaceMethod(index)
+	if err != nil {
+		return err
+	}
+
+	if resolved.IsStatic() {
+		return NewJavaError(IncompatibleClassChangeError, "Expected instance not static method '%s'", resolved)
+	}
+
+	args := f.popSlots(resolved.ArgSlots)
+	receiver := args[0].Ref
+	if receiver == nil {
+		return &JavaError{ClassName: NullPointerException}
+	}
+
+	// the entry was checked when the method was resolved
+	entry, _ := pool.entry(index, CONSTANT_InterfaceMethodref)
+
+	iface, err := pool.ResolveClass(entry.Info.(ConstantPoolIndexableInfo).ClassIndex)
+	if err != nil {
+		return err
+	}
+
+	if !receiver.IsInstanceOf(iface) {
+		return NewJavaError(
+			IncompatibleClassChangeError, "Class %s does not implement the requested interface %s", receiver.Class.JavaName(), iface.JavaName(),
+		)
+	}
+
+	method, err := receiver.Class.SelectMethod(resolved)
+	if err != nil {
+		return err
+	}
+
+	if !resolved.IsPrivate() && !method.IsPublic() {
+		return NewJavaError(IllegalAccessError, "'%s'", method)
+	}
+
+	return t.invokeAndPush(f, method, args)
+}
+
+// resolveInvoked resolves the method or interface method reference of an invoke instruction.
+func resolveInvoked(pool *RuntimeConstantPool, index uint16, tag uint8) (*Method, error) {
+	if tag == CONSTANT_Methodref {
+		return pool.ResolveMethod(index)
+	}
+
+	return pool.ResolveInterfaceMethod(index)
+}
+
+// invokeAndPush invokes the method and pushes its return value on the operand stack of the frame.
+func (t *Thread) invokeAndPush(f *Frame, method *Method, args []Slot) error {
+	ret, err := t.Invoke(method, args)
+	if err != nil {
+		return err
+	}
+
+	for _, slot := range ret {
+		f.push(slot)
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package core
+
+import "encoding/binary"
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-6.html#jvms-6.5.invokedynamic
+
+// callSite is a linked invokedynamic call site.
+type callSite struct {
+	// descriptor is the method descriptor of the call site, the arguments it takes and the value it returns.
+	descriptor string
+	// target is what the call site invokes, it gets the arguments from the operand stack.
+	target func(t *Thread, args []Slot) ([]Slot, error)
+}
+
+// bootstrapMethod links a call site of the caller class, with the given name and descriptor, to its target.
+// It gets the constant pool indexes of the static arguments of the call site.
+type bootstrapMethod func(caller *Class, name string, descriptor string, args []uint16) (func(t *Thread, args []Slot) ([]Slot, error), error)
+
+// bootstrapMethods are the bootstrap methods the runtime implements itself, matched by the symbolic reference of
+// their method handle, since the java.lang.invoke machinery does not run yet.
+var bootstrapMethods = map[string]bootstrapMethod{
+	"java/lang/invoke/LambdaMetafactory.metafactory(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;" +
+		"Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodHandle;Ljava/lang/invoke/MethodType;)" +
+		"Ljava/lang/invoke/CallSite;": lambdaMetafactory,
+	"java/lang/invoke/LambdaMetafactory.altMetafactory(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;" +
+		"Ljava/lang/invoke/MethodType;[Ljava/lang/Object;)Ljava/lang/invoke/CallSite;": lambdaAltMetafactory,
+	"java/lang/invoke/StringConcatFactory.makeConcat(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;" +
+		"Ljava/lang/invoke/MethodType;)Ljava/lang/invoke/CallSite;": makeConcat,
+	"java/lang/invoke/StringConcatFactory.makeConcatWithConstants(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;" +
+		"Ljava/lang/invoke/MethodType;Ljava/lang/String;[Ljava/lang/Object;)Ljava/lang/invoke/CallSite;": makeConcatWithConstants,
+}
+
+// invokeDynamic links the call site of an invokedynamic instruction, the first time it runs, and invokes its
+// target with the arguments on the operand stack, pushing its result.
+func (t *Thread) invokeDynamic(f *Frame, index uint16) error {
+	site, err := f.Method.Class.ConstantPool.resolveCallSite(index)
+	if err != nil {
+		return err
+	}
+
+	// the descriptor was checked when the call site was linked
+	slots, _ := ParameterSlots(site.descriptor)
+
+	ret, err := site.target(t, f.popSlots(slots))
+	if err != nil {
+		return err
+	}
+
+	for _, slot := range ret {
+		f.push(slot)
+	}
+
+	return nil
+}
+
+// resolveCallSite links the call site of the CONSTANT_InvokeDynamic_info entry at the given index (JVMS 5.4.3.6)
+// with its bootstrap method, which must be one the runtime implements.
+//
+// Every invokedynamic instruction of the entry gets the same call site. It makes no difference since the
+// bootstrap methods of the runtime link the same target for the same arguments.
+func (p *RuntimeConstantPool) resolveCallSite(index uint16) (*callSite, error) {
+	entry, err := p.entry(index, CONSTANT_InvokeDynamic)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := p.resolve(index, func() (any, error) {
+		info := entry.Info.(DynamicInfo)
+
+		nameAndType, err := p.entry(info.NameAndTypeIndex, CONSTANT_NameAndType)
+		if err != nil {
+			return nil, err
+		}
+
+		nt := nameAndType.Info.(NameAndTypeInfo)
+
+		name, err := p.class.File.Utf8(nt.NameIndex)
+		if err != nil {
+			return nil, NewJavaError(ClassFormatError, "%w", err)
+		}
+
+		descriptor, err := p.class.File.Utf8(nt.DescriptorIndex)
+		if err != nil {
+			return nil, NewJavaError(ClassFormatError, "%w", err)
+		}
+
+		if _, _, err := ParseMethodDescriptor(descriptor); err != nil {
+			return nil, NewJavaError(ClassFormatError, "%s in class file %s", err, p.class.Name)
+		}
+
+		handle, args, err := p.class.bootstrapSpecifier(info.BootstrapMethodAttrIndex)
+		if err != nil {
+			return nil, err
+		}
+
+		handleEntry, err := p.entry(handle, CONSTANT_MethodHandle)
+		if err != nil {
+			return nil, err
+		}
+
+		ref, err := p.entry(handleEntry.Info.(MethodHandleInfo).ReferenceIndex, CONSTANT_Methodref, CONSTANT_InterfaceMethodref)
+		if err != nil {
+			return nil, err
+		}
+
+		className, methodName, methodDescriptor, err := p.symbolicRef(ref)
+		if err != nil {
+			return nil, err
+		}
+
+		bootstrap, ok := bootstrapMethods[className+"."+methodName+methodDescriptor]
+		if !ok || handleEntry.Info.(MethodHandleInfo).ReferenceKind != REF_invokeStatic {
+			return nil, NewJavaError(
+				BootstrapMethodError, "bootstrap method '%s' is not supported", MethodSignature(className, methodName, methodDescriptor),
+			)
+		}
+
+		target, err := bootstrap(p.class, name, descriptor, args)
+		if err != nil {
+			return nil, err
+		}
+
+		return &callSite{descriptor: descriptor, target: target}, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*callSite), nil
+}
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-4.html#jvms-4.7.23
+
+// bootstrapSpecifier returns the constant pool indexes of the method handle and of the static arguments of the
+// entry of the BootstrapMethods attribute of the class at the given index.
+func (c *Class) bootstrapSpecifier(index uint16) (uint16, []uint16, error) {
+	attr, ok := c.File.FindAttribute(c.File.Attributes, "BootstrapMethods")
+	if !ok {
+		return 0, nil, NewJavaError(ClassFormatError, "Missing BootstrapMethods attribute in class file %s", c.Name)
+	}
+
+	info := attr.Info
+	if len(info) < 2 || index >= binary.BigEndian.Uint16(info) {
+		return 0, nil, NewJavaError(ClassFormatError, "bootstrap_method_index %d is out of bounds in class file %s", index, c.Name)
+	}
+
+	at := 2
+	for i := uint16(0); ; i++ {
+		if at+4 > len(info) {
+			return 0, nil, NewJavaError(ClassFormatError, "Short length on BootstrapMethods in class file %s", c.Name)
+		}
+
+		handle, count := binary.BigEndian.Uint16(info[at:]), int(binary.BigEndian.Uint16(info[at+2:]))
+		if at+4+2*count > len(info) {
+			return 0, nil, NewJavaError(ClassFormatError, "Short length on BootstrapMethods in class file %s", c.Name)
+		}
+
+		if i == index {
+			args := make([]uint16, count)
+			for j := range args {
+				args[j] = binary.BigEndian.Uint16(info[at+4+2*j:])
+			}
+
+			return handle, args, nil
+		}
+
+		at += 4 + 2*count
+	}
+}
+
+// methodType returns the descriptor of the CONSTANT_MethodType_info entry at the given index.
+func (p *RuntimeConstantPool) methodType(index uint16) (string, error) {
+	entry, err := p.entry(index, CONSTANT_MethodType)
+	if err != nil {
+		return "", err
+	}
+
+	descriptor, err := p.class.File.Utf8(entry.Info.(MethodTypeInfo).DescriptorIndex)
+	if err != nil {
+		return "", NewJavaError(ClassFormatError, "%w", err)
+	}
+
+	if _, _, err := ParseMethodDescriptor(descriptor); err != nil {
+		return "", NewJavaError(ClassFormatError, "%s in class file %s", err, p.class.Name)
+	}
+
+	return descriptor, nil
+}
+
+// methodHandle resolves the method of the CONSTANT_MethodHandle_info entry at the given index (JVMS 5.4.3.5),
+// returning the kind of the handle with it. Only the handles that invoke methods are supported.
+func (p *RuntimeConstantPool) methodHandle(index uint16) (uint8, *Method, error) {
+	entry, err := p.entry(index, CONSTANT_MethodHandle)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	info := entry.Info.(MethodHandleInfo)
+	if info.ReferenceKind < REF_invokeVirtual || info.ReferenceKind > REF_invokeInterface {
+		return 0, nil, NewJavaError(BootstrapMethodError, "method handles of kind %d are not supported", info.ReferenceKind)
+	}
+
+	ref, err := p.entry(info.ReferenceIndex, CONSTANT_Methodref, CONSTANT_InterfaceMethodref)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	method, err := resolveInvoked(p, info.ReferenceIndex, ref.Tag)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if (info.ReferenceKind == REF_invokeStatic) != method.IsStatic() || (info.ReferenceKind == REF_newInvokeSpecial) != (method.Name == "<init>") {
+		return 0, nil, NewJavaError(IncompatibleClassChangeError, "Method handle of kind %d can not invoke '%s'", info.ReferenceKind, method)
+	}
+
+	return info.ReferenceKind, method, nil
+}
+
+// intConstant returns the value of the CONSTANT_Integer_info entry at the given index.
+func (p *RuntimeConstantPool) intConstant(index uint16) (int32, error) {
+	entry, err := p.entry(index, CONSTANT_Integer)
+	if err != nil {
+		return 0, err
+	}
+
+	return int32(entry.Info.(Numeric32BitsInfo).Value), nil
+}
@@ -0,0 +1,229 @@
+package core
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var ErrEntryNotFound = errors.New("entry not found")
+
+// DefaultRelease is the Java SE release implemented by jbm, it is used to select the
+// versioned entries of multi-release jars when no other release is configured.
+const DefaultRelease = 21
+
+// versionedEntriesPrefix is the directory of a multi-release jar holding the entries specific to a release.
+const versionedEntriesPrefix = "META-INF/versions/"
+
+// JarFile represents an opened `.jar` file.
+//
+// The archive is opened once, but the entries are only read when they are requested,
+// so opening a big jar is cheap.
+type JarFile struct {
+	// Path is the path of the jar file in the filesystem.
+	Path string
+	// Manifest is the parsed `META-INF/MANIFEST.MF`, it is nil if the jar has no manifest.
+	Manifest *Manifest
+	// Release is the runtime release used to select the versioned entries of a multi-release jar.
+	Release int
+	// MultiRelease tells if the manifest has the `Multi-Release: true` attribute.
+	MultiRelease bool
+
+	reader  *zip.ReadCloser
+	entries map[string]*zip.File
+	// versions holds the releases that have a `META-INF/versions/<N>/` directory, in descending order.
+	versions []int
+}
+
+// OpenJarFile opens the jar file in the given path and parses its manifest, if there is any.
+//
+// Versioned entries of multi-release jars are selected according to `DefaultRelease`.
+func OpenJarFile(path string) (*JarFile, error) {
+	return OpenJarFileWithRelease(path, DefaultRelease)
+}
+
+// OpenJarFileWithRelease opens the jar file in the given path, selecting the versioned entries of
+// multi-release jars according to the given runtime release.
+func OpenJarFileWithRelease(path string, release int) (*JarFile, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening jar file %s: %w", path, err)
+	}
+
+	jar := &JarFile{
+		Path:    path,
+		Release: release,
+		reader:  reader,
+		entries: make(map[string]*zip.File, len(reader.File)),
+	}
+
+	versions := make(map[int]bool)
+	for _, f := range reader.File {
+		jar.entries[f.Name] = f
+
+		if version, ok := parseVersionedEntryRelease(f.Name); ok {
+			versions[version] = true
+		}
+	}
+
+	for version := range versions {
+		jar.versions = append(jar.versions, version)
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(jar.versions)))
+
+	if _, ok := jar.entries[ManifestPath]; ok {
+		content, err := jar.ReadEntry(ManifestPath)
+		if err != nil {
+			reader.Close()
+			return nil, err
+		}
+
+		manifest, err := ParseManifest(content)
+		if err != nil {
+			reader.Close()
+			return nil, fmt.Errorf("error reading manifest of %s: %w", path, err)
+		}
+
+		jar.Manifest = manifest
+		jar.MultiRelease = strings.EqualFold(strings.TrimSpace(manifest.Get("Multi-Release")), "true")
+	}
+
+	return jar, nil
+}
+
+// parseVersionedEntryRelease returns the release of an entry inside of `META-INF/versions/<N>/`.
+//
+// Only releases starting from 9 are valid, since that is when multi-release jars were introduced.
+func parseVersionedEntryRelease(name string) (int, bool) {
+	if !strings.HasPrefix(name, versionedEntriesPrefix) {
+		return 0, false
+	}
+
+	rest := strings.TrimPrefix(name, versionedEntriesPrefix)
+	sep := strings.Index(rest, "/")
+	if sep <= 0 {
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(rest[:sep])
+	if err != nil || version < 9 {
+		return 0, false
+	}
+
+	return version, true
+}
+
+// resolveEntry returns the name of the entry that should be read for the given name.
+//
+// For multi-release jars, the entry of the highest release that is not greater than the runtime
+// release wins, falling back to the base entry. Entries inside of `META-INF/` are never versioned.
+func (j *JarFile) resolveEntry(name string) string {
+	if !j.MultiRelease || strings.HasPrefix(name, "META-INF/") {
+		return name
+	}
+
+	for _, version := range j.versions {
+		if version > j.Release {
+			continue
+		}
+
+		versioned := versionedEntriesPrefix + strconv.Itoa(version) + "/" + name
+		if _, ok := j.entries[versioned]; ok {
+			return versioned
+		}
+	}
+
+	return name
+}
+
+// Close closes the underlying archive.
+func (j *JarFile) Close() error {
+	return j.reader.Close()
+}
+
+// HasEntry tells if the jar has an entry with the given name.
+func (j *JarFile) HasEntry(name string) bool {
+	_, ok := j.entries[j.resolveEntry(name)]
+	return ok
+}
+
+// Names returns the names of all the entries of the jar, without selecting versioned entries.
+func (j *JarFile) Names() []string {
+	names := make([]string, 0, len(j.entries))
+	for name := range j.entries {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// ReadEntry reads the whole content of the entry with the given name.
+//
+// For multi-release jars the versioned entry selected by the runtime release is read instead.
+func (j *JarFile) ReadEntry(name string) ([]byte, error) {
+	f, ok := j.entries[j.resolveEntry(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s in %s", ErrEntryNotFound, name, j.Path)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+// ReadClass reads the bytes of a class, given its internal name (e.g. com/acme/Main).
+func (j *JarFile) ReadClass(name string) ([]byte, error) {
+	return j.ReadEntry(name + ".class")
+}
+
+// MainClass returns the internal name of the class in the `Main-Class` attribute of the manifest.
+//
+// It returns an empty string if there is no manifest or the attribute is missing.
+func (j *JarFile) MainClass() string {
+	if j.Manifest == nil {
+		return ""
+	}
+
+	return BinaryNameToInternal(j.Manifest.MainClass())
+}
+
+// ClassPath returns the paths of the jar files listed in the `Class-Path` attribute of the manifest.
+//
+// The URLs in the attribute are relative to the directory of the jar file.
+func (j *JarFile) ClassPath() []string {
+	if j.Manifest == nil {
+		return nil
+	}
+
+	dir := filepath.Dir(j.Path)
+	paths := []string{}
+	for _, url := range j.Manifest.ClassPath() {
+		url = strings.TrimPrefix(url, "file:")
+		if filepath.IsAbs(url) {
+			paths = append(paths, filepath.Clean(url))
+			continue
+		}
+
+		paths = append(paths, filepath.Join(dir, filepath.FromSlash(url)))
+	}
+
+	return paths
+}
+
+// BinaryNameToInternal converts a binary class name (e.g. com.acme.Main) to its internal form (e.g. com/acme/Main).
+func BinaryNameToInternal(name string) string {
+	return strings.ReplaceAll(name, ".", "/")
+}
@@ -0,0 +1,171 @@
+package core_test
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// minimalClassFile builds the bytes of a class file with the given name, which extends
+// java/lang/Object and has no fields or methods.
+func minimalClassFile(name string) []byte {
+	b := []byte{0xCA, 0xFE, 0xBA, 0xBE, 0x00, 0x00, 0x00, 0x34, 0x00, 0x05}
+
+	b = append(b, core.CONSTANT_Utf8, byte(len(name)>>8), byte(len(name)))
+	b = append(b, name...)
+	b = append(b, core.CONSTANT_Class, 0x00, 0x01)
+
+	object := "java/lang/Object"
+	b = append(b, core.CONSTANT_Utf8, byte(len(object)>>8), byte(len(object)))
+	b = append(b, object...)
+	b = append(b, core.CONSTANT_Class, 0x00, 0x03)
+
+	// access flags, this class, super class, interfaces, fields, methods and attributes
+	return append(b, 0x00, 0x21, 0x00, 0x02, 0x00, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00)
+}
+
+// mainClassFile builds a class with a `public static void main(String[])` method that just returns.
+func mainClassFile(name string) []byte {
+	b := newTestClassBuilder()
+	main := b.member(core.ACC_PUBLIC|core.ACC_STATIC, "main", "([Ljava/lang/String;)V", b.code(0, 1, core.OP_RETURN))
+
+	return b.build(core.ACC_PUBLIC|core.ACC_SUPER, name, "java/lang/Object", nil, nil, [][]byte{main}, nil)
+}
+
+// writeJarFile writes a jar file in the given path with the given entries.
+func writeJarFile(t *testing.T, path string, entries map[string][]byte) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Error creating jar file: %v", err)
+	}
+
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for name, content := range entries {
+		entry, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("Error creating jar entry: %v", err)
+		}
+
+		if _, err := entry.Write(content); err != nil {
+			t.Fatalf("Error writing jar entry: %v", err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Error closing jar file: %v", err)
+	}
+}
+
+func TestShouldReadTheManifestOfAJarFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.jar")
+	writeJarFile(t, path, map[string][]byte{
+		"META-INF/MANIFEST.MF": []byte("Manifest-Version: 1.0\nMain-Class: com.acme.Main\nClass-Path: lib/dep.jar\n"),
+		"com/acme/Main.class":  minimalClassFile("com/acme/Main"),
+	})
+
+	jar, err := core.OpenJarFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	defer jar.Close()
+
+	if jar.MainClass() != "com/acme/Main" {
+		t.Errorf("Expected main class com/acme/Main, got %s", jar.MainClass())
+	}
+
+	classPath := jar.ClassPath()
+	if len(classPath) != 1 || classPath[0] != filepath.Join(filepath.Dir(path), "lib", "dep.jar") {
+		t.Errorf("Expected class path to be relative to the jar, got %v", classPath)
+	}
+}
+
+func TestShouldReadClassesFromAJarFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.jar")
+	writeJarFile(t, path, map[string][]byte{
+		"com/acme/Main.class": minimalClassFile("com/acme/Main"),
+	})
+
+	jar, err := core.OpenJarFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	defer jar.Close()
+
+	if jar.Manifest != nil || jar.MainClass() != "" {
+		t.Errorf("Expected jar without a manifest to have no main class")
+	}
+
+	content, err := jar.ReadClass("com/acme/Main")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if string(content) != string(minimalClassFile("com/acme/Main")) {
+		t.Errorf("Expected the content of the class entry")
+	}
+
+	if _, err := jar.ReadClass("com/acme/Missing"); err == nil {
+		t.Errorf("Expected error reading a missing class, got nil")
+	}
+}
+
+func TestShouldSelectTheVersionedEntriesOfMultiReleaseJars(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mr.jar")
+	writeJarFile(t, path, map[string][]byte{
+		"META-INF/MANIFEST.MF":                 []byte("Manifest-Version: 1.0\nMulti-Release: true\n"),
+		"data.txt":                             []byte("base"),
+		"META-INF/versions/11/data.txt":        []byte("11"),
+		"META-INF/versions/17/data.txt":        []byte("17"),
+		"META-INF/versions/17/OnlyVersioned.x": []byte("17"),
+	})
+
+	cases := map[int]string{8: "base", 9: "base", 11: "11", 16: "11", 17: "17", 21: "17"}
+	for release, expected := range cases {
+		jar, err := core.OpenJarFileWithRelease(path, release)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		content, err := jar.ReadEntry("data.txt")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if string(content) != expected {
+			t.Errorf("Expected release %d to read %s, got %s", release, expected, content)
+		}
+
+		if jar.HasEntry("OnlyVersioned.x") != (release >= 17) {
+			t.Errorf("Expected OnlyVersioned.x to be visible only from release 17, release %d", release)
+		}
+
+		jar.Close()
+	}
+}
+
+func TestShouldIgnoreVersionedEntriesIfTheJarIsNotMultiRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plain.jar")
+	writeJarFile(t, path, map[string][]byte{
+		"META-INF/MANIFEST.MF":          []byte("Manifest-Version: 1.0\n"),
+		"data.txt":                      []byte("base"),
+		"META-INF/versions/11/data.txt": []byte("11"),
+	})
+
+	jar, err := core.OpenJarFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	defer jar.Close()
+
+	if content, _ := jar.ReadEntry("data.txt"); string(content) != "base" {
+		t.Errorf("Expected the base entry, got %s", content)
+	}
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Internal names of the throwables raised by the runtime itself and by its built-in class library.
+const (
+	AbstractMethodError             = "java/lang/AbstractMethodError"
+	ArithmeticException             = "java/lang/ArithmeticException"
+	ArrayIndexOutOfBoundsException  = "java/lang/ArrayIndexOutOfBoundsException"
+	ArrayStoreException             = "java/lang/ArrayStoreException"
+	BootstrapMethodError            = "java/lang/BootstrapMethodError"
+	ClassCastException              = "java/lang/ClassCastException"
+	ClassCircularityError           = "java/lang/ClassCircularityError"
+	ClassFormatError                = "java/lang/ClassFormatError"
+	ConcurrentModificationException = "java/util/ConcurrentModificationException"
+	ClassNotFoundException          = "java/lang/ClassNotFoundException"
+	ExceptionInInitializerError     = "java/lang/ExceptionInInitializerError"
+	IllegalAccessError              = "java/lang/IllegalAccessError"
+	IllegalArgumentException        = "java/lang/IllegalArgumentException"
+	IllegalMonitorStateException    = "java/lang/IllegalMonitorStateException"
+	IllegalStateException           = "java/lang/IllegalStateException"
+	IncompatibleClassChangeError    = "java/lang/IncompatibleClassChangeError"
+	IndexOutOfBoundsException       = "java/lang/IndexOutOfBoundsException"
+	InstantiationError              = "java/lang/InstantiationError"
+	InstantiationException          = "java/lang/InstantiationException"
+	InternalError                   = "java/lang/InternalError"
+	IOException                     = "java/io/IOException"
+	LinkageError                    = "java/lang/LinkageError"
+	NegativeArraySizeException      = "java/lang/NegativeArraySizeException"
+	NoClassDefFoundError            = "java/lang/NoClassDefFoundError"
+	NoSuchElementException          = "java/util/NoSuchElementException"
+	NoSuchFieldError                = "java/lang/NoSuchFieldError"
+	NoSuchMethodError               = "java/lang/NoSuchMethodError"
+	NullPointerException            = "java/lang/NullPointerException"
+	NumberFormatException           = "java/lang/NumberFormatException"
+	OutOfMemoryError                = "java/lang/OutOfMemoryError"
+	SecurityException               = "java/lang/SecurityException"
+	StackOverflowError              = "java/lang/StackOverflowError"
+	StringIndexOutOfBoundsException = "java/lang/StringIndexOutOfBoundsException"
+	UnsatisfiedLinkError            = "java/lang/UnsatisfiedLinkError"
+	UnsupportedClassVersionError    = "java/lang/UnsupportedClassVersionError"
+	UnsupportedOperationException   = "java/lang/UnsupportedOperationException"
+	VerifyError                     = "java/lang/VerifyError"
+)
+
+// Throwable is the internal name of the superclass of every exception and error.
+const Throwable = "java/lang/Throwable"
+
+// throwableSuperclasses are the superclasses of the throwables raised by the runtime, of a few other common
+// ones and of their superclasses, up to java/lang/Throwable, so minimal versions of them can be defined
+// without a JDK.
+var throwableSuperclasses = map[string]string{
+	Throwable:                                "java/lang/Object",
+	"java/lang/Exception":                    Throwable,
+	"java/lang/Error":                        Throwable,
+	"java/lang/RuntimeException":             "java/lang/Exception",
+	"java/lang/ReflectiveOperationException": "java/lang/Exception",
+	IndexOutOfBoundsException:                "java/lang/RuntimeException",
+	"java/lang/VirtualMachineError":          "java/lang/Error",
+	ArithmeticException:                      "java/lang/RuntimeException",
+	ArrayIndexOutOfBoundsException:           IndexOutOfBoundsException,
+	StringIndexOutOfBoundsException:          IndexOutOfBoundsException,
+	ArrayStoreException:                      "java/lang/RuntimeException",
+	ClassCastException:                       "java/lang/RuntimeException",
+	ClassNotFoundException:                   "java/lang/ReflectiveOperationException",
+	InstantiationException:                   "java/lang/ReflectiveOperationException",
+	IllegalArgumentException:                 "java/lang/RuntimeException",
+	IllegalMonitorStateException:             "java/lang/RuntimeException",
+	IllegalStateException:                    "java/lang/RuntimeException",
+	NumberFormatException:                    IllegalArgumentException,
+	UnsupportedOperationException:            "java/lang/RuntimeException",
+	ConcurrentModificationException:          "java/lang/RuntimeException",
+	NoSuchElementException:                   "java/lang/RuntimeException",
+	"java/lang/InterruptedException":         "java/lang/Exception",
+	"java/lang/CloneNotSupportedException":   "java/lang/Exception",
+	"java/io/IOException":                    "java/lang/Exception",
+	NegativeArraySizeException:               "java/lang/RuntimeException",
+	NullPointerException:                     "java/lang/RuntimeException",
+	SecurityException:                        "java/lang/RuntimeException",
+	LinkageError:                             "java/lang/Error",
+	"java/lang/AssertionError":               "java/lang/Error",
+	BootstrapMethodError:                     LinkageError,
+	ClassCircularityError:                    LinkageError,
+	ClassFormatError:                         LinkageError,
+	ExceptionInInitializerError:              LinkageError,
+	IncompatibleClassChangeError:             LinkageError,
+	NoClassDefFoundError:                     LinkageError,
+	UnsatisfiedLinkError:                     LinkageError,
+	VerifyError:                              LinkageError,
+	UnsupportedClassVersionError:             ClassFormatError,
+	AbstractMethodError:                      IncompatibleClassChangeError,
+	IllegalAccessError:                       IncompatibleClassChangeError,
+	InstantiationError:                       IncompatibleClassChangeError,
+	NoSuchFieldError:                         IncompatibleClassChangeError,
+	NoSuchMethodError:                        IncompatibleClassChangeError,
+	InternalError:                            "java/lang/VirtualMachineError",
+	OutOfMemoryError:                         "java/lang/VirtualMachineError",
+	StackOverflowError:                       "java/lang/VirtualMachineError",
+}
+
+// JavaError is a Java throwable being thrown, either raised by the runtime, like a NoClassDefFoundError when
+// a class can not be loaded, or thrown by the program with athrow. It unwinds the frames of the thread until
+// a handler of the class, or of a superclass, of the throwable catches it.
+type JavaError struct {
+	// ClassName is the internal name of the throwable class (e.g. java/lang/NoClassDefFoundError).
+	ClassName string
+	// Message is the detail message of the throwable, it can be empty.
+	Message string
+	// Cause is the error that caused this one, if any. It is the cause of the throwable when it is, or wraps,
+	// a Java error.
+	Cause error
+	// Suppressed are the exceptions suppressed to deliver this one, see `Throwable.addSuppressed`.
+	Suppressed []*JavaError
+	// StackTrace are the frames of the thread where the throwable was created, the innermost first. It is nil
+	// until it is filled in, see `Thread.fillInStackTrace`.
+	StackTrace []StackTraceElement
+	// Object is the throwable object, the errors raised by the runtime only get one when they are about to
+	// be caught, see `Thread.throwable`.
+	Object *Object
+
+	// causeSet tells if the cause was set by the program, even to null, so it can not be set again.
+	causeSet bool
+}
+
+// NewJavaError creates a throwable of the given class with a formatted message, when the arguments
+// have an error wrapped with `%w`, it becomes the cause.
+func NewJavaError(className string, format string, args ...any) *JavaError {
+	err := fmt.Errorf(format, args...)
+
+	return &JavaError{ClassName: className, Message: err.Error(), Cause: errors.Unwrap(err)}
+}
+
+// Error returns the throwable the same way `Throwable.toString` does, e.g. `java.lang.NoClassDefFoundError: Foo`.
+func (e *JavaError) Error() string {
+	name := strings.ReplaceAll(e.ClassName, "/", ".")
+	if e.Message == "" {
+		return name
+	}
+
+	return name + ": " + e.Message
+}
+
+func (e *JavaError) Unwrap() error {
+	return e.Cause
+}
+
+// cause returns the Java error that is, or is wrapped by, the cause of the error, or nil when there is none.
+func (e *JavaError) cause() *JavaError {
+	var cause *JavaError
+	if errors.As(e.Cause, &cause) {
+		return cause
+	}
+
+	return nil
+}
+
+// IsJavaError tells if the error is, or wraps, a throwable of the given class.
+func IsJavaError(err error, className string) bool {
+	var javaErr *JavaError
+	for errors.As(err, &javaErr) {
+		if javaErr.ClassName == className {
+			return true
+		}
+
+		err = javaErr.Cause
+	}
+
+	return false
+}
@@ -1,85 +1,534 @@
 package core
 
 import (
-	"bytes"
 	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/Gustrb/jbm/src/utils"
 )
 
-var Flags = []string{"--jar", "-m"}
+// ClassPathOptions are the options that take the class path as their value.
+var ClassPathOptions = []string{"-cp", "-classpath", "--class-path"}
+
+// ModulePathOptions are the options that take the module path as their value.
+var ModulePathOptions = []string{"-p", "--module-path"}
 
 type ExecutionContext struct {
+	// Filepath is the path of the file being executed, it is only set when running a `.class` file
+	// directly or a jar file.
 	Filepath string
-	Type     string
+	// MainClass is the internal name of the class to be executed (e.g. com/acme/Main).
+	MainClass string
+	// ClassPath is where the classes are looked up, in the format accepted by `ParseClassPath`.
+	ClassPath string
+	// Arguments are the arguments passed to the main class.
+	Arguments []string
+	// Properties are the system properties set with `-Dkey=value`.
+	Properties map[string]string
+	// Release is the runtime release used to select the versioned entries of multi-release jars,
+	// it can be changed with the `jdk.util.jar.version` property.
+	Release int
+	// JavaHome is the directory of the JDK whose platform classes are used, set with `--java-home`.
+	JavaHome string
+	// ModulePath is the list of jmods, modular jars and exploded modules, set with `--module-path`.
+	ModulePath string
+	// Module is the name of the module being executed, set with `-m` or `--module`.
+	Module string
+	// AddModules are the root modules to resolve in addition to the initial module, set with `--add-modules`.
+	AddModules []string
+	// ClassStats prints the counters of the class cache to stderr once the program finishes, set with `--class-stats`.
+	ClassStats bool
+	// Type is how the program is launched: "class" for a main class or a `.class` file, "jar" with `-jar`
+	// and "module" with `-m`.
+	Type string
+}
+
+// NewExecutionContext parses the command line arguments the same way the `java` launcher does.
+//
+// The first argument that is not an option is the main class (or the file after -jar and -m), and
+// everything after it is passed to the program. When no class path option is passed, the `CLASSPATH`
+// environment variable is used, defaulting to the current directory.
+func NewExecutionContext(args []string) (*ExecutionContext, error) {
+	ctx := &ExecutionContext{Properties: make(map[string]string), Release: DefaultRelease}
+	classPath, hasClassPath := os.LookupEnv("CLASSPATH")
+
+	i := 0
+	for ; i < len(args); i++ {
+		arg := args[i]
+
+		if utils.Contains(ClassPathOptions, arg) {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s requires class path specification", arg)
+			}
+
+			i++
+			classPath, hasClassPath = args[i], true
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--class-path=") {
+			classPath, hasClassPath = strings.TrimPrefix(arg, "--class-path="), true
+			continue
+		}
+
+		if utils.Contains(ModulePathOptions, arg) {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s requires module path specification", arg)
+			}
+
+			i++
+			ctx.ModulePath = args[i]
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--module-path=") {
+			ctx.ModulePath = strings.TrimPrefix(arg, "--module-path=")
+			continue
+		}
+
+		if arg == "--add-modules" {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s requires modules to be specified", arg)
+			}
+
+			i++
+			ctx.AddModules = append(ctx.AddModules, strings.Split(args[i], ",")...)
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--add-modules=") {
+			ctx.AddModules = append(ctx.AddModules, strings.Split(strings.TrimPrefix(arg, "--add-modules="), ",")...)
+			continue
+		}
+
+		if arg == "--class-stats" {
+			ctx.ClassStats = true
+			continue
+		}
+
+		if arg == "--java-home" {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s requires a directory", arg)
+			}
+
+			i++
+			ctx.JavaHome = args[i]
+			continue
+		}
+
+		if strings.HasPrefix(arg, "--java-home=") {
+			ctx.JavaHome = strings.TrimPrefix(arg, "--java-home=")
+			continue
+		}
+
+		if strings.HasPrefix(arg, "-D") {
+			key, value, _ := strings.Cut(strings.TrimPrefix(arg, "-D"), "=")
+			if key == "" {
+				return nil, fmt.Errorf("invalid system property: %s", arg)
+			}
+
+			ctx.Properties[key] = value
+			continue
+		}
+
+		if arg == "-jar" || arg == "--jar" {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("%s requires jar file specification", arg)
+			}
+
+			ctx.Type = "jar"
+			i++
+			ctx.Filepath = args[i]
+			break
+		}
+
+		if arg == "-m" || arg == "--module" || strings.HasPrefix(arg, "--module=") {
+			spec := strings.TrimPrefix(arg, "--module=")
+			if spec == arg {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("%s requires module name", arg)
+				}
+
+				i++
+				spec = args[i]
+			}
+
+			// the main class is optional, the ModuleMainClass attribute of the module is used instead
+			module, mainClass, _ := strings.Cut(spec, "/")
+			ctx.Type = "module"
+			ctx.Module = module
+			ctx.MainClass = BinaryNameToInternal(mainClass)
+			break
+		}
+
+		if strings.HasPrefix(arg, "-") {
+			return nil, fmt.Errorf("unrecognized option: %s", arg)
+		}
+
+		// kept for compatibility, the path of a class file can be executed directly
+		if strings.HasSuffix(arg, ".class") {
+			ctx.Type = "class"
+			ctx.Filepath = arg
+			break
+		}
+
+		ctx.Type = "class"
+		ctx.MainClass = BinaryNameToInternal(arg)
+		break
+	}
+
+	if ctx.Filepath == "" && ctx.MainClass == "" && ctx.Module == "" {
+		return nil, errors.New("no main class provided")
+	}
+
+	if i+1 < len(args) {
+		ctx.Arguments = args[i+1:]
+	}
+
+	if !hasClassPath {
+		classPath = "."
+	}
+
+	ctx.ClassPath = classPath
+
+	if version, ok := ctx.Properties["jdk.util.jar.version"]; ok {
+		release, err := strconv.Atoi(version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jdk.util.jar.version: %s", version)
+		}
+
+		// just like the reference implementation, the release can not be greater than the runtime one
+		ctx.Release = min(release, DefaultRelease)
+	}
+
+	return ctx, nil
 }
 
 // Run executes the file that was passed as an argument.
 //
 // It first reads the file, parses the bytecode, and then executes it.
 func (ctx *ExecutionContext) Run() error {
-	fcontent, err := utils.ReadFileContent(ctx.Filepath)
+	switch ctx.Type {
+	case "class":
+		if ctx.Filepath != "" {
+			return ctx.runClassFile()
+		}
+
+		return ctx.runMainClass()
+	case "jar":
+		return ctx.runJarFile()
+	case "module":
+		return ctx.runModule()
+	}
+
+	return nil
+}
+
+// runClassFile defines the class of the class file with the application loader and executes it, the other
+// classes are looked up in the class path.
+func (ctx *ExecutionContext) runClassFile() error {
+	content, err := utils.ReadFileContent(ctx.Filepath)
 	if err != nil {
 		return err
 	}
 
-	// create a reader from the file content
-	reader := bytes.NewReader(fcontent)
-	switch ctx.Type {
-	case "class":
-		err = ExecuteClassFile(reader)
+	loaders, _, err := ctx.openClassLoaders(ctx.ClassPath, "")
+	if err != nil {
+		return err
+	}
+
+	defer loaders.Close()
+
+	class, err := loaders.Application.DefineClass("", content)
+	if err == nil {
+		err = executeClass(class, ctx.Arguments, ctx.systemProperties())
 	}
 
+	ctx.printClassStats(loaders)
+
 	return err
 }
 
-// findExecutionType returns the type of the file that is being executed.
+// openClassLoaders creates the built-in class loaders: the bootstrap loader reads the platform classes
+// of the JDK in `JavaHome`, when it is set, and the application loader reads the modules resolved from the
+// module path and then the given class path.
 //
-// If the --jar flag is passed, the type is "jar".
-// If the -m flag is passed, the type is "module".
-// If there is no type flag passed, the type is "class".
-func findExecutionType(args []string) string {
-	for i := 0; i < len(args); i++ {
-		if args[i] == "--jar" {
-			return "jar"
-		} else if args[i] == "-m" {
-			return "module"
+// The modules resolved are the given main module, if any, plus the ones in `AddModules`, where
+// ALL-MODULE-PATH stands for every module of the module path.
+func (ctx *ExecutionContext) openClassLoaders(classPath string, mainModule string) (*ClassLoaders, *Configuration, error) {
+	boot := NewClassPath()
+
+	if ctx.JavaHome != "" {
+		cp, err := OpenBootClassPath(ctx.JavaHome)
+		if err != nil {
+			return nil, nil, err
 		}
+
+		boot = cp
+	}
+
+	config, err := ctx.resolveModules(boot, mainModule)
+	if err != nil {
+		boot.Close()
+		return nil, nil, err
+	}
+
+	app := NewClassPath()
+	if config != nil {
+		app.Add(config.Entries()...)
+	}
+
+	cp, err := ParseClassPathWithRelease(classPath, ctx.Release)
+	if err != nil {
+		boot.Close()
+		app.Close()
+		return nil, nil, err
 	}
 
-	return "class"
+	app.Add(cp.Entries...)
+
+	loaders := NewClassLoaders(boot, nil, app)
+	loaders.Bootstrap.configuration = config
+
+	return loaders, config, nil
 }
 
-// findFilepath returns the filepath of the file that was used to execute the program.
-//
-// We can assume that the filepath is the argument that is not a flag.
-func findFilepath(args []string) string {
-	for i := 0; i < len(args); i++ {
-		// I guess we couldve used a map here, but since the list is so small, it's not worth it.
-		// maybe sometime in the future.
-		if !utils.Contains(Flags, args[i]) {
-			return args[i]
+// resolveModules resolves the root modules against the module path, the entries of the module path that
+// are not resolved are closed. It returns nil when there are no root modules.
+func (ctx *ExecutionContext) resolveModules(boot *ClassPath, mainModule string) (*Configuration, error) {
+	modulePath, err := ParseModulePath(ctx.ModulePath, ctx.Release)
+	if err != nil {
+		return nil, err
+	}
+
+	finder, err := NewModuleFinder(modulePath.Entries, boot)
+	if err != nil {
+		modulePath.Close()
+		return nil, err
+	}
+
+	roots := []string{}
+	if mainModule != "" {
+		roots = append(roots, mainModule)
+	}
+
+	for _, name := range ctx.AddModules {
+		if name == "ALL-MODULE-PATH" {
+			roots = append(roots, finder.Names()...)
+		} else if name != "" {
+			roots = append(roots, name)
 		}
 	}
 
-	return ""
+	if len(roots) == 0 {
+		modulePath.Close()
+		return nil, nil
+	}
+
+	config, err := finder.Resolve(roots)
+	if err != nil {
+		modulePath.Close()
+		return nil, err
+	}
+
+	resolved := make(map[ClassPathEntry]bool)
+	for _, entry := range config.Entries() {
+		resolved[entry] = true
+	}
+
+	for _, entry := range modulePath.Entries {
+		if !resolved[entry] {
+			entry.Close()
+		}
+	}
+
+	return config, nil
 }
 
-func RunJBM(args []string) error {
-	filepath := findFilepath(args)
+// runMainClass looks up the main class in the class path and executes it.
+func (ctx *ExecutionContext) runMainClass() error {
+	loaders, _, err := ctx.openClassLoaders(ctx.ClassPath, "")
+	if err != nil {
+		return err
+	}
+
+	defer loaders.Close()
+
+	return ctx.executeMainClass(loaders, ctx.MainClass)
+}
+
+// runJarFile executes the class pointed by the `Main-Class` attribute of the jar manifest.
+//
+// Just like the reference implementation, the class path options are ignored and the jar, together
+// with the jars listed in its `Class-Path` attribute, is the whole class path.
+func (ctx *ExecutionContext) runJarFile() error {
+	jar, err := OpenJarFile(ctx.Filepath)
+	if err != nil {
+		return err
+	}
+
+	mainClass := jar.MainClass()
+	jar.Close()
+
+	if mainClass == "" {
+		return fmt.Errorf("no main manifest attribute, in %s", ctx.Filepath)
+	}
+
+	loaders, _, err := ctx.openClassLoaders(ctx.Filepath, "")
+	if err != nil {
+		return err
+	}
+
+	defer loaders.Close()
+
+	return ctx.executeMainClass(loaders, mainClass)
+}
+
+// runModule resolves the module being executed and runs the main class given with `-m <module>/<mainclass>`,
+// or the one in its ModuleMainClass attribute.
+func (ctx *ExecutionContext) runModule() error {
+	loaders, config, err := ctx.openClassLoaders(ctx.ClassPath, ctx.Module)
+	if err != nil {
+		return err
+	}
+
+	defer loaders.Close()
+
+	mainClass := ctx.MainClass
+	if mainClass == "" {
+		mainClass = config.Modules[ctx.Module].Descriptor.MainClass
+	}
+
+	if mainClass == "" {
+		return fmt.Errorf("module %s does not have a ModuleMainClass attribute, use -m <module>/<main-class>", ctx.Module)
+	}
+
+	return ctx.executeMainClass(loaders, mainClass)
+}
+
+// executeMainClass executes the main class with the application loader, printing the class cache stats
+// if they were requested.
+func (ctx *ExecutionContext) executeMainClass(loaders *ClassLoaders, mainClass string) error {
+	class, err := loadMainClass(loaders.Application, mainClass)
+	if err == nil {
+		err = executeClass(class, ctx.Arguments, ctx.systemProperties())
+	}
+
+	ctx.printClassStats(loaders)
 
-	if filepath == "" {
-		return errors.New("no filepath provided")
+	return err
+}
+
+// systemProperties returns the system properties the JDK boots with, the ones set with `-D` plus the ones
+// the launcher sets.
+func (ctx *ExecutionContext) systemProperties() map[string]string {
+	// the class path options are ignored with -jar, the jar is the class path
+	classPath := ctx.ClassPath
+	if ctx.Type == "jar" {
+		classPath = ctx.Filepath
+	}
+
+	properties := map[string]string{"java.class.path": classPath}
+	if ctx.JavaHome != "" {
+		properties["java.home"] = ctx.JavaHome
+	}
+
+	for key, value := range ctx.Properties {
+		properties[key] = value
+	}
+
+	return properties
+}
+
+// printClassStats prints the class cache stats to stderr, if they were requested.
+func (ctx *ExecutionContext) printClassStats(loaders *ClassLoaders) {
+	if ctx.ClassStats {
+		fmt.Fprintf(os.Stderr, "class cache: %s\n", loaders.Application.Cache.Stats())
+	}
+}
+
+// ExecuteMainClass loads the class with the given internal name with the given loader and executes it.
+func ExecuteMainClass(loader *ClassLoader, name string) error {
+	class, err := loadMainClass(loader, name)
+	if err != nil {
+		return err
+	}
+
+	return ExecuteClass(class)
+}
+
+// loadMainClass loads the main class with the given internal name with the given loader.
+func loadMainClass(loader *ClassLoader, name string) (*Class, error) {
+	class, err := loader.LoadClass(name)
+	if err != nil {
+		return nil, fmt.Errorf("could not find or load main class %s: %w", strings.ReplaceAll(name, "/", "."), err)
+	}
+
+	return class, nil
+}
+
+// ExecuteClass invokes the `public static void main(String[])` method of the class in the main thread,
+// initializing the class first. With the class library of a JDK, the main thread boots it first, see
+// `initializeSystem`. The main method is given an empty array of arguments.
+func ExecuteClass(class *Class) error {
+	return executeClass(class, nil, nil)
+}
+
+// executeClass executes the main method of the class with the given arguments, booting the JDK with the
+// given system properties.
+func executeClass(class *Class, args []string, properties map[string]string) error {
+	main := class.lookupMethod("main", "([Ljava/lang/String;)V")
+	if main == nil || !main.IsStatic() || main.AccessFlags&ACC_PUBLIC == 0 {
+		return fmt.Errorf(
+			"main method not found in class %s, please define the main method as:\n   public static void main(String[] args)",
+			class.JavaName(),
+		)
+	}
+
+	thread := NewThread(MainThreadName)
+
+	var array *Object
+
+	err := thread.initializeSystem(class.Loader.bootstrap(), properties)
+	if err == nil {
+		array, err = newArgumentsArray(class.Loader, args)
+	}
+
+	if err == nil {
+		err = main.Class.Initialize(thread)
 	}
 
-	executionType := findExecutionType(args)
+	if err == nil {
+		_, err = thread.Invoke(main, []Slot{{Ref: array}})
+	}
 
-	ctx := ExecutionContext{
-		Filepath: filepath,
-		Type:     executionType,
+	if javaErr, ok := err.(*JavaError); ok {
+		return &UncaughtException{Thread: thread.Name, Err: javaErr}
+	}
+
+	return err
+}
+
+// newArgumentsArray returns the java/lang/String array of the arguments of the main method.
+func newArgumentsArray(loader *ClassLoader, args []string) (*Object, error) {
+	strs := make([]*string, len(args))
+	for i := range args {
+		strs[i] = &args[i]
+	}
+
+	return newStringArray(loader, strs)
+}
+
+func RunJBM(args []string) error {
+	ctx, err := NewExecutionContext(args)
+	if err != nil {
+		return err
 	}
 
-	err := ctx.Run()
+	err = ctx.Run()
 
 	return err
 }
@@ -0,0 +1,658 @@
+package core
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Reference: src/java.base/share/classes/jdk/internal/jimage in the OpenJDK sources.
+
+// ImageMagic is the magic number of a jimage file, it is stored in the byte order of the
+// platform that created the image, so it is also used to detect the byte order of the file.
+const ImageMagic uint32 = 0xCAFEDADA
+
+const (
+	// imageHeaderSize is the size of the header, which is made of 7 u4 values.
+	imageHeaderSize = 7 * 4
+	// imageHashMultiplier is both the multiplier and the default seed of the hash function used by the lookup table.
+	imageHashMultiplier = 0x01000193
+	// imageModulesPath is the path of the image inside of a JDK.
+	imageModulesPath = "lib/modules"
+)
+
+// Kinds of attributes of an image location.
+const (
+	imageAttributeEnd = iota
+	imageAttributeModule
+	imageAttributeParent
+	imageAttributeBase
+	imageAttributeExtension
+	imageAttributeOffset
+	imageAttributeCompressed
+	imageAttributeUncompressed
+	imageAttributeCount
+)
+
+const (
+	compressedResourceMagic      uint32 = 0xCAFEFAFA
+	compressedResourceHeaderSize        = 4 + 8 + 8 + 4 + 4 + 1
+)
+
+var ErrInvalidImage = errors.New("invalid jimage file")
+
+// ImageHeader is the header of a jimage file.
+type ImageHeader struct {
+	Magic        uint32
+	MajorVersion uint16
+	MinorVersion uint16
+	Flags        uint32
+	// ResourceCount is the number of resources in the image.
+	ResourceCount uint32
+	// TableLength is the length of the redirect and offsets tables.
+	TableLength uint32
+	// LocationsSize is the size in bytes of the locations section.
+	LocationsSize uint32
+	// StringsSize is the size in bytes of the strings section.
+	StringsSize uint32
+}
+
+// ImageLocation describes a resource of the image.
+//
+// The full name of a resource is `/<module>/<parent>/<base>.<extension>`, e.g. the location of
+// `/java.base/java/lang/Object.class` has module `java.base`, parent `java/lang`, base `Object` and
+// extension `class`.
+type ImageLocation struct {
+	Module    string
+	Parent    string
+	Base      string
+	Extension string
+	// ContentOffset is the offset of the resource content, relative to the end of the index.
+	ContentOffset uint64
+	// CompressedSize is the size of the content in the image, it is zero when the resource is not compressed.
+	CompressedSize uint64
+	// UncompressedSize is the size of the resource after it is decompressed.
+	UncompressedSize uint64
+}
+
+// FullName returns the name of the resource, the same one used to look it up.
+func (l *ImageLocation) FullName() string {
+	var sb strings.Builder
+
+	if l.Module != "" {
+		sb.WriteString("/" + l.Module + "/")
+	}
+
+	if l.Parent != "" {
+		sb.WriteString(l.Parent + "/")
+	}
+
+	sb.WriteString(l.Base)
+
+	if l.Extension != "" {
+		sb.WriteString("." + l.Extension)
+	}
+
+	return sb.String()
+}
+
+// ImageReader reads the resources of a jimage file, like the `lib/modules` file of a JDK.
+//
+// Only the index is kept in memory, the resources are read from the file when they are requested.
+type ImageReader struct {
+	Path   string
+	Header ImageHeader
+
+	file      *os.File
+	order     binary.ByteOrder
+	redirect  []int32
+	offsets   []uint32
+	locations []byte
+	strings   []byte
+	// indexSize is the size of the header and all the tables, the resources start right after it.
+	indexSize int64
+
+	// packages caches the module of each package already looked up.
+	packages sync.Map
+}
+
+// OpenImage opens the jimage file in the given path and reads its index.
+func OpenImage(path string) (*ImageReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &ImageReader{Path: path, file: file}
+	if err := reader.readIndex(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error reading image %s: %w", path, err)
+	}
+
+	return reader, nil
+}
+
+func (r *ImageReader) readIndex() error {
+	header := make([]byte, imageHeaderSize)
+	if _, err := io.ReadFull(r.file, header); err != nil {
+		return err
+	}
+
+	switch {
+	case binary.LittleEndian.Uint32(header) == ImageMagic:
+		r.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(header) == ImageMagic:
+		r.order = binary.BigEndian
+	default:
+		return fmt.Errorf("%w: bad magic number", ErrInvalidImage)
+	}
+
+	version := r.order.Uint32(header[4:])
+	r.Header = ImageHeader{
+		Magic:         ImageMagic,
+		MajorVersion:  uint16(version >> 16),
+		MinorVersion:  uint16(version),
+		Flags:         r.order.Uint32(header[8:]),
+		ResourceCount: r.order.Uint32(header[12:]),
+		TableLength:   r.order.Uint32(header[16:]),
+		LocationsSize: r.order.Uint32(header[20:]),
+		StringsSize:   r.order.Uint32(header[24:]),
+	}
+
+	if r.Header.MajorVersion != 1 {
+		return fmt.Errorf("%w: unsupported version %d.%d", ErrInvalidImage, r.Header.MajorVersion, r.Header.MinorVersion)
+	}
+
+	tableLength := int(r.Header.TableLength)
+	index := make([]byte, tableLength*8+int(r.Header.LocationsSize)+int(r.Header.StringsSize))
+	if _, err := io.ReadFull(r.file, index); err != nil {
+		return err
+	}
+
+	r.redirect = make([]int32, tableLength)
+	r.offsets = make([]uint32, tableLength)
+	for i := 0; i < tableLength; i++ {
+		r.redirect[i] = int32(r.order.Uint32(index[i*4:]))
+		r.offsets[i] = r.order.Uint32(index[(tableLength+i)*4:])
+	}
+
+	locationsStart := tableLength * 8
+	r.locations = index[locationsStart : locationsStart+int(r.Header.LocationsSize)]
+	r.strings = index[locationsStart+int(r.Header.LocationsSize):]
+	r.indexSize = int64(imageHeaderSize + len(index))
+
+	return nil
+}
+
+// imageStringHash is the hash function used by the image lookup table, it hashes the UTF-8 bytes of the
+// string with the given seed and keeps the result positive.
+func imageStringHash(s string, seed int32) int32 {
+	h := seed
+	for i := 0; i < len(s); i++ {
+		h = (h * imageHashMultiplier) ^ int32(s[i])
+	}
+
+	return h & 0x7FFFFFFF
+}
+
+// stringAt reads the NUL terminated string at the given offset of the strings section.
+func (r *ImageReader) stringAt(offset uint32) (string, error) {
+	if int(offset) >= len(r.strings) {
+		return "", fmt.Errorf("%w: string offset %d out of bounds", ErrInvalidImage, offset)
+	}
+
+	end := bytes.IndexByte(r.strings[offset:], 0)
+	if end < 0 {
+		return "", fmt.Errorf("%w: unterminated string at offset %d", ErrInvalidImage, offset)
+	}
+
+	return string(r.strings[offset : int(offset)+end]), nil
+}
+
+// FindLocation looks up the location of the resource with the given name (e.g. /java.base/java/lang/Object.class).
+func (r *ImageReader) FindLocation(name string) (*ImageLocation, bool) {
+	length := int32(r.Header.TableLength)
+	if length == 0 {
+		return nil, false
+	}
+
+	index := r.redirect[imageStringHash(name, imageHashMultiplier)%length]
+	switch {
+	case index < 0:
+		// the index was stored directly, as its two's complement
+		index = -index - 1
+	case index > 0:
+		// the value is the seed of a second hash that resolves the collisions of the bucket
+		index = imageStringHash(name, index) % length
+	default:
+		return nil, false
+	}
+
+	location, err := r.decodeLocation(r.offsets[index])
+	if err != nil || location.FullName() != name {
+		return nil, false
+	}
+
+	return location, true
+}
+
+// decodeLocation decodes the attributes of the location at the given offset of the locations section.
+//
+// Each attribute starts with a byte holding its kind in the 5 high bits and its length minus one in the
+// 3 low bits, followed by its value in big-endian.
+func (r *ImageReader) decodeLocation(offset uint32) (*ImageLocation, error) {
+	attributes := [imageAttributeCount]uint64{}
+
+	i := int(offset)
+	for i < len(r.locations) {
+		kind := r.locations[i] >> 3
+		if kind == imageAttributeEnd {
+			break
+		}
+
+		if kind >= imageAttributeCount {
+			return nil, fmt.Errorf("%w: invalid location attribute %d", ErrInvalidImage, kind)
+		}
+
+		length := int(r.locations[i]&0x7) + 1
+		if i+1+length > len(r.locations) {
+			return nil, fmt.Errorf("%w: truncated location at offset %d", ErrInvalidImage, offset)
+		}
+
+		value := uint64(0)
+		for j := 1; j <= length; j++ {
+			value = value<<8 | uint64(r.locations[i+j])
+		}
+
+		attributes[kind] = value
+		i += length + 1
+	}
+
+	location := &ImageLocation{
+		ContentOffset:    attributes[imageAttributeOffset],
+		CompressedSize:   attributes[imageAttributeCompressed],
+		UncompressedSize: attributes[imageAttributeUncompressed],
+	}
+
+	names := []*string{&location.Module, &location.Parent, &location.Base, &location.Extension}
+	for kind, name := range names {
+		s, err := r.stringAt(uint32(attributes[imageAttributeModule+kind]))
+		if err != nil {
+			return nil, err
+		}
+
+		*name = s
+	}
+
+	return location, nil
+}
+
+// ReadResource reads the content of the resource in the given location, decompressing it if needed.
+func (r *ImageReader) ReadResource(location *ImageLocation) ([]byte, error) {
+	size := location.UncompressedSize
+	if location.CompressedSize != 0 {
+		size = location.CompressedSize
+	}
+
+	content := make([]byte, size)
+	if _, err := r.file.ReadAt(content, r.indexSize+int64(location.ContentOffset)); err != nil {
+		return nil, err
+	}
+
+	if location.CompressedSize == 0 {
+		return content, nil
+	}
+
+	return r.decompress(content)
+}
+
+// decompress undoes the compressions applied to a resource, which are stacked one on top of the other,
+// each one with its own header.
+func (r *ImageReader) decompress(content []byte) ([]byte, error) {
+	for len(content) >= compressedResourceHeaderSize && r.order.Uint32(content) == compressedResourceMagic {
+		uncompressedSize := r.order.Uint64(content[12:])
+		decompressor, err := r.stringAt(r.order.Uint32(content[20:]))
+		if err != nil {
+			return nil, err
+		}
+
+		payload := content[compressedResourceHeaderSize:]
+		switch decompressor {
+		case "zip":
+			content, err = inflateImageResource(payload, uncompressedSize)
+		case "compact-cp":
+			content, err = r.expandSharedStrings(payload)
+		default:
+			err = fmt.Errorf("%w: unsupported decompressor %s", ErrInvalidImage, decompressor)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return content, nil
+}
+
+// inflateImageResource decompresses a resource compressed by the `zip` plugin, which uses zlib.
+func inflateImageResource(payload []byte, size uint64) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+
+	content := make([]byte, size)
+	if _, err := io.ReadFull(reader, content); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// Constant pool tags used by the `compact-cp` plugin to replace UTF-8 entries by strings of the image.
+const (
+	externalizedString           = 23
+	externalizedStringDescriptor = 25
+)
+
+// compactConstantSizes holds the size of the constant pool entries the `compact-cp` plugin copies as is.
+var compactConstantSizes = map[uint8]int{
+	CONSTANT_Integer:            4,
+	CONSTANT_Float:              4,
+	CONSTANT_Long:               8,
+	CONSTANT_Double:             8,
+	CONSTANT_Class:              2,
+	CONSTANT_String:             2,
+	CONSTANT_Fieldref:           4,
+	CONSTANT_Methodref:          4,
+	CONSTANT_InterfaceMethodref: 4,
+	CONSTANT_NameAndType:        4,
+	CONSTANT_MethodHandle:       3,
+	CONSTANT_MethodType:         2,
+	CONSTANT_Dynamic:            4,
+	CONSTANT_InvokeDynamic:      4,
+	CONSTANT_Module:             2,
+	CONSTANT_Package:            2,
+}
+
+// expandSharedStrings rebuilds a class file compressed by the `compact-cp` plugin, which moves the UTF-8
+// entries of the constant pool to the strings section of the image, so they are shared among classes.
+//
+// Descriptors are split too, each class name in them is stored as a package and a simple name.
+func (r *ImageReader) expandSharedStrings(payload []byte) ([]byte, error) {
+	if len(payload) < 10 {
+		return nil, fmt.Errorf("%w: truncated compact-cp resource", ErrInvalidImage)
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(payload)*2))
+	// magic, minor and major versions and the constant pool count are kept as is
+	out.Write(payload[:10])
+	count := int(binary.BigEndian.Uint16(payload[8:]))
+	in := bytes.NewReader(payload[10:])
+
+	for i := 1; i < count; i++ {
+		tag, err := in.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		switch tag {
+		case CONSTANT_Utf8:
+			var length uint16
+			if err := binary.Read(in, binary.BigEndian, &length); err != nil {
+				return nil, err
+			}
+
+			utf := make([]byte, length)
+			if _, err := io.ReadFull(in, utf); err != nil {
+				return nil, err
+			}
+
+			writeCompactUtf8(out, string(utf))
+		case externalizedString:
+			index, err := readCompressedIndex(in)
+			if err != nil {
+				return nil, err
+			}
+
+			s, err := r.stringAt(uint32(index))
+			if err != nil {
+				return nil, err
+			}
+
+			writeCompactUtf8(out, s)
+		case externalizedStringDescriptor:
+			descriptor, err := r.reconstructDescriptor(in)
+			if err != nil {
+				return nil, err
+			}
+
+			writeCompactUtf8(out, descriptor)
+		default:
+			size, ok := compactConstantSizes[tag]
+			if !ok {
+				return nil, fmt.Errorf("%w: invalid constant pool tag %d in compact-cp resource", ErrInvalidImage, tag)
+			}
+
+			entry := make([]byte, size)
+			if _, err := io.ReadFull(in, entry); err != nil {
+				return nil, err
+			}
+
+			out.WriteByte(tag)
+			out.Write(entry)
+
+			if tag == CONSTANT_Long || tag == CONSTANT_Double {
+				i++
+			}
+		}
+	}
+
+	// the rest of the class file is untouched
+	if _, err := in.WriteTo(out); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+func writeCompactUtf8(out *bytes.Buffer, s string) {
+	out.WriteByte(CONSTANT_Utf8)
+	out.WriteByte(byte(len(s) >> 8))
+	out.WriteByte(byte(len(s)))
+	out.WriteString(s)
+}
+
+// reconstructDescriptor rebuilds a descriptor whose class names were replaced by `L` followed by the
+// indexes of the package and the simple name of the class, e.g. `(Ljava/lang/String;)V` is stored as
+// `(L;)V` plus the indexes of `java.lang` and `String`.
+func (r *ImageReader) reconstructDescriptor(in *bytes.Reader) (string, error) {
+	descriptorIndex, err := readCompressedIndex(in)
+	if err != nil {
+		return "", err
+	}
+
+	descriptor, err := r.stringAt(uint32(descriptorIndex))
+	if err != nil {
+		return "", err
+	}
+
+	length, err := readCompressedIndex(in)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := make([]byte, length)
+	if _, err := io.ReadFull(in, encoded); err != nil {
+		return "", err
+	}
+
+	indexes := []int{}
+	flow := bytes.NewReader(encoded)
+	for flow.Len() > 0 {
+		index, err := readCompressedIndex(flow)
+		if err != nil {
+			return "", err
+		}
+
+		indexes = append(indexes, index)
+	}
+
+	var sb strings.Builder
+	next := 0
+	for i := 0; i < len(descriptor); i++ {
+		sb.WriteByte(descriptor[i])
+		if descriptor[i] != 'L' {
+			continue
+		}
+
+		if next+1 >= len(indexes) {
+			return "", fmt.Errorf("%w: missing class name in descriptor %s", ErrInvalidImage, descriptor)
+		}
+
+		pkg, err := r.stringAt(uint32(indexes[next]))
+		if err != nil {
+			return "", err
+		}
+
+		class, err := r.stringAt(uint32(indexes[next+1]))
+		if err != nil {
+			return "", err
+		}
+
+		if pkg != "" {
+			sb.WriteString(strings.ReplaceAll(pkg, ".", "/") + "/")
+		}
+
+		sb.WriteString(class)
+		next += 2
+	}
+
+	return sb.String(), nil
+}
+
+// readCompressedIndex reads an integer compressed by the `compact-cp` plugin.
+//
+// If the high bit of the first byte is set, the next 2 bits are the number of bytes of the value and the
+// low 5 bits are its most significant bits, otherwise the value is a plain 4 bytes integer.
+func readCompressedIndex(in *bytes.Reader) (int, error) {
+	header, err := in.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	size, value := 4, int(int8(header))
+	if header&0x80 != 0 {
+		size, value = int(header>>5)&0x3, int(header&0x1F)
+	}
+
+	for i := 1; i < size; i++ {
+		b, err := in.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		value = value<<8 | int(b)
+	}
+
+	return value, nil
+}
+
+// PackageModule returns the name of the module that contains the given package, the package can be
+// written either with dots (java.lang) or slashes (java/lang).
+//
+// The image has a `/packages/<package>` resource for each package, holding pairs of u4 values, the
+// first one telling if the package is empty in that module and the second one the offset of the
+// module name in the strings section.
+func (r *ImageReader) PackageModule(pkg string) (string, bool) {
+	pkg = strings.ReplaceAll(pkg, "/", ".")
+	if module, ok := r.packages.Load(pkg); ok {
+		return module.(string), module.(string) != ""
+	}
+
+	module := ""
+	if location, ok := r.FindLocation("/packages/" + pkg); ok {
+		content, err := r.ReadResource(location)
+		if err == nil {
+			for i := 0; i+8 <= len(content); i += 8 {
+				if r.order.Uint32(content[i:]) != 0 {
+					continue
+				}
+
+				module, _ = r.stringAt(r.order.Uint32(content[i+4:]))
+				break
+			}
+		}
+	}
+
+	r.packages.Store(pkg, module)
+
+	return module, module != ""
+}
+
+// ReadModuleResource reads a resource of a module, e.g. ReadModuleResource("java.base", "java/lang/Object.class").
+func (r *ImageReader) ReadModuleResource(module string, name string) ([]byte, error) {
+	location, ok := r.FindLocation("/" + module + "/" + name)
+	if !ok {
+		return nil, fmt.Errorf("%w: /%s/%s in %s", ErrEntryNotFound, module, name, r.Path)
+	}
+
+	return r.ReadResource(location)
+}
+
+// ReadClass reads the bytes of a class given its internal name, looking up its module by the package.
+func (r *ImageReader) ReadClass(name string) ([]byte, error) {
+	module, ok := r.PackageModule(path.Dir(name))
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrClassNotFound, name)
+	}
+
+	location, ok := r.FindLocation("/" + module + "/" + name + ".class")
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrClassNotFound, name)
+	}
+
+	return r.ReadResource(location)
+}
+
+// Close closes the image file.
+func (r *ImageReader) Close() error {
+	return r.file.Close()
+}
+
+// String returns the path of the image file.
+func (r *ImageReader) String() string {
+	return r.Path
+}
+
+// OpenBootClassPath opens the class path with the platform classes of the JDK installed in the given
+// directory, which are read from its `lib/modules` image.
+//
+// JDKs distributed without the image have their modules in the `jmods` directory, which is used instead.
+func OpenBootClassPath(javaHome string) (*ClassPath, error) {
+	imagePath := filepath.Join(javaHome, imageModulesPath)
+	jmodsPath := filepath.Join(javaHome, "jmods")
+
+	if _, err := os.Stat(imagePath); errors.Is(err, os.ErrNotExist) {
+		if _, err := os.Stat(jmodsPath); err == nil {
+			return ParseModulePath(jmodsPath, DefaultRelease)
+		}
+	}
+
+	image, err := OpenImage(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not open the platform classes of %s: %w", javaHome, err)
+	}
+
+	return NewClassPath(image), nil
+}
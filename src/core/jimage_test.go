@@ -0,0 +1,354 @@
+package core_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// testImage builds a jimage file in memory, following the same layout written by jlink.
+type testImage struct {
+	strings   bytes.Buffer
+	offsets   map[string]uint32
+	names     []string
+	locations map[string][]byte
+	content   bytes.Buffer
+}
+
+func newTestImage() *testImage {
+	img := &testImage{offsets: map[string]uint32{"": 0}, locations: map[string][]byte{}}
+	img.strings.WriteByte(0)
+
+	return img
+}
+
+func (img *testImage) addString(s string) uint32 {
+	if offset, ok := img.offsets[s]; ok {
+		return offset
+	}
+
+	offset := uint32(img.strings.Len())
+	img.strings.WriteString(s)
+	img.strings.WriteByte(0)
+	img.offsets[s] = offset
+
+	return offset
+}
+
+func encodeImageAttribute(b []byte, kind byte, value uint64) []byte {
+	length := 1
+	for value>>(8*length) != 0 {
+		length++
+	}
+
+	b = append(b, kind<<3|byte(length-1))
+	for i := length - 1; i >= 0; i-- {
+		b = append(b, byte(value>>(8*i)))
+	}
+
+	return b
+}
+
+// add adds a resource, the stored bytes may be compressed, in which case the size of the original
+// content should be passed as uncompressedSize.
+func (img *testImage) add(name string, stored []byte, uncompressedSize int) {
+	module, rest := "", strings.TrimPrefix(name, "/")
+	module, rest, _ = strings.Cut(rest, "/")
+
+	parent, file := "", rest
+	if i := strings.LastIndex(rest, "/"); i >= 0 {
+		parent, file = rest[:i], rest[i+1:]
+	}
+
+	base, extension := file, ""
+	if i := strings.LastIndex(file, "."); i >= 0 {
+		base, extension = file[:i], file[i+1:]
+	}
+
+	location := []byte{}
+	location = encodeImageAttribute(location, 1, uint64(img.addString(module)))
+	location = encodeImageAttribute(location, 2, uint64(img.addString(parent)))
+	location = encodeImageAttribute(location, 3, uint64(img.addString(base)))
+	location = encodeImageAttribute(location, 4, uint64(img.addString(extension)))
+	location = encodeImageAttribute(location, 5, uint64(img.content.Len()))
+	if uncompressedSize != len(stored) {
+		location = encodeImageAttribute(location, 6, uint64(len(stored)))
+	}
+	location = encodeImageAttribute(location, 7, uint64(uncompressedSize))
+	location = append(location, 0)
+
+	img.content.Write(stored)
+	img.names = append(img.names, name)
+	img.locations[name] = location
+}
+
+// compressedHeader builds the header jlink puts in front of compressed resources.
+func (img *testImage) compressedHeader(decompressor string, compressedSize int, uncompressedSize int) []byte {
+	header := binary.LittleEndian.AppendUint32(nil, 0xCAFEFAFA)
+	header = binary.LittleEndian.AppendUint64(header, uint64(compressedSize))
+	header = binary.LittleEndian.AppendUint64(header, uint64(uncompressedSize))
+	header = binary.LittleEndian.AppendUint32(header, img.addString(decompressor))
+	header = binary.LittleEndian.AppendUint32(header, 0)
+
+	return append(header, 1)
+}
+
+func imageHash(s string, seed int32) int32 {
+	h := seed
+	for i := 0; i < len(s); i++ {
+		h = (h * 0x01000193) ^ int32(s[i])
+	}
+
+	return h & 0x7FFFFFFF
+}
+
+// write builds the perfect hash table and writes the image to the given path.
+func (img *testImage) write(t *testing.T, path string) {
+	n := int32(len(img.names))
+	redirect, slots := make([]int32, n), make([]string, n)
+
+	buckets := map[int32][]string{}
+	for _, name := range img.names {
+		bucket := imageHash(name, 0x01000193) % n
+		buckets[bucket] = append(buckets[bucket], name)
+	}
+
+	keys := []int32{}
+	for k := range buckets {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return len(buckets[keys[i]]) > len(buckets[keys[j]]) })
+
+	for _, bucket := range keys {
+		names := buckets[bucket]
+		if len(names) == 1 {
+			for slot := int32(0); slot < n; slot++ {
+				if slots[slot] == "" {
+					slots[slot], redirect[bucket] = names[0], -slot-1
+					break
+				}
+			}
+
+			continue
+		}
+
+		for seed := int32(1); ; seed++ {
+			taken := map[int32]bool{}
+			ok := true
+			for _, name := range names {
+				slot := imageHash(name, seed) % n
+				if slots[slot] != "" || taken[slot] {
+					ok = false
+					break
+				}
+
+				taken[slot] = true
+			}
+
+			if !ok {
+				continue
+			}
+
+			for _, name := range names {
+				slots[imageHash(name, seed)%n] = name
+			}
+
+			redirect[bucket] = seed
+			break
+		}
+	}
+
+	locations, offsets := []byte{}, make([]uint32, n)
+	for i, name := range slots {
+		offsets[i] = uint32(len(locations))
+		locations = append(locations, img.locations[name]...)
+	}
+
+	out := binary.LittleEndian.AppendUint32(nil, core.ImageMagic)
+	out = binary.LittleEndian.AppendUint32(out, 1<<16)
+	for _, v := range []uint32{0, uint32(n), uint32(n), uint32(len(locations)), uint32(img.strings.Len())} {
+		out = binary.LittleEndian.AppendUint32(out, v)
+	}
+
+	for _, v := range redirect {
+		out = binary.LittleEndian.AppendUint32(out, uint32(v))
+	}
+
+	for _, v := range offsets {
+		out = binary.LittleEndian.AppendUint32(out, v)
+	}
+
+	out = append(out, locations...)
+	out = append(out, img.strings.Bytes()...)
+	out = append(out, img.content.Bytes()...)
+
+	if err := os.WriteFile(path, out, 0o644); err != nil {
+		t.Fatalf("Error writing image: %v", err)
+	}
+}
+
+func (img *testImage) addPackage(pkg string, module string) {
+	content := binary.LittleEndian.AppendUint32(nil, 0)
+	content = binary.LittleEndian.AppendUint32(content, img.addString(module))
+	img.add("/packages/"+pkg, content, len(content))
+}
+
+func compressIndex(v int) []byte {
+	if v < 32 {
+		return []byte{0x80 | 1<<5 | byte(v)}
+	}
+
+	return []byte{0x80 | 2<<5 | byte(v>>8)&0x1F, byte(v)}
+}
+
+func TestShouldReadClassesFromAnImage(t *testing.T) {
+	object, str := minimalClassFile("java/lang/Object"), minimalClassFile("java/lang/String")
+
+	img := newTestImage()
+	img.add("/java.base/java/lang/Object.class", object, len(object))
+	img.add("/java.base/module-info.class", []byte("module"), 6)
+	img.addPackage("java.lang", "java.base")
+
+	var zipped bytes.Buffer
+	w := zlib.NewWriter(&zipped)
+	w.Write(str)
+	w.Close()
+	stored := append(img.compressedHeader("zip", zipped.Len(), len(str)), zipped.Bytes()...)
+	img.add("/java.base/java/lang/String.class", stored, len(str))
+
+	path := filepath.Join(t.TempDir(), "modules")
+	img.write(t, path)
+
+	image, err := core.OpenImage(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	defer image.Close()
+
+	if image.Header.MajorVersion != 1 || image.Header.TableLength != 4 {
+		t.Errorf("Expected version 1 and 4 entries, got %+v", image.Header)
+	}
+
+	location, ok := image.FindLocation("/java.base/java/lang/Object.class")
+	if !ok {
+		t.Fatalf("Expected to find java/lang/Object")
+	}
+
+	if location.Module != "java.base" || location.Parent != "java/lang" || location.Base != "Object" || location.Extension != "class" {
+		t.Errorf("Expected the location attributes to be decoded, got %+v", location)
+	}
+
+	if module, ok := image.PackageModule("java/lang"); !ok || module != "java.base" {
+		t.Errorf("Expected java/lang to be in java.base, got %s", module)
+	}
+
+	content, err := image.ReadClass("java/lang/Object")
+	if err != nil || !bytes.Equal(content, object) {
+		t.Errorf("Expected to read java/lang/Object, got %v", err)
+	}
+
+	content, err = image.ReadClass("java/lang/String")
+	if err != nil || !bytes.Equal(content, str) {
+		t.Errorf("Expected to decompress java/lang/String, got %v", err)
+	}
+
+	if _, err := image.ReadClass("java/lang/Missing"); !errors.Is(err, core.ErrClassNotFound) {
+		t.Errorf("Expected ErrClassNotFound, got %v", err)
+	}
+
+	if _, err := image.ReadClass("java/util/List"); !errors.Is(err, core.ErrClassNotFound) {
+		t.Errorf("Expected ErrClassNotFound for a package that is not in the image, got %v", err)
+	}
+}
+
+func TestShouldExpandSharedStringsOfAnImage(t *testing.T) {
+	img := newTestImage()
+
+	// magic, versions and a constant pool with 2 entries (count 3)
+	payload := []byte{0xCA, 0xFE, 0xBA, 0xBE, 0x00, 0x00, 0x00, 0x41, 0x00, 0x03}
+	payload = append(payload, 23)
+	payload = append(payload, compressIndex(int(img.addString("java/lang/Object")))...)
+
+	flow := append(compressIndex(int(img.addString("java.lang"))), compressIndex(int(img.addString("String")))...)
+	payload = append(payload, 25)
+	payload = append(payload, compressIndex(int(img.addString("(L;)V")))...)
+	payload = append(payload, compressIndex(len(flow))...)
+	payload = append(payload, flow...)
+	payload = append(payload, []byte("rest")...)
+
+	expected := []byte{0xCA, 0xFE, 0xBA, 0xBE, 0x00, 0x00, 0x00, 0x41, 0x00, 0x03}
+	expected = append(expected, core.CONSTANT_Utf8, 0x00, 0x10)
+	expected = append(expected, "java/lang/Object"...)
+	expected = append(expected, core.CONSTANT_Utf8, 0x00, 0x15)
+	expected = append(expected, "(Ljava/lang/String;)V"...)
+	expected = append(expected, "rest"...)
+
+	stored := append(img.compressedHeader("compact-cp", len(payload), len(expected)), payload...)
+	img.add("/java.base/java/lang/Shared.class", stored, len(expected))
+	img.addPackage("java.lang", "java.base")
+
+	path := filepath.Join(t.TempDir(), "modules")
+	img.write(t, path)
+
+	image, err := core.OpenImage(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	defer image.Close()
+
+	content, err := image.ReadClass("java/lang/Shared")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !bytes.Equal(content, expected) {
+		t.Errorf("Expected the shared strings to be expanded, got %q", content)
+	}
+}
+
+func TestShouldRejectFilesThatAreNotImages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "modules")
+	os.WriteFile(path, make([]byte, 64), 0o644)
+
+	if _, err := core.OpenImage(path); !errors.Is(err, core.ErrInvalidImage) {
+		t.Errorf("Expected ErrInvalidImage, got %v", err)
+	}
+}
+
+func TestShouldLoadThePlatformClassesFromTheJavaHome(t *testing.T) {
+	javaHome := t.TempDir()
+	os.Mkdir(filepath.Join(javaHome, "lib"), 0o755)
+
+	object := minimalClassFile("java/lang/Object")
+	img := newTestImage()
+	img.add("/java.base/java/lang/Object.class", object, len(object))
+	img.addPackage("java.lang", "java.base")
+	img.write(t, filepath.Join(javaHome, "lib", "modules"))
+
+	cp, err := core.OpenBootClassPath(javaHome)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	defer cp.Close()
+
+	if _, err := cp.FindClass("java/lang/Object"); err != nil {
+		t.Errorf("Expected to find java/lang/Object, got %v", err)
+	}
+
+	ctx, err := core.NewExecutionContext([]string{"--java-home", javaHome, "Main"})
+	if err != nil || ctx.JavaHome != javaHome {
+		t.Errorf("Expected the java home to be parsed, got %v", err)
+	}
+}
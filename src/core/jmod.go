@@ -0,0 +1,225 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// JmodMagic is the header of a `.jmod` file, the letters `JM` followed by the major and minor versions
+// of the format, right after it comes a regular zip file.
+var JmodMagic = []byte{'J', 'M', 0x01, 0x00}
+
+// Sections of a jmod file, each one is a directory of the zip file.
+const (
+	JmodClasses        = "classes"
+	JmodConfig         = "conf"
+	JmodHeaderFiles    = "include"
+	JmodLegalNotices   = "legal"
+	JmodManPages       = "man"
+	JmodNativeCommands = "bin"
+	JmodNativeLibs     = "lib"
+)
+
+var ErrInvalidJmod = errors.New("invalid jmod file")
+
+// JmodFile represents an opened `.jmod` file, the format used by the JDK to distribute the modules
+// that are linked into a runtime image by jlink.
+type JmodFile struct {
+	// Path is the path of the jmod file in the filesystem.
+	Path string
+
+	file    *os.File
+	entries map[string]*zip.File
+}
+
+// OpenJmodFile opens the jmod file in the given path.
+func OpenJmodFile(path string) (*JmodFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	magic := make([]byte, len(JmodMagic))
+	if _, err := io.ReadFull(file, magic); err != nil || !bytes.Equal(magic, JmodMagic) {
+		file.Close()
+		return nil, fmt.Errorf("%w: %s has a bad header", ErrInvalidJmod, path)
+	}
+
+	// the offsets of the zip file are relative to the end of the header
+	size := info.Size() - int64(len(JmodMagic))
+	reader, err := zip.NewReader(io.NewSectionReader(file, int64(len(JmodMagic)), size), size)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidJmod, path, err)
+	}
+
+	jmod := &JmodFile{
+		Path:    path,
+		file:    file,
+		entries: make(map[string]*zip.File, len(reader.File)),
+	}
+
+	for _, f := range reader.File {
+		jmod.entries[f.Name] = f
+	}
+
+	return jmod, nil
+}
+
+// Close closes the jmod file.
+func (j *JmodFile) Close() error {
+	return j.file.Close()
+}
+
+// String returns the path of the jmod file.
+func (j *JmodFile) String() string {
+	return j.Path
+}
+
+// HasEntry tells if the given section has an entry with the given name.
+func (j *JmodFile) HasEntry(section string, name string) bool {
+	_, ok := j.entries[section+"/"+name]
+	return ok
+}
+
+// ReadEntry reads the whole content of an entry of a section, e.g. ReadEntry(JmodConfig, "net.properties").
+func (j *JmodFile) ReadEntry(section string, name string) ([]byte, error) {
+	f, ok := j.entries[section+"/"+name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s/%s in %s", ErrEntryNotFound, section, name, j.Path)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+// Entries returns the names of the entries of a section, relative to the section and sorted.
+func (j *JmodFile) Entries(section string) []string {
+	prefix := section + "/"
+	names := []string{}
+
+	for name := range j.entries {
+		if strings.HasPrefix(name, prefix) && !strings.HasSuffix(name, "/") {
+			names = append(names, strings.TrimPrefix(name, prefix))
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// ReadClass reads the bytes of a class of the `classes` section given its internal name.
+func (j *JmodFile) ReadClass(name string) ([]byte, error) {
+	if !j.HasEntry(JmodClasses, name+".class") {
+		return nil, fmt.Errorf("%w: %s", ErrClassNotFound, name)
+	}
+
+	return j.ReadEntry(JmodClasses, name+".class")
+}
+
+// ParseModulePath opens the entries of a module path, the format used by `--module-path`.
+//
+// Each path in the list can be a jmod file, a modular jar, an exploded module (a directory with a
+// `module-info.class`) or a directory whose children are any of those.
+func ParseModulePath(modulePath string, release int) (*ClassPath, error) {
+	cp := NewClassPath()
+
+	for _, path := range strings.Split(modulePath, string(os.PathListSeparator)) {
+		if path == "" {
+			continue
+		}
+
+		entries, err := openModulePathEntries(path, release, true)
+		if err != nil {
+			cp.Close()
+			return nil, err
+		}
+
+		cp.Add(entries...)
+	}
+
+	return cp, nil
+}
+
+// openModulePathEntries opens the module in the given path, when it is a directory that is not an
+// exploded module and expand is true, each one of its children is opened instead.
+func openModulePathEntries(path string, release int, expand bool) ([]ClassPathEntry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid module path entry %s: %w", path, err)
+	}
+
+	if info.IsDir() {
+		if _, err := os.Stat(filepath.Join(path, "module-info.class")); err == nil || !expand {
+			return []ClassPathEntry{&DirClassPathEntry{Dir: filepath.Clean(path)}}, nil
+		}
+
+		children, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+
+		entries := []ClassPathEntry{}
+		for _, child := range children {
+			childPath := filepath.Join(path, child.Name())
+			ext := strings.ToLower(filepath.Ext(child.Name()))
+
+			// only directories, jars and jmods are modules, other files are ignored
+			if !child.IsDir() && ext != ".jar" && ext != ".jmod" {
+				continue
+			}
+
+			opened, err := openModulePathEntries(childPath, release, false)
+			if err != nil {
+				closeClassPathEntries(entries)
+				return nil, err
+			}
+
+			entries = append(entries, opened...)
+		}
+
+		return entries, nil
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".jmod") {
+		jmod, err := OpenJmodFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		return []ClassPathEntry{jmod}, nil
+	}
+
+	jar, err := OpenJarFileWithRelease(path, release)
+	if err != nil {
+		return nil, err
+	}
+
+	return []ClassPathEntry{&JarClassPathEntry{Jar: jar}}, nil
+}
+
+func closeClassPathEntries(entries []ClassPathEntry) {
+	for _, entry := range entries {
+		entry.Close()
+	}
+}
@@ -0,0 +1,129 @@
+package core_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// writeJmodFile writes a jmod file, which is the jmod header followed by a zip file with the entries.
+func writeJmodFile(t *testing.T, path string, entries map[string][]byte) {
+	var buf bytes.Buffer
+	buf.Write(core.JmodMagic)
+
+	w := zip.NewWriter(&buf)
+	for name, content := range entries {
+		entry, _ := w.Create(name)
+		entry.Write(content)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Error writing jmod file: %v", err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("Error writing jmod file: %v", err)
+	}
+}
+
+func TestShouldReadTheSectionsOfAJmodFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "java.base.jmod")
+	writeJmodFile(t, path, map[string][]byte{
+		"classes/module-info.class":      []byte("module-info"),
+		"classes/java/lang/Object.class": minimalClassFile("java/lang/Object"),
+		"conf/net.properties":            []byte("a=b"),
+		"lib/libjava.so":                 []byte("elf"),
+		"legal/LICENSE":                  []byte("license"),
+	})
+
+	jmod, err := core.OpenJmodFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	defer jmod.Close()
+
+	content, err := jmod.ReadClass("java/lang/Object")
+	if err != nil || !bytes.Equal(content, minimalClassFile("java/lang/Object")) {
+		t.Errorf("Expected to read java/lang/Object, got %v", err)
+	}
+
+	if _, err := jmod.ReadClass("java/lang/String"); !errors.Is(err, core.ErrClassNotFound) {
+		t.Errorf("Expected ErrClassNotFound, got %v", err)
+	}
+
+	content, err = jmod.ReadEntry(core.JmodConfig, "net.properties")
+	if err != nil || string(content) != "a=b" {
+		t.Errorf("Expected to read the conf section, got %v", err)
+	}
+
+	classes := jmod.Entries(core.JmodClasses)
+	if len(classes) != 2 || classes[0] != "java/lang/Object.class" || classes[1] != "module-info.class" {
+		t.Errorf("Expected the entries of the classes section, got %v", classes)
+	}
+
+	if libs := jmod.Entries(core.JmodNativeLibs); len(libs) != 1 || libs[0] != "libjava.so" {
+		t.Errorf("Expected the entries of the lib section, got %v", libs)
+	}
+}
+
+func TestShouldRejectJmodFilesWithABadHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.jmod")
+	os.WriteFile(path, []byte("PK\x03\x04"), 0o644)
+
+	if _, err := core.OpenJmodFile(path); !errors.Is(err, core.ErrInvalidJmod) {
+		t.Errorf("Expected ErrInvalidJmod, got %v", err)
+	}
+}
+
+func TestShouldOpenTheEntriesOfAModulePath(t *testing.T) {
+	dir := t.TempDir()
+	writeJmodFile(t, filepath.Join(dir, "java.base.jmod"), map[string][]byte{
+		"classes/java/lang/Object.class": minimalClassFile("java/lang/Object"),
+	})
+	writeJarFile(t, filepath.Join(dir, "app.jar"), map[string][]byte{"app/Main.class": minimalClassFile("app/Main")})
+	writeClassFile(t, filepath.Join(dir, "exploded"), "module-info", []byte("module-info"))
+	writeClassFile(t, filepath.Join(dir, "exploded"), "lib/Util", minimalClassFile("lib/Util"))
+	os.WriteFile(filepath.Join(dir, "README"), []byte("ignored"), 0o644)
+
+	cp, err := core.ParseModulePath(dir, core.DefaultRelease)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	defer cp.Close()
+
+	if len(cp.Entries) != 3 {
+		t.Fatalf("Expected 3 modules, got %d", len(cp.Entries))
+	}
+
+	for _, name := range []string{"java/lang/Object", "app/Main", "lib/Util"} {
+		if _, err := cp.FindClass(name); err != nil {
+			t.Errorf("Expected to find %s, got %v", name, err)
+		}
+	}
+}
+
+func TestShouldBootFromTheJmodsOfAJavaHomeWithoutAnImage(t *testing.T) {
+	javaHome := t.TempDir()
+	os.Mkdir(filepath.Join(javaHome, "jmods"), 0o755)
+	writeJmodFile(t, filepath.Join(javaHome, "jmods", "java.base.jmod"), map[string][]byte{
+		"classes/java/lang/Object.class": minimalClassFile("java/lang/Object"),
+	})
+
+	cp, err := core.OpenBootClassPath(javaHome)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	defer cp.Close()
+
+	if _, err := cp.FindClass("java/lang/Object"); err != nil {
+		t.Errorf("Expected to find java/lang/Object, got %v", err)
+	}
+}
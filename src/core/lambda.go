@@ -0,0 +1,427 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Spec: https://docs.oracle.com/en/java/javase/21/docs/api/java.base/java/lang/invoke/LambdaMetafactory.html
+
+// Flags of `LambdaMetafactory.altMetafactory`.
+const (
+	lambdaFlagSerializable = 1 << 0
+	lambdaFlagMarkers      = 1 << 1
+	lambdaFlagBridges      = 1 << 2
+)
+
+// lambdaClasses counts the lambda classes spun, to give each one its own name.
+var lambdaClasses atomic.Int64
+
+// lambda is a lambda expression or method reference, implemented by a class the runtime spins, whose instances
+// keep the captured arguments in their fields and whose functional interface methods forward to the
+// implementation method.
+type lambda struct {
+	// caller is the class of the call site, its loader loads the types the arguments are cast to.
+	caller *Class
+	// kind is the kind of the method handle of the implementation method.
+	kind uint8
+	// impl is the implementation method, like the `lambda$main$0` method javac generates for a lambda expression.
+	impl *Method
+	// captured are the field descriptors of the arguments of the call site, which come first.
+	captured []string
+	// params are the field descriptors of the parameters of the implementation method, starting with its
+	// receiver when it is an instance method.
+	params []string
+	// ret is the field descriptor of what the implementation method returns, the class for constructors.
+	ret string
+}
+
+// lambdaMetafactory links the call sites of lambda expressions and method references, whose target returns an
+// instance of a class implementing the functional interface, like `LambdaMetafactory.metafactory`.
+func lambdaMetafactory(caller *Class, name string, descriptor string, args []uint16) (func(t *Thread, args []Slot) ([]Slot, error), error) {
+	if len(args) != 3 {
+		return nil, NewJavaError(BootstrapMethodError, "LambdaMetafactory.metafactory takes 3 static arguments, got %d", len(args))
+	}
+
+	return newLambda(caller, name, descriptor, args[0], args[1], args[2], nil, nil)
+}
+
+// lambdaAltMetafactory is `LambdaMetafactory.altMetafactory`, used by javac for serializable lambdas and when the
+// class needs marker interfaces or bridge methods.
+func lambdaAltMetafactory(caller *Class, name string, descriptor string, args []uint16) (func(t *Thread, args []Slot) ([]Slot, error), error) {
+	pool := caller.ConstantPool
+
+	if len(args) < 4 {
+		return nil, NewJavaError(BootstrapMethodError, "LambdaMetafactory.altMetafactory takes at least 4 static arguments, got %d", len(args))
+	}
+
+	flags, err := pool.intConstant(args[3])
+	if err != nil {
+		return nil, err
+	}
+
+	// the flags tell which of the counted lists of markers and bridges follow
+	rest := args[4:]
+	counted := func(flag int32) ([]uint16, error) {
+		if flags&flag == 0 {
+			return nil, nil
+		}
+
+		if len(rest) == 0 {
+			return nil, NewJavaError(BootstrapMethodError, "LambdaMetafactory.altMetafactory is missing static arguments")
+		}
+
+		count, err := pool.intConstant(rest[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if count < 0 || int(count) > len(rest)-1 {
+			return nil, NewJavaError(BootstrapMethodError, "LambdaMetafactory.altMetafactory is missing static arguments")
+		}
+
+		list := rest[1 : 1+count]
+		rest = rest[1+count:]
+
+		return list, nil
+	}
+
+	markerIndexes, err := counted(lambdaFlagMarkers)
+	if err != nil {
+		return nil, err
+	}
+
+	bridgeIndexes, err := counted(lambdaFlagBridges)
+	if err != nil {
+		return nil, err
+	}
+
+	markers := []string{}
+	for _, index := range markerIndexes {
+		marker, err := caller.File.ClassName(index)
+		if err != nil {
+			return nil, NewJavaError(ClassFormatError, "%w", err)
+		}
+
+		markers = append(markers, marker)
+	}
+
+	if flags&lambdaFlagSerializable != 0 {
+		markers = append(markers, "java/io/Serializable")
+	}
+
+	bridges := []string{}
+	for _, index := range bridgeIndexes {
+		bridge, err := pool.methodType(index)
+		if err != nil {
+			return nil, err
+		}
+
+		bridges = append(bridges, bridge)
+	}
+
+	return newLambda(caller, name, descriptor, args[0], args[1], args[2], markers, bridges)
+}
+
+// newLambda spins the class of a lambda and returns the target of its call site, which creates an instance with
+// the captured arguments. Lambdas that capture nothing always return the same instance, like the reference
+// implementation.
+//
+// The class implements the functional interface returned by the call site and the marker interfaces. Its method
+// with the name of the call site and the descriptor of the erased functional interface method, and the bridges
+// with the other descriptors, are native methods forwarding to the implementation method.
+func newLambda(caller *Class, name string, descriptor string, samType uint16, implHandle uint16, instantiatedType uint16, markers []string, bridges []string) (func(t *Thread, args []Slot) ([]Slot, error), error) {
+	pool := caller.ConstantPool
+
+	sam, err := pool.methodType(samType)
+	if err != nil {
+		return nil, err
+	}
+
+	kind, impl, err := pool.methodHandle(implHandle)
+	if err != nil {
+		return nil, err
+	}
+
+	// the instantiated type only matters to check the arguments, which the casts to the parameters already do
+	if _, err := pool.methodType(instantiatedType); err != nil {
+		return nil, err
+	}
+
+	captured, iface, _ := ParseMethodDescriptor(descriptor)
+	if !strings.HasPrefix(iface, "L") {
+		return nil, NewJavaError(BootstrapMethodError, "Call site of %s does not return a functional interface: %s", name, descriptor)
+	}
+
+	l := &lambda{caller: caller, kind: kind, impl: impl, captured: captured}
+
+	params, ret, _ := ParseMethodDescriptor(impl.Descriptor)
+	switch kind {
+	case REF_newInvokeSpecial:
+		ret = "L" + impl.Class.Name + ";"
+	case REF_invokeVirtual, REF_invokeSpecial, REF_invokeInterface:
+		params = append([]string{"L" + impl.Class.Name + ";"}, params...)
+	}
+
+	l.params, l.ret = params, ret
+
+	className := caller.Name + "$$Lambda$" + strconv.FormatInt(lambdaClasses.Add(1), 10)
+	b := newSyntheticClass(className, "java/lang/Object", ACC_FINAL|ACC_SUPER|ACC_SYNTHETIC)
+
+	for _, implemented := range append([]string{iface[1 : len(iface)-1]}, markers...) {
+		b.implements(implemented)
+	}
+
+	for i, field := range captured {
+		b.field(ACC_PRIVATE|ACC_FINAL, "arg$"+strconv.Itoa(i+1), field)
+	}
+
+	declared := map[string]bool{}
+	for _, method := range append([]string{sam}, bridges...) {
+		if declared[method] {
+			continue
+		}
+
+		declared[method] = true
+
+		methodParams, methodRet, _ := ParseMethodDescriptor(method)
+		if len(captured)+len(methodParams) != len(params) || (methodRet != "V" && ret == "V") {
+			return nil, NewJavaError(
+				BootstrapMethodError, "Type mismatch for lambda of %s: %d captured and %d functional interface parameters for %s, which takes %d",
+				javaName(caller.Name), len(captured), len(methodParams), impl, len(params),
+			)
+		}
+
+		b.method(ACC_PUBLIC|ACC_NATIVE, name, method, 0, 0)
+	}
+
+	class, err := caller.Loader.linkHierarchy(className, b.cf, &loadState{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, method := range class.Methods {
+		method.native = l.forward(method.Descriptor)
+	}
+
+	if len(captured) == 0 {
+		instance := NewObject(class)
+
+		return func(t *Thread, args []Slot) ([]Slot, error) {
+			return []Slot{{Ref: instance}}, nil
+		}, nil
+	}
+
+	return func(t *Thread, args []Slot) ([]Slot, error) {
+		obj := NewObject(class)
+		copy(obj.Fields, args)
+
+		return []Slot{{Ref: obj}}, nil
+	}, nil
+}
+
+// forward returns the implementation of the method of the lambda class with the given descriptor, which invokes
+// the implementation method with the captured arguments and its own, converted to the types of its parameters,
+// and converts the value it returns.
+func (l *lambda) forward(descriptor string) NativeMethod {
+	params, ret, _ := ParseMethodDescriptor(descriptor)
+	types := append(append([]string{}, l.captured...), params...)
+
+	return func(call *NativeCall) ([]Slot, error) {
+		t := call.Thread
+		values := append(append([]Slot{}, call.This().Fields...), call.Args[1:]...)
+
+		implArgs := make([]Slot, 0, len(values))
+		for i, param := range l.params {
+			n := DescriptorSlots(types[i])
+
+			value, err := t.convert(l.caller.Loader, values[:n], types[i], param)
+			if err != nil {
+				return nil, err
+			}
+
+			implArgs = append(implArgs, value...)
+			values = values[n:]
+		}
+
+		method := l.impl
+
+		switch l.kind {
+		case REF_invokeStatic:
+			if err := method.Class.Initialize(t); err != nil {
+				return nil, err
+			}
+		case REF_newInvokeSpecial:
+			if err := method.Class.Initialize(t); err != nil {
+				return nil, err
+			}
+
+			implArgs = append([]Slot{{Ref: NewObject(method.Class)}}, implArgs...)
+		default:
+			receiver := implArgs[0].Ref
+			if receiver == nil {
+				return nil, &JavaError{ClassName: NullPointerException}
+			}
+
+			if l.kind != REF_invokeSpecial {
+				selected, err := receiver.Class.SelectMethod(method)
+				if err != nil {
+					return nil, err
+				}
+
+				method = selected
+			}
+		}
+
+		result, err := t.Invoke(method, implArgs)
+		if err != nil {
+			return nil, err
+		}
+
+		if l.kind == REF_newInvokeSpecial {
+			result = implArgs[:1]
+		}
+
+		if ret == "V" {
+			return nil, nil
+		}
+
+		return t.convert(l.caller.Loader, result, l.ret, ret)
+	}
+}
+
+// wrappers are the classes of the boxed primitive types.
+var wrappers = map[string]string{
+	"Z": "java/lang/Boolean",
+	"B": "java/lang/Byte",
+	"C": "java/lang/Character",
+	"S": "java/lang/Short",
+	"I": "java/lang/Integer",
+	"J": "java/lang/Long",
+	"F": "java/lang/Float",
+	"D": "java/lang/Double",
+}
+
+// widenings are the primitive types each primitive type widens to (JLS 5.1.2).
+var widenings = map[string]string{"B": "SIJFD", "S": "IJFD", "C": "IJFD", "I": "JFD", "J": "FD", "F": "D"}
+
+// convert converts a value from the type of a field descriptor to another one, the way lambdas adapt their
+// arguments and return values: primitives are widened, boxed with the `valueOf` method of their wrapper and
+// unboxed from the `value` field of the wrapper, and references are cast.
+func (t *Thread) convert(loader *ClassLoader, value []Slot, from string, to string) ([]Slot, error) {
+	if from == to {
+		return value, nil
+	}
+
+	_, fromPrimitive := wrappers[from]
+	_, toPrimitive := wrappers[to]
+
+	switch {
+	case fromPrimitive && toPrimitive:
+		return widen(value, from, to)
+	case fromPrimitive:
+		return t.box(loader, value, from)
+	case toPrimitive:
+		obj := value[0].Ref
+		if obj == nil {
+			return nil, &JavaError{ClassName: NullPointerException}
+		}
+
+		for primitive, wrapper := range wrappers {
+			if obj.Class.Name != wrapper {
+				continue
+			}
+
+			if field := obj.Class.DeclaredField("value", primitive); field != nil {
+				return widen(obj.Fields[field.Slot:field.Slot+DescriptorSlots(primitive)], primitive, to)
+			}
+		}
+
+		wrapper, err := loader.bootstrap().LoadClass(wrappers[to])
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, &JavaError{ClassName: ClassCastException, Message: classCastMessage(obj.Class, wrapper)}
+	}
+
+	obj := value[0].Ref
+	if obj == nil || to == "Ljava/lang/Object;" {
+		return value, nil
+	}
+
+	name := to
+	if strings.HasPrefix(to, "L") {
+		name = to[1 : len(to)-1]
+	}
+
+	class, err := loader.LoadClass(name)
+	if IsJavaError(err, ClassNotFoundException) {
+		return nil, &JavaError{ClassName: NoClassDefFoundError, Message: name, Cause: err}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !obj.IsInstanceOf(class) {
+		return nil, &JavaError{ClassName: ClassCastException, Message: classCastMessage(obj.Class, class)}
+	}
+
+	return value, nil
+}
+
+// box returns the wrapper of a primitive value, from the `valueOf` method of the wrapper class.
+func (t *Thread) box(loader *ClassLoader, value []Slot, primitive string) ([]Slot, error) {
+	class, err := loader.bootstrap().LoadClass(wrappers[primitive])
+	if err != nil {
+		return nil, err
+	}
+
+	valueOf := class.DeclaredMethod("valueOf", "("+primitive+")L"+class.Name+";")
+	if valueOf == nil || !valueOf.IsStatic() {
+		return nil, NewJavaError(NoSuchMethodError, "'%s'", MethodSignature(class.Name, "valueOf", "("+primitive+")L"+class.Name+";"))
+	}
+
+	if err := class.Initialize(t); err != nil {
+		return nil, err
+	}
+
+	return t.Invoke(valueOf, value)
+}
+
+// widen applies a widening primitive conversion to a value, or none when both types are the same.
+func widen(value []Slot, from string, to string) ([]Slot, error) {
+	if from == to {
+		return value, nil
+	}
+
+	if !strings.Contains(widenings[from], to) {
+		return nil, NewJavaError(ClassCastException, "%s can not be converted to %s", TypeName(from), TypeName(to))
+	}
+
+	var integer int64
+	switch from {
+	case "J":
+		integer = SlotsLong(value)
+	case "F":
+		if to == "D" {
+			return DoubleSlots(float64(SlotFloat(value[0]))), nil
+		}
+	default:
+		integer = int64(value[0].Num)
+	}
+
+	switch to {
+	case "J":
+		return LongSlots(integer), nil
+	case "F":
+		return []Slot{FloatSlot(float32(integer))}, nil
+	case "D":
+		return DoubleSlots(float64(integer)), nil
+	}
+
+	// byte to short, or to int
+	return []Slot{{Num: int32(integer)}}, nil
+}
@@ -0,0 +1,193 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// writeLambdaClasses writes the functional interfaces IntOp, Fn and Factory, Lambdas, whose methods create
+// lambdas of them, and a minimal java/lang/Integer in the boot class path to box ints.
+func writeLambdaClasses(t *testing.T, boot string, dir string) {
+	public, static, abstract := core.ACC_PUBLIC, core.ACC_PUBLIC|core.ACC_STATIC, core.ACC_PUBLIC|core.ACC_ABSTRACT
+
+	for _, iface := range []struct{ name, method, descriptor string }{
+		{"IntOp", "apply", "(I)I"}, {"Fn", "apply", "(Ljava/lang/Object;)Ljava/lang/Object;"}, {"Factory", "make", "()Ljava/lang/Object;"},
+	} {
+		b := newTestClassBuilder()
+		writeClassFile(t, dir, iface.name, b.build(public|core.ACC_INTERFACE|core.ACC_ABSTRACT, iface.name, "java/lang/Object", nil, nil,
+			[][]byte{b.member(abstract, iface.method, iface.descriptor)}, nil,
+		))
+	}
+
+	b := newTestClassBuilder()
+	objectInit, integerInit := b.methodref("java/lang/Object", "<init>", "()V"), b.methodref("java/lang/Integer", "<init>", "(I)V")
+	writeClassFile(t, boot, "java/lang/Integer", b.build(public|core.ACC_FINAL, "java/lang/Integer", "java/lang/Object", nil,
+		[][]byte{b.member(public|core.ACC_FINAL, "value", "I")},
+		[][]byte{
+			b.member(public, "<init>", "(I)V", b.code(2, 2, concat(
+				[]byte{core.OP_ALOAD_0, core.OP_INVOKESPECIAL}, u2(objectInit),
+				[]byte{core.OP_ALOAD_0, core.OP_ILOAD_1, core.OP_PUTFIELD}, u2(b.fieldref("java/lang/Integer", "value", "I")), []byte{core.OP_RETURN},
+			)...)),
+			b.member(static, "valueOf", "(I)Ljava/lang/Integer;", b.code(3, 1, concat(
+				[]byte{core.OP_NEW}, u2(b.class("java/lang/Integer")), []byte{core.OP_DUP, core.OP_ILOAD_0, core.OP_INVOKESPECIAL}, u2(integerInit), []byte{core.OP_ARETURN},
+			)...)),
+		},
+		nil,
+	))
+
+	b = newTestClassBuilder()
+	objectInit = b.methodref("java/lang/Object", "<init>", "()V")
+	metafactory := b.methodHandle(core.REF_invokeStatic, b.methodref("java/lang/invoke/LambdaMetafactory", "metafactory",
+		"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodType;"+
+			"Ljava/lang/invoke/MethodHandle;Ljava/lang/invoke/MethodType;)Ljava/lang/invoke/CallSite;",
+	))
+	unknown := b.methodHandle(core.REF_invokeStatic, b.methodref("Lambdas", "unknown",
+		"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;)Ljava/lang/invoke/CallSite;",
+	))
+
+	object := "(Ljava/lang/Object;)Ljava/lang/Object;"
+	bootstrapMethods := b.bootstrapMethods(
+		[]uint16{metafactory, b.methodType("(I)I"), b.methodHandle(core.REF_invokeStatic, b.methodref("Lambdas", "lambda$adder$0", "(II)I")), b.methodType("(I)I")},
+		[]uint16{metafactory, b.methodType(object), b.methodHandle(core.REF_invokeVirtual, b.methodref("Lambdas", "self", "()LLambdas;")), b.methodType("(LLambdas;)LLambdas;")},
+		[]uint16{metafactory, b.methodType("()Ljava/lang/Object;"), b.methodHandle(core.REF_newInvokeSpecial, b.methodref("Lambdas", "<init>", "()V")), b.methodType("()LLambdas;")},
+		[]uint16{
+			metafactory, b.methodType(object), b.methodHandle(core.REF_invokeStatic, b.methodref("Lambdas", "lambda$twice$1", "(I)I")),
+			b.methodType("(Ljava/lang/Integer;)Ljava/lang/Integer;"),
+		},
+		[]uint16{unknown},
+	)
+
+	indy := func(bootstrap uint16, name string, descriptor string) []byte {
+		return concat([]byte{core.OP_INVOKEDYNAMIC}, u2(b.invokeDynamic(bootstrap, name, descriptor)), []byte{0, 0})
+	}
+
+	invokeInterface := func(class string, name string, descriptor string, count byte) []byte {
+		return concat([]byte{core.OP_INVOKEINTERFACE}, u2(b.interfaceMethodref(class, name, descriptor)), []byte{count, 0})
+	}
+
+	private := core.ACC_PRIVATE | core.ACC_STATIC | core.ACC_SYNTHETIC
+
+	writeClassFile(t, dir, "Lambdas", b.build(public, "Lambdas", "java/lang/Object", nil, nil,
+		[][]byte{
+			b.member(public, "<init>", "()V", b.code(1, 1, concat([]byte{core.OP_ALOAD_0, core.OP_INVOKESPECIAL}, u2(objectInit), []byte{core.OP_RETURN})...)),
+			b.member(public, "self", "()LLambdas;", b.code(1, 1, core.OP_ALOAD_0, core.OP_ARETURN)),
+			b.member(private, "lambda$adder$0", "(II)I", b.code(2, 2, core.OP_ILOAD_0, core.OP_ILOAD_1, core.OP_IADD, core.OP_IRETURN)),
+			b.member(private, "lambda$twice$1", "(I)I", b.code(2, 1, core.OP_ILOAD_0, core.OP_ICONST_2, core.OP_IMUL, core.OP_IRETURN)),
+			// IntOp adder = x -> n + x
+			b.member(static, "adder", "(I)LIntOp;", b.code(1, 1, concat([]byte{core.OP_ILOAD_0}, indy(0, "apply", "(I)LIntOp;"), []byte{core.OP_ARETURN})...)),
+			b.member(static, "add", "(II)I", b.code(2, 2, concat(
+				[]byte{core.OP_ILOAD_0, core.OP_INVOKESTATIC}, u2(b.methodref("Lambdas", "adder", "(I)LIntOp;")),
+				[]byte{core.OP_ILOAD_1}, invokeInterface("IntOp", "apply", "(I)I", 2), []byte{core.OP_IRETURN},
+			)...)),
+			// ((Fn) Lambdas::self).apply(o)
+			b.member(static, "identity", object, b.code(2, 1, concat(
+				indy(1, "apply", "()LFn;"), []byte{core.OP_ALOAD_0}, invokeInterface("Fn", "apply", object, 2), []byte{core.OP_ARETURN},
+			)...)),
+			// Factory factory = Lambdas::new
+			b.member(static, "factory", "()LFactory;", b.code(1, 0, concat(indy(2, "make", "()LFactory;"), []byte{core.OP_ARETURN})...)),
+			b.member(static, "make", "()Ljava/lang/Object;", b.code(1, 0, concat(
+				[]byte{core.OP_INVOKESTATIC}, u2(b.methodref("Lambdas", "factory", "()LFactory;")),
+				invokeInterface("Factory", "make", "()Ljava/lang/Object;", 1), []byte{core.OP_ARETURN},
+			)...)),
+			// ((Fn<Integer, Integer>) x -> x * 2).apply(n)
+			b.member(static, "twice", "(I)I", b.code(2, 1, concat(
+				indy(3, "apply", "()LFn;"), []byte{core.OP_ILOAD_0, core.OP_INVOKESTATIC}, u2(b.methodref("java/lang/Integer", "valueOf", "(I)Ljava/lang/Integer;")),
+				invokeInterface("Fn", "apply", object, 2), []byte{core.OP_CHECKCAST}, u2(b.class("java/lang/Integer")),
+				[]byte{core.OP_GETFIELD}, u2(b.fieldref("java/lang/Integer", "value", "I")), []byte{core.OP_IRETURN},
+			)...)),
+			b.member(static, "unsupported", "()V", b.code(1, 0, concat(indy(4, "run", "()Ljava/lang/Runnable;"), []byte{core.OP_POP, core.OP_RETURN})...)),
+		},
+		[][]byte{bootstrapMethods},
+	))
+}
+
+func loadLambdas(t *testing.T) *core.Class {
+	boot, dir := t.TempDir(), t.TempDir()
+	writeLambdaClasses(t, boot, dir)
+
+	bootPath, err := core.ParseClassPath(boot)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	appPath, err := core.ParseClassPath(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	loaders := core.NewClassLoaders(bootPath, nil, appPath)
+	t.Cleanup(func() { loaders.Close() })
+
+	lambdas, err := loaders.Application.LoadClass("Lambdas")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	return lambdas
+}
+
+func TestShouldLinkLambdasWithTheLambdaMetafactory(t *testing.T) {
+	lambdas := loadLambdas(t)
+
+	if sum, err := invokeInt(t, lambdas, "add", "(II)I", 3, 4); err != nil || sum != 7 {
+		t.Errorf("Expected the lambda capturing 3 to return 7, got %d (%v)", sum, err)
+	}
+
+	adder, err := invokeSlots(t, lambdas, "adder", "(I)LIntOp;", []core.Slot{{Num: 1}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	class := adder[0].Ref.Class
+	if class.Loader != lambdas.Loader || len(class.Interfaces) != 1 || class.Interfaces[0].Name != "IntOp" {
+		t.Errorf("Expected the lambda to be defined by the loader of Lambdas and to implement IntOp, got %v", class)
+	}
+
+	// the lambdas that capture nothing are the same instance
+	first, err := invokeSlots(t, lambdas, "factory", "()LFactory;")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	second, _ := invokeSlots(t, lambdas, "factory", "()LFactory;")
+	if first[0].Ref != second[0].Ref {
+		t.Errorf("Expected the same instance of the lambda capturing nothing")
+	}
+
+	if made, err := invokeSlots(t, lambdas, "make", "()Ljava/lang/Object;"); err != nil || made[0].Ref == nil || made[0].Ref.Class != lambdas {
+		t.Errorf("Expected the constructor reference to create a Lambdas, got %v (%v)", made, err)
+	}
+
+	obj := core.NewObject(lambdas)
+	if same, err := invokeSlots(t, lambdas, "identity", "(Ljava/lang/Object;)Ljava/lang/Object;", []core.Slot{{Ref: obj}}); err != nil || same[0].Ref != obj {
+		t.Errorf("Expected the method reference to return its receiver, got %v (%v)", same, err)
+	}
+
+	_, err = invokeSlots(t, lambdas, "identity", "(Ljava/lang/Object;)Ljava/lang/Object;", []core.Slot{{Ref: first[0].Ref}})
+	if !core.IsJavaError(err, core.ClassCastException) {
+		t.Errorf("Expected the receiver of another class to be a ClassCastException, got %v", err)
+	}
+}
+
+func TestShouldBoxAndUnboxTheArgumentsOfLambdas(t *testing.T) {
+	lambdas := loadLambdas(t)
+
+	if twice, err := invokeInt(t, lambdas, "twice", "(I)I", 21); err != nil || twice != 42 {
+		t.Errorf("Expected the lambda to return 42, got %d (%v)", twice, err)
+	}
+}
+
+func TestShouldNotLinkCallSitesOfUnsupportedBootstrapMethods(t *testing.T) {
+	lambdas := loadLambdas(t)
+
+	_, err := invokeSlots(t, lambdas, "unsupported", "()V")
+	if !core.IsJavaError(err, core.BootstrapMethodError) {
+		t.Fatalf("Expected a BootstrapMethodError, got %v", err)
+	}
+
+	// the call site fails the same way every time
+	if _, again := invokeSlots(t, lambdas, "unsupported", "()V"); again == nil || again.Error() != err.Error() {
+		t.Errorf("Expected the same error, got %v", again)
+	}
+}
@@ -0,0 +1,282 @@
+package core
+
+import (
+	"io"
+	"os"
+)
+
+// SystemOut and SystemErr are where `System.out` and `System.err` of the built-in class library write. They
+// are read when java/lang/System is initialized, so embedders can redirect them before running a program.
+var (
+	SystemOut io.Writer = os.Stdout
+	SystemErr io.Writer = os.Stderr
+)
+
+// libraryClass is a class of the built-in class library, the minimal subset of java.base the bootstrap loader
+// defines when the boot class path does not have the class, so programs run without a JDK.
+//
+// Its methods are native methods implemented in Go, registered like the other natives so embedders can
+// override them. Its constructors invoke the constructor without parameters of the superclass and then, when
+// they have parameters, the native method `init` with the same descriptor.
+type libraryClass struct {
+	name       string
+	super      string
+	access     uint16
+	interfaces []string
+	fields     []libraryField
+	// constructors are the descriptors of the constructors.
+	constructors []string
+	methods      []libraryMethod
+	// initialize is the static initializer of the class, invoked by its <clinit> method, when it has one.
+	initialize NativeMethod
+}
+
+// libraryField is a field of a class of the built-in class library.
+type libraryField struct {
+	access     uint16
+	name       string
+	descriptor string
+}
+
+// libraryMethod is a method of a class of the built-in class library, the methods of interfaces are
+// abstract and have no native implementation.
+type libraryMethod struct {
+	access     uint16
+	name       string
+	descriptor string
+	native     NativeMethod
+}
+
+func publicMethod(name string, descriptor string, native NativeMethod) libraryMethod {
+	return libraryMethod{access: ACC_PUBLIC, name: name, descriptor: descriptor, native: native}
+}
+
+func staticMethod(name string, descriptor string, native NativeMethod) libraryMethod {
+	return libraryMethod{access: ACC_PUBLIC | ACC_STATIC, name: name, descriptor: descriptor, native: native}
+}
+
+func abstractMethod(name string, descriptor string) libraryMethod {
+	return libraryMethod{access: ACC_PUBLIC | ACC_ABSTRACT, name: name, descriptor: descriptor}
+}
+
+// initMethod is the `init` method invoked by the constructor with the given descriptor.
+func initMethod(descriptor string, native NativeMethod) libraryMethod {
+	return libraryMethod{access: ACC_PRIVATE, name: "init", descriptor: descriptor, native: native}
+}
+
+// libraryInterface returns an interface of the built-in class library with the given abstract methods.
+func libraryInterface(name string, interfaces []string, methods ...libraryMethod) *libraryClass {
+	return &libraryClass{
+		name: name, super: "java/lang/Object", access: ACC_PUBLIC | ACC_INTERFACE | ACC_ABSTRACT, interfaces: interfaces, methods: methods,
+	}
+}
+
+// library are the classes of the built-in class library, by internal name.
+var library = map[string]*libraryClass{}
+
+// addLibraryClasses adds classes to the built-in class library and registers their native methods.
+func addLibraryClasses(classes ...*libraryClass) {
+	for _, class := range classes {
+		library[class.name] = class
+
+		for _, method := range class.methods {
+			if method.native != nil {
+				natives.methods[class.name+"."+method.name+method.descriptor] = method.native
+			}
+		}
+
+		if class.initialize != nil {
+			natives.methods[class.name+".initialize()V"] = class.initialize
+		}
+	}
+}
+
+// classFile builds the class file of the class.
+func (c *libraryClass) classFile() *ClassFile {
+	access := c.access
+	if access&ACC_INTERFACE == 0 {
+		access |= ACC_SUPER
+	}
+
+	b := newSyntheticClass(c.name, c.super, access)
+
+	for _, iface := range c.interfaces {
+		b.implements(iface)
+	}
+
+	for _, field := range c.fields {
+		b.field(field.access, field.name, field.descriptor)
+	}
+
+	for _, descriptor := range c.constructors {
+		b.libraryConstructor(c.name, c.super, descriptor)
+	}
+
+	for _, method := range c.methods {
+		access := method.access
+		if method.native != nil {
+			access |= ACC_NATIVE
+		}
+
+		b.method(access, method.name, method.descriptor, 0, 0)
+	}
+
+	if c.initialize != nil {
+		initialize := b.methodref(c.name, "initialize", "()V")
+		b.method(ACC_STATIC, "<clinit>", "()V", 0, 0, OP_INVOKESTATIC, high(initialize), low(initialize), OP_RETURN)
+		b.method(ACC_PRIVATE|ACC_STATIC|ACC_NATIVE, "initialize", "()V", 0, 0)
+	}
+
+	return b.cf
+}
+
+// libraryConstructor adds a constructor with the given descriptor, which invokes the constructor without
+// parameters of the superclass and then passes its arguments to the `init` method of the class.
+func (b *syntheticClassBuilder) libraryConstructor(class string, super string, descriptor string) {
+	params, _, _ := ParseMethodDescriptor(descriptor)
+	if len(params) == 0 {
+		if super == "" {
+			b.method(ACC_PUBLIC, "<init>", "()V", 0, 1, OP_RETURN)
+		} else {
+			b.constructor(super)
+		}
+
+		return
+	}
+
+	superInit, init := b.methodref(super, "<init>", "()V"), b.methodref(class, "init", descriptor)
+
+	bytecode := []byte{OP_ALOAD_0, OP_INVOKESPECIAL, high(superInit), low(superInit), OP_ALOAD_0}
+	slots := 1
+
+	for _, param := range params {
+		load := OP_ALOAD
+		switch param {
+		case "Z", "B", "C", "S", "I":
+			load = OP_ILOAD
+		case "J":
+			load = OP_LLOAD
+		case "F":
+			load = OP_FLOAD
+		case "D":
+			load = OP_DLOAD
+		}
+
+		bytecode = append(bytecode, load, byte(slots))
+		slots += DescriptorSlots(param)
+	}
+
+	bytecode = append(bytecode, OP_INVOKESPECIAL, high(init), low(init), OP_RETURN)
+	b.method(ACC_PUBLIC, "<init>", descriptor, uint16(slots), uint16(slots), bytecode...)
+}
+
+// libraryState returns the Go value behind an object of a class of the built-in library, creating it the
+// first time, as objects can be allocated without running their constructor.
+func libraryState[T any](obj *Object) *T {
+	if state, ok := obj.state.(*T); ok {
+		return state
+	}
+
+	state := new(T)
+	obj.state = state
+
+	return state
+}
+
+// callMethod invokes the instance method with the given name and descriptor selected for the class of the
+// object, like invokevirtual does, with the object and the given arguments.
+func (t *Thread) callMethod(obj *Object, name string, descriptor string, args ...Slot) ([]Slot, error) {
+	method := obj.Class.lookupMethod(name, descriptor)
+	if method == nil || method.IsStatic() {
+		return nil, NewJavaError(NoSuchMethodError, "'%s'", MethodSignature(obj.Class.Name, name, descriptor))
+	}
+
+	return t.Invoke(method, append([]Slot{{Ref: obj}}, args...))
+}
+
+// javaEquals tells if two references are equal with the `equals` method of the first one, like `Objects.equals`.
+func (t *Thread) javaEquals(a *Object, b *Object) (bool, error) {
+	if a == b {
+		return true, nil
+	}
+
+	if a == nil || b == nil {
+		return false, nil
+	}
+
+	ret, err := t.callMethod(a, "equals", "(Ljava/lang/Object;)Z", Slot{Ref: b})
+	if err != nil {
+		return false, err
+	}
+
+	return ret[0].Num != 0, nil
+}
+
+// javaHashCode returns the hash code of a reference with its `hashCode` method, or 0 for null, like `Objects.hashCode`.
+func (t *Thread) javaHashCode(obj *Object) (int32, error) {
+	if obj == nil {
+		return 0, nil
+	}
+
+	ret, err := t.callMethod(obj, "hashCode", "()I")
+	if err != nil {
+		return 0, err
+	}
+
+	return ret[0].Num, nil
+}
+
+// chars returns the chars of the java/lang/String parameter at the given index, throwing a
+// NullPointerException when it is null.
+func (c *NativeCall) chars(i int) ([]uint16, error) {
+	str := c.Ref(i)
+	if str == nil {
+		return nil, &JavaError{ClassName: NullPointerException}
+	}
+
+	return stringChars(str), nil
+}
+
+// charSequence returns the chars of the java/lang/CharSequence parameter at the given index, the result of
+// its `toString` method, throwing a NullPointerException when it is null.
+func (c *NativeCall) charSequence(i int) ([]uint16, error) {
+	if c.Ref(i) == nil {
+		return nil, &JavaError{ClassName: NullPointerException}
+	}
+
+	return c.Thread.stringValueOf(c.param(i), "Ljava/lang/CharSequence;")
+}
+
+// newObject allocates an instance of a class of the built-in class library.
+func (c *NativeCall) newObject(class string) (*Object, error) {
+	loaded, err := c.Method.Class.Loader.bootstrap().LoadClass(class)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewObject(loaded), nil
+}
+
+// checkIndex throws the exception of the given class, an IndexOutOfBoundsException or a subclass, when the
+// index is not in [0, length), with the message of `Objects.checkIndex`.
+func checkIndex(className string, index int, length int) error {
+	if index < 0 || index >= length {
+		return NewJavaError(className, "Index %d out of bounds for length %d", index, length)
+	}
+
+	return nil
+}
+
+// boolSlots returns the slot of a boolean value returned by a native method.
+func boolSlots(v bool) []Slot {
+	if v {
+		return []Slot{{Num: 1}}
+	}
+
+	return []Slot{{Num: 0}}
+}
+
+// javaString returns the chars of `String.valueOf` of a reference.
+func (t *Thread) javaString(obj *Object) ([]uint16, error) {
+	return t.stringValueOf([]Slot{{Ref: obj}}, "Ljava/lang/Object;")
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Spec: https://docs.oracle.com/en/java/javase/21/docs/api/java.base/java/io/PrintStream.html
+
+// printStreamClass is the internal name of the class of `System.out` and `System.err`.
+const printStreamClass = "java/io/PrintStream"
+
+func init() {
+	stream := &libraryClass{
+		name:   printStreamClass,
+		super:  objectClass,
+		access: ACC_PUBLIC,
+		methods: []libraryMethod{
+			publicMethod("println", "()V", func(call *NativeCall) ([]Slot, error) {
+				libraryState[printStream](call.This()).print([]uint16{'\n'})
+				return nil, nil
+			}),
+			publicMethod("write", "(I)V", func(call *NativeCall) ([]Slot, error) {
+				libraryState[printStream](call.This()).write([]byte{byte(call.Int(0))})
+				return nil, nil
+			}),
+			publicMethod("flush", "()V", func(call *NativeCall) ([]Slot, error) {
+				return nil, nil
+			}),
+		},
+	}
+
+	for _, descriptor := range stringValueDescriptors {
+		stream.methods = append(stream.methods,
+			publicMethod("print", "("+descriptor+")V", printStreamPrint(descriptor, false)),
+			publicMethod("println", "("+descriptor+")V", printStreamPrint(descriptor, true)),
+		)
+	}
+
+	addLibraryClasses(stream)
+}
+
+// printStream is the state of a java/io/PrintStream, where it writes. Like the JDK, it does not report the
+// errors of the writer.
+type printStream struct {
+	w io.Writer
+}
+
+// print writes chars encoded in UTF-8, the unpaired surrogates are replaced with '?' like the encoders of
+// the JDK do.
+func (s *printStream) print(chars []uint16) {
+	b := make([]byte, 0, len(chars))
+
+	for i := 0; i < len(chars); i++ {
+		r := rune(chars[i])
+
+		if utf16.IsSurrogate(r) {
+			r = utf8.RuneError
+			if i+1 < len(chars) {
+				r = utf16.DecodeRune(rune(chars[i]), rune(chars[i+1]))
+			}
+
+			if r == utf8.RuneError {
+				b = append(b, '?')
+				continue
+			}
+
+			i++
+		}
+
+		b = utf8.AppendRune(b, r)
+	}
+
+	s.write(b)
+}
+
+func (s *printStream) write(b []byte) {
+	if s.w != nil {
+		s.w.Write(b)
+	}
+}
+
+// printStreamPrint returns the native method of the `print` or `println` overload of PrintStream for the type
+// of the given descriptor, which writes the chars of `String.valueOf` of the value.
+func printStreamPrint(descriptor string, newLine bool) NativeMethod {
+	return func(call *NativeCall) ([]Slot, error) {
+		chars, err := valueOfChars(call, 0, descriptor)
+		if err != nil {
+			return nil, err
+		}
+
+		if newLine {
+			chars = append(chars, '\n')
+		}
+
+		libraryState[printStream](call.This()).print(chars)
+
+		return nil, nil
+	}
+}
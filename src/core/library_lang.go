@@ -0,0 +1,407 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Spec: https://docs.oracle.com/en/java/javase/21/docs/api/java.base/java/lang/package-summary.html
+
+// objectClass is the internal name of the root of the class hierarchy.
+const objectClass = "java/lang/Object"
+
+// systemClass is the internal name of the class of the standard streams and of the access to the runtime.
+const systemClass = "java/lang/System"
+
+// start is when the runtime started, the origin of `System.nanoTime`.
+var start = time.Now()
+
+func init() {
+	addLibraryClasses(
+		&libraryClass{
+			name:         objectClass,
+			access:       ACC_PUBLIC,
+			constructors: []string{"()V"},
+			methods: []libraryMethod{
+				publicMethod("hashCode", "()I", func(call *NativeCall) ([]Slot, error) {
+					return []Slot{{Num: call.This().IdentityHash()}}, nil
+				}),
+				publicMethod("equals", "(Ljava/lang/Object;)Z", func(call *NativeCall) ([]Slot, error) {
+					return boolSlots(call.This() == call.Ref(0)), nil
+				}),
+				publicMethod("toString", "()Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+					obj := call.This()
+
+					hash, err := call.Thread.javaHashCode(obj)
+					if err != nil {
+						return nil, err
+					}
+
+					return call.returnString(utf16Chars(fmt.Sprintf("%s@%x", javaName(obj.Class.Name), uint32(hash))))
+				}),
+				{access: ACC_PROTECTED, name: "clone", descriptor: "()Ljava/lang/Object;", native: objectClone},
+				{access: ACC_PUBLIC | ACC_FINAL, name: "getClass", descriptor: "()Ljava/lang/Class;", native: objectGetClass},
+				{access: ACC_PUBLIC | ACC_FINAL, name: "notify", descriptor: "()V", native: objectNotify(false)},
+				{access: ACC_PUBLIC | ACC_FINAL, name: "notifyAll", descriptor: "()V", native: objectNotify(true)},
+				{access: ACC_PUBLIC | ACC_FINAL, name: "wait", descriptor: "(J)V", native: objectWait},
+				{access: ACC_PUBLIC | ACC_FINAL, name: "wait", descriptor: "()V", native: func(call *NativeCall) ([]Slot, error) {
+					return nil, call.This().monitor().wait(call.Thread, 0)
+				}},
+			},
+		},
+		libraryInterface("java/lang/Comparable", nil, abstractMethod("compareTo", "(Ljava/lang/Object;)I")),
+		libraryInterface("java/lang/CharSequence", nil,
+			abstractMethod("length", "()I"),
+			abstractMethod("charAt", "(I)C"),
+			abstractMethod("subSequence", "(II)Ljava/lang/CharSequence;"),
+		),
+		libraryInterface("java/lang/Iterable", nil, abstractMethod("iterator", "()Ljava/util/Iterator;")),
+		libraryInterface("java/lang/Runnable", nil, abstractMethod("run", "()V")),
+		&libraryClass{
+			name:   systemClass,
+			super:  objectClass,
+			access: ACC_PUBLIC | ACC_FINAL,
+			fields: []libraryField{
+				{ACC_PUBLIC | ACC_STATIC | ACC_FINAL, "out", "Ljava/io/PrintStream;"},
+				{ACC_PUBLIC | ACC_STATIC | ACC_FINAL, "err", "Ljava/io/PrintStream;"},
+			},
+			methods: []libraryMethod{
+				staticMethod("currentTimeMillis", "()J", func(call *NativeCall) ([]Slot, error) {
+					return LongSlots(time.Now().UnixMilli()), nil
+				}),
+				staticMethod("nanoTime", "()J", func(call *NativeCall) ([]Slot, error) {
+					return LongSlots(int64(time.Since(start))), nil
+				}),
+				staticMethod("arraycopy", "(Ljava/lang/Object;ILjava/lang/Object;II)V", systemArraycopy),
+				staticMethod("identityHashCode", "(Ljava/lang/Object;)I", func(call *NativeCall) ([]Slot, error) {
+					if obj := call.Ref(0); obj != nil {
+						return []Slot{{Num: obj.IdentityHash()}}, nil
+					}
+
+					return []Slot{{Num: 0}}, nil
+				}),
+				staticMethod("exit", "(I)V", func(call *NativeCall) ([]Slot, error) {
+					return nil, &ExitError{Code: int(call.Int(0))}
+				}),
+				staticMethod("lineSeparator", "()Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+					return call.returnString([]uint16{'\n'})
+				}),
+				staticMethod("gc", "()V", func(call *NativeCall) ([]Slot, error) {
+					return nil, nil
+				}),
+			},
+			initialize: func(call *NativeCall) ([]Slot, error) {
+				class := call.Method.Class
+
+				for _, stream := range []struct {
+					name string
+					w    io.Writer
+				}{{"out", SystemOut}, {"err", SystemErr}} {
+					obj, err := call.newObject(printStreamClass)
+					if err != nil {
+						return nil, err
+					}
+
+					libraryState[printStream](obj).w = stream.w
+					class.StaticValues[class.DeclaredField(stream.name, "Ljava/io/PrintStream;").Slot].Ref = obj
+				}
+
+				return nil, nil
+			},
+		},
+		&libraryClass{
+			name:    "java/lang/Math",
+			super:   objectClass,
+			access:  ACC_PUBLIC | ACC_FINAL,
+			methods: mathMethods(),
+		},
+	)
+}
+
+// objectClone returns a copy of an array, or of an object whose class implements java/lang/Cloneable, with
+// the same values in its fields.
+func objectClone(call *NativeCall) ([]Slot, error) {
+	obj := call.This()
+
+	cloneable, err := obj.Class.Loader.bootstrap().LoadClass("java/lang/Cloneable")
+	if err != nil {
+		return nil, err
+	}
+
+	if !obj.IsInstanceOf(cloneable) {
+		return nil, NewJavaError("java/lang/CloneNotSupportedException", "%s", javaName(obj.Class.Name))
+	}
+
+	clone := &Object{Class: obj.Class, Fields: append([]Slot(nil), obj.Fields...)}
+
+	switch elements := obj.Elements.(type) {
+	case []int8:
+		clone.Elements = append([]int8(nil), elements...)
+	case []uint16:
+		clone.Elements = append([]uint16(nil), elements...)
+	case []int16:
+		clone.Elements = append([]int16(nil), elements...)
+	case []int32:
+		clone.Elements = append([]int32(nil), elements...)
+	case []int64:
+		clone.Elements = append([]int64(nil), elements...)
+	case []float32:
+		clone.Elements = append([]float32(nil), elements...)
+	case []float64:
+		clone.Elements = append([]float64(nil), elements...)
+	case []*Object:
+		clone.Elements = append([]*Object(nil), elements...)
+	}
+
+	return []Slot{{Ref: clone}}, nil
+}
+
+// systemArraycopy copies the components of an array to another one, or to the same one, as if they were
+// first copied to a temporary array, with the messages of HotSpot when it can not.
+func systemArraycopy(call *NativeCall) ([]Slot, error) {
+	src, srcPos, dest, destPos, length := call.Ref(0), int(call.Int(1)), call.Ref(2), int(call.Int(3)), int(call.Int(4))
+
+	if src == nil || dest == nil {
+		return nil, &JavaError{ClassName: NullPointerException}
+	}
+
+	for _, array := range []struct {
+		kind string
+		obj  *Object
+	}{{"source", src}, {"destination", dest}} {
+		if !array.obj.Class.IsArray() {
+			return nil, NewJavaError(ArrayStoreException, "arraycopy: %s type %s is not an array", array.kind, javaName(array.obj.Class.Name))
+		}
+	}
+
+	// primitive arrays are only copied to arrays of the same type
+	if (src.Class.ComponentType == nil || dest.Class.ComponentType == nil) && src.Class != dest.Class {
+		return nil, NewJavaError(ArrayStoreException, "arraycopy: type mismatch: can not copy %s[] into %s[]", arrayTypeName(src.Class), arrayTypeName(dest.Class))
+	}
+
+	srcLength, destLength := src.ArrayLength(), dest.ArrayLength()
+
+	switch {
+	case length < 0:
+		return nil, NewJavaError(ArrayIndexOutOfBoundsException, "arraycopy: length %d is negative", length)
+	case srcPos < 0:
+		return nil, NewJavaError(ArrayIndexOutOfBoundsException, "arraycopy: source index %d out of bounds for %s[%d]", srcPos, arrayTypeName(src.Class), srcLength)
+	case destPos < 0:
+		return nil, NewJavaError(ArrayIndexOutOfBoundsException, "arraycopy: destination index %d out of bounds for %s[%d]", destPos, arrayTypeName(dest.Class), destLength)
+	case srcPos+length > srcLength:
+		return nil, NewJavaError(ArrayIndexOutOfBoundsException, "arraycopy: last source index %d out of bounds for %s[%d]", srcPos+length, arrayTypeName(src.Class), srcLength)
+	case destPos+length > destLength:
+		return nil, NewJavaError(ArrayIndexOutOfBoundsException, "arraycopy: last destination index %d out of bounds for %s[%d]", destPos+length, arrayTypeName(dest.Class), destLength)
+	}
+
+	switch elements := src.Elements.(type) {
+	case []int8:
+		copy(dest.Elements.([]int8)[destPos:], elements[srcPos:srcPos+length])
+	case []uint16:
+		copy(dest.Elements.([]uint16)[destPos:], elements[srcPos:srcPos+length])
+	case []int16:
+		copy(dest.Elements.([]int16)[destPos:], elements[srcPos:srcPos+length])
+	case []int32:
+		copy(dest.Elements.([]int32)[destPos:], elements[srcPos:srcPos+length])
+	case []int64:
+		copy(dest.Elements.([]int64)[destPos:], elements[srcPos:srcPos+length])
+	case []float32:
+		copy(dest.Elements.([]float32)[destPos:], elements[srcPos:srcPos+length])
+	case []float64:
+		copy(dest.Elements.([]float64)[destPos:], elements[srcPos:srcPos+length])
+	case []*Object:
+		destElements := dest.Elements.([]*Object)
+		if src.Class.ComponentType.IsSubclassOf(dest.Class.ComponentType) {
+			copy(destElements[destPos:], elements[srcPos:srcPos+length])
+			return nil, nil
+		}
+
+		// the components are copied until one of them can not be stored in the destination
+		for i, element := range elements[srcPos : srcPos+length] {
+			if element != nil && !element.IsInstanceOf(dest.Class.ComponentType) {
+				return nil, NewJavaError(ArrayStoreException,
+					"arraycopy: element type mismatch: can not cast one of the elements of %s[] to the type of the destination array, %s",
+					javaName(src.Class.ComponentType.Name), javaName(dest.Class.ComponentType.Name),
+				)
+			}
+
+			destElements[destPos+i] = element
+		}
+	}
+
+	return nil, nil
+}
+
+// arrayTypeName returns the name of the type of the components of an array class in the messages of
+// arraycopy, the primitive type or `object array`.
+func arrayTypeName(class *Class) string {
+	if class.ComponentType != nil {
+		return "object array"
+	}
+
+	return TypeName(class.Name[1:])
+}
+
+// mathMethods returns the methods of java/lang/Math.
+func mathMethods() []libraryMethod {
+	methods := []libraryMethod{
+		staticMethod("abs", "(I)I", func(call *NativeCall) ([]Slot, error) {
+			// the minimum int is its own absolute value
+			if v := call.Int(0); v < 0 {
+				return []Slot{{Num: -v}}, nil
+			}
+
+			return call.Args[:1], nil
+		}),
+		staticMethod("abs", "(J)J", func(call *NativeCall) ([]Slot, error) {
+			if v := call.Long(0); v < 0 {
+				return LongSlots(-v), nil
+			}
+
+			return call.Args[:2], nil
+		}),
+		staticMethod("abs", "(F)F", func(call *NativeCall) ([]Slot, error) {
+			return []Slot{FloatSlot(float32(math.Abs(float64(call.Float(0)))))}, nil
+		}),
+		staticMethod("abs", "(D)D", func(call *NativeCall) ([]Slot, error) {
+			return DoubleSlots(math.Abs(call.Double(0))), nil
+		}),
+		staticMethod("max", "(II)I", func(call *NativeCall) ([]Slot, error) {
+			return []Slot{{Num: max(call.Int(0), call.Int(1))}}, nil
+		}),
+		staticMethod("min", "(II)I", func(call *NativeCall) ([]Slot, error) {
+			return []Slot{{Num: min(call.Int(0), call.Int(1))}}, nil
+		}),
+		staticMethod("max", "(JJ)J", func(call *NativeCall) ([]Slot, error) {
+			return LongSlots(max(call.Long(0), call.Long(1))), nil
+		}),
+		staticMethod("min", "(JJ)J", func(call *NativeCall) ([]Slot, error) {
+			return LongSlots(min(call.Long(0), call.Long(1))), nil
+		}),
+		// math.Max and math.Min have the semantics of Java for NaN and signed zeros
+		staticMethod("max", "(FF)F", func(call *NativeCall) ([]Slot, error) {
+			return []Slot{FloatSlot(float32(math.Max(float64(call.Float(0)), float64(call.Float(1)))))}, nil
+		}),
+		staticMethod("min", "(FF)F", func(call *NativeCall) ([]Slot, error) {
+			return []Slot{FloatSlot(float32(math.Min(float64(call.Float(0)), float64(call.Float(1)))))}, nil
+		}),
+		staticMethod("max", "(DD)D", func(call *NativeCall) ([]Slot, error) {
+			return DoubleSlots(math.Max(call.Double(0), call.Double(1))), nil
+		}),
+		staticMethod("min", "(DD)D", func(call *NativeCall) ([]Slot, error) {
+			return DoubleSlots(math.Min(call.Double(0), call.Double(1))), nil
+		}),
+		staticMethod("pow", "(DD)D", func(call *NativeCall) ([]Slot, error) {
+			x, y := call.Double(0), call.Double(1)
+
+			// unlike math.Pow, a NaN exponent and 1 to the power of an infinite exponent are NaN
+			if math.IsNaN(y) || math.Abs(x) == 1 && math.IsInf(y, 0) {
+				return DoubleSlots(math.NaN()), nil
+			}
+
+			return DoubleSlots(math.Pow(x, y)), nil
+		}),
+		staticMethod("atan2", "(DD)D", func(call *NativeCall) ([]Slot, error) {
+			return DoubleSlots(math.Atan2(call.Double(0), call.Double(1))), nil
+		}),
+		staticMethod("hypot", "(DD)D", func(call *NativeCall) ([]Slot, error) {
+			return DoubleSlots(math.Hypot(call.Double(0), call.Double(1))), nil
+		}),
+		staticMethod("round", "(F)I", func(call *NativeCall) ([]Slot, error) {
+			return []Slot{{Num: doubleToInt(roundHalfUp(float64(call.Float(0))))}}, nil
+		}),
+		staticMethod("round", "(D)J", func(call *NativeCall) ([]Slot, error) {
+			return LongSlots(doubleToLong(roundHalfUp(call.Double(0)))), nil
+		}),
+		staticMethod("random", "()D", func(call *NativeCall) ([]Slot, error) {
+			return DoubleSlots(rand.Float64()), nil
+		}),
+		staticMethod("floorDiv", "(II)I", func(call *NativeCall) ([]Slot, error) {
+			x, y := call.Int(0), call.Int(1)
+			if y == 0 {
+				return nil, NewJavaError(ArithmeticException, "/ by zero")
+			}
+
+			q := x / y
+			if (x%y != 0) && ((x < 0) != (y < 0)) {
+				q--
+			}
+
+			return []Slot{{Num: q}}, nil
+		}),
+		staticMethod("floorMod", "(II)I", func(call *NativeCall) ([]Slot, error) {
+			x, y := call.Int(0), call.Int(1)
+			if y == 0 {
+				return nil, NewJavaError(ArithmeticException, "/ by zero")
+			}
+
+			m := x % y
+			if m != 0 && ((m < 0) != (y < 0)) {
+				m += y
+			}
+
+			return []Slot{{Num: m}}, nil
+		}),
+		staticMethod("addExact", "(II)I", func(call *NativeCall) ([]Slot, error) {
+			sum := int64(call.Int(0)) + int64(call.Int(1))
+			if sum != int64(int32(sum)) {
+				return nil, NewJavaError(ArithmeticException, "integer overflow")
+			}
+
+			return []Slot{{Num: int32(sum)}}, nil
+		}),
+		staticMethod("multiplyExact", "(II)I", func(call *NativeCall) ([]Slot, error) {
+			product := int64(call.Int(0)) * int64(call.Int(1))
+			if product != int64(int32(product)) {
+				return nil, NewJavaError(ArithmeticException, "integer overflow")
+			}
+
+			return []Slot{{Num: int32(product)}}, nil
+		}),
+	}
+
+	// the functions of a double with the same semantics in Go
+	for _, function := range []struct {
+		name string
+		f    func(float64) float64
+	}{
+		{"sqrt", math.Sqrt}, {"cbrt", math.Cbrt}, {"floor", math.Floor}, {"ceil", math.Ceil}, {"rint", math.RoundToEven},
+		{"sin", math.Sin}, {"cos", math.Cos}, {"tan", math.Tan}, {"asin", math.Asin}, {"acos", math.Acos}, {"atan", math.Atan},
+		{"exp", math.Exp}, {"log", math.Log}, {"log10", math.Log10}, {"signum", signum},
+		{"toRadians", func(v float64) float64 { return v / 180 * math.Pi }},
+		{"toDegrees", func(v float64) float64 { return v * 180 / math.Pi }},
+	} {
+		f := function.f
+		methods = append(methods, staticMethod(function.name, "(D)D", func(call *NativeCall) ([]Slot, error) {
+			return DoubleSlots(f(call.Double(0))), nil
+		}))
+	}
+
+	return methods
+}
+
+// roundHalfUp rounds a double to the closest integer, ties rounding towards positive infinity, like
+// `Math.round`. NaN stays NaN, it converts to 0.
+func roundHalfUp(v float64) float64 {
+	floor := math.Floor(v)
+	if v-floor >= 0.5 {
+		return floor + 1
+	}
+
+	return floor
+}
+
+// signum returns 1 for positive numbers, -1 for negative ones and the number itself for zeros and NaN.
+func signum(v float64) float64 {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+
+	return v
+}
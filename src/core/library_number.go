@@ -0,0 +1,784 @@
+package core
+
+import (
+	"math"
+	"math/bits"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Spec: https://docs.oracle.com/en/java/javase/21/docs/api/java.base/java/lang/Number.html
+
+// boxType is a boxed primitive type, whose class has a `value` field with the primitive value.
+type boxType struct {
+	primitive string
+	super     string
+	// low and high are the values whose boxes `valueOf` returns from a cache, there are none when high is
+	// lower than low.
+	low, high int32
+	// compare compares two values like the static `compare` method of the class.
+	compare func(a []Slot, b []Slot) int32
+	// hash returns the hash code of a value like the static `hashCode` method of the class.
+	hash func(v []Slot) int32
+	// methods are the methods specific to the class.
+	methods []libraryMethod
+}
+
+func init() {
+	addLibraryClasses(
+		&libraryClass{
+			name:         "java/lang/Number",
+			super:        objectClass,
+			access:       ACC_PUBLIC | ACC_ABSTRACT,
+			interfaces:   []string{"java/io/Serializable"},
+			constructors: []string{"()V"},
+			methods: []libraryMethod{
+				abstractMethod("intValue", "()I"),
+				abstractMethod("longValue", "()J"),
+				abstractMethod("floatValue", "()F"),
+				abstractMethod("doubleValue", "()D"),
+				publicMethod("byteValue", "()B", func(call *NativeCall) ([]Slot, error) {
+					ret, err := call.Thread.callMethod(call.This(), "intValue", "()I")
+					if err != nil {
+						return nil, err
+					}
+
+					return convertPrimitive(ret, "I", "B"), nil
+				}),
+				publicMethod("shortValue", "()S", func(call *NativeCall) ([]Slot, error) {
+					ret, err := call.Thread.callMethod(call.This(), "intValue", "()I")
+					if err != nil {
+						return nil, err
+					}
+
+					return convertPrimitive(ret, "I", "S"), nil
+				}),
+			},
+		},
+		boxType{
+			primitive: "I", super: "java/lang/Number", low: -128, high: 127, compare: compareInts, hash: intHash,
+			methods: []libraryMethod{
+				staticMethod("parseInt", "(Ljava/lang/String;)I", parseIntegerNative("I", 10)),
+				staticMethod("parseInt", "(Ljava/lang/String;I)I", parseIntegerNative("I", -1)),
+				staticMethod("valueOf", "(Ljava/lang/String;)Ljava/lang/Integer;", valueOfStringNative("I")),
+				staticMethod("toString", "(II)Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+					return call.returnString(utf16Chars(strconv.FormatInt(int64(call.Int(0)), radix(call.Int(1)))))
+				}),
+				staticMethod("toHexString", "(I)Ljava/lang/String;", unsignedStringNative(16)),
+				staticMethod("toOctalString", "(I)Ljava/lang/String;", unsignedStringNative(8)),
+				staticMethod("toBinaryString", "(I)Ljava/lang/String;", unsignedStringNative(2)),
+				staticMethod("bitCount", "(I)I", func(call *NativeCall) ([]Slot, error) {
+					return []Slot{{Num: int32(bits.OnesCount32(uint32(call.Int(0))))}}, nil
+				}),
+				staticMethod("numberOfLeadingZeros", "(I)I", func(call *NativeCall) ([]Slot, error) {
+					return []Slot{{Num: int32(bits.LeadingZeros32(uint32(call.Int(0))))}}, nil
+				}),
+				staticMethod("numberOfTrailingZeros", "(I)I", func(call *NativeCall) ([]Slot, error) {
+					return []Slot{{Num: int32(bits.TrailingZeros32(uint32(call.Int(0))))}}, nil
+				}),
+				staticMethod("signum", "(I)I", func(call *NativeCall) ([]Slot, error) {
+					return []Slot{{Num: compareInts(call.Args[:1], []Slot{{Num: 0}})}}, nil
+				}),
+				staticMethod("max", "(II)I", func(call *NativeCall) ([]Slot, error) {
+					return []Slot{{Num: max(call.Int(0), call.Int(1))}}, nil
+				}),
+				staticMethod("min", "(II)I", func(call *NativeCall) ([]Slot, error) {
+					return []Slot{{Num: min(call.Int(0), call.Int(1))}}, nil
+				}),
+				staticMethod("sum", "(II)I", func(call *NativeCall) ([]Slot, error) {
+					return []Slot{{Num: call.Int(0) + call.Int(1)}}, nil
+				}),
+			},
+		}.class(),
+		boxType{
+			primitive: "J", super: "java/lang/Number", low: -128, high: 127, compare: compareLongs, hash: longHash,
+			methods: []libraryMethod{
+				staticMethod("parseLong", "(Ljava/lang/String;)J", parseIntegerNative("J", 10)),
+				staticMethod("parseLong", "(Ljava/lang/String;I)J", parseIntegerNative("J", -1)),
+				staticMethod("valueOf", "(Ljava/lang/String;)Ljava/lang/Long;", valueOfStringNative("J")),
+				staticMethod("toString", "(JI)Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+					return call.returnString(utf16Chars(strconv.FormatInt(call.Long(0), radix(call.Int(1)))))
+				}),
+				staticMethod("toHexString", "(J)Ljava/lang/String;", unsignedStringNative(16)),
+				staticMethod("toOctalString", "(J)Ljava/lang/String;", unsignedStringNative(8)),
+				staticMethod("toBinaryString", "(J)Ljava/lang/String;", unsignedStringNative(2)),
+				staticMethod("bitCount", "(J)I", func(call *NativeCall) ([]Slot, error) {
+					return []Slot{{Num: int32(bits.OnesCount64(uint64(call.Long(0))))}}, nil
+				}),
+				staticMethod("numberOfLeadingZeros", "(J)I", func(call *NativeCall) ([]Slot, error) {
+					return []Slot{{Num: int32(bits.LeadingZeros64(uint64(call.Long(0))))}}, nil
+				}),
+				staticMethod("numberOfTrailingZeros", "(J)I", func(call *NativeCall) ([]Slot, error) {
+					return []Slot{{Num: int32(bits.TrailingZeros64(uint64(call.Long(0))))}}, nil
+				}),
+				staticMethod("max", "(JJ)J", func(call *NativeCall) ([]Slot, error) {
+					return LongSlots(max(call.Long(0), call.Long(1))), nil
+				}),
+				staticMethod("min", "(JJ)J", func(call *NativeCall) ([]Slot, error) {
+					return LongSlots(min(call.Long(0), call.Long(1))), nil
+				}),
+				staticMethod("sum", "(JJ)J", func(call *NativeCall) ([]Slot, error) {
+					return LongSlots(call.Long(0) + call.Long(1)), nil
+				}),
+			},
+		}.class(),
+		boxType{
+			primitive: "S", super: "java/lang/Number", low: -128, high: 127, compare: subtractInts, hash: intHash,
+			methods: []libraryMethod{
+				staticMethod("parseShort", "(Ljava/lang/String;)S", parseIntegerNative("S", 10)),
+				staticMethod("parseShort", "(Ljava/lang/String;I)S", parseIntegerNative("S", -1)),
+				staticMethod("valueOf", "(Ljava/lang/String;)Ljava/lang/Short;", valueOfStringNative("S")),
+			},
+		}.class(),
+		boxType{
+			primitive: "B", super: "java/lang/Number", low: -128, high: 127, compare: subtractInts, hash: intHash,
+			methods: []libraryMethod{
+				staticMethod("parseByte", "(Ljava/lang/String;)B", parseIntegerNative("B", 10)),
+				staticMethod("parseByte", "(Ljava/lang/String;I)B", parseIntegerNative("B", -1)),
+				staticMethod("valueOf", "(Ljava/lang/String;)Ljava/lang/Byte;", valueOfStringNative("B")),
+			},
+		}.class(),
+		boxType{
+			primitive: "F", super: "java/lang/Number", low: 1, high: 0, compare: compareFloats, hash: floatHash,
+			methods: []libraryMethod{
+				staticMethod("parseFloat", "(Ljava/lang/String;)F", parseFloatingNative("F")),
+				staticMethod("valueOf", "(Ljava/lang/String;)Ljava/lang/Float;", valueOfStringNative("F")),
+				staticMethod("isNaN", "(F)Z", func(call *NativeCall) ([]Slot, error) {
+					return boolSlots(math.IsNaN(float64(call.Float(0)))), nil
+				}),
+				staticMethod("isInfinite", "(F)Z", func(call *NativeCall) ([]Slot, error) {
+					return boolSlots(math.IsInf(float64(call.Float(0)), 0)), nil
+				}),
+				staticMethod("isFinite", "(F)Z", func(call *NativeCall) ([]Slot, error) {
+					v := float64(call.Float(0))
+					return boolSlots(!math.IsInf(v, 0) && !math.IsNaN(v)), nil
+				}),
+				publicMethod("isNaN", "()Z", func(call *NativeCall) ([]Slot, error) {
+					return boolSlots(math.IsNaN(float64(SlotFloat(boxValue(call.This(), "F")[0])))), nil
+				}),
+				staticMethod("floatToIntBits", "(F)I", func(call *NativeCall) ([]Slot, error) {
+					return []Slot{{Num: floatHash(call.Args)}}, nil
+				}),
+				staticMethod("floatToRawIntBits", "(F)I", func(call *NativeCall) ([]Slot, error) {
+					return call.Args[:1], nil
+				}),
+				staticMethod("intBitsToFloat", "(I)F", func(call *NativeCall) ([]Slot, error) {
+					return call.Args[:1], nil
+				}),
+			},
+		}.class(),
+		boxType{
+			primitive: "D", super: "java/lang/Number", low: 1, high: 0, compare: compareDoubles, hash: doubleHash,
+			methods: []libraryMethod{
+				staticMethod("parseDouble", "(Ljava/lang/String;)D", parseFloatingNative("D")),
+				staticMethod("valueOf", "(Ljava/lang/String;)Ljava/lang/Double;", valueOfStringNative("D")),
+				staticMethod("isNaN", "(D)Z", func(call *NativeCall) ([]Slot, error) {
+					return boolSlots(math.IsNaN(call.Double(0))), nil
+				}),
+				staticMethod("isInfinite", "(D)Z", func(call *NativeCall) ([]Slot, error) {
+					return boolSlots(math.IsInf(call.Double(0), 0)), nil
+				}),
+				staticMethod("isFinite", "(D)Z", func(call *NativeCall) ([]Slot, error) {
+					v := call.Double(0)
+					return boolSlots(!math.IsInf(v, 0) && !math.IsNaN(v)), nil
+				}),
+				publicMethod("isNaN", "()Z", func(call *NativeCall) ([]Slot, error) {
+					return boolSlots(math.IsNaN(SlotsDouble(boxValue(call.This(), "D")))), nil
+				}),
+				staticMethod("doubleToLongBits", "(D)J", func(call *NativeCall) ([]Slot, error) {
+					return LongSlots(doubleBits(call.Double(0))), nil
+				}),
+				staticMethod("doubleToRawLongBits", "(D)J", func(call *NativeCall) ([]Slot, error) {
+					return call.Args[:2], nil
+				}),
+				staticMethod("longBitsToDouble", "(J)D", func(call *NativeCall) ([]Slot, error) {
+					return call.Args[:2], nil
+				}),
+			},
+		}.class(),
+		boxType{
+			primitive: "C", super: objectClass, low: 0, high: 127, compare: subtractInts, hash: intHash,
+			methods: characterMethods(),
+		}.class(),
+		boxType{
+			primitive: "Z", super: objectClass, low: 1, high: 0, compare: subtractInts,
+			hash: func(v []Slot) int32 {
+				if v[0].Num != 0 {
+					return 1231
+				}
+
+				return 1237
+			},
+			methods: []libraryMethod{
+				staticMethod("parseBoolean", "(Ljava/lang/String;)Z", func(call *NativeCall) ([]Slot, error) {
+					return boolSlots(strings.EqualFold(call.String(0), "true")), nil
+				}),
+				staticMethod("valueOf", "(Ljava/lang/String;)Ljava/lang/Boolean;", func(call *NativeCall) ([]Slot, error) {
+					return booleanBox(call, strings.EqualFold(call.String(0), "true")), nil
+				}),
+			},
+		}.class(),
+	)
+}
+
+// class returns the class of the boxed primitive type.
+//
+// Its static initializer fills in the cache of the boxes `valueOf` returns, the `TRUE` and `FALSE` fields
+// of java/lang/Boolean.
+func (b boxType) class() *libraryClass {
+	name, primitive := wrappers[b.primitive], b.primitive
+	descriptor := "L" + name + ";"
+
+	interfaces := []string{"java/lang/Comparable"}
+	if b.super == objectClass {
+		interfaces = append(interfaces, "java/io/Serializable")
+	}
+
+	class := &libraryClass{
+		name:         name,
+		super:        b.super,
+		access:       ACC_PUBLIC | ACC_FINAL,
+		interfaces:   interfaces,
+		fields:       []libraryField{{ACC_PRIVATE | ACC_FINAL, "value", primitive}},
+		constructors: []string{"(" + primitive + ")V"},
+		methods: append([]libraryMethod{
+			initMethod("("+primitive+")V", func(call *NativeCall) ([]Slot, error) {
+				setBoxValue(call.This(), primitive, call.param(0))
+				return nil, nil
+			}),
+			staticMethod("valueOf", "("+primitive+")"+descriptor, func(call *NativeCall) ([]Slot, error) {
+				return b.valueOf(call, call.param(0))
+			}),
+			staticMethod("toString", "("+primitive+")Ljava/lang/String;", stringValueOfNative(primitive)),
+			staticMethod("hashCode", "("+primitive+")I", func(call *NativeCall) ([]Slot, error) {
+				return []Slot{{Num: b.hash(call.param(0))}}, nil
+			}),
+			staticMethod("compare", "("+primitive+primitive+")I", func(call *NativeCall) ([]Slot, error) {
+				return []Slot{{Num: b.compare(call.param(0), call.param(1))}}, nil
+			}),
+			publicMethod("toString", "()Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+				chars, err := call.Thread.stringValueOf(boxValue(call.This(), primitive), primitive)
+				if err != nil {
+					return nil, err
+				}
+
+				return call.returnString(chars)
+			}),
+			publicMethod("hashCode", "()I", func(call *NativeCall) ([]Slot, error) {
+				return []Slot{{Num: b.hash(boxValue(call.This(), primitive))}}, nil
+			}),
+			publicMethod("equals", "(Ljava/lang/Object;)Z", func(call *NativeCall) ([]Slot, error) {
+				obj, other := call.This(), call.Ref(0)
+				return boolSlots(other != nil && other.Class == obj.Class && b.compare(boxValue(obj, primitive), boxValue(other, primitive)) == 0), nil
+			}),
+			publicMethod("compareTo", "("+descriptor+")I", func(call *NativeCall) ([]Slot, error) {
+				other := call.Ref(0)
+				if other == nil {
+					return nil, &JavaError{ClassName: NullPointerException}
+				}
+
+				return []Slot{{Num: b.compare(boxValue(call.This(), primitive), boxValue(other, primitive))}}, nil
+			}),
+			publicMethod("compareTo", "(Ljava/lang/Object;)I", func(call *NativeCall) ([]Slot, error) {
+				// the bridge method of Comparable
+				obj, other := call.This(), call.Ref(0)
+				if other == nil {
+					return nil, &JavaError{ClassName: NullPointerException}
+				}
+
+				if other.Class != obj.Class {
+					return nil, &JavaError{ClassName: ClassCastException, Message: classCastMessage(other.Class, obj.Class)}
+				}
+
+				return []Slot{{Num: b.compare(boxValue(obj, primitive), boxValue(other, primitive))}}, nil
+			}),
+		}, b.methods...),
+	}
+
+	switch {
+	case b.super == "java/lang/Number":
+		for _, to := range "BSIJFD" {
+			to := string(to)
+			class.methods = append(class.methods, publicMethod(strings.ToLower(TypeName(to))+"Value", "()"+to, func(call *NativeCall) ([]Slot, error) {
+				return convertPrimitive(boxValue(call.This(), primitive), primitive, to), nil
+			}))
+		}
+	case primitive == "C":
+		class.methods = append(class.methods, publicMethod("charValue", "()C", func(call *NativeCall) ([]Slot, error) {
+			return boxValue(call.This(), primitive), nil
+		}))
+	case primitive == "Z":
+		class.methods = append(class.methods, publicMethod("booleanValue", "()Z", func(call *NativeCall) ([]Slot, error) {
+			return boxValue(call.This(), primitive), nil
+		}))
+	}
+
+	if b.low <= b.high {
+		class.fields = append(class.fields, libraryField{ACC_PRIVATE | ACC_STATIC | ACC_FINAL, "cache", "[" + descriptor})
+		class.initialize = func(call *NativeCall) ([]Slot, error) {
+			return nil, b.fillCache(call.Method.Class)
+		}
+	}
+
+	if primitive == "Z" {
+		class.fields = append(class.fields,
+			libraryField{ACC_PUBLIC | ACC_STATIC | ACC_FINAL, "TRUE", descriptor},
+			libraryField{ACC_PUBLIC | ACC_STATIC | ACC_FINAL, "FALSE", descriptor},
+		)
+		class.initialize = func(call *NativeCall) ([]Slot, error) {
+			box := call.Method.Class
+
+			for i, field := range []string{"FALSE", "TRUE"} {
+				obj := NewObject(box)
+				setBoxValue(obj, primitive, []Slot{{Num: int32(i)}})
+
+				box.StaticValues[box.DeclaredField(field, descriptor).Slot].Ref = obj
+			}
+
+			return nil, nil
+		}
+	}
+
+	return class
+}
+
+// fillCache sets the `cache` field of the class of the boxes to an array with the boxes of the values from
+// low to high.
+func (b boxType) fillCache(box *Class) error {
+	arrayClass, err := box.ArrayClass()
+	if err != nil {
+		return err
+	}
+
+	cache := NewArray(arrayClass, int(b.high-b.low+1))
+	for i := range cache.Elements.([]*Object) {
+		obj := NewObject(box)
+		setBoxValue(obj, b.primitive, convertPrimitive([]Slot{{Num: b.low + int32(i)}}, "I", b.primitive))
+
+		cache.Elements.([]*Object)[i] = obj
+	}
+
+	box.StaticValues[box.DeclaredField("cache", arrayClass.Name).Slot].Ref = cache
+
+	return nil
+}
+
+// valueOf returns the box of a value, from the cache when the value is in it.
+func (b boxType) valueOf(call *NativeCall, value []Slot) ([]Slot, error) {
+	box := call.Method.Class
+
+	if b.primitive == "Z" {
+		return booleanBox(call, value[0].Num != 0), nil
+	}
+
+	if v := SlotsLong(convertPrimitive(value, b.primitive, "J")); v >= int64(b.low) && v <= int64(b.high) {
+		cache := box.StaticValues[box.DeclaredField("cache", "[L"+box.Name+";").Slot].Ref
+		return []Slot{{Ref: cache.Elements.([]*Object)[v-int64(b.low)]}}, nil
+	}
+
+	obj := NewObject(box)
+	setBoxValue(obj, b.primitive, value)
+
+	return []Slot{{Ref: obj}}, nil
+}
+
+// booleanBox returns `Boolean.TRUE` or `Boolean.FALSE`.
+func booleanBox(call *NativeCall, v bool) []Slot {
+	box := call.Method.Class
+
+	field := "FALSE"
+	if v {
+		field = "TRUE"
+	}
+
+	return []Slot{box.StaticValues[box.DeclaredField(field, "Ljava/lang/Boolean;").Slot]}
+}
+
+// boxValue returns the slots of the value of a box.
+func boxValue(obj *Object, primitive string) []Slot {
+	field := obj.Class.DeclaredField("value", primitive)
+	return obj.Fields[field.Slot : field.Slot+DescriptorSlots(primitive)]
+}
+
+func setBoxValue(obj *Object, primitive string, value []Slot) {
+	field := obj.Class.DeclaredField("value", primitive)
+	copy(obj.Fields[field.Slot:field.Slot+DescriptorSlots(primitive)], value)
+}
+
+// convertPrimitive converts a primitive value to another primitive type, the way the casts of the Java
+// language do.
+func convertPrimitive(value []Slot, from string, to string) []Slot {
+	var integer int64
+
+	switch from {
+	case "J":
+		integer = SlotsLong(value)
+	case "F", "D":
+		floating := float64(SlotFloat(value[0]))
+		if from == "D" {
+			floating = SlotsDouble(value)
+		}
+
+		switch to {
+		case "F":
+			return []Slot{FloatSlot(float32(floating))}
+		case "D":
+			return DoubleSlots(floating)
+		case "J":
+			return LongSlots(doubleToLong(floating))
+		}
+
+		integer = int64(doubleToInt(floating))
+	default:
+		integer = int64(value[0].Num)
+	}
+
+	switch to {
+	case "B":
+		return []Slot{{Num: int32(int8(integer))}}
+	case "S":
+		return []Slot{{Num: int32(int16(integer))}}
+	case "C":
+		return []Slot{{Num: int32(uint16(integer))}}
+	case "J":
+		return LongSlots(integer)
+	case "F":
+		return []Slot{FloatSlot(float32(integer))}
+	case "D":
+		return DoubleSlots(float64(integer))
+	}
+
+	return []Slot{{Num: int32(integer)}}
+}
+
+func compareInts(a []Slot, b []Slot) int32 {
+	return compareLongs(LongSlots(int64(a[0].Num)), LongSlots(int64(b[0].Num)))
+}
+
+func compareLongs(a []Slot, b []Slot) int32 {
+	switch x, y := SlotsLong(a), SlotsLong(b); {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+
+	return 0
+}
+
+// subtractInts compares chars, shorts, bytes and booleans, whose difference can not overflow an int, by
+// subtracting them.
+func subtractInts(a []Slot, b []Slot) int32 {
+	return a[0].Num - b[0].Num
+}
+
+// compareFloats compares floats like `Float.compare`, which unlike the operators of the language orders
+// -0.0 before 0.0 and NaN after every other value, and NaN is equal to itself.
+func compareFloats(a []Slot, b []Slot) int32 {
+	x, y := SlotFloat(a[0]), SlotFloat(b[0])
+	if x < y || x > y {
+		return compareFloating(float64(x), float64(y), true)
+	}
+
+	return compareInts([]Slot{{Num: floatHash(a)}}, []Slot{{Num: floatHash(b)}})
+}
+
+// compareDoubles compares doubles like `Double.compare`, see `compareFloats`.
+func compareDoubles(a []Slot, b []Slot) int32 {
+	x, y := SlotsDouble(a), SlotsDouble(b)
+	if x < y || x > y {
+		return compareFloating(x, y, true)
+	}
+
+	return compareLongs(LongSlots(doubleBits(x)), LongSlots(doubleBits(y)))
+}
+
+func intHash(v []Slot) int32 {
+	return v[0].Num
+}
+
+func longHash(v []Slot) int32 {
+	l := SlotsLong(v)
+	return int32(l ^ int64(uint64(l)>>32))
+}
+
+// floatHash returns the bits of a float like `Float.floatToIntBits`, every NaN having the same bits.
+func floatHash(v []Slot) int32 {
+	if f := SlotFloat(v[0]); f != f {
+		return 0x7fc00000
+	}
+
+	return v[0].Num
+}
+
+func doubleHash(v []Slot) int32 {
+	return longHash(LongSlots(doubleBits(SlotsDouble(v))))
+}
+
+// doubleBits returns the bits of a double like `Double.doubleToLongBits`, every NaN having the same bits.
+func doubleBits(v float64) int64 {
+	if v != v {
+		return 0x7ff8000000000000
+	}
+
+	return int64(math.Float64bits(v))
+}
+
+// radix returns the radix of the conversions of numbers to strings, which are in radix 10 when it is not
+// valid.
+func radix(radix int32) int {
+	if radix < 2 || radix > 36 {
+		return 10
+	}
+
+	return int(radix)
+}
+
+// unsignedStringNative returns the native method that formats an int or a long as an unsigned number in
+// the given radix, like `Integer.toHexString`.
+func unsignedStringNative(radix int) NativeMethod {
+	return func(call *NativeCall) ([]Slot, error) {
+		v := uint64(uint32(call.Args[0].Num))
+		if call.Method.Descriptor[1] == 'J' {
+			v = uint64(call.Long(0))
+		}
+
+		return call.returnString(utf16Chars(strconv.FormatUint(v, radix)))
+	}
+}
+
+// parseIntegerNative returns the native method that parses an integer of the given primitive type, like
+// `Integer.parseInt`, in radix 10 or in the radix of its second parameter when the given radix is -1.
+func parseIntegerNative(primitive string, radix int) NativeMethod {
+	return func(call *NativeCall) ([]Slot, error) {
+		v, err := parseInteger(call, primitive, radix)
+		if err != nil {
+			return nil, err
+		}
+
+		return convertPrimitive(LongSlots(v), "J", primitive), nil
+	}
+}
+
+// parseInteger parses the string parameter as an integer of the given primitive type, throwing
+// NumberFormatExceptions with the messages of the JDK.
+func parseInteger(call *NativeCall, primitive string, radix int) (int64, error) {
+	if radix == -1 {
+		radix = int(call.Int(1))
+	}
+
+	str := call.Ref(0)
+	if str == nil {
+		return 0, NewJavaError(NumberFormatException, "Cannot parse null string: null")
+	}
+
+	switch {
+	case radix < 2:
+		return 0, NewJavaError(NumberFormatException, "radix %d less than Character.MIN_RADIX", radix)
+	case radix > 36:
+		return 0, NewJavaError(NumberFormatException, "radix %d greater than Character.MAX_RADIX", radix)
+	}
+
+	s := GoString(str)
+
+	bitSize := 32
+	if primitive == "J" {
+		bitSize = 64
+	}
+
+	// with a base, strconv only accepts a sign and digits, like Java
+	v, err := strconv.ParseInt(s, radix, bitSize)
+	if err != nil {
+		under := ""
+		if radix != 10 {
+			under = " under radix " + strconv.Itoa(radix)
+		}
+
+		return 0, NewJavaError(NumberFormatException, "For input string: \"%s\"%s", s, under)
+	}
+
+	if primitive == "B" && v != int64(int8(v)) || primitive == "S" && v != int64(int16(v)) {
+		return 0, NewJavaError(NumberFormatException, "Value out of range. Value:\"%s\" Radix:%d", s, radix)
+	}
+
+	return v, nil
+}
+
+// floatingLiteral matches the strings `Double.parseDouble` accepts, once the whitespace around them is
+// removed: decimal and hexadecimal literals, with an optional type suffix, NaN and infinities.
+var floatingLiteral = regexp.MustCompile(
+	`^[+-]?(NaN|Infinity|(([0-9]+\.?[0-9]*|\.[0-9]+)([eE][+-]?[0-9]+)?|0[xX]([0-9a-fA-F]+\.?[0-9a-fA-F]*|\.[0-9a-fA-F]+)[pP][+-]?[0-9]+)[fFdD]?)$`,
+)
+
+// parseFloatingNative returns the native method that parses a float or a double, like `Double.parseDouble`.
+func parseFloatingNative(primitive string) NativeMethod {
+	return func(call *NativeCall) ([]Slot, error) {
+		v, err := parseFloating(call, primitive)
+		if err != nil {
+			return nil, err
+		}
+
+		return convertPrimitive(DoubleSlots(v), "D", primitive), nil
+	}
+}
+
+// parseFloating parses the string parameter as a float or a double, rounded to the precision of the type.
+func parseFloating(call *NativeCall, primitive string) (float64, error) {
+	str := call.Ref(0)
+	if str == nil {
+		return 0, &JavaError{ClassName: NullPointerException}
+	}
+
+	s := GoString(str)
+
+	trimmed := strings.TrimFunc(s, func(r rune) bool { return r <= ' ' })
+	if trimmed == "" {
+		return 0, NewJavaError(NumberFormatException, "empty String")
+	}
+
+	if !floatingLiteral.MatchString(trimmed) {
+		return 0, NewJavaError(NumberFormatException, "For input string: \"%s\"", s)
+	}
+
+	if strings.HasSuffix(trimmed, "NaN") {
+		return math.NaN(), nil
+	}
+
+	if strings.HasSuffix(trimmed, "Infinity") {
+		return math.Inf(1 - 2*strings.Count(trimmed[:1], "-")), nil
+	}
+
+	bitSize := 64
+	if primitive == "F" {
+		bitSize = 32
+	}
+
+	// out of range values are infinities, which strconv returns along with an error
+	v, _ := strconv.ParseFloat(strings.TrimRight(trimmed, "fFdD"), bitSize)
+
+	return v, nil
+}
+
+// valueOfStringNative returns the native method that parses a string into a box, like `Integer.valueOf(String)`.
+func valueOfStringNative(primitive string) NativeMethod {
+	return func(call *NativeCall) ([]Slot, error) {
+		var value []Slot
+
+		switch primitive {
+		case "F", "D":
+			v, err := parseFloating(call, primitive)
+			if err != nil {
+				return nil, err
+			}
+
+			value = convertPrimitive(DoubleSlots(v), "D", primitive)
+		default:
+			v, err := parseInteger(call, primitive, 10)
+			if err != nil {
+				return nil, err
+			}
+
+			value = convertPrimitive(LongSlots(v), "J", primitive)
+		}
+
+		box := call.Method.Class
+
+		valueOf := box.DeclaredMethod("valueOf", "("+primitive+")L"+box.Name+";")
+		if valueOf == nil {
+			return nil, NewJavaError(NoSuchMethodError, "'%s'", MethodSignature(box.Name, "valueOf", "("+primitive+")L"+box.Name+";"))
+		}
+
+		return call.Thread.Invoke(valueOf, value)
+	}
+}
+
+// characterMethods returns the static methods of java/lang/Character about the properties of characters.
+func characterMethods() []libraryMethod {
+	methods := []libraryMethod{
+		staticMethod("toUpperCase", "(C)C", func(call *NativeCall) ([]Slot, error) {
+			return []Slot{{Num: int32(uint16(toUpperCase(rune(call.Int(0)))))}}, nil
+		}),
+		staticMethod("toLowerCase", "(C)C", func(call *NativeCall) ([]Slot, error) {
+			return []Slot{{Num: int32(uint16(toLowerCase(rune(call.Int(0)))))}}, nil
+		}),
+		staticMethod("digit", "(CI)I", func(call *NativeCall) ([]Slot, error) {
+			return []Slot{{Num: int32(digit(rune(call.Int(0)), int(call.Int(1))))}}, nil
+		}),
+		staticMethod("forDigit", "(II)C", func(call *NativeCall) ([]Slot, error) {
+			d, r := call.Int(0), call.Int(1)
+			if r < 2 || r > 36 || d < 0 || d >= r {
+				return []Slot{{Num: 0}}, nil
+			}
+
+			return []Slot{{Num: int32(strconv.FormatInt(int64(d), int(r))[0])}}, nil
+		}),
+		staticMethod("getNumericValue", "(C)I", func(call *NativeCall) ([]Slot, error) {
+			return []Slot{{Num: int32(digit(rune(call.Int(0)), 36))}}, nil
+		}),
+	}
+
+	for _, predicate := range []struct {
+		name string
+		is   func(rune) bool
+	}{
+		{"isDigit", unicode.IsDigit},
+		{"isLetter", unicode.IsLetter},
+		{"isLetterOrDigit", func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }},
+		{"isUpperCase", unicode.IsUpper},
+		{"isLowerCase", unicode.IsLower},
+		{"isWhitespace", isJavaWhitespace},
+		{"isSpaceChar", func(r rune) bool { return unicode.In(r, unicode.Zs, unicode.Zl, unicode.Zp) }},
+	} {
+		is := predicate.is
+		methods = append(methods, staticMethod(predicate.name, "(C)Z", func(call *NativeCall) ([]Slot, error) {
+			return boolSlots(is(rune(call.Int(0)))), nil
+		}))
+	}
+
+	return methods
+}
+
+// toUpperCase and toLowerCase map a character with the simple case mappings of Unicode, like
+// `Character.toUpperCase` and `Character.toLowerCase`.
+func toUpperCase(r rune) rune {
+	return unicode.ToUpper(r)
+}
+
+func toLowerCase(r rune) rune {
+	return unicode.ToLower(r)
+}
+
+// isJavaWhitespace tells if a character is whitespace like `Character.isWhitespace`: the separators of
+// Unicode except the non-breaking spaces, and the ASCII tabulations, line feeds and separators.
+func isJavaWhitespace(r rune) bool {
+	switch r {
+	case '\u00a0', '\u2007', '\u202f':
+		return false
+	case '\t', '\n', '\u000b', '\f', '\r', '\u001c', '\u001d', '\u001e', '\u001f':
+		return true
+	}
+
+	return unicode.In(r, unicode.Zs, unicode.Zl, unicode.Zp)
+}
+
+// digit returns the value of a character as a digit in the given radix, or -1 when it is not one.
+func digit(r rune, radix int) int {
+	d := -1
+
+	switch {
+	case r >= '0' && r <= '9':
+		d = int(r - '0')
+	case r >= 'a' && r <= 'z':
+		d = int(r-'a') + 10
+	case r >= 'A' && r <= 'Z':
+		d = int(r-'A') + 10
+	}
+
+	if d >= radix {
+		return -1
+	}
+
+	return d
+}
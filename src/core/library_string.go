@@ -0,0 +1,715 @@
+package core
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+// Spec: https://docs.oracle.com/en/java/javase/21/docs/api/java.base/java/lang/String.html
+
+// stringBuilderClass is the internal name of the mutable sequence of chars javac used to concatenate strings
+// before Java 9.
+const stringBuilderClass = "java/lang/StringBuilder"
+
+// stringValueDescriptors are the descriptors of the values `String.valueOf`, `StringBuilder.append` and
+// `PrintStream.print` have an overload for.
+var stringValueDescriptors = []string{"Z", "C", "I", "J", "F", "D", "[C", "Ljava/lang/String;", "Ljava/lang/Object;"}
+
+func init() {
+	str := &libraryClass{
+		name:       stringClass,
+		super:      "java/lang/Object",
+		access:     ACC_PUBLIC | ACC_FINAL,
+		interfaces: []string{"java/io/Serializable", "java/lang/Comparable", "java/lang/CharSequence"},
+		fields: []libraryField{
+			{ACC_PRIVATE | ACC_FINAL, "value", "[B"},
+			{ACC_PRIVATE | ACC_FINAL, "coder", "B"},
+			{ACC_PRIVATE, "hash", "I"},
+			{ACC_PRIVATE, "hashIsZero", "Z"},
+		},
+		constructors: []string{"()V", "([C)V", "([CII)V", "(Ljava/lang/String;)V", "(Ljava/lang/StringBuilder;)V"},
+		methods: []libraryMethod{
+			initMethod("([C)V", func(call *NativeCall) ([]Slot, error) {
+				chars, err := charArray(call.Ref(0))
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, setStringChars(call.This(), chars)
+			}),
+			initMethod("([CII)V", func(call *NativeCall) ([]Slot, error) {
+				chars, err := charArray(call.Ref(0))
+				if err != nil {
+					return nil, err
+				}
+
+				offset, count := int(call.Int(1)), int(call.Int(2))
+				if offset < 0 || count < 0 || offset > len(chars)-count {
+					return nil, NewJavaError(StringIndexOutOfBoundsException, "offset %d, count %d, length %d", offset, count, len(chars))
+				}
+
+				return nil, setStringChars(call.This(), chars[offset:offset+count])
+			}),
+			initMethod("(Ljava/lang/String;)V", func(call *NativeCall) ([]Slot, error) {
+				chars, err := call.chars(0)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, setStringChars(call.This(), chars)
+			}),
+			initMethod("(Ljava/lang/StringBuilder;)V", func(call *NativeCall) ([]Slot, error) {
+				builder := call.Ref(0)
+				if builder == nil {
+					return nil, &JavaError{ClassName: NullPointerException}
+				}
+
+				return nil, setStringChars(call.This(), libraryState[stringBuilder](builder).chars)
+			}),
+
+			publicMethod("length", "()I", func(call *NativeCall) ([]Slot, error) {
+				return []Slot{{Num: int32(len(stringChars(call.This())))}}, nil
+			}),
+			publicMethod("isEmpty", "()Z", func(call *NativeCall) ([]Slot, error) {
+				return boolSlots(len(stringChars(call.This())) == 0), nil
+			}),
+			publicMethod("charAt", "(I)C", func(call *NativeCall) ([]Slot, error) {
+				chars, index := stringChars(call.This()), int(call.Int(0))
+				if err := checkIndex(StringIndexOutOfBoundsException, index, len(chars)); err != nil {
+					return nil, err
+				}
+
+				return []Slot{{Num: int32(chars[index])}}, nil
+			}),
+			publicMethod("equals", "(Ljava/lang/Object;)Z", func(call *NativeCall) ([]Slot, error) {
+				other := call.Ref(0)
+				if other == call.This() {
+					return boolSlots(true), nil
+				}
+
+				return boolSlots(other != nil && other.Class.Name == stringClass && equalChars(stringChars(call.This()), stringChars(other))), nil
+			}),
+			publicMethod("equalsIgnoreCase", "(Ljava/lang/String;)Z", func(call *NativeCall) ([]Slot, error) {
+				other := call.Ref(0)
+				if other == nil {
+					return boolSlots(false), nil
+				}
+
+				a, b := stringChars(call.This()), stringChars(other)
+				if len(a) != len(b) {
+					return boolSlots(false), nil
+				}
+
+				for i := range a {
+					if !equalCharsIgnoreCase(a[i], b[i]) {
+						return boolSlots(false), nil
+					}
+				}
+
+				return boolSlots(true), nil
+			}),
+			publicMethod("hashCode", "()I", stringHashCode),
+			publicMethod("toString", "()Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+				return call.Args[:1], nil
+			}),
+			publicMethod("compareTo", "(Ljava/lang/String;)I", stringCompareTo),
+			publicMethod("compareTo", "(Ljava/lang/Object;)I", func(call *NativeCall) ([]Slot, error) {
+				// the bridge method of Comparable
+				if other := call.Ref(0); other != nil && other.Class.Name != stringClass {
+					return nil, &JavaError{ClassName: ClassCastException, Message: classCastMessage(other.Class, call.This().Class)}
+				}
+
+				return stringCompareTo(call)
+			}),
+			publicMethod("concat", "(Ljava/lang/String;)Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+				other, err := call.chars(0)
+				if err != nil {
+					return nil, err
+				}
+
+				if len(other) == 0 {
+					return call.Args[:1], nil
+				}
+
+				return call.returnString(append(stringChars(call.This()), other...))
+			}),
+			publicMethod("substring", "(I)Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+				chars := stringChars(call.This())
+				return substring(call, chars, int(call.Int(0)), len(chars))
+			}),
+			publicMethod("substring", "(II)Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+				return substring(call, stringChars(call.This()), int(call.Int(0)), int(call.Int(1)))
+			}),
+			publicMethod("subSequence", "(II)Ljava/lang/CharSequence;", func(call *NativeCall) ([]Slot, error) {
+				return substring(call, stringChars(call.This()), int(call.Int(0)), int(call.Int(1)))
+			}),
+			publicMethod("indexOf", "(I)I", func(call *NativeCall) ([]Slot, error) {
+				return []Slot{{Num: int32(indexOfChars(stringChars(call.This()), codePointChars(call.Int(0)), 0))}}, nil
+			}),
+			publicMethod("indexOf", "(II)I", func(call *NativeCall) ([]Slot, error) {
+				return []Slot{{Num: int32(indexOfChars(stringChars(call.This()), codePointChars(call.Int(0)), int(call.Int(1))))}}, nil
+			}),
+			publicMethod("indexOf", "(Ljava/lang/String;)I", func(call *NativeCall) ([]Slot, error) {
+				sub, err := call.chars(0)
+				if err != nil {
+					return nil, err
+				}
+
+				return []Slot{{Num: int32(indexOfChars(stringChars(call.This()), sub, 0))}}, nil
+			}),
+			publicMethod("indexOf", "(Ljava/lang/String;I)I", func(call *NativeCall) ([]Slot, error) {
+				sub, err := call.chars(0)
+				if err != nil {
+					return nil, err
+				}
+
+				return []Slot{{Num: int32(indexOfChars(stringChars(call.This()), sub, int(call.Int(1))))}}, nil
+			}),
+			publicMethod("lastIndexOf", "(I)I", func(call *NativeCall) ([]Slot, error) {
+				return []Slot{{Num: int32(lastIndexOfChars(stringChars(call.This()), codePointChars(call.Int(0))))}}, nil
+			}),
+			publicMethod("lastIndexOf", "(Ljava/lang/String;)I", func(call *NativeCall) ([]Slot, error) {
+				sub, err := call.chars(0)
+				if err != nil {
+					return nil, err
+				}
+
+				return []Slot{{Num: int32(lastIndexOfChars(stringChars(call.This()), sub))}}, nil
+			}),
+			publicMethod("contains", "(Ljava/lang/CharSequence;)Z", func(call *NativeCall) ([]Slot, error) {
+				sub, err := call.charSequence(0)
+				if err != nil {
+					return nil, err
+				}
+
+				return boolSlots(indexOfChars(stringChars(call.This()), sub, 0) >= 0), nil
+			}),
+			publicMethod("startsWith", "(Ljava/lang/String;)Z", func(call *NativeCall) ([]Slot, error) {
+				prefix, err := call.chars(0)
+				if err != nil {
+					return nil, err
+				}
+
+				return boolSlots(startsWithChars(stringChars(call.This()), prefix, 0)), nil
+			}),
+			publicMethod("startsWith", "(Ljava/lang/String;I)Z", func(call *NativeCall) ([]Slot, error) {
+				prefix, err := call.chars(0)
+				if err != nil {
+					return nil, err
+				}
+
+				return boolSlots(startsWithChars(stringChars(call.This()), prefix, int(call.Int(1)))), nil
+			}),
+			publicMethod("endsWith", "(Ljava/lang/String;)Z", func(call *NativeCall) ([]Slot, error) {
+				suffix, err := call.chars(0)
+				if err != nil {
+					return nil, err
+				}
+
+				chars := stringChars(call.This())
+
+				return boolSlots(startsWithChars(chars, suffix, len(chars)-len(suffix))), nil
+			}),
+			publicMethod("toUpperCase", "()Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+				return mapString(call, strings.ToUpper)
+			}),
+			publicMethod("toLowerCase", "()Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+				return mapString(call, strings.ToLower)
+			}),
+			publicMethod("trim", "()Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+				// trim removes the ASCII control characters and spaces
+				chars := stringChars(call.This())
+
+				begin, end := 0, len(chars)
+				for begin < end && chars[begin] <= ' ' {
+					begin++
+				}
+
+				for end > begin && chars[end-1] <= ' ' {
+					end--
+				}
+
+				if begin == 0 && end == len(chars) {
+					return call.Args[:1], nil
+				}
+
+				return call.returnString(chars[begin:end])
+			}),
+			publicMethod("isBlank", "()Z", func(call *NativeCall) ([]Slot, error) {
+				for _, char := range stringChars(call.This()) {
+					if !isJavaWhitespace(rune(char)) {
+						return boolSlots(false), nil
+					}
+				}
+
+				return boolSlots(true), nil
+			}),
+			publicMethod("replace", "(CC)Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+				chars, old, replacement := stringChars(call.This()), uint16(call.Int(0)), uint16(call.Int(1))
+				if indexOfChars(chars, []uint16{old}, 0) < 0 {
+					return call.Args[:1], nil
+				}
+
+				for i, char := range chars {
+					if char == old {
+						chars[i] = replacement
+					}
+				}
+
+				return call.returnString(chars)
+			}),
+			publicMethod("replace", "(Ljava/lang/CharSequence;Ljava/lang/CharSequence;)Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+				target, err := call.charSequence(0)
+				if err != nil {
+					return nil, err
+				}
+
+				replacement, err := call.charSequence(1)
+				if err != nil {
+					return nil, err
+				}
+
+				return call.returnString(replaceChars(stringChars(call.This()), target, replacement))
+			}),
+			publicMethod("repeat", "(I)Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+				count := int(call.Int(0))
+				if count < 0 {
+					return nil, NewJavaError(IllegalArgumentException, "count is negative: %d", count)
+				}
+
+				chars := stringChars(call.This())
+
+				repeated := make([]uint16, 0, len(chars)*count)
+				for i := 0; i < count; i++ {
+					repeated = append(repeated, chars...)
+				}
+
+				return call.returnString(repeated)
+			}),
+			publicMethod("intern", "()Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+				return []Slot{{Ref: intern(call.This())}}, nil
+			}),
+			publicMethod("toCharArray", "()[C", func(call *NativeCall) ([]Slot, error) {
+				array, err := newCharArray(call.Method.Class.Loader, stringChars(call.This()))
+				if err != nil {
+					return nil, err
+				}
+
+				return []Slot{{Ref: array}}, nil
+			}),
+		},
+	}
+
+	for _, descriptor := range stringValueDescriptors {
+		str.methods = append(str.methods, staticMethod("valueOf", "("+descriptor+")Ljava/lang/String;", stringValueOfNative(descriptor)))
+	}
+
+	builder := &libraryClass{
+		name:         stringBuilderClass,
+		super:        "java/lang/Object",
+		access:       ACC_PUBLIC | ACC_FINAL,
+		interfaces:   []string{"java/io/Serializable", "java/lang/Comparable", "java/lang/CharSequence"},
+		constructors: []string{"()V", "(I)V", "(Ljava/lang/String;)V", "(Ljava/lang/CharSequence;)V"},
+		methods: []libraryMethod{
+			initMethod("(I)V", func(call *NativeCall) ([]Slot, error) {
+				capacity := call.Int(0)
+				if capacity < 0 {
+					return nil, NewJavaError(NegativeArraySizeException, "%d", capacity)
+				}
+
+				libraryState[stringBuilder](call.This()).chars = make([]uint16, 0, capacity)
+
+				return nil, nil
+			}),
+			initMethod("(Ljava/lang/String;)V", func(call *NativeCall) ([]Slot, error) {
+				chars, err := call.chars(0)
+				if err != nil {
+					return nil, err
+				}
+
+				libraryState[stringBuilder](call.This()).chars = chars
+
+				return nil, nil
+			}),
+			initMethod("(Ljava/lang/CharSequence;)V", func(call *NativeCall) ([]Slot, error) {
+				chars, err := call.charSequence(0)
+				if err != nil {
+					return nil, err
+				}
+
+				libraryState[stringBuilder](call.This()).chars = chars
+
+				return nil, nil
+			}),
+
+			publicMethod("toString", "()Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+				return call.returnString(libraryState[stringBuilder](call.This()).chars)
+			}),
+			publicMethod("length", "()I", func(call *NativeCall) ([]Slot, error) {
+				return []Slot{{Num: int32(len(libraryState[stringBuilder](call.This()).chars))}}, nil
+			}),
+			publicMethod("isEmpty", "()Z", func(call *NativeCall) ([]Slot, error) {
+				return boolSlots(len(libraryState[stringBuilder](call.This()).chars) == 0), nil
+			}),
+			publicMethod("charAt", "(I)C", func(call *NativeCall) ([]Slot, error) {
+				chars, index := libraryState[stringBuilder](call.This()).chars, int(call.Int(0))
+				if err := checkIndex(StringIndexOutOfBoundsException, index, len(chars)); err != nil {
+					return nil, err
+				}
+
+				return []Slot{{Num: int32(chars[index])}}, nil
+			}),
+			publicMethod("setCharAt", "(IC)V", func(call *NativeCall) ([]Slot, error) {
+				chars, index := libraryState[stringBuilder](call.This()).chars, int(call.Int(0))
+				if err := checkIndex(StringIndexOutOfBoundsException, index, len(chars)); err != nil {
+					return nil, err
+				}
+
+				chars[index] = uint16(call.Int(1))
+
+				return nil, nil
+			}),
+			publicMethod("deleteCharAt", "(I)Ljava/lang/StringBuilder;", func(call *NativeCall) ([]Slot, error) {
+				builder, index := libraryState[stringBuilder](call.This()), int(call.Int(0))
+				if err := checkIndex(StringIndexOutOfBoundsException, index, len(builder.chars)); err != nil {
+					return nil, err
+				}
+
+				builder.chars = append(builder.chars[:index], builder.chars[index+1:]...)
+
+				return call.Args[:1], nil
+			}),
+			publicMethod("delete", "(II)Ljava/lang/StringBuilder;", func(call *NativeCall) ([]Slot, error) {
+				builder, start, end := libraryState[stringBuilder](call.This()), int(call.Int(0)), int(call.Int(1))
+				end = min(end, len(builder.chars))
+
+				if start < 0 || start > end {
+					return nil, NewJavaError(StringIndexOutOfBoundsException, "start %d, end %d, length %d", start, end, len(builder.chars))
+				}
+
+				builder.chars = append(builder.chars[:start], builder.chars[end:]...)
+
+				return call.Args[:1], nil
+			}),
+			publicMethod("replace", "(IILjava/lang/String;)Ljava/lang/StringBuilder;", func(call *NativeCall) ([]Slot, error) {
+				builder, start, end := libraryState[stringBuilder](call.This()), int(call.Int(0)), int(call.Int(1))
+
+				replacement, err := call.chars(2)
+				if err != nil {
+					return nil, err
+				}
+
+				if start < 0 || start > len(builder.chars) || start > end {
+					return nil, NewJavaError(StringIndexOutOfBoundsException, "start %d, end %d, length %d", start, end, len(builder.chars))
+				}
+
+				end = min(end, len(builder.chars))
+				builder.chars = append(append(append([]uint16(nil), builder.chars[:start]...), replacement...), builder.chars[end:]...)
+
+				return call.Args[:1], nil
+			}),
+			publicMethod("reverse", "()Ljava/lang/StringBuilder;", func(call *NativeCall) ([]Slot, error) {
+				builder := libraryState[stringBuilder](call.This())
+
+				// reversing the runes keeps the surrogate pairs in order, like the JDK does
+				runes := utf16.Decode(builder.chars)
+				if len(runes) == len(builder.chars) {
+					for i, j := 0, len(builder.chars)-1; i < j; i, j = i+1, j-1 {
+						builder.chars[i], builder.chars[j] = builder.chars[j], builder.chars[i]
+					}
+
+					return call.Args[:1], nil
+				}
+
+				reversed := make([]uint16, 0, len(builder.chars))
+				for i := len(builder.chars); i > 0; {
+					n := 1
+					if i > 1 && utf16.IsSurrogate(rune(builder.chars[i-1])) && utf16.DecodeRune(rune(builder.chars[i-2]), rune(builder.chars[i-1])) != 0xfffd {
+						n = 2
+					}
+
+					reversed = append(reversed, builder.chars[i-n:i]...)
+					i -= n
+				}
+
+				builder.chars = reversed
+
+				return call.Args[:1], nil
+			}),
+			publicMethod("setLength", "(I)V", func(call *NativeCall) ([]Slot, error) {
+				builder, length := libraryState[stringBuilder](call.This()), int(call.Int(0))
+				if length < 0 {
+					return nil, NewJavaError(StringIndexOutOfBoundsException, "String index out of range: %d", length)
+				}
+
+				if length <= len(builder.chars) {
+					builder.chars = builder.chars[:length]
+				} else {
+					builder.chars = append(builder.chars, make([]uint16, length-len(builder.chars))...)
+				}
+
+				return nil, nil
+			}),
+			publicMethod("indexOf", "(Ljava/lang/String;)I", func(call *NativeCall) ([]Slot, error) {
+				sub, err := call.chars(0)
+				if err != nil {
+					return nil, err
+				}
+
+				return []Slot{{Num: int32(indexOfChars(libraryState[stringBuilder](call.This()).chars, sub, 0))}}, nil
+			}),
+			publicMethod("compareTo", "(Ljava/lang/StringBuilder;)I", func(call *NativeCall) ([]Slot, error) {
+				other := call.Ref(0)
+				if other == nil {
+					return nil, &JavaError{ClassName: NullPointerException}
+				}
+
+				return []Slot{{Num: compareChars(libraryState[stringBuilder](call.This()).chars, libraryState[stringBuilder](other).chars)}}, nil
+			}),
+		},
+	}
+
+	for _, descriptor := range append(stringValueDescriptors, "Ljava/lang/CharSequence;") {
+		builder.methods = append(builder.methods,
+			publicMethod("append", "("+descriptor+")Ljava/lang/StringBuilder;", stringBuilderInsert(descriptor, false)),
+			publicMethod("insert", "(I"+descriptor+")Ljava/lang/StringBuilder;", stringBuilderInsert(descriptor, true)),
+		)
+	}
+
+	addLibraryClasses(str, builder)
+}
+
+// stringBuilder is the state of a java/lang/StringBuilder, its chars.
+type stringBuilder struct {
+	chars []uint16
+}
+
+// stringBuilderInsert returns the native method of the `append` or `insert` overload of StringBuilder for the
+// type of the given descriptor, which adds the chars of `String.valueOf` of the value.
+func stringBuilderInsert(descriptor string, insert bool) NativeMethod {
+	return func(call *NativeCall) ([]Slot, error) {
+		builder, value := libraryState[stringBuilder](call.This()), 0
+
+		offset := len(builder.chars)
+		if insert {
+			offset, value = int(call.Int(0)), 1
+			if offset < 0 || offset > len(builder.chars) {
+				return nil, NewJavaError(StringIndexOutOfBoundsException, "offset %d, length %d", offset, len(builder.chars))
+			}
+		}
+
+		chars, err := valueOfChars(call, value, descriptor)
+		if err != nil {
+			return nil, err
+		}
+
+		builder.chars = append(append(append([]uint16(nil), builder.chars[:offset]...), chars...), builder.chars[offset:]...)
+
+		return call.Args[:1], nil
+	}
+}
+
+// stringValueOfNative returns the native method of the `String.valueOf` overload for the type of the given
+// descriptor.
+func stringValueOfNative(descriptor string) NativeMethod {
+	return func(call *NativeCall) ([]Slot, error) {
+		chars, err := valueOfChars(call, 0, descriptor)
+		if err != nil {
+			return nil, err
+		}
+
+		return call.returnString(chars)
+	}
+}
+
+// valueOfChars returns the chars of `String.valueOf` of the parameter at the given index, whose type has the
+// given descriptor. The chars of a char array are its elements.
+func valueOfChars(call *NativeCall, i int, descriptor string) ([]uint16, error) {
+	if descriptor == "[C" {
+		return charArray(call.Ref(i))
+	}
+
+	return call.Thread.stringValueOf(call.param(i), descriptor)
+}
+
+// stringHashCode returns the hash code of a string, `s[0]*31^(n-1) + s[1]*31^(n-2) + ... + s[n-1]`, which
+// is cached in its fields like the JDK does.
+func stringHashCode(call *NativeCall) ([]Slot, error) {
+	str := call.This()
+	hash, hashIsZero := str.Class.DeclaredField("hash", "I"), str.Class.DeclaredField("hashIsZero", "Z")
+
+	if h := str.Fields[hash.Slot].Num; h != 0 || str.Fields[hashIsZero.Slot].Num != 0 {
+		return []Slot{{Num: h}}, nil
+	}
+
+	h := int32(0)
+	for _, char := range stringChars(str) {
+		h = 31*h + int32(char)
+	}
+
+	if h == 0 {
+		str.Fields[hashIsZero.Slot].Num = 1
+	} else {
+		str.Fields[hash.Slot].Num = h
+	}
+
+	return []Slot{{Num: h}}, nil
+}
+
+// stringCompareTo compares two strings lexicographically, see `compareChars`.
+func stringCompareTo(call *NativeCall) ([]Slot, error) {
+	other, err := call.chars(0)
+	if err != nil {
+		return nil, err
+	}
+
+	return []Slot{{Num: compareChars(stringChars(call.This()), other)}}, nil
+}
+
+// compareChars returns the difference of the first chars that differ, or the difference of the lengths when
+// one is a prefix of the other.
+func compareChars(a []uint16, b []uint16) int32 {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return int32(a[i]) - int32(b[i])
+		}
+	}
+
+	return int32(len(a) - len(b))
+}
+
+func equalChars(a []uint16, b []uint16) bool {
+	return compareChars(a, b) == 0
+}
+
+// equalCharsIgnoreCase tells if two chars are the same ignoring case, like `String.equalsIgnoreCase`.
+func equalCharsIgnoreCase(a uint16, b uint16) bool {
+	if a == b {
+		return true
+	}
+
+	upperA, upperB := toUpperCase(rune(a)), toUpperCase(rune(b))
+
+	return upperA == upperB || toLowerCase(upperA) == toLowerCase(upperB)
+}
+
+// substring returns the chars of the string from begin up to end, excluded.
+func substring(call *NativeCall, chars []uint16, begin int, end int) ([]Slot, error) {
+	if begin < 0 || begin > end || end > len(chars) {
+		return nil, NewJavaError(StringIndexOutOfBoundsException, "begin %d, end %d, length %d", begin, end, len(chars))
+	}
+
+	if begin == 0 && end == len(chars) && call.Method.Class.Name == stringClass {
+		return call.Args[:1], nil
+	}
+
+	return call.returnString(chars[begin:end])
+}
+
+// codePointChars returns the chars of a code point, a surrogate pair for supplementary characters.
+func codePointChars(codePoint int32) []uint16 {
+	if r1, r2 := utf16.EncodeRune(rune(codePoint)); r1 != 0xfffd {
+		return []uint16{uint16(r1), uint16(r2)}
+	}
+
+	return []uint16{uint16(codePoint)}
+}
+
+// indexOfChars returns the index of the first occurrence of sub in chars from the given index, or -1.
+func indexOfChars(chars []uint16, sub []uint16, from int) int {
+	for i := max(from, 0); i+len(sub) <= len(chars); i++ {
+		if startsWithChars(chars, sub, i) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// lastIndexOfChars returns the index of the last occurrence of sub in chars, or -1.
+func lastIndexOfChars(chars []uint16, sub []uint16) int {
+	for i := len(chars) - len(sub); i >= 0; i-- {
+		if startsWithChars(chars, sub, i) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// startsWithChars tells if prefix is in chars at the given offset.
+func startsWithChars(chars []uint16, prefix []uint16, offset int) bool {
+	if offset < 0 || offset > len(chars)-len(prefix) {
+		return false
+	}
+
+	for i, char := range prefix {
+		if chars[offset+i] != char {
+			return false
+		}
+	}
+
+	return true
+}
+
+// replaceChars replaces each occurrence of target in chars, from the start, with replacement. An empty target
+// matches before every char and at the end.
+func replaceChars(chars []uint16, target []uint16, replacement []uint16) []uint16 {
+	replaced := make([]uint16, 0, len(chars))
+
+	if len(target) == 0 {
+		for _, char := range chars {
+			replaced = append(append(replaced, replacement...), char)
+		}
+
+		return append(replaced, replacement...)
+	}
+
+	for i := 0; i < len(chars); {
+		if startsWithChars(chars, target, i) {
+			replaced = append(replaced, replacement...)
+			i += len(target)
+
+			continue
+		}
+
+		replaced = append(replaced, chars[i])
+		i++
+	}
+
+	return replaced
+}
+
+// mapString returns the string the method is invoked on mapped by a function of the strings package.
+func mapString(call *NativeCall, mapping func(string) string) ([]Slot, error) {
+	s := GoString(call.This())
+
+	mapped := mapping(s)
+	if mapped == s {
+		return call.Args[:1], nil
+	}
+
+	return call.returnString(utf16Chars(mapped))
+}
+
+// charArray returns the elements of a char array, throwing a NullPointerException when it is null.
+func charArray(array *Object) ([]uint16, error) {
+	if array == nil {
+		return nil, &JavaError{ClassName: NullPointerException}
+	}
+
+	return append([]uint16(nil), array.Elements.([]uint16)...), nil
+}
+
+// newCharArray allocates a char array with the given elements.
+func newCharArray(loader *ClassLoader, chars []uint16) (*Object, error) {
+	class, err := loader.bootstrap().LoadClass("[C")
+	if err != nil {
+		return nil, err
+	}
+
+	array := NewArray(class, len(chars))
+	copy(array.Elements.([]uint16), chars)
+
+	return array, nil
+}
@@ -0,0 +1,386 @@
+package core_test
+
+import (
+	"bytes"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// invokeLibrary initializes a class of the built-in class library and invokes one of its methods, the
+// instance methods take the object first.
+func invokeLibrary(t *testing.T, loaders *core.ClassLoaders, className string, name string, descriptor string, args ...[]core.Slot) ([]core.Slot, error) {
+	class, err := loaders.Bootstrap.LoadClass(className)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	thread := core.NewThread(core.MainThreadName)
+	if err := class.Initialize(thread); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	method := class.DeclaredMethod(name, descriptor)
+	if method == nil {
+		t.Fatalf("Expected %s to declare %s%s", className, name, descriptor)
+	}
+
+	slots := []core.Slot{}
+	for _, arg := range args {
+		slots = append(slots, arg...)
+	}
+
+	return thread.Invoke(method, slots)
+}
+
+// newLibraryObject allocates an object of a class of the built-in class library with its constructor
+// without parameters.
+func newLibraryObject(t *testing.T, loaders *core.ClassLoaders, className string) *core.Object {
+	class, err := loaders.Bootstrap.LoadClass(className)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	obj := core.NewObject(class)
+	if _, err := invokeLibrary(t, loaders, className, "<init>", "()V", []core.Slot{{Ref: obj}}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	return obj
+}
+
+// javaString returns a new java/lang/String with the contents of s, built by `String.valueOf(char[])`.
+func javaString(t *testing.T, loaders *core.ClassLoaders, s string) core.Slot {
+	charArray, err := loaders.Bootstrap.LoadClass("[C")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	chars := utf16.Encode([]rune(s))
+	array := core.NewArray(charArray, len(chars))
+	copy(array.Elements.([]uint16), chars)
+
+	ret, err := invokeLibrary(t, loaders, "java/lang/String", "valueOf", "([C)Ljava/lang/String;", []core.Slot{{Ref: array}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	return ret[0]
+}
+
+// boxInt returns the java/lang/Integer of a value.
+func boxInt(t *testing.T, loaders *core.ClassLoaders, v int32) core.Slot {
+	ret, err := invokeLibrary(t, loaders, "java/lang/Integer", "valueOf", "(I)Ljava/lang/Integer;", []core.Slot{{Num: v}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	return ret[0]
+}
+
+func TestShouldPrintHelloWorldWithTheBuiltInClassLibrary(t *testing.T) {
+	var out bytes.Buffer
+
+	previous := core.SystemOut
+	core.SystemOut = &out
+	t.Cleanup(func() { core.SystemOut = previous })
+
+	b := newTestClassBuilder()
+	systemOut := b.fieldref("java/lang/System", "out", "Ljava/io/PrintStream;")
+	printString := b.methodref("java/io/PrintStream", "println", "(Ljava/lang/String;)V")
+	printInt := b.methodref("java/io/PrintStream", "println", "(I)V")
+	hello := b.str("Hello, World!")
+
+	class := defineTestClass(t, b.build(core.ACC_PUBLIC, "HelloWorld", "java/lang/Object", nil, nil,
+		[][]byte{
+			b.member(core.ACC_PUBLIC|core.ACC_STATIC, "main", "([Ljava/lang/String;)V", b.code(2, 1, concat(
+				[]byte{core.OP_GETSTATIC}, u2(systemOut), []byte{core.OP_LDC, byte(hello)}, []byte{core.OP_INVOKEVIRTUAL}, u2(printString),
+				[]byte{core.OP_GETSTATIC}, u2(systemOut), []byte{core.OP_BIPUSH, 42}, []byte{core.OP_INVOKEVIRTUAL}, u2(printInt),
+				[]byte{core.OP_RETURN},
+			)...)),
+		},
+		nil,
+	))
+
+	if _, err := invokeSlots(t, class, "main", "([Ljava/lang/String;)V", []core.Slot{{}}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if out.String() != "Hello, World!\n42\n" {
+		t.Errorf("Expected \"Hello, World!\\n42\\n\", got %q", out.String())
+	}
+}
+
+func TestShouldRunTheMethodsOfBuiltInStrings(t *testing.T) {
+	loaders := newTestLoaders(t, t.TempDir())
+	hello := javaString(t, loaders, "Hello, World")
+
+	for _, test := range []struct {
+		name       string
+		descriptor string
+		args       []core.Slot
+		expected   string
+	}{
+		{"substring", "(II)Ljava/lang/String;", []core.Slot{{Num: 7}, {Num: 12}}, "World"},
+		{"toUpperCase", "()Ljava/lang/String;", nil, "HELLO, WORLD"},
+		{"replace", "(CC)Ljava/lang/String;", []core.Slot{{Num: 'l'}, {Num: 'L'}}, "HeLLo, WorLd"},
+		{"concat", "(Ljava/lang/String;)Ljava/lang/String;", []core.Slot{javaString(t, loaders, "!")}, "Hello, World!"},
+		{"repeat", "(I)Ljava/lang/String;", []core.Slot{{Num: 2}}, "Hello, WorldHello, World"},
+	} {
+		ret, err := invokeLibrary(t, loaders, "java/lang/String", test.name, test.descriptor, []core.Slot{hello}, test.args)
+		if err != nil || core.GoString(ret[0].Ref) != test.expected {
+			t.Errorf("Expected %s to return %q, got %v (%v)", test.name, test.expected, ret, err)
+		}
+	}
+
+	for _, test := range []struct {
+		name       string
+		descriptor string
+		args       []core.Slot
+		expected   int32
+	}{
+		{"length", "()I", nil, 12},
+		{"indexOf", "(Ljava/lang/String;)I", []core.Slot{javaString(t, loaders, "World")}, 7},
+		{"lastIndexOf", "(I)I", []core.Slot{{Num: 'o'}}, 8},
+		{"charAt", "(I)C", []core.Slot{{Num: 4}}, 'o'},
+		{"hashCode", "()I", nil, -505841268},
+		{"equals", "(Ljava/lang/Object;)Z", []core.Slot{javaString(t, loaders, "Hello, World")}, 1},
+		{"compareTo", "(Ljava/lang/String;)I", []core.Slot{javaString(t, loaders, "Help")}, 'l' - 'p'},
+	} {
+		ret, err := invokeLibrary(t, loaders, "java/lang/String", test.name, test.descriptor, []core.Slot{hello}, test.args)
+		if err != nil || ret[0].Num != test.expected {
+			t.Errorf("Expected %s to return %d, got %v (%v)", test.name, test.expected, ret, err)
+		}
+	}
+
+	_, err := invokeLibrary(t, loaders, "java/lang/String", "charAt", "(I)C", []core.Slot{hello}, []core.Slot{{Num: 12}})
+	if !core.IsJavaError(err, core.StringIndexOutOfBoundsException) || err.(*core.JavaError).Message != "Index 12 out of bounds for length 12" {
+		t.Errorf("Expected StringIndexOutOfBoundsException, got %v", err)
+	}
+}
+
+func TestShouldBuildStringsWithAStringBuilder(t *testing.T) {
+	loaders := newTestLoaders(t, t.TempDir())
+	sb := []core.Slot{{Ref: newLibraryObject(t, loaders, "java/lang/StringBuilder")}}
+
+	for _, test := range []struct {
+		descriptor string
+		value      []core.Slot
+	}{
+		{"(Ljava/lang/String;)Ljava/lang/StringBuilder;", []core.Slot{javaString(t, loaders, "abc")}},
+		{"(I)Ljava/lang/StringBuilder;", []core.Slot{{Num: -12}}},
+		{"(C)Ljava/lang/StringBuilder;", []core.Slot{{Num: '€'}}},
+		{"(Z)Ljava/lang/StringBuilder;", []core.Slot{{Num: 1}}},
+		{"(D)Ljava/lang/StringBuilder;", core.DoubleSlots(0.5)},
+		{"(Ljava/lang/Object;)Ljava/lang/StringBuilder;", []core.Slot{{}}},
+	} {
+		if _, err := invokeLibrary(t, loaders, "java/lang/StringBuilder", "append", test.descriptor, sb, test.value); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	ret, err := invokeLibrary(t, loaders, "java/lang/StringBuilder", "toString", "()Ljava/lang/String;", sb)
+	if err != nil || core.GoString(ret[0].Ref) != "abc-12€true0.5null" {
+		t.Errorf("Expected \"abc-12€true0.5null\", got %v (%v)", ret, err)
+	}
+
+	if _, err := invokeLibrary(t, loaders, "java/lang/StringBuilder", "reverse", "()Ljava/lang/StringBuilder;", sb); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ret, err = invokeLibrary(t, loaders, "java/lang/StringBuilder", "toString", "()Ljava/lang/String;", sb)
+	if err != nil || core.GoString(ret[0].Ref) != "llun5.0eurt€21-cba" {
+		t.Errorf("Expected \"llun5.0eurt€21-cba\", got %v (%v)", ret, err)
+	}
+}
+
+func TestShouldParseAndBoxNumbers(t *testing.T) {
+	loaders := newTestLoaders(t, t.TempDir())
+
+	ret, err := invokeLibrary(t, loaders, "java/lang/Integer", "parseInt", "(Ljava/lang/String;I)I", []core.Slot{javaString(t, loaders, "-ff")}, []core.Slot{{Num: 16}})
+	if err != nil || ret[0].Num != -255 {
+		t.Errorf("Expected -255, got %v (%v)", ret, err)
+	}
+
+	for input, message := range map[string]string{
+		"12a":         `For input string: "12a"`,
+		"2147483648":  `For input string: "2147483648"`,
+		"":            `For input string: ""`,
+		"-":           `For input string: "-"`,
+		"99999999999": `For input string: "99999999999"`,
+	} {
+		_, err := invokeLibrary(t, loaders, "java/lang/Integer", "parseInt", "(Ljava/lang/String;)I", []core.Slot{javaString(t, loaders, input)})
+		if !core.IsJavaError(err, core.NumberFormatException) || err.(*core.JavaError).Message != message {
+			t.Errorf("Expected NumberFormatException: %s, got %v", message, err)
+		}
+	}
+
+	ret, err = invokeLibrary(t, loaders, "java/lang/Double", "parseDouble", "(Ljava/lang/String;)D", []core.Slot{javaString(t, loaders, " 1.5e3 ")})
+	if err != nil || core.SlotsDouble(ret) != 1500 {
+		t.Errorf("Expected 1500, got %v (%v)", ret, err)
+	}
+
+	// small values are cached, like the JDK does
+	if a, b := boxInt(t, loaders, 127), boxInt(t, loaders, 127); a.Ref != b.Ref {
+		t.Errorf("Expected Integer.valueOf(127) to be cached")
+	}
+
+	if a, b := boxInt(t, loaders, 128), boxInt(t, loaders, 128); a.Ref == b.Ref {
+		t.Errorf("Expected Integer.valueOf(128) not to be cached")
+	}
+
+	ret, err = invokeLibrary(t, loaders, "java/lang/Integer", "equals", "(Ljava/lang/Object;)Z", []core.Slot{boxInt(t, loaders, 1000)}, []core.Slot{boxInt(t, loaders, 1000)})
+	if err != nil || ret[0].Num != 1 {
+		t.Errorf("Expected equal Integers, got %v (%v)", ret, err)
+	}
+}
+
+func TestShouldIterateAHashMapInTheOrderOfTheJDK(t *testing.T) {
+	loaders := newTestLoaders(t, t.TempDir())
+	m := []core.Slot{{Ref: newLibraryObject(t, loaders, "java/util/HashMap")}}
+
+	put := func(key int32, value string) {
+		if _, err := invokeLibrary(t, loaders, "java/util/HashMap", "put", "(Ljava/lang/Object;Ljava/lang/Object;)Ljava/lang/Object;",
+			m, []core.Slot{boxInt(t, loaders, key)}, []core.Slot{javaString(t, loaders, value)},
+		); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	toString := func() string {
+		ret, err := invokeLibrary(t, loaders, "java/util/HashMap", "toString", "()Ljava/lang/String;", m)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		return core.GoString(ret[0].Ref)
+	}
+
+	// 17, 1 and 33 share a bucket of the initial table, in the order they were put
+	put(17, "a")
+	put(1, "b")
+	put(33, "c")
+	put(2, "d")
+	put(1, "e")
+
+	if s := toString(); s != "{17=a, 1=e, 33=c, 2=d}" {
+		t.Errorf("Expected {17=a, 1=e, 33=c, 2=d}, got %s", s)
+	}
+
+	// the 13th mapping doubles the table, 17 moves to its own bucket
+	for key := int32(3); key < 12; key++ {
+		put(key, "x")
+	}
+
+	if s := toString(); s != "{1=e, 33=c, 2=d, 3=x, 4=x, 5=x, 6=x, 7=x, 8=x, 9=x, 10=x, 11=x, 17=a}" {
+		t.Errorf("Expected the order of the resized table, got %s", s)
+	}
+
+	ret, err := invokeLibrary(t, loaders, "java/util/HashMap", "get", "(Ljava/lang/Object;)Ljava/lang/Object;", m, []core.Slot{boxInt(t, loaders, 33)})
+	if err != nil || core.GoString(ret[0].Ref) != "c" {
+		t.Errorf("Expected \"c\", got %v (%v)", ret, err)
+	}
+}
+
+func TestShouldReportTheIndexesOfAnArrayList(t *testing.T) {
+	loaders := newTestLoaders(t, t.TempDir())
+	list := []core.Slot{{Ref: newLibraryObject(t, loaders, "java/util/ArrayList")}}
+
+	for _, s := range []string{"a", "b", "c"} {
+		if _, err := invokeLibrary(t, loaders, "java/util/ArrayList", "add", "(Ljava/lang/Object;)Z", list, []core.Slot{javaString(t, loaders, s)}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	// the list contains itself
+	if _, err := invokeLibrary(t, loaders, "java/util/ArrayList", "add", "(ILjava/lang/Object;)V", list, []core.Slot{{Num: 1}}, list); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ret, err := invokeLibrary(t, loaders, "java/util/ArrayList", "toString", "()Ljava/lang/String;", list)
+	if err != nil || core.GoString(ret[0].Ref) != "[a, (this Collection), b, c]" {
+		t.Errorf("Expected \"[a, (this Collection), b, c]\", got %v (%v)", ret, err)
+	}
+
+	_, err = invokeLibrary(t, loaders, "java/util/ArrayList", "get", "(I)Ljava/lang/Object;", list, []core.Slot{{Num: 4}})
+	if !core.IsJavaError(err, core.IndexOutOfBoundsException) || err.(*core.JavaError).Message != "Index 4 out of bounds for length 4" {
+		t.Errorf("Expected IndexOutOfBoundsException, got %v", err)
+	}
+
+	_, err = invokeLibrary(t, loaders, "java/util/ArrayList", "add", "(ILjava/lang/Object;)V", list, []core.Slot{{Num: 5}}, []core.Slot{{}})
+	if !core.IsJavaError(err, core.IndexOutOfBoundsException) || err.(*core.JavaError).Message != "Index: 5, Size: 4" {
+		t.Errorf("Expected IndexOutOfBoundsException, got %v", err)
+	}
+}
+
+func TestShouldCheckTheBoundsOfArraycopy(t *testing.T) {
+	loaders := newTestLoaders(t, t.TempDir())
+
+	intArray, err := loaders.Bootstrap.LoadClass("[I")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	src, dest := core.NewArray(intArray, 3), core.NewArray(intArray, 3)
+	copy(src.Elements.([]int32), []int32{1, 2, 3})
+
+	arraycopy := func(srcPos int32, destPos int32, length int32) error {
+		_, err := invokeLibrary(t, loaders, "java/lang/System", "arraycopy", "(Ljava/lang/Object;ILjava/lang/Object;II)V",
+			[]core.Slot{{Ref: src}, {Num: srcPos}, {Ref: dest}, {Num: destPos}, {Num: length}},
+		)
+
+		return err
+	}
+
+	if err := arraycopy(0, 1, 2); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if elements := dest.Elements.([]int32); elements[0] != 0 || elements[1] != 1 || elements[2] != 2 {
+		t.Errorf("Expected [0 1 2], got %v", elements)
+	}
+
+	err = arraycopy(0, 2, 2)
+	if !core.IsJavaError(err, core.ArrayIndexOutOfBoundsException) || err.(*core.JavaError).Message != "arraycopy: last destination index 4 out of bounds for int[3]" {
+		t.Errorf("Expected ArrayIndexOutOfBoundsException, got %v", err)
+	}
+}
+
+func TestShouldCreateThrowablesWithAMessage(t *testing.T) {
+	loaders := newTestLoaders(t, t.TempDir())
+
+	class, err := loaders.Bootstrap.LoadClass("java/lang/IllegalStateException")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	cause := []core.Slot{{Ref: newLibraryObject(t, loaders, "java/lang/ArithmeticException")}}
+	thrown := []core.Slot{{Ref: core.NewObject(class)}}
+
+	if _, err := invokeLibrary(t, loaders, "java/lang/IllegalStateException", "<init>", "(Ljava/lang/String;Ljava/lang/Throwable;)V",
+		thrown, []core.Slot{javaString(t, loaders, "boom")}, cause,
+	); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ret, err := invokeLibrary(t, loaders, core.Throwable, "toString", "()Ljava/lang/String;", thrown)
+	if err != nil || core.GoString(ret[0].Ref) != "java.lang.IllegalStateException: boom" {
+		t.Errorf("Expected \"java.lang.IllegalStateException: boom\", got %v (%v)", ret, err)
+	}
+
+	ret, err = invokeLibrary(t, loaders, core.Throwable, "getCause", "()Ljava/lang/Throwable;", thrown)
+	if err != nil || ret[0].Ref != cause[0].Ref {
+		t.Errorf("Expected the cause, got %v (%v)", ret, err)
+	}
+
+	// the message of a throwable created with a cause is the one of the cause
+	if _, err := invokeLibrary(t, loaders, "java/lang/IllegalStateException", "<init>", "(Ljava/lang/Throwable;)V", thrown, cause); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ret, err = invokeLibrary(t, loaders, core.Throwable, "getMessage", "()Ljava/lang/String;", thrown)
+	if err != nil || core.GoString(ret[0].Ref) != "java.lang.ArithmeticException" {
+		t.Errorf("Expected \"java.lang.ArithmeticException\", got %v (%v)", ret, err)
+	}
+}
@@ -0,0 +1,1013 @@
+package core
+
+// Spec: https://docs.oracle.com/en/java/javase/21/docs/api/java.base/java/util/package-summary.html
+
+// Internal names of the collections of the built-in class library and of their iterators.
+const (
+	arrayListClass         = "java/util/ArrayList"
+	arrayListIteratorClass = "java/util/ArrayList$Itr"
+	hashMapClass           = "java/util/HashMap"
+	hashMapNodeClass       = "java/util/HashMap$Node"
+	hashMapViewClass       = "java/util/HashMap$View"
+	hashMapIteratorClass   = "java/util/HashMap$HashIterator"
+)
+
+func init() {
+	addLibraryClasses(
+		libraryInterface("java/util/Iterator", nil,
+			abstractMethod("hasNext", "()Z"),
+			abstractMethod("next", "()Ljava/lang/Object;"),
+			abstractMethod("remove", "()V"),
+		),
+		libraryInterface("java/util/Collection", []string{"java/lang/Iterable"},
+			abstractMethod("size", "()I"),
+			abstractMethod("isEmpty", "()Z"),
+			abstractMethod("contains", "(Ljava/lang/Object;)Z"),
+			abstractMethod("add", "(Ljava/lang/Object;)Z"),
+			abstractMethod("remove", "(Ljava/lang/Object;)Z"),
+			abstractMethod("addAll", "(Ljava/util/Collection;)Z"),
+			abstractMethod("clear", "()V"),
+			abstractMethod("toArray", "()[Ljava/lang/Object;"),
+		),
+		libraryInterface("java/util/List", []string{"java/util/Collection"},
+			abstractMethod("get", "(I)Ljava/lang/Object;"),
+			abstractMethod("set", "(ILjava/lang/Object;)Ljava/lang/Object;"),
+			abstractMethod("add", "(ILjava/lang/Object;)V"),
+			abstractMethod("remove", "(I)Ljava/lang/Object;"),
+			abstractMethod("indexOf", "(Ljava/lang/Object;)I"),
+		),
+		libraryInterface("java/util/Set", []string{"java/util/Collection"}),
+		libraryInterface("java/util/Map", nil,
+			abstractMethod("size", "()I"),
+			abstractMethod("isEmpty", "()Z"),
+			abstractMethod("get", "(Ljava/lang/Object;)Ljava/lang/Object;"),
+			abstractMethod("getOrDefault", "(Ljava/lang/Object;Ljava/lang/Object;)Ljava/lang/Object;"),
+			abstractMethod("put", "(Ljava/lang/Object;Ljava/lang/Object;)Ljava/lang/Object;"),
+			abstractMethod("putIfAbsent", "(Ljava/lang/Object;Ljava/lang/Object;)Ljava/lang/Object;"),
+			abstractMethod("remove", "(Ljava/lang/Object;)Ljava/lang/Object;"),
+			abstractMethod("containsKey", "(Ljava/lang/Object;)Z"),
+			abstractMethod("containsValue", "(Ljava/lang/Object;)Z"),
+			abstractMethod("keySet", "()Ljava/util/Set;"),
+			abstractMethod("values", "()Ljava/util/Collection;"),
+			abstractMethod("entrySet", "()Ljava/util/Set;"),
+			abstractMethod("clear", "()V"),
+		),
+		libraryInterface("java/util/Map$Entry", nil,
+			abstractMethod("getKey", "()Ljava/lang/Object;"),
+			abstractMethod("getValue", "()Ljava/lang/Object;"),
+			abstractMethod("setValue", "(Ljava/lang/Object;)Ljava/lang/Object;"),
+		),
+		arrayListClasses(),
+		arrayListIteratorClasses(),
+		hashMapClasses(),
+		hashMapNodeClasses(),
+		hashMapViewClasses(),
+		hashMapIteratorClasses(),
+	)
+}
+
+// arrayList is the state of a java/util/ArrayList.
+type arrayList struct {
+	elements []*Object
+	// modCount is the number of times the size of the list changed, so its iterators fail when the list
+	// changes while iterating, like the JDK.
+	modCount int
+}
+
+// arrayListIterator is the state of the iterator of an ArrayList.
+type arrayListIterator struct {
+	list *arrayList
+	// cursor is the index of the next element and lastReturned the one of the last element returned, -1
+	// when there is none.
+	cursor, lastReturned int
+	expectedModCount     int
+}
+
+func arrayListClasses() *libraryClass {
+	return &libraryClass{
+		name:         arrayListClass,
+		super:        objectClass,
+		access:       ACC_PUBLIC,
+		interfaces:   []string{"java/util/List", "java/lang/Cloneable", "java/io/Serializable"},
+		constructors: []string{"()V", "(I)V", "(Ljava/util/Collection;)V"},
+		methods: []libraryMethod{
+			initMethod("(I)V", func(call *NativeCall) ([]Slot, error) {
+				capacity := call.Int(0)
+				if capacity < 0 {
+					return nil, NewJavaError(IllegalArgumentException, "Illegal Capacity: %d", capacity)
+				}
+
+				libraryState[arrayList](call.This()).elements = make([]*Object, 0, capacity)
+
+				return nil, nil
+			}),
+			initMethod("(Ljava/util/Collection;)V", func(call *NativeCall) ([]Slot, error) {
+				list := libraryState[arrayList](call.This())
+
+				return nil, call.Thread.forEach(call.Ref(0), func(element *Object) error {
+					list.elements = append(list.elements, element)
+					return nil
+				})
+			}),
+
+			publicMethod("size", "()I", func(call *NativeCall) ([]Slot, error) {
+				return []Slot{{Num: int32(len(libraryState[arrayList](call.This()).elements))}}, nil
+			}),
+			publicMethod("isEmpty", "()Z", func(call *NativeCall) ([]Slot, error) {
+				return boolSlots(len(libraryState[arrayList](call.This()).elements) == 0), nil
+			}),
+			publicMethod("get", "(I)Ljava/lang/Object;", func(call *NativeCall) ([]Slot, error) {
+				list, index := libraryState[arrayList](call.This()), int(call.Int(0))
+				if err := checkIndex(IndexOutOfBoundsException, index, len(list.elements)); err != nil {
+					return nil, err
+				}
+
+				return []Slot{{Ref: list.elements[index]}}, nil
+			}),
+			publicMethod("set", "(ILjava/lang/Object;)Ljava/lang/Object;", func(call *NativeCall) ([]Slot, error) {
+				list, index := libraryState[arrayList](call.This()), int(call.Int(0))
+				if err := checkIndex(IndexOutOfBoundsException, index, len(list.elements)); err != nil {
+					return nil, err
+				}
+
+				old := list.elements[index]
+				list.elements[index] = call.Ref(1)
+
+				return []Slot{{Ref: old}}, nil
+			}),
+			publicMethod("add", "(Ljava/lang/Object;)Z", func(call *NativeCall) ([]Slot, error) {
+				list := libraryState[arrayList](call.This())
+				list.elements = append(list.elements, call.Ref(0))
+				list.modCount++
+
+				return boolSlots(true), nil
+			}),
+			publicMethod("add", "(ILjava/lang/Object;)V", func(call *NativeCall) ([]Slot, error) {
+				list, index := libraryState[arrayList](call.This()), int(call.Int(0))
+				if index < 0 || index > len(list.elements) {
+					return nil, NewJavaError(IndexOutOfBoundsException, "Index: %d, Size: %d", index, len(list.elements))
+				}
+
+				list.elements = append(list.elements[:index], append([]*Object{call.Ref(1)}, list.elements[index:]...)...)
+				list.modCount++
+
+				return nil, nil
+			}),
+			publicMethod("remove", "(I)Ljava/lang/Object;", func(call *NativeCall) ([]Slot, error) {
+				list, index := libraryState[arrayList](call.This()), int(call.Int(0))
+				if err := checkIndex(IndexOutOfBoundsException, index, len(list.elements)); err != nil {
+					return nil, err
+				}
+
+				return []Slot{{Ref: list.remove(index)}}, nil
+			}),
+			publicMethod("remove", "(Ljava/lang/Object;)Z", func(call *NativeCall) ([]Slot, error) {
+				list := libraryState[arrayList](call.This())
+
+				index, err := list.indexOf(call.Thread, call.Ref(0))
+				if err != nil || index < 0 {
+					return boolSlots(false), err
+				}
+
+				list.remove(index)
+
+				return boolSlots(true), nil
+			}),
+			publicMethod("indexOf", "(Ljava/lang/Object;)I", func(call *NativeCall) ([]Slot, error) {
+				index, err := libraryState[arrayList](call.This()).indexOf(call.Thread, call.Ref(0))
+				return []Slot{{Num: int32(index)}}, err
+			}),
+			publicMethod("contains", "(Ljava/lang/Object;)Z", func(call *NativeCall) ([]Slot, error) {
+				index, err := libraryState[arrayList](call.This()).indexOf(call.Thread, call.Ref(0))
+				return boolSlots(index >= 0), err
+			}),
+			publicMethod("clear", "()V", func(call *NativeCall) ([]Slot, error) {
+				list := libraryState[arrayList](call.This())
+				list.elements = list.elements[:0]
+				list.modCount++
+
+				return nil, nil
+			}),
+			publicMethod("addAll", "(Ljava/util/Collection;)Z", func(call *NativeCall) ([]Slot, error) {
+				list := libraryState[arrayList](call.This())
+
+				// the elements are gathered first, so a list can be added to itself
+				var added []*Object
+				if err := call.Thread.forEach(call.Ref(0), func(element *Object) error {
+					added = append(added, element)
+					return nil
+				}); err != nil {
+					return nil, err
+				}
+
+				list.elements = append(list.elements, added...)
+				list.modCount++
+
+				return boolSlots(len(added) > 0), nil
+			}),
+			publicMethod("iterator", "()Ljava/util/Iterator;", func(call *NativeCall) ([]Slot, error) {
+				list := libraryState[arrayList](call.This())
+
+				it, err := call.newObject(arrayListIteratorClass)
+				if err != nil {
+					return nil, err
+				}
+
+				*libraryState[arrayListIterator](it) = arrayListIterator{list: list, lastReturned: -1, expectedModCount: list.modCount}
+
+				return []Slot{{Ref: it}}, nil
+			}),
+			publicMethod("toArray", "()[Ljava/lang/Object;", func(call *NativeCall) ([]Slot, error) {
+				return newObjectArray(call, libraryState[arrayList](call.This()).elements)
+			}),
+			publicMethod("clone", "()Ljava/lang/Object;", func(call *NativeCall) ([]Slot, error) {
+				clone := NewObject(call.This().Class)
+				libraryState[arrayList](clone).elements = append([]*Object(nil), libraryState[arrayList](call.This()).elements...)
+
+				return []Slot{{Ref: clone}}, nil
+			}),
+			publicMethod("toString", "()Ljava/lang/String;", collectionToString),
+			publicMethod("hashCode", "()I", func(call *NativeCall) ([]Slot, error) {
+				h := int32(1)
+				for _, element := range libraryState[arrayList](call.This()).elements {
+					hash, err := call.Thread.javaHashCode(element)
+					if err != nil {
+						return nil, err
+					}
+
+					h = 31*h + hash
+				}
+
+				return []Slot{{Num: h}}, nil
+			}),
+			publicMethod("equals", "(Ljava/lang/Object;)Z", func(call *NativeCall) ([]Slot, error) {
+				obj, other := call.This(), call.Ref(0)
+				if obj == other {
+					return boolSlots(true), nil
+				}
+
+				listClass, err := obj.Class.Loader.bootstrap().LoadClass("java/util/List")
+				if err != nil {
+					return nil, err
+				}
+
+				if other == nil || !other.IsInstanceOf(listClass) {
+					return boolSlots(false), nil
+				}
+
+				var elements []*Object
+				if err := call.Thread.forEach(other, func(element *Object) error {
+					elements = append(elements, element)
+					return nil
+				}); err != nil {
+					return nil, err
+				}
+
+				list := libraryState[arrayList](obj)
+				if len(elements) != len(list.elements) {
+					return boolSlots(false), nil
+				}
+
+				for i, element := range list.elements {
+					if equal, err := call.Thread.javaEquals(element, elements[i]); err != nil || !equal {
+						return boolSlots(false), err
+					}
+				}
+
+				return boolSlots(true), nil
+			}),
+		},
+	}
+}
+
+// indexOf returns the index of the first element equal to the object, or -1 when there is none.
+func (l *arrayList) indexOf(t *Thread, obj *Object) (int, error) {
+	for i, element := range l.elements {
+		equal, err := t.javaEquals(obj, element)
+		if err != nil {
+			return -1, err
+		}
+
+		if equal {
+			return i, nil
+		}
+	}
+
+	return -1, nil
+}
+
+// remove removes the element at the given index and returns it.
+func (l *arrayList) remove(index int) *Object {
+	removed := l.elements[index]
+	l.elements = append(l.elements[:index], l.elements[index+1:]...)
+	l.modCount++
+
+	return removed
+}
+
+func arrayListIteratorClasses() *libraryClass {
+	return &libraryClass{
+		name:       arrayListIteratorClass,
+		super:      objectClass,
+		access:     ACC_PRIVATE | ACC_FINAL,
+		interfaces: []string{"java/util/Iterator"},
+		methods: []libraryMethod{
+			publicMethod("hasNext", "()Z", func(call *NativeCall) ([]Slot, error) {
+				it := libraryState[arrayListIterator](call.This())
+				return boolSlots(it.cursor != len(it.list.elements)), nil
+			}),
+			publicMethod("next", "()Ljava/lang/Object;", func(call *NativeCall) ([]Slot, error) {
+				it := libraryState[arrayListIterator](call.This())
+				if it.list.modCount != it.expectedModCount {
+					return nil, &JavaError{ClassName: ConcurrentModificationException}
+				}
+
+				if it.cursor >= len(it.list.elements) {
+					return nil, &JavaError{ClassName: NoSuchElementException}
+				}
+
+				it.lastReturned = it.cursor
+				it.cursor++
+
+				return []Slot{{Ref: it.list.elements[it.lastReturned]}}, nil
+			}),
+			publicMethod("remove", "()V", func(call *NativeCall) ([]Slot, error) {
+				it := libraryState[arrayListIterator](call.This())
+				if it.lastReturned < 0 {
+					return nil, &JavaError{ClassName: IllegalStateException}
+				}
+
+				if it.list.modCount != it.expectedModCount {
+					return nil, &JavaError{ClassName: ConcurrentModificationException}
+				}
+
+				it.list.remove(it.lastReturned)
+				it.cursor, it.lastReturned, it.expectedModCount = it.lastReturned, -1, it.list.modCount
+
+				return nil, nil
+			}),
+		},
+	}
+}
+
+// Spec: https://docs.oracle.com/en/java/javase/21/docs/api/java.base/java/util/HashMap.html
+
+// Parameters of the table of a HashMap, the ones of the JDK so maps iterate in the same order.
+const (
+	hashMapDefaultCapacity = 16
+	hashMapMaximumCapacity = 1 << 30
+	hashMapLoadFactor      = 0.75
+)
+
+// hashMap is the state of a java/util/HashMap, a table of buckets like the one of the JDK. Its nodes are
+// HashMap$Node objects, which are the entries of its entry set.
+type hashMap struct {
+	table []*Object
+	size  int
+	// threshold is the size above which the table grows, or its initial capacity before it is allocated.
+	threshold int
+	modCount  int
+}
+
+// hashMapNode is the state of a HashMap$Node, a mapping of a bucket of the table.
+type hashMapNode struct {
+	hash  int32
+	key   *Object
+	value *Object
+	next  *Object
+}
+
+// Kinds of the views of the mappings of a HashMap.
+const (
+	hashMapKeys = iota
+	hashMapValues
+	hashMapEntries
+)
+
+// hashMapView is the state of the set of the keys, the collection of the values or the set of the entries
+// of a HashMap, and of their iterators.
+type hashMapView struct {
+	m    *hashMap
+	kind int
+}
+
+// hashMapIterator is the state of the iterator of a view of a HashMap, it goes through the buckets of the
+// table in order.
+type hashMapIterator struct {
+	hashMapView
+	next, current    *Object
+	index            int
+	expectedModCount int
+}
+
+func hashMapClasses() *libraryClass {
+	return &libraryClass{
+		name:         hashMapClass,
+		super:        objectClass,
+		access:       ACC_PUBLIC,
+		interfaces:   []string{"java/util/Map", "java/io/Serializable"},
+		constructors: []string{"()V", "(I)V"},
+		methods: []libraryMethod{
+			initMethod("(I)V", func(call *NativeCall) ([]Slot, error) {
+				capacity := int(call.Int(0))
+				if capacity < 0 {
+					return nil, NewJavaError(IllegalArgumentException, "Illegal initial capacity: %d", capacity)
+				}
+
+				libraryState[hashMap](call.This()).threshold = tableSizeFor(min(capacity, hashMapMaximumCapacity))
+
+				return nil, nil
+			}),
+
+			publicMethod("size", "()I", func(call *NativeCall) ([]Slot, error) {
+				return []Slot{{Num: int32(libraryState[hashMap](call.This()).size)}}, nil
+			}),
+			publicMethod("isEmpty", "()Z", func(call *NativeCall) ([]Slot, error) {
+				return boolSlots(libraryState[hashMap](call.This()).size == 0), nil
+			}),
+			publicMethod("get", "(Ljava/lang/Object;)Ljava/lang/Object;", func(call *NativeCall) ([]Slot, error) {
+				node, err := libraryState[hashMap](call.This()).find(call.Thread, call.Ref(0))
+				if err != nil || node == nil {
+					return []Slot{{}}, err
+				}
+
+				return []Slot{{Ref: libraryState[hashMapNode](node).value}}, nil
+			}),
+			publicMethod("getOrDefault", "(Ljava/lang/Object;Ljava/lang/Object;)Ljava/lang/Object;", func(call *NativeCall) ([]Slot, error) {
+				node, err := libraryState[hashMap](call.This()).find(call.Thread, call.Ref(0))
+				if err != nil || node == nil {
+					return []Slot{{Ref: call.Ref(1)}}, err
+				}
+
+				return []Slot{{Ref: libraryState[hashMapNode](node).value}}, nil
+			}),
+			publicMethod("containsKey", "(Ljava/lang/Object;)Z", func(call *NativeCall) ([]Slot, error) {
+				node, err := libraryState[hashMap](call.This()).find(call.Thread, call.Ref(0))
+				return boolSlots(node != nil), err
+			}),
+			publicMethod("containsValue", "(Ljava/lang/Object;)Z", func(call *NativeCall) ([]Slot, error) {
+				m := libraryState[hashMap](call.This())
+
+				for _, bucket := range m.table {
+					for node := bucket; node != nil; node = libraryState[hashMapNode](node).next {
+						if equal, err := call.Thread.javaEquals(call.Ref(0), libraryState[hashMapNode](node).value); err != nil || equal {
+							return boolSlots(equal), err
+						}
+					}
+				}
+
+				return boolSlots(false), nil
+			}),
+			publicMethod("put", "(Ljava/lang/Object;Ljava/lang/Object;)Ljava/lang/Object;", func(call *NativeCall) ([]Slot, error) {
+				old, err := libraryState[hashMap](call.This()).put(call, call.Ref(0), call.Ref(1), false)
+				return []Slot{{Ref: old}}, err
+			}),
+			publicMethod("putIfAbsent", "(Ljava/lang/Object;Ljava/lang/Object;)Ljava/lang/Object;", func(call *NativeCall) ([]Slot, error) {
+				old, err := libraryState[hashMap](call.This()).put(call, call.Ref(0), call.Ref(1), true)
+				return []Slot{{Ref: old}}, err
+			}),
+			publicMethod("remove", "(Ljava/lang/Object;)Ljava/lang/Object;", func(call *NativeCall) ([]Slot, error) {
+				m := libraryState[hashMap](call.This())
+
+				node, err := m.find(call.Thread, call.Ref(0))
+				if err != nil || node == nil {
+					return []Slot{{}}, err
+				}
+
+				m.remove(node)
+
+				return []Slot{{Ref: libraryState[hashMapNode](node).value}}, nil
+			}),
+			publicMethod("clear", "()V", func(call *NativeCall) ([]Slot, error) {
+				libraryState[hashMap](call.This()).clear()
+				return nil, nil
+			}),
+			publicMethod("keySet", "()Ljava/util/Set;", hashMapViewNative(hashMapKeys)),
+			publicMethod("values", "()Ljava/util/Collection;", hashMapViewNative(hashMapValues)),
+			publicMethod("entrySet", "()Ljava/util/Set;", hashMapViewNative(hashMapEntries)),
+			publicMethod("toString", "()Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+				obj := call.This()
+				chars := []uint16{'{'}
+
+				for i, node := range libraryState[hashMap](obj).nodes() {
+					if i > 0 {
+						chars = append(chars, ',', ' ')
+					}
+
+					entry := libraryState[hashMapNode](node)
+					for j, element := range []*Object{entry.key, entry.value} {
+						if j > 0 {
+							chars = append(chars, '=')
+						}
+
+						if element == obj {
+							chars = append(chars, utf16Chars("(this Map)")...)
+							continue
+						}
+
+						elementChars, err := call.Thread.javaString(element)
+						if err != nil {
+							return nil, err
+						}
+
+						chars = append(chars, elementChars...)
+					}
+				}
+
+				return call.returnString(append(chars, '}'))
+			}),
+			publicMethod("hashCode", "()I", func(call *NativeCall) ([]Slot, error) {
+				h := int32(0)
+				for _, node := range libraryState[hashMap](call.This()).nodes() {
+					hash, err := hashMapEntryHash(call.Thread, libraryState[hashMapNode](node))
+					if err != nil {
+						return nil, err
+					}
+
+					h += hash
+				}
+
+				return []Slot{{Num: h}}, nil
+			}),
+		},
+	}
+}
+
+// tableSizeFor returns the smallest power of two that is at least the given capacity.
+func tableSizeFor(capacity int) int {
+	size := 1
+	for size < capacity {
+		size <<= 1
+	}
+
+	return size
+}
+
+// hash spreads the higher bits of the hash code of a key to the lower ones, which select the bucket, like
+// the JDK does.
+func (m *hashMap) hash(t *Thread, key *Object) (int32, error) {
+	h, err := t.javaHashCode(key)
+	return h ^ int32(uint32(h)>>16), err
+}
+
+// find returns the node of a key, or nil when the map does not have it.
+func (m *hashMap) find(t *Thread, key *Object) (*Object, error) {
+	if m.size == 0 {
+		return nil, nil
+	}
+
+	hash, err := m.hash(t, key)
+	if err != nil {
+		return nil, err
+	}
+
+	for node := m.table[int(hash)&(len(m.table)-1)]; node != nil; node = libraryState[hashMapNode](node).next {
+		entry := libraryState[hashMapNode](node)
+		if entry.hash != hash {
+			continue
+		}
+
+		if equal, err := t.javaEquals(key, entry.key); err != nil || equal {
+			return node, err
+		}
+	}
+
+	return nil, nil
+}
+
+// put maps a key to a value, unless it is already mapped and onlyIfAbsent is set, and returns the value it
+// was mapped to.
+func (m *hashMap) put(call *NativeCall, key *Object, value *Object, onlyIfAbsent bool) (*Object, error) {
+	node, err := m.find(call.Thread, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if node != nil {
+		entry := libraryState[hashMapNode](node)
+
+		old := entry.value
+		if !onlyIfAbsent || old == nil {
+			entry.value = value
+		}
+
+		return old, nil
+	}
+
+	hash, err := m.hash(call.Thread, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if node, err = call.newObject(hashMapNodeClass); err != nil {
+		return nil, err
+	}
+
+	*libraryState[hashMapNode](node) = hashMapNode{hash: hash, key: key, value: value}
+
+	if m.table == nil {
+		m.resize()
+	}
+
+	// new nodes go at the end of their bucket
+	bucket := &m.table[int(hash)&(len(m.table)-1)]
+	for *bucket != nil {
+		bucket = &libraryState[hashMapNode](*bucket).next
+	}
+
+	*bucket = node
+	m.modCount++
+
+	if m.size++; m.size > m.threshold {
+		m.resize()
+	}
+
+	return nil, nil
+}
+
+// resize allocates the table, or doubles its size, splitting each bucket into the one with the same index
+// and the one with the index plus the old size, keeping the nodes in order.
+func (m *hashMap) resize() {
+	oldCapacity := len(m.table)
+
+	capacity, threshold := 0, 0
+	switch {
+	case oldCapacity >= hashMapMaximumCapacity:
+		m.threshold = int(^uint32(0) >> 1)
+		return
+	case oldCapacity > 0:
+		capacity = oldCapacity << 1
+		if capacity < hashMapMaximumCapacity && oldCapacity >= hashMapDefaultCapacity {
+			threshold = m.threshold << 1
+		}
+	case m.threshold > 0:
+		capacity = m.threshold
+	default:
+		capacity, threshold = hashMapDefaultCapacity, int(hashMapDefaultCapacity*hashMapLoadFactor)
+	}
+
+	if threshold == 0 {
+		threshold = int(float32(capacity) * hashMapLoadFactor)
+	}
+
+	table := make([]*Object, capacity)
+
+	for i, bucket := range m.table {
+		var low, high []*Object
+		for node := bucket; node != nil; node = libraryState[hashMapNode](node).next {
+			if int(libraryState[hashMapNode](node).hash)&oldCapacity == 0 {
+				low = append(low, node)
+			} else {
+				high = append(high, node)
+			}
+		}
+
+		table[i], table[i+oldCapacity] = linkNodes(low), linkNodes(high)
+	}
+
+	m.table, m.threshold = table, threshold
+}
+
+// linkNodes links nodes in order and returns the first one.
+func linkNodes(nodes []*Object) *Object {
+	for i, node := range nodes {
+		libraryState[hashMapNode](node).next = nil
+		if i > 0 {
+			libraryState[hashMapNode](nodes[i-1]).next = node
+		}
+	}
+
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	return nodes[0]
+}
+
+// remove unlinks a node from its bucket.
+func (m *hashMap) remove(node *Object) {
+	entry := libraryState[hashMapNode](node)
+
+	bucket := &m.table[int(entry.hash)&(len(m.table)-1)]
+	for *bucket != node {
+		bucket = &libraryState[hashMapNode](*bucket).next
+	}
+
+	*bucket = entry.next
+	m.size--
+	m.modCount++
+}
+
+func (m *hashMap) clear() {
+	if m.size > 0 {
+		clear(m.table)
+		m.size = 0
+	}
+
+	m.modCount++
+}
+
+// nodes returns the nodes of the map in the order of iteration.
+func (m *hashMap) nodes() []*Object {
+	nodes := make([]*Object, 0, m.size)
+
+	for _, bucket := range m.table {
+		for node := bucket; node != nil; node = libraryState[hashMapNode](node).next {
+			nodes = append(nodes, node)
+		}
+	}
+
+	return nodes
+}
+
+// hashMapEntryHash returns the hash code of an entry, the hash codes of its key and value xored.
+func hashMapEntryHash(t *Thread, entry *hashMapNode) (int32, error) {
+	key, err := t.javaHashCode(entry.key)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := t.javaHashCode(entry.value)
+
+	return key ^ value, err
+}
+
+func hashMapNodeClasses() *libraryClass {
+	return &libraryClass{
+		name:       hashMapNodeClass,
+		super:      objectClass,
+		access:     ACC_FINAL,
+		interfaces: []string{"java/util/Map$Entry"},
+		methods: []libraryMethod{
+			publicMethod("getKey", "()Ljava/lang/Object;", func(call *NativeCall) ([]Slot, error) {
+				return []Slot{{Ref: libraryState[hashMapNode](call.This()).key}}, nil
+			}),
+			publicMethod("getValue", "()Ljava/lang/Object;", func(call *NativeCall) ([]Slot, error) {
+				return []Slot{{Ref: libraryState[hashMapNode](call.This()).value}}, nil
+			}),
+			publicMethod("setValue", "(Ljava/lang/Object;)Ljava/lang/Object;", func(call *NativeCall) ([]Slot, error) {
+				entry := libraryState[hashMapNode](call.This())
+
+				old := entry.value
+				entry.value = call.Ref(0)
+
+				return []Slot{{Ref: old}}, nil
+			}),
+			publicMethod("hashCode", "()I", func(call *NativeCall) ([]Slot, error) {
+				hash, err := hashMapEntryHash(call.Thread, libraryState[hashMapNode](call.This()))
+				return []Slot{{Num: hash}}, err
+			}),
+			publicMethod("toString", "()Ljava/lang/String;", func(call *NativeCall) ([]Slot, error) {
+				entry := libraryState[hashMapNode](call.This())
+
+				key, err := call.Thread.javaString(entry.key)
+				if err != nil {
+					return nil, err
+				}
+
+				value, err := call.Thread.javaString(entry.value)
+				if err != nil {
+					return nil, err
+				}
+
+				return call.returnString(append(append(key, '='), value...))
+			}),
+		},
+	}
+}
+
+// hashMapViewNative returns the native method that returns a view of the mappings of a HashMap.
+func hashMapViewNative(kind int) NativeMethod {
+	return func(call *NativeCall) ([]Slot, error) {
+		view, err := call.newObject(hashMapViewClass)
+		if err != nil {
+			return nil, err
+		}
+
+		*libraryState[hashMapView](view) = hashMapView{m: libraryState[hashMap](call.This()), kind: kind}
+
+		return []Slot{{Ref: view}}, nil
+	}
+}
+
+// element returns the key, the value or the node itself, the element of the view for the node.
+func (v *hashMapView) element(node *Object) *Object {
+	switch v.kind {
+	case hashMapKeys:
+		return libraryState[hashMapNode](node).key
+	case hashMapValues:
+		return libraryState[hashMapNode](node).value
+	}
+
+	return node
+}
+
+func hashMapViewClasses() *libraryClass {
+	return &libraryClass{
+		name:       hashMapViewClass,
+		super:      objectClass,
+		access:     ACC_FINAL,
+		interfaces: []string{"java/util/Set"},
+		methods: []libraryMethod{
+			publicMethod("size", "()I", func(call *NativeCall) ([]Slot, error) {
+				return []Slot{{Num: int32(libraryState[hashMapView](call.This()).m.size)}}, nil
+			}),
+			publicMethod("isEmpty", "()Z", func(call *NativeCall) ([]Slot, error) {
+				return boolSlots(libraryState[hashMapView](call.This()).m.size == 0), nil
+			}),
+			publicMethod("contains", "(Ljava/lang/Object;)Z", func(call *NativeCall) ([]Slot, error) {
+				view := libraryState[hashMapView](call.This())
+
+				for _, node := range view.m.nodes() {
+					if equal, err := call.Thread.javaEquals(call.Ref(0), view.element(node)); err != nil || equal {
+						return boolSlots(equal), err
+					}
+				}
+
+				return boolSlots(false), nil
+			}),
+			publicMethod("add", "(Ljava/lang/Object;)Z", func(call *NativeCall) ([]Slot, error) {
+				return nil, &JavaError{ClassName: UnsupportedOperationException}
+			}),
+			publicMethod("addAll", "(Ljava/util/Collection;)Z", func(call *NativeCall) ([]Slot, error) {
+				return nil, &JavaError{ClassName: UnsupportedOperationException}
+			}),
+			publicMethod("remove", "(Ljava/lang/Object;)Z", func(call *NativeCall) ([]Slot, error) {
+				view := libraryState[hashMapView](call.This())
+
+				for _, node := range view.m.nodes() {
+					if equal, err := call.Thread.javaEquals(call.Ref(0), view.element(node)); err != nil || equal {
+						if equal {
+							view.m.remove(node)
+						}
+
+						return boolSlots(equal), err
+					}
+				}
+
+				return boolSlots(false), nil
+			}),
+			publicMethod("clear", "()V", func(call *NativeCall) ([]Slot, error) {
+				libraryState[hashMapView](call.This()).m.clear()
+				return nil, nil
+			}),
+			publicMethod("iterator", "()Ljava/util/Iterator;", func(call *NativeCall) ([]Slot, error) {
+				view := libraryState[hashMapView](call.This())
+
+				it, err := call.newObject(hashMapIteratorClass)
+				if err != nil {
+					return nil, err
+				}
+
+				iterator := libraryState[hashMapIterator](it)
+				*iterator = hashMapIterator{hashMapView: *view, expectedModCount: view.m.modCount}
+				iterator.advance()
+
+				return []Slot{{Ref: it}}, nil
+			}),
+			publicMethod("toArray", "()[Ljava/lang/Object;", func(call *NativeCall) ([]Slot, error) {
+				view := libraryState[hashMapView](call.This())
+
+				nodes := view.m.nodes()
+				for i, node := range nodes {
+					nodes[i] = view.element(node)
+				}
+
+				return newObjectArray(call, nodes)
+			}),
+			publicMethod("toString", "()Ljava/lang/String;", collectionToString),
+		},
+	}
+}
+
+// advance moves the iterator to the node after the current one, the first one of the next bucket that is
+// not empty when the current one is the last of its bucket.
+func (it *hashMapIterator) advance() {
+	if it.next != nil {
+		it.next = libraryState[hashMapNode](it.next).next
+	}
+
+	for it.next == nil && it.index < len(it.m.table) {
+		it.next = it.m.table[it.index]
+		it.index++
+	}
+}
+
+func hashMapIteratorClasses() *libraryClass {
+	return &libraryClass{
+		name:       hashMapIteratorClass,
+		super:      objectClass,
+		access:     ACC_FINAL,
+		interfaces: []string{"java/util/Iterator"},
+		methods: []libraryMethod{
+			publicMethod("hasNext", "()Z", func(call *NativeCall) ([]Slot, error) {
+				return boolSlots(libraryState[hashMapIterator](call.This()).next != nil), nil
+			}),
+			publicMethod("next", "()Ljava/lang/Object;", func(call *NativeCall) ([]Slot, error) {
+				it := libraryState[hashMapIterator](call.This())
+				if it.m.modCount != it.expectedModCount {
+					return nil, &JavaError{ClassName: ConcurrentModificationException}
+				}
+
+				if it.next == nil {
+					return nil, &JavaError{ClassName: NoSuchElementException}
+				}
+
+				it.current = it.next
+				it.advance()
+
+				return []Slot{{Ref: it.element(it.current)}}, nil
+			}),
+			publicMethod("remove", "()V", func(call *NativeCall) ([]Slot, error) {
+				it := libraryState[hashMapIterator](call.This())
+				if it.current == nil {
+					return nil, &JavaError{ClassName: IllegalStateException}
+				}
+
+				if it.m.modCount != it.expectedModCount {
+					return nil, &JavaError{ClassName: ConcurrentModificationException}
+				}
+
+				it.m.remove(it.current)
+				it.current, it.expectedModCount = nil, it.m.modCount
+
+				return nil, nil
+			}),
+		},
+	}
+}
+
+// forEach calls the action with each element of a java/lang/Iterable, in the order of its iterator,
+// throwing a NullPointerException when it is null.
+func (t *Thread) forEach(iterable *Object, action func(element *Object) error) error {
+	if iterable == nil {
+		return &JavaError{ClassName: NullPointerException}
+	}
+
+	ret, err := t.callMethod(iterable, "iterator", "()Ljava/util/Iterator;")
+	if err != nil {
+		return err
+	}
+
+	it := ret[0].Ref
+	if it == nil {
+		return &JavaError{ClassName: NullPointerException}
+	}
+
+	for {
+		hasNext, err := t.callMethod(it, "hasNext", "()Z")
+		if err != nil || hasNext[0].Num == 0 {
+			return err
+		}
+
+		next, err := t.callMethod(it, "next", "()Ljava/lang/Object;")
+		if err != nil {
+			return err
+		}
+
+		if err := action(next[0].Ref); err != nil {
+			return err
+		}
+	}
+}
+
+// collectionToString returns the elements of a collection between brackets, separated by commas, like
+// `AbstractCollection.toString`.
+func collectionToString(call *NativeCall) ([]Slot, error) {
+	obj := call.This()
+	chars := []uint16{'['}
+
+	err := call.Thread.forEach(obj, func(element *Object) error {
+		if len(chars) > 1 {
+			chars = append(chars, ',', ' ')
+		}
+
+		if element == obj {
+			chars = append(chars, utf16Chars("(this Collection)")...)
+			return nil
+		}
+
+		elementChars, err := call.Thread.javaString(element)
+		chars = append(chars, elementChars...)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return call.returnString(append(chars, ']'))
+}
+
+// newObjectArray returns a new java/lang/Object array with the given elements.
+func newObjectArray(call *NativeCall, elements []*Object) ([]Slot, error) {
+	class, err := call.Method.Class.Loader.bootstrap().LoadClass("[Ljava/lang/Object;")
+	if err != nil {
+		return nil, err
+	}
+
+	array := NewArray(class, len(elements))
+	copy(array.Elements.([]*Object), elements)
+
+	return []Slot{{Ref: array}}, nil
+}
@@ -0,0 +1,166 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Spec: https://docs.oracle.com/en/java/javase/21/docs/specs/jar/jar.html#jar-manifest
+
+// ManifestPath is the path of the manifest file inside a jar file.
+const ManifestPath = "META-INF/MANIFEST.MF"
+
+// Manifest represents the content of a `META-INF/MANIFEST.MF` file.
+//
+// Attribute names are case-insensitive, so they are stored in lower case and
+// should be looked up using the `Get` and `GetEntry` methods.
+type Manifest struct {
+	// MainAttributes holds the attributes of the main section, which describe the jar as a whole.
+	MainAttributes map[string]string
+	// Entries holds the attributes of each individual section, keyed by the value of their `Name` attribute.
+	Entries map[string]map[string]string
+}
+
+// ParseManifest parses the content of a manifest file.
+//
+// Lines may end with CR LF, LF or CR, a line starting with a single space continues the
+// value of the previous attribute and sections are separated by blank lines.
+func ParseManifest(content []byte) (*Manifest, error) {
+	manifest := &Manifest{
+		MainAttributes: make(map[string]string),
+		Entries:        make(map[string]map[string]string),
+	}
+
+	sections, err := splitManifestSections(content)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, section := range sections {
+		if i == 0 {
+			manifest.MainAttributes = section
+			continue
+		}
+
+		name, ok := section["name"]
+		if !ok {
+			return nil, fmt.Errorf("invalid manifest: section %d has no Name attribute", i)
+		}
+
+		// the same section can be split across multiple blocks, in that case the attributes are merged
+		entry, ok := manifest.Entries[name]
+		if !ok {
+			entry = make(map[string]string)
+			manifest.Entries[name] = entry
+		}
+
+		for k, v := range section {
+			entry[k] = v
+		}
+	}
+
+	return manifest, nil
+}
+
+// splitManifestSections splits the manifest in sections, joining the continuation lines and
+// parsing each header into a map.
+func splitManifestSections(content []byte) ([]map[string]string, error) {
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	// the main section is always present, even if it is empty
+	sections := []map[string]string{make(map[string]string)}
+	current := sections[0]
+	// lastKey is the key of the last attribute read, used to append continuation lines
+	lastKey := ""
+	// sectionStarted tells if the current section already has an attribute, so a blank line ends it
+	sectionStarted := false
+
+	for lineNumber, line := range strings.Split(text, "\n") {
+		if line == "" {
+			if sectionStarted {
+				current = make(map[string]string)
+				sections = append(sections, current)
+				sectionStarted = false
+			}
+
+			lastKey = ""
+			continue
+		}
+
+		if line[0] == ' ' {
+			if lastKey == "" {
+				return nil, fmt.Errorf("invalid manifest: unexpected continuation line at line %d", lineNumber+1)
+			}
+
+			current[lastKey] += line[1:]
+			continue
+		}
+
+		sep := strings.Index(line, ": ")
+		if sep <= 0 {
+			return nil, fmt.Errorf("invalid manifest: invalid header field at line %d", lineNumber+1)
+		}
+
+		key := line[:sep]
+		if !isValidManifestHeaderName(key) {
+			return nil, fmt.Errorf("invalid manifest: invalid header name %q at line %d", key, lineNumber+1)
+		}
+
+		lastKey = strings.ToLower(key)
+		current[lastKey] = line[sep+2:]
+		sectionStarted = true
+	}
+
+	// a trailing blank line leaves an empty section behind, which is not a section at all
+	if len(sections) > 1 && len(sections[len(sections)-1]) == 0 {
+		sections = sections[:len(sections)-1]
+	}
+
+	return sections, nil
+}
+
+// isValidManifestHeaderName checks if the name only contains alphanumeric characters, `-` and `_`.
+func isValidManifestHeaderName(name string) bool {
+	if len(name) > 70 {
+		return false
+	}
+
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+		isAlpha := (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+		isDigit := ch >= '0' && ch <= '9'
+
+		if !isAlpha && !isDigit && ch != '-' && ch != '_' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Get returns the value of an attribute of the main section, or an empty string if it is not present.
+func (m *Manifest) Get(name string) string {
+	return m.MainAttributes[strings.ToLower(name)]
+}
+
+// GetEntry returns the value of an attribute of the section of the given entry, or an empty string if it
+// is not present.
+func (m *Manifest) GetEntry(entry string, name string) string {
+	attributes, ok := m.Entries[entry]
+	if !ok {
+		return ""
+	}
+
+	return attributes[strings.ToLower(name)]
+}
+
+// MainClass returns the value of the `Main-Class` attribute, in its binary form (e.g. com.acme.Main).
+func (m *Manifest) MainClass() string {
+	return strings.TrimSpace(m.Get("Main-Class"))
+}
+
+// ClassPath returns the relative URLs listed in the `Class-Path` attribute.
+func (m *Manifest) ClassPath() []string {
+	return strings.Fields(m.Get("Class-Path"))
+}
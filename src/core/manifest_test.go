@@ -0,0 +1,87 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+func TestShouldParseTheMainSectionOfAManifest(t *testing.T) {
+	content := "Manifest-Version: 1.0\r\nMain-Class: com.acme.Main\r\nCreated-By: 21 (Oracle Corporation)\r\n\r\n"
+
+	m, err := core.ParseManifest([]byte(content))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if m.Get("Manifest-Version") != "1.0" {
+		t.Errorf("Expected manifest version 1.0, got %s", m.Get("Manifest-Version"))
+	}
+
+	if m.MainClass() != "com.acme.Main" {
+		t.Errorf("Expected main class com.acme.Main, got %s", m.MainClass())
+	}
+
+	// attribute names are case-insensitive
+	if m.Get("main-class") != "com.acme.Main" {
+		t.Errorf("Expected main class com.acme.Main, got %s", m.Get("main-class"))
+	}
+}
+
+func TestShouldJoinManifestContinuationLines(t *testing.T) {
+	content := "Manifest-Version: 1.0\nClass-Path: lib/first.jar lib/sec\n ond.jar\n  lib/third.jar\n"
+
+	m, err := core.ParseManifest([]byte(content))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	classPath := m.ClassPath()
+	expected := []string{"lib/first.jar", "lib/second.jar", "lib/third.jar"}
+
+	if len(classPath) != len(expected) {
+		t.Fatalf("Expected %d class path entries, got %d", len(expected), len(classPath))
+	}
+
+	for i, e := range expected {
+		if classPath[i] != e {
+			t.Errorf("Expected class path entry %d to be %s, got %s", i, e, classPath[i])
+		}
+	}
+}
+
+func TestShouldParseTheIndividualSectionsOfAManifest(t *testing.T) {
+	content := "Manifest-Version: 1.0\n\nName: com/acme/\nSealed: true\n\nName: com/acme/Main.class\nSHA-256-Digest: abc\n"
+
+	m, err := core.ParseManifest([]byte(content))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(m.Entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(m.Entries))
+	}
+
+	if m.GetEntry("com/acme/", "Sealed") != "true" {
+		t.Errorf("Expected com/acme/ to be sealed, got %s", m.GetEntry("com/acme/", "Sealed"))
+	}
+
+	if m.GetEntry("com/acme/Main.class", "SHA-256-Digest") != "abc" {
+		t.Errorf("Expected digest abc, got %s", m.GetEntry("com/acme/Main.class", "SHA-256-Digest"))
+	}
+}
+
+func TestShouldFailOnInvalidManifestHeaders(t *testing.T) {
+	invalid := []string{
+		"Manifest-Version 1.0\n",
+		" continuation\n",
+		"Main Class: Main\n",
+		"Manifest-Version: 1.0\n\nSealed: true\n",
+	}
+
+	for _, content := range invalid {
+		if _, err := core.ParseManifest([]byte(content)); err == nil {
+			t.Errorf("Expected error parsing %q, got nil", content)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package core
+
+import "fmt"
+
+// Field is a field declared by a class.
+type Field struct {
+	// Class is the class that declares the field.
+	Class *Class
+	// Name is the name of the field.
+	Name string
+	// Descriptor is the field descriptor of the type of the field (e.g. I or Ljava/lang/String;).
+	Descriptor string
+	// AccessFlags are the access flags of the field.
+	AccessFlags uint16
+	// Slot is the index of the first slot of the field, in the static values of the class for static
+	// fields and in the instance slots of the objects otherwise.
+	Slot int
+	// Info is the field in the class file.
+	Info *FieldInfo
+}
+
+// IsStatic tells if the field belongs to the class instead of its instances.
+func (f *Field) IsStatic() bool {
+	return f.AccessFlags&ACC_STATIC != 0
+}
+
+// IsVolatile tells if the accesses to the field are atomic and ordered with each other.
+func (f *Field) IsVolatile() bool {
+	return f.AccessFlags&ACC_VOLATILE != 0
+}
+
+// String returns the type and the name of the field, e.g. `int count`.
+func (f *Field) String() string {
+	return TypeName(f.Descriptor) + " " + f.Name
+}
+
+// Method is a method declared by a class.
+type Method struct {
+	// Class is the class that declares the method.
+	Class *Class
+	// Name is the name of the method, `<init>` for constructors and `<clinit>` for static initializers.
+	Name string
+	// Descriptor is the method descriptor of the parameters and return type (e.g. ([Ljava/lang/String;)V).
+	Descriptor string
+	// AccessFlags are the access flags of the method.
+	AccessFlags uint16
+	// ArgSlots is the number of slots taken by the arguments of the method, including `this` for instance methods.
+	ArgSlots int
+	// Code is the bytecode of the method, it is nil for abstract and native methods.
+	Code *Code
+	// Info is the method in the class file.
+	Info *MethodInfo
+	// native implements the method when it is a native method of a class the runtime spins, like the lambda
+	// classes, whose methods are not registered with `RegisterNative`.
+	native NativeMethod
+
+	// index is the position of the method in the methods of its class, which is also its index in the
+	// itables of the interface that declares it.
+	index int
+	// vtableIndex is the index of the method in the vtables, it is -1 for methods that are not selected
+	// through a vtable: static and private methods, initializers and interface methods.
+	vtableIndex int
+}
+
+// IsStatic tells if the method is a class method.
+func (m *Method) IsStatic() bool {
+	return m.AccessFlags&ACC_STATIC != 0
+}
+
+// IsAbstract tells if the method has no implementation.
+func (m *Method) IsAbstract() bool {
+	return m.AccessFlags&ACC_ABSTRACT != 0
+}
+
+// IsNative tells if the method is implemented in native code.
+func (m *Method) IsNative() bool {
+	return m.AccessFlags&ACC_NATIVE != 0
+}
+
+// IsPrivate tells if the method is private.
+func (m *Method) IsPrivate() bool {
+	return m.AccessFlags&ACC_PRIVATE != 0
+}
+
+// IsPublic tells if the method is public.
+func (m *Method) IsPublic() bool {
+	return m.AccessFlags&ACC_PUBLIC != 0
+}
+
+// String returns the method the way the reference implementation shows it, e.g. `void com.acme.Main.main(java.lang.String[])`.
+func (m *Method) String() string {
+	return MethodSignature(m.Class.Name, m.Name, m.Descriptor)
+}
+
+// newMembers creates the fields and methods declared in the class file of the class.
+func newMembers(class *Class) ([]*Field, []*Method, error) {
+	cf := class.File
+
+	fields := make([]*Field, len(cf.Fields))
+	for i := range cf.Fields {
+		info := &cf.Fields[i]
+
+		name, err := cf.Utf8(info.NameIndex)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid field name: %w", err)
+		}
+
+		descriptor, err := cf.Utf8(info.DescriptorIndex)
+		if err != nil || fieldDescriptorLength(descriptor) != len(descriptor) {
+			return nil, nil, fmt.Errorf("invalid descriptor for field %s", name)
+		}
+
+		fields[i] = &Field{Class: class, Name: name, Descriptor: descriptor, AccessFlags: info.AccessFlags, Info: info}
+	}
+
+	methods := make([]*Method, len(cf.Methods))
+	for i := range cf.Methods {
+		info := &cf.Methods[i]
+
+		name, err := cf.Utf8(info.NameIndex)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid method name: %w", err)
+		}
+
+		descriptor, err := cf.Utf8(info.DescriptorIndex)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid descriptor for method %s", name)
+		}
+
+		argSlots, err := ParameterSlots(descriptor)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid descriptor for method %s: %w", name, err)
+		}
+
+		if info.AccessFlags&ACC_STATIC == 0 {
+			argSlots++
+		}
+
+		method := &Method{
+			Class: class, Name: name, Descriptor: descriptor, AccessFlags: info.AccessFlags, ArgSlots: argSlots, Info: info,
+			index: i, vtableIndex: -1,
+		}
+
+		if attr, ok := cf.FindAttribute(info.Attributes, "Code"); ok {
+			if info.AccessFlags&(ACC_ABSTRACT|ACC_NATIVE) != 0 {
+				return nil, nil, fmt.Errorf("Code attribute in native or abstract method %s", name)
+			}
+
+			if method.Code, err = parseCode(cf, attr.Info); err != nil {
+				return nil, nil, fmt.Errorf("invalid Code attribute of method %s: %w", name, err)
+			}
+
+			if int(method.Code.MaxLocals) < argSlots {
+				return nil, nil, fmt.Errorf("arguments can't fit into locals of method %s", name)
+			}
+		}
+
+		methods[i] = method
+	}
+
+	return fields, methods, nil
+}
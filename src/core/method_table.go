@@ -0,0 +1,214 @@
+package core
+
+import "strings"
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-5.html#jvms-5.4.6
+
+// buildMethodTables creates the vtable and the itables of the class, which keep the method selected for
+// each virtual method and each method of its superinterfaces, so invoking them does not search the hierarchy.
+//
+// The vtable starts with the vtable of the superclass, and each virtual method declared by the class either
+// replaces the entries of the methods it overrides or is appended to it. Interfaces have neither.
+func (c *Class) buildMethodTables() {
+	if c.IsInterface() {
+		return
+	}
+
+	var vtable []*Method
+	if c.Super != nil {
+		vtable = append(vtable, c.Super.vtable...)
+	}
+
+	for _, method := range c.Methods {
+		if method.IsStatic() || method.IsPrivate() || method.Name == "<init>" || method.Name == "<clinit>" {
+			continue
+		}
+
+		for i, inherited := range vtable {
+			if inherited.Name == method.Name && inherited.Descriptor == method.Descriptor && method.canOverride(inherited) {
+				vtable[i] = method
+				if method.vtableIndex < 0 {
+					method.vtableIndex = i
+				}
+			}
+		}
+
+		if method.vtableIndex < 0 {
+			method.vtableIndex = len(vtable)
+			vtable = append(vtable, method)
+		}
+	}
+
+	c.vtable = vtable
+	c.itables = make(map[*Class][]*Method)
+
+	var visit func(class *Class)
+	visit = func(class *Class) {
+		for _, iface := range class.Interfaces {
+			if _, ok := c.itables[iface]; ok {
+				continue
+			}
+
+			itable := make([]*Method, len(iface.Methods))
+			for i, method := range iface.Methods {
+				if !method.IsStatic() && !method.IsPrivate() && method.Name != "<clinit>" {
+					itable[i] = c.selectInterfaceMethod(method)
+				}
+			}
+
+			c.itables[iface] = itable
+			visit(iface)
+		}
+	}
+
+	for class := c; class != nil; class = class.Super {
+		visit(class)
+	}
+}
+
+// canOverride tells if the method can override the given method of a superclass (JVMS 5.4.5): a package
+// private method can only be overridden by methods of the same run-time package.
+func (m *Method) canOverride(other *Method) bool {
+	if other.AccessFlags&(ACC_PUBLIC|ACC_PROTECTED) != 0 {
+		return true
+	}
+
+	return m.Class.SamePackage(other.Class)
+}
+
+// selectInterfaceMethod selects the method of the class that implements the given interface method: the
+// instance method declared by the class or its superclasses, otherwise the only non-abstract maximally-specific
+// superinterface method. It is the interface method itself when there is no implementation, and nil when there
+// are conflicting default methods.
+func (c *Class) selectInterfaceMethod(method *Method) *Method {
+	for class := c; class != nil; class = class.Super {
+		declared := class.DeclaredMethod(method.Name, method.Descriptor)
+		if declared != nil && !declared.IsStatic() && !declared.IsPrivate() {
+			return declared
+		}
+	}
+
+	selected, err := c.selectDefaultMethod(method.Name, method.Descriptor)
+	if err != nil {
+		return nil
+	}
+
+	if selected == nil {
+		return method
+	}
+
+	return selected
+}
+
+// selectDefaultMethod returns the only non-abstract maximally-specific superinterface method of the class
+// with the given name and descriptor, or nil when there is none. It fails with an IncompatibleClassChangeError
+// when there are several of them.
+func (c *Class) selectDefaultMethod(name string, descriptor string) (*Method, error) {
+	concrete := []*Method{}
+	for _, method := range c.maximallySpecificMethods(name, descriptor) {
+		if !method.IsAbstract() {
+			concrete = append(concrete, method)
+		}
+	}
+
+	switch len(concrete) {
+	case 0:
+		return nil, nil
+	case 1:
+		return concrete[0], nil
+	}
+
+	names := make([]string, len(concrete))
+	for i, method := range concrete {
+		names[i] = method.Class.JavaName() + "." + method.Name
+	}
+
+	return nil, NewJavaError(IncompatibleClassChangeError, "Conflicting default methods: %s", strings.Join(names, " "))
+}
+
+// SelectMethod selects the method invoked by invokevirtual or invokeinterface on an instance of the class
+// for the resolved method (JVMS 5.4.6), looking it up in the vtable or in the itable of the interface that
+// declares the resolved method. Private methods are not overridden, they are selected as is.
+//
+// It fails with an AbstractMethodError when the class has no implementation of the method, and with an
+// IncompatibleClassChangeError when it inherits conflicting default methods.
+func (c *Class) SelectMethod(resolved *Method) (*Method, error) {
+	if resolved.IsPrivate() {
+		return resolved, nil
+	}
+
+	var selected *Method
+	if resolved.Class.IsInterface() {
+		selected = c.itables[resolved.Class][resolved.index]
+	} else {
+		selected = c.vtable[resolved.vtableIndex]
+	}
+
+	if selected == nil {
+		_, err := c.selectDefaultMethod(resolved.Name, resolved.Descriptor)
+		return nil, err
+	}
+
+	if selected.IsAbstract() {
+		return nil, abstractMethodError(c, resolved)
+	}
+
+	return selected, nil
+}
+
+// lookupSpecial selects the method invoked by invokespecial in the given class, which is the class of the
+// resolved method or the superclass of the current class for super calls: the instance method declared by
+// the class or its superclasses, for an interface the one declared by it or a public method of java/lang/Object,
+// and otherwise the only non-abstract maximally-specific superinterface method. It returns nil when there
+// is none.
+func (c *Class) lookupSpecial(name string, descriptor string) (*Method, error) {
+	for class := c; class != nil; class = class.Super {
+		if method := class.DeclaredMethod(name, descriptor); method != nil && !method.IsStatic() {
+			return method, nil
+		}
+
+		if c.IsInterface() {
+			break
+		}
+	}
+
+	if c.IsInterface() && c.Super != nil {
+		if method := c.Super.DeclaredMethod(name, descriptor); method != nil && method.IsPublic() && !method.IsStatic() {
+			return method, nil
+		}
+	}
+
+	return c.selectDefaultMethod(name, descriptor)
+}
+
+// abstractMethodError is the error of invoking a method that the class of the receiver does not implement,
+// with the message of the reference implementation.
+func abstractMethodError(receiver *Class, resolved *Method) error {
+	return NewJavaError(
+		AbstractMethodError, "Receiver class %s does not define or inherit an implementation of the resolved method '%s' of %s %s.",
+		receiver.JavaName(), methodDeclaration(resolved), classKind(resolved.Class), resolved.Class.JavaName(),
+	)
+}
+
+// methodDeclaration returns the method the way the reference implementation declares it in error messages,
+// without its class, e.g. `abstract void eat()`.
+func methodDeclaration(method *Method) string {
+	signature := methodSignature(method.Name, method.Descriptor)
+	if method.IsAbstract() {
+		return "abstract " + signature
+	}
+
+	return signature
+}
+
+// classKind returns the kind of the class the way the reference implementation names it in error messages.
+func classKind(c *Class) string {
+	switch {
+	case c.IsInterface():
+		return "interface"
+	case c.AccessFlags&ACC_ABSTRACT != 0:
+		return "abstract class"
+	}
+
+	return "class"
+}
@@ -0,0 +1,191 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// writeDispatchClasses writes a hierarchy of shapes, whose methods return constants, and the class Calls with
+// static methods invoking them.
+func writeDispatchClasses(t *testing.T, dir string) {
+	public, static := core.ACC_PUBLIC, core.ACC_PUBLIC|core.ACC_STATIC
+	iface := core.ACC_PUBLIC | core.ACC_INTERFACE | core.ACC_ABSTRACT
+	abstract := core.ACC_PUBLIC | core.ACC_ABSTRACT
+
+	returns := func(b *testClassBuilder, access uint16, name string, value byte) []byte {
+		return b.member(access, name, "()I", b.code(1, 1, core.OP_BIPUSH, value, core.OP_IRETURN))
+	}
+
+	constructor := func(b *testClassBuilder, super string) []byte {
+		init := b.methodref(super, "<init>", "()V")
+		return b.member(public, "<init>", "()V", b.code(1, 1, concat([]byte{core.OP_ALOAD_0, core.OP_INVOKESPECIAL}, u2(init), []byte{core.OP_RETURN})...))
+	}
+
+	b := newTestClassBuilder()
+	writeClassFile(t, dir, "Named", b.build(iface, "Named", "java/lang/Object", nil, nil, [][]byte{returns(b, public, "name", 1)}, nil))
+
+	b = newTestClassBuilder()
+	writeClassFile(t, dir, "Labeled", b.build(iface, "Labeled", "java/lang/Object", []string{"Named"}, nil, [][]byte{returns(b, public, "name", 2)}, nil))
+
+	b = newTestClassBuilder()
+	writeClassFile(t, dir, "Other", b.build(iface, "Other", "java/lang/Object", nil, nil, [][]byte{returns(b, public, "name", 3)}, nil))
+
+	b = newTestClassBuilder()
+	sides := b.interfaceMethodref("Shape", "sides", "()I")
+	writeClassFile(t, dir, "Shape", b.build(iface, "Shape", "java/lang/Object", nil, nil,
+		[][]byte{
+			b.member(abstract, "sides", "()I"),
+			b.member(public, "corners", "()I", b.code(1, 1, concat([]byte{core.OP_ALOAD_0, core.OP_INVOKEINTERFACE}, u2(sides), []byte{1, 0, core.OP_IRETURN})...)),
+		},
+		nil,
+	))
+
+	b = newTestClassBuilder()
+	secret := b.methodref("Polygon", "secret", "()I")
+	writeClassFile(t, dir, "Polygon", b.build(abstract, "Polygon", "java/lang/Object", []string{"Shape", "Labeled"}, nil,
+		[][]byte{
+			constructor(b, "java/lang/Object"),
+			returns(b, 0, "scale", 1),
+			returns(b, core.ACC_PRIVATE, "secret", 10),
+			b.member(public, "reveal", "()I", b.code(1, 1, concat([]byte{core.OP_ALOAD_0, core.OP_INVOKEVIRTUAL}, u2(secret), []byte{core.OP_IRETURN})...)),
+		},
+		nil,
+	))
+
+	b = newTestClassBuilder()
+	scale := b.methodref("Polygon", "scale", "()I")
+	writeClassFile(t, dir, "Square", b.build(public, "Square", "Polygon", nil, nil,
+		[][]byte{
+			constructor(b, "Polygon"),
+			returns(b, public, "sides", 4),
+			b.member(0, "scale", "()I", b.code(2, 1, concat([]byte{core.OP_ALOAD_0, core.OP_INVOKESPECIAL}, u2(scale), []byte{core.OP_ICONST_1, core.OP_IADD, core.OP_IRETURN})...)),
+		},
+		nil,
+	))
+
+	b = newTestClassBuilder()
+	writeClassFile(t, dir, "p/Outsider", b.build(public, "p/Outsider", "Polygon", nil, nil,
+		[][]byte{constructor(b, "Polygon"), returns(b, public, "sides", 5), returns(b, 0, "scale", 100)},
+		nil,
+	))
+
+	b = newTestClassBuilder()
+	writeClassFile(t, dir, "Blob", b.build(public, "Blob", "Polygon", nil, nil, [][]byte{constructor(b, "Polygon")}, nil))
+
+	b = newTestClassBuilder()
+	writeClassFile(t, dir, "Clash", b.build(public, "Clash", "Polygon", []string{"Other"}, nil, [][]byte{constructor(b, "Polygon")}, nil))
+
+	b = newTestClassBuilder()
+	invoke := func(name string, descriptor string, op byte, ref uint16, operands ...byte) []byte {
+		code := concat([]byte{core.OP_ALOAD_0, op}, u2(ref), operands, []byte{core.OP_IRETURN})
+		return b.member(static, name, descriptor, b.code(1, 1, code...))
+	}
+
+	writeClassFile(t, dir, "Calls", b.build(public, "Calls", "java/lang/Object", nil, nil,
+		[][]byte{
+			invoke("sides", "(LShape;)I", core.OP_INVOKEINTERFACE, b.interfaceMethodref("Shape", "sides", "()I"), 1, 0),
+			invoke("corners", "(LShape;)I", core.OP_INVOKEINTERFACE, b.interfaceMethodref("Shape", "corners", "()I"), 1, 0),
+			invoke("sidesOf", "(Ljava/lang/Object;)I", core.OP_INVOKEINTERFACE, b.interfaceMethodref("Shape", "sides", "()I"), 1, 0),
+			invoke("scale", "(LPolygon;)I", core.OP_INVOKEVIRTUAL, b.methodref("Polygon", "scale", "()I")),
+			invoke("name", "(LPolygon;)I", core.OP_INVOKEVIRTUAL, b.methodref("Polygon", "name", "()I")),
+			invoke("reveal", "(LPolygon;)I", core.OP_INVOKEVIRTUAL, b.methodref("Polygon", "reveal", "()I")),
+		},
+		nil,
+	))
+}
+
+func TestShouldSelectTheInvokedMethodFromTheClassOfTheReceiver(t *testing.T) {
+	dir := t.TempDir()
+	writeDispatchClasses(t, dir)
+
+	loaders := newTestLoaders(t, dir)
+
+	calls, err := loaders.Application.LoadClass("Calls")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	instances := map[string]*core.Object{}
+	for _, name := range []string{"Square", "p/Outsider", "Blob", "Clash", "java/lang/Object"} {
+		class, err := loaders.Application.LoadClass(name)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		instances[name] = core.NewObject(class)
+	}
+
+	for _, test := range []struct {
+		method   string
+		param    string
+		receiver string
+		expected int32
+	}{
+		{"sides", "Shape", "Square", 4},
+		{"sides", "Shape", "p/Outsider", 5},
+		{"corners", "Shape", "Square", 4},
+		{"corners", "Shape", "p/Outsider", 5},
+		{"scale", "Polygon", "Square", 2},
+		{"scale", "Polygon", "Blob", 1},
+		// a package private method is not overridden by a method of another package
+		{"scale", "Polygon", "p/Outsider", 1},
+		{"name", "Polygon", "Square", 2},
+		{"reveal", "Polygon", "Square", 10},
+	} {
+		ret, err := invokeSlots(t, calls, test.method, "(L"+test.param+";)I", []core.Slot{{Ref: instances[test.receiver]}})
+		if err != nil || ret[0].Num != test.expected {
+			t.Errorf("Expected %s of %s to return %d, got %v (%v)", test.method, test.receiver, test.expected, ret, err)
+		}
+	}
+
+	_, err = invokeSlots(t, calls, "sides", "(LShape;)I", []core.Slot{{Ref: instances["Blob"]}})
+	expected := "java.lang.AbstractMethodError: Receiver class Blob does not define or inherit an implementation of the resolved method 'abstract int sides()' of interface Shape."
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+
+	_, err = invokeSlots(t, calls, "name", "(LPolygon;)I", []core.Slot{{Ref: instances["Clash"]}})
+	if err == nil || err.Error() != "java.lang.IncompatibleClassChangeError: Conflicting default methods: Other.name Labeled.name" {
+		t.Errorf("Expected an IncompatibleClassChangeError, got %v", err)
+	}
+
+	_, err = invokeSlots(t, calls, "sidesOf", "(Ljava/lang/Object;)I", []core.Slot{{Ref: instances["java/lang/Object"]}})
+	if err == nil || err.Error() != "java.lang.IncompatibleClassChangeError: Class java.lang.Object does not implement the requested interface Shape" {
+		t.Errorf("Expected an IncompatibleClassChangeError, got %v", err)
+	}
+
+	_, err = invokeSlots(t, calls, "scale", "(LPolygon;)I", []core.Slot{{}})
+	if !core.IsJavaError(err, core.NullPointerException) {
+		t.Errorf("Expected a NullPointerException, got %v", err)
+	}
+}
+
+func TestShouldSelectMethodsThroughInterfaces(t *testing.T) {
+	dir := t.TempDir()
+	writeDispatchClasses(t, dir)
+
+	loaders := newTestLoaders(t, dir)
+
+	square, err := loaders.Application.LoadClass("Square")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	shape, _ := loaders.Application.LoadClass("Shape")
+	named, _ := loaders.Application.LoadClass("Named")
+
+	for _, test := range []struct {
+		resolved *core.Method
+		expected string
+	}{
+		{shape.DeclaredMethod("sides", "()I"), "Square"},
+		{shape.DeclaredMethod("corners", "()I"), "Shape"},
+		{named.DeclaredMethod("name", "()I"), "Labeled"},
+	} {
+		selected, err := square.SelectMethod(test.resolved)
+		if err != nil || selected.Class.Name != test.expected {
+			t.Errorf("Expected %s to be selected from %s, got %v (%v)", test.resolved, test.expected, selected, err)
+		}
+	}
+}
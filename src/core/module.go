@@ -0,0 +1,826 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Gustrb/jbm/src/utils"
+)
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-4.html#jvms-4.7.25
+
+// Flags of the Module attribute and its requires, exports and opens items.
+const (
+	ACC_OPEN         uint16 = 0x0020
+	ACC_TRANSITIVE   uint16 = 0x0020
+	ACC_STATIC_PHASE uint16 = 0x0040
+	ACC_MANDATED     uint16 = 0x8000
+)
+
+// JavaBaseModule is the module every other module depends on, even if it does not say so.
+const JavaBaseModule = "java.base"
+
+var (
+	ErrModuleNotFound = errors.New("module not found")
+	ErrSplitPackage   = errors.New("split package")
+	ErrModuleCycle    = errors.New("cycle detected")
+)
+
+// ModuleRequires is a dependency of a module.
+type ModuleRequires struct {
+	Name    string
+	Flags   uint16
+	Version string
+}
+
+// ModuleExports is a package exported (or opened) by a module, optionally only to some modules.
+type ModuleExports struct {
+	// Package is the internal name of the package (e.g. com/acme/api).
+	Package string
+	Flags   uint16
+	// To has the names of the modules the package is exported to, it is empty for unqualified exports.
+	To []string
+}
+
+// ModuleProvides is a service implemented by a module.
+type ModuleProvides struct {
+	Service string
+	With    []string
+}
+
+// ModuleDescriptor describes a module, it is read from the `module-info.class` file.
+type ModuleDescriptor struct {
+	Name     string
+	Flags    uint16
+	Version  string
+	Requires []ModuleRequires
+	Exports  []ModuleExports
+	Opens    []ModuleExports
+	Uses     []string
+	Provides []ModuleProvides
+	// Packages has the internal names of all the packages of the module.
+	Packages []string
+	// MainClass is the internal name of the class in the ModuleMainClass attribute, if any.
+	MainClass string
+	// Automatic tells if the module is a plain jar in the module path, which has no module-info.
+	Automatic bool
+}
+
+// ParseModuleDescriptor reads the descriptor from the Module, ModulePackages and ModuleMainClass attributes
+// of a `module-info.class`.
+func ParseModuleDescriptor(cf *ClassFile) (*ModuleDescriptor, error) {
+	if cf.AccessFlags&ACC_MODULE == 0 {
+		return nil, errors.New("module-info.class is not a module descriptor")
+	}
+
+	attr, ok := cf.FindAttribute(cf.Attributes, "Module")
+	if !ok {
+		return nil, errors.New("module-info.class has no Module attribute")
+	}
+
+	descriptor, err := parseModuleAttribute(cf, attr.Info)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Module attribute: %w", err)
+	}
+
+	if attr, ok := cf.FindAttribute(cf.Attributes, "ModulePackages"); ok {
+		reader := utils.NewBigEndianReaderFromReader(bytes.NewReader(attr.Info))
+		descriptor.Packages, err = readModuleNames(reader, cf.ModuleName)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ModulePackages attribute: %w", err)
+		}
+	}
+
+	if attr, ok := cf.FindAttribute(cf.Attributes, "ModuleMainClass"); ok {
+		reader := utils.NewBigEndianReaderFromReader(bytes.NewReader(attr.Info))
+		index, err := reader.ReadUint16()
+		if err != nil {
+			return nil, fmt.Errorf("invalid ModuleMainClass attribute: %w", err)
+		}
+
+		descriptor.MainClass, err = cf.ClassName(index)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ModuleMainClass attribute: %w", err)
+		}
+	}
+
+	return descriptor, nil
+}
+
+func parseModuleAttribute(cf *ClassFile, info []byte) (*ModuleDescriptor, error) {
+	reader := utils.NewBigEndianReaderFromReader(bytes.NewReader(info))
+	descriptor := &ModuleDescriptor{}
+
+	nameIndex, err := reader.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+
+	if descriptor.Name, err = cf.ModuleName(nameIndex); err != nil {
+		return nil, err
+	}
+
+	if descriptor.Flags, err = reader.ReadUint16(); err != nil {
+		return nil, err
+	}
+
+	if descriptor.Version, err = readOptionalUtf8(cf, reader); err != nil {
+		return nil, err
+	}
+
+	requiresCount, err := reader.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < int(requiresCount); i++ {
+		requires := ModuleRequires{}
+
+		index, err := reader.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+
+		if requires.Name, err = cf.ModuleName(index); err != nil {
+			return nil, err
+		}
+
+		if requires.Flags, err = reader.ReadUint16(); err != nil {
+			return nil, err
+		}
+
+		if requires.Version, err = readOptionalUtf8(cf, reader); err != nil {
+			return nil, err
+		}
+
+		descriptor.Requires = append(descriptor.Requires, requires)
+	}
+
+	if descriptor.Exports, err = readModuleExports(cf, reader); err != nil {
+		return nil, err
+	}
+
+	if descriptor.Opens, err = readModuleExports(cf, reader); err != nil {
+		return nil, err
+	}
+
+	if descriptor.Uses, err = readModuleNames(reader, cf.ClassName); err != nil {
+		return nil, err
+	}
+
+	providesCount, err := reader.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < int(providesCount); i++ {
+		provides := ModuleProvides{}
+
+		index, err := reader.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+
+		if provides.Service, err = cf.ClassName(index); err != nil {
+			return nil, err
+		}
+
+		if provides.With, err = readModuleNames(reader, cf.ClassName); err != nil {
+			return nil, err
+		}
+
+		descriptor.Provides = append(descriptor.Provides, provides)
+	}
+
+	return descriptor, nil
+}
+
+// readModuleExports reads the exports or opens table of the Module attribute.
+func readModuleExports(cf *ClassFile, reader *utils.BigEndianReader) ([]ModuleExports, error) {
+	count, err := reader.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+
+	exports := []ModuleExports{}
+	for i := 0; i < int(count); i++ {
+		export := ModuleExports{}
+
+		index, err := reader.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+
+		if export.Package, err = cf.ModuleName(index); err != nil {
+			return nil, err
+		}
+
+		if export.Flags, err = reader.ReadUint16(); err != nil {
+			return nil, err
+		}
+
+		if export.To, err = readModuleNames(reader, cf.ModuleName); err != nil {
+			return nil, err
+		}
+
+		exports = append(exports, export)
+	}
+
+	return exports, nil
+}
+
+// readModuleNames reads a u2 count followed by that many constant pool indexes, resolving each one of
+// them with the given function.
+func readModuleNames(reader *utils.BigEndianReader, resolve func(uint16) (string, error)) ([]string, error) {
+	count, err := reader.ReadUint16()
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for i := 0; i < int(count); i++ {
+		index, err := reader.ReadUint16()
+		if err != nil {
+			return nil, err
+		}
+
+		name, err := resolve(index)
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// readOptionalUtf8 reads a constant pool index of an UTF-8 entry that may be zero.
+func readOptionalUtf8(cf *ClassFile, reader *utils.BigEndianReader) (string, error) {
+	index, err := reader.ReadUint16()
+	if err != nil || index == 0 {
+		return "", err
+	}
+
+	return cf.Utf8(index)
+}
+
+// Module is a module found in the module path or in the platform.
+type Module struct {
+	Descriptor *ModuleDescriptor
+	// Entry is where the classes of the module are read from, it is nil for platform modules.
+	Entry ClassPathEntry
+}
+
+// ModuleFinder finds modules by name, first in the platform modules and then in the module path, so a module
+// of the module path can not replace a platform module, like in the boot layer of the JDK.
+type ModuleFinder struct {
+	// modules are the modules of the module path, the first one found with a given name wins.
+	modules map[string]*Module
+	// order keeps the names of the modules in the order they appear in the module path.
+	order []string
+	// system finds the platform modules, it returns nil when the platform has no such module.
+	system func(name string) (*Module, error)
+}
+
+// NewModuleFinder reads the descriptors of the modules in the given module path entries, each entry is
+// one module, and uses the boot class path to look up the platform modules.
+func NewModuleFinder(entries []ClassPathEntry, boot *ClassPath) (*ModuleFinder, error) {
+	finder := &ModuleFinder{modules: make(map[string]*Module)}
+
+	for _, entry := range entries {
+		descriptor, err := readModuleDescriptor(entry)
+		if err != nil {
+			return nil, fmt.Errorf("error reading module %s: %w", entry, err)
+		}
+
+		if _, ok := finder.modules[descriptor.Name]; ok {
+			continue
+		}
+
+		finder.modules[descriptor.Name] = &Module{Descriptor: descriptor, Entry: entry}
+		finder.order = append(finder.order, descriptor.Name)
+	}
+
+	finder.system = func(name string) (*Module, error) {
+		return findSystemModule(boot, name)
+	}
+
+	return finder, nil
+}
+
+// Names returns the names of the modules in the module path, in order.
+func (f *ModuleFinder) Names() []string {
+	return append([]string{}, f.order...)
+}
+
+// Find returns the module with the given name, or nil if there is no such module.
+func (f *ModuleFinder) Find(name string) (*Module, error) {
+	module, err := f.system(name)
+	if module != nil || err != nil {
+		return module, err
+	}
+
+	return f.modules[name], nil
+}
+
+// findSystemModule reads the descriptor of a platform module from the boot class path.
+//
+// When there is no boot class path, `java.base` is still known, since every module requires it.
+func findSystemModule(boot *ClassPath, name string) (*Module, error) {
+	if boot == nil || len(boot.Entries) == 0 {
+		if name == JavaBaseModule {
+			return &Module{Descriptor: &ModuleDescriptor{Name: JavaBaseModule}}, nil
+		}
+
+		return nil, nil
+	}
+
+	for _, entry := range boot.Entries {
+		var content []byte
+		var err error
+
+		switch e := entry.(type) {
+		case *ImageReader:
+			content, err = e.ReadModuleResource(name, "module-info.class")
+			if errors.Is(err, ErrEntryNotFound) {
+				continue
+			}
+		default:
+			// each entry of a boot class path made of jmods is a module
+			if strings.TrimSuffix(filepath.Base(entry.String()), ".jmod") != name {
+				continue
+			}
+
+			content, err = entry.ReadClass("module-info")
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		descriptor, err := parseModuleInfo(content)
+		if err != nil {
+			return nil, fmt.Errorf("error reading platform module %s: %w", name, err)
+		}
+
+		return &Module{Descriptor: descriptor}, nil
+	}
+
+	return nil, nil
+}
+
+func parseModuleInfo(content []byte) (*ModuleDescriptor, error) {
+	cf, err := ClassFileFromReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseModuleDescriptor(&cf)
+}
+
+// readModuleDescriptor reads the descriptor of the module in a module path entry, plain jars are
+// automatic modules, named after their `Automatic-Module-Name` attribute or their file name.
+func readModuleDescriptor(entry ClassPathEntry) (*ModuleDescriptor, error) {
+	content, err := entry.ReadClass("module-info")
+	if err != nil && !errors.Is(err, ErrClassNotFound) {
+		return nil, err
+	}
+
+	var descriptor *ModuleDescriptor
+	if err == nil {
+		if descriptor, err = parseModuleInfo(content); err != nil {
+			return nil, err
+		}
+	} else {
+		jarEntry, ok := entry.(*JarClassPathEntry)
+		if !ok {
+			return nil, errors.New("module-info.class not found")
+		}
+
+		descriptor = &ModuleDescriptor{Name: automaticModuleName(jarEntry.Jar), Automatic: true}
+		if jarEntry.Jar.Manifest != nil {
+			descriptor.MainClass = jarEntry.Jar.MainClass()
+		}
+	}
+
+	if len(descriptor.Packages) == 0 {
+		if descriptor.Packages, err = entryPackages(entry); err != nil {
+			return nil, err
+		}
+	}
+
+	return descriptor, nil
+}
+
+var (
+	automaticModuleVersion     = regexp.MustCompile(`-(\d+(\.|$))`)
+	automaticModuleInvalidChar = regexp.MustCompile(`[^A-Za-z0-9]`)
+	automaticModuleRepeatedDot = regexp.MustCompile(`\.{2,}`)
+)
+
+// automaticModuleName derives the name of an automatic module, following the same rules of
+// `java.lang.module.ModuleFinder.of`: the version is dropped from the file name and every
+// non-alphanumeric character becomes a dot.
+func automaticModuleName(jar *JarFile) string {
+	if jar.Manifest != nil {
+		if name := strings.TrimSpace(jar.Manifest.Get("Automatic-Module-Name")); name != "" {
+			return name
+		}
+	}
+
+	name := strings.TrimSuffix(filepath.Base(jar.Path), filepath.Ext(jar.Path))
+	if loc := automaticModuleVersion.FindStringIndex(name); loc != nil {
+		name = name[:loc[0]]
+	}
+
+	name = automaticModuleInvalidChar.ReplaceAllString(name, ".")
+	name = automaticModuleRepeatedDot.ReplaceAllString(name, ".")
+
+	return strings.Trim(name, ".")
+}
+
+// entryPackages lists the packages of the classes in a module path entry.
+func entryPackages(entry ClassPathEntry) ([]string, error) {
+	names := []string{}
+
+	switch e := entry.(type) {
+	case *DirClassPathEntry:
+		err := filepath.WalkDir(e.Dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			rel, err := filepath.Rel(e.Dir, p)
+			names = append(names, filepath.ToSlash(rel))
+
+			return err
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	case *JarClassPathEntry:
+		names = e.Jar.Names()
+	case *JmodFile:
+		names = e.Entries(JmodClasses)
+	}
+
+	seen := make(map[string]bool)
+	packages := []string{}
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".class") || strings.HasPrefix(name, "META-INF/") {
+			continue
+		}
+
+		pkg := path.Dir(name)
+		if pkg == "." || seen[pkg] {
+			continue
+		}
+
+		seen[pkg] = true
+		packages = append(packages, pkg)
+	}
+
+	sort.Strings(packages)
+
+	return packages, nil
+}
+
+// Configuration is the result of resolving a set of root modules: the modules that are needed and
+// which modules each one of them reads.
+type Configuration struct {
+	// Modules are the resolved modules, keyed by name.
+	Modules map[string]*Module
+	// Order has the names of the modules in the order they were resolved, starting by the roots.
+	Order []string
+	// Readability has, for each module, the names of the modules it reads.
+	Readability map[string][]string
+
+	// packages has the name of the module of each package of the resolved modules.
+	packages map[string]string
+}
+
+// Resolve resolves the given root modules and all their dependencies.
+//
+// Static dependencies are not resolved, since they are only needed at compile time. A module reads the
+// modules it requires, plus the modules they require transitively. It fails if a module is missing, if
+// modules require each other in a cycle or if two modules have the same package, since all of them are
+// defined to the same class loader.
+func (f *ModuleFinder) Resolve(roots []string) (*Configuration, error) {
+	config := &Configuration{Modules: make(map[string]*Module), Readability: make(map[string][]string)}
+
+	type pending struct {
+		name       string
+		requiredBy string
+	}
+
+	queue := []pending{}
+	for _, root := range roots {
+		queue = append(queue, pending{name: root})
+	}
+
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+
+		if _, ok := config.Modules[next.name]; ok {
+			continue
+		}
+
+		module, err := f.Find(next.name)
+		if err != nil {
+			return nil, err
+		}
+
+		if module == nil {
+			if next.requiredBy == "" {
+				return nil, fmt.Errorf("%w: module %s not found", ErrModuleNotFound, next.name)
+			}
+
+			return nil, fmt.Errorf("%w: module %s not found, required by %s", ErrModuleNotFound, next.name, next.requiredBy)
+		}
+
+		config.Modules[next.name] = module
+		config.Order = append(config.Order, next.name)
+
+		for _, requires := range module.Descriptor.Requires {
+			if requires.Flags&ACC_STATIC_PHASE != 0 {
+				continue
+			}
+
+			queue = append(queue, pending{name: requires.Name, requiredBy: next.name})
+		}
+
+		if next.name != JavaBaseModule {
+			queue = append(queue, pending{name: JavaBaseModule, requiredBy: next.name})
+		}
+	}
+
+	if err := config.checkCycles(); err != nil {
+		return nil, err
+	}
+
+	for _, name := range config.Order {
+		config.Readability[name] = config.computeReads(name)
+	}
+
+	if err := config.checkSplitPackages(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// computeReads returns the modules read by the given module: the ones it requires and, recursively,
+// the ones required transitively by them. Automatic modules read every resolved module.
+func (c *Configuration) computeReads(name string) []string {
+	module := c.Modules[name]
+	if module.Descriptor.Automatic {
+		reads := []string{}
+		for _, other := range c.Order {
+			if other != name {
+				reads = append(reads, other)
+			}
+		}
+
+		return reads
+	}
+
+	seen := map[string]bool{name: true}
+	reads := []string{}
+
+	var visit func(requires ModuleRequires)
+	visit = func(requires ModuleRequires) {
+		dependency, ok := c.Modules[requires.Name]
+		if !ok || seen[requires.Name] {
+			return
+		}
+
+		seen[requires.Name] = true
+		reads = append(reads, requires.Name)
+
+		for _, transitive := range dependency.Descriptor.Requires {
+			if transitive.Flags&ACC_TRANSITIVE != 0 {
+				visit(transitive)
+			}
+		}
+	}
+
+	for _, requires := range module.Descriptor.Requires {
+		visit(requires)
+	}
+
+	if name != JavaBaseModule && !seen[JavaBaseModule] {
+		reads = append(reads, JavaBaseModule)
+	}
+
+	return reads
+}
+
+// checkCycles fails if the resolved modules require each other in a cycle, reporting it the way `java`
+// does, like `a -> b -> a`.
+func (c *Configuration) checkCycles() error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	states := make(map[string]int)
+	path := []string{}
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch states[name] {
+		case visiting:
+			for i, other := range path {
+				if other == name {
+					return fmt.Errorf("%w: %s", ErrModuleCycle, strings.Join(append(path[i:], name), " -> "))
+				}
+			}
+		case visited:
+			return nil
+		}
+
+		states[name] = visiting
+		path = append(path, name)
+
+		for _, requires := range c.Modules[name].Descriptor.Requires {
+			if _, ok := c.Modules[requires.Name]; !ok {
+				continue
+			}
+
+			if err := visit(requires.Name); err != nil {
+				return err
+			}
+		}
+
+		states[name] = visited
+		path = path[:len(path)-1]
+
+		return nil
+	}
+
+	for _, name := range c.Order {
+		if err := visit(name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkSplitPackages fails if two resolved modules have the same package.
+func (c *Configuration) checkSplitPackages() error {
+	owners := make(map[string]string)
+
+	for _, name := range c.Order {
+		for _, pkg := range c.Modules[name].Descriptor.Packages {
+			if owner, ok := owners[pkg]; ok {
+				return fmt.Errorf("%w: package %s in both module %s and module %s",
+					ErrSplitPackage, strings.ReplaceAll(pkg, "/", "."), owner, name)
+			}
+
+			owners[pkg] = name
+		}
+	}
+
+	c.packages = owners
+
+	return nil
+}
+
+// Reads tells if the module `from` reads the module `to`, every module reads itself.
+func (c *Configuration) Reads(from string, to string) bool {
+	if from == to {
+		return true
+	}
+
+	for _, name := range c.Readability[from] {
+		if name == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// moduleOf returns the resolved module of the class, or nil when the class is in the unnamed module of its
+// loader. The packages of the platform modules are the ones the bootstrap loader defines, the packages of
+// the modules of the module path are the ones the application loader defines.
+func (c *Configuration) moduleOf(class *Class) *Module {
+	module := c.Modules[c.packages[class.PackageName()]]
+	if module == nil || (module.Entry == nil) != class.Loader.IsBootstrap() {
+		return nil
+	}
+
+	return module
+}
+
+// checkAccess fails with an IllegalAccessError when the module of the class `from` can not access the
+// class `to` (JVMS 5.4.4): it must read the module of `to`, which must export the package of `to` to it.
+//
+// The unnamed modules read every module and export all their packages, and so do automatic modules, which
+// also read the unnamed modules. The classes of the bootstrap loader outside the resolved platform modules,
+// like the ones defined when there is no JDK, are not checked.
+func (c *Configuration) checkAccess(from *Class, to *Class) error {
+	source, target := c.moduleOf(from), c.moduleOf(to)
+	if source == target {
+		return nil
+	}
+
+	if target == nil {
+		if to.Loader.IsBootstrap() || source.Descriptor.Automatic {
+			return nil
+		}
+
+		return NewJavaError(IllegalAccessError, "class %s (in %s) cannot access class %s (in %s) because %s does not read %s",
+			from.JavaName(), moduleName(source), to.JavaName(), moduleName(target), moduleName(source), moduleName(target))
+	}
+
+	if source != nil && !c.Reads(source.Descriptor.Name, target.Descriptor.Name) {
+		return NewJavaError(IllegalAccessError, "class %s (in %s) cannot access class %s (in %s) because %s does not read %s",
+			from.JavaName(), moduleName(source), to.JavaName(), moduleName(target), moduleName(source), moduleName(target))
+	}
+
+	if !target.exports(to.PackageName(), source) {
+		return NewJavaError(IllegalAccessError, "class %s (in %s) cannot access class %s (in %s) because %s does not export %s to %s",
+			from.JavaName(), moduleName(source), to.JavaName(), moduleName(target), moduleName(target),
+			strings.ReplaceAll(to.PackageName(), "/", "."), moduleName(source))
+	}
+
+	return nil
+}
+
+// exports tells if the module exports the package to the given module, which is nil for the unnamed
+// modules. Automatic modules export all their packages.
+func (m *Module) exports(pkg string, to *Module) bool {
+	if m.Descriptor.Automatic {
+		return true
+	}
+
+	for _, exports := range m.Descriptor.Exports {
+		if exports.Package != pkg {
+			continue
+		}
+
+		if len(exports.To) == 0 {
+			return true
+		}
+
+		for _, name := range exports.To {
+			if to != nil && name == to.Descriptor.Name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// moduleName names the module in the messages of the errors of the accesses between modules, a nil module
+// being an unnamed one.
+func moduleName(module *Module) string {
+	if module == nil {
+		return "unnamed module"
+	}
+
+	return "module " + module.Descriptor.Name
+}
+
+// checkModuleAccess fails with an IllegalAccessError when the module of the class can not access the module
+// of the other class, or of its element type for arrays, see `Configuration.checkAccess`. Nothing is checked
+// when no module was resolved.
+func (c *Class) checkModuleAccess(other *Class) error {
+	config := c.Loader.bootstrap().configuration
+	if config == nil {
+		return nil
+	}
+
+	if other.IsArray() {
+		if other = other.ElementType(); other == nil {
+			return nil
+		}
+	}
+
+	return config.checkAccess(c, other)
+}
+
+// Entries returns the class path entries of the resolved modules of the module path, in resolution order.
+func (c *Configuration) Entries() []ClassPathEntry {
+	entries := []ClassPathEntry{}
+	for _, name := range c.Order {
+		if entry := c.Modules[name].Entry; entry != nil {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
@@ -0,0 +1,160 @@
+package core
+
+import "strings"
+
+// Spec: https://docs.oracle.com/en/java/javase/21/docs/api/java.base/java/lang/Module.html
+
+// moduleClass is the internal name of the class of the objects that represent modules at run time.
+const moduleClass = "java/lang/Module"
+
+// javaBasePackage is the package whose module is java.base, the one of the classes of primitive types.
+const javaBasePackage = "java/lang"
+
+// moduleNatives are the native methods of java/lang/Module. The accesses between modules are checked against
+// the modules resolved at launch (see `checkModuleAccess`), so it only keeps the modules of the packages of the
+// bootstrap loader, for the `module` field of the class mirrors that `Class.getModule` returns.
+var moduleNatives = map[string]NativeMethod{
+	"defineModule0(Ljava/lang/Module;ZLjava/lang/String;Ljava/lang/String;[Ljava/lang/Object;)V": defineModule0,
+	"addReads0(Ljava/lang/Module;Ljava/lang/Module;)V":                                           moduleUpdate,
+	"addExports0(Ljava/lang/Module;Ljava/lang/String;Ljava/lang/Module;)V":                       moduleUpdate,
+	"addExportsToAll0(Ljava/lang/Module;Ljava/lang/String;)V":                                    moduleUpdate,
+	"addExportsToAllUnnamed0(Ljava/lang/Module;Ljava/lang/String;)V":                             moduleUpdate,
+}
+
+// defineModule0 defines the module with its packages. The modules of the bootstrap loader, whose Java loader
+// is null, become the ones of their packages, the other loaders do not have Java objects (see `forName0`).
+//
+// Like HotSpot, the mirrors created before java.base is defined are in java.base.
+func defineModule0(call *NativeCall) ([]Slot, error) {
+	module := call.Ref(0)
+	if module == nil {
+		return nil, NewJavaError(NullPointerException, "Null module object")
+	}
+
+	if getField(module, "loader", "Ljava/lang/ClassLoader;").Ref != nil {
+		return nil, nil
+	}
+
+	name := GoString(getField(module, "name", "Ljava/lang/String;").Ref)
+
+	var packages []string
+	if pns := call.Ref(4); pns != nil {
+		for _, pn := range pns.Elements.([]*Object) {
+			if pn == nil {
+				return nil, NewJavaError(IllegalArgumentException, "Bad package name")
+			}
+
+			packages = append(packages, strings.ReplaceAll(GoString(pn), ".", "/"))
+		}
+	}
+
+	return nil, call.Method.Class.Loader.bootstrap().defineModule(module, name, packages)
+}
+
+// moduleUpdate is the native method that changes what a module reads or exports, which the runtime ignores
+// since it checks the accesses against the modules resolved at launch.
+func moduleUpdate(call *NativeCall) ([]Slot, error) {
+	if call.Ref(0) == nil {
+		return nil, NewJavaError(NullPointerException, "from_module is null")
+	}
+
+	return nil, nil
+}
+
+// setBootLoaderUnnamedModule0 sets the unnamed module of the bootstrap loader, the one of its packages that are
+// not in a named module.
+func setBootLoaderUnnamedModule0(call *NativeCall) ([]Slot, error) {
+	module := call.Ref(0)
+	if module == nil {
+		return nil, NewJavaError(NullPointerException, "Null module object")
+	}
+
+	boot := call.Method.Class.Loader.bootstrap()
+
+	boot.modulesMu.Lock()
+	defer boot.modulesMu.Unlock()
+
+	if boot.unnamedModule != nil {
+		return nil, NewJavaError(IllegalArgumentException, "unnamed module for the boot loader is already set")
+	}
+
+	boot.unnamedModule = module
+
+	// the mirrors created before java.base is defined wait for java.base instead
+	if boot.modules[javaBasePackage] != nil {
+		boot.setPendingModules(module)
+	}
+
+	return nil, nil
+}
+
+// defineModule makes the module the one of the packages the bootstrap loader defines.
+func (l *ClassLoader) defineModule(module *Object, name string, packages []string) error {
+	l.modulesMu.Lock()
+	defer l.modulesMu.Unlock()
+
+	for _, pkg := range packages {
+		if other, ok := l.modules[pkg]; ok {
+			return NewJavaError(IllegalStateException, "Package %s for module %s is already in another module, %s, defined to the class loader",
+				strings.ReplaceAll(pkg, "/", "."), name, GoString(getField(other, "name", "Ljava/lang/String;").Ref))
+		}
+	}
+
+	if l.modules == nil {
+		l.modules = make(map[string]*Object)
+	}
+
+	for _, pkg := range packages {
+		l.modules[pkg] = module
+	}
+
+	if name == JavaBaseModule {
+		l.setPendingModules(module)
+	}
+
+	return nil
+}
+
+// setPendingModules sets the module of the mirrors created before the module of their package was known.
+func (l *ClassLoader) setPendingModules(module *Object) {
+	for _, mirror := range l.pendingModules {
+		setField(mirror, "module", "Ljava/lang/Module;", Slot{Ref: module})
+	}
+
+	l.pendingModules = nil
+}
+
+// setMirrorModule sets the module of the mirror of a class the bootstrap loader defines, the module of the
+// element type for arrays. Until java.base is defined, and then until the unnamed module of the loader is set,
+// the mirror waits for it.
+func (c *Class) setMirrorModule(mirror *Object) {
+	for c.IsArray() && c.ComponentType != nil {
+		c = c.ComponentType
+	}
+
+	if !c.Loader.IsBootstrap() {
+		return
+	}
+
+	pkg := c.PackageName()
+	if c.IsPrimitive() || c.IsArray() {
+		pkg = javaBasePackage
+	}
+
+	l := c.Loader
+
+	l.modulesMu.Lock()
+	defer l.modulesMu.Unlock()
+
+	module, ok := l.modules[pkg]
+	if !ok && l.modules[javaBasePackage] != nil {
+		module = l.unnamedModule
+	}
+
+	if module == nil {
+		l.pendingModules = append(l.pendingModules, mirror)
+		return
+	}
+
+	setField(mirror, "module", "Ljava/lang/Module;", Slot{Ref: module})
+}
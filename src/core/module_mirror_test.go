@@ -0,0 +1,127 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+const defineModule = "(Ljava/lang/Module;ZLjava/lang/String;Ljava/lang/String;[Ljava/lang/Object;)V"
+
+// writeModuleClasses writes a java/lang/Class with the module field of the one of the class library, the
+// java/lang/Module and BootLoader natives that set it, and a class of the boot class path outside java.base.
+func writeModuleClasses(t *testing.T, dir string) {
+	static := core.ACC_PUBLIC | core.ACC_STATIC
+
+	b := newTestClassBuilder()
+	writeClassFile(t, dir, "java/lang/Class", b.build(core.ACC_PUBLIC|core.ACC_FINAL, "java/lang/Class", "java/lang/Object", nil,
+		[][]byte{b.member(core.ACC_PRIVATE, "module", "Ljava/lang/Module;")},
+		nil,
+		nil,
+	))
+
+	b = newTestClassBuilder()
+	writeClassFile(t, dir, "java/lang/Module", b.build(core.ACC_PUBLIC|core.ACC_FINAL, "java/lang/Module", "java/lang/Object", nil,
+		[][]byte{b.member(core.ACC_PRIVATE, "name", "Ljava/lang/String;"), b.member(core.ACC_PRIVATE, "loader", "Ljava/lang/ClassLoader;")},
+		[][]byte{b.member(static|core.ACC_NATIVE, "defineModule0", defineModule)},
+		nil,
+	))
+
+	b = newTestClassBuilder()
+	writeClassFile(t, dir, "jdk/internal/loader/BootLoader", b.build(core.ACC_PUBLIC, "jdk/internal/loader/BootLoader", "java/lang/Object", nil, nil,
+		[][]byte{b.member(static|core.ACC_NATIVE, "setBootLoaderUnnamedModule0", "(Ljava/lang/Module;)V")},
+		nil,
+	))
+
+	writeClassFile(t, dir, "com/acme/Boot", minimalClassFile("com/acme/Boot"))
+}
+
+func TestShouldSetTheModuleOfTheClassMirrors(t *testing.T) {
+	dir := t.TempDir()
+	writeModuleClasses(t, dir)
+
+	loaders := core.NewClassLoaders(core.NewClassPath(&core.DirClassPathEntry{Dir: dir}), nil, nil)
+	t.Cleanup(func() { loaders.Close() })
+
+	load := func(name string) *core.Class {
+		class, err := loaders.Bootstrap.LoadClass(name)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		return class
+	}
+
+	mirror := func(class *core.Class) *core.Object {
+		mirror, err := class.Mirror()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		return mirror
+	}
+
+	moduleClass, bootLoader := load("java/lang/Module"), load("jdk/internal/loader/BootLoader")
+	moduleField := load("java/lang/Class").DeclaredField("module", "Ljava/lang/Module;")
+
+	str := func(s string) *core.Object {
+		str, err := core.NewString(loaders.Bootstrap, s)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		return str
+	}
+
+	newModule := func(name string) *core.Object {
+		module := core.NewObject(moduleClass)
+		module.Fields[moduleClass.DeclaredField("name", "Ljava/lang/String;").Slot].Ref = str(name)
+
+		return module
+	}
+
+	packages := func(names ...string) *core.Object {
+		array := core.NewArray(load("[Ljava/lang/Object;"), len(names))
+		for i, name := range names {
+			array.Elements.([]*core.Object)[i] = str(name)
+		}
+
+		return array
+	}
+
+	object := mirror(load("java/lang/Object"))
+	if module := object.Fields[moduleField.Slot].Ref; module != nil {
+		t.Fatalf("Expected no module before java.base is defined, got %v", module)
+	}
+
+	javaBase, unnamed := newModule(core.JavaBaseModule), newModule("")
+
+	if _, err := invokeSlots(t, bootLoader, "setBootLoaderUnnamedModule0", "(Ljava/lang/Module;)V", []core.Slot{{Ref: unnamed}}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := invokeSlots(t, moduleClass, "defineModule0", defineModule,
+		[]core.Slot{{Ref: javaBase}, {Num: 0}, {}, {}, {Ref: packages("java.lang")}},
+	); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if module := object.Fields[moduleField.Slot].Ref; module != javaBase {
+		t.Errorf("Expected the mirrors created before java.base is defined to be in java.base, got %v", module)
+	}
+
+	if module := mirror(load("[I")).Fields[moduleField.Slot].Ref; module != javaBase {
+		t.Errorf("Expected the arrays of primitive types to be in java.base, got %v", module)
+	}
+
+	if module := mirror(load("com/acme/Boot")).Fields[moduleField.Slot].Ref; module != unnamed {
+		t.Errorf("Expected the other packages of the bootstrap loader to be in its unnamed module, got %v", module)
+	}
+
+	_, err := invokeSlots(t, moduleClass, "defineModule0", defineModule,
+		[]core.Slot{{Ref: newModule("java.other")}, {Num: 0}, {}, {}, {Ref: packages("java.lang")}},
+	)
+	if !core.IsJavaError(err, core.IllegalStateException) {
+		t.Errorf("Expected an IllegalStateException for a package already in java.base, got %v", err)
+	}
+}
@@ -0,0 +1,382 @@
+package core_test
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+type testRequires struct {
+	name  string
+	flags uint16
+}
+
+// moduleInfoClassFile builds a module-info.class with the given dependencies, exported packages and
+// main class (which can be empty).
+func moduleInfoClassFile(name string, requires []testRequires, exports []string, mainClass string) []byte {
+	qualified := []core.ModuleExports{}
+	for _, pkg := range exports {
+		qualified = append(qualified, core.ModuleExports{Package: pkg})
+	}
+
+	return qualifiedModuleInfoClassFile(name, requires, qualified, mainClass)
+}
+
+// qualifiedModuleInfoClassFile builds a module-info.class whose exports can be qualified.
+func qualifiedModuleInfoClassFile(name string, requires []testRequires, exports []core.ModuleExports, mainClass string) []byte {
+	b := newTestClassBuilder()
+
+	module := append(u2(b.module(name)), u2(0)...)
+	module = append(module, u2(0)...)
+
+	module = append(module, u2(uint16(len(requires)))...)
+	for _, r := range requires {
+		module = append(module, u2(b.module(r.name))...)
+		module = append(module, u2(r.flags)...)
+		module = append(module, u2(0)...)
+	}
+
+	module = append(module, u2(uint16(len(exports)))...)
+	for _, e := range exports {
+		module = append(module, u2(b.pkg(e.Package))...)
+		module = append(module, u2(0)...)
+		module = append(module, u2(uint16(len(e.To)))...)
+		for _, to := range e.To {
+			module = append(module, u2(b.module(to))...)
+		}
+	}
+
+	// opens, uses and provides
+	module = append(module, 0, 0, 0, 0, 0, 0)
+
+	attributes := [][]byte{b.attribute("Module", module)}
+	if mainClass != "" {
+		attributes = append(attributes, b.attribute("ModuleMainClass", u2(b.class(mainClass))))
+	}
+
+	return b.build(core.ACC_MODULE, "module-info", "", nil, nil, nil, attributes)
+}
+
+// writeExplodedModule writes an exploded module with the given module-info and classes.
+func writeExplodedModule(t *testing.T, dir string, moduleInfo []byte, classes ...string) {
+	writeClassFile(t, dir, "module-info", moduleInfo)
+	for _, class := range classes {
+		writeClassFile(t, dir, class, mainClassFile(class))
+	}
+}
+
+func TestShouldParseAModuleDescriptor(t *testing.T) {
+	b := newTestClassBuilder()
+
+	module := append(u2(b.module("com.acme.app")), u2(core.ACC_OPEN)...)
+	module = append(module, u2(b.utf8("1.0"))...)
+	module = append(module, u2(2)...)
+	module = append(module, append(u2(b.module("java.base")), append(u2(core.ACC_MANDATED), u2(0)...)...)...)
+	module = append(module, append(u2(b.module("com.acme.lib")), append(u2(core.ACC_TRANSITIVE), u2(0)...)...)...)
+	// exports com/acme/api to com.acme.friend
+	module = append(module, u2(1)...)
+	module = append(module, append(u2(b.pkg("com/acme/api")), append(u2(0), append(u2(1), u2(b.module("com.acme.friend"))...)...)...)...)
+	// no opens, uses com/acme/spi/Service and provides it with com/acme/impl/ServiceImpl
+	module = append(module, u2(0)...)
+	module = append(module, append(u2(1), u2(b.class("com/acme/spi/Service"))...)...)
+	module = append(module, u2(1)...)
+	module = append(module, append(u2(b.class("com/acme/spi/Service")), append(u2(1), u2(b.class("com/acme/impl/ServiceImpl"))...)...)...)
+
+	packages := append(u2(2), append(u2(b.pkg("com/acme/api")), u2(b.pkg("com/acme/impl"))...)...)
+	content := b.build(core.ACC_MODULE, "module-info", "", nil, nil, nil, [][]byte{
+		b.attribute("Module", module),
+		b.attribute("ModulePackages", packages),
+		b.attribute("ModuleMainClass", u2(b.class("com/acme/api/Main"))),
+	})
+
+	cf, err := core.ClassFileFromReader(bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	d, err := core.ParseModuleDescriptor(&cf)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if d.Name != "com.acme.app" || d.Flags != core.ACC_OPEN || d.Version != "1.0" {
+		t.Errorf("Expected the module name, flags and version, got %s %d %s", d.Name, d.Flags, d.Version)
+	}
+
+	if len(d.Requires) != 2 || d.Requires[1].Name != "com.acme.lib" || d.Requires[1].Flags != core.ACC_TRANSITIVE {
+		t.Errorf("Expected the requires to be parsed, got %+v", d.Requires)
+	}
+
+	if len(d.Exports) != 1 || d.Exports[0].Package != "com/acme/api" || len(d.Exports[0].To) != 1 || d.Exports[0].To[0] != "com.acme.friend" {
+		t.Errorf("Expected the qualified export to be parsed, got %+v", d.Exports)
+	}
+
+	if len(d.Uses) != 1 || d.Uses[0] != "com/acme/spi/Service" {
+		t.Errorf("Expected the uses to be parsed, got %v", d.Uses)
+	}
+
+	if len(d.Provides) != 1 || d.Provides[0].With[0] != "com/acme/impl/ServiceImpl" {
+		t.Errorf("Expected the provides to be parsed, got %+v", d.Provides)
+	}
+
+	if len(d.Packages) != 2 || d.MainClass != "com/acme/api/Main" {
+		t.Errorf("Expected the packages and main class, got %v %s", d.Packages, d.MainClass)
+	}
+}
+
+func TestShouldResolveTheModuleGraph(t *testing.T) {
+	dir := t.TempDir()
+	writeExplodedModule(t, filepath.Join(dir, "app"), moduleInfoClassFile("app", []testRequires{
+		{"lib", 0},
+		{"optional", core.ACC_STATIC_PHASE},
+	}, nil, ""), "app/Main")
+	writeExplodedModule(t, filepath.Join(dir, "lib"), moduleInfoClassFile("lib", []testRequires{{"util", core.ACC_TRANSITIVE}}, []string{"lib"}, ""), "lib/Lib")
+	writeExplodedModule(t, filepath.Join(dir, "util"), moduleInfoClassFile("util", nil, []string{"util"}, ""), "util/Util")
+	writeExplodedModule(t, filepath.Join(dir, "unused"), moduleInfoClassFile("unused", nil, nil, ""), "unused/Unused")
+
+	modulePath, err := core.ParseModulePath(dir, core.DefaultRelease)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	defer modulePath.Close()
+
+	finder, err := core.NewModuleFinder(modulePath.Entries, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	config, err := finder.Resolve([]string{"app"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if strings.Join(config.Order, ",") != "app,lib,java.base,util" {
+		t.Errorf("Expected app, lib, java.base and util to be resolved, got %v", config.Order)
+	}
+
+	if !config.Reads("app", "lib") || !config.Reads("app", "util") || !config.Reads("app", "java.base") {
+		t.Errorf("Expected app to read lib, util (transitively) and java.base, got %v", config.Readability["app"])
+	}
+
+	if config.Reads("util", "lib") {
+		t.Errorf("Expected util not to read lib")
+	}
+
+	if len(config.Entries()) != 3 {
+		t.Errorf("Expected the entries of the 3 modules of the module path, got %d", len(config.Entries()))
+	}
+}
+
+func TestShouldFailToResolveMissingModules(t *testing.T) {
+	dir := t.TempDir()
+	writeExplodedModule(t, filepath.Join(dir, "app"), moduleInfoClassFile("app", []testRequires{{"missing", 0}}, nil, ""), "app/Main")
+
+	modulePath, _ := core.ParseModulePath(dir, core.DefaultRelease)
+	defer modulePath.Close()
+
+	finder, _ := core.NewModuleFinder(modulePath.Entries, nil)
+
+	_, err := finder.Resolve([]string{"app"})
+	if !errors.Is(err, core.ErrModuleNotFound) || !strings.Contains(err.Error(), "module missing not found, required by app") {
+		t.Errorf("Expected missing module error, got %v", err)
+	}
+
+	if _, err := finder.Resolve([]string{"nope"}); !errors.Is(err, core.ErrModuleNotFound) {
+		t.Errorf("Expected missing root module error, got %v", err)
+	}
+}
+
+func TestShouldFailToResolveSplitPackages(t *testing.T) {
+	dir := t.TempDir()
+	writeExplodedModule(t, filepath.Join(dir, "a"), moduleInfoClassFile("a", []testRequires{{"b", 0}}, nil, ""), "shared/A")
+	writeExplodedModule(t, filepath.Join(dir, "b"), moduleInfoClassFile("b", nil, nil, ""), "shared/B")
+
+	modulePath, _ := core.ParseModulePath(dir, core.DefaultRelease)
+	defer modulePath.Close()
+
+	finder, _ := core.NewModuleFinder(modulePath.Entries, nil)
+
+	_, err := finder.Resolve([]string{"a"})
+	if !errors.Is(err, core.ErrSplitPackage) || !strings.Contains(err.Error(), "package shared in both module a and module b") {
+		t.Errorf("Expected split package error, got %v", err)
+	}
+}
+
+func TestShouldFailToResolveCyclicModules(t *testing.T) {
+	dir := t.TempDir()
+	writeExplodedModule(t, filepath.Join(dir, "a"), moduleInfoClassFile("a", []testRequires{{"b", 0}}, nil, ""), "a/A")
+	writeExplodedModule(t, filepath.Join(dir, "b"), moduleInfoClassFile("b", []testRequires{{"c", 0}}, nil, ""), "b/B")
+	writeExplodedModule(t, filepath.Join(dir, "c"), moduleInfoClassFile("c", []testRequires{{"b", 0}}, nil, ""), "c/C")
+
+	modulePath, _ := core.ParseModulePath(dir, core.DefaultRelease)
+	defer modulePath.Close()
+
+	finder, _ := core.NewModuleFinder(modulePath.Entries, nil)
+
+	_, err := finder.Resolve([]string{"a"})
+	if !errors.Is(err, core.ErrModuleCycle) || !strings.Contains(err.Error(), "b -> c -> b") {
+		t.Errorf("Expected cycle error, got %v", err)
+	}
+}
+
+func TestShouldNotLetTheModulePathReplacePlatformModules(t *testing.T) {
+	dir := t.TempDir()
+	writeExplodedModule(t, filepath.Join(dir, "base"), moduleInfoClassFile(core.JavaBaseModule, nil, nil, ""), "java/lang/Fake")
+
+	modulePath, _ := core.ParseModulePath(dir, core.DefaultRelease)
+	defer modulePath.Close()
+
+	finder, _ := core.NewModuleFinder(modulePath.Entries, nil)
+
+	config, err := finder.Resolve([]string{core.JavaBaseModule})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if entries := config.Entries(); len(entries) != 0 {
+		t.Errorf("Expected the platform java.base to be resolved, got %v", entries)
+	}
+}
+
+func TestShouldNameAutomaticModules(t *testing.T) {
+	dir := t.TempDir()
+	writeJarFile(t, filepath.Join(dir, "commons-lang3-3.12.0.jar"), map[string][]byte{
+		"org/apache/commons/lang3/StringUtils.class": minimalClassFile("org/apache/commons/lang3/StringUtils"),
+	})
+	writeJarFile(t, filepath.Join(dir, "whatever.jar"), map[string][]byte{
+		"META-INF/MANIFEST.MF": []byte("Manifest-Version: 1.0\nAutomatic-Module-Name: com.acme.named\n"),
+	})
+
+	modulePath, _ := core.ParseModulePath(dir, core.DefaultRelease)
+	defer modulePath.Close()
+
+	finder, err := core.NewModuleFinder(modulePath.Entries, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	names := finder.Names()
+	if len(names) != 2 || names[0] != "commons.lang3" || names[1] != "com.acme.named" {
+		t.Errorf("Expected the automatic module names, got %v", names)
+	}
+
+	module, _ := finder.Find("commons.lang3")
+	if !module.Descriptor.Automatic || len(module.Descriptor.Packages) != 1 {
+		t.Errorf("Expected an automatic module with one package, got %+v", module.Descriptor)
+	}
+}
+
+func TestShouldLaunchTheMainClassOfAModule(t *testing.T) {
+	dir := t.TempDir()
+	writeExplodedModule(t, filepath.Join(dir, "app"), moduleInfoClassFile("app", nil, nil, "app/Main"), "app/Main", "app/Other")
+	writeExplodedModule(t, filepath.Join(dir, "nomain"), moduleInfoClassFile("nomain", nil, nil, ""), "nomain/Main")
+
+	if err := core.RunJBM([]string{"--module-path", dir, "-m", "app"}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if err := core.RunJBM([]string{"--module-path", dir, "--module", "app/app.Other"}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	err := core.RunJBM([]string{"--module-path", dir, "-m", "nomain"})
+	if err == nil || !strings.Contains(err.Error(), "does not have a ModuleMainClass attribute") {
+		t.Errorf("Expected missing main class error, got %v", err)
+	}
+
+	// modules of the module path are only visible once they are resolved
+	classPath := t.TempDir()
+	if err := core.RunJBM([]string{"--module-path", dir, "-cp", classPath, "app.Main"}); err == nil {
+		t.Errorf("Expected app.Main not to be found without --add-modules")
+	}
+
+	if err := core.RunJBM([]string{"--module-path", dir, "--add-modules", "app", "-cp", classPath, "app.Main"}); err != nil {
+		t.Errorf("Expected app.Main to be found with --add-modules, got %v", err)
+	}
+}
+
+// callerClassFile builds a main class whose main method calls the main method of the given class.
+func callerClassFile(name string, callee string) []byte {
+	b := newTestClassBuilder()
+	ref := b.methodref(callee, "main", "([Ljava/lang/String;)V")
+	main := b.member(core.ACC_PUBLIC|core.ACC_STATIC, "main", "([Ljava/lang/String;)V", b.code(1, 1,
+		core.OP_ALOAD_0, core.OP_INVOKESTATIC, byte(ref>>8), byte(ref), core.OP_RETURN,
+	))
+
+	return b.build(core.ACC_PUBLIC|core.ACC_SUPER, name, "java/lang/Object", nil, nil, [][]byte{main}, nil)
+}
+
+func TestShouldCheckTheAccessesBetweenModules(t *testing.T) {
+	dir, classPath := t.TempDir(), t.TempDir()
+
+	app := filepath.Join(dir, "app")
+	writeExplodedModule(t, app, moduleInfoClassFile("app", []testRequires{{"lib", 0}}, nil, ""))
+	for caller, callee := range map[string]string{
+		"app/Api": "lib/api/Api", "app/Internal": "lib/internal/Secret", "app/Friend": "lib/friend/Friend",
+		"app/Other": "other/Other", "app/ClassPath": "cp/Helper",
+	} {
+		writeClassFile(t, app, caller, callerClassFile(caller, callee))
+	}
+
+	writeExplodedModule(t, filepath.Join(dir, "lib"), qualifiedModuleInfoClassFile("lib", nil,
+		[]core.ModuleExports{{Package: "lib/api"}, {Package: "lib/friend", To: []string{"app"}}}, "",
+	), "lib/api/Api", "lib/internal/Secret", "lib/friend/Friend")
+	writeExplodedModule(t, filepath.Join(dir, "other"), moduleInfoClassFile("other", nil, []string{"other"}, ""), "other/Other")
+
+	writeClassFile(t, classPath, "cp/Helper", mainClassFile("cp/Helper"))
+	writeClassFile(t, classPath, "cp/Api", callerClassFile("cp/Api", "lib/api/Api"))
+	writeClassFile(t, classPath, "cp/Friend", callerClassFile("cp/Friend", "lib/friend/Friend"))
+
+	tests := []struct {
+		main    string
+		message string
+	}{
+		{main: "app/app.Api"},
+		{main: "app/app.Friend"},
+		{main: "app/app.Internal", message: "class app.Internal (in module app) cannot access class lib.internal.Secret (in module lib) because module lib does not export lib.internal to module app"},
+		{main: "app/app.Other", message: "class app.Other (in module app) cannot access class other.Other (in module other) because module app does not read module other"},
+		{main: "app/app.ClassPath", message: "class app.ClassPath (in module app) cannot access class cp.Helper (in unnamed module) because module app does not read unnamed module"},
+	}
+
+	for _, tt := range tests {
+		err := core.RunJBM([]string{"--module-path", dir, "--add-modules", "other", "-cp", classPath, "-m", tt.main})
+		if tt.message == "" {
+			if err != nil {
+				t.Errorf("Expected %s to run, got %v", tt.main, err)
+			}
+
+			continue
+		}
+
+		if !core.IsJavaError(err, core.IllegalAccessError) || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("Expected an IllegalAccessError '%s' running %s, got %v", tt.message, tt.main, err)
+		}
+	}
+
+	// the classes of the class path read every module, but only see the packages exported to everyone
+	if err := core.RunJBM([]string{"--module-path", dir, "--add-modules", "lib", "-cp", classPath, "cp.Api"}); err != nil {
+		t.Errorf("Expected cp.Api to run, got %v", err)
+	}
+
+	err := core.RunJBM([]string{"--module-path", dir, "--add-modules", "lib", "-cp", classPath, "cp.Friend"})
+	if message := "because module lib does not export lib.friend to unnamed module"; !core.IsJavaError(err, core.IllegalAccessError) || !strings.Contains(err.Error(), message) {
+		t.Errorf("Expected an IllegalAccessError '%s', got %v", message, err)
+	}
+}
+
+func TestShouldAllowModuleAccessFlags(t *testing.T) {
+	cf := core.ClassFile{AccessFlags: core.ACC_MODULE}
+	if err := cf.ValidateAccessFlags(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	cf.AccessFlags = core.ACC_MODULE | core.ACC_PUBLIC
+	if err := cf.ValidateAccessFlags(); err == nil || err.Error() != "module must not have any other flag set" {
+		t.Errorf("Expected 'module must not have any other flag set', got %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-2.html#jvms-2.11.10
+
+// monitor is the lock of an object, held by one thread at a time, which can enter it again while it holds it.
+// Threads holding it can wait until other threads notify them, see `Object.wait`.
+type monitor struct {
+	mu sync.Mutex
+	// released is signaled when the monitor is released, to wake up the threads waiting to enter it.
+	released *sync.Cond
+	owner    *Thread
+	// count is the number of times the owner entered the monitor.
+	count int
+	// waiters are the channels of the threads waiting to be notified, in the order they started waiting.
+	waiters []chan struct{}
+}
+
+// monitorsMu guards the creation of the monitors of objects.
+var monitorsMu sync.Mutex
+
+// monitor returns the monitor of the object, creating it the first time.
+func (o *Object) monitor() *monitor {
+	monitorsMu.Lock()
+	defer monitorsMu.Unlock()
+
+	if o.lock == nil {
+		o.lock = &monitor{}
+		o.lock.released = sync.NewCond(&o.lock.mu)
+	}
+
+	return o.lock
+}
+
+// enter blocks until the thread owns the monitor.
+func (m *monitor) enter(t *Thread) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.acquire(t, 1)
+}
+
+// acquire waits for the monitor to be free and takes it, m.mu is held.
+func (m *monitor) acquire(t *Thread, count int) {
+	for m.owner != nil && m.owner != t {
+		m.released.Wait()
+	}
+
+	m.owner = t
+	m.count += count
+}
+
+// exit releases the monitor once, it is free when the owner exits it as many times as it entered it.
+func (m *monitor) exit(t *Thread) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.owner != t {
+		return &JavaError{ClassName: IllegalMonitorStateException}
+	}
+
+	if m.count--; m.count == 0 {
+		m.owner = nil
+		m.released.Broadcast()
+	}
+
+	return nil
+}
+
+// wait releases the monitor until another thread notifies the thread or the timeout expires, when it is
+// positive, and then enters it again as many times as it had entered it.
+func (m *monitor) wait(t *Thread, timeout time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.owner != t {
+		return NewJavaError(IllegalMonitorStateException, "current thread is not owner")
+	}
+
+	notified := make(chan struct{})
+	m.waiters = append(m.waiters, notified)
+
+	count := m.count
+	m.owner, m.count = nil, 0
+	m.released.Broadcast()
+	m.mu.Unlock()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		expired = timer.C
+	}
+
+	select {
+	case <-notified:
+	case <-expired:
+	}
+
+	m.mu.Lock()
+	m.removeWaiter(notified)
+	m.acquire(t, count)
+
+	return nil
+}
+
+// notify wakes up the thread that has been waiting the longest, or every waiting thread, the thread must own
+// the monitor.
+func (m *monitor) notify(t *Thread, all bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.owner != t {
+		return NewJavaError(IllegalMonitorStateException, "current thread is not owner")
+	}
+
+	for len(m.waiters) > 0 {
+		close(m.waiters[0])
+		m.waiters = m.waiters[1:]
+
+		if !all {
+			break
+		}
+	}
+
+	return nil
+}
+
+// removeWaiter removes the channel of a thread that is done waiting, when it was not notified.
+func (m *monitor) removeWaiter(notified chan struct{}) {
+	for i, waiter := range m.waiters {
+		if waiter == notified {
+			m.waiters = append(m.waiters[:i], m.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// holdsLock tells if the thread owns the monitor of the object.
+func (o *Object) holdsLock(t *Thread) bool {
+	m := o.monitor()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.owner == t
+}
+
+// monitorEnter enters the monitor of the object of a monitorenter instruction, or of a synchronized method.
+func (t *Thread) monitorEnter(obj *Object) error {
+	if obj == nil {
+		return &JavaError{ClassName: NullPointerException}
+	}
+
+	obj.monitor().enter(t)
+
+	return nil
+}
+
+// monitorExit exits the monitor of the object of a monitorexit instruction, or of a synchronized method.
+func (t *Thread) monitorExit(obj *Object) error {
+	if obj == nil {
+		return &JavaError{ClassName: NullPointerException}
+	}
+
+	return obj.monitor().exit(t)
+}
+
+// synchronizedObject returns the object whose monitor a synchronized method holds while it runs, the object
+// it is invoked on, or the java/lang/Class of its class for static methods.
+func synchronizedObject(method *Method, args []Slot) (*Object, error) {
+	if method.IsStatic() {
+		return method.Class.Mirror()
+	}
+
+	return args[0].Ref, nil
+}
+
+// objectNatives are the native methods of java/lang/Object of the JDK that the built-in class library does not
+// have, the others are registered with the built-in java/lang/Object.
+var objectNatives = map[string]NativeMethod{
+	"wait0(J)V": objectWait,
+}
+
+// objectWait waits on the monitor of the object for the given number of milliseconds, or until it is
+// notified when it is 0.
+func objectWait(call *NativeCall) ([]Slot, error) {
+	timeout := call.Long(0)
+	if timeout < 0 {
+		return nil, NewJavaError(IllegalArgumentException, "timeout value is negative")
+	}
+
+	return nil, call.This().monitor().wait(call.Thread, time.Duration(timeout)*time.Millisecond)
+}
+
+// objectNotify returns the native method of `Object.notify`, or of `Object.notifyAll`.
+func objectNotify(all bool) NativeMethod {
+	return func(call *NativeCall) ([]Slot, error) {
+		return nil, call.This().monitor().notify(call.Thread, all)
+	}
+}
@@ -0,0 +1,95 @@
+package core_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+func TestShouldRunSynchronizedMethodsOneThreadAtATime(t *testing.T) {
+	b := newTestClassBuilder()
+	count := b.fieldref("Counter", "count", "I")
+
+	class := defineTestClass(t, b.build(core.ACC_PUBLIC, "Counter", "java/lang/Object", nil,
+		[][]byte{b.member(core.ACC_STATIC, "count", "I")},
+		[][]byte{
+			b.member(core.ACC_PUBLIC|core.ACC_STATIC|core.ACC_SYNCHRONIZED, "increment", "()V", b.code(2, 0, concat(
+				[]byte{core.OP_GETSTATIC}, u2(count), []byte{core.OP_ICONST_1, core.OP_IADD, core.OP_PUTSTATIC}, u2(count),
+				[]byte{core.OP_RETURN},
+			)...)),
+			b.member(core.ACC_PUBLIC|core.ACC_STATIC, "get", "()I", b.code(1, 0, concat(
+				[]byte{core.OP_GETSTATIC}, u2(count), []byte{core.OP_IRETURN},
+			)...)),
+		},
+		nil,
+	))
+
+	if err := class.Initialize(core.NewThread(core.MainThreadName)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	increment := class.DeclaredMethod("increment", "()V")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			thread := core.NewThread("counter")
+			for j := 0; j < 500; j++ {
+				if _, err := thread.Invoke(increment, nil); err != nil {
+					t.Errorf("Expected no error, got %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if n, err := invokeInt(t, class, "get", "()I"); err != nil || n != 4000 {
+		t.Errorf("Expected 4000 increments, got %d (%v)", n, err)
+	}
+}
+
+func TestShouldThrowWhenExitingAMonitorThatIsNotOwned(t *testing.T) {
+	b := newTestClassBuilder()
+
+	class := defineTestClass(t, b.build(core.ACC_PUBLIC, "Monitors", "java/lang/Object", nil, nil,
+		[][]byte{
+			b.member(core.ACC_PUBLIC|core.ACC_STATIC, "exit", "(Ljava/lang/Object;)V", b.code(1, 1,
+				core.OP_ALOAD_0, core.OP_MONITOREXIT, core.OP_RETURN,
+			)),
+			b.member(core.ACC_PUBLIC|core.ACC_STATIC, "enterTwice", "(Ljava/lang/Object;)V", b.code(1, 1,
+				core.OP_ALOAD_0, core.OP_MONITORENTER, core.OP_ALOAD_0, core.OP_MONITORENTER,
+				core.OP_ALOAD_0, core.OP_MONITOREXIT, core.OP_ALOAD_0, core.OP_MONITOREXIT, core.OP_RETURN,
+			)),
+			b.member(core.ACC_PUBLIC|core.ACC_STATIC, "enterNull", "()V", b.code(1, 0,
+				core.OP_ACONST_NULL, core.OP_MONITORENTER, core.OP_RETURN,
+			)),
+		},
+		nil,
+	))
+
+	obj := core.NewObject(class)
+
+	if _, err := invokeSlots(t, class, "exit", "(Ljava/lang/Object;)V", []core.Slot{{Ref: obj}}); !core.IsJavaError(err, core.IllegalMonitorStateException) {
+		t.Errorf("Expected an IllegalMonitorStateException, got %v", err)
+	}
+
+	// the monitor is reentrant, and free once exited as many times as entered
+	if _, err := invokeSlots(t, class, "enterTwice", "(Ljava/lang/Object;)V", []core.Slot{{Ref: obj}}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	if _, err := invokeSlots(t, class, "enterTwice", "(Ljava/lang/Object;)V", []core.Slot{{Ref: obj}}); err != nil {
+		t.Errorf("Expected the monitor to be free, got %v", err)
+	}
+
+	if _, err := invokeSlots(t, class, "enterNull", "()V"); !core.IsJavaError(err, core.NullPointerException) {
+		t.Errorf("Expected a NullPointerException, got %v", err)
+	}
+}
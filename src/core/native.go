@@ -0,0 +1,195 @@
+package core
+
+import "sync"
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-5.html#jvms-5.6
+
+// NativeMethod is the Go implementation of a native method. It returns the slots of the value the method
+// returns, none for void methods, or the error the method throws.
+type NativeMethod func(call *NativeCall) ([]Slot, error)
+
+// NativeCall is an invocation of a native method, it gives the implementation the thread that invokes it and
+// typed access to its arguments.
+type NativeCall struct {
+	// Thread is the thread invoking the method.
+	Thread *Thread
+	// Method is the native method.
+	Method *Method
+	// Args are the slots of the arguments, `this` first for instance methods.
+	Args []Slot
+
+	// offsets are the indexes of the first slot of each parameter in Args, computed on first use.
+	offsets []int
+}
+
+// This returns the object the instance method is invoked on.
+func (c *NativeCall) This() *Object {
+	return c.Args[0].Ref
+}
+
+// param returns the slots of the parameter at the given index, starting from the first one after `this`.
+func (c *NativeCall) param(i int) []Slot {
+	if c.offsets == nil {
+		// the descriptor was checked when the class was loaded
+		params, _, _ := ParseMethodDescriptor(c.Method.Descriptor)
+
+		at := 0
+		if !c.Method.IsStatic() {
+			at = 1
+		}
+
+		c.offsets = make([]int, len(params))
+		for j, param := range params {
+			c.offsets[j] = at
+			at += DescriptorSlots(param)
+		}
+	}
+
+	return c.Args[c.offsets[i]:]
+}
+
+// Int returns the parameter at the given index as an int, which is also how booleans, bytes, chars and
+// shorts are passed.
+func (c *NativeCall) Int(i int) int32 {
+	return c.param(i)[0].Num
+}
+
+// Bool returns the boolean parameter at the given index.
+func (c *NativeCall) Bool(i int) bool {
+	return c.param(i)[0].Num != 0
+}
+
+// Long returns the long parameter at the given index.
+func (c *NativeCall) Long(i int) int64 {
+	return SlotsLong(c.param(i))
+}
+
+// Float returns the float parameter at the given index.
+func (c *NativeCall) Float(i int) float32 {
+	return SlotFloat(c.param(i)[0])
+}
+
+// Double returns the double parameter at the given index.
+func (c *NativeCall) Double(i int) float64 {
+	return SlotsDouble(c.param(i))
+}
+
+// Ref returns the reference parameter at the given index.
+func (c *NativeCall) Ref(i int) *Object {
+	return c.param(i)[0].Ref
+}
+
+// String returns the contents of the java/lang/String parameter at the given index, see `GoString`. A null
+// string is empty.
+func (c *NativeCall) String(i int) string {
+	if str := c.Ref(i); str != nil {
+		return GoString(str)
+	}
+
+	return ""
+}
+
+// NewString allocates a java/lang/String with the contents of a Go string, with the java/lang/String of the
+// loader of the class of the method, see `NewString`.
+func (c *NativeCall) NewString(s string) (*Object, error) {
+	return NewString(c.Method.Class.Loader, s)
+}
+
+// returnString returns a new java/lang/String with the given chars as the value of the native method.
+func (c *NativeCall) returnString(chars []uint16) ([]Slot, error) {
+	str, err := newString(c.Method.Class.Loader, chars)
+	if err != nil {
+		return nil, err
+	}
+
+	return []Slot{{Ref: str}}, nil
+}
+
+// nativeRegistry holds the native methods implemented in Go, matched by the class, name and descriptor of
+// the method.
+type nativeRegistry struct {
+	mu      sync.RWMutex
+	methods map[string]NativeMethod
+}
+
+// natives are the native methods of the runtime and the ones registered by embedders with `RegisterNative`.
+var natives = &nativeRegistry{methods: make(map[string]NativeMethod)}
+
+func init() {
+	for method, native := range throwableNatives {
+		natives.methods[Throwable+"."+method] = native
+	}
+
+	for method, native := range objectNatives {
+		natives.methods[objectClass+"."+method] = native
+	}
+
+	for method, native := range classNatives {
+		natives.methods[classClass+"."+method] = native
+	}
+
+	for method, native := range threadNatives {
+		natives.methods[threadClass+"."+method] = native
+	}
+
+	for method, native := range moduleNatives {
+		natives.methods[moduleClass+"."+method] = native
+	}
+
+	for method, native := range unsafeNatives() {
+		natives.methods[unsafeClass+"."+method] = native
+	}
+
+	for class, methods := range bootNatives {
+		for method, native := range methods {
+			natives.methods[class+"."+method] = native
+		}
+	}
+
+	natives.methods["java/lang/AssertionError.init(Ljava/lang/Object;)V"] = assertionErrorInit
+}
+
+// RegisterNative registers the Go implementation of the native method of the class, given by its internal
+// name, with the given name and descriptor. It replaces the one already registered, even if the runtime
+// implements it, and returns it so it can be restored; registering nil removes the implementation.
+//
+// Native methods are looked up every time they are invoked, the new implementation is used from the next
+// invocation on.
+func RegisterNative(class string, name string, descriptor string, native NativeMethod) NativeMethod {
+	key := class + "." + name + descriptor
+
+	natives.mu.Lock()
+	defer natives.mu.Unlock()
+
+	previous := natives.methods[key]
+	if native == nil {
+		delete(natives.methods, key)
+	} else {
+		natives.methods[key] = native
+	}
+
+	return previous
+}
+
+// LookupNative returns the Go implementation of a native method, or nil when there is none.
+func LookupNative(class string, name string, descriptor string) NativeMethod {
+	natives.mu.RLock()
+	defer natives.mu.RUnlock()
+
+	return natives.methods[class+"."+name+descriptor]
+}
+
+// invokeNative invokes the Go implementation of a native method, the one of the classes the runtime spins or
+// the registered one, throwing an UnsatisfiedLinkError when there is none.
+func (t *Thread) invokeNative(method *Method, args []Slot) ([]Slot, error) {
+	native := method.native
+	if native == nil {
+		native = LookupNative(method.Class.Name, method.Name, method.Descriptor)
+	}
+
+	if native == nil {
+		return nil, NewJavaError(UnsatisfiedLinkError, "'%s'", method)
+	}
+
+	return native(&NativeCall{Thread: t, Method: method, Args: args})
+}
@@ -0,0 +1,126 @@
+package core_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// writeNativesClass writes Natives, whose native methods are registered by the tests.
+func writeNativesClass(t *testing.T, dir string) {
+	b := newTestClassBuilder()
+	public, static := core.ACC_PUBLIC|core.ACC_NATIVE, core.ACC_PUBLIC|core.ACC_STATIC|core.ACC_NATIVE
+
+	writeClassFile(t, dir, "Natives", b.build(core.ACC_PUBLIC, "Natives", "java/lang/Object", nil, nil,
+		[][]byte{
+			b.member(static, "mix", "(IJFDZLjava/lang/String;)Ljava/lang/String;"),
+			b.member(public, "self", "(C)LNatives;"),
+			b.member(static, "twice", "(I)I"),
+			b.member(static, "hello", "()Ljava/lang/String;"),
+			b.member(static, "missing", "()V"),
+			b.member(core.ACC_PUBLIC|core.ACC_STATIC, "run", "()I", b.code(1, 0, concat(
+				[]byte{core.OP_BIPUSH, 21, core.OP_INVOKESTATIC}, u2(b.methodref("Natives", "twice", "(I)I")), []byte{core.OP_IRETURN},
+			)...)),
+		},
+		nil,
+	))
+}
+
+// registerNative registers a native method for the test, removing it once the test finishes.
+func registerNative(t *testing.T, class string, name string, descriptor string, native core.NativeMethod) {
+	previous := core.RegisterNative(class, name, descriptor, native)
+	t.Cleanup(func() { core.RegisterNative(class, name, descriptor, previous) })
+}
+
+func TestShouldInvokeTheRegisteredNativeMethods(t *testing.T) {
+	dir := t.TempDir()
+	writeNativesClass(t, dir)
+
+	natives, err := newTestLoaders(t, dir).Application.LoadClass("Natives")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	registerNative(t, "Natives", "mix", "(IJFDZLjava/lang/String;)Ljava/lang/String;", func(call *core.NativeCall) ([]core.Slot, error) {
+		str, err := call.NewString(fmt.Sprintf("%d %d %g %g %t %s", call.Int(0), call.Long(1), call.Float(2), call.Double(3), call.Bool(4), call.String(5)))
+		if err != nil {
+			return nil, err
+		}
+
+		return []core.Slot{{Ref: str}}, nil
+	})
+
+	registerNative(t, "Natives", "self", "(C)LNatives;", func(call *core.NativeCall) ([]core.Slot, error) {
+		if call.Int(0) != 'x' {
+			return nil, core.NewJavaError(core.IllegalArgumentException, "%c", call.Int(0))
+		}
+
+		return []core.Slot{{Ref: call.This()}}, nil
+	})
+
+	registerNative(t, "Natives", "twice", "(I)I", func(call *core.NativeCall) ([]core.Slot, error) {
+		return []core.Slot{{Num: call.Int(0) * 2}}, nil
+	})
+
+	registerNative(t, "Natives", "hello", "()Ljava/lang/String;", func(call *core.NativeCall) ([]core.Slot, error) {
+		str, err := call.NewString("hello")
+		return []core.Slot{{Ref: str}}, err
+	})
+
+	hello, err := invokeSlots(t, natives, "hello", "()Ljava/lang/String;")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	mixed, err := invokeSlots(t, natives, "mix", "(IJFDZLjava/lang/String;)Ljava/lang/String;",
+		[]core.Slot{{Num: -3}}, core.LongSlots(1<<40), []core.Slot{core.FloatSlot(1.5)}, core.DoubleSlots(0.25), []core.Slot{{Num: 1}, hello[0]},
+	)
+	if err != nil || core.GoString(mixed[0].Ref) != "-3 1099511627776 1.5 0.25 true hello" {
+		t.Errorf("Expected the native method to get its typed arguments, got %v (%v)", mixed, err)
+	}
+
+	obj := core.NewObject(natives)
+	if self, err := invokeSlots(t, natives, "self", "(C)LNatives;", []core.Slot{{Ref: obj}, {Num: 'x'}}); err != nil || self[0].Ref != obj {
+		t.Errorf("Expected the native method to get this, got %v (%v)", self, err)
+	}
+
+	if twice, err := invokeInt(t, natives, "run", "()I"); err != nil || twice != 42 {
+		t.Errorf("Expected the interpreter to invoke the native method, got %d (%v)", twice, err)
+	}
+
+	_, err = invokeSlots(t, natives, "missing", "()V")
+	if !core.IsJavaError(err, core.UnsatisfiedLinkError) || err.Error() != "java.lang.UnsatisfiedLinkError: 'void Natives.missing()'" {
+		t.Errorf("Expected an UnsatisfiedLinkError, got %v", err)
+	}
+}
+
+func TestShouldLetEmbeddersOverrideTheNativeMethodsOfTheRuntime(t *testing.T) {
+	loaders := newTestLoaders(t, t.TempDir())
+
+	throwable, err := loaders.Bootstrap.LoadClass(core.Throwable)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	obj := core.NewObject(throwable)
+
+	registerNative(t, core.Throwable, "getCause", "()Ljava/lang/Throwable;", func(call *core.NativeCall) ([]core.Slot, error) {
+		return []core.Slot{{Ref: call.This()}}, nil
+	})
+
+	if cause, err := invokeSlots(t, throwable, "getCause", "()Ljava/lang/Throwable;", []core.Slot{{Ref: obj}}); err != nil || cause[0].Ref != obj {
+		t.Fatalf("Expected the registered native method to be invoked, got %v (%v)", cause, err)
+	}
+
+	// registering nil removes the native method
+	builtin := core.RegisterNative(core.Throwable, "getCause", "()Ljava/lang/Throwable;", nil)
+	if _, err := invokeSlots(t, throwable, "getCause", "()Ljava/lang/Throwable;", []core.Slot{{Ref: obj}}); !core.IsJavaError(err, core.UnsatisfiedLinkError) {
+		t.Errorf("Expected an UnsatisfiedLinkError, got %v", err)
+	}
+
+	core.RegisterNative(core.Throwable, "getCause", "()Ljava/lang/Throwable;", builtin)
+	if core.LookupNative(core.Throwable, "getCause", "()Ljava/lang/Throwable;") == nil {
+		t.Errorf("Expected the native method to be registered again")
+	}
+}
@@ -0,0 +1,687 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Spec: https://openjdk.org/jeps/358
+
+// maxNullCauseDetail is how many nested expressions are described in the cause of a NullPointerException.
+const maxNullCauseDetail = 5
+
+// nullPointerMessage returns the message of a NullPointerException raised by the instruction at the pc of the
+// method, describing the action that failed and the expression that is null, e.g.
+//
+//	Cannot invoke "String.length()" because "<local1>" is null
+//
+// Like the reference implementation, the expression is found by simulating the operand stack of the method,
+// and locals are named after the LocalVariableTable when the method has one. It returns an empty string when
+// the instruction does not dereference a value of the operand stack.
+func nullPointerMessage(method *Method, pc int) string {
+	if method.Code == nil || pc >= len(method.Code.Bytecode) {
+		return ""
+	}
+
+	a := &nullPointerAnalysis{method: method, code: method.Code.Bytecode}
+
+	slot := a.nullSlot(pc)
+	if slot < 0 {
+		return ""
+	}
+
+	action := a.failedAction(pc)
+	if action == "" || !a.analyze() {
+		return action
+	}
+
+	return action + a.cause(pc, slot)
+}
+
+// nullPointerAnalysis simulates the operand stack of a method to find the instructions that pushed its values.
+type nullPointerAnalysis struct {
+	method *Method
+	code   []byte
+	// stacks are the operand stacks before the instructions, nil for the pcs that are not reached.
+	stacks []*simulatedStack
+}
+
+// simulatedStack is the operand stack before an instruction, as seen by the analysis.
+type simulatedStack struct {
+	// sources are the pcs of the instructions that pushed the slots of the stack, -1 when the slot is pushed
+	// by different instructions depending on the path taken.
+	sources []int
+	// written tells which locals were stored to on some path, they no longer hold the parameters.
+	written []bool
+}
+
+func (s *simulatedStack) clone() *simulatedStack {
+	return &simulatedStack{sources: append([]int(nil), s.sources...), written: append([]bool(nil), s.written...)}
+}
+
+// pop removes slots from the stack, it fails when the stack has fewer.
+func (s *simulatedStack) pop(slots int) bool {
+	if slots > len(s.sources) {
+		return false
+	}
+
+	s.sources = s.sources[:len(s.sources)-slots]
+
+	return true
+}
+
+func (s *simulatedStack) push(slots int, pc int) {
+	for i := 0; i < slots; i++ {
+		s.sources = append(s.sources, pc)
+	}
+}
+
+// write marks locals as stored to, it fails when the method does not have them.
+func (s *simulatedStack) write(index int, slots int) bool {
+	if index+slots > len(s.written) {
+		return false
+	}
+
+	for i := index; i < index+slots; i++ {
+		s.written[i] = true
+	}
+
+	return true
+}
+
+// merge merges the stack of another path to the same instruction, and tells if it changed. The stacks must
+// have the same depth.
+func (s *simulatedStack) merge(other *simulatedStack) (changed bool, ok bool) {
+	if len(s.sources) != len(other.sources) {
+		return false, false
+	}
+
+	for i, source := range other.sources {
+		if s.sources[i] != source && s.sources[i] != -1 {
+			s.sources[i], changed = -1, true
+		}
+	}
+
+	for i, written := range other.written {
+		if written && !s.written[i] {
+			s.written[i], changed = true, true
+		}
+	}
+
+	return changed, true
+}
+
+// analyze simulates every path of the method, from its start and from its exception handlers, whose stack
+// only has the exception and whose locals are the ones written in their protected range. It fails when the bytecode is not valid or uses subroutines.
+func (a *nullPointerAnalysis) analyze() bool {
+	a.stacks = make([]*simulatedStack, len(a.code))
+
+	var pending []int
+	enter := func(pc int, stack *simulatedStack) bool {
+		if pc < 0 || pc >= len(a.code) {
+			return false
+		}
+
+		if a.stacks[pc] == nil {
+			a.stacks[pc] = stack.clone()
+			pending = append(pending, pc)
+
+			return true
+		}
+
+		changed, ok := a.stacks[pc].merge(stack)
+		if changed {
+			pending = append(pending, pc)
+		}
+
+		return ok
+	}
+
+	locals := int(a.method.Code.MaxLocals)
+	enter(0, &simulatedStack{written: make([]bool, locals)})
+
+	for _, handler := range a.method.Code.ExceptionTable {
+		if a.stacks[handler.HandlerPC] == nil {
+			enter(int(handler.HandlerPC), &simulatedStack{sources: []int{int(handler.HandlerPC)}, written: make([]bool, locals)})
+		}
+	}
+
+	for len(pending) > 0 {
+		pc := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		stack := a.stacks[pc].clone()
+
+		next, ok := a.simulate(pc, stack)
+		if !ok {
+			return false
+		}
+
+		for _, target := range next {
+			if !enter(target, stack) {
+				return false
+			}
+		}
+
+		// the handlers of the instruction can be entered after it wrote to the locals
+		for _, handler := range a.method.Code.ExceptionTable {
+			if pc >= int(handler.StartPC) && pc < int(handler.EndPC) {
+				entry := &simulatedStack{sources: []int{int(handler.HandlerPC)}, written: stack.written}
+				if !enter(int(handler.HandlerPC), entry) {
+					return false
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+// simulate applies the instruction at pc to the stack, and returns the pcs of the instructions executed next.
+func (a *nullPointerAnalysis) simulate(pc int, stack *simulatedStack) ([]int, bool) {
+	code := a.code
+
+	length := instructionLength(code, pc)
+	if length == 0 {
+		return nil, false
+	}
+
+	next := []int{pc + length}
+	pops, pushes := 0, 0
+
+	switch op := code[pc]; {
+	case op == OP_NOP || op == OP_CHECKCAST:
+	case op >= OP_ACONST_NULL && op <= OP_LDC2_W:
+		pushes = 1
+		if op == OP_LCONST_0 || op == OP_LCONST_1 || op == OP_DCONST_0 || op == OP_DCONST_1 || op == OP_LDC2_W {
+			pushes = 2
+		}
+	case op >= OP_ILOAD && op <= OP_ALOAD:
+		pushes = valueSlots(op - OP_ILOAD)
+	case op >= OP_ILOAD_0 && op <= OP_ALOAD_3:
+		pushes = valueSlots((op - OP_ILOAD_0) / 4)
+	case op >= OP_IALOAD && op <= OP_SALOAD:
+		pops, pushes = 2, valueSlots(op-OP_IALOAD)
+	case op >= OP_ISTORE && op <= OP_ASTORE:
+		pops = valueSlots(op - OP_ISTORE)
+		if !stack.write(int(code[pc+1]), pops) {
+			return nil, false
+		}
+	case op >= OP_ISTORE_0 && op <= OP_ASTORE_3:
+		pops = valueSlots((op - OP_ISTORE_0) / 4)
+		if !stack.write(int(op-OP_ISTORE_0)%4, pops) {
+			return nil, false
+		}
+	case op >= OP_IASTORE && op <= OP_SASTORE:
+		pops = 2 + valueSlots(op-OP_IASTORE)
+	case op == OP_POP || op == OP_POP2:
+		pops = int(op-OP_POP) + 1
+	case op >= OP_DUP && op <= OP_DUP2_X2:
+		// the number of slots copied and how deep the copy goes
+		copied, depth := 1+int(op-OP_DUP)/3, 1+int(op-OP_DUP)%3+int(op-OP_DUP)/3
+		if depth > len(stack.sources) {
+			return nil, false
+		}
+
+		at := len(stack.sources) - depth
+		top := append([]int(nil), stack.sources[len(stack.sources)-copied:]...)
+		stack.sources = append(stack.sources[:at], append(top, stack.sources[at:]...)...)
+	case op == OP_SWAP:
+		n := len(stack.sources)
+		if n < 2 {
+			return nil, false
+		}
+
+		stack.sources[n-1], stack.sources[n-2] = stack.sources[n-2], stack.sources[n-1]
+	case op >= OP_IADD && op <= OP_DREM, op >= OP_IAND && op <= OP_LXOR:
+		// the long and double operations alternate with the int and float ones
+		slots := valueSlots((op - OP_IADD) % 4)
+		pops, pushes = 2*slots, slots
+	case op >= OP_INEG && op <= OP_DNEG:
+		pops = valueSlots(op - OP_INEG)
+		pushes = pops
+	case op >= OP_ISHL && op <= OP_LUSHR:
+		pushes = valueSlots((op - OP_ISHL) % 2)
+		pops = pushes + 1
+	case op == OP_IINC:
+		if !stack.write(int(code[pc+1]), 1) {
+			return nil, false
+		}
+	case op >= OP_I2L && op <= OP_I2S:
+		conversion := conversionSlots[op-OP_I2L]
+		pops, pushes = conversion[0], conversion[1]
+	case op >= OP_LCMP && op <= OP_DCMPG:
+		pops, pushes = 4, 1
+		if op == OP_FCMPL || op == OP_FCMPG {
+			pops = 2
+		}
+	case op >= OP_IFEQ && op <= OP_IFLE, op == OP_IFNULL, op == OP_IFNONNULL:
+		pops = 1
+		next = append(next, pc+int(readS2(code, pc+1)))
+	case op >= OP_IF_ICMPEQ && op <= OP_IF_ACMPNE:
+		pops = 2
+		next = append(next, pc+int(readS2(code, pc+1)))
+	case op == OP_GOTO:
+		next = []int{pc + int(readS2(code, pc+1))}
+	case op == OP_GOTO_W:
+		next = []int{pc + int(readS4(code, pc+1))}
+	case op == OP_TABLESWITCH || op == OP_LOOKUPSWITCH:
+		pops = 1
+		next = switchTargets(code, pc)
+	case op >= OP_IRETURN && op <= OP_RETURN, op == OP_ATHROW:
+		return nil, true
+	case op >= OP_GETSTATIC && op <= OP_PUTFIELD:
+		_, _, descriptor, ok := a.member(pc)
+		if !ok {
+			return nil, false
+		}
+
+		switch op {
+		case OP_GETSTATIC:
+			pushes = DescriptorSlots(descriptor)
+		case OP_PUTSTATIC:
+			pops = DescriptorSlots(descriptor)
+		case OP_GETFIELD:
+			pops, pushes = 1, DescriptorSlots(descriptor)
+		case OP_PUTFIELD:
+			pops = 1 + DescriptorSlots(descriptor)
+		}
+	case op >= OP_INVOKEVIRTUAL && op <= OP_INVOKEDYNAMIC:
+		_, _, descriptor, ok := a.member(pc)
+		if !ok {
+			return nil, false
+		}
+
+		params, ret, err := ParseMethodDescriptor(descriptor)
+		if err != nil {
+			return nil, false
+		}
+
+		if op != OP_INVOKESTATIC && op != OP_INVOKEDYNAMIC {
+			pops = 1
+		}
+
+		for _, param := range params {
+			pops += DescriptorSlots(param)
+		}
+
+		pushes = DescriptorSlots(ret)
+	case op == OP_NEW:
+		pushes = 1
+	case op == OP_NEWARRAY || op == OP_ANEWARRAY || op == OP_ARRAYLENGTH || op == OP_INSTANCEOF:
+		pops, pushes = 1, 1
+	case op == OP_MONITORENTER || op == OP_MONITOREXIT:
+		pops = 1
+	case op == OP_WIDE:
+		index := int(readU2(code, pc+2))
+
+		switch modified := code[pc+1]; {
+		case modified >= OP_ILOAD && modified <= OP_ALOAD:
+			pushes = valueSlots(modified - OP_ILOAD)
+		case modified >= OP_ISTORE && modified <= OP_ASTORE:
+			pops = valueSlots(modified - OP_ISTORE)
+			if !stack.write(index, pops) {
+				return nil, false
+			}
+		case modified == OP_IINC:
+			if !stack.write(index, 1) {
+				return nil, false
+			}
+		default:
+			return nil, false
+		}
+	case op == OP_MULTIANEWARRAY:
+		pops, pushes = int(code[pc+3]), 1
+	default:
+		// jsr and ret are not supported, like in the reference implementation
+		return nil, false
+	}
+
+	if !stack.pop(pops) {
+		return nil, false
+	}
+
+	stack.push(pushes, pc)
+
+	return next, true
+}
+
+// valueSlots returns the slots of a value of the kind of an instruction, whose variants are ordered int, long,
+// float, double, reference, byte, char and short.
+func valueSlots(kind uint8) int {
+	if kind == 1 || kind == 3 {
+		return 2
+	}
+
+	return 1
+}
+
+// conversionSlots are the slots popped and pushed by the conversions, from i2l to i2s.
+var conversionSlots = [...][2]int{
+	{1, 2}, {1, 1}, {1, 2}, // i2l, i2f, i2d
+	{2, 1}, {2, 1}, {2, 2}, // l2i, l2f, l2d
+	{1, 1}, {1, 2}, {1, 2}, // f2i, f2l, f2d
+	{2, 1}, {2, 2}, {2, 1}, // d2i, d2l, d2f
+	{1, 1}, {1, 1}, {1, 1}, // i2b, i2c, i2s
+}
+
+// instructionLength returns the length of the instruction at pc, or 0 when it is not valid or goes past
+// the end of the code.
+func instructionLength(code []byte, pc int) int {
+	length := 1
+
+	switch op := code[pc]; {
+	case op == OP_BIPUSH || op == OP_LDC || op == OP_RET || op == OP_NEWARRAY,
+		op >= OP_ILOAD && op <= OP_ALOAD, op >= OP_ISTORE && op <= OP_ASTORE:
+		length = 2
+	case op == OP_SIPUSH || op == OP_LDC_W || op == OP_LDC2_W || op == OP_IINC || op == OP_GOTO || op == OP_JSR,
+		op >= OP_IFEQ && op <= OP_IF_ACMPNE, op >= OP_GETSTATIC && op <= OP_INVOKESTATIC,
+		op == OP_NEW || op == OP_ANEWARRAY || op == OP_CHECKCAST || op == OP_INSTANCEOF || op == OP_IFNULL || op == OP_IFNONNULL:
+		length = 3
+	case op == OP_MULTIANEWARRAY:
+		length = 4
+	case op == OP_INVOKEINTERFACE || op == OP_INVOKEDYNAMIC || op == OP_GOTO_W || op == OP_JSR_W:
+		length = 5
+	case op == OP_WIDE:
+		length = 4
+		if pc+1 < len(code) && code[pc+1] == OP_IINC {
+			length = 6
+		}
+	case op == OP_TABLESWITCH || op == OP_LOOKUPSWITCH:
+		at := (pc + 4) &^ 3
+		if at+12 > len(code) {
+			return 0
+		}
+
+		if op == OP_TABLESWITCH {
+			low, high := int(readS4(code, at+4)), int(readS4(code, at+8))
+			if low > high || high-low >= len(code) {
+				return 0
+			}
+
+			length = at + 12 + 4*(high-low+1) - pc
+		} else {
+			pairs := int(readS4(code, at+4))
+			if pairs < 0 || pairs >= len(code) {
+				return 0
+			}
+
+			length = at + 8 + 8*pairs - pc
+		}
+	case op > OP_JSR_W:
+		return 0
+	}
+
+	if pc+length > len(code) {
+		return 0
+	}
+
+	return length
+}
+
+// switchTargets returns the pcs a tableswitch or lookupswitch instruction at pc can branch to.
+func switchTargets(code []byte, pc int) []int {
+	at := (pc + 4) &^ 3
+	targets := []int{pc + int(readS4(code, at))}
+
+	if code[pc] == OP_TABLESWITCH {
+		for offset := at + 12; offset < pc+instructionLength(code, pc); offset += 4 {
+			targets = append(targets, pc+int(readS4(code, offset)))
+		}
+	} else {
+		for pair := at + 8; pair < pc+instructionLength(code, pc); pair += 8 {
+			targets = append(targets, pc+int(readS4(code, pair+4)))
+		}
+	}
+
+	return targets
+}
+
+// member returns the class, the name and the descriptor of the field or the method referred to by the
+// instruction at pc, without resolving them. The class and the name of an invokedynamic are empty.
+func (a *nullPointerAnalysis) member(pc int) (string, string, string, bool) {
+	pool := a.method.Class.ConstantPool
+	index := readU2(a.code, pc+1)
+
+	if a.code[pc] == OP_INVOKEDYNAMIC {
+		entry, err := pool.entry(index, CONSTANT_InvokeDynamic)
+		if err != nil {
+			return "", "", "", false
+		}
+
+		nameAndType, err := pool.entry(entry.Info.(DynamicInfo).NameAndTypeIndex, CONSTANT_NameAndType)
+		if err != nil {
+			return "", "", "", false
+		}
+
+		descriptor, err := a.method.Class.File.Utf8(nameAndType.Info.(NameAndTypeInfo).DescriptorIndex)
+
+		return "", "", descriptor, err == nil
+	}
+
+	entry, err := pool.entry(index, CONSTANT_Fieldref, CONSTANT_Methodref, CONSTANT_InterfaceMethodref)
+	if err != nil {
+		return "", "", "", false
+	}
+
+	class, name, descriptor, err := pool.symbolicRef(entry)
+
+	return class, name, descriptor, err == nil
+}
+
+// nullSlot returns the depth in the operand stack of the reference the instruction at pc dereferences, or -1
+// when it does not dereference one.
+func (a *nullPointerAnalysis) nullSlot(pc int) int {
+	switch op := a.code[pc]; {
+	case op >= OP_IALOAD && op <= OP_SALOAD:
+		return 1
+	case op >= OP_IASTORE && op <= OP_SASTORE:
+		return 1 + valueSlots(op-OP_IASTORE)
+	case op == OP_GETFIELD || op == OP_ARRAYLENGTH || op == OP_ATHROW || op == OP_MONITORENTER || op == OP_MONITOREXIT:
+		return 0
+	case op == OP_PUTFIELD || op == OP_INVOKEVIRTUAL || op == OP_INVOKESPECIAL || op == OP_INVOKEINTERFACE:
+		if instructionLength(a.code, pc) == 0 {
+			return -1
+		}
+
+		_, _, descriptor, ok := a.member(pc)
+		if !ok {
+			return -1
+		}
+
+		if op == OP_PUTFIELD {
+			return DescriptorSlots(descriptor)
+		}
+
+		slots, err := ParameterSlots(descriptor)
+		if err != nil {
+			return -1
+		}
+
+		return slots
+	}
+
+	return -1
+}
+
+// arrayKinds are the kinds of arrays of the array loads and stores, in the order of their opcodes.
+var arrayKinds = [...]string{"int", "long", "float", "double", "object", "byte/boolean", "char", "short"}
+
+// failedAction describes what the instruction at pc could not do because of the null reference.
+func (a *nullPointerAnalysis) failedAction(pc int) string {
+	switch op := a.code[pc]; {
+	case op >= OP_IALOAD && op <= OP_SALOAD:
+		return "Cannot load from " + arrayKinds[op-OP_IALOAD] + " array"
+	case op >= OP_IASTORE && op <= OP_SASTORE:
+		return "Cannot store to " + arrayKinds[op-OP_IASTORE] + " array"
+	case op == OP_ARRAYLENGTH:
+		return "Cannot read the array length"
+	case op == OP_ATHROW:
+		return "Cannot throw exception"
+	case op == OP_MONITORENTER:
+		return "Cannot enter synchronized block"
+	case op == OP_MONITOREXIT:
+		return "Cannot exit synchronized block"
+	}
+
+	class, name, descriptor, ok := a.member(pc)
+	if !ok {
+		return ""
+	}
+
+	switch a.code[pc] {
+	case OP_GETFIELD:
+		return "Cannot read field \"" + name + "\""
+	case OP_PUTFIELD:
+		return "Cannot assign field \"" + name + "\""
+	}
+
+	return "Cannot invoke \"" + methodName(class, name, descriptor) + "\""
+}
+
+// cause describes the expression that pushed the null reference at the given depth of the operand stack of
+// the instruction at pc, or returns an empty string when it cannot be described.
+func (a *nullPointerAnalysis) cause(pc int, slot int) string {
+	description, ok := a.describe(pc, slot, maxNullCauseDetail)
+	if !ok {
+		return ""
+	}
+
+	if source := a.source(pc, slot); a.code[source] >= OP_INVOKEVIRTUAL && a.code[source] <= OP_INVOKEINTERFACE {
+		return " because the return value of \"" + description + "\" is null"
+	}
+
+	return " because \"" + description + "\" is null"
+}
+
+// source returns the pc of the instruction that pushed the slot at the given depth of the operand stack of the
+// instruction at pc, or -1 when it is not known.
+func (a *nullPointerAnalysis) source(pc int, slot int) int {
+	stack := a.stacks[pc]
+	if stack == nil || slot >= len(stack.sources) {
+		return -1
+	}
+
+	return stack.sources[len(stack.sources)-1-slot]
+}
+
+// describe describes the expression that pushed the slot at the given depth of the operand stack of the
+// instruction at pc, like `a.b[i]` or `Foo.bar()`, describing at most detail nested expressions.
+func (a *nullPointerAnalysis) describe(pc int, slot int, detail int) (string, bool) {
+	if detail <= 0 {
+		return "", false
+	}
+
+	source := a.source(pc, slot)
+	if source < 0 {
+		return "", false
+	}
+
+	code := a.code
+
+	switch op := code[source]; {
+	case op == OP_ACONST_NULL:
+		return "null", true
+	case op >= OP_ICONST_M1 && op <= OP_ICONST_5:
+		return strconv.Itoa(int(op) - int(OP_ICONST_0)), true
+	case op == OP_BIPUSH:
+		return strconv.Itoa(int(int8(code[source+1]))), true
+	case op == OP_SIPUSH:
+		return strconv.Itoa(int(readS2(code, source+1))), true
+	case op == OP_ILOAD || op == OP_ALOAD:
+		return a.localName(pc, source, int(code[source+1])), true
+	case op >= OP_ILOAD_0 && op <= OP_ILOAD_3:
+		return a.localName(pc, source, int(op-OP_ILOAD_0)), true
+	case op >= OP_ALOAD_0 && op <= OP_ALOAD_3:
+		return a.localName(pc, source, int(op-OP_ALOAD_0)), true
+	case op == OP_WIDE && (code[source+1] == OP_ILOAD || code[source+1] == OP_ALOAD):
+		return a.localName(pc, source, int(readU2(code, source+2))), true
+	case op == OP_IALOAD || op == OP_AALOAD:
+		array, ok := a.describe(source, 1, detail-1)
+		if !ok {
+			array = "<array>"
+		}
+
+		index, ok := a.describe(source, 0, detail-1)
+		if !ok {
+			index = "..."
+		}
+
+		return array + "[" + index + "]", true
+	}
+
+	class, name, descriptor, ok := a.member(source)
+	if !ok {
+		return "", false
+	}
+
+	switch code[source] {
+	case OP_GETSTATIC:
+		return wellKnownName(javaName(class)) + "." + name, true
+	case OP_GETFIELD:
+		if object, ok := a.describe(source, 0, detail-1); ok {
+			return object + "." + name, true
+		}
+
+		return name, true
+	case OP_INVOKEVIRTUAL, OP_INVOKESPECIAL, OP_INVOKESTATIC, OP_INVOKEINTERFACE:
+		return methodName(class, name, descriptor), true
+	}
+
+	return "", false
+}
+
+// localName names the local at the index loaded by the instruction at source, for the instruction at pc: the name
+// of the local variable of the source file when the method has a LocalVariableTable, otherwise `this`,
+// `<parameterN>` for the parameters not stored to, or `<localN>`.
+func (a *nullPointerAnalysis) localName(pc int, source int, index int) string {
+	if name := a.method.Code.LocalVariable(source, index); name != "" {
+		return name
+	}
+
+	parameter := !a.stacks[pc].written[index]
+
+	current := 0
+	if !a.method.IsStatic() {
+		if index == 0 && parameter {
+			return "this"
+		}
+
+		current = 1
+	}
+
+	params, _, _ := ParseMethodDescriptor(a.method.Descriptor)
+	for i, param := range params {
+		slots := DescriptorSlots(param)
+		if index >= current && index < current+slots && parameter {
+			return "<parameter" + strconv.Itoa(i+1) + ">"
+		}
+
+		current += slots
+	}
+
+	return "<local" + strconv.Itoa(index) + ">"
+}
+
+// methodName names a method in the messages, e.g. `String.format(String, Object[])`.
+func methodName(class string, name string, descriptor string) string {
+	params, _, _ := ParseMethodDescriptor(descriptor)
+
+	names := make([]string, len(params))
+	for i, param := range params {
+		names[i] = wellKnownName(TypeName(param))
+	}
+
+	return wellKnownName(javaName(class)) + "." + name + "(" + strings.Join(names, ", ") + ")"
+}
+
+// wellKnownName shortens java.lang.Object and java.lang.String, and arrays of them, like the reference
+// implementation does in the messages.
+func wellKnownName(name string) string {
+	switch strings.TrimRight(name, "[]") {
+	case "java.lang.Object", "java.lang.String":
+		return strings.TrimPrefix(name, "java.lang.")
+	}
+
+	return name
+}
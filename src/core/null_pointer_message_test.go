@@ -0,0 +1,135 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// localVariable builds a LocalVariableTable attribute with one local variable.
+func localVariable(b *testClassBuilder, start uint16, length uint16, name string, descriptor string, index uint16) []byte {
+	return b.attribute("LocalVariableTable", concat(u2(1), u2(start), u2(length), u2(b.utf8(name)), u2(b.utf8(descriptor)), u2(index)))
+}
+
+// writeNullsClass writes Nulls, whose methods dereference null in different ways.
+func writeNullsClass(t *testing.T, dir string) {
+	b := newTestClassBuilder()
+	public, static := core.ACC_PUBLIC, core.ACC_PUBLIC|core.ACC_STATIC
+
+	next, count, values := b.fieldref("Nulls", "next", "LNulls;"), b.fieldref("Nulls", "count", "I"), b.fieldref("Nulls", "values", "[I")
+	instance := b.fieldref("Nulls", "instance", "LNulls;")
+	get, none := b.methodref("Nulls", "get", "(Ljava/lang/Object;[Ljava/lang/String;)LNulls;"), b.methodref("Nulls", "none", "()LNulls;")
+	npe, npeInit := b.class(core.NullPointerException), b.methodref(core.NullPointerException, "<init>", "()V")
+
+	method := func(access uint16, name string, descriptor string, maxStack uint16, maxLocals uint16, bytecode []byte, attributes ...[]byte) []byte {
+		return b.member(access, name, descriptor, codeWithHandlers(b, maxStack, maxLocals, bytecode, nil, attributes...))
+	}
+
+	readCount := concat([]byte{core.OP_ACONST_NULL, core.OP_ASTORE_0, core.OP_ALOAD_0, core.OP_GETFIELD}, u2(count), []byte{core.OP_IRETURN})
+
+	writeClassFile(t, dir, "Nulls", b.build(public, "Nulls", "java/lang/Object", nil,
+		[][]byte{b.member(public, "next", "LNulls;"), b.member(public, "count", "I"), b.member(public, "values", "[I"), b.member(static, "instance", "LNulls;")},
+		[][]byte{
+			method(public, "get", "(Ljava/lang/Object;[Ljava/lang/String;)LNulls;", 1, 3, []byte{core.OP_ACONST_NULL, core.OP_ARETURN}),
+			method(static, "none", "()LNulls;", 1, 0, []byte{core.OP_ACONST_NULL, core.OP_ARETURN}),
+			method(static, "invoke", "(LNulls;)LNulls;", 3, 1, concat([]byte{core.OP_ALOAD_0, core.OP_ACONST_NULL, core.OP_ACONST_NULL, core.OP_INVOKEVIRTUAL}, u2(get), []byte{core.OP_ARETURN})),
+			method(static, "local", "()I", 1, 1, readCount),
+			method(static, "named", "()I", 1, 1, readCount, localVariable(b, 2, 5, "nulls", "LNulls;", 0)),
+			method(static, "returned", "()I", 1, 0, concat([]byte{core.OP_INVOKESTATIC}, u2(none), []byte{core.OP_GETFIELD}, u2(count), []byte{core.OP_IRETURN})),
+			method(static, "chain", "(LNulls;)I", 1, 1, concat([]byte{core.OP_ALOAD_0, core.OP_GETFIELD}, u2(next), []byte{core.OP_GETFIELD}, u2(count), []byte{core.OP_IRETURN})),
+			method(static, "assign", "(LNulls;)V", 2, 1, concat([]byte{core.OP_ALOAD_0, core.OP_GETFIELD}, u2(next), []byte{core.OP_ICONST_1, core.OP_PUTFIELD}, u2(count), []byte{core.OP_RETURN})),
+			method(static, "instance", "()I", 1, 0, concat([]byte{core.OP_GETSTATIC}, u2(instance), []byte{core.OP_GETFIELD}, u2(count), []byte{core.OP_IRETURN})),
+			method(static, "element", "(LNulls;I)I", 2, 2, concat([]byte{core.OP_ALOAD_0, core.OP_GETFIELD}, u2(values), []byte{core.OP_ILOAD_1, core.OP_IALOAD, core.OP_IRETURN})),
+			method(static, "store", "([[J)V", 5, 1, []byte{core.OP_ALOAD_0, core.OP_ICONST_1, core.OP_AALOAD, core.OP_ICONST_2, core.OP_LCONST_1, core.OP_LASTORE, core.OP_RETURN}),
+			method(public, "size", "()I", 1, 1, concat([]byte{core.OP_ALOAD_0, core.OP_GETFIELD}, u2(values), []byte{core.OP_ARRAYLENGTH, core.OP_IRETURN})),
+			method(static, "throwNull", "()V", 1, 0, []byte{core.OP_ACONST_NULL, core.OP_ATHROW}),
+			// the null comes from either branch
+			method(static, "branch", "(I)I", 1, 1, concat(
+				[]byte{core.OP_ILOAD_0, core.OP_IFEQ}, u2(7), []byte{core.OP_ACONST_NULL, core.OP_GOTO}, u2(4),
+				[]byte{core.OP_ACONST_NULL, core.OP_GETFIELD}, u2(count), []byte{core.OP_IRETURN},
+			)),
+			// the parameter is overwritten in the try block and dereferenced in the catch block
+			b.member(static, "caught", "(LNulls;)I", codeWithHandlers(b, 2, 1, concat(
+				[]byte{core.OP_ACONST_NULL, core.OP_ASTORE_0, core.OP_ICONST_1, core.OP_ICONST_0, core.OP_IDIV, core.OP_IRETURN},
+				[]byte{core.OP_POP, core.OP_ALOAD_0, core.OP_GETFIELD}, u2(count), []byte{core.OP_IRETURN},
+			), [][]byte{handler(0, 6, 6, 0)})),
+			method(static, "created", "()V", 2, 0, concat([]byte{core.OP_NEW}, u2(npe), []byte{core.OP_DUP, core.OP_INVOKESPECIAL}, u2(npeInit), []byte{core.OP_ATHROW})),
+		},
+		nil,
+	))
+}
+
+func TestShouldParseTheLocalVariablesOfTheCode(t *testing.T) {
+	dir := t.TempDir()
+	writeNullsClass(t, dir)
+
+	nulls, err := newTestLoaders(t, dir).Application.LoadClass("Nulls")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	code := nulls.DeclaredMethod("named", "()I").Code
+	if len(code.LocalVariables) != 1 || code.LocalVariables[0] != (core.LocalVariable{StartPC: 2, Length: 5, Name: "nulls", Descriptor: "LNulls;"}) {
+		t.Fatalf("Expected the local variable nulls, got %v", code.LocalVariables)
+	}
+
+	for pc, expected := range map[int]string{0: "", 2: "nulls", 6: "nulls", 7: ""} {
+		if name := code.LocalVariable(pc, 0); name != expected {
+			t.Errorf("Expected the local variable at pc %d to be %q, got %q", pc, expected, name)
+		}
+	}
+
+	if name := code.LocalVariable(2, 1); name != "" {
+		t.Errorf("Expected no local variable in local 1, got %q", name)
+	}
+}
+
+func TestShouldDescribeWhatIsNullInNullPointerExceptions(t *testing.T) {
+	dir := t.TempDir()
+	writeNullsClass(t, dir)
+
+	loaders := newTestLoaders(t, dir)
+
+	nulls, err := loaders.Application.LoadClass("Nulls")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	longs, err := loaders.Bootstrap.LoadClass("[[J")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	object := []core.Slot{{Ref: core.NewObject(nulls)}}
+
+	for _, test := range []struct {
+		method     string
+		descriptor string
+		args       [][]core.Slot
+		expected   string
+	}{
+		{"invoke", "(LNulls;)LNulls;", [][]core.Slot{{{}}}, `Cannot invoke "Nulls.get(Object, String[])" because "<parameter1>" is null`},
+		{"local", "()I", nil, `Cannot read field "count" because "<local0>" is null`},
+		{"named", "()I", nil, `Cannot read field "count" because "nulls" is null`},
+		{"returned", "()I", nil, `Cannot read field "count" because the return value of "Nulls.none()" is null`},
+		{"chain", "(LNulls;)I", [][]core.Slot{object}, `Cannot read field "count" because "<parameter1>.next" is null`},
+		{"assign", "(LNulls;)V", [][]core.Slot{object}, `Cannot assign field "count" because "<parameter1>.next" is null`},
+		{"instance", "()I", nil, `Cannot read field "count" because "Nulls.instance" is null`},
+		{"element", "(LNulls;I)I", [][]core.Slot{object, {{Num: 3}}}, `Cannot load from int array because "<parameter1>.values" is null`},
+		{"store", "([[J)V", [][]core.Slot{{{Ref: core.NewArray(longs, 2)}}}, `Cannot store to long array because "<parameter1>[1]" is null`},
+		{"size", "()I", [][]core.Slot{object}, `Cannot read the array length because "this.values" is null`},
+		{"throwNull", "()V", nil, `Cannot throw exception because "null" is null`},
+		{"branch", "(I)I", [][]core.Slot{{{Num: 1}}}, `Cannot read field "count"`},
+		{"caught", "(LNulls;)I", [][]core.Slot{object}, `Cannot read field "count" because "<local0>" is null`},
+	} {
+		_, err := invokeSlots(t, nulls, test.method, test.descriptor, test.args...)
+		if err == nil || err.Error() != "java.lang.NullPointerException: "+test.expected {
+			t.Errorf("Expected %s to throw a NullPointerException with the message %q, got %v", test.method, test.expected, err)
+		}
+	}
+
+	// the NullPointerExceptions created by the program keep their message
+	if _, err := invokeSlots(t, nulls, "created", "()V"); err == nil || err.Error() != "java.lang.NullPointerException" {
+		t.Errorf("Expected a NullPointerException without a message, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Slot is a value in the locals, the operand stack or the fields of a class or object.
+//
+// Primitive values are kept in Num, with long and double values taking two slots (the first one holds the
+// high bits), and references are kept in Ref. The zero value is the default value of every type.
+type Slot struct {
+	Num int32
+	Ref *Object
+}
+
+// LongSlots returns the two slots of a long, high bits first.
+func LongSlots(v int64) []Slot {
+	return []Slot{{Num: int32(v >> 32)}, {Num: int32(v)}}
+}
+
+// SlotsLong returns the long held by the first two slots.
+func SlotsLong(slots []Slot) int64 {
+	return int64(slots[0].Num)<<32 | int64(uint32(slots[1].Num))
+}
+
+// FloatSlot returns the slot of a float, which holds its IEEE 754 bits.
+func FloatSlot(v float32) Slot {
+	return Slot{Num: int32(math.Float32bits(v))}
+}
+
+// SlotFloat returns the float held by the slot.
+func SlotFloat(slot Slot) float32 {
+	return math.Float32frombits(uint32(slot.Num))
+}
+
+// DoubleSlots returns the two slots of a double, which hold its IEEE 754 bits like a long.
+func DoubleSlots(v float64) []Slot {
+	return LongSlots(int64(math.Float64bits(v)))
+}
+
+// SlotsDouble returns the double held by the first two slots.
+func SlotsDouble(slots []Slot) float64 {
+	return math.Float64frombits(uint64(SlotsLong(slots)))
+}
+
+// Object is an instance of a class or an array, it lives in the Go heap and is collected by the Go garbage collector.
+type Object struct {
+	// Class is the class the object is an instance of.
+	Class *Class
+	// Fields hold the values of the instance fields, indexed by `Field.Slot`, the fields declared by the
+	// superclasses come first.
+	Fields []Slot
+	// Elements are the components of an array, see `NewArray`, it is nil for objects that are not arrays.
+	Elements any
+
+	// thrown is the error of a throwable object, which keeps its stack trace and cause, see `thrownError`.
+	thrown *JavaError
+	// state is the Go value behind an object of a class of the built-in library that keeps one, like the chars
+	// of a StringBuilder, see `libraryState`.
+	state any
+	// lock is the monitor of the object, nil until a thread first enters it, see `monitor`.
+	lock *monitor
+	// hash is the identity hash code of the object, 0 until it is first asked for, see `IdentityHash`.
+	hash atomic.Int32
+}
+
+// NewObject allocates an instance of the class with every field set to its default value.
+func NewObject(class *Class) *Object {
+	return &Object{Class: class, Fields: make([]Slot, class.InstanceSlots)}
+}
+
+// IsInstanceOf tells if the object is an instance of the class, or of a subclass or implementation of it.
+func (o *Object) IsInstanceOf(class *Class) bool {
+	return o.Class.IsSubclassOf(class)
+}
+
+// identityHashes generates the identity hash codes of objects.
+var identityHashes atomic.Uint32
+
+// IdentityHash returns the identity hash code of the object, as returned by `System.identityHashCode`, a
+// positive number that does not change during the lifetime of the object.
+func (o *Object) IdentityHash() int32 {
+	if hash := o.hash.Load(); hash != 0 {
+		return hash
+	}
+
+	// a Fibonacci hash of a counter, so the hash codes look random like the ones of HotSpot, the first thread
+	// to set it wins when several threads ask at the same time
+	for {
+		hash := int32((identityHashes.Add(1) * 0x9e3779b9) >> 1)
+		if hash != 0 && (o.hash.CompareAndSwap(0, hash) || o.hash.Load() != 0) {
+			return o.hash.Load()
+		}
+	}
+}
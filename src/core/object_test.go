@@ -0,0 +1,212 @@
+package core_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// writeObjectClasses writes the interface Shape, the abstract class Base implementing it, Circle extending
+// Base and shadowing its id field, and Other.
+func writeObjectClasses(t *testing.T, dir string) {
+	public := core.ACC_PUBLIC
+
+	b := newTestClassBuilder()
+	writeClassFile(t, dir, "Shape", b.build(public|core.ACC_INTERFACE|core.ACC_ABSTRACT, "Shape", "java/lang/Object", nil, nil, nil, nil))
+
+	b = newTestClassBuilder()
+	objectInit := b.methodref("java/lang/Object", "<init>", "()V")
+	writeClassFile(t, dir, "Base", b.build(public|core.ACC_ABSTRACT, "Base", "java/lang/Object", []string{"Shape"},
+		[][]byte{b.member(public, "id", "I"), b.member(public, "size", "J")},
+		[][]byte{b.member(public, "<init>", "()V", b.code(1, 1, concat([]byte{core.OP_ALOAD_0, core.OP_INVOKESPECIAL}, u2(objectInit), []byte{core.OP_RETURN})...))},
+		nil,
+	))
+
+	b = newTestClassBuilder()
+	circle := b.class("Circle")
+	baseInit := b.methodref("Base", "<init>", "()V")
+	circleInit := b.methodref("Circle", "<init>", "(I)V")
+	radius, baseID, circleID := b.fieldref("Circle", "radius", "I"), b.fieldref("Base", "id", "I"), b.fieldref("Circle", "id", "I")
+	total := b.fieldref("Circle", "total", "I")
+
+	init := concat(
+		[]byte{core.OP_ALOAD_0, core.OP_INVOKESPECIAL}, u2(baseInit),
+		[]byte{core.OP_ALOAD_0, core.OP_ILOAD_1, core.OP_PUTFIELD}, u2(radius),
+		[]byte{core.OP_ALOAD_0, core.OP_BIPUSH, 7, core.OP_PUTFIELD}, u2(baseID),
+		[]byte{core.OP_ALOAD_0, core.OP_BIPUSH, 9, core.OP_PUTFIELD}, u2(circleID),
+		[]byte{core.OP_RETURN},
+	)
+
+	make := concat(
+		[]byte{core.OP_NEW}, u2(circle),
+		[]byte{core.OP_DUP, core.OP_ILOAD_0, core.OP_INVOKESPECIAL}, u2(circleInit),
+		[]byte{core.OP_ARETURN},
+	)
+
+	static := core.ACC_PUBLIC | core.ACC_STATIC
+	writeClassFile(t, dir, "Circle", b.build(public, "Circle", "Base", nil,
+		[][]byte{b.member(public, "id", "I"), b.member(public|core.ACC_FINAL, "radius", "I"), b.member(static, "total", "I")},
+		[][]byte{
+			b.member(public, "<init>", "(I)V", b.code(2, 2, init...)),
+			b.member(static, "make", "(I)LCircle;", b.code(3, 1, make...)),
+			b.member(static, "radius", "(LCircle;)I", b.code(1, 1, concat([]byte{core.OP_ALOAD_0, core.OP_GETFIELD}, u2(radius), []byte{core.OP_IRETURN})...)),
+			b.member(static, "setRadius", "(LCircle;)V", b.code(2, 1, concat([]byte{core.OP_ALOAD_0, core.OP_ICONST_1, core.OP_PUTFIELD}, u2(radius), []byte{core.OP_RETURN})...)),
+			b.member(static, "total", "(LCircle;)I", b.code(1, 1, concat([]byte{core.OP_ALOAD_0, core.OP_GETFIELD}, u2(total), []byte{core.OP_IRETURN})...)),
+			b.member(static, "isShape", "(Ljava/lang/Object;)I", b.code(1, 1, concat([]byte{core.OP_ALOAD_0, core.OP_INSTANCEOF}, u2(b.class("Shape")), []byte{core.OP_IRETURN})...)),
+			b.member(static, "toCircle", "(Ljava/lang/Object;)Ljava/lang/Object;", b.code(1, 1, concat([]byte{core.OP_ALOAD_0, core.OP_CHECKCAST}, u2(circle), []byte{core.OP_ARETURN})...)),
+			b.member(static, "newBase", "()Ljava/lang/Object;", b.code(1, 0, concat([]byte{core.OP_NEW}, u2(b.class("Base")), []byte{core.OP_ARETURN})...)),
+		},
+		nil,
+	))
+
+	writeClassFile(t, dir, "Other", minimalClassFile("Other"))
+}
+
+func TestShouldLayOutInstanceFieldsAfterTheInheritedOnes(t *testing.T) {
+	dir := t.TempDir()
+	writeObjectClasses(t, dir)
+
+	circle, err := newTestLoaders(t, dir).Application.LoadClass("Circle")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	base := circle.Super
+	if base.DeclaredField("id", "I").Slot != 0 || base.DeclaredField("size", "J").Slot != 1 || base.InstanceSlots != 3 {
+		t.Errorf("Expected the fields of Base to take the slots 0 to 2, got %d slots", base.InstanceSlots)
+	}
+
+	if circle.DeclaredField("id", "I").Slot != 3 || circle.DeclaredField("radius", "I").Slot != 4 || circle.InstanceSlots != 5 {
+		t.Errorf("Expected the fields of Circle to take the slots 3 and 4, got %d slots", circle.InstanceSlots)
+	}
+
+	obj := core.NewObject(circle)
+	if len(obj.Fields) != 5 || obj.Fields[0] != (core.Slot{}) {
+		t.Errorf("Expected a new object to have 5 fields with their default values, got %v", obj.Fields)
+	}
+}
+
+func TestShouldCreateObjectsAndAccessTheirFields(t *testing.T) {
+	dir := t.TempDir()
+	writeObjectClasses(t, dir)
+
+	circle, err := newTestLoaders(t, dir).Application.LoadClass("Circle")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ret, err := invokeSlots(t, circle, "make", "(I)LCircle;", []core.Slot{{Num: 42}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	obj := ret[0].Ref
+	if obj == nil || obj.Class != circle || !circle.IsInitialized() {
+		t.Fatalf("Expected an instance of an initialized Circle, got %v", obj)
+	}
+
+	if obj.Fields[0].Num != 7 || obj.Fields[3].Num != 9 || obj.Fields[4].Num != 42 {
+		t.Errorf("Expected the shadowed and the shadowing id to be set, got %v", obj.Fields)
+	}
+
+	if result, err := invokeSlots(t, circle, "radius", "(LCircle;)I", []core.Slot{{Ref: obj}}); err != nil || result[0].Num != 42 {
+		t.Errorf("Expected the radius to be 42, got %v (%v)", result, err)
+	}
+
+	_, err = invokeSlots(t, circle, "radius", "(LCircle;)I", []core.Slot{{}})
+	if !core.IsJavaError(err, core.NullPointerException) {
+		t.Errorf("Expected a NullPointerException, got %v", err)
+	}
+
+	_, err = invokeSlots(t, circle, "setRadius", "(LCircle;)V", []core.Slot{{Ref: obj}})
+	expected := "java.lang.IllegalAccessError: Update to non-static final field Circle.radius attempted from a different method (setRadius) than the initializer method <init> "
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+
+	_, err = invokeSlots(t, circle, "total", "(LCircle;)I", []core.Slot{{Ref: obj}})
+	if err == nil || err.Error() != "java.lang.IncompatibleClassChangeError: Expected non-static field Circle.total" {
+		t.Errorf("Expected an IncompatibleClassChangeError, got %v", err)
+	}
+
+	_, err = invokeSlots(t, circle, "newBase", "()Ljava/lang/Object;")
+	if err == nil || err.Error() != "java.lang.InstantiationError: Base" {
+		t.Errorf("Expected an InstantiationError, got %v", err)
+	}
+}
+
+func TestShouldCheckTheTypeOfObjects(t *testing.T) {
+	dir := t.TempDir()
+	writeObjectClasses(t, dir)
+
+	loaders := newTestLoaders(t, dir)
+
+	circle, err := loaders.Application.LoadClass("Circle")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	other, _ := loaders.Application.LoadClass("Other")
+	object, _ := loaders.Application.LoadClass("java/lang/Object")
+	instance := core.NewObject(circle)
+
+	for _, test := range []struct {
+		obj      *core.Object
+		expected int32
+	}{{instance, 1}, {core.NewObject(other), 0}, {nil, 0}} {
+		result, err := invokeSlots(t, circle, "isShape", "(Ljava/lang/Object;)I", []core.Slot{{Ref: test.obj}})
+		if err != nil || result[0].Num != test.expected {
+			t.Errorf("Expected instanceof Shape of %v to be %d, got %v (%v)", test.obj, test.expected, result, err)
+		}
+	}
+
+	for _, obj := range []*core.Object{instance, nil} {
+		if result, err := invokeSlots(t, circle, "toCircle", "(Ljava/lang/Object;)Ljava/lang/Object;", []core.Slot{{Ref: obj}}); err != nil || result[0].Ref != obj {
+			t.Errorf("Expected the cast of %v to succeed, got %v", obj, err)
+		}
+	}
+
+	_, err = invokeSlots(t, circle, "toCircle", "(Ljava/lang/Object;)Ljava/lang/Object;", []core.Slot{{Ref: core.NewObject(other)}})
+	expected := "java.lang.ClassCastException: class Other cannot be cast to class Circle (Other and Circle are in unnamed module of loader 'app')"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+
+	_, err = invokeSlots(t, circle, "toCircle", "(Ljava/lang/Object;)Ljava/lang/Object;", []core.Slot{{Ref: core.NewObject(object)}})
+	expected = "java.lang.ClassCastException: class java.lang.Object cannot be cast to class Circle " +
+		"(java.lang.Object is in module java.base of loader 'bootstrap'; Circle is in unnamed module of loader 'app')"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+}
+
+func TestShouldGiveAnObjectTheSameIdentityHashFromEveryThread(t *testing.T) {
+	class, err := newTestLoaders(t, t.TempDir()).Bootstrap.LoadClass("java/lang/Object")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	obj := core.NewObject(class)
+
+	hashes := make([]int32, 8)
+	var wg sync.WaitGroup
+
+	for i := range hashes {
+		i := i
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			hashes[i] = obj.IdentityHash()
+		}()
+	}
+
+	wg.Wait()
+
+	for _, hash := range hashes {
+		if hash <= 0 || hash != hashes[0] {
+			t.Fatalf("Expected every thread to see the same positive hash, got %v", hashes)
+		}
+	}
+}
@@ -0,0 +1,415 @@
+package core
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-6.html#jvms-6.5
+
+// Opcodes of the JVM instructions.
+const (
+	OP_NOP             uint8 = 0x00
+	OP_ACONST_NULL     uint8 = 0x01
+	OP_ICONST_M1       uint8 = 0x02
+	OP_ICONST_0        uint8 = 0x03
+	OP_ICONST_1        uint8 = 0x04
+	OP_ICONST_2        uint8 = 0x05
+	OP_ICONST_3        uint8 = 0x06
+	OP_ICONST_4        uint8 = 0x07
+	OP_ICONST_5        uint8 = 0x08
+	OP_LCONST_0        uint8 = 0x09
+	OP_LCONST_1        uint8 = 0x0a
+	OP_FCONST_0        uint8 = 0x0b
+	OP_FCONST_1        uint8 = 0x0c
+	OP_FCONST_2        uint8 = 0x0d
+	OP_DCONST_0        uint8 = 0x0e
+	OP_DCONST_1        uint8 = 0x0f
+	OP_BIPUSH          uint8 = 0x10
+	OP_SIPUSH          uint8 = 0x11
+	OP_LDC             uint8 = 0x12
+	OP_LDC_W           uint8 = 0x13
+	OP_LDC2_W          uint8 = 0x14
+	OP_ILOAD           uint8 = 0x15
+	OP_LLOAD           uint8 = 0x16
+	OP_FLOAD           uint8 = 0x17
+	OP_DLOAD           uint8 = 0x18
+	OP_ALOAD           uint8 = 0x19
+	OP_ILOAD_0         uint8 = 0x1a
+	OP_ILOAD_1         uint8 = 0x1b
+	OP_ILOAD_2         uint8 = 0x1c
+	OP_ILOAD_3         uint8 = 0x1d
+	OP_LLOAD_0         uint8 = 0x1e
+	OP_LLOAD_1         uint8 = 0x1f
+	OP_LLOAD_2         uint8 = 0x20
+	OP_LLOAD_3         uint8 = 0x21
+	OP_FLOAD_0         uint8 = 0x22
+	OP_FLOAD_1         uint8 = 0x23
+	OP_FLOAD_2         uint8 = 0x24
+	OP_FLOAD_3         uint8 = 0x25
+	OP_DLOAD_0         uint8 = 0x26
+	OP_DLOAD_1         uint8 = 0x27
+	OP_DLOAD_2         uint8 = 0x28
+	OP_DLOAD_3         uint8 = 0x29
+	OP_ALOAD_0         uint8 = 0x2a
+	OP_ALOAD_1         uint8 = 0x2b
+	OP_ALOAD_2         uint8 = 0x2c
+	OP_ALOAD_3         uint8 = 0x2d
+	OP_IALOAD          uint8 = 0x2e
+	OP_LALOAD          uint8 = 0x2f
+	OP_FALOAD          uint8 = 0x30
+	OP_DALOAD          uint8 = 0x31
+	OP_AALOAD          uint8 = 0x32
+	OP_BALOAD          uint8 = 0x33
+	OP_CALOAD          uint8 = 0x34
+	OP_SALOAD          uint8 = 0x35
+	OP_ISTORE          uint8 = 0x36
+	OP_LSTORE          uint8 = 0x37
+	OP_FSTORE          uint8 = 0x38
+	OP_DSTORE          uint8 = 0x39
+	OP_ASTORE          uint8 = 0x3a
+	OP_ISTORE_0        uint8 = 0x3b
+	OP_ISTORE_1        uint8 = 0x3c
+	OP_ISTORE_2        uint8 = 0x3d
+	OP_ISTORE_3        uint8 = 0x3e
+	OP_LSTORE_0        uint8 = 0x3f
+	OP_LSTORE_1        uint8 = 0x40
+	OP_LSTORE_2        uint8 = 0x41
+	OP_LSTORE_3        uint8 = 0x42
+	OP_FSTORE_0        uint8 = 0x43
+	OP_FSTORE_1        uint8 = 0x44
+	OP_FSTORE_2        uint8 = 0x45
+	OP_FSTORE_3        uint8 = 0x46
+	OP_DSTORE_0        uint8 = 0x47
+	OP_DSTORE_1        uint8 = 0x48
+	OP_DSTORE_2        uint8 = 0x49
+	OP_DSTORE_3        uint8 = 0x4a
+	OP_ASTORE_0        uint8 = 0x4b
+	OP_ASTORE_1        uint8 = 0x4c
+	OP_ASTORE_2        uint8 = 0x4d
+	OP_ASTORE_3        uint8 = 0x4e
+	OP_IASTORE         uint8 = 0x4f
+	OP_LASTORE         uint8 = 0x50
+	OP_FASTORE         uint8 = 0x51
+	OP_DASTORE         uint8 = 0x52
+	OP_AASTORE         uint8 = 0x53
+	OP_BASTORE         uint8 = 0x54
+	OP_CASTORE         uint8 = 0x55
+	OP_SASTORE         uint8 = 0x56
+	OP_POP             uint8 = 0x57
+	OP_POP2            uint8 = 0x58
+	OP_DUP             uint8 = 0x59
+	OP_DUP_X1          uint8 = 0x5a
+	OP_DUP_X2          uint8 = 0x5b
+	OP_DUP2            uint8 = 0x5c
+	OP_DUP2_X1         uint8 = 0x5d
+	OP_DUP2_X2         uint8 = 0x5e
+	OP_SWAP            uint8 = 0x5f
+	OP_IADD            uint8 = 0x60
+	OP_LADD            uint8 = 0x61
+	OP_FADD            uint8 = 0x62
+	OP_DADD            uint8 = 0x63
+	OP_ISUB            uint8 = 0x64
+	OP_LSUB            uint8 = 0x65
+	OP_FSUB            uint8 = 0x66
+	OP_DSUB            uint8 = 0x67
+	OP_IMUL            uint8 = 0x68
+	OP_LMUL            uint8 = 0x69
+	OP_FMUL            uint8 = 0x6a
+	OP_DMUL            uint8 = 0x6b
+	OP_IDIV            uint8 = 0x6c
+	OP_LDIV            uint8 = 0x6d
+	OP_FDIV            uint8 = 0x6e
+	OP_DDIV            uint8 = 0x6f
+	OP_IREM            uint8 = 0x70
+	OP_LREM            uint8 = 0x71
+	OP_FREM            uint8 = 0x72
+	OP_DREM            uint8 = 0x73
+	OP_INEG            uint8 = 0x74
+	OP_LNEG            uint8 = 0x75
+	OP_FNEG            uint8 = 0x76
+	OP_DNEG            uint8 = 0x77
+	OP_ISHL            uint8 = 0x78
+	OP_LSHL            uint8 = 0x79
+	OP_ISHR            uint8 = 0x7a
+	OP_LSHR            uint8 = 0x7b
+	OP_IUSHR           uint8 = 0x7c
+	OP_LUSHR           uint8 = 0x7d
+	OP_IAND            uint8 = 0x7e
+	OP_LAND            uint8 = 0x7f
+	OP_IOR             uint8 = 0x80
+	OP_LOR             uint8 = 0x81
+	OP_IXOR            uint8 = 0x82
+	OP_LXOR            uint8 = 0x83
+	OP_IINC            uint8 = 0x84
+	OP_I2L             uint8 = 0x85
+	OP_I2F             uint8 = 0x86
+	OP_I2D             uint8 = 0x87
+	OP_L2I             uint8 = 0x88
+	OP_L2F             uint8 = 0x89
+	OP_L2D             uint8 = 0x8a
+	OP_F2I             uint8 = 0x8b
+	OP_F2L             uint8 = 0x8c
+	OP_F2D             uint8 = 0x8d
+	OP_D2I             uint8 = 0x8e
+	OP_D2L             uint8 = 0x8f
+	OP_D2F             uint8 = 0x90
+	OP_I2B             uint8 = 0x91
+	OP_I2C             uint8 = 0x92
+	OP_I2S             uint8 = 0x93
+	OP_LCMP            uint8 = 0x94
+	OP_FCMPL           uint8 = 0x95
+	OP_FCMPG           uint8 = 0x96
+	OP_DCMPL           uint8 = 0x97
+	OP_DCMPG           uint8 = 0x98
+	OP_IFEQ            uint8 = 0x99
+	OP_IFNE            uint8 = 0x9a
+	OP_IFLT            uint8 = 0x9b
+	OP_IFGE            uint8 = 0x9c
+	OP_IFGT            uint8 = 0x9d
+	OP_IFLE            uint8 = 0x9e
+	OP_IF_ICMPEQ       uint8 = 0x9f
+	OP_IF_ICMPNE       uint8 = 0xa0
+	OP_IF_ICMPLT       uint8 = 0xa1
+	OP_IF_ICMPGE       uint8 = 0xa2
+	OP_IF_ICMPGT       uint8 = 0xa3
+	OP_IF_ICMPLE       uint8 = 0xa4
+	OP_IF_ACMPEQ       uint8 = 0xa5
+	OP_IF_ACMPNE       uint8 = 0xa6
+	OP_GOTO            uint8 = 0xa7
+	OP_JSR             uint8 = 0xa8
+	OP_RET             uint8 = 0xa9
+	OP_TABLESWITCH     uint8 = 0xaa
+	OP_LOOKUPSWITCH    uint8 = 0xab
+	OP_IRETURN         uint8 = 0xac
+	OP_LRETURN         uint8 = 0xad
+	OP_FRETURN         uint8 = 0xae
+	OP_DRETURN         uint8 = 0xaf
+	OP_ARETURN         uint8 = 0xb0
+	OP_RETURN          uint8 = 0xb1
+	OP_GETSTATIC       uint8 = 0xb2
+	OP_PUTSTATIC       uint8 = 0xb3
+	OP_GETFIELD        uint8 = 0xb4
+	OP_PUTFIELD        uint8 = 0xb5
+	OP_INVOKEVIRTUAL   uint8 = 0xb6
+	OP_INVOKESPECIAL   uint8 = 0xb7
+	OP_INVOKESTATIC    uint8 = 0xb8
+	OP_INVOKEINTERFACE uint8 = 0xb9
+	OP_INVOKEDYNAMIC   uint8 = 0xba
+	OP_NEW             uint8 = 0xbb
+	OP_NEWARRAY        uint8 = 0xbc
+	OP_ANEWARRAY       uint8 = 0xbd
+	OP_ARRAYLENGTH     uint8 = 0xbe
+	OP_ATHROW          uint8 = 0xbf
+	OP_CHECKCAST       uint8 = 0xc0
+	OP_INSTANCEOF      uint8 = 0xc1
+	OP_MONITORENTER    uint8 = 0xc2
+	OP_MONITOREXIT     uint8 = 0xc3
+	OP_WIDE            uint8 = 0xc4
+	OP_MULTIANEWARRAY  uint8 = 0xc5
+	OP_IFNULL          uint8 = 0xc6
+	OP_IFNONNULL       uint8 = 0xc7
+	OP_GOTO_W          uint8 = 0xc8
+	OP_JSR_W           uint8 = 0xc9
+)
+
+// OpcodeNames are the mnemonics of the opcodes, as used by `javap`.
+var OpcodeNames = map[uint8]string{
+	OP_NOP:             "nop",
+	OP_ACONST_NULL:     "aconst_null",
+	OP_ICONST_M1:       "iconst_m1",
+	OP_ICONST_0:        "iconst_0",
+	OP_ICONST_1:        "iconst_1",
+	OP_ICONST_2:        "iconst_2",
+	OP_ICONST_3:        "iconst_3",
+	OP_ICONST_4:        "iconst_4",
+	OP_ICONST_5:        "iconst_5",
+	OP_LCONST_0:        "lconst_0",
+	OP_LCONST_1:        "lconst_1",
+	OP_FCONST_0:        "fconst_0",
+	OP_FCONST_1:        "fconst_1",
+	OP_FCONST_2:        "fconst_2",
+	OP_DCONST_0:        "dconst_0",
+	OP_DCONST_1:        "dconst_1",
+	OP_BIPUSH:          "bipush",
+	OP_SIPUSH:          "sipush",
+	OP_LDC:             "ldc",
+	OP_LDC_W:           "ldc_w",
+	OP_LDC2_W:          "ldc2_w",
+	OP_ILOAD:           "iload",
+	OP_LLOAD:           "lload",
+	OP_FLOAD:           "fload",
+	OP_DLOAD:           "dload",
+	OP_ALOAD:           "aload",
+	OP_ILOAD_0:         "iload_0",
+	OP_ILOAD_1:         "iload_1",
+	OP_ILOAD_2:         "iload_2",
+	OP_ILOAD_3:         "iload_3",
+	OP_LLOAD_0:         "lload_0",
+	OP_LLOAD_1:         "lload_1",
+	OP_LLOAD_2:         "lload_2",
+	OP_LLOAD_3:         "lload_3",
+	OP_FLOAD_0:         "fload_0",
+	OP_FLOAD_1:         "fload_1",
+	OP_FLOAD_2:         "fload_2",
+	OP_FLOAD_3:         "fload_3",
+	OP_DLOAD_0:         "dload_0",
+	OP_DLOAD_1:         "dload_1",
+	OP_DLOAD_2:         "dload_2",
+	OP_DLOAD_3:         "dload_3",
+	OP_ALOAD_0:         "aload_0",
+	OP_ALOAD_1:         "aload_1",
+	OP_ALOAD_2:         "aload_2",
+	OP_ALOAD_3:         "aload_3",
+	OP_IALOAD:          "iaload",
+	OP_LALOAD:          "laload",
+	OP_FALOAD:          "faload",
+	OP_DALOAD:          "daload",
+	OP_AALOAD:          "aaload",
+	OP_BALOAD:          "baload",
+	OP_CALOAD:          "caload",
+	OP_SALOAD:          "saload",
+	OP_ISTORE:          "istore",
+	OP_LSTORE:          "lstore",
+	OP_FSTORE:          "fstore",
+	OP_DSTORE:          "dstore",
+	OP_ASTORE:          "astore",
+	OP_ISTORE_0:        "istore_0",
+	OP_ISTORE_1:        "istore_1",
+	OP_ISTORE_2:        "istore_2",
+	OP_ISTORE_3:        "istore_3",
+	OP_LSTORE_0:        "lstore_0",
+	OP_LSTORE_1:        "lstore_1",
+	OP_LSTORE_2:        "lstore_2",
+	OP_LSTORE_3:        "lstore_3",
+	OP_FSTORE_0:        "fstore_0",
+	OP_FSTORE_1:        "fstore_1",
+	OP_FSTORE_2:        "fstore_2",
+	OP_FSTORE_3:        "fstore_3",
+	OP_DSTORE_0:        "dstore_0",
+	OP_DSTORE_1:        "dstore_1",
+	OP_DSTORE_2:        "dstore_2",
+	OP_DSTORE_3:        "dstore_3",
+	OP_ASTORE_0:        "astore_0",
+	OP_ASTORE_1:        "astore_1",
+	OP_ASTORE_2:        "astore_2",
+	OP_ASTORE_3:        "astore_3",
+	OP_IASTORE:         "iastore",
+	OP_LASTORE:         "lastore",
+	OP_FASTORE:         "fastore",
+	OP_DASTORE:         "dastore",
+	OP_AASTORE:         "aastore",
+	OP_BASTORE:         "bastore",
+	OP_CASTORE:         "castore",
+	OP_SASTORE:         "sastore",
+	OP_POP:             "pop",
+	OP_POP2:            "pop2",
+	OP_DUP:             "dup",
+	OP_DUP_X1:          "dup_x1",
+	OP_DUP_X2:          "dup_x2",
+	OP_DUP2:            "dup2",
+	OP_DUP2_X1:         "dup2_x1",
+	OP_DUP2_X2:         "dup2_x2",
+	OP_SWAP:            "swap",
+	OP_IADD:            "iadd",
+	OP_LADD:            "ladd",
+	OP_FADD:            "fadd",
+	OP_DADD:            "dadd",
+	OP_ISUB:            "isub",
+	OP_LSUB:            "lsub",
+	OP_FSUB:            "fsub",
+	OP_DSUB:            "dsub",
+	OP_IMUL:            "imul",
+	OP_LMUL:            "lmul",
+	OP_FMUL:            "fmul",
+	OP_DMUL:            "dmul",
+	OP_IDIV:            "idiv",
+	OP_LDIV:            "ldiv",
+	OP_FDIV:            "fdiv",
+	OP_DDIV:            "ddiv",
+	OP_IREM:            "irem",
+	OP_LREM:            "lrem",
+	OP_FREM:            "frem",
+	OP_DREM:            "drem",
+	OP_INEG:            "ineg",
+	OP_LNEG:            "lneg",
+	OP_FNEG:            "fneg",
+	OP_DNEG:            "dneg",
+	OP_ISHL:            "ishl",
+	OP_LSHL:            "lshl",
+	OP_ISHR:            "ishr",
+	OP_LSHR:            "lshr",
+	OP_IUSHR:           "iushr",
+	OP_LUSHR:           "lushr",
+	OP_IAND:            "iand",
+	OP_LAND:            "land",
+	OP_IOR:             "ior",
+	OP_LOR:             "lor",
+	OP_IXOR:            "ixor",
+	OP_LXOR:            "lxor",
+	OP_IINC:            "iinc",
+	OP_I2L:             "i2l",
+	OP_I2F:             "i2f",
+	OP_I2D:             "i2d",
+	OP_L2I:             "l2i",
+	OP_L2F:             "l2f",
+	OP_L2D:             "l2d",
+	OP_F2I:             "f2i",
+	OP_F2L:             "f2l",
+	OP_F2D:             "f2d",
+	OP_D2I:             "d2i",
+	OP_D2L:             "d2l",
+	OP_D2F:             "d2f",
+	OP_I2B:             "i2b",
+	OP_I2C:             "i2c",
+	OP_I2S:             "i2s",
+	OP_LCMP:            "lcmp",
+	OP_FCMPL:           "fcmpl",
+	OP_FCMPG:           "fcmpg",
+	OP_DCMPL:           "dcmpl",
+	OP_DCMPG:           "dcmpg",
+	OP_IFEQ:            "ifeq",
+	OP_IFNE:            "ifne",
+	OP_IFLT:            "iflt",
+	OP_IFGE:            "ifge",
+	OP_IFGT:            "ifgt",
+	OP_IFLE:            "ifle",
+	OP_IF_ICMPEQ:       "if_icmpeq",
+	OP_IF_ICMPNE:       "if_icmpne",
+	OP_IF_ICMPLT:       "if_icmplt",
+	OP_IF_ICMPGE:       "if_icmpge",
+	OP_IF_ICMPGT:       "if_icmpgt",
+	OP_IF_ICMPLE:       "if_icmple",
+	OP_IF_ACMPEQ:       "if_acmpeq",
+	OP_IF_ACMPNE:       "if_acmpne",
+	OP_GOTO:            "goto",
+	OP_JSR:             "jsr",
+	OP_RET:             "ret",
+	OP_TABLESWITCH:     "tableswitch",
+	OP_LOOKUPSWITCH:    "lookupswitch",
+	OP_IRETURN:         "ireturn",
+	OP_LRETURN:         "lreturn",
+	OP_FRETURN:         "freturn",
+	OP_DRETURN:         "dreturn",
+	OP_ARETURN:         "areturn",
+	OP_RETURN:          "return",
+	OP_GETSTATIC:       "getstatic",
+	OP_PUTSTATIC:       "putstatic",
+	OP_GETFIELD:        "getfield",
+	OP_PUTFIELD:        "putfield",
+	OP_INVOKEVIRTUAL:   "invokevirtual",
+	OP_INVOKESPECIAL:   "invokespecial",
+	OP_INVOKESTATIC:    "invokestatic",
+	OP_INVOKEINTERFACE: "invokeinterface",
+	OP_INVOKEDYNAMIC:   "invokedynamic",
+	OP_NEW:             "new",
+	OP_NEWARRAY:        "newarray",
+	OP_ANEWARRAY:       "anewarray",
+	OP_ARRAYLENGTH:     "arraylength",
+	OP_ATHROW:          "athrow",
+	OP_CHECKCAST:       "checkcast",
+	OP_INSTANCEOF:      "instanceof",
+	OP_MONITORENTER:    "monitorenter",
+	OP_MONITOREXIT:     "monitorexit",
+	OP_WIDE:            "wide",
+	OP_MULTIANEWARRAY:  "multianewarray",
+	OP_IFNULL:          "ifnull",
+	OP_IFNONNULL:       "ifnonnull",
+	OP_GOTO_W:          "goto_w",
+	OP_JSR_W:           "jsr_w",
+}
@@ -0,0 +1,261 @@
+package core
+
+import (
+	"errors"
+	"sync"
+)
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-5.html#jvms-5.4.3
+
+// RuntimeConstantPool resolves the symbolic references of the constant pool of a class on their first use,
+// keeping the result so the next uses of the same entry get it directly.
+//
+// Just like the reference implementation, when resolving an entry fails with a linkage error, the next
+// attempts fail with the same error. It is safe to use from multiple goroutines.
+type RuntimeConstantPool struct {
+	class *Class
+
+	mu       sync.Mutex
+	resolved []resolvedEntry
+}
+
+// resolvedEntry is the result of resolving a constant pool entry.
+type resolvedEntry struct {
+	done  bool
+	value any
+	err   error
+}
+
+func newRuntimeConstantPool(class *Class) *RuntimeConstantPool {
+	return &RuntimeConstantPool{class: class, resolved: make([]resolvedEntry, len(class.File.ConstantPool))}
+}
+
+// entry returns the constant pool entry at the given index, checking that it has one of the given tags.
+func (p *RuntimeConstantPool) entry(index uint16, tags ...uint8) (ConstantPoolInfo, error) {
+	cp := p.class.File.ConstantPool
+	if index == 0 || int(index) > len(cp) {
+		return ConstantPoolInfo{}, NewJavaError(ClassFormatError, "invalid constant pool index %d in class file %s", index, p.class.Name)
+	}
+
+	entry := cp[index-1]
+	for _, tag := range tags {
+		if entry.Tag == tag {
+			return entry, nil
+		}
+	}
+
+	return entry, NewJavaError(
+		ClassFormatError, "constant pool entry %d should be a %s in class file %s", index, Tags[tags[0]], p.class.Name,
+	)
+}
+
+// resolve returns the cached result of the entry at the given index, or calls the resolver and caches its
+// result. Errors are only cached when they are Java errors, as other errors may not happen again.
+func (p *RuntimeConstantPool) resolve(index uint16, resolver func() (any, error)) (any, error) {
+	p.mu.Lock()
+	if r := p.resolved[index-1]; r.done {
+		p.mu.Unlock()
+		return r.value, freshError(r.err)
+	}
+	p.mu.Unlock()
+
+	// the resolver may resolve other entries, so it runs without the lock and the first result stored wins
+	value, err := resolver()
+
+	var javaErr *JavaError
+	if err != nil && !errors.As(err, &javaErr) {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if r := p.resolved[index-1]; r.done {
+		return r.value, freshError(r.err)
+	}
+
+	p.resolved[index-1] = resolvedEntry{done: true, value: value, err: err}
+
+	return value, freshError(err)
+}
+
+// ResolveClass resolves the CONSTANT_Class_info entry at the given index (JVMS 5.4.3.1), loading the class
+// with the defining loader of the class that owns the constant pool.
+func (p *RuntimeConstantPool) ResolveClass(index uint16) (*Class, error) {
+	if _, err := p.entry(index, CONSTANT_Class); err != nil {
+		return nil, err
+	}
+
+	value, err := p.resolve(index, func() (any, error) {
+		name, err := p.class.File.ClassName(index)
+		if err != nil {
+			return nil, NewJavaError(ClassFormatError, "%w", err)
+		}
+
+		class, err := p.class.Loader.LoadClass(name)
+		if IsJavaError(err, ClassNotFoundException) {
+			return nil, &JavaError{ClassName: NoClassDefFoundError, Message: name, Cause: err}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.class.CanAccess(class) {
+			return nil, NewJavaError(IllegalAccessError, "failed to access class %s from class %s", class.JavaName(), p.class.JavaName())
+		}
+
+		if err := p.class.checkModuleAccess(class); err != nil {
+			return nil, err
+		}
+
+		return class, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*Class), nil
+}
+
+// memberRef returns the class, name and descriptor of a field or method reference, resolving the class.
+func (p *RuntimeConstantPool) memberRef(entry ConstantPoolInfo) (*Class, string, string, error) {
+	_, name, descriptor, err := p.symbolicRef(entry)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	class, err := p.ResolveClass(entry.Info.(ConstantPoolIndexableInfo).ClassIndex)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	return class, name, descriptor, nil
+}
+
+// symbolicRef returns the internal name of the class, the name and the descriptor of a field or method
+// reference, without resolving anything.
+func (p *RuntimeConstantPool) symbolicRef(entry ConstantPoolInfo) (string, string, string, error) {
+	ref := entry.Info.(ConstantPoolIndexableInfo)
+
+	nameAndType, err := p.entry(ref.NameAndTypeIndex, CONSTANT_NameAndType)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	nt := nameAndType.Info.(NameAndTypeInfo)
+
+	name, err := p.class.File.Utf8(nt.NameIndex)
+	if err != nil {
+		return "", "", "", NewJavaError(ClassFormatError, "%w", err)
+	}
+
+	descriptor, err := p.class.File.Utf8(nt.DescriptorIndex)
+	if err != nil {
+		return "", "", "", NewJavaError(ClassFormatError, "%w", err)
+	}
+
+	if _, err := p.entry(ref.ClassIndex, CONSTANT_Class); err != nil {
+		return "", "", "", err
+	}
+
+	className, err := p.class.File.ClassName(ref.ClassIndex)
+	if err != nil {
+		return "", "", "", NewJavaError(ClassFormatError, "%w", err)
+	}
+
+	return className, name, descriptor, nil
+}
+
+// ResolveField resolves the CONSTANT_Fieldref_info entry at the given index (JVMS 5.4.3.2).
+func (p *RuntimeConstantPool) ResolveField(index uint16) (*Field, error) {
+	entry, err := p.entry(index, CONSTANT_Fieldref)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := p.resolve(index, func() (any, error) {
+		class, name, descriptor, err := p.memberRef(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		field := class.lookupField(name, descriptor)
+		if field == nil {
+			return nil, NewJavaError(NoSuchFieldError, "Class %s does not have member field '%s %s'", class.JavaName(), TypeName(descriptor), name)
+		}
+
+		if !p.class.CanAccessMember(field.Class, field.AccessFlags) {
+			return nil, NewJavaError(
+				IllegalAccessError, "class %s tried to access %sfield %s.%s",
+				p.class.JavaName(), accessName(field.AccessFlags), field.Class.JavaName(), field.Name,
+			)
+		}
+
+		return field, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*Field), nil
+}
+
+// ResolveMethod resolves the CONSTANT_Methodref_info entry at the given index (JVMS 5.4.3.3).
+func (p *RuntimeConstantPool) ResolveMethod(index uint16) (*Method, error) {
+	return p.resolveMethod(index, CONSTANT_Methodref)
+}
+
+// ResolveInterfaceMethod resolves the CONSTANT_InterfaceMethodref_info entry at the given index (JVMS 5.4.3.4).
+func (p *RuntimeConstantPool) ResolveInterfaceMethod(index uint16) (*Method, error) {
+	return p.resolveMethod(index, CONSTANT_InterfaceMethodref)
+}
+
+func (p *RuntimeConstantPool) resolveMethod(index uint16, tag uint8) (*Method, error) {
+	entry, err := p.entry(index, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := p.resolve(index, func() (any, error) {
+		class, name, descriptor, err := p.memberRef(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		var method *Method
+		if tag == CONSTANT_Methodref {
+			if class.IsInterface() {
+				return nil, NewJavaError(IncompatibleClassChangeError, "Found interface %s, but class was expected", class.JavaName())
+			}
+
+			method = class.lookupMethod(name, descriptor)
+		} else {
+			if !class.IsInterface() {
+				return nil, NewJavaError(IncompatibleClassChangeError, "Found class %s, but interface was expected", class.JavaName())
+			}
+
+			method = class.lookupInterfaceMethod(name, descriptor)
+		}
+
+		if method == nil {
+			return nil, NewJavaError(NoSuchMethodError, "'%s'", MethodSignature(class.Name, name, descriptor))
+		}
+
+		if !p.class.CanAccessMember(method.Class, method.AccessFlags) {
+			return nil, NewJavaError(
+				IllegalAccessError, "class %s tried to access %smethod '%s'", p.class.JavaName(), accessName(method.AccessFlags), method,
+			)
+		}
+
+		return method, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value.(*Method), nil
+}
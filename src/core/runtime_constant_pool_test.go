@@ -0,0 +1,214 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// constant pool indexes of the references made by the Client class of the resolution tests
+type clientRefs struct {
+	inheritedField, interfaceField, missingField, privateField uint16
+	defaultMethod, superMethod, interfaceMethod, missingMethod uint16
+	interfaceAsClass, classAsInterface, missingClass           uint16
+}
+
+// writeResolutionClasses writes the hierarchy used by the resolution tests:
+//
+//	interface I { int CONST; void run(); default void greet() }
+//	interface J extends I { default void greet() }
+//	class Base { int count; private int secret; static long total; void base() }
+//	class Impl extends Base implements J { String name }
+//	class Nested (nestmate of Base)
+//	class Client
+func writeResolutionClasses(t *testing.T, dir string) clientRefs {
+	iface := core.ACC_PUBLIC | core.ACC_INTERFACE | core.ACC_ABSTRACT
+	public := core.ACC_PUBLIC
+
+	b := newTestClassBuilder()
+	writeClassFile(t, dir, "I", b.build(iface, "I", "java/lang/Object", nil,
+		[][]byte{b.member(public|core.ACC_STATIC|core.ACC_FINAL, "CONST", "I")},
+		[][]byte{b.member(public|core.ACC_ABSTRACT, "run", "()V"), b.member(public, "greet", "()V")},
+		nil,
+	))
+
+	b = newTestClassBuilder()
+	writeClassFile(t, dir, "J", b.build(iface, "J", "java/lang/Object", []string{"I"}, nil,
+		[][]byte{b.member(public, "greet", "()V")},
+		nil,
+	))
+
+	b = newTestClassBuilder()
+	writeClassFile(t, dir, "Base", b.build(public, "Base", "java/lang/Object", nil,
+		[][]byte{b.member(public, "count", "I"), b.member(core.ACC_PRIVATE, "secret", "I"), b.member(core.ACC_STATIC, "total", "J")},
+		[][]byte{b.member(public, "base", "()V")},
+		nil,
+	))
+
+	b = newTestClassBuilder()
+	writeClassFile(t, dir, "Impl", b.build(public, "Impl", "Base", []string{"J"},
+		[][]byte{b.member(public, "name", "Ljava/lang/String;")},
+		[][]byte{b.member(public, "run", "()V")},
+		nil,
+	))
+
+	b = newTestClassBuilder()
+	secret := b.fieldref("Base", "secret", "I")
+	writeClassFile(t, dir, "Nested", b.build(public, "Nested", "java/lang/Object", nil, nil, nil,
+		[][]byte{b.attribute("NestHost", u2(b.class("Base"))), b.attribute("Secret", u2(secret))},
+	))
+
+	b = newTestClassBuilder()
+	refs := clientRefs{
+		inheritedField:   b.fieldref("Impl", "count", "I"),
+		interfaceField:   b.fieldref("Impl", "CONST", "I"),
+		missingField:     b.fieldref("Impl", "missing", "I"),
+		privateField:     b.fieldref("Base", "secret", "I"),
+		defaultMethod:    b.methodref("Impl", "greet", "()V"),
+		superMethod:      b.methodref("Impl", "base", "()V"),
+		interfaceMethod:  b.interfaceMethodref("J", "run", "()V"),
+		missingMethod:    b.methodref("Impl", "nope", "(ILjava/lang/String;)[J"),
+		interfaceAsClass: b.methodref("J", "run", "()V"),
+		classAsInterface: b.interfaceMethodref("Impl", "run", "()V"),
+		missingClass:     b.class("Missing"),
+	}
+
+	writeClassFile(t, dir, "Client", b.build(public, "Client", "java/lang/Object", nil, nil, nil, nil))
+
+	return refs
+}
+
+func TestShouldPrepareTheFieldsOfAClass(t *testing.T) {
+	dir := t.TempDir()
+	writeResolutionClasses(t, dir)
+
+	loaders := newTestLoaders(t, dir)
+
+	impl, err := loaders.Application.LoadClass("Impl")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if impl.InstanceSlots != 3 || impl.DeclaredField("name", "Ljava/lang/String;").Slot != 2 {
+		t.Errorf("Expected the fields of Impl to be laid out after the ones of Base, got %d slots", impl.InstanceSlots)
+	}
+
+	base := impl.Super
+	if len(base.StaticValues) != 2 || base.DeclaredField("total", "J").Slot != 0 {
+		t.Errorf("Expected a long static field to take 2 slots, got %d", len(base.StaticValues))
+	}
+
+	if method := impl.DeclaredMethod("run", "()V"); method == nil || method.String() != "void Impl.run()" {
+		t.Errorf("Expected Impl.run to be declared, got %v", method)
+	}
+}
+
+func TestShouldResolveSymbolicReferences(t *testing.T) {
+	dir := t.TempDir()
+	refs := writeResolutionClasses(t, dir)
+
+	loaders := newTestLoaders(t, dir)
+
+	client, err := loaders.Application.LoadClass("Client")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	cp := client.ConstantPool
+
+	field, err := cp.ResolveField(refs.inheritedField)
+	if err != nil || field.Class.Name != "Base" || field.Name != "count" {
+		t.Errorf("Expected Base.count, got %v, %v", field, err)
+	}
+
+	if again, _ := cp.ResolveField(refs.inheritedField); again != field {
+		t.Errorf("Expected the resolved field to be cached")
+	}
+
+	field, err = cp.ResolveField(refs.interfaceField)
+	if err != nil || field.Class.Name != "I" {
+		t.Errorf("Expected I.CONST, got %v, %v", field, err)
+	}
+
+	method, err := cp.ResolveMethod(refs.defaultMethod)
+	if err != nil || method.Class.Name != "J" {
+		t.Errorf("Expected the maximally-specific J.greet, got %v, %v", method, err)
+	}
+
+	method, err = cp.ResolveMethod(refs.superMethod)
+	if err != nil || method.Class.Name != "Base" {
+		t.Errorf("Expected Base.base, got %v, %v", method, err)
+	}
+
+	method, err = cp.ResolveInterfaceMethod(refs.interfaceMethod)
+	if err != nil || method.Class.Name != "I" || !method.IsAbstract() {
+		t.Errorf("Expected I.run, got %v, %v", method, err)
+	}
+}
+
+func TestShouldFailToResolveInvalidReferences(t *testing.T) {
+	dir := t.TempDir()
+	refs := writeResolutionClasses(t, dir)
+
+	loaders := newTestLoaders(t, dir)
+	client, _ := loaders.Application.LoadClass("Client")
+	cp := client.ConstantPool
+
+	_, err := cp.ResolveField(refs.missingField)
+	if err == nil || err.Error() != "java.lang.NoSuchFieldError: Class Impl does not have member field 'int missing'" {
+		t.Errorf("Expected NoSuchFieldError, got %v", err)
+	}
+
+	_, err = cp.ResolveField(refs.privateField)
+	if err == nil || err.Error() != "java.lang.IllegalAccessError: class Client tried to access private field Base.secret" {
+		t.Errorf("Expected IllegalAccessError, got %v", err)
+	}
+
+	_, err = cp.ResolveMethod(refs.missingMethod)
+	if err == nil || err.Error() != "java.lang.NoSuchMethodError: 'long[] Impl.nope(int, java.lang.String)'" {
+		t.Errorf("Expected NoSuchMethodError, got %v", err)
+	}
+
+	_, err = cp.ResolveMethod(refs.interfaceAsClass)
+	if err == nil || err.Error() != "java.lang.IncompatibleClassChangeError: Found interface J, but class was expected" {
+		t.Errorf("Expected IncompatibleClassChangeError, got %v", err)
+	}
+
+	_, err = cp.ResolveInterfaceMethod(refs.classAsInterface)
+	if err == nil || err.Error() != "java.lang.IncompatibleClassChangeError: Found class Impl, but interface was expected" {
+		t.Errorf("Expected IncompatibleClassChangeError, got %v", err)
+	}
+
+	_, first := cp.ResolveClass(refs.missingClass)
+	if !core.IsJavaError(first, core.NoClassDefFoundError) {
+		t.Errorf("Expected NoClassDefFoundError, got %v", first)
+	}
+
+	// the class is now available, but the failed resolution is remembered, each attempt gets its own throwable
+	writeClassFile(t, dir, "Missing", minimalClassFile("Missing"))
+	if _, second := cp.ResolveClass(refs.missingClass); second == nil || second == first || second.Error() != first.Error() {
+		t.Errorf("Expected the same error to be returned, got %v", second)
+	}
+
+	if _, err := cp.ResolveField(refs.missingClass); !core.IsJavaError(err, core.ClassFormatError) {
+		t.Errorf("Expected ClassFormatError for an entry of the wrong type, got %v", err)
+	}
+}
+
+func TestShouldAllowNestmatesToAccessPrivateMembers(t *testing.T) {
+	dir := t.TempDir()
+	writeResolutionClasses(t, dir)
+
+	loaders := newTestLoaders(t, dir)
+
+	nested, err := loaders.Application.LoadClass("Nested")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	attr, _ := nested.File.FindAttribute(nested.File.Attributes, "Secret")
+	field, err := nested.ConstantPool.ResolveField(uint16(attr.Info[0])<<8 | uint16(attr.Info[1]))
+	if err != nil || field.Name != "secret" {
+		t.Errorf("Expected Base.secret to be accessible from its nestmate, got %v, %v", field, err)
+	}
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"fmt"
+	"io"
+)
+
+// StackTraceElement is a frame of a stack trace, as returned by `Throwable.getStackTrace`.
+type StackTraceElement struct {
+	// ModuleName is the name of the module of the class, empty for the unnamed module.
+	ModuleName string
+	// ClassName is the binary name of the class of the method (e.g. com.acme.Main).
+	ClassName string
+	// MethodName is the name of the method.
+	MethodName string
+	// FileName is the name of the source file of the class, empty when it is unknown.
+	FileName string
+	// LineNumber is the line of the source file, -1 when it is unknown and -2 for native methods.
+	LineNumber int
+
+	// class is the class of the method, which the stack trace elements of the class library refer to.
+	class *Class
+}
+
+// String returns the frame the same way `StackTraceElement.toString` does, e.g. `com.acme.Main.run(Main.java:42)`
+// or `java.base/java.lang.Thread.run(Unknown Source)`.
+func (e StackTraceElement) String() string {
+	name := e.ClassName
+	if e.ModuleName != "" {
+		name = e.ModuleName + "/" + name
+	}
+
+	var location string
+	switch {
+	case e.LineNumber == -2:
+		location = "Native Method"
+	case e.FileName == "":
+		location = "Unknown Source"
+	case e.LineNumber >= 0:
+		location = fmt.Sprintf("%s:%d", e.FileName, e.LineNumber)
+	default:
+		location = e.FileName
+	}
+
+	return name + "." + e.MethodName + "(" + location + ")"
+}
+
+// stackTrace returns the stack trace of the given frames of a thread, the innermost first, with the line
+// of the instruction each frame is executing.
+func stackTrace(frames []*Frame) []StackTraceElement {
+	trace := make([]StackTraceElement, len(frames))
+
+	for i, f := range frames {
+		class := f.Method.Class
+
+		trace[len(frames)-1-i] = StackTraceElement{
+			ModuleName: class.ModuleName(),
+			ClassName:  class.JavaName(),
+			MethodName: f.Method.Name,
+			FileName:   class.SourceFile(),
+			LineNumber: f.Method.Code.LineNumber(f.PC),
+			class:      class,
+		}
+	}
+
+	return trace
+}
+
+// PrintStackTrace prints the throwable and its stack trace, followed by the ones of its suppressed exceptions
+// and of its cause, the same way `Throwable.printStackTrace` does, e.g.
+//
+//	java.lang.IllegalStateException
+//		at Main.run(Main.java:12)
+//		at Main.main(Main.java:5)
+//	Caused by: java.lang.ArithmeticException: / by zero
+//		at Main.divide(Main.java:20)
+//		... 2 more
+//
+// The frames that a cause or a suppressed exception has in common with the throwable enclosing it are left out.
+func (e *JavaError) PrintStackTrace(w io.Writer) {
+	e.printEnclosedStackTrace(w, nil, "", "", make(map[*JavaError]bool))
+}
+
+// printEnclosedStackTrace prints the stack trace of a throwable enclosed by another one, with the given caption
+// and indentation. A throwable that was already printed is shown as a circular reference.
+func (e *JavaError) printEnclosedStackTrace(w io.Writer, enclosing []StackTraceElement, caption string, prefix string, printed map[*JavaError]bool) {
+	if printed[e] {
+		fmt.Fprintf(w, "%s%s[CIRCULAR REFERENCE: %s]\n", prefix, caption, e)
+		return
+	}
+
+	printed[e] = true
+
+	// the frames in common are at the bottom of both traces
+	m, n := len(e.StackTrace)-1, len(enclosing)-1
+	for m >= 0 && n >= 0 && e.StackTrace[m] == enclosing[n] {
+		m--
+		n--
+	}
+
+	fmt.Fprintf(w, "%s%s%s\n", prefix, caption, e)
+	for _, element := range e.StackTrace[:m+1] {
+		fmt.Fprintf(w, "%s\tat %s\n", prefix, element)
+	}
+
+	if common := len(e.StackTrace) - 1 - m; common != 0 {
+		fmt.Fprintf(w, "%s\t... %d more\n", prefix, common)
+	}
+
+	for _, suppressed := range e.Suppressed {
+		suppressed.printEnclosedStackTrace(w, e.StackTrace, "Suppressed: ", prefix+"\t", printed)
+	}
+
+	if cause := e.cause(); cause != nil {
+		cause.printEnclosedStackTrace(w, e.StackTrace, "Caused by: ", prefix, printed)
+	}
+}
@@ -0,0 +1,281 @@
+package core_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// lineNumbers builds a LineNumberTable attribute from pairs of start pc and line.
+func lineNumbers(b *testClassBuilder, pairs ...uint16) []byte {
+	info := u2(uint16(len(pairs) / 2))
+	for _, value := range pairs {
+		info = append(info, u2(value)...)
+	}
+
+	return b.attribute("LineNumberTable", info)
+}
+
+// writeTraceClasses writes Boom and Traces, compiled from Traces.java, whose methods throw exceptions at
+// known lines.
+func writeTraceClasses(t *testing.T, dir string) {
+	writeBoomClass(t, dir)
+
+	b := newTestClassBuilder()
+	static := core.ACC_PUBLIC | core.ACC_STATIC
+
+	boom := b.class("Boom")
+	init, initCause := b.methodref("Boom", "<init>", "()V"), b.methodref("Boom", "<init>", "(Ljava/lang/Throwable;)V")
+	divide, throwBoom := b.methodref("Traces", "divide", "(II)I"), b.methodref("Traces", "boom", "()V")
+	wrap := b.methodref("Traces", "wrap", "()V")
+	addSuppressed := b.methodref("java/lang/Throwable", "addSuppressed", "(Ljava/lang/Throwable;)V")
+	getStackTrace := b.methodref("java/lang/Throwable", "getStackTrace", "()[Ljava/lang/StackTraceElement;")
+	getLineNumber := b.methodref("java/lang/StackTraceElement", "getLineNumber", "()I")
+	getCause := b.methodref("java/lang/Throwable", "getCause", "()Ljava/lang/Throwable;")
+	initCauseMethod := b.methodref("java/lang/Throwable", "initCause", "(Ljava/lang/Throwable;)Ljava/lang/Throwable;")
+	printStackTrace := b.methodref("java/lang/Throwable", "printStackTrace", "()V")
+
+	newBoom := concat([]byte{core.OP_NEW}, u2(boom), []byte{core.OP_DUP, core.OP_INVOKESPECIAL}, u2(init))
+
+	method := func(name string, descriptor string, maxStack uint16, maxLocals uint16, bytecode []byte, handlers [][]byte, lines ...uint16) []byte {
+		var attributes [][]byte
+		if len(lines) > 0 {
+			attributes = append(attributes, lineNumbers(b, lines...))
+		}
+
+		return b.member(static, name, descriptor, codeWithHandlers(b, maxStack, maxLocals, bytecode, handlers, attributes...))
+	}
+
+	writeClassFile(t, dir, "Traces", b.build(core.ACC_PUBLIC, "Traces", "java/lang/Object", nil, nil,
+		[][]byte{
+			method("divide", "(II)I", 2, 2, []byte{core.OP_ILOAD_0, core.OP_ILOAD_1, core.OP_IDIV, core.OP_IRETURN}, nil, 0, 20),
+			method("compute", "()I", 2, 0, concat([]byte{core.OP_ICONST_1, core.OP_ICONST_0, core.OP_INVOKESTATIC}, u2(divide), []byte{core.OP_IRETURN}), nil, 0, 10, 2, 11),
+			method("boom", "()V", 2, 0, concat(newBoom, []byte{core.OP_ATHROW}), nil, 0, 30, 7, 31),
+			// try { boom(); } catch (Boom e) { throw new Boom(e); }
+			method("wrap", "()V", 3, 1,
+				concat(
+					[]byte{core.OP_INVOKESTATIC}, u2(throwBoom), []byte{core.OP_RETURN},
+					[]byte{core.OP_ASTORE_0, core.OP_NEW}, u2(boom), []byte{core.OP_DUP, core.OP_ALOAD_0, core.OP_INVOKESPECIAL}, u2(initCause), []byte{core.OP_ATHROW},
+				),
+				[][]byte{handler(0, 3, 4, boom)}, 0, 40, 4, 42,
+			),
+			method("run", "()V", 0, 0, concat([]byte{core.OP_INVOKESTATIC}, u2(wrap), []byte{core.OP_RETURN}), nil, 0, 50),
+			method("suppress", "()V", 3, 1,
+				concat(newBoom, []byte{core.OP_ASTORE_0, core.OP_ALOAD_0}, newBoom, []byte{core.OP_INVOKEVIRTUAL}, u2(addSuppressed), []byte{core.OP_ALOAD_0, core.OP_ATHROW}),
+				nil, 0, 60,
+			),
+			method("line", "()I", 2, 0,
+				concat(newBoom, []byte{core.OP_INVOKEVIRTUAL}, u2(getStackTrace), []byte{core.OP_ICONST_0, core.OP_AALOAD, core.OP_INVOKEVIRTUAL}, u2(getLineNumber), []byte{core.OP_IRETURN}),
+				nil, 0, 70,
+			),
+			// try { wrap(); } catch (Boom e) { return e.getCause(); }
+			method("cause", "()Ljava/lang/Throwable;", 1, 0,
+				concat([]byte{core.OP_INVOKESTATIC}, u2(wrap), []byte{core.OP_ACONST_NULL, core.OP_ARETURN, core.OP_INVOKEVIRTUAL}, u2(getCause), []byte{core.OP_ARETURN}),
+				[][]byte{handler(0, 3, 5, boom)},
+			),
+			// try { wrap(); } catch (Boom e) { e.printStackTrace(); return e.getStackTrace(); }
+			method("trace", "()[Ljava/lang/StackTraceElement;", 1, 1,
+				concat(
+					[]byte{core.OP_INVOKESTATIC}, u2(wrap), []byte{core.OP_ACONST_NULL, core.OP_ARETURN},
+					[]byte{core.OP_ASTORE_0, core.OP_ALOAD_0, core.OP_INVOKEVIRTUAL}, u2(printStackTrace),
+					[]byte{core.OP_ALOAD_0, core.OP_INVOKEVIRTUAL}, u2(getStackTrace), []byte{core.OP_ARETURN},
+				),
+				[][]byte{handler(0, 3, 5, boom)}, 0, 80, 5, 82,
+			),
+			method("reinit", "()V", 3, 1,
+				concat(
+					newBoom, []byte{core.OP_ASTORE_0, core.OP_ALOAD_0, core.OP_ACONST_NULL, core.OP_INVOKEVIRTUAL}, u2(initCauseMethod), []byte{core.OP_POP},
+					[]byte{core.OP_ALOAD_0}, newBoom, []byte{core.OP_INVOKEVIRTUAL}, u2(initCauseMethod), []byte{core.OP_POP, core.OP_RETURN},
+				),
+				nil,
+			),
+		},
+		[][]byte{b.attribute("SourceFile", u2(b.utf8("Traces.java")))},
+	))
+}
+
+func TestShouldFindTheLinesOfTheInstructions(t *testing.T) {
+	dir := t.TempDir()
+	writeTraceClasses(t, dir)
+
+	traces, err := newTestLoaders(t, dir).Application.LoadClass("Traces")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if traces.SourceFile() != "Traces.java" {
+		t.Errorf("Expected the source file to be Traces.java, got %q", traces.SourceFile())
+	}
+
+	code := traces.DeclaredMethod("wrap", "()V").Code
+	for pc, expected := range map[int]int{0: 40, 3: 40, 4: 42, 13: 42} {
+		if line := code.LineNumber(pc); line != expected {
+			t.Errorf("Expected the line of pc %d to be %d, got %d", pc, expected, line)
+		}
+	}
+
+	if line := traces.DeclaredMethod("reinit", "()V").Code.LineNumber(0); line != -1 {
+		t.Errorf("Expected the line of code without line numbers to be unknown, got %d", line)
+	}
+}
+
+func TestShouldFillInTheStackTraceOfExceptions(t *testing.T) {
+	dir := t.TempDir()
+	writeTraceClasses(t, dir)
+
+	traces, err := newTestLoaders(t, dir).Application.LoadClass("Traces")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	stackTraceOf := func(method string) string {
+		_, err := invokeSlots(t, traces, method, "()V")
+
+		var thrown *core.JavaError
+		if !errors.As(err, &thrown) {
+			t.Fatalf("Expected %s to throw, got %v", method, err)
+		}
+
+		var out bytes.Buffer
+		thrown.PrintStackTrace(&out)
+
+		return out.String()
+	}
+
+	_, err = invokeSlots(t, traces, "compute", "()I")
+
+	var thrown *core.JavaError
+	if !errors.As(err, &thrown) || len(thrown.StackTrace) != 2 ||
+		thrown.StackTrace[0].String() != "Traces.divide(Traces.java:20)" || thrown.StackTrace[1].String() != "Traces.compute(Traces.java:11)" {
+		t.Errorf("Expected the stack trace of the ArithmeticException, got %v", err)
+	}
+
+	// the frames of the constructors of Boom are left out
+	expected := "Boom: Boom\n" +
+		"\tat Traces.wrap(Traces.java:42)\n" +
+		"\tat Traces.run(Traces.java:50)\n" +
+		"Caused by: Boom\n" +
+		"\tat Traces.boom(Traces.java:30)\n" +
+		"\tat Traces.wrap(Traces.java:40)\n" +
+		"\t... 1 more\n"
+	if actual := stackTraceOf("run"); actual != expected {
+		t.Errorf("Expected the stack trace\n%s\ngot\n%s", expected, actual)
+	}
+
+	expected = "Boom\n" +
+		"\tat Traces.suppress(Traces.java:60)\n" +
+		"\tSuppressed: Boom\n" +
+		"\t\t... 1 more\n"
+	if actual := stackTraceOf("suppress"); actual != expected {
+		t.Errorf("Expected the stack trace\n%s\ngot\n%s", expected, actual)
+	}
+
+	if line, err := invokeInt(t, traces, "line", "()I"); err != nil || line != 70 {
+		t.Errorf("Expected getStackTrace to return the line of the exception, got %d (%v)", line, err)
+	}
+
+	if ret, err := invokeSlots(t, traces, "cause", "()Ljava/lang/Throwable;"); err != nil || ret[0].Ref == nil || ret[0].Ref.Class.Name != "Boom" {
+		t.Errorf("Expected getCause to return the Boom, got %v (%v)", ret, err)
+	}
+
+	_, err = invokeSlots(t, traces, "reinit", "()V")
+	if err == nil || err.Error() != "java.lang.IllegalStateException: Can't overwrite cause with Boom" {
+		t.Errorf("Expected an IllegalStateException, got %v", err)
+	}
+}
+
+func TestShouldReturnThePrintedFramesFromGetStackTrace(t *testing.T) {
+	dir := t.TempDir()
+	writeTraceClasses(t, dir)
+
+	traces, err := newTestLoaders(t, dir).Application.LoadClass("Traces")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var out bytes.Buffer
+	previous := core.SystemErr
+	core.SystemErr = &out
+	t.Cleanup(func() { core.SystemErr = previous })
+
+	ret, err := invokeSlots(t, traces, "trace", "()[Ljava/lang/StackTraceElement;")
+	if err != nil || ret[0].Ref == nil {
+		t.Fatalf("Expected the stack trace, got %v (%v)", ret, err)
+	}
+
+	// the frames of the throwable, before its cause
+	printed, _, _ := strings.Cut(out.String(), "Caused by:")
+	lines := strings.Split(strings.TrimSpace(printed), "\n")[1:]
+
+	elements := ret[0].Ref.Elements.([]*core.Object)
+	if len(elements) != len(lines) {
+		t.Fatalf("Expected %d frames like the printed ones, got %d\n%s", len(lines), len(elements), out.String())
+	}
+
+	for i, element := range elements {
+		field := func(name string) string {
+			if str := element.Fields[element.Class.DeclaredField(name, "Ljava/lang/String;").Slot].Ref; str != nil {
+				return core.GoString(str)
+			}
+
+			return ""
+		}
+
+		frame := core.StackTraceElement{
+			ModuleName: field("moduleName"),
+			ClassName:  field("declaringClass"),
+			MethodName: field("methodName"),
+			FileName:   field("fileName"),
+			LineNumber: int(element.Fields[element.Class.DeclaredField("lineNumber", "I").Slot].Num),
+		}
+
+		if actual := "\tat " + frame.String(); actual != lines[i] {
+			t.Errorf("Expected frame %d to be %q, got %q", i, lines[i], actual)
+		}
+	}
+
+	// the names are interned
+	declaringClass := elements[0].Class.DeclaredField("declaringClass", "Ljava/lang/String;").Slot
+	if elements[0].Fields[declaringClass].Ref != elements[1].Fields[declaringClass].Ref {
+		t.Errorf("Expected the class names of the frames to be the same string")
+	}
+}
+
+func TestShouldPrintStackTracesLikeTheReferenceImplementation(t *testing.T) {
+	main := core.StackTraceElement{ClassName: "Main", MethodName: "main", FileName: "Main.java", LineNumber: 5}
+	run := core.StackTraceElement{ClassName: "Main", MethodName: "run", FileName: "Main.java", LineNumber: 12}
+	sleep := core.StackTraceElement{ModuleName: "java.base", ClassName: "java.lang.Thread", MethodName: "sleep0", LineNumber: -2}
+	load := core.StackTraceElement{ClassName: "Lib", MethodName: "load", FileName: "Lib.java", LineNumber: -1}
+	call := core.StackTraceElement{ClassName: "Lib", MethodName: "call", LineNumber: 3}
+
+	cause := &core.JavaError{ClassName: core.ArithmeticException, Message: "/ by zero", StackTrace: []core.StackTraceElement{call, run, main}}
+	err := &core.JavaError{
+		ClassName:  core.IllegalStateException,
+		Message:    "msg",
+		Cause:      cause,
+		Suppressed: []*core.JavaError{{ClassName: core.IllegalStateException, StackTrace: []core.StackTraceElement{sleep, load, main}}},
+		StackTrace: []core.StackTraceElement{run, main},
+	}
+	cause.Cause = err
+
+	var out bytes.Buffer
+	(&core.UncaughtException{Thread: core.MainThreadName, Err: err}).PrintStackTrace(&out)
+
+	expected := "Exception in thread \"main\" java.lang.IllegalStateException: msg\n" +
+		"\tat Main.run(Main.java:12)\n" +
+		"\tat Main.main(Main.java:5)\n" +
+		"\tSuppressed: java.lang.IllegalStateException\n" +
+		"\t\tat java.base/java.lang.Thread.sleep0(Native Method)\n" +
+		"\t\tat Lib.load(Lib.java)\n" +
+		"\t\t... 1 more\n" +
+		"Caused by: java.lang.ArithmeticException: / by zero\n" +
+		"\tat Lib.call(Unknown Source)\n" +
+		"\t... 2 more\n" +
+		"Caused by: [CIRCULAR REFERENCE: java.lang.IllegalStateException: msg]\n"
+	if out.String() != expected {
+		t.Errorf("Expected the stack trace\n%s\ngot\n%s", expected, out.String())
+	}
+}
@@ -0,0 +1,312 @@
+package core
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+	"unsafe"
+)
+
+// Spec: https://docs.oracle.com/en/java/javase/21/docs/api/java.base/java/lang/String.html
+
+// stringClass is the internal name of the class of strings.
+const stringClass = "java/lang/String"
+
+// Coders of the `value` of a string, which tell how its chars are stored.
+const (
+	stringLatin1 = 0
+	stringUTF16  = 1
+)
+
+// newString allocates a java/lang/String with the given UTF-16 code units, see `setStringChars`.
+func newString(loader *ClassLoader, chars []uint16) (*Object, error) {
+	class, err := loader.bootstrap().LoadClass(stringClass)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := NewObject(class)
+
+	return obj, setStringChars(obj, chars)
+}
+
+// NewString allocates a java/lang/String with the contents of a Go string, invalid UTF-8 is decoded as
+// U+FFFD. The bytes of ASCII strings, the most common ones, are copied as they are into the value.
+func NewString(loader *ClassLoader, s string) (*Object, error) {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return newString(loader, utf16Chars(s))
+		}
+	}
+
+	class, err := loader.bootstrap().LoadClass(stringClass)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := NewObject(class)
+
+	value, err := setStringValue(obj, len(s), stringLatin1)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range value {
+		value[i] = int8(s[i])
+	}
+
+	return obj, nil
+}
+
+// setStringChars sets the UTF-16 code units of a java/lang/String, laid out like the compact strings of the
+// JDK: the `value` byte array holds one byte per char when every char is in Latin-1, with a `coder` of
+// LATIN1, or two bytes per char otherwise, low byte first as HotSpot does on little-endian machines.
+func setStringChars(obj *Object, chars []uint16) error {
+	coder := stringLatin1
+	for _, char := range chars {
+		if char > math.MaxUint8 {
+			coder = stringUTF16
+			break
+		}
+	}
+
+	elements, err := setStringValue(obj, len(chars), coder)
+	if err != nil {
+		return err
+	}
+
+	for i, char := range chars {
+		if coder == stringLatin1 {
+			elements[i] = int8(char)
+			continue
+		}
+
+		elements[2*i], elements[2*i+1] = int8(char), int8(char>>8)
+	}
+
+	return nil
+}
+
+// setStringValue sets the `value` of a java/lang/String to a new byte array for the given number of chars
+// with the given coder, and returns its elements to be filled.
+func setStringValue(obj *Object, length int, coder int) ([]int8, error) {
+	valueField, coderField := obj.Class.DeclaredField("value", "[B"), obj.Class.DeclaredField("coder", "B")
+	if valueField == nil || coderField == nil {
+		return nil, NewJavaError(InternalError, "%s does not have the fields of compact strings", javaName(stringClass))
+	}
+
+	bytes, err := obj.Class.Loader.bootstrap().LoadClass("[B")
+	if err != nil {
+		return nil, err
+	}
+
+	value := NewArray(bytes, length<<coder)
+
+	obj.Fields[valueField.Slot].Ref = value
+	obj.Fields[coderField.Slot].Num = int32(coder)
+
+	return value.Elements.([]int8), nil
+}
+
+// stringValue returns the elements of the `value` of a java/lang/String and its coder, or false when the
+// string does not have one.
+func stringValue(obj *Object) ([]int8, int32, bool) {
+	valueField, coderField := obj.Class.DeclaredField("value", "[B"), obj.Class.DeclaredField("coder", "B")
+	if valueField == nil || coderField == nil || obj.Fields[valueField.Slot].Ref == nil {
+		return nil, 0, false
+	}
+
+	return obj.Fields[valueField.Slot].Ref.Elements.([]int8), obj.Fields[coderField.Slot].Num, true
+}
+
+// stringChars returns the UTF-16 code units of a java/lang/String.
+func stringChars(obj *Object) []uint16 {
+	elements, coder, ok := stringValue(obj)
+	if !ok {
+		return nil
+	}
+
+	if coder == stringLatin1 {
+		chars := make([]uint16, len(elements))
+		for i, b := range elements {
+			chars[i] = uint16(uint8(b))
+		}
+
+		return chars
+	}
+
+	chars := make([]uint16, len(elements)/2)
+	for i := range chars {
+		chars[i] = uint16(uint8(elements[2*i])) | uint16(uint8(elements[2*i+1]))<<8
+	}
+
+	return chars
+}
+
+// utf16Chars returns the UTF-16 code units of a Go string.
+func utf16Chars(s string) []uint16 {
+	return utf16.Encode([]rune(s))
+}
+
+// GoString returns the contents of a java/lang/String as a Go string, unpaired surrogates become U+FFFD.
+// Latin-1 strings are encoded straight from their value.
+func GoString(obj *Object) string {
+	elements, coder, ok := stringValue(obj)
+	if !ok || coder != stringLatin1 {
+		return string(utf16.Decode(stringChars(obj)))
+	}
+
+	var b strings.Builder
+	b.Grow(len(elements))
+
+	for _, char := range elements {
+		if uint8(char) < utf8.RuneSelf {
+			b.WriteByte(uint8(char))
+			continue
+		}
+
+		b.WriteRune(rune(uint8(char)))
+	}
+
+	return b.String()
+}
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-4.html#jvms-4.4.7
+
+// modifiedUTF8Chars decodes the modified UTF-8 of a CONSTANT_Utf8_info entry into UTF-16 code units. Its
+// supplementary characters are already encoded as surrogate pairs, each surrogate taking three bytes.
+// Malformed bytes are decoded as U+FFFD.
+func modifiedUTF8Chars(b []byte) []uint16 {
+	chars := make([]uint16, 0, len(b))
+
+	for i := 0; i < len(b); {
+		switch c := b[i]; {
+		case c < 0x80:
+			chars = append(chars, uint16(c))
+			i++
+		case c&0xe0 == 0xc0 && i+1 < len(b) && b[i+1]&0xc0 == 0x80:
+			chars = append(chars, uint16(c&0x1f)<<6|uint16(b[i+1]&0x3f))
+			i += 2
+		case c&0xf0 == 0xe0 && i+2 < len(b) && b[i+1]&0xc0 == 0x80 && b[i+2]&0xc0 == 0x80:
+			chars = append(chars, uint16(c&0x0f)<<12|uint16(b[i+1]&0x3f)<<6|uint16(b[i+2]&0x3f))
+			i += 3
+		default:
+			chars = append(chars, unicode.ReplacementChar)
+			i++
+		}
+	}
+
+	return chars
+}
+
+// Spec: https://docs.oracle.com/javase/specs/jvms/se21/html/jvms-5.html#jvms-5.1
+
+// internString returns the interned java/lang/String with the given chars, which is allocated the first time.
+// The strings are interned by the bootstrap loader, so equal literals are the same object across the VM.
+func internString(loader *ClassLoader, chars []uint16) (*Object, error) {
+	boot := loader.bootstrap()
+
+	key := internKey(chars)
+	if str, ok := boot.interned.Load(key); ok {
+		return str.(*Object), nil
+	}
+
+	str, err := newString(boot, chars)
+	if err != nil {
+		return nil, err
+	}
+
+	// another thread may have interned the same chars meanwhile, the first one wins
+	interned, _ := boot.interned.LoadOrStore(key, str)
+
+	return interned.(*Object), nil
+}
+
+// intern returns the interned java/lang/String equal to the given one, which becomes the interned one when
+// there is none, as `String.intern` does.
+func intern(str *Object) *Object {
+	interned, _ := str.Class.Loader.bootstrap().interned.LoadOrStore(internKey(stringChars(str)), str)
+	return interned.(*Object)
+}
+
+// internKey returns the key of the chars of a string in the table of interned strings, their bytes in memory,
+// since unpaired surrogates would make distinct strings equal once decoded to a Go string.
+func internKey(chars []uint16) string {
+	return string(unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(chars))), 2*len(chars)))
+}
+
+// resolveString returns the java/lang/String of the CONSTANT_String_info entry at the given index, the
+// interned string with its chars, see `internString`.
+func (p *RuntimeConstantPool) resolveString(index uint16) (*Object, error) {
+	str, err := p.resolve(index, func() (any, error) {
+		chars, err := p.stringConstant(index)
+		if err != nil {
+			return nil, err
+		}
+
+		return internString(p.class.Loader, chars)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return str.(*Object), nil
+}
+
+// stringConstant returns the UTF-16 code units of the CONSTANT_String_info entry at the given index.
+func (p *RuntimeConstantPool) stringConstant(index uint16) ([]uint16, error) {
+	entry, err := p.entry(index, CONSTANT_String)
+	if err != nil {
+		return nil, err
+	}
+
+	utf8Index := entry.Info.(StringInfo).StringIndex
+	if _, err := p.class.File.Utf8(utf8Index); err != nil {
+		return nil, NewJavaError(ClassFormatError, "%w", err)
+	}
+
+	return modifiedUTF8Chars(p.class.File.ConstantPool[utf8Index-1].Info.(UTF8Info).Bytes), nil
+}
+
+// Spec: https://docs.oracle.com/en/java/javase/21/docs/api/java.base/java/lang/Double.html#toString(double)
+
+// floatString formats a float or double, given its size in bits, like `Float.toString` and `Double.toString`:
+// the shortest decimal that rounds to it, in plain notation with at least one digit after the point from
+// 10^-3 up to 10^7, and in computerized scientific notation, like `1.0E10`, otherwise.
+func floatString(v float64, bits int) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	case v == 0 && math.Signbit(v):
+		return "-0.0"
+	case v == 0:
+		return "0.0"
+	}
+
+	if abs := math.Abs(v); abs >= 1e-3 && abs < 1e7 {
+		s := strconv.FormatFloat(v, 'f', -1, bits)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+
+		return s
+	}
+
+	s := strconv.FormatFloat(v, 'e', -1, bits)
+	mantissa, exponent, _ := strings.Cut(s, "e")
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+
+	// the exponent was formatted by strconv, it is always a number
+	e, _ := strconv.Atoi(exponent)
+
+	return mantissa + "E" + strconv.Itoa(e)
+}
@@ -0,0 +1,179 @@
+package core
+
+import "strconv"
+
+// Spec: https://docs.oracle.com/en/java/javase/21/docs/api/java.base/java/lang/invoke/StringConcatFactory.html
+
+// Tags of the recipe of `StringConcatFactory.makeConcatWithConstants`.
+const (
+	concatArgument = '\u0001'
+	concatConstant = '\u0002'
+)
+
+// makeConcatWithConstants links the call sites javac generates for string concatenations, like
+// `StringConcatFactory.makeConcatWithConstants`. The first static argument is the recipe, whose chars are
+// copied to the result, except for the tags replaced by the next argument of the call site and by the next
+// constant, the other static arguments.
+func makeConcatWithConstants(caller *Class, name string, descriptor string, args []uint16) (func(t *Thread, args []Slot) ([]Slot, error), error) {
+	if len(args) == 0 {
+		return nil, NewJavaError(BootstrapMethodError, "StringConcatFactory.makeConcatWithConstants takes a recipe")
+	}
+
+	pool := caller.ConstantPool
+
+	recipe, err := pool.stringConstant(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	constants := make([][]uint16, len(args)-1)
+	for i, index := range args[1:] {
+		if constants[i], err = pool.concatConstant(index); err != nil {
+			return nil, err
+		}
+	}
+
+	return newConcat(caller, descriptor, recipe, constants)
+}
+
+// makeConcat links string concatenations without constants, like `StringConcatFactory.makeConcat`, whose
+// recipe is a tag for each argument.
+func makeConcat(caller *Class, name string, descriptor string, args []uint16) (func(t *Thread, args []Slot) ([]Slot, error), error) {
+	params, _, _ := ParseMethodDescriptor(descriptor)
+
+	recipe := make([]uint16, len(params))
+	for i := range recipe {
+		recipe[i] = concatArgument
+	}
+
+	return newConcat(caller, descriptor, recipe, nil)
+}
+
+// newConcat returns the target of a string concatenation call site, which checks that the tags of the recipe
+// match the arguments and the constants, with the messages of the StringConcatException of the reference
+// implementation.
+func newConcat(caller *Class, descriptor string, recipe []uint16, constants [][]uint16) (func(t *Thread, args []Slot) ([]Slot, error), error) {
+	params, ret, _ := ParseMethodDescriptor(descriptor)
+	if ret != "L"+stringClass+";" {
+		return nil, NewJavaError(BootstrapMethodError, "The return type should be compatible with String, but it is %s", TypeName(ret))
+	}
+
+	arguments, constantTags := 0, 0
+	for _, char := range recipe {
+		switch char {
+		case concatArgument:
+			arguments++
+		case concatConstant:
+			constantTags++
+		}
+	}
+
+	if arguments != len(params) {
+		return nil, NewJavaError(
+			BootstrapMethodError, "Mismatched number of concat arguments: recipe wants %d arguments, but signature provides %d", arguments, len(params),
+		)
+	}
+
+	if constantTags != len(constants) {
+		return nil, NewJavaError(
+			BootstrapMethodError, "Mismatched number of concat constants: recipe wants %d constants, but only %d are passed", constantTags, len(constants),
+		)
+	}
+
+	return func(t *Thread, args []Slot) ([]Slot, error) {
+		chars := make([]uint16, 0, len(recipe))
+		param, constant := 0, 0
+
+		for _, char := range recipe {
+			switch char {
+			case concatArgument:
+				n := DescriptorSlots(params[param])
+
+				value, err := t.stringValueOf(args[:n], params[param])
+				if err != nil {
+					return nil, err
+				}
+
+				chars = append(chars, value...)
+				args, param = args[n:], param+1
+			case concatConstant:
+				chars = append(chars, constants[constant]...)
+				constant++
+			default:
+				chars = append(chars, char)
+			}
+		}
+
+		obj, err := newString(caller.Loader, chars)
+		if err != nil {
+			return nil, err
+		}
+
+		return []Slot{{Ref: obj}}, nil
+	}, nil
+}
+
+// concatConstant returns the chars of a constant of a string concatenation, a CONSTANT_String_info or the
+// string of a numeric constant.
+func (p *RuntimeConstantPool) concatConstant(index uint16) ([]uint16, error) {
+	entry, err := p.entry(index, CONSTANT_String, CONSTANT_Integer, CONSTANT_Float, CONSTANT_Long, CONSTANT_Double)
+	if err != nil {
+		return nil, err
+	}
+
+	var value string
+
+	switch entry.Tag {
+	case CONSTANT_String:
+		return p.stringConstant(index)
+	case CONSTANT_Integer:
+		value = strconv.Itoa(int(int32(entry.Info.(Numeric32BitsInfo).Value)))
+	case CONSTANT_Float:
+		value = floatString(float64(SlotFloat(Slot{Num: int32(entry.Info.(Numeric32BitsInfo).Value)})), 32)
+	case CONSTANT_Long:
+		value = strconv.FormatInt(int64(entry.Info.(Numeric64BitsInfo).Value), 10)
+	case CONSTANT_Double:
+		value = floatString(SlotsDouble(LongSlots(int64(entry.Info.(Numeric64BitsInfo).Value))), 64)
+	}
+
+	return utf16Chars(value), nil
+}
+
+// stringValueOf returns the chars of a value of the type of a field descriptor like `String.valueOf`: null
+// references are "null", strings are themselves and other objects are the result of their `toString` method.
+func (t *Thread) stringValueOf(value []Slot, descriptor string) ([]uint16, error) {
+	var s string
+
+	switch descriptor {
+	case "Z":
+		s = strconv.FormatBool(value[0].Num != 0)
+	case "C":
+		return []uint16{uint16(value[0].Num)}, nil
+	case "B", "S", "I":
+		s = strconv.Itoa(int(value[0].Num))
+	case "J":
+		s = strconv.FormatInt(SlotsLong(value), 10)
+	case "F":
+		s = floatString(float64(SlotFloat(value[0])), 32)
+	case "D":
+		s = floatString(SlotsDouble(value), 64)
+	default:
+		obj := value[0].Ref
+		if obj == nil {
+			return []uint16{'n', 'u', 'l', 'l'}, nil
+		}
+
+		if obj.Class.Name == stringClass {
+			return stringChars(obj), nil
+		}
+
+		ret, err := t.callMethod(obj, "toString", "()L"+stringClass+";")
+		if err != nil {
+			return nil, err
+		}
+
+		return t.stringValueOf(ret, "L"+stringClass+";")
+	}
+
+	return utf16Chars(s), nil
+}
@@ -0,0 +1,177 @@
+package core_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// writeConcatClass writes Concat, whose methods concatenate strings like the code javac generates.
+func writeConcatClass(t *testing.T, dir string) {
+	b := newTestClassBuilder()
+	public, static := core.ACC_PUBLIC, core.ACC_PUBLIC|core.ACC_STATIC
+
+	withConstants := b.methodHandle(core.REF_invokeStatic, b.methodref("java/lang/invoke/StringConcatFactory", "makeConcatWithConstants",
+		"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;Ljava/lang/String;[Ljava/lang/Object;)"+
+			"Ljava/lang/invoke/CallSite;",
+	))
+	withoutConstants := b.methodHandle(core.REF_invokeStatic, b.methodref("java/lang/invoke/StringConcatFactory", "makeConcat",
+		"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;)Ljava/lang/invoke/CallSite;",
+	))
+
+	bootstrapMethods := b.bootstrapMethods(
+		[]uint16{
+			withConstants, b.str("z=\x01 c=\x01 b=\x01 s=\x01 i=\x01 j=\x01 f=\x01 d=\x01 o=\x01 \x02\x02"),
+			b.str("\x01"), b.entry(core.CONSTANT_Integer, u4(7)),
+		},
+		[]uint16{withConstants, b.str("Concat!")},
+		[]uint16{withConstants, b.str("→\x01")},
+		[]uint16{withoutConstants},
+		[]uint16{withConstants, b.str("\x01\x01")},
+	)
+
+	indy := func(bootstrap uint16, descriptor string) []byte {
+		return concat([]byte{core.OP_INVOKEDYNAMIC}, u2(b.invokeDynamic(bootstrap, "makeConcatWithConstants", descriptor)), []byte{0, 0})
+	}
+
+	all := "(ZCBSIJFDLjava/lang/Object;)Ljava/lang/String;"
+
+	writeClassFile(t, dir, "Concat", b.build(public, "Concat", "java/lang/Object", nil, nil,
+		[][]byte{
+			b.member(static, "all", all, b.code(11, 11, concat(
+				[]byte{core.OP_ILOAD_0, core.OP_ILOAD_1, core.OP_ILOAD_2, core.OP_ILOAD_3, core.OP_ILOAD, 4, core.OP_LLOAD, 5},
+				[]byte{core.OP_FLOAD, 7, core.OP_DLOAD, 8, core.OP_ALOAD, 10}, indy(0, all), []byte{core.OP_ARETURN},
+			)...)),
+			b.member(public, "toString", "()Ljava/lang/String;", b.code(1, 1, concat(indy(1, "()Ljava/lang/String;"), []byte{core.OP_ARETURN})...)),
+			b.member(static, "arrow", "(C)Ljava/lang/String;", b.code(1, 1, concat([]byte{core.OP_ILOAD_0}, indy(2, "(C)Ljava/lang/String;"), []byte{core.OP_ARETURN})...)),
+			b.member(static, "join", "(Ljava/lang/Object;I)Ljava/lang/String;", b.code(2, 2, concat(
+				[]byte{core.OP_ALOAD_0, core.OP_ILOAD_1}, indy(3, "(Ljava/lang/Object;I)Ljava/lang/String;"), []byte{core.OP_ARETURN},
+			)...)),
+			b.member(static, "mismatched", "(I)Ljava/lang/String;", b.code(1, 1, concat([]byte{core.OP_ILOAD_0}, indy(4, "(I)Ljava/lang/String;"), []byte{core.OP_ARETURN})...)),
+		},
+		[][]byte{bootstrapMethods},
+	))
+}
+
+func TestShouldConcatenateStringsWithTheRecipeOfTheCallSite(t *testing.T) {
+	dir := t.TempDir()
+	writeConcatClass(t, dir)
+
+	class, err := newTestLoaders(t, dir).Application.LoadClass("Concat")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	all := func(z int32, c int32, b int32, s int32, i int32, j int64, f float32, d float64, o *core.Object) string {
+		ret, err := invokeSlots(t, class, "all", "(ZCBSIJFDLjava/lang/Object;)Ljava/lang/String;",
+			[]core.Slot{{Num: z}, {Num: c}, {Num: b}, {Num: s}, {Num: i}}, core.LongSlots(j), []core.Slot{core.FloatSlot(f)}, core.DoubleSlots(d),
+			[]core.Slot{{Ref: o}},
+		)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		return core.GoString(ret[0].Ref)
+	}
+
+	for _, test := range []struct {
+		actual   string
+		expected string
+	}{
+		{
+			all(1, 'x', -1, 300, 42, 1<<40, 0.1, 1e10, core.NewObject(class)),
+			"z=true c=x b=-1 s=300 i=42 j=1099511627776 f=0.1 d=1.0E10 o=Concat! \x017",
+		},
+		{
+			all(0, '0', 0, 0, math.MinInt32, math.MaxInt64, 1e-5, 0.001, nil),
+			"z=false c=0 b=0 s=0 i=-2147483648 j=9223372036854775807 f=1.0E-5 d=0.001 o=null \x017",
+		},
+		{
+			all(0, ' ', 0, 0, 0, 0, float32(math.Inf(-1)), math.Copysign(0, -1), nil),
+			"z=false c=  b=0 s=0 i=0 j=0 f=-Infinity d=-0.0 o=null \x017",
+		},
+		{all(0, ' ', 0, 0, 0, 0, 100, math.NaN(), nil), "z=false c=  b=0 s=0 i=0 j=0 f=100.0 d=NaN o=null \x017"},
+		{all(0, ' ', 0, 0, 0, 0, 1234567, 1e7, nil), "z=false c=  b=0 s=0 i=0 j=0 f=1234567.0 d=1.0E7 o=null \x017"},
+	} {
+		if test.actual != test.expected {
+			t.Errorf("Expected %q, got %q", test.expected, test.actual)
+		}
+	}
+
+	// the strings are arguments too
+	arrow, err := invokeSlots(t, class, "arrow", "(C)Ljava/lang/String;", []core.Slot{{Num: '€'}})
+	if err != nil || core.GoString(arrow[0].Ref) != "→€" {
+		t.Fatalf("Expected \"→€\", got %v (%v)", arrow, err)
+	}
+
+	joined, err := invokeSlots(t, class, "join", "(Ljava/lang/Object;I)Ljava/lang/String;", arrow, []core.Slot{{Num: 1}})
+	if err != nil || core.GoString(joined[0].Ref) != "→€1" {
+		t.Errorf("Expected \"→€1\", got %v (%v)", joined, err)
+	}
+}
+
+func TestShouldKeepTheCharsOfStringsInTheirCompactForm(t *testing.T) {
+	dir := t.TempDir()
+	writeConcatClass(t, dir)
+
+	class, err := newTestLoaders(t, dir).Application.LoadClass("Concat")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, test := range []struct {
+		char  int32
+		coder int32
+		value []int8
+	}{
+		// the arrow is not in Latin-1, its chars take two bytes low byte first
+		{'é', 1, []int8{-110, 33, -23, 0}},
+		{0x2603, 1, []int8{-110, 33, 3, 38}},
+	} {
+		ret, err := invokeSlots(t, class, "arrow", "(C)Ljava/lang/String;", []core.Slot{{Num: test.char}})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		str := ret[0].Ref
+		value, coder := str.Class.DeclaredField("value", "[B"), str.Class.DeclaredField("coder", "B")
+
+		elements := str.Fields[value.Slot].Ref.Elements.([]int8)
+		if str.Fields[coder.Slot].Num != test.coder || len(elements) != len(test.value) {
+			t.Fatalf("Expected the coder %d and the value %v, got %d and %v", test.coder, test.value, str.Fields[coder.Slot].Num, elements)
+		}
+
+		for i := range elements {
+			if elements[i] != test.value[i] {
+				t.Errorf("Expected the value %v, got %v", test.value, elements)
+			}
+		}
+	}
+
+	latin1, err := invokeSlots(t, class, "join", "(Ljava/lang/Object;I)Ljava/lang/String;", []core.Slot{{}}, []core.Slot{{Num: 5}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	str := latin1[0].Ref
+	if coder := str.Fields[str.Class.DeclaredField("coder", "B").Slot].Num; coder != 0 || core.GoString(str) != "null5" {
+		t.Errorf("Expected \"null5\" with the LATIN1 coder, got %q with %d", core.GoString(str), coder)
+	}
+}
+
+func TestShouldNotLinkConcatenationsWhoseRecipeDoesNotMatchTheArguments(t *testing.T) {
+	dir := t.TempDir()
+	writeConcatClass(t, dir)
+
+	class, err := newTestLoaders(t, dir).Application.LoadClass("Concat")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	_, err = invokeSlots(t, class, "mismatched", "(I)Ljava/lang/String;", []core.Slot{{Num: 1}})
+	expected := "java.lang.BootstrapMethodError: Mismatched number of concat arguments: recipe wants 2 arguments, but signature provides 1"
+	if !core.IsJavaError(err, core.BootstrapMethodError) || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,111 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// writeInternClasses writes A, with a constant and a literal "hello", and B, which loads the same literal, the
+// constant of A and interns strings.
+func writeInternClasses(t *testing.T, dir string) {
+	static := core.ACC_PUBLIC | core.ACC_STATIC
+
+	b := newTestClassBuilder()
+	writeClassFile(t, dir, "A", b.build(core.ACC_PUBLIC, "A", "java/lang/Object", nil,
+		[][]byte{b.member(static|core.ACC_FINAL, "GREETING", "Ljava/lang/String;", b.attribute("ConstantValue", u2(b.str("hello"))))},
+		[][]byte{b.member(static, "literal", "()Ljava/lang/String;", b.code(1, 0, core.OP_LDC, byte(b.str("hello")), core.OP_ARETURN))},
+		nil,
+	))
+
+	b = newTestClassBuilder()
+	greeting := b.fieldref("A", "GREETING", "Ljava/lang/String;")
+	intern := b.methodref("java/lang/String", "intern", "()Ljava/lang/String;")
+	writeClassFile(t, dir, "B", b.build(core.ACC_PUBLIC, "B", "java/lang/Object", nil, nil,
+		[][]byte{
+			b.member(static, "literal", "()Ljava/lang/String;", b.code(1, 0, core.OP_LDC, byte(b.str("hello")), core.OP_ARETURN)),
+			b.member(static, "greeting", "()Ljava/lang/String;", b.code(1, 0, concat([]byte{core.OP_GETSTATIC}, u2(greeting), []byte{core.OP_ARETURN})...)),
+			b.member(static, "intern", "(Ljava/lang/String;)Ljava/lang/String;", b.code(1, 1,
+				concat([]byte{core.OP_ALOAD_0, core.OP_INVOKEVIRTUAL}, u2(intern), []byte{core.OP_ARETURN})...,
+			)),
+		},
+		nil,
+	))
+}
+
+func TestShouldInternStringLiteralsAcrossClasses(t *testing.T) {
+	dir := t.TempDir()
+	writeInternClasses(t, dir)
+
+	loaders := newTestLoaders(t, dir)
+
+	var classes []*core.Class
+	for _, name := range []string{"A", "B"} {
+		class, err := loaders.Application.LoadClass(name)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		classes = append(classes, class)
+	}
+
+	var strs []*core.Object
+	for _, call := range []struct {
+		class *core.Class
+		name  string
+	}{{classes[0], "literal"}, {classes[1], "literal"}, {classes[1], "greeting"}} {
+		ret, err := invokeSlots(t, call.class, call.name, "()Ljava/lang/String;")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		strs = append(strs, ret[0].Ref)
+	}
+
+	if strs[0] == nil || core.GoString(strs[0]) != "hello" || strs[1] != strs[0] || strs[2] != strs[0] {
+		t.Errorf("Expected the literals and the constant to be the same \"hello\", got %p, %p and %p", strs[0], strs[1], strs[2])
+	}
+
+	ret, err := invokeSlots(t, classes[1], "intern", "(Ljava/lang/String;)Ljava/lang/String;", []core.Slot{javaString(t, loaders, "hello")})
+	if err != nil || ret[0].Ref != strs[0] {
+		t.Errorf("Expected intern to return the literal, got %p (%v)", ret[0].Ref, err)
+	}
+
+	fresh := javaString(t, loaders, "fresh")
+	for _, str := range []core.Slot{fresh, javaString(t, loaders, "fresh")} {
+		ret, err := invokeSlots(t, classes[1], "intern", "(Ljava/lang/String;)Ljava/lang/String;", []core.Slot{str})
+		if err != nil || ret[0].Ref != fresh.Ref {
+			t.Errorf("Expected intern to return the first string interned, got %p (%v)", ret[0].Ref, err)
+		}
+	}
+}
+
+func TestShouldConvertBetweenGoAndJavaStrings(t *testing.T) {
+	loaders := newTestLoaders(t, t.TempDir())
+
+	for _, test := range []struct {
+		s        string
+		expected string
+		coder    int32
+	}{
+		{"", "", 0},
+		{"hello", "hello", 0},
+		{"café", "café", 0},
+		{"日本語", "日本語", 1},
+		{"😀!", "😀!", 1},
+		{"a\xffb", "a�b", 1},
+	} {
+		str, err := core.NewString(loaders.Application, test.s)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if coder := str.Fields[str.Class.DeclaredField("coder", "B").Slot].Num; coder != test.coder {
+			t.Errorf("Expected %q to have the coder %d, got %d", test.s, test.coder, coder)
+		}
+
+		if s := core.GoString(str); s != test.expected {
+			t.Errorf("Expected %q, got %q", test.expected, s)
+		}
+	}
+}
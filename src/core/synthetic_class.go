@@ -0,0 +1,248 @@
+package core
+
+import "encoding/binary"
+
+// stackTraceElementClass is the internal name of the class of the frames returned by `Throwable.getStackTrace`.
+const stackTraceElementClass = "java/lang/StackTraceElement"
+
+// causeConstructors are the throwables raised by the runtime, or their superclasses, that have constructors
+// taking a cause, as they have in the JDK.
+var causeConstructors = map[string]bool{
+	Throwable:                                true,
+	"java/lang/Exception":                    true,
+	"java/lang/Error":                        true,
+	"java/lang/RuntimeException":             true,
+	"java/lang/ReflectiveOperationException": true,
+	"java/lang/VirtualMachineError":          true,
+	"java/lang/AssertionError":               true,
+	"java/io/IOException":                    true,
+	IllegalArgumentException:                 true,
+	IllegalStateException:                    true,
+	SecurityException:                        true,
+	UnsupportedOperationException:            true,
+}
+
+// syntheticClassFile returns the class file of the minimal version of a class the runtime needs, or nil when
+// the class is not one of them. They are the classes of the built-in class library, see `libraryClass`, the
+// java/lang/Cloneable and java/io/Serializable interfaces of arrays, the throwables raised by the runtime,
+// which have public constructors with a message and, for some of them, a cause, and
+// java/lang/StackTraceElement.
+//
+// The methods of java/lang/Throwable that need the runtime are native, see `throwableNatives`.
+func syntheticClassFile(name string) *ClassFile {
+	if class, ok := library[name]; ok {
+		return class.classFile()
+	}
+
+	switch name {
+	case "java/lang/Cloneable", "java/io/Serializable":
+		return newSyntheticClass(name, "java/lang/Object", ACC_PUBLIC|ACC_INTERFACE|ACC_ABSTRACT).cf
+	case Throwable:
+		return syntheticThrowable()
+	case stackTraceElementClass:
+		return syntheticStackTraceElement()
+	}
+
+	super, ok := throwableSuperclasses[name]
+	if !ok {
+		return nil
+	}
+
+	b := newSyntheticClass(name, super, ACC_PUBLIC|ACC_SUPER)
+	b.constructor(super)
+	b.superConstructor(super, "(Ljava/lang/String;)V")
+
+	if causeConstructors[name] {
+		b.superConstructor(super, "(Ljava/lang/Throwable;)V")
+		b.superConstructor(super, "(Ljava/lang/String;Ljava/lang/Throwable;)V")
+	}
+
+	if name == "java/lang/AssertionError" {
+		b.libraryConstructor(name, super, "(Ljava/lang/Object;)V")
+		b.method(ACC_PRIVATE|ACC_NATIVE, "init", "(Ljava/lang/Object;)V", 0, 0)
+	}
+
+	return b.cf
+}
+
+// syntheticThrowable builds java/lang/Throwable. Its constructors fill in the stack trace and set the message
+// and the cause they are given, and its methods about them and the suppressed exceptions are native.
+func syntheticThrowable() *ClassFile {
+	b := newSyntheticClass(Throwable, "java/lang/Object", ACC_PUBLIC|ACC_SUPER)
+
+	object := b.methodref("java/lang/Object", "<init>", "()V")
+	fill := b.methodref(Throwable, "fillInStackTrace", "()Ljava/lang/Throwable;")
+	init := b.methodref(Throwable, "<init>", "()V")
+	setCause := b.methodref(Throwable, "setCause", "(Ljava/lang/Throwable;)V")
+	setMessage := b.methodref(Throwable, "setMessage", "(Ljava/lang/String;)V")
+
+	b.method(ACC_PUBLIC, "<init>", "()V", 1, 1,
+		OP_ALOAD_0, OP_INVOKESPECIAL, high(object), low(object), OP_ALOAD_0, OP_INVOKEVIRTUAL, high(fill), low(fill), OP_POP, OP_RETURN,
+	)
+	b.method(ACC_PUBLIC, "<init>", "(Ljava/lang/String;)V", 2, 2,
+		OP_ALOAD_0, OP_INVOKESPECIAL, high(init), low(init), OP_ALOAD_0, OP_ALOAD_1, OP_INVOKESPECIAL, high(setMessage), low(setMessage), OP_RETURN,
+	)
+	b.method(ACC_PUBLIC, "<init>", "(Ljava/lang/Throwable;)V", 2, 2,
+		OP_ALOAD_0, OP_INVOKESPECIAL, high(init), low(init), OP_ALOAD_0, OP_ALOAD_1, OP_INVOKESPECIAL, high(setCause), low(setCause), OP_RETURN,
+	)
+	b.method(ACC_PUBLIC, "<init>", "(Ljava/lang/String;Ljava/lang/Throwable;)V", 2, 3,
+		OP_ALOAD_0, OP_INVOKESPECIAL, high(init), low(init),
+		OP_ALOAD_0, OP_ALOAD_2, OP_INVOKESPECIAL, high(setCause), low(setCause),
+		OP_ALOAD_0, OP_ALOAD_1, OP_INVOKESPECIAL, high(setMessage), low(setMessage),
+		OP_RETURN,
+	)
+
+	b.method(ACC_PRIVATE|ACC_NATIVE, "setCause", "(Ljava/lang/Throwable;)V", 0, 0)
+	b.method(ACC_PRIVATE|ACC_NATIVE, "setMessage", "(Ljava/lang/String;)V", 0, 0)
+	b.method(ACC_PUBLIC|ACC_NATIVE, "getMessage", "()Ljava/lang/String;", 0, 0)
+	b.method(ACC_PUBLIC|ACC_NATIVE, "getLocalizedMessage", "()Ljava/lang/String;", 0, 0)
+	b.method(ACC_PUBLIC|ACC_NATIVE, "toString", "()Ljava/lang/String;", 0, 0)
+	b.method(ACC_PUBLIC|ACC_NATIVE, "printStackTrace", "()V", 0, 0)
+	b.method(ACC_PUBLIC|ACC_NATIVE, "fillInStackTrace", "()Ljava/lang/Throwable;", 0, 0)
+	b.method(ACC_PUBLIC|ACC_NATIVE, "getStackTrace", "()[Ljava/lang/StackTraceElement;", 0, 0)
+	b.method(ACC_PUBLIC|ACC_NATIVE, "getCause", "()Ljava/lang/Throwable;", 0, 0)
+	b.method(ACC_PUBLIC|ACC_NATIVE, "initCause", "(Ljava/lang/Throwable;)Ljava/lang/Throwable;", 0, 0)
+	b.method(ACC_PUBLIC|ACC_NATIVE, "addSuppressed", "(Ljava/lang/Throwable;)V", 0, 0)
+	b.method(ACC_PUBLIC|ACC_NATIVE, "getSuppressed", "()[Ljava/lang/Throwable;", 0, 0)
+
+	return b.cf
+}
+
+// syntheticStackTraceElement builds java/lang/StackTraceElement, whose fields are set by `Throwable.getStackTrace`
+// and read by its getters.
+func syntheticStackTraceElement() *ClassFile {
+	b := newSyntheticClass(stackTraceElementClass, "java/lang/Object", ACC_PUBLIC|ACC_FINAL|ACC_SUPER)
+
+	for _, field := range []struct{ name, getter string }{
+		{"moduleName", "getModuleName"}, {"declaringClass", "getClassName"}, {"methodName", "getMethodName"}, {"fileName", "getFileName"},
+	} {
+		b.field(ACC_PRIVATE, field.name, "Ljava/lang/String;")
+		ref := b.fieldref(stackTraceElementClass, field.name, "Ljava/lang/String;")
+
+		b.method(ACC_PUBLIC, field.getter, "()Ljava/lang/String;", 1, 1, OP_ALOAD_0, OP_GETFIELD, high(ref), low(ref), OP_ARETURN)
+	}
+
+	b.field(ACC_PRIVATE, "lineNumber", "I")
+	line := b.fieldref(stackTraceElementClass, "lineNumber", "I")
+
+	b.method(ACC_PUBLIC, "getLineNumber", "()I", 1, 1, OP_ALOAD_0, OP_GETFIELD, high(line), low(line), OP_IRETURN)
+	// `lineNumber == -2`
+	b.method(ACC_PUBLIC, "isNativeMethod", "()Z", 2, 1,
+		OP_ALOAD_0, OP_GETFIELD, high(line), low(line), OP_BIPUSH, 0xfe, OP_IF_ICMPNE, 0, 5, OP_ICONST_1, OP_IRETURN, OP_ICONST_0, OP_IRETURN,
+	)
+
+	return b.cf
+}
+
+// syntheticClassBuilder builds the class file of a synthetic class, adding the constants its members refer to.
+type syntheticClassBuilder struct {
+	cf    *ClassFile
+	utf8s map[string]uint16
+}
+
+// newSyntheticClass starts the class file of a class with the given superclass, which is empty for java/lang/Object.
+func newSyntheticClass(name string, super string, flags uint16) *syntheticClassBuilder {
+	b := &syntheticClassBuilder{
+		cf:    &ClassFile{Magic: MagicNumber, MajorVersion: MaxMajorVersion, AccessFlags: flags},
+		utf8s: make(map[string]uint16),
+	}
+
+	b.cf.ThisClass = b.class(name)
+	if super != "" {
+		b.cf.SuperClass = b.class(super)
+	}
+
+	return b
+}
+
+// implements adds a superinterface to the class.
+func (b *syntheticClassBuilder) implements(iface string) {
+	b.cf.Interfaces = append(b.cf.Interfaces, b.class(iface))
+}
+
+// constant adds an entry to the constant pool and returns its index.
+func (b *syntheticClassBuilder) constant(tag uint8, info any) uint16 {
+	b.cf.ConstantPool = append(b.cf.ConstantPool, ConstantPoolInfo{Tag: tag, Info: info})
+	return uint16(len(b.cf.ConstantPool))
+}
+
+func (b *syntheticClassBuilder) utf8(value string) uint16 {
+	if index, ok := b.utf8s[value]; ok {
+		return index
+	}
+
+	index := b.constant(CONSTANT_Utf8, UTF8Info{Bytes: []byte(value)})
+	b.utf8s[value] = index
+
+	return index
+}
+
+func (b *syntheticClassBuilder) class(name string) uint16 {
+	return b.constant(CONSTANT_Class, ClassInfo{NameIndex: b.utf8(name)})
+}
+
+func (b *syntheticClassBuilder) ref(tag uint8, class string, name string, descriptor string) uint16 {
+	nameAndType := b.constant(CONSTANT_NameAndType, NameAndTypeInfo{NameIndex: b.utf8(name), DescriptorIndex: b.utf8(descriptor)})
+	return b.constant(tag, ConstantPoolIndexableInfo{ClassIndex: b.class(class), NameAndTypeIndex: nameAndType})
+}
+
+func (b *syntheticClassBuilder) methodref(class string, name string, descriptor string) uint16 {
+	return b.ref(CONSTANT_Methodref, class, name, descriptor)
+}
+
+func (b *syntheticClassBuilder) fieldref(class string, name string, descriptor string) uint16 {
+	return b.ref(CONSTANT_Fieldref, class, name, descriptor)
+}
+
+func (b *syntheticClassBuilder) field(flags uint16, name string, descriptor string) {
+	b.cf.Fields = append(b.cf.Fields, FieldInfo{AccessFlags: flags, NameIndex: b.utf8(name), DescriptorIndex: b.utf8(descriptor)})
+}
+
+// method adds a method with the given bytecode, native and abstract methods have none and get no Code attribute.
+func (b *syntheticClassBuilder) method(flags uint16, name string, descriptor string, maxStack uint16, maxLocals uint16, bytecode ...byte) {
+	info := MethodInfo{AccessFlags: flags, NameIndex: b.utf8(name), DescriptorIndex: b.utf8(descriptor)}
+
+	if flags&(ACC_NATIVE|ACC_ABSTRACT) == 0 {
+		code := binary.BigEndian.AppendUint16(nil, maxStack)
+		code = binary.BigEndian.AppendUint16(code, maxLocals)
+		code = binary.BigEndian.AppendUint32(code, uint32(len(bytecode)))
+		code = append(code, bytecode...)
+
+		// no exception table and no attributes
+		code = append(code, 0, 0, 0, 0)
+
+		info.Attributes = []AttributeInfo{{AttributeNameIndex: b.utf8("Code"), Info: code}}
+	}
+
+	b.cf.Methods = append(b.cf.Methods, info)
+}
+
+// constructor adds a public constructor without parameters that invokes the one of the superclass.
+func (b *syntheticClassBuilder) constructor(super string) {
+	init := b.methodref(super, "<init>", "()V")
+	b.method(ACC_PUBLIC, "<init>", "()V", 1, 1, OP_ALOAD_0, OP_INVOKESPECIAL, high(init), low(init), OP_RETURN)
+}
+
+// superConstructor adds a public constructor that passes its reference parameters to the constructor of the
+// superclass with the same descriptor.
+func (b *syntheticClassBuilder) superConstructor(super string, descriptor string) {
+	params, _, _ := ParseMethodDescriptor(descriptor)
+	init := b.methodref(super, "<init>", descriptor)
+
+	bytecode := []byte{OP_ALOAD_0}
+	for i := range params {
+		bytecode = append(bytecode, OP_ALOAD, byte(i+1))
+	}
+
+	bytecode = append(bytecode, OP_INVOKESPECIAL, high(init), low(init), OP_RETURN)
+	b.method(ACC_PUBLIC, "<init>", descriptor, uint16(len(params)+1), uint16(len(params)+1), bytecode...)
+}
+
+// high and low are the bytes of a constant pool index operand.
+func high(index uint16) byte {
+	return byte(index >> 8)
+}
+
+func low(index uint16) byte {
+	return byte(index)
+}
@@ -0,0 +1,267 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// MainThreadName is the name of the thread that runs the main method.
+const MainThreadName = "main"
+
+// MaxStackDepth is the maximum number of frames of a thread, invoking a method beyond it throws a
+// StackOverflowError.
+const MaxStackDepth = 4096
+
+// Thread is a Java thread, the methods it invokes run on the goroutine that calls `Invoke`.
+type Thread struct {
+	// Name is the name of the thread, as returned by `Thread.getName`.
+	Name string
+
+	// frames are the frames of the methods being executed, the innermost last.
+	frames []*Frame
+	// object is the java/lang/Thread object of the thread, as returned by `Thread.currentThread`, it is nil
+	// with the built-in class library, which has no threads.
+	object *Object
+	// scopedValueCache is the cache of the scoped values bound by the thread, see `Thread.scopedValueCache`.
+	scopedValueCache *Object
+	// permit holds the permit of `LockSupport.park` when it is available.
+	permit chan struct{}
+}
+
+// ExitError is returned when the program calls `System.exit`, it unwinds every frame of the thread up to
+// the launcher, which exits with its code.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// UncaughtException is returned when a thread terminates because of an exception it did not catch.
+type UncaughtException struct {
+	// Thread is the name of the thread.
+	Thread string
+	// Err is the exception.
+	Err *JavaError
+}
+
+// Error returns the exception the way the reference implementation reports it, e.g.
+// `Exception in thread "main" java.lang.ArithmeticException: / by zero`.
+func (e *UncaughtException) Error() string {
+	return fmt.Sprintf("Exception in thread \"%s\" %s", e.Thread, e.Err)
+}
+
+// PrintStackTrace prints the exception the way the default uncaught exception handler does, the line returned
+// by `Error` followed by the stack trace of the exception, see `JavaError.PrintStackTrace`.
+func (e *UncaughtException) PrintStackTrace(w io.Writer) {
+	fmt.Fprintf(w, "Exception in thread \"%s\" ", e.Thread)
+	e.Err.PrintStackTrace(w)
+}
+
+func (e *UncaughtException) Unwrap() error {
+	return e.Err
+}
+
+// NewThread creates a thread with the given name.
+func NewThread(name string) *Thread {
+	return &Thread{Name: name, permit: make(chan struct{}, 1)}
+}
+
+// Invoke executes the method with the given arguments, `this` comes first for instance methods, and returns
+// the slots of its return value.
+func (t *Thread) Invoke(method *Method, args []Slot) ([]Slot, error) {
+	return executeMethod(t, method, args)
+}
+
+// executeMethod runs the code of a method on a thread, it is a variable so tests can replace it.
+var executeMethod func(t *Thread, method *Method, args []Slot) ([]Slot, error)
+
+func init() {
+	// set here since the interpreter invokes methods through it
+	executeMethod = (*Thread).execute
+}
+
+// Spec: https://docs.oracle.com/en/java/javase/21/docs/api/java.base/java/lang/Thread.html
+
+// Values of the `threadStatus` field of java/lang/Thread, the JVM TI thread states HotSpot uses.
+const (
+	threadStatusRunnable   = 0x0005
+	threadStatusTerminated = 0x0002
+)
+
+// eetops generates the values of the `eetop` fields of the started threads, the JDK considers a thread alive
+// while it is not 0.
+var eetops atomic.Int64
+
+// threadNatives are the native methods of java/lang/Thread.
+var threadNatives = map[string]NativeMethod{
+	"registerNatives()V": func(call *NativeCall) ([]Slot, error) {
+		return nil, nil
+	},
+	"currentThread()Ljava/lang/Thread;": func(call *NativeCall) ([]Slot, error) {
+		return []Slot{{Ref: call.Thread.object}}, nil
+	},
+	// there are no virtual threads, the carrier thread is the current thread
+	"currentCarrierThread()Ljava/lang/Thread;": func(call *NativeCall) ([]Slot, error) {
+		return []Slot{{Ref: call.Thread.object}}, nil
+	},
+	"setCurrentThread(Ljava/lang/Thread;)V": func(call *NativeCall) ([]Slot, error) {
+		call.Thread.object = call.Ref(0)
+		return nil, nil
+	},
+	"scopedValueCache()[Ljava/lang/Object;": func(call *NativeCall) ([]Slot, error) {
+		return []Slot{{Ref: call.Thread.scopedValueCache}}, nil
+	},
+	"setScopedValueCache([Ljava/lang/Object;)V": func(call *NativeCall) ([]Slot, error) {
+		call.Thread.scopedValueCache = call.Ref(0)
+		return nil, nil
+	},
+	"ensureMaterializedForStackWalk(Ljava/lang/Object;)V": func(call *NativeCall) ([]Slot, error) {
+		return nil, nil
+	},
+	"holdsLock(Ljava/lang/Object;)Z": func(call *NativeCall) ([]Slot, error) {
+		obj := call.Ref(0)
+		if obj == nil {
+			return nil, &JavaError{ClassName: NullPointerException}
+		}
+
+		return boolSlots(obj.holdsLock(call.Thread)), nil
+	},
+	"yield0()V": func(call *NativeCall) ([]Slot, error) {
+		runtime.Gosched()
+		return nil, nil
+	},
+	"sleep0(J)V": func(call *NativeCall) ([]Slot, error) {
+		time.Sleep(time.Duration(call.Long(0)))
+		return nil, nil
+	},
+	// priorities and native names are left to the Go runtime
+	"setPriority0(I)V": func(call *NativeCall) ([]Slot, error) {
+		return nil, nil
+	},
+	// interrupting a thread wakes it up when it is parked, the interrupt status is kept by the JDK
+	"interrupt0()V": func(call *NativeCall) ([]Slot, error) {
+		if thread := threadOf(call.This()); thread != nil {
+			thread.unpark()
+		}
+
+		return nil, nil
+	},
+	"clearInterruptEvent()V": func(call *NativeCall) ([]Slot, error) {
+		return nil, nil
+	},
+	"setNativeName(Ljava/lang/String;)V": func(call *NativeCall) ([]Slot, error) {
+		return nil, nil
+	},
+	"start0()V": threadStart,
+}
+
+// threadStart starts a thread, running the `run` method of its java/lang/Thread object in a new goroutine.
+// When it terminates, the uncaught exception is dispatched to its handler and the threads joining it are
+// notified, like HotSpot does.
+func threadStart(call *NativeCall) ([]Slot, error) {
+	obj := call.This()
+
+	started := NewThread(GoString(getField(obj, "name", "Ljava/lang/String;").Ref))
+	started.setObject(obj)
+
+	setField(obj, "eetop", "J", LongSlots(eetops.Add(1))...)
+	setThreadStatus(obj, threadStatusRunnable)
+
+	go func() {
+		_, err := started.callMethod(obj, "run", "()V")
+
+		if thrown, ok := err.(*JavaError); ok {
+			if ref, throwableErr := started.throwable(obj.Class.Loader, thrown); throwableErr == nil {
+				_, err = started.callMethod(obj, "dispatchUncaughtException", "(Ljava/lang/Throwable;)V", Slot{Ref: ref})
+			}
+
+			if err != nil {
+				(&UncaughtException{Thread: started.Name, Err: thrown}).PrintStackTrace(SystemErr)
+			}
+		}
+
+		if obj.Class.lookupMethod("exit", "()V") != nil {
+			started.callMethod(obj, "exit", "()V")
+		}
+
+		obj.monitor().enter(started)
+		setField(obj, "eetop", "J", LongSlots(0)...)
+		setThreadStatus(obj, threadStatusTerminated)
+		obj.monitor().notify(started, true)
+		obj.monitor().exit(started)
+	}()
+
+	return nil, nil
+}
+
+// setObject makes the java/lang/Thread the object of the thread.
+func (t *Thread) setObject(obj *Object) {
+	t.object = obj
+	obj.state = t
+}
+
+// threadOf returns the thread of a java/lang/Thread object, nil when it was not started.
+func threadOf(obj *Object) *Thread {
+	if obj == nil {
+		return nil
+	}
+
+	thread, _ := obj.state.(*Thread)
+
+	return thread
+}
+
+// park blocks the thread until its permit is available, consuming it, or until the timeout expires when it
+// is positive.
+func (t *Thread) park(timeout time.Duration) {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		expired = timer.C
+	}
+
+	select {
+	case <-t.permit:
+	case <-expired:
+	}
+}
+
+// unpark makes the permit of the thread available, waking it up if it is parked.
+func (t *Thread) unpark() {
+	select {
+	case t.permit <- struct{}{}:
+	default:
+	}
+}
+
+// setThreadStatus sets the status of a java/lang/Thread, kept in its `holder` since JDK 19.
+func setThreadStatus(thread *Object, status int32) {
+	if holder := getField(thread, "holder", "Ljava/lang/Thread$FieldHolder;").Ref; holder != nil {
+		setField(holder, "threadStatus", "I", Slot{Num: status})
+	}
+}
+
+// getField returns the first slot of the instance field of the object with the given name and descriptor,
+// declared by its class or a superclass, or the zero slot when there is none.
+func getField(obj *Object, name string, descriptor string) Slot {
+	if field := obj.Class.lookupField(name, descriptor); field != nil && !field.IsStatic() {
+		return obj.Fields[field.Slot]
+	}
+
+	return Slot{}
+}
+
+// setField sets the slots of the instance field of the object with the given name and descriptor, when its
+// class or a superclass declares it.
+func setField(obj *Object, name string, descriptor string, slots ...Slot) {
+	if field := obj.Class.lookupField(name, descriptor); field != nil && !field.IsStatic() {
+		copy(obj.Fields[field.Slot:], slots)
+	}
+}
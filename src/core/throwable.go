@@ -0,0 +1,329 @@
+package core
+
+import "unicode/utf16"
+
+// Spec: https://docs.oracle.com/en/java/javase/21/docs/api/java.base/java/lang/Throwable.html
+
+// throwableNatives are the native methods of java/lang/Throwable, which keep its stack trace, its cause and its
+// suppressed exceptions in the error of the throwable object, where the runtime finds them to report it.
+var throwableNatives = map[string]NativeMethod{
+	"fillInStackTrace()Ljava/lang/Throwable;":               throwableFillInStackTrace,
+	"fillInStackTrace(I)Ljava/lang/Throwable;":              throwableFillInStackTrace,
+	"getStackTrace()[Ljava/lang/StackTraceElement;":         throwableGetStackTrace,
+	"setCause(Ljava/lang/Throwable;)V":                      throwableSetCause,
+	"setMessage(Ljava/lang/String;)V":                       throwableSetMessage,
+	"getMessage()Ljava/lang/String;":                        throwableGetMessage,
+	"getLocalizedMessage()Ljava/lang/String;":               throwableGetLocalizedMessage,
+	"toString()Ljava/lang/String;":                          throwableToString,
+	"printStackTrace()V":                                    throwablePrintStackTrace,
+	"getCause()Ljava/lang/Throwable;":                       throwableGetCause,
+	"initCause(Ljava/lang/Throwable;)Ljava/lang/Throwable;": throwableInitCause,
+	"addSuppressed(Ljava/lang/Throwable;)V":                 throwableAddSuppressed,
+	"getSuppressed()[Ljava/lang/Throwable;":                 throwableGetSuppressed,
+}
+
+// throwableFillInStackTrace sets the stack trace of the throwable to the frames of the thread, leaving out
+// the ones of fillInStackTrace and of the constructors of the throwable, like the reference implementation.
+func throwableFillInStackTrace(call *NativeCall) ([]Slot, error) {
+	obj := call.This()
+
+	frames := call.Thread.frames
+	for _, name := range []string{"fillInStackTrace", "<init>"} {
+		for len(frames) > 0 {
+			method := frames[len(frames)-1].Method
+			if method.Name != name || !obj.Class.IsSubclassOf(method.Class) {
+				break
+			}
+
+			frames = frames[:len(frames)-1]
+		}
+	}
+
+	setStackTrace(obj, stackTrace(frames))
+
+	return call.Args[:1], nil
+}
+
+// setStackTrace sets the stack trace of the throwable. The throwable of the class library builds its stack trace
+// from its backtrace and depth, the backtrace is the throwable itself since its error keeps the frames, see
+// `initStackTraceElements`.
+func setStackTrace(obj *Object, trace []StackTraceElement) {
+	obj.thrownError().StackTrace = trace
+
+	if backtrace := throwableField(obj.Class, "backtrace", "Ljava/lang/Object;"); backtrace != nil {
+		obj.Fields[backtrace.Slot].Ref = obj
+	}
+
+	if depth := throwableField(obj.Class, "depth", "I"); depth != nil {
+		obj.Fields[depth.Slot].Num = int32(len(trace))
+	}
+}
+
+// throwableField returns a field java/lang/Throwable declares, or nil when the class is not a throwable or
+// java/lang/Throwable does not declare it, as the one of the runtime does not.
+func throwableField(class *Class, name string, descriptor string) *Field {
+	for ; class != nil; class = class.Super {
+		if class.Name == Throwable {
+			return class.DeclaredField(name, descriptor)
+		}
+	}
+
+	return nil
+}
+
+// throwableGetStackTrace returns a new array with the frames of the stack trace of the throwable, the same ones
+// `PrintStackTrace` prints.
+func throwableGetStackTrace(call *NativeCall) ([]Slot, error) {
+	obj := call.This()
+
+	arrayClass, err := obj.Class.Loader.bootstrap().LoadClass("[L" + stackTraceElementClass + ";")
+	if err != nil {
+		return nil, err
+	}
+
+	trace := obj.thrownError().StackTrace
+	array := NewArray(arrayClass, len(trace))
+
+	for i, element := range trace {
+		frame := NewObject(arrayClass.ComponentType)
+		if err := setStackTraceElement(frame, element); err != nil {
+			return nil, err
+		}
+
+		array.Elements.([]*Object)[i] = frame
+	}
+
+	return []Slot{{Ref: array}}, nil
+}
+
+// initStackTraceElements fills the given stack trace elements with the first frames of the stack trace of a
+// throwable, whose backtrace is the throwable itself, see `throwableFillInStackTrace`.
+func initStackTraceElements(call *NativeCall) ([]Slot, error) {
+	elements, backtrace := call.Ref(0), call.Ref(1)
+	if elements == nil || backtrace == nil {
+		return nil, &JavaError{ClassName: NullPointerException}
+	}
+
+	trace := backtrace.thrownError().StackTrace
+	for i, frame := range elements.Elements.([]*Object) {
+		if i >= len(trace) || i >= int(call.Int(2)) {
+			break
+		}
+
+		if frame == nil {
+			return nil, &JavaError{ClassName: NullPointerException}
+		}
+
+		if err := setStackTraceElement(frame, trace[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// setStackTraceElement sets the fields a java/lang/StackTraceElement has to the ones of the frame. Their names
+// are interned, like the reference implementation does.
+func setStackTraceElement(frame *Object, element StackTraceElement) error {
+	class := frame.Class
+
+	for name, value := range map[string]string{
+		"moduleName": element.ModuleName, "declaringClass": element.ClassName, "methodName": element.MethodName, "fileName": element.FileName,
+	} {
+		field := class.DeclaredField(name, "Ljava/lang/String;")
+		if field == nil || value == "" {
+			continue
+		}
+
+		str, err := internString(class.Loader, utf16Chars(value))
+		if err != nil {
+			return err
+		}
+
+		frame.Fields[field.Slot].Ref = str
+	}
+
+	if lineNumber := class.DeclaredField("lineNumber", "I"); lineNumber != nil {
+		frame.Fields[lineNumber.Slot].Num = int32(element.LineNumber)
+	}
+
+	if declaringClass := class.DeclaredField("declaringClassObject", "Ljava/lang/Class;"); declaringClass != nil && element.class != nil {
+		mirror, err := element.class.Mirror()
+		if err != nil {
+			return err
+		}
+
+		frame.Fields[declaringClass.Slot].Ref = mirror
+	}
+
+	return nil
+}
+
+// throwableSetCause sets the cause of a throwable created with one, its message is then the one of the cause.
+func throwableSetCause(call *NativeCall) ([]Slot, error) {
+	thrown := call.This().thrownError()
+	thrown.causeSet = true
+
+	if cause := call.Ref(0); cause != nil {
+		thrown.Cause = cause.thrownError()
+		thrown.Message = thrown.Cause.Error()
+	}
+
+	return nil, nil
+}
+
+// throwableSetMessage sets the detail message of a throwable created with one, a null message is empty.
+func throwableSetMessage(call *NativeCall) ([]Slot, error) {
+	call.This().thrownError().Message = call.String(0)
+	return nil, nil
+}
+
+// throwableGetMessage returns the detail message of the throwable, or null when it has none.
+func throwableGetMessage(call *NativeCall) ([]Slot, error) {
+	message := call.This().thrownError().Message
+	if message == "" {
+		return []Slot{{}}, nil
+	}
+
+	ref, err := call.NewString(message)
+
+	return []Slot{{Ref: ref}}, err
+}
+
+// throwableGetLocalizedMessage returns the result of `getMessage`, which subclasses can override.
+func throwableGetLocalizedMessage(call *NativeCall) ([]Slot, error) {
+	return call.Thread.callMethod(call.This(), "getMessage", "()Ljava/lang/String;")
+}
+
+// throwableToString returns the name of the class of the throwable followed by its localized message, when it
+// has one.
+func throwableToString(call *NativeCall) ([]Slot, error) {
+	obj := call.This()
+
+	ret, err := call.Thread.callMethod(obj, "getLocalizedMessage", "()Ljava/lang/String;")
+	if err != nil {
+		return nil, err
+	}
+
+	chars := utf16Chars(javaName(obj.Class.Name))
+	if message := ret[0].Ref; message != nil {
+		chars = append(append(chars, ':', ' '), stringChars(message)...)
+	}
+
+	return call.returnString(chars)
+}
+
+// throwablePrintStackTrace prints the throwable with its stack trace, its causes and its suppressed exceptions
+// to the standard error stream.
+func throwablePrintStackTrace(call *NativeCall) ([]Slot, error) {
+	call.This().thrownError().PrintStackTrace(SystemErr)
+	return nil, nil
+}
+
+// assertionErrorInit sets the message of an AssertionError created with a detail object to the string of the
+// object, which is also its cause when it is a throwable.
+func assertionErrorInit(call *NativeCall) ([]Slot, error) {
+	detail := call.Ref(0)
+
+	message, err := call.Thread.javaString(detail)
+	if err != nil {
+		return nil, err
+	}
+
+	thrown := call.This().thrownError()
+	thrown.Message = string(utf16.Decode(message))
+
+	throwable, err := call.Method.Class.Loader.bootstrap().LoadClass(Throwable)
+	if err != nil {
+		return nil, err
+	}
+
+	if detail != nil && detail.IsInstanceOf(throwable) {
+		thrown.Cause, thrown.causeSet = detail.thrownError(), true
+	}
+
+	return nil, nil
+}
+
+// throwableGetCause returns the cause of the throwable, or null when it has none.
+func throwableGetCause(call *NativeCall) ([]Slot, error) {
+	obj := call.This()
+
+	cause := obj.thrownError().cause()
+	if cause == nil {
+		return []Slot{{}}, nil
+	}
+
+	ref, err := call.Thread.throwable(obj.Class.Loader, cause)
+	if err != nil {
+		return nil, err
+	}
+
+	return []Slot{{Ref: ref}}, nil
+}
+
+// throwableInitCause sets the cause of the throwable, which can only be done once, and not when the throwable
+// was created with a cause, and returns the throwable.
+func throwableInitCause(call *NativeCall) ([]Slot, error) {
+	obj, cause := call.This(), call.Ref(0)
+	thrown := obj.thrownError()
+
+	if thrown.causeSet || thrown.cause() != nil {
+		with := "a null"
+		if cause != nil {
+			with = cause.thrownError().Error()
+		}
+
+		return nil, &JavaError{ClassName: IllegalStateException, Message: "Can't overwrite cause with " + with, Cause: thrown}
+	}
+
+	if cause == obj {
+		return nil, &JavaError{ClassName: IllegalArgumentException, Message: "Self-causation not permitted", Cause: thrown}
+	}
+
+	thrown.causeSet = true
+	if cause != nil {
+		thrown.Cause = cause.thrownError()
+	}
+
+	return call.Args[:1], nil
+}
+
+// throwableAddSuppressed adds an exception to the exceptions suppressed to deliver the throwable.
+func throwableAddSuppressed(call *NativeCall) ([]Slot, error) {
+	obj, suppressed := call.This(), call.Ref(0)
+	thrown := obj.thrownError()
+
+	if suppressed == obj {
+		return nil, &JavaError{ClassName: IllegalArgumentException, Message: "Self-suppression not permitted", Cause: thrown}
+	}
+
+	if suppressed == nil {
+		return nil, &JavaError{ClassName: NullPointerException, Message: "Cannot suppress a null exception."}
+	}
+
+	thrown.Suppressed = append(thrown.Suppressed, suppressed.thrownError())
+
+	return nil, nil
+}
+
+// throwableGetSuppressed returns a new array with the exceptions suppressed to deliver the throwable.
+func throwableGetSuppressed(call *NativeCall) ([]Slot, error) {
+	obj := call.This()
+
+	arrayClass, err := obj.Class.Loader.bootstrap().LoadClass("[L" + Throwable + ";")
+	if err != nil {
+		return nil, err
+	}
+
+	suppressed := obj.thrownError().Suppressed
+	array := NewArray(arrayClass, len(suppressed))
+
+	for i, thrown := range suppressed {
+		if array.Elements.([]*Object)[i], err = call.Thread.throwable(obj.Class.Loader, thrown); err != nil {
+			return nil, err
+		}
+	}
+
+	return []Slot{{Ref: array}}, nil
+}
@@ -0,0 +1,631 @@
+package core
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// Spec: https://github.com/openjdk/jdk21u/blob/master/src/java.base/share/classes/jdk/internal/misc/Unsafe.java
+
+// unsafeClass is the internal name of the class of the low-level access to the memory of the JDK.
+const unsafeClass = "jdk/internal/misc/Unsafe"
+
+// Layout of the objects seen through Unsafe, the one of HotSpot with compressed class pointers and oops: an
+// object has a 12 bytes header followed by its slots, each taking 4 bytes, and an array has a 16 bytes
+// header followed by its elements, each taking the size of its type.
+//
+// The static fields of a class come after the instance fields of its java/lang/Class object, which is their
+// base, as in HotSpot. A null base makes the offset the address of off-heap memory, see `offHeap`.
+const (
+	unsafeFieldsOffset = 12
+	unsafeSlotSize     = 4
+	unsafeArrayOffset  = 16
+)
+
+// unsafeMu makes the accesses through Unsafe and the ones of the bytecode to volatile fields atomic and
+// ordered with each other, which covers the volatile and compare-and-set semantics.
+var unsafeMu sync.Mutex
+
+// loadField returns a copy of the slots of a field, the slots of its object or the static values of its class,
+// read under `unsafeMu` when the field is volatile.
+func loadField(field *Field, slots []Slot) []Slot {
+	if field.IsVolatile() {
+		unsafeMu.Lock()
+		defer unsafeMu.Unlock()
+	}
+
+	return append([]Slot(nil), slots[field.Slot:field.Slot+DescriptorSlots(field.Descriptor)]...)
+}
+
+// storeField sets the slots of a field, written under `unsafeMu` when the field is volatile.
+func storeField(field *Field, slots []Slot, values []Slot) {
+	if field.IsVolatile() {
+		unsafeMu.Lock()
+		defer unsafeMu.Unlock()
+	}
+
+	copy(slots[field.Slot:], values)
+}
+
+// fieldOffset returns the offset of an instance field, the one `Unsafe.objectFieldOffset` returns.
+func fieldOffset(field *Field) int64 {
+	return unsafeFieldsOffset + unsafeSlotSize*int64(field.Slot)
+}
+
+// staticFieldOffset returns the offset of a static field in the java/lang/Class object of its class, the
+// one `Unsafe.staticFieldOffset` returns.
+func staticFieldOffset(field *Field) (int64, error) {
+	mirror, err := field.Class.Mirror()
+	if err != nil {
+		return 0, err
+	}
+
+	return unsafeFieldsOffset + unsafeSlotSize*int64(len(mirror.Fields)+field.Slot), nil
+}
+
+// unsafeKinds are the sizes in bytes of the values of the types accessed through Unsafe, by descriptor, a
+// reference takes 4 bytes like a compressed oop.
+var unsafeKinds = map[byte]int64{'Z': 1, 'B': 1, 'C': 2, 'S': 2, 'I': 4, 'F': 4, 'L': 4, 'J': 8, 'D': 8}
+
+// unsafeTypes are the names Unsafe gives to the types it accesses, by descriptor.
+var unsafeTypes = map[byte]string{
+	'Z': "Boolean", 'B': "Byte", 'C': "Char", 'S': "Short", 'I': "Int", 'F': "Float", 'J': "Long", 'D': "Double", 'L': "Reference",
+}
+
+// invalidAccess is the error of an access through Unsafe that does not match the layout of its base.
+func invalidAccess(obj *Object, offset int64) error {
+	if obj == nil {
+		return NewJavaError(InternalError, "invalid Unsafe access at address %d", offset)
+	}
+
+	return NewJavaError(InternalError, "invalid Unsafe access at offset %d of %s", offset, TypeName(obj.Class.Name))
+}
+
+// unsafeSlots returns the slots of the field of the object at the given offset that hold a value of the type
+// of the given descriptor, the static fields of a class are accessed through its java/lang/Class object.
+func unsafeSlots(obj *Object, offset int64, kind byte) ([]Slot, error) {
+	n := int64(1)
+	if kind == 'J' || kind == 'D' {
+		n = 2
+	}
+
+	fields, slot := obj.Fields, (offset-unsafeFieldsOffset)/unsafeSlotSize
+	if class := mirroredClass(obj); class != nil && slot >= int64(len(fields)) {
+		fields, slot = class.StaticValues, slot-int64(len(fields))
+	}
+
+	if (offset-unsafeFieldsOffset)%unsafeSlotSize != 0 || slot < 0 || slot+n > int64(len(fields)) {
+		return nil, invalidAccess(obj, offset)
+	}
+
+	return fields[slot : slot+n], nil
+}
+
+// unsafeBytes returns the n bytes at the offset of an array of primitives, or at the address of off-heap
+// memory when the base is null. The elements of arrays are viewed as the little-endian bytes they are made
+// of, so a value can be read from an array of another type, like an int from 4 elements of a byte[].
+func unsafeBytes(obj *Object, offset int64, n int64) ([]byte, error) {
+	if obj == nil {
+		return offHeap.bytes(offset, n)
+	}
+
+	var memory []byte
+
+	switch elements := obj.Elements.(type) {
+	case []int8:
+		memory = elementBytes(elements, 1)
+	case []uint16:
+		memory = elementBytes(elements, 2)
+	case []int16:
+		memory = elementBytes(elements, 2)
+	case []int32:
+		memory = elementBytes(elements, 4)
+	case []int64:
+		memory = elementBytes(elements, 8)
+	case []float32:
+		memory = elementBytes(elements, 4)
+	case []float64:
+		memory = elementBytes(elements, 8)
+	}
+
+	start := offset - unsafeArrayOffset
+	if memory == nil || start < 0 || n < 0 || start+n > int64(len(memory)) {
+		return nil, invalidAccess(obj, offset)
+	}
+
+	return memory[start : start+n], nil
+}
+
+// elementBytes returns the bytes of the elements of an array, the machine being little-endian.
+func elementBytes[T any](elements []T, size int) []byte {
+	if len(elements) == 0 {
+		return []byte{}
+	}
+
+	return unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(elements))), len(elements)*size)
+}
+
+// unsafeElement returns the index of the element of an array of references at the given offset.
+func unsafeElement(obj *Object, offset int64) ([]*Object, int64, error) {
+	elements, ok := obj.Elements.([]*Object)
+
+	index := (offset - unsafeArrayOffset) / unsafeSlotSize
+	if !ok || (offset-unsafeArrayOffset)%unsafeSlotSize != 0 || index < 0 || index >= int64(len(elements)) {
+		return nil, 0, invalidAccess(obj, offset)
+	}
+
+	return elements, index, nil
+}
+
+// normalize truncates an int to the type of the given descriptor, which is how the values of fields are kept.
+func normalize(kind byte, v int32) int32 {
+	switch kind {
+	case 'Z':
+		return v & 1
+	case 'B':
+		return int32(int8(v))
+	case 'C':
+		return int32(uint16(v))
+	case 'S':
+		return int32(int16(v))
+	}
+
+	return v
+}
+
+// unsafeGet reads the value of the type of the given descriptor at the offset of the object into the slots
+// of the value.
+func unsafeGet(obj *Object, offset int64, kind byte) ([]Slot, error) {
+	switch {
+	case obj != nil && obj.Elements == nil:
+		slots, err := unsafeSlots(obj, offset, kind)
+		if err != nil {
+			return nil, err
+		}
+
+		if kind == 'J' || kind == 'D' || kind == 'L' {
+			return append([]Slot(nil), slots...), nil
+		}
+
+		return []Slot{{Num: normalize(kind, slots[0].Num)}}, nil
+	case kind == 'L':
+		elements, index, err := unsafeElement(obj, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		return []Slot{{Ref: elements[index]}}, nil
+	}
+
+	b, err := unsafeBytes(obj, offset, unsafeKinds[kind])
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case 'Z', 'B':
+		return []Slot{{Num: normalize(kind, int32(b[0]))}}, nil
+	case 'C', 'S':
+		return []Slot{{Num: normalize(kind, int32(binary.LittleEndian.Uint16(b)))}}, nil
+	case 'I', 'F':
+		return []Slot{{Num: int32(binary.LittleEndian.Uint32(b))}}, nil
+	}
+
+	return LongSlots(int64(binary.LittleEndian.Uint64(b))), nil
+}
+
+// unsafePut writes the value in the slots, of the type of the given descriptor, at the offset of the object.
+func unsafePut(obj *Object, offset int64, kind byte, value []Slot) error {
+	switch {
+	case obj != nil && obj.Elements == nil:
+		slots, err := unsafeSlots(obj, offset, kind)
+		if err != nil {
+			return err
+		}
+
+		if kind == 'J' || kind == 'D' || kind == 'L' {
+			copy(slots, value)
+		} else {
+			slots[0].Num = normalize(kind, value[0].Num)
+		}
+
+		return nil
+	case kind == 'L':
+		elements, index, err := unsafeElement(obj, offset)
+		if err == nil {
+			elements[index] = value[0].Ref
+		}
+
+		return err
+	}
+
+	b, err := unsafeBytes(obj, offset, unsafeKinds[kind])
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case 'Z', 'B':
+		b[0] = byte(normalize(kind, value[0].Num))
+	case 'C', 'S':
+		binary.LittleEndian.PutUint16(b, uint16(value[0].Num))
+	case 'I', 'F':
+		binary.LittleEndian.PutUint32(b, uint32(value[0].Num))
+	default:
+		binary.LittleEndian.PutUint64(b, uint64(SlotsLong(value)))
+	}
+
+	return nil
+}
+
+// sameValue tells if the slots of two values of the same type are the same, references are compared by
+// identity.
+func sameValue(a []Slot, b []Slot) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// unsafeDescriptor returns the descriptor Unsafe uses for the values of the type of the given descriptor,
+// references are objects.
+func unsafeDescriptor(kind byte) string {
+	if kind == 'L' {
+		return "Ljava/lang/Object;"
+	}
+
+	return string(kind)
+}
+
+// unsafeAccessNatives returns the native methods of Unsafe that access memory: the get and put methods of
+// every type, and the atomic updates of ints, longs and references, with their variants. The JDK implements
+// the acquire, release, opaque and weak variants and the read-modify-write methods in Java, on top of the
+// volatile and compare-and-set ones, so registering them only matters to libraries declaring them native.
+func unsafeAccessNatives() map[string]NativeMethod {
+	natives := make(map[string]NativeMethod)
+
+	for kind, name := range unsafeTypes {
+		kind, descriptor := kind, unsafeDescriptor(kind)
+
+		get := func(call *NativeCall) ([]Slot, error) {
+			unsafeMu.Lock()
+			defer unsafeMu.Unlock()
+
+			return unsafeGet(call.Ref(0), call.Long(1), kind)
+		}
+
+		put := func(call *NativeCall) ([]Slot, error) {
+			unsafeMu.Lock()
+			defer unsafeMu.Unlock()
+
+			return nil, unsafePut(call.Ref(0), call.Long(1), kind, call.param(2))
+		}
+
+		for _, variant := range []string{"", "Volatile", "Acquire", "Opaque"} {
+			natives["get"+name+variant+"(Ljava/lang/Object;J)"+descriptor] = get
+		}
+
+		for _, variant := range []string{"", "Volatile", "Release", "Opaque"} {
+			natives["put"+name+variant+"(Ljava/lang/Object;J"+descriptor+")V"] = put
+		}
+	}
+
+	for _, kind := range []byte{'I', 'J', 'L'} {
+		kind, name, descriptor := kind, unsafeTypes[kind], unsafeDescriptor(kind)
+
+		// compareAndExchange sets the new value when the current one is the expected one
+		compareAndExchange := func(call *NativeCall) ([]Slot, error) {
+			return unsafeUpdate(call, kind, func(old []Slot) []Slot {
+				if sameValue(old, call.param(2)) {
+					return call.param(3)
+				}
+
+				return nil
+			})
+		}
+
+		compareAndSet := func(call *NativeCall) ([]Slot, error) {
+			old, err := compareAndExchange(call)
+			return boolSlots(err == nil && sameValue(old, call.param(2))), err
+		}
+
+		getAndSet := func(call *NativeCall) ([]Slot, error) {
+			return unsafeUpdate(call, kind, func(old []Slot) []Slot {
+				return call.param(2)
+			})
+		}
+
+		params := "(Ljava/lang/Object;J" + descriptor
+		natives["compareAndSet"+name+params+descriptor+")Z"] = compareAndSet
+
+		for _, variant := range []string{"", "Plain", "Acquire", "Release"} {
+			natives["weakCompareAndSet"+name+variant+params+descriptor+")Z"] = compareAndSet
+		}
+
+		for _, variant := range []string{"", "Acquire", "Release"} {
+			natives["compareAndExchange"+name+variant+params+descriptor+")"+descriptor] = compareAndExchange
+			natives["getAndSet"+name+variant+params+")"+descriptor] = getAndSet
+		}
+
+		if kind == 'L' {
+			continue
+		}
+
+		getAndAdd := func(call *NativeCall) ([]Slot, error) {
+			return unsafeUpdate(call, kind, func(old []Slot) []Slot {
+				if kind == 'I' {
+					return []Slot{{Num: old[0].Num + call.Int(2)}}
+				}
+
+				return LongSlots(SlotsLong(old) + call.Long(2))
+			})
+		}
+
+		for _, variant := range []string{"", "Acquire", "Release"} {
+			natives["getAndAdd"+name+variant+params+")"+descriptor] = getAndAdd
+		}
+	}
+
+	return natives
+}
+
+// unsafeUpdate atomically replaces the value at the offset of the object, the first two parameters of the
+// native method, with the one the update returns for the current value, unless it returns nil. It returns
+// the value it replaced.
+func unsafeUpdate(call *NativeCall, kind byte, update func(old []Slot) []Slot) ([]Slot, error) {
+	unsafeMu.Lock()
+	defer unsafeMu.Unlock()
+
+	obj, offset := call.Ref(0), call.Long(1)
+
+	old, err := unsafeGet(obj, offset, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	if value := update(old); value != nil {
+		return old, unsafePut(obj, offset, kind, value)
+	}
+
+	return old, nil
+}
+
+// unsafeNatives returns the native methods of Unsafe.
+func unsafeNatives() map[string]NativeMethod {
+	natives := unsafeAccessNatives()
+
+	for method, native := range map[string]NativeMethod{
+		"registerNatives()V": noNative,
+		"arrayBaseOffset0(Ljava/lang/Class;)I": func(call *NativeCall) ([]Slot, error) {
+			if !mirroredClass(call.Ref(0)).IsArray() {
+				return nil, &JavaError{ClassName: IllegalArgumentException}
+			}
+
+			return []Slot{{Num: unsafeArrayOffset}}, nil
+		},
+		"arrayIndexScale0(Ljava/lang/Class;)I": func(call *NativeCall) ([]Slot, error) {
+			class := mirroredClass(call.Ref(0))
+			if !class.IsArray() {
+				return nil, &JavaError{ClassName: IllegalArgumentException}
+			}
+
+			kind := class.Name[1]
+			if kind == '[' {
+				kind = 'L'
+			}
+
+			return []Slot{{Num: int32(unsafeKinds[kind])}}, nil
+		},
+		"objectFieldOffset0(Ljava/lang/reflect/Field;)J": func(call *NativeCall) ([]Slot, error) {
+			field, err := reflectedField(call.Ref(0), false)
+			if err != nil {
+				return nil, err
+			}
+
+			return LongSlots(fieldOffset(field)), nil
+		},
+		"objectFieldOffset1(Ljava/lang/Class;Ljava/lang/String;)J": func(call *NativeCall) ([]Slot, error) {
+			field := declaredField(mirroredClass(call.Ref(0)), call.String(1), false)
+			if field == nil {
+				return nil, NewJavaError(InternalError, "%s", call.String(1))
+			}
+
+			return LongSlots(fieldOffset(field)), nil
+		},
+		"staticFieldOffset0(Ljava/lang/reflect/Field;)J": func(call *NativeCall) ([]Slot, error) {
+			field, err := reflectedField(call.Ref(0), true)
+			if err != nil {
+				return nil, err
+			}
+
+			offset, err := staticFieldOffset(field)
+
+			return LongSlots(offset), err
+		},
+		"staticFieldBase0(Ljava/lang/reflect/Field;)Ljava/lang/Object;": func(call *NativeCall) ([]Slot, error) {
+			field, err := reflectedField(call.Ref(0), true)
+			if err != nil {
+				return nil, err
+			}
+
+			return mirrorSlots(field.Class)
+		},
+		"ensureClassInitialized0(Ljava/lang/Class;)V": func(call *NativeCall) ([]Slot, error) {
+			return nil, mirroredClass(call.Ref(0)).Initialize(call.Thread)
+		},
+		"shouldBeInitialized0(Ljava/lang/Class;)Z": func(call *NativeCall) ([]Slot, error) {
+			return boolSlots(mirroredClass(call.Ref(0)).InitState() != ClassInitialized), nil
+		},
+		"allocateInstance(Ljava/lang/Class;)Ljava/lang/Object;": unsafeAllocateInstance,
+		"throwException(Ljava/lang/Throwable;)V": func(call *NativeCall) ([]Slot, error) {
+			if call.Ref(0) == nil {
+				return nil, &JavaError{ClassName: NullPointerException}
+			}
+
+			return nil, call.Ref(0).thrownError()
+		},
+		"park(ZJ)V": unsafePark,
+		"unpark(Ljava/lang/Object;)V": func(call *NativeCall) ([]Slot, error) {
+			if thread := threadOf(call.Ref(0)); thread != nil {
+				thread.unpark()
+			}
+
+			return nil, nil
+		},
+		// the load average of the system is not available
+		"getLoadAverage0([DI)I": func(call *NativeCall) ([]Slot, error) {
+			return []Slot{{Num: -1}}, nil
+		},
+		"fullFence()V":  unsafeFence,
+		"loadFence()V":  unsafeFence,
+		"storeFence()V": unsafeFence,
+		"allocateMemory0(J)J": func(call *NativeCall) ([]Slot, error) {
+			return LongSlots(offHeap.allocate(call.Long(0))), nil
+		},
+		"reallocateMemory0(JJ)J": func(call *NativeCall) ([]Slot, error) {
+			address, err := offHeap.reallocate(call.Long(0), call.Long(1))
+			return LongSlots(address), err
+		},
+		"freeMemory0(J)V": func(call *NativeCall) ([]Slot, error) {
+			return nil, offHeap.free(call.Long(0))
+		},
+		"setMemory0(Ljava/lang/Object;JJB)V": func(call *NativeCall) ([]Slot, error) {
+			unsafeMu.Lock()
+			defer unsafeMu.Unlock()
+
+			b, err := unsafeBytes(call.Ref(0), call.Long(1), call.Long(2))
+			if err != nil {
+				return nil, err
+			}
+
+			for i := range b {
+				b[i] = byte(call.Int(3))
+			}
+
+			return nil, nil
+		},
+		"copyMemory0(Ljava/lang/Object;JLjava/lang/Object;JJ)V": func(call *NativeCall) ([]Slot, error) {
+			return nil, unsafeCopy(call, 1)
+		},
+		"copySwapMemory0(Ljava/lang/Object;JLjava/lang/Object;JJJ)V": func(call *NativeCall) ([]Slot, error) {
+			return nil, unsafeCopy(call, call.Long(5))
+		},
+	} {
+		natives[method] = native
+	}
+
+	return natives
+}
+
+// unsafeFence orders the memory accesses around it, taking the mutex waits for the accesses in progress.
+func unsafeFence(call *NativeCall) ([]Slot, error) {
+	unsafeMu.Lock()
+	defer unsafeMu.Unlock()
+
+	return nil, nil
+}
+
+// unsafeCopy copies bytes between arrays of primitives or off-heap memory, the first five parameters of the
+// native method, reversing the bytes of each element of the given size when it is more than 1.
+func unsafeCopy(call *NativeCall, elementSize int64) error {
+	unsafeMu.Lock()
+	defer unsafeMu.Unlock()
+
+	n := call.Long(4)
+
+	src, err := unsafeBytes(call.Ref(0), call.Long(1), n)
+	if err != nil {
+		return err
+	}
+
+	dst, err := unsafeBytes(call.Ref(2), call.Long(3), n)
+	if err != nil {
+		return err
+	}
+
+	copy(dst, src)
+
+	for i := int64(0); elementSize > 1 && i+elementSize <= n; i += elementSize {
+		element := dst[i : i+elementSize]
+		for j, k := 0, len(element)-1; j < k; j, k = j+1, k-1 {
+			element[j], element[k] = element[k], element[j]
+		}
+	}
+
+	return nil
+}
+
+// declaredField returns the field of the class with the given name, static or not, a class has at most one
+// of each.
+func declaredField(class *Class, name string, static bool) *Field {
+	for _, field := range class.Fields {
+		if field.Name == name && field.IsStatic() == static {
+			return field
+		}
+	}
+
+	return nil
+}
+
+// reflectedField returns the field a java/lang/reflect/Field object reflects, found by the class and the
+// name it keeps.
+func reflectedField(obj *Object, static bool) (*Field, error) {
+	if obj == nil {
+		return nil, &JavaError{ClassName: NullPointerException}
+	}
+
+	class := mirroredClass(getField(obj, "clazz", "Ljava/lang/Class;").Ref)
+	name := getField(obj, "name", "Ljava/lang/String;").Ref
+	if class == nil || name == nil {
+		return nil, NewJavaError(InternalError, "%s does not reflect a field", obj.Class.JavaName())
+	}
+
+	field := declaredField(class, GoString(name), static)
+	if field == nil {
+		return nil, &JavaError{ClassName: IllegalArgumentException}
+	}
+
+	return field, nil
+}
+
+// unsafeAllocateInstance allocates an object of a class without running a constructor, initializing the
+// class first.
+func unsafeAllocateInstance(call *NativeCall) ([]Slot, error) {
+	if call.Ref(0) == nil {
+		return nil, &JavaError{ClassName: NullPointerException}
+	}
+
+	class := mirroredClass(call.Ref(0))
+	if class.AccessFlags&ACC_ABSTRACT != 0 || class.IsArray() || class.Name == classClass {
+		return nil, NewJavaError(InstantiationException, "%s", class.JavaName())
+	}
+
+	if err := class.Initialize(call.Thread); err != nil {
+		return nil, err
+	}
+
+	return []Slot{{Ref: NewObject(class)}}, nil
+}
+
+// unsafePark blocks the thread until it is unparked, or until the deadline in milliseconds since the epoch
+// when it is absolute, or until the given number of nanoseconds elapsed when it is positive. It returns at
+// once when the thread was unparked since it last parked.
+func unsafePark(call *NativeCall) ([]Slot, error) {
+	absolute, timeout := call.Bool(0), time.Duration(call.Long(1))
+	if absolute {
+		timeout = time.Until(time.UnixMilli(call.Long(1)))
+	}
+
+	if timeout < 0 || absolute && timeout == 0 {
+		return nil, nil
+	}
+
+	call.Thread.park(timeout)
+
+	return nil, nil
+}
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"sort"
+	"sync"
+)
+
+// nativeMemory is the off-heap memory allocated through Unsafe, in blocks of Go memory whose addresses
+// are handed out in increasing order and never reused, so a dangling address does not reach another block.
+type nativeMemory struct {
+	mu sync.Mutex
+	// blocks are the allocated blocks, by increasing address.
+	blocks []*memoryBlock
+	// next is the address of the next block.
+	next int64
+}
+
+// memoryBlock is a block of off-heap memory.
+type memoryBlock struct {
+	address int64
+	data    []byte
+}
+
+// memoryAlignment is the alignment of the addresses of blocks, enough for any value.
+const memoryAlignment = 16
+
+// offHeap is the off-heap memory of the VM, its addresses start above the first 4GB so they are not
+// mistaken for small offsets.
+var offHeap = &nativeMemory{next: 1 << 32}
+
+// allocate allocates a zeroed block of n bytes and returns its address, 0 for an empty block.
+func (m *nativeMemory) allocate(n int64) int64 {
+	if n <= 0 {
+		return 0
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	block := &memoryBlock{address: m.next, data: make([]byte, n)}
+	m.blocks = append(m.blocks, block)
+
+	// a gap is left after each block so accesses past its end are detected
+	m.next += (n + 2*memoryAlignment - 1) &^ (memoryAlignment - 1)
+
+	return block.address
+}
+
+// free frees the block at the given address, freeing 0 does nothing.
+func (m *nativeMemory) free(address int64) error {
+	if address == 0 {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.find(address)
+	if i < 0 || m.blocks[i].address != address {
+		return NewJavaError(InternalError, "invalid address %d", address)
+	}
+
+	m.blocks = append(m.blocks[:i], m.blocks[i+1:]...)
+
+	return nil
+}
+
+// reallocate allocates a block of n bytes with the contents of the block at the given address, which is
+// freed, and returns its address. The bytes past the end of the previous block are zero.
+func (m *nativeMemory) reallocate(address int64, n int64) (int64, error) {
+	var data []byte
+	if address != 0 {
+		block, err := m.block(address)
+		if err != nil {
+			return 0, err
+		}
+
+		data = block.data
+	}
+
+	reallocated := m.allocate(n)
+	if reallocated != 0 {
+		b, _ := m.bytes(reallocated, n)
+		copy(b, data)
+	}
+
+	return reallocated, m.free(address)
+}
+
+// block returns the block allocated at the given address.
+func (m *nativeMemory) block(address int64) (*memoryBlock, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.find(address)
+	if i < 0 || m.blocks[i].address != address {
+		return nil, NewJavaError(InternalError, "invalid address %d", address)
+	}
+
+	return m.blocks[i], nil
+}
+
+// bytes returns the n bytes at the given address, which must be inside an allocated block.
+func (m *nativeMemory) bytes(address int64, n int64) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if i := m.find(address); i >= 0 && n >= 0 {
+		block := m.blocks[i]
+		if start := address - block.address; start+n <= int64(len(block.data)) {
+			return block.data[start : start+n], nil
+		}
+	}
+
+	return nil, invalidAccess(nil, address)
+}
+
+// find returns the index of the block the address can be in, the last one starting at or before it, or -1
+// when there is none. m.mu is held.
+func (m *nativeMemory) find(address int64) int {
+	return sort.Search(len(m.blocks), func(i int) bool { return m.blocks[i].address > address }) - 1
+}
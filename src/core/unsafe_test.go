@@ -0,0 +1,340 @@
+package core_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// defineUnsafe defines a jdk/internal/misc/Unsafe that only declares the native methods the tests use, with
+// an int, a long and a reference field to access through them.
+func defineUnsafe(t *testing.T, loaders *core.ClassLoaders, natives ...[2]string) *core.Class {
+	b := newTestClassBuilder()
+
+	methods := [][]byte{}
+	for _, native := range natives {
+		methods = append(methods, b.member(core.ACC_PUBLIC|core.ACC_NATIVE, native[0], native[1]))
+	}
+
+	class, err := loaders.Application.DefineClass("", b.build(core.ACC_PUBLIC, "jdk/internal/misc/Unsafe", "java/lang/Object", nil,
+		[][]byte{b.member(0, "value", "I"), b.member(0, "big", "J"), b.member(0, "ref", "Ljava/lang/Object;")},
+		methods,
+		nil,
+	))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	return class
+}
+
+func TestShouldCompareAndSetFieldsAndElementsWithUnsafe(t *testing.T) {
+	loaders := newTestLoaders(t, t.TempDir())
+	unsafe := defineUnsafe(t, loaders,
+		[2]string{"objectFieldOffset1", "(Ljava/lang/Class;Ljava/lang/String;)J"},
+		[2]string{"compareAndSetInt", "(Ljava/lang/Object;JII)Z"},
+		[2]string{"compareAndSetLong", "(Ljava/lang/Object;JJJ)Z"},
+		[2]string{"compareAndSetReference", "(Ljava/lang/Object;JLjava/lang/Object;Ljava/lang/Object;)Z"},
+		[2]string{"getIntVolatile", "(Ljava/lang/Object;J)I"},
+		[2]string{"getLong", "(Ljava/lang/Object;J)J"},
+		[2]string{"arrayBaseOffset0", "(Ljava/lang/Class;)I"},
+		[2]string{"arrayIndexScale0", "(Ljava/lang/Class;)I"},
+	)
+
+	obj := core.NewObject(unsafe)
+	this := []core.Slot{{Ref: obj}}
+
+	mirror, err := unsafe.Mirror()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	offsets := map[string]int64{}
+	for _, name := range []string{"value", "big", "ref"} {
+		ret, err := invokeSlots(t, unsafe, "objectFieldOffset1", "(Ljava/lang/Class;Ljava/lang/String;)J",
+			this, []core.Slot{{Ref: mirror}, javaString(t, loaders, name)},
+		)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		offsets[name] = core.SlotsLong(ret)
+	}
+
+	// the fields are laid out after a 12 bytes header, the long taking 8 bytes
+	if offsets["value"] != 12 || offsets["big"] != 16 || offsets["ref"] != 24 {
+		t.Errorf("Expected the offsets 12, 16 and 24, got %v", offsets)
+	}
+
+	_, err = invokeSlots(t, unsafe, "objectFieldOffset1", "(Ljava/lang/Class;Ljava/lang/String;)J",
+		this, []core.Slot{{Ref: mirror}, javaString(t, loaders, "missing")},
+	)
+	if !core.IsJavaError(err, core.InternalError) {
+		t.Errorf("Expected an InternalError, got %v", err)
+	}
+
+	casInt := func(target *core.Object, offset int64, expected int32, value int32) bool {
+		ret, err := invokeSlots(t, unsafe, "compareAndSetInt", "(Ljava/lang/Object;JII)Z",
+			this, []core.Slot{{Ref: target}}, core.LongSlots(offset), []core.Slot{{Num: expected}, {Num: value}},
+		)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		return ret[0].Num != 0
+	}
+
+	if !casInt(obj, offsets["value"], 0, 7) || casInt(obj, offsets["value"], 0, 9) {
+		t.Errorf("Expected only the first compareAndSetInt to succeed")
+	}
+
+	if ret, err := invokeSlots(t, unsafe, "getIntVolatile", "(Ljava/lang/Object;J)I", this, []core.Slot{{Ref: obj}}, core.LongSlots(offsets["value"])); err != nil || ret[0].Num != 7 {
+		t.Errorf("Expected 7, got %v (%v)", ret, err)
+	}
+
+	ret, err := invokeSlots(t, unsafe, "compareAndSetLong", "(Ljava/lang/Object;JJJ)Z",
+		this, []core.Slot{{Ref: obj}}, core.LongSlots(offsets["big"]), core.LongSlots(0), core.LongSlots(1<<40),
+	)
+	if err != nil || ret[0].Num != 1 {
+		t.Errorf("Expected compareAndSetLong to succeed, got %v (%v)", ret, err)
+	}
+
+	if ret, err := invokeSlots(t, unsafe, "getLong", "(Ljava/lang/Object;J)J", this, []core.Slot{{Ref: obj}}, core.LongSlots(offsets["big"])); err != nil || core.SlotsLong(ret) != 1<<40 {
+		t.Errorf("Expected 1<<40, got %v (%v)", ret, err)
+	}
+
+	ret, err = invokeSlots(t, unsafe, "compareAndSetReference", "(Ljava/lang/Object;JLjava/lang/Object;Ljava/lang/Object;)Z",
+		this, []core.Slot{{Ref: obj}}, core.LongSlots(offsets["ref"]), []core.Slot{{}, {Ref: obj}},
+	)
+	if err != nil || ret[0].Num != 1 || obj.Fields[3].Ref != obj {
+		t.Errorf("Expected compareAndSetReference to set the field, got %v (%v)", ret, err)
+	}
+
+	intArray, err := loaders.Bootstrap.LoadClass("[I")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	intMirror, err := intArray.Mirror()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	base, err := invokeSlots(t, unsafe, "arrayBaseOffset0", "(Ljava/lang/Class;)I", this, []core.Slot{{Ref: intMirror}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	scale, err := invokeSlots(t, unsafe, "arrayIndexScale0", "(Ljava/lang/Class;)I", this, []core.Slot{{Ref: intMirror}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	array := core.NewArray(intArray, 4)
+	if !casInt(array, int64(base[0].Num+2*scale[0].Num), 0, 5) || array.Elements.([]int32)[2] != 5 {
+		t.Errorf("Expected compareAndSetInt to set the third element, got %v", array.Elements)
+	}
+}
+
+func TestShouldAccessArraysAndOffHeapMemoryWithUnsafe(t *testing.T) {
+	loaders := newTestLoaders(t, t.TempDir())
+	unsafe := defineUnsafe(t, loaders,
+		[2]string{"getInt", "(Ljava/lang/Object;J)I"},
+		[2]string{"putLong", "(Ljava/lang/Object;JJ)V"},
+		[2]string{"getLong", "(Ljava/lang/Object;J)J"},
+		[2]string{"getByte", "(Ljava/lang/Object;J)B"},
+		[2]string{"putByte", "(Ljava/lang/Object;JB)V"},
+		[2]string{"getCharAcquire", "(Ljava/lang/Object;J)C"},
+		[2]string{"putShortRelease", "(Ljava/lang/Object;JS)V"},
+		[2]string{"getShort", "(Ljava/lang/Object;J)S"},
+		[2]string{"getAndAddInt", "(Ljava/lang/Object;JI)I"},
+		[2]string{"compareAndExchangeLong", "(Ljava/lang/Object;JJJ)J"},
+		[2]string{"allocateMemory0", "(J)J"},
+		[2]string{"freeMemory0", "(J)V"},
+		[2]string{"copyMemory0", "(Ljava/lang/Object;JLjava/lang/Object;JJ)V"},
+	)
+
+	this := []core.Slot{{Ref: core.NewObject(unsafe)}}
+
+	byteArray, err := loaders.Bootstrap.LoadClass("[B")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	bytes := core.NewArray(byteArray, 8)
+	copy(bytes.Elements.([]int8), []int8{1, 2, 3, 4, -1, 0, 0, 0})
+
+	// the elements are read as the little-endian bytes of the value
+	if ret, err := invokeSlots(t, unsafe, "getInt", "(Ljava/lang/Object;J)I", this, []core.Slot{{Ref: bytes}}, core.LongSlots(16)); err != nil || ret[0].Num != 0x04030201 {
+		t.Errorf("Expected 0x04030201, got %v (%v)", ret, err)
+	}
+
+	if ret, err := invokeSlots(t, unsafe, "getByte", "(Ljava/lang/Object;J)B", this, []core.Slot{{Ref: bytes}}, core.LongSlots(20)); err != nil || ret[0].Num != -1 {
+		t.Errorf("Expected -1, got %v (%v)", ret, err)
+	}
+
+	if ret, err := invokeSlots(t, unsafe, "getCharAcquire", "(Ljava/lang/Object;J)C", this, []core.Slot{{Ref: bytes}}, core.LongSlots(20)); err != nil || ret[0].Num != 0xff {
+		t.Errorf("Expected 0xff, got %v (%v)", ret, err)
+	}
+
+	if _, err := invokeSlots(t, unsafe, "getInt", "(Ljava/lang/Object;J)I", this, []core.Slot{{Ref: bytes}}, core.LongSlots(21)); !core.IsJavaError(err, core.InternalError) {
+		t.Errorf("Expected an InternalError reading past the end, got %v", err)
+	}
+
+	obj := this[0].Ref
+	if _, err := invokeSlots(t, unsafe, "putShortRelease", "(Ljava/lang/Object;JS)V", this, []core.Slot{{Ref: obj}}, core.LongSlots(12), []core.Slot{{Num: 0x18000}}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// the value of a field is truncated to the type it is accessed with
+	if ret, err := invokeSlots(t, unsafe, "getShort", "(Ljava/lang/Object;J)S", this, []core.Slot{{Ref: obj}}, core.LongSlots(12)); err != nil || ret[0].Num != -0x8000 {
+		t.Errorf("Expected -0x8000, got %v (%v)", ret, err)
+	}
+
+	if ret, err := invokeSlots(t, unsafe, "getAndAddInt", "(Ljava/lang/Object;JI)I", this, []core.Slot{{Ref: obj}}, core.LongSlots(12), []core.Slot{{Num: 2}}); err != nil || ret[0].Num != -0x8000 || obj.Fields[0].Num != -0x7ffe {
+		t.Errorf("Expected getAndAddInt to return -0x8000 and add 2, got %v and %d (%v)", ret, obj.Fields[0].Num, err)
+	}
+
+	ret, err := invokeSlots(t, unsafe, "compareAndExchangeLong", "(Ljava/lang/Object;JJJ)J", this, []core.Slot{{Ref: obj}}, core.LongSlots(16), core.LongSlots(1), core.LongSlots(2))
+	if err != nil || core.SlotsLong(ret) != 0 || core.SlotsLong(obj.Fields[1:3]) != 0 {
+		t.Errorf("Expected compareAndExchangeLong to fail and return 0, got %v (%v)", ret, err)
+	}
+
+	address, err := invokeSlots(t, unsafe, "allocateMemory0", "(J)J", this, core.LongSlots(16))
+	if err != nil || core.SlotsLong(address) == 0 {
+		t.Fatalf("Expected memory to be allocated, got %v (%v)", address, err)
+	}
+
+	_, err = invokeSlots(t, unsafe, "copyMemory0", "(Ljava/lang/Object;JLjava/lang/Object;JJ)V",
+		this, []core.Slot{{Ref: bytes}}, core.LongSlots(16), []core.Slot{{}}, core.LongSlots(core.SlotsLong(address)+8), core.LongSlots(8),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := invokeSlots(t, unsafe, "putLong", "(Ljava/lang/Object;JJ)V", this, []core.Slot{{}}, address, core.LongSlots(-2)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if ret, err := invokeSlots(t, unsafe, "getLong", "(Ljava/lang/Object;J)J", this, []core.Slot{{}}, address); err != nil || core.SlotsLong(ret) != -2 {
+		t.Errorf("Expected -2, got %v (%v)", ret, err)
+	}
+
+	if ret, err := invokeSlots(t, unsafe, "getInt", "(Ljava/lang/Object;J)I", this, []core.Slot{{}}, core.LongSlots(core.SlotsLong(address)+8)); err != nil || ret[0].Num != 0x04030201 {
+		t.Errorf("Expected the copied bytes, got %v (%v)", ret, err)
+	}
+
+	if _, err := invokeSlots(t, unsafe, "freeMemory0", "(J)V", this, address); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := invokeSlots(t, unsafe, "getLong", "(Ljava/lang/Object;J)J", this, []core.Slot{{}}, address); !core.IsJavaError(err, core.InternalError) {
+		t.Errorf("Expected an InternalError reading freed memory, got %v", err)
+	}
+}
+
+func TestShouldAllocateInstancesAndParkThreadsWithUnsafe(t *testing.T) {
+	loaders := newTestLoaders(t, t.TempDir())
+	unsafe := defineUnsafe(t, loaders,
+		[2]string{"allocateInstance", "(Ljava/lang/Class;)Ljava/lang/Object;"},
+		[2]string{"park", "(ZJ)V"},
+	)
+
+	this := []core.Slot{{Ref: core.NewObject(unsafe)}}
+
+	mirror, err := unsafe.Mirror()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if ret, err := invokeSlots(t, unsafe, "allocateInstance", "(Ljava/lang/Class;)Ljava/lang/Object;", this, []core.Slot{{Ref: mirror}}); err != nil || ret[0].Ref.Class != unsafe {
+		t.Errorf("Expected an instance of the class, got %v (%v)", ret, err)
+	}
+
+	runnable, err := loaders.Bootstrap.LoadClass("java/lang/Runnable")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	runnableMirror, err := runnable.Mirror()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := invokeSlots(t, unsafe, "allocateInstance", "(Ljava/lang/Class;)Ljava/lang/Object;", this, []core.Slot{{Ref: runnableMirror}}); !core.IsJavaError(err, core.InstantiationException) {
+		t.Errorf("Expected an InstantiationException, got %v", err)
+	}
+
+	// a relative park of 1ms returns once it elapses
+	if _, err := invokeSlots(t, unsafe, "park", "(ZJ)V", this, []core.Slot{{Num: 0}}, core.LongSlots(1_000_000)); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestShouldOrderVolatileFieldAccessesWithUnsafe(t *testing.T) {
+	loaders := newTestLoaders(t, t.TempDir())
+
+	b := newTestClassBuilder()
+	big := b.fieldref("jdk/internal/misc/Unsafe", "big", "J")
+	unsafe, err := loaders.Application.DefineClass("", b.build(core.ACC_PUBLIC, "jdk/internal/misc/Unsafe", "java/lang/Object", nil,
+		[][]byte{b.member(core.ACC_VOLATILE, "big", "J")},
+		[][]byte{
+			b.member(core.ACC_PUBLIC|core.ACC_NATIVE, "getAndAddLong", "(Ljava/lang/Object;JJ)J"),
+			b.member(core.ACC_PUBLIC, "big", "()J", b.code(2, 1,
+				core.OP_ALOAD_0, core.OP_GETFIELD, byte(big>>8), byte(big), core.OP_LRETURN,
+			)),
+		},
+		nil,
+	))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	getAndAdd, read := unsafe.DeclaredMethod("getAndAddLong", "(Ljava/lang/Object;JJ)J"), unsafe.DeclaredMethod("big", "()J")
+	obj := core.NewObject(unsafe)
+
+	// adding to both halves of the long, a read that sees one half of an update sees different halves
+	const updates, delta = 1000, 1<<32 | 1
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+
+		thread := core.NewThread("adder")
+		for i := 0; i < updates && errs[0] == nil; i++ {
+			args := append(append([]core.Slot{{Ref: obj}, {Ref: obj}}, core.LongSlots(12)...), core.LongSlots(delta)...)
+			_, errs[0] = thread.Invoke(getAndAdd, args)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		thread := core.NewThread("reader")
+		for i := 0; i < updates && errs[1] == nil; i++ {
+			ret, err := thread.Invoke(read, []core.Slot{{Ref: obj}})
+			if err != nil {
+				errs[1] = err
+			} else if v := core.SlotsLong(ret); v>>32 != v&0xFFFFFFFF {
+				t.Errorf("Expected the read of the volatile long not to be torn, got %#x", v)
+				return
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if ret, err := core.NewThread(core.MainThreadName).Invoke(read, []core.Slot{{Ref: obj}}); err != nil || core.SlotsLong(ret) != updates*delta {
+		t.Errorf("Expected %d, got %v (%v)", updates*delta, ret, err)
+	}
+}
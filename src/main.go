@@ -7,14 +7,14 @@ import (
 )
 
 func main() {
-	cli, err := cli.CreateCLI(os.Args)
+	app, err := cli.CreateCLI(os.Args)
 	if err != nil {
 		os.Exit(1)
 		return
 	}
 
-	if err := cli.Run(); err != nil {
-		os.Exit(1)
+	if err := app.Run(); err != nil {
+		os.Exit(cli.ExitCode(err))
 	}
 
 	os.Exit(0)
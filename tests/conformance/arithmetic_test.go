@@ -0,0 +1,93 @@
+package conformance_test
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+	"github.com/Gustrb/jbm/src/utils"
+	"github.com/Gustrb/jbm/tests/fixtures"
+)
+
+// ArithmeticExpected are the results of the cases of the Arithmetic fixture on a JVM, following the JLS and the
+// JVMS, one line per case sorted by name.
+var ArithmeticExpected, _ = utils.ReadFileContent("../fixtures/Arithmetic.expected")
+
+// result formats what a case returned the same way as Arithmetic.expected: the bits of the value, with NaN
+// collapsed into the canonical one of `Float.floatToIntBits` and `Double.doubleToLongBits`, or the exception
+// thrown.
+func result(descriptor string, ret []core.Slot, err error) string {
+	var javaErr *core.JavaError
+	if errors.As(err, &javaErr) {
+		return "throws " + strings.ReplaceAll(javaErr.ClassName, "/", ".")
+	}
+
+	if err != nil {
+		return err.Error()
+	}
+
+	switch descriptor {
+	case "()J":
+		return strconv.FormatInt(core.SlotsLong(ret), 10)
+	case "()F":
+		bits := math.Float32bits(core.SlotFloat(ret[0]))
+		if value := core.SlotFloat(ret[0]); value != value {
+			bits = 0x7fc00000
+		}
+
+		return strconv.FormatInt(int64(int32(bits)), 10)
+	case "()D":
+		bits := math.Float64bits(core.SlotsDouble(ret))
+		if value := core.SlotsDouble(ret); value != value {
+			bits = 0x7ff8000000000000
+		}
+
+		return strconv.FormatInt(int64(bits), 10)
+	}
+
+	return strconv.FormatInt(int64(ret[0].Num), 10)
+}
+
+func TestShouldComputeTheSameArithmeticResultsAsJava(t *testing.T) {
+	loaders := core.NewClassLoaders(core.NewClassPath(), nil, core.NewClassPath())
+	defer loaders.Close()
+
+	class, err := loaders.Application.DefineClass("", fixtures.Arithmetic().Bytes())
+	if err != nil {
+		t.Fatalf("Error defining class: %s", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(ArithmeticExpected)), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("Expected the results of the Arithmetic fixture, got %q", ArithmeticExpected)
+	}
+
+	methods := make(map[string]*core.Method)
+	for _, method := range class.Methods {
+		if strings.HasPrefix(method.Name, "case") {
+			methods[method.Name] = method
+		}
+	}
+
+	if len(lines) != len(methods) {
+		t.Errorf("Expected a result for each of the %d cases, got %d", len(methods), len(lines))
+	}
+
+	for _, line := range lines {
+		name, expected, _ := strings.Cut(line, " ")
+
+		method, ok := methods[name]
+		if !ok {
+			t.Errorf("Expected the fixture to have the case %s", name)
+			continue
+		}
+
+		ret, err := core.NewThread(core.MainThreadName).Invoke(method, nil)
+		if actual := result(method.Descriptor, ret, err); actual != expected {
+			t.Errorf("Expected %s to be %s, got %s", name, expected, actual)
+		}
+	}
+}
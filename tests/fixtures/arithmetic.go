@@ -0,0 +1,241 @@
+package fixtures
+
+import (
+	"math"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// arithmeticHelper is a static method of the Arithmetic fixture applying an instruction to its parameters.
+type arithmeticHelper struct {
+	name       string
+	descriptor string
+	code       func(c *Code) *Code
+}
+
+// binaryOp returns the code of a helper applying the instruction to its two parameters, loaded with the given
+// instructions, and returning the result.
+func binaryOp(first byte, second byte, op byte, ret byte) func(c *Code) *Code {
+	return func(c *Code) *Code {
+		return c.Op(first, second, op, ret)
+	}
+}
+
+// unaryOp returns the code of a helper applying the instruction to its parameter.
+func unaryOp(load byte, op byte, ret byte) func(c *Code) *Code {
+	return func(c *Code) *Code {
+		return c.Op(load, op, ret)
+	}
+}
+
+// compareOp returns the code of a helper returning 1 when the comparison of its parameters, followed by the
+// given branch on its result, does not branch, the way javac compiles `a < b ? 1 : 0`.
+func compareOp(first byte, second byte, cmp byte, branch byte) func(c *Code) *Code {
+	return func(c *Code) *Code {
+		return c.Op(first, second, cmp).Jump(branch, "false").
+			Op(core.OP_ICONST_1, core.OP_IRETURN).
+			Label("false").
+			Op(core.OP_ICONST_0, core.OP_IRETURN)
+	}
+}
+
+// arithmeticHelpers are the helpers, like `static long ldiv(long a, long b) { return a / b; }` for ldiv.
+var arithmeticHelpers = []arithmeticHelper{
+	{"idiv", "(II)I", binaryOp(core.OP_ILOAD_0, core.OP_ILOAD_1, core.OP_IDIV, core.OP_IRETURN)},
+	{"irem", "(II)I", binaryOp(core.OP_ILOAD_0, core.OP_ILOAD_1, core.OP_IREM, core.OP_IRETURN)},
+	{"imul", "(II)I", binaryOp(core.OP_ILOAD_0, core.OP_ILOAD_1, core.OP_IMUL, core.OP_IRETURN)},
+	{"ishl", "(II)I", binaryOp(core.OP_ILOAD_0, core.OP_ILOAD_1, core.OP_ISHL, core.OP_IRETURN)},
+	{"ishr", "(II)I", binaryOp(core.OP_ILOAD_0, core.OP_ILOAD_1, core.OP_ISHR, core.OP_IRETURN)},
+	{"iushr", "(II)I", binaryOp(core.OP_ILOAD_0, core.OP_ILOAD_1, core.OP_IUSHR, core.OP_IRETURN)},
+	{"i2b", "(I)I", unaryOp(core.OP_ILOAD_0, core.OP_I2B, core.OP_IRETURN)},
+	{"i2c", "(I)I", unaryOp(core.OP_ILOAD_0, core.OP_I2C, core.OP_IRETURN)},
+	{"i2s", "(I)I", unaryOp(core.OP_ILOAD_0, core.OP_I2S, core.OP_IRETURN)},
+	{"i2f", "(I)F", unaryOp(core.OP_ILOAD_0, core.OP_I2F, core.OP_FRETURN)},
+
+	{"ladd", "(JJ)J", binaryOp(core.OP_LLOAD_0, core.OP_LLOAD_2, core.OP_LADD, core.OP_LRETURN)},
+	{"lsub", "(JJ)J", binaryOp(core.OP_LLOAD_0, core.OP_LLOAD_2, core.OP_LSUB, core.OP_LRETURN)},
+	{"lmul", "(JJ)J", binaryOp(core.OP_LLOAD_0, core.OP_LLOAD_2, core.OP_LMUL, core.OP_LRETURN)},
+	{"ldiv", "(JJ)J", binaryOp(core.OP_LLOAD_0, core.OP_LLOAD_2, core.OP_LDIV, core.OP_LRETURN)},
+	{"lrem", "(JJ)J", binaryOp(core.OP_LLOAD_0, core.OP_LLOAD_2, core.OP_LREM, core.OP_LRETURN)},
+	{"land", "(JJ)J", binaryOp(core.OP_LLOAD_0, core.OP_LLOAD_2, core.OP_LAND, core.OP_LRETURN)},
+	{"lor", "(JJ)J", binaryOp(core.OP_LLOAD_0, core.OP_LLOAD_2, core.OP_LOR, core.OP_LRETURN)},
+	{"lxor", "(JJ)J", binaryOp(core.OP_LLOAD_0, core.OP_LLOAD_2, core.OP_LXOR, core.OP_LRETURN)},
+	{"lneg", "(J)J", unaryOp(core.OP_LLOAD_0, core.OP_LNEG, core.OP_LRETURN)},
+	{"lshl", "(JI)J", binaryOp(core.OP_LLOAD_0, core.OP_ILOAD_2, core.OP_LSHL, core.OP_LRETURN)},
+	{"lshr", "(JI)J", binaryOp(core.OP_LLOAD_0, core.OP_ILOAD_2, core.OP_LSHR, core.OP_LRETURN)},
+	{"lushr", "(JI)J", binaryOp(core.OP_LLOAD_0, core.OP_ILOAD_2, core.OP_LUSHR, core.OP_LRETURN)},
+	{"lless", "(JJ)I", compareOp(core.OP_LLOAD_0, core.OP_LLOAD_2, core.OP_LCMP, core.OP_IFGE)},
+	{"l2i", "(J)I", unaryOp(core.OP_LLOAD_0, core.OP_L2I, core.OP_IRETURN)},
+	{"l2f", "(J)F", unaryOp(core.OP_LLOAD_0, core.OP_L2F, core.OP_FRETURN)},
+	{"l2d", "(J)D", unaryOp(core.OP_LLOAD_0, core.OP_L2D, core.OP_DRETURN)},
+
+	{"fadd", "(FF)F", binaryOp(core.OP_FLOAD_0, core.OP_FLOAD_1, core.OP_FADD, core.OP_FRETURN)},
+	{"fsub", "(FF)F", binaryOp(core.OP_FLOAD_0, core.OP_FLOAD_1, core.OP_FSUB, core.OP_FRETURN)},
+	{"fmul", "(FF)F", binaryOp(core.OP_FLOAD_0, core.OP_FLOAD_1, core.OP_FMUL, core.OP_FRETURN)},
+	{"fdiv", "(FF)F", binaryOp(core.OP_FLOAD_0, core.OP_FLOAD_1, core.OP_FDIV, core.OP_FRETURN)},
+	{"frem", "(FF)F", binaryOp(core.OP_FLOAD_0, core.OP_FLOAD_1, core.OP_FREM, core.OP_FRETURN)},
+	{"fneg", "(F)F", unaryOp(core.OP_FLOAD_0, core.OP_FNEG, core.OP_FRETURN)},
+	{"fless", "(FF)I", compareOp(core.OP_FLOAD_0, core.OP_FLOAD_1, core.OP_FCMPG, core.OP_IFGE)},
+	{"fgreater", "(FF)I", compareOp(core.OP_FLOAD_0, core.OP_FLOAD_1, core.OP_FCMPL, core.OP_IFLE)},
+	{"f2i", "(F)I", unaryOp(core.OP_FLOAD_0, core.OP_F2I, core.OP_IRETURN)},
+	{"f2l", "(F)J", unaryOp(core.OP_FLOAD_0, core.OP_F2L, core.OP_LRETURN)},
+	{"f2d", "(F)D", unaryOp(core.OP_FLOAD_0, core.OP_F2D, core.OP_DRETURN)},
+
+	{"dadd", "(DD)D", binaryOp(core.OP_DLOAD_0, core.OP_DLOAD_2, core.OP_DADD, core.OP_DRETURN)},
+	{"dsub", "(DD)D", binaryOp(core.OP_DLOAD_0, core.OP_DLOAD_2, core.OP_DSUB, core.OP_DRETURN)},
+	{"dmul", "(DD)D", binaryOp(core.OP_DLOAD_0, core.OP_DLOAD_2, core.OP_DMUL, core.OP_DRETURN)},
+	{"ddiv", "(DD)D", binaryOp(core.OP_DLOAD_0, core.OP_DLOAD_2, core.OP_DDIV, core.OP_DRETURN)},
+	{"drem", "(DD)D", binaryOp(core.OP_DLOAD_0, core.OP_DLOAD_2, core.OP_DREM, core.OP_DRETURN)},
+	{"dneg", "(D)D", unaryOp(core.OP_DLOAD_0, core.OP_DNEG, core.OP_DRETURN)},
+	{"dless", "(DD)I", compareOp(core.OP_DLOAD_0, core.OP_DLOAD_2, core.OP_DCMPG, core.OP_IFGE)},
+	{"dgreater", "(DD)I", compareOp(core.OP_DLOAD_0, core.OP_DLOAD_2, core.OP_DCMPL, core.OP_IFLE)},
+	{"d2i", "(D)I", unaryOp(core.OP_DLOAD_0, core.OP_D2I, core.OP_IRETURN)},
+	{"d2l", "(D)J", unaryOp(core.OP_DLOAD_0, core.OP_D2L, core.OP_LRETURN)},
+	{"d2f", "(D)F", unaryOp(core.OP_DLOAD_0, core.OP_D2F, core.OP_FRETURN)},
+}
+
+// arithmeticCase is a method of the Arithmetic fixture returning what a helper returns for the given
+// arguments, each one an int32, int64, float32 or float64.
+type arithmeticCase struct {
+	name   string
+	helper string
+	args   []any
+}
+
+var (
+	negativeZero32 = float32(math.Copysign(0, -1))
+	negativeZero64 = math.Copysign(0, -1)
+)
+
+// arithmeticCases are the cases, like `static int caseIntDivByZero() { return idiv(1, 0); }`.
+var arithmeticCases = []arithmeticCase{
+	{"caseIntMinDivMinusOne", "idiv", []any{int32(math.MinInt32), int32(-1)}},
+	{"caseIntMinRemMinusOne", "irem", []any{int32(math.MinInt32), int32(-1)}},
+	{"caseIntDivRoundsTowardsZero", "idiv", []any{int32(-7), int32(2)}},
+	{"caseIntRemTakesTheDividendSign", "irem", []any{int32(-7), int32(2)}},
+	{"caseIntDivByZero", "idiv", []any{int32(1), int32(0)}},
+	{"caseIntRemByZero", "irem", []any{int32(1), int32(0)}},
+	{"caseIntMulOverflows", "imul", []any{int32(0x10000), int32(0x10001)}},
+	{"caseIntShlMasksTheDistance", "ishl", []any{int32(1), int32(33)}},
+	{"caseIntShrKeepsTheSign", "ishr", []any{int32(-8), int32(33)}},
+	{"caseIntUshrNegativeDistance", "iushr", []any{int32(-1), int32(-1)}},
+	{"caseIntToByte", "i2b", []any{int32(200)}},
+	{"caseIntToChar", "i2c", []any{int32(-1)}},
+	{"caseIntToShort", "i2s", []any{int32(40000)}},
+	{"caseIntToFloatRounds", "i2f", []any{int32(16777217)}},
+
+	{"caseLongMinDivMinusOne", "ldiv", []any{int64(math.MinInt64), int64(-1)}},
+	{"caseLongMinRemMinusOne", "lrem", []any{int64(math.MinInt64), int64(-1)}},
+	{"caseLongDivByZero", "ldiv", []any{int64(1), int64(0)}},
+	{"caseLongRemByZero", "lrem", []any{int64(1), int64(0)}},
+	{"caseLongRemTakesTheDividendSign", "lrem", []any{int64(-7000000000), int64(3)}},
+	{"caseLongAddOverflows", "ladd", []any{int64(math.MaxInt64), int64(1)}},
+	{"caseLongSubOverflows", "lsub", []any{int64(math.MinInt64), int64(1)}},
+	{"caseLongMulOverflows", "lmul", []any{int64(0x100000001), int64(0x100000001)}},
+	{"caseLongAnd", "land", []any{int64(-0x00FF00FF00FF0100), int64(0x0FF00FF00FF00FF0)}},
+	{"caseLongOr", "lor", []any{int64(-0x0100000000000000), int64(0xFF)}},
+	{"caseLongXor", "lxor", []any{int64(-1), int64(0x123456789)}},
+	{"caseLongNegMin", "lneg", []any{int64(math.MinInt64)}},
+	{"caseLongShlMasksTheDistance", "lshl", []any{int64(1), int32(65)}},
+	{"caseLongShrKeepsTheSign", "lshr", []any{int64(math.MinInt64), int32(63)}},
+	{"caseLongUshrNegativeDistance", "lushr", []any{int64(-1), int32(-1)}},
+	{"caseLongCompare", "lless", []any{int64(-1), int64(0x100000000)}},
+	{"caseLongToInt", "l2i", []any{int64(0x100000001)}},
+	{"caseLongToFloatRounds", "l2f", []any{int64(math.MaxInt64)}},
+	{"caseLongToDoubleRounds", "l2d", []any{int64(9007199254740993)}},
+
+	{"caseFloatAddRounds", "fadd", []any{float32(16777216), float32(1)}},
+	{"caseFloatSubNegativeZero", "fsub", []any{negativeZero32, float32(0)}},
+	{"caseFloatMulUnderflows", "fmul", []any{float32(math.SmallestNonzeroFloat32), float32(0.5)}},
+	{"caseFloatDivByZero", "fdiv", []any{float32(1), float32(0)}},
+	{"caseFloatDivNegativeByZero", "fdiv", []any{float32(-1), float32(0)}},
+	{"caseFloatZeroDivZero", "fdiv", []any{float32(0), float32(0)}},
+	{"caseFloatRem", "frem", []any{float32(5.5), float32(-2)}},
+	{"caseFloatRemNegative", "frem", []any{float32(-5.5), float32(2)}},
+	{"caseFloatRemByZero", "frem", []any{float32(1), float32(0)}},
+	{"caseFloatNegZero", "fneg", []any{float32(0)}},
+	{"caseFloatLessNaN", "fless", []any{float32(math.NaN()), float32(1)}},
+	{"caseFloatGreaterNaN", "fgreater", []any{float32(math.NaN()), float32(1)}},
+	{"caseFloatLessNegativeZero", "fless", []any{negativeZero32, float32(0)}},
+	{"caseFloatToIntNaN", "f2i", []any{float32(math.NaN())}},
+	{"caseFloatToIntSaturates", "f2i", []any{float32(3e10)}},
+	{"caseFloatToIntSaturatesNegative", "f2i", []any{float32(-3e10)}},
+	{"caseFloatToIntTruncates", "f2i", []any{float32(-1.9)}},
+	{"caseFloatToLongInfinity", "f2l", []any{float32(math.Inf(1))}},
+	{"caseFloatToDouble", "f2d", []any{float32(0.1)}},
+
+	{"caseDoubleAdd", "dadd", []any{0.1, 0.2}},
+	{"caseDoubleSubNegativeZero", "dsub", []any{negativeZero64, 0.0}},
+	{"caseDoubleMul", "dmul", []any{0.1, 3.0}},
+	{"caseDoubleDivByZero", "ddiv", []any{1.0, 0.0}},
+	{"caseDoubleRemByZero", "drem", []any{1.0, 0.0}},
+	{"caseDoubleRemInfinity", "drem", []any{1.0, math.Inf(1)}},
+	{"caseDoubleRemOfInfinity", "drem", []any{math.Inf(-1), 2.0}},
+	{"caseDoubleRem", "drem", []any{10.5, 3.0}},
+	{"caseDoubleNegZero", "dneg", []any{0.0}},
+	{"caseDoubleLessNaN", "dless", []any{1.0, math.NaN()}},
+	{"caseDoubleGreaterNaN", "dgreater", []any{1.0, math.NaN()}},
+	{"caseDoubleToIntNaN", "d2i", []any{math.NaN()}},
+	{"caseDoubleToIntTruncates", "d2i", []any{-0.5}},
+	{"caseDoubleToIntSaturates", "d2i", []any{1e10}},
+	{"caseDoubleToLongNaN", "d2l", []any{math.NaN()}},
+	{"caseDoubleToLongSaturates", "d2l", []any{1e19}},
+	{"caseDoubleToLongSaturatesNegative", "d2l", []any{-1e19}},
+	{"caseDoubleToFloatOverflows", "d2f", []any{1e40}},
+	{"caseDoubleToFloatRounds", "d2f", []any{1.00000001}},
+	{"caseDoubleToFloatUnderflows", "d2f", []any{1e-50}},
+}
+
+// Arithmetic has a static helper for each int conversion, division and shift, and for each long, float and
+// double instruction, and the `case` methods calling them with edge-case operands, so the results are computed
+// at run time:
+//
+//	public class Arithmetic {
+//	    static int idiv(int a, int b) { return a / b; }
+//	    static int lless(long a, long b) { return a < b ? 1 : 0; }
+//	    ...
+//
+//	    static int caseIntDivByZero() { return idiv(1, 0); }
+//	    static int caseLongCompare() { return lless(-1, 0x100000000L); }
+//	    ...
+//	}
+//
+// Arithmetic.expected has what each case returns on a JVM: the bits of the value, the NaNs collapsed into the
+// canonical one, or the exception it throws.
+func Arithmetic() *Class {
+	c := NewClass(core.ACC_PUBLIC|core.ACC_SUPER, "Arithmetic", "java/lang/Object")
+	c.Constructor(core.ACC_PUBLIC)
+
+	descriptors := make(map[string]string)
+	for _, helper := range arithmeticHelpers {
+		descriptors[helper.name] = helper.descriptor
+		c.Method(core.ACC_STATIC, helper.name, helper.descriptor, helper.code(c.Code(4, 4)))
+	}
+
+	for _, cc := range arithmeticCases {
+		descriptor := descriptors[cc.helper]
+		code := c.Code(4, 0)
+
+		for _, arg := range cc.args {
+			switch v := arg.(type) {
+			case int32:
+				code.Int(v)
+			case int64:
+				code.Long(v)
+			case float32:
+				code.Float(v)
+			case float64:
+				code.Double(v)
+			}
+		}
+
+		ret := descriptor[len(descriptor)-1]
+		code.Invoke(core.OP_INVOKESTATIC, "Arithmetic", cc.helper, descriptor).Op(map[byte]byte{
+			'I': core.OP_IRETURN, 'J': core.OP_LRETURN, 'F': core.OP_FRETURN, 'D': core.OP_DRETURN,
+		}[ret])
+
+		c.Method(core.ACC_STATIC, cc.name, "()"+string(ret), code)
+	}
+
+	return c
+}
@@ -0,0 +1,143 @@
+package fixtures
+
+import (
+	"math"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// Arrays allocates arrays of primitive types and references, with one or more dimensions, and prints their
+// elements and what they are instances of. It uses the classes of the Person fixture:
+//
+//	public class Arrays {
+//	    static int sum(int[] values) {
+//	        int sum = 0;
+//	        for (int i = 0; i < values.length; i++) {
+//	            sum += values[i];
+//	        }
+//
+//	        return sum;
+//	    }
+//
+//	    public static void main(String[] args) {
+//	        int[] squares = new int[5];
+//	        for (int i = 0; i < squares.length; i++) {
+//	            squares[i] = i * i;
+//	        }
+//
+//	        System.out.println(sum(squares));                            // 30
+//
+//	        long[][] grid = new long[3][4];
+//	        grid[2][3] = Long.MAX_VALUE;
+//	        System.out.println(grid.length);                             // 3
+//	        System.out.println(grid[0].length);                          // 4
+//	        System.out.println(grid[1][1]);                              // 0
+//	        System.out.println(grid[2][3]);                              // 9223372036854775807
+//
+//	        byte[] bytes = { (byte) 200 };
+//	        char[] chars = { 'a' };
+//	        short[] shorts = { (short) 40000 };
+//	        double[] doubles = { 0.5 };
+//	        System.out.println(bytes[0]);                                // -56
+//	        System.out.println((char) (chars[0] + 1));                   // b
+//	        System.out.println(shorts[0]);                               // -25536
+//	        System.out.println(doubles[0] * 2);                          // 1.0
+//
+//	        Object[] people = new Person[2];
+//	        people[0] = new Person();
+//	        System.out.println(people instanceof Animal[]);              // true
+//	        System.out.println(people instanceof Mammal[]);              // true
+//	        System.out.println(people instanceof Arrays[]);              // false
+//
+//	        Object array = new int[1];
+//	        System.out.println(array instanceof Cloneable);              // true
+//	        System.out.println(array instanceof java.io.Serializable);   // true
+//	        System.out.println(array instanceof Object[]);               // false
+//
+//	        int[][] jagged = new int[2][];
+//	        jagged[1] = new int[] { 7 };
+//	        System.out.println(jagged[0] == null);                       // true
+//	        System.out.println(jagged[1][0]);                            // 7
+//	    }
+//	}
+//
+// Unlike javac, which folds the casts, the bytecode stores 200 and 40000 so bastore and sastore narrow them.
+func Arrays() []*Class {
+	c := NewClass(core.ACC_PUBLIC|core.ACC_SUPER, "Arrays", "java/lang/Object")
+	c.Constructor(core.ACC_PUBLIC)
+
+	c.Method(core.ACC_STATIC, "sum", "([I)I", c.Code(3, 3).
+		Op(core.OP_ICONST_0, core.OP_ISTORE_1, core.OP_ICONST_0, core.OP_ISTORE_2).
+		Label("loop").
+		Op(core.OP_ILOAD_2, core.OP_ALOAD_0, core.OP_ARRAYLENGTH).Jump(core.OP_IF_ICMPGE, "done").
+		Op(core.OP_ILOAD_1, core.OP_ALOAD_0, core.OP_ILOAD_2, core.OP_IALOAD, core.OP_IADD, core.OP_ISTORE_1).
+		Iinc(2, 1).
+		Jump(core.OP_GOTO, "loop").
+		Label("done").
+		Op(core.OP_ILOAD_1, core.OP_IRETURN),
+	)
+
+	const (
+		squares, i, grid, bytes, chars, shorts, doubles, people, array, jagged = 1, 2, 3, 4, 5, 6, 7, 8, 9, 10
+	)
+
+	main := c.Code(6, 11)
+
+	main.Op(core.OP_ICONST_5).NewArray('I').Local(core.OP_ASTORE, squares).
+		Op(core.OP_ICONST_0).Local(core.OP_ISTORE, i).
+		Label("loop").
+		Local(core.OP_ILOAD, i).Local(core.OP_ALOAD, squares).Op(core.OP_ARRAYLENGTH).Jump(core.OP_IF_ICMPGE, "done").
+		Local(core.OP_ALOAD, squares).Local(core.OP_ILOAD, i).Local(core.OP_ILOAD, i).Local(core.OP_ILOAD, i).
+		Op(core.OP_IMUL, core.OP_IASTORE).
+		Iinc(i, 1).
+		Jump(core.OP_GOTO, "loop").
+		Label("done").
+		Local(core.OP_ALOAD, squares).Invoke(core.OP_INVOKESTATIC, "Arrays", "sum", "([I)I").Println("I")
+
+	main.Op(core.OP_ICONST_3, core.OP_ICONST_4).MultiANewArray("[[J", 2).Local(core.OP_ASTORE, grid).
+		Local(core.OP_ALOAD, grid).Op(core.OP_ICONST_2, core.OP_AALOAD, core.OP_ICONST_3).Long(math.MaxInt64).Op(core.OP_LASTORE).
+		Local(core.OP_ALOAD, grid).Op(core.OP_ARRAYLENGTH).Println("I").
+		Local(core.OP_ALOAD, grid).Op(core.OP_ICONST_0, core.OP_AALOAD, core.OP_ARRAYLENGTH).Println("I").
+		Local(core.OP_ALOAD, grid).Op(core.OP_ICONST_1, core.OP_AALOAD, core.OP_ICONST_1, core.OP_LALOAD).Println("J").
+		Local(core.OP_ALOAD, grid).Op(core.OP_ICONST_2, core.OP_AALOAD, core.OP_ICONST_3, core.OP_LALOAD).Println("J")
+
+	main.Op(core.OP_ICONST_1).NewArray('B').Op(core.OP_DUP, core.OP_ICONST_0).Int(200).Op(core.OP_BASTORE).Local(core.OP_ASTORE, bytes).
+		Op(core.OP_ICONST_1).NewArray('C').Op(core.OP_DUP, core.OP_ICONST_0).Int('a').Op(core.OP_CASTORE).Local(core.OP_ASTORE, chars).
+		Op(core.OP_ICONST_1).NewArray('S').Op(core.OP_DUP, core.OP_ICONST_0).Int(40000).Op(core.OP_SASTORE).Local(core.OP_ASTORE, shorts).
+		Op(core.OP_ICONST_1).NewArray('D').Op(core.OP_DUP, core.OP_ICONST_0).Double(0.5).Op(core.OP_DASTORE).Local(core.OP_ASTORE, doubles).
+		Local(core.OP_ALOAD, bytes).Op(core.OP_ICONST_0, core.OP_BALOAD).Println("I").
+		Local(core.OP_ALOAD, chars).Op(core.OP_ICONST_0, core.OP_CALOAD, core.OP_ICONST_1, core.OP_IADD, core.OP_I2C).Println("C").
+		Local(core.OP_ALOAD, shorts).Op(core.OP_ICONST_0, core.OP_SALOAD).Println("I").
+		Local(core.OP_ALOAD, doubles).Op(core.OP_ICONST_0, core.OP_DALOAD).Double(2).Op(core.OP_DMUL).Println("D")
+
+	main.Op(core.OP_ICONST_2).Type(core.OP_ANEWARRAY, "Person").Local(core.OP_ASTORE, people).
+		Local(core.OP_ALOAD, people).Op(core.OP_ICONST_0).
+		Type(core.OP_NEW, "Person").Op(core.OP_DUP).Invoke(core.OP_INVOKESPECIAL, "Person", "<init>", "()V").
+		Op(core.OP_AASTORE)
+
+	for _, class := range []string{"[LAnimal;", "[LMammal;", "[LArrays;"} {
+		main.Local(core.OP_ALOAD, people).Type(core.OP_INSTANCEOF, class).Println("Z")
+	}
+
+	main.Op(core.OP_ICONST_1).NewArray('I').Local(core.OP_ASTORE, array)
+
+	for _, class := range []string{"java/lang/Cloneable", "java/io/Serializable", "[Ljava/lang/Object;"} {
+		main.Local(core.OP_ALOAD, array).Type(core.OP_INSTANCEOF, class).Println("Z")
+	}
+
+	main.Op(core.OP_ICONST_2).Type(core.OP_ANEWARRAY, "[I").Local(core.OP_ASTORE, jagged).
+		Local(core.OP_ALOAD, jagged).Op(core.OP_ICONST_1, core.OP_ICONST_1).NewArray('I').
+		Op(core.OP_DUP, core.OP_ICONST_0).Int(7).Op(core.OP_IASTORE, core.OP_AASTORE).
+		Local(core.OP_ALOAD, jagged).Op(core.OP_ICONST_0, core.OP_AALOAD).Jump(core.OP_IFNONNULL, "allocated").
+		Op(core.OP_ICONST_1).Jump(core.OP_GOTO, "print").
+		Label("allocated").
+		Op(core.OP_ICONST_0).
+		Label("print").
+		Println("Z").
+		Local(core.OP_ALOAD, jagged).Op(core.OP_ICONST_1, core.OP_AALOAD, core.OP_ICONST_0, core.OP_IALOAD).Println("I").
+		Op(core.OP_RETURN)
+
+	c.Method(core.ACC_PUBLIC|core.ACC_STATIC, "main", "([Ljava/lang/String;)V", main)
+
+	return []*Class{c}
+}
@@ -0,0 +1,546 @@
+// Package fixtures assembles the class files the tests run, so running them does not need a JDK to compile
+// them. Each fixture documents the Java source it is the bytecode of.
+package fixtures
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/Gustrb/jbm/src/core"
+)
+
+// Class builds a class file, adding the constant pool entries its members and code refer to.
+type Class struct {
+	access     uint16
+	name       string
+	super      string
+	interfaces []string
+
+	pool      []byte
+	poolCount uint16
+	entries   map[string]uint16
+
+	fields     [][]byte
+	methods    [][]byte
+	attributes [][]byte
+	bootstrap  [][]byte
+}
+
+// NewClass starts a class file of Java 21 with the given access flags, name, superclass and interfaces, an
+// empty superclass is only allowed for java/lang/Object.
+func NewClass(access uint16, name string, super string, interfaces ...string) *Class {
+	return &Class{access: access, name: name, super: super, interfaces: interfaces, poolCount: 1, entries: make(map[string]uint16)}
+}
+
+// Interface starts an interface, which extends the given interfaces.
+func Interface(name string, interfaces ...string) *Class {
+	return NewClass(core.ACC_INTERFACE|core.ACC_ABSTRACT, name, "java/lang/Object", interfaces...)
+}
+
+// Name returns the internal name of the class.
+func (c *Class) Name() string {
+	return c.name
+}
+
+// entry adds a constant pool entry with the given tag and content, unless there is already one, returning
+// its index. Longs and doubles take two indexes.
+func (c *Class) entry(tag uint8, info []byte) uint16 {
+	key := string(append([]byte{tag}, info...))
+	if index, ok := c.entries[key]; ok {
+		return index
+	}
+
+	index := c.poolCount
+	c.entries[key] = index
+	c.pool = append(append(c.pool, tag), info...)
+
+	c.poolCount++
+	if tag == core.CONSTANT_Long || tag == core.CONSTANT_Double {
+		c.poolCount++
+	}
+
+	return index
+}
+
+// Utf8 adds a CONSTANT_Utf8_info entry, the fixtures only use ASCII so the string is already in modified UTF-8.
+func (c *Class) Utf8(s string) uint16 {
+	return c.entry(core.CONSTANT_Utf8, append(u2(uint16(len(s))), s...))
+}
+
+// ClassRef adds a CONSTANT_Class_info entry.
+func (c *Class) ClassRef(name string) uint16 {
+	return c.entry(core.CONSTANT_Class, u2(c.Utf8(name)))
+}
+
+// String adds a CONSTANT_String_info entry.
+func (c *Class) String(s string) uint16 {
+	return c.entry(core.CONSTANT_String, u2(c.Utf8(s)))
+}
+
+// Int adds a CONSTANT_Integer_info entry.
+func (c *Class) Int(v int32) uint16 {
+	return c.entry(core.CONSTANT_Integer, u4(uint32(v)))
+}
+
+// Float adds a CONSTANT_Float_info entry.
+func (c *Class) Float(v float32) uint16 {
+	return c.entry(core.CONSTANT_Float, u4(math.Float32bits(v)))
+}
+
+// Long adds a CONSTANT_Long_info entry.
+func (c *Class) Long(v int64) uint16 {
+	return c.entry(core.CONSTANT_Long, u8(uint64(v)))
+}
+
+// Double adds a CONSTANT_Double_info entry.
+func (c *Class) Double(v float64) uint16 {
+	return c.entry(core.CONSTANT_Double, u8(math.Float64bits(v)))
+}
+
+// NameAndType adds a CONSTANT_NameAndType_info entry.
+func (c *Class) NameAndType(name string, descriptor string) uint16 {
+	return c.entry(core.CONSTANT_NameAndType, append(u2(c.Utf8(name)), u2(c.Utf8(descriptor))...))
+}
+
+// Fieldref adds a CONSTANT_Fieldref_info entry.
+func (c *Class) Fieldref(class string, name string, descriptor string) uint16 {
+	return c.entry(core.CONSTANT_Fieldref, append(u2(c.ClassRef(class)), u2(c.NameAndType(name, descriptor))...))
+}
+
+// Methodref adds a CONSTANT_Methodref_info entry.
+func (c *Class) Methodref(class string, name string, descriptor string) uint16 {
+	return c.entry(core.CONSTANT_Methodref, append(u2(c.ClassRef(class)), u2(c.NameAndType(name, descriptor))...))
+}
+
+// InterfaceMethodref adds a CONSTANT_InterfaceMethodref_info entry.
+func (c *Class) InterfaceMethodref(class string, name string, descriptor string) uint16 {
+	return c.entry(core.CONSTANT_InterfaceMethodref, append(u2(c.ClassRef(class)), u2(c.NameAndType(name, descriptor))...))
+}
+
+// MethodHandle adds a CONSTANT_MethodHandle_info entry of the given kind (one of the REF_* constants).
+func (c *Class) MethodHandle(kind uint8, ref uint16) uint16 {
+	return c.entry(core.CONSTANT_MethodHandle, append([]byte{kind}, u2(ref)...))
+}
+
+// MethodType adds a CONSTANT_MethodType_info entry.
+func (c *Class) MethodType(descriptor string) uint16 {
+	return c.entry(core.CONSTANT_MethodType, u2(c.Utf8(descriptor)))
+}
+
+// Bootstrap adds a bootstrap method to the BootstrapMethods attribute, returning its index.
+func (c *Class) Bootstrap(handle uint16, args ...uint16) uint16 {
+	method := append(u2(handle), u2(uint16(len(args)))...)
+	for _, arg := range args {
+		method = append(method, u2(arg)...)
+	}
+
+	c.bootstrap = append(c.bootstrap, method)
+
+	return uint16(len(c.bootstrap) - 1)
+}
+
+// Metafactory adds a bootstrap method that links a lambda with `LambdaMetafactory.metafactory`, implementing
+// the method of the functional interface whose erased descriptor is given with the method handle.
+func (c *Class) Metafactory(erased string, implementation uint16, instantiated string) uint16 {
+	metafactory := c.MethodHandle(core.REF_invokeStatic, c.Methodref("java/lang/invoke/LambdaMetafactory", "metafactory",
+		"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodType;"+
+			"Ljava/lang/invoke/MethodHandle;Ljava/lang/invoke/MethodType;)Ljava/lang/invoke/CallSite;",
+	))
+
+	return c.Bootstrap(metafactory, c.MethodType(erased), implementation, c.MethodType(instantiated))
+}
+
+// InvokeDynamic adds a CONSTANT_InvokeDynamic_info entry.
+func (c *Class) InvokeDynamic(bootstrap uint16, name string, descriptor string) uint16 {
+	return c.entry(core.CONSTANT_InvokeDynamic, append(u2(bootstrap), u2(c.NameAndType(name, descriptor))...))
+}
+
+// Field adds a field.
+func (c *Class) Field(access uint16, name string, descriptor string) *Class {
+	c.fields = append(c.fields, c.member(access, name, descriptor))
+	return c
+}
+
+// Method adds a method, the code is nil for abstract and native methods.
+func (c *Class) Method(access uint16, name string, descriptor string, code *Code) *Class {
+	if code == nil {
+		c.methods = append(c.methods, c.member(access, name, descriptor))
+	} else {
+		c.methods = append(c.methods, c.member(access, name, descriptor, code.attribute()))
+	}
+
+	return c
+}
+
+// Constructor adds a constructor that only calls the one of the superclass without arguments.
+func (c *Class) Constructor(access uint16) *Class {
+	return c.Method(access, "<init>", "()V", c.Code(1, 1).
+		Op(core.OP_ALOAD_0).Invoke(core.OP_INVOKESPECIAL, c.super, "<init>", "()V").
+		Op(core.OP_RETURN),
+	)
+}
+
+// SourceFile adds the SourceFile attribute.
+func (c *Class) SourceFile(name string) *Class {
+	c.attributes = append(c.attributes, c.attribute("SourceFile", u2(c.Utf8(name))))
+	return c
+}
+
+func (c *Class) member(access uint16, name string, descriptor string, attributes ...[]byte) []byte {
+	out := append(u2(access), u2(c.Utf8(name))...)
+	out = append(out, u2(c.Utf8(descriptor))...)
+	out = append(out, u2(uint16(len(attributes)))...)
+
+	for _, attribute := range attributes {
+		out = append(out, attribute...)
+	}
+
+	return out
+}
+
+func (c *Class) attribute(name string, info []byte) []byte {
+	return append(append(u2(c.Utf8(name)), u4(uint32(len(info)))...), info...)
+}
+
+// Bytes returns the class file.
+func (c *Class) Bytes() []byte {
+	this := c.ClassRef(c.name)
+
+	super := uint16(0)
+	if c.super != "" {
+		super = c.ClassRef(c.super)
+	}
+
+	interfaces := u2(uint16(len(c.interfaces)))
+	for _, name := range c.interfaces {
+		interfaces = append(interfaces, u2(c.ClassRef(name))...)
+	}
+
+	attributes := c.attributes
+	if len(c.bootstrap) > 0 {
+		info := u2(uint16(len(c.bootstrap)))
+		for _, method := range c.bootstrap {
+			info = append(info, method...)
+		}
+
+		attributes = append(attributes, c.attribute("BootstrapMethods", info))
+	}
+
+	// every entry of the constant pool is known once the attributes are built
+	out := []byte{0xCA, 0xFE, 0xBA, 0xBE, 0x00, 0x00, 0x00, 0x41}
+	out = append(out, u2(c.poolCount)...)
+	out = append(out, c.pool...)
+	out = append(out, u2(c.access)...)
+	out = append(out, u2(this)...)
+	out = append(out, u2(super)...)
+	out = append(out, interfaces...)
+
+	for _, members := range [][][]byte{c.fields, c.methods, attributes} {
+		out = append(out, u2(uint16(len(members)))...)
+		for _, member := range members {
+			out = append(out, member...)
+		}
+	}
+
+	return out
+}
+
+// Write writes the class files of the classes in the directory, where a class path can find them.
+func Write(dir string, classes ...*Class) error {
+	for _, class := range classes {
+		path := filepath.Join(dir, filepath.FromSlash(class.name)+".class")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(path, class.Bytes(), 0o644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Code builds the Code attribute of a method. Branches jump to labels, which can be placed after them.
+type Code struct {
+	class     *Class
+	maxStack  uint16
+	maxLocals uint16
+
+	bytecode []byte
+	labels   map[string]int
+	branches []branch
+	handlers []handler
+	lines    []byte
+}
+
+// branch is an offset to a label to fill in once the label is placed.
+type branch struct {
+	// from is the address of the instruction, the offset is relative to it.
+	from int
+	// at is the address of the offset, wide offsets take 4 bytes.
+	at    int
+	wide  bool
+	label string
+}
+
+// handler is an entry of the exception table.
+type handler struct {
+	start, end, handler string
+	catchType           uint16
+}
+
+// Code starts the code of a method of the class.
+func (c *Class) Code(maxStack uint16, maxLocals uint16) *Code {
+	return &Code{class: c, maxStack: maxStack, maxLocals: maxLocals, labels: make(map[string]int)}
+}
+
+// Op adds instructions without operands or with operands given as raw bytes.
+func (c *Code) Op(bytecode ...byte) *Code {
+	c.bytecode = append(c.bytecode, bytecode...)
+	return c
+}
+
+// Local adds an instruction taking a local variable index, like iload or astore.
+func (c *Code) Local(op byte, index uint8) *Code {
+	return c.Op(op, index)
+}
+
+// Iinc adds an iinc of the local variable.
+func (c *Code) Iinc(index uint8, delta int8) *Code {
+	return c.Op(core.OP_IINC, index, byte(delta))
+}
+
+// Int pushes an int with the shortest instruction.
+func (c *Code) Int(v int32) *Code {
+	switch {
+	case v >= -1 && v <= 5:
+		return c.Op(core.OP_ICONST_0 + byte(v))
+	case v >= math.MinInt8 && v <= math.MaxInt8:
+		return c.Op(core.OP_BIPUSH, byte(v))
+	case v >= math.MinInt16 && v <= math.MaxInt16:
+		return c.Op(core.OP_SIPUSH, byte(v>>8), byte(v))
+	}
+
+	return c.ldc(c.class.Int(v))
+}
+
+// Long pushes a long, with lconst when it can.
+func (c *Code) Long(v int64) *Code {
+	if v == 0 || v == 1 {
+		return c.Op(core.OP_LCONST_0 + byte(v))
+	}
+
+	return c.Op(core.OP_LDC2_W).index(c.class.Long(v))
+}
+
+// Float pushes a float, with fconst when it can.
+func (c *Code) Float(v float32) *Code {
+	if (v == 0 && !math.Signbit(float64(v))) || v == 1 || v == 2 {
+		return c.Op(core.OP_FCONST_0 + byte(v))
+	}
+
+	return c.ldc(c.class.Float(v))
+}
+
+// Double pushes a double, with dconst when it can.
+func (c *Code) Double(v float64) *Code {
+	if (v == 0 && !math.Signbit(v)) || v == 1 {
+		return c.Op(core.OP_DCONST_0 + byte(v))
+	}
+
+	return c.Op(core.OP_LDC2_W).index(c.class.Double(v))
+}
+
+// String pushes a string literal.
+func (c *Code) String(s string) *Code {
+	return c.ldc(c.class.String(s))
+}
+
+func (c *Code) ldc(index uint16) *Code {
+	if index <= math.MaxUint8 {
+		return c.Op(core.OP_LDC, byte(index))
+	}
+
+	return c.Op(core.OP_LDC_W).index(index)
+}
+
+func (c *Code) index(index uint16) *Code {
+	return c.Op(u2(index)...)
+}
+
+// Field adds getfield, putfield, getstatic or putstatic.
+func (c *Code) Field(op byte, class string, name string, descriptor string) *Code {
+	return c.Op(op).index(c.class.Fieldref(class, name, descriptor))
+}
+
+// Invoke adds invokevirtual, invokespecial or invokestatic of a method of a class.
+func (c *Code) Invoke(op byte, class string, name string, descriptor string) *Code {
+	return c.Op(op).index(c.class.Methodref(class, name, descriptor))
+}
+
+// InvokeInterface adds invokeinterface, invokespecial or invokestatic of a method of an interface.
+func (c *Code) InvokeInterface(op byte, class string, name string, descriptor string) *Code {
+	c.Op(op).index(c.class.InterfaceMethodref(class, name, descriptor))
+
+	if op == core.OP_INVOKEINTERFACE {
+		slots, _ := core.ParameterSlots(descriptor)
+		c.Op(byte(slots+1), 0)
+	}
+
+	return c
+}
+
+// InvokeDynamic adds an invokedynamic linked by the given bootstrap method.
+func (c *Code) InvokeDynamic(bootstrap uint16, name string, descriptor string) *Code {
+	return c.Op(core.OP_INVOKEDYNAMIC).index(c.class.InvokeDynamic(bootstrap, name, descriptor)).Op(0, 0)
+}
+
+// Type adds new, anewarray, checkcast or instanceof.
+func (c *Code) Type(op byte, class string) *Code {
+	return c.Op(op).index(c.class.ClassRef(class))
+}
+
+// arrayTypes are the operands of newarray, by the descriptor of the type of the elements.
+var arrayTypes = map[byte]byte{'Z': 4, 'C': 5, 'F': 6, 'D': 7, 'B': 8, 'S': 9, 'I': 10, 'J': 11}
+
+// NewArray adds a newarray of elements of the primitive type with the given descriptor.
+func (c *Code) NewArray(descriptor byte) *Code {
+	return c.Op(core.OP_NEWARRAY, arrayTypes[descriptor])
+}
+
+// MultiANewArray adds a multianewarray of the array class with the given dimensions.
+func (c *Code) MultiANewArray(class string, dimensions uint8) *Code {
+	return c.Op(core.OP_MULTIANEWARRAY).index(c.class.ClassRef(class)).Op(dimensions)
+}
+
+// Println prints the value on top of the stack with `System.out.println`, its descriptor being one of the
+// overloads of println. It needs two slots of stack above the value.
+func (c *Code) Println(descriptor string) *Code {
+	c.Field(core.OP_GETSTATIC, "java/lang/System", "out", "Ljava/io/PrintStream;")
+
+	// the stream goes below the value
+	if descriptor == "J" || descriptor == "D" {
+		c.Op(core.OP_DUP_X2, core.OP_POP)
+	} else {
+		c.Op(core.OP_SWAP)
+	}
+
+	return c.Invoke(core.OP_INVOKEVIRTUAL, "java/io/PrintStream", "println", "("+descriptor+")V")
+}
+
+// Label places the label at the next instruction.
+func (c *Code) Label(name string) *Code {
+	c.labels[name] = len(c.bytecode)
+	return c
+}
+
+// Jump adds a branch instruction, like goto or ifeq, to the label.
+func (c *Code) Jump(op byte, label string) *Code {
+	c.branches = append(c.branches, branch{from: len(c.bytecode), at: len(c.bytecode) + 1, label: label})
+	return c.Op(op, 0, 0)
+}
+
+// TableSwitch adds a tableswitch jumping to the labels for the keys from low, and to the default label for the
+// other keys.
+func (c *Code) TableSwitch(low int32, defaultLabel string, labels ...string) *Code {
+	from := len(c.bytecode)
+	c.Op(core.OP_TABLESWITCH)
+
+	for len(c.bytecode)%4 != 0 {
+		c.Op(0)
+	}
+
+	c.wideBranch(from, defaultLabel)
+	c.Op(u4(uint32(low))...)
+	c.Op(u4(uint32(low + int32(len(labels)) - 1))...)
+
+	for _, label := range labels {
+		c.wideBranch(from, label)
+	}
+
+	return c
+}
+
+func (c *Code) wideBranch(from int, label string) {
+	c.branches = append(c.branches, branch{from: from, at: len(c.bytecode), wide: true, label: label})
+	c.Op(0, 0, 0, 0)
+}
+
+// Try adds a handler of the exceptions of the given class, or of any exception when it is empty, thrown by
+// the instructions between the start and end labels.
+func (c *Code) Try(start string, end string, handlerLabel string, catchType string) *Code {
+	var index uint16
+	if catchType != "" {
+		index = c.class.ClassRef(catchType)
+	}
+
+	c.handlers = append(c.handlers, handler{start: start, end: end, handler: handlerLabel, catchType: index})
+
+	return c
+}
+
+// Line maps the next instructions to the given line of the source file.
+func (c *Code) Line(line uint16) *Code {
+	c.lines = append(c.lines, u2(uint16(len(c.bytecode)))...)
+	c.lines = append(c.lines, u2(line)...)
+
+	return c
+}
+
+func (c *Code) label(name string) int {
+	address, ok := c.labels[name]
+	if !ok {
+		panic("fixtures: undefined label " + name)
+	}
+
+	return address
+}
+
+// attribute returns the Code attribute, filling the offsets of the branches.
+func (c *Code) attribute() []byte {
+	for _, b := range c.branches {
+		offset := c.label(b.label) - b.from
+		if b.wide {
+			binary.BigEndian.PutUint32(c.bytecode[b.at:], uint32(int32(offset)))
+		} else {
+			binary.BigEndian.PutUint16(c.bytecode[b.at:], uint16(int16(offset)))
+		}
+	}
+
+	info := append(u2(c.maxStack), u2(c.maxLocals)...)
+	info = append(info, u4(uint32(len(c.bytecode)))...)
+	info = append(info, c.bytecode...)
+
+	info = append(info, u2(uint16(len(c.handlers)))...)
+	for _, h := range c.handlers {
+		info = append(info, u2(uint16(c.label(h.start)))...)
+		info = append(info, u2(uint16(c.label(h.end)))...)
+		info = append(info, u2(uint16(c.label(h.handler)))...)
+		info = append(info, u2(h.catchType)...)
+	}
+
+	if len(c.lines) == 0 {
+		return c.class.attribute("Code", append(info, u2(0)...))
+	}
+
+	lines := append(u2(uint16(len(c.lines)/4)), c.lines...)
+	info = append(info, u2(1)...)
+	info = append(info, c.class.attribute("LineNumberTable", lines)...)
+
+	return c.class.attribute("Code", info)
+}
+
+func u2(v uint16) []byte {
+	return binary.BigEndian.AppendUint16(nil, v)
+}
+
+func u4(v uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, v)
+}
+
+func u8(v uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, v)
+}
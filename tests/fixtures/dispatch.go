@@ -0,0 +1,139 @@
+package fixtures
+
+import "github.com/Gustrb/jbm/src/core"
+
+// Dispatch calls the methods of a class hierarchy through its interfaces, superclasses and private methods,
+// printing what they return:
+//
+//	interface Shape {
+//	    int sides();
+//
+//	    default int corners() {
+//	        return sides();
+//	    }
+//	}
+//
+//	interface Named {
+//	    default int name() {
+//	        return 1;
+//	    }
+//	}
+//
+//	interface Labeled extends Named {
+//	    // more specific than the default method of Named
+//	    default int name() {
+//	        return 2;
+//	    }
+//	}
+//
+//	abstract class Polygon implements Shape, Labeled {
+//	    int scale() {
+//	        return 1;
+//	    }
+//
+//	    private int secret() {
+//	        return 10;
+//	    }
+//
+//	    int reveal() {
+//	        return secret();
+//	    }
+//	}
+//
+//	class Square extends Polygon {
+//	    public int sides() {
+//	        return 4;
+//	    }
+//
+//	    int scale() {
+//	        return super.scale() + 1;
+//	    }
+//	}
+//
+//	class Triangle extends Polygon {
+//	    public int sides() {
+//	        return 3;
+//	    }
+//
+//	    public int corners() {
+//	        return Shape.super.corners() * 10;
+//	    }
+//	}
+//
+//	public class Dispatch {
+//	    public static void main(String[] args) {
+//	        Shape square = new Square();
+//	        Shape triangle = new Triangle();
+//	        System.out.println(square.sides());             // 4
+//	        System.out.println(triangle.sides());           // 3
+//	        System.out.println(square.corners());           // 4
+//	        System.out.println(triangle.corners());         // 30
+//
+//	        Polygon polygon = (Polygon) square;
+//	        System.out.println(polygon.scale());            // 2
+//	        System.out.println(new Triangle().scale());     // 1
+//	        System.out.println(polygon.name());             // 2
+//	        System.out.println(polygon.reveal());           // 10
+//	    }
+//	}
+func Dispatch() []*Class {
+	shape := Interface("Shape")
+	shape.Method(core.ACC_PUBLIC|core.ACC_ABSTRACT, "sides", "()I", nil)
+	shape.Method(core.ACC_PUBLIC, "corners", "()I", shape.Code(1, 1).
+		Op(core.OP_ALOAD_0).InvokeInterface(core.OP_INVOKEINTERFACE, "Shape", "sides", "()I").
+		Op(core.OP_IRETURN),
+	)
+
+	named := Interface("Named")
+	named.Method(core.ACC_PUBLIC, "name", "()I", named.Code(1, 1).Op(core.OP_ICONST_1, core.OP_IRETURN))
+
+	labeled := Interface("Labeled", "Named")
+	labeled.Method(core.ACC_PUBLIC, "name", "()I", labeled.Code(1, 1).Op(core.OP_ICONST_2, core.OP_IRETURN))
+
+	polygon := NewClass(core.ACC_ABSTRACT|core.ACC_SUPER, "Polygon", "java/lang/Object", "Shape", "Labeled")
+	polygon.Constructor(0)
+	polygon.Method(0, "scale", "()I", polygon.Code(1, 1).Op(core.OP_ICONST_1, core.OP_IRETURN))
+	polygon.Method(core.ACC_PRIVATE, "secret", "()I", polygon.Code(1, 1).Int(10).Op(core.OP_IRETURN))
+	polygon.Method(0, "reveal", "()I", polygon.Code(1, 1).
+		Op(core.OP_ALOAD_0).Invoke(core.OP_INVOKESPECIAL, "Polygon", "secret", "()I").
+		Op(core.OP_IRETURN),
+	)
+
+	square := NewClass(core.ACC_SUPER, "Square", "Polygon")
+	square.Constructor(0)
+	square.Method(core.ACC_PUBLIC, "sides", "()I", square.Code(1, 1).Op(core.OP_ICONST_4, core.OP_IRETURN))
+	square.Method(0, "scale", "()I", square.Code(2, 1).
+		Op(core.OP_ALOAD_0).Invoke(core.OP_INVOKESPECIAL, "Polygon", "scale", "()I").
+		Op(core.OP_ICONST_1, core.OP_IADD, core.OP_IRETURN),
+	)
+
+	triangle := NewClass(core.ACC_SUPER, "Triangle", "Polygon")
+	triangle.Constructor(0)
+	triangle.Method(core.ACC_PUBLIC, "sides", "()I", triangle.Code(1, 1).Op(core.OP_ICONST_3, core.OP_IRETURN))
+	triangle.Method(core.ACC_PUBLIC, "corners", "()I", triangle.Code(2, 1).
+		Op(core.OP_ALOAD_0).InvokeInterface(core.OP_INVOKESPECIAL, "Shape", "corners", "()I").
+		Int(10).Op(core.OP_IMUL, core.OP_IRETURN),
+	)
+
+	dispatch := NewClass(core.ACC_PUBLIC|core.ACC_SUPER, "Dispatch", "java/lang/Object")
+	dispatch.Constructor(core.ACC_PUBLIC)
+
+	main := dispatch.Code(3, 4).
+		Type(core.OP_NEW, "Square").Op(core.OP_DUP).Invoke(core.OP_INVOKESPECIAL, "Square", "<init>", "()V").Op(core.OP_ASTORE_1).
+		Type(core.OP_NEW, "Triangle").Op(core.OP_DUP).Invoke(core.OP_INVOKESPECIAL, "Triangle", "<init>", "()V").Op(core.OP_ASTORE_2).
+		Op(core.OP_ALOAD_1).InvokeInterface(core.OP_INVOKEINTERFACE, "Shape", "sides", "()I").Println("I").
+		Op(core.OP_ALOAD_2).InvokeInterface(core.OP_INVOKEINTERFACE, "Shape", "sides", "()I").Println("I").
+		Op(core.OP_ALOAD_1).InvokeInterface(core.OP_INVOKEINTERFACE, "Shape", "corners", "()I").Println("I").
+		Op(core.OP_ALOAD_2).InvokeInterface(core.OP_INVOKEINTERFACE, "Shape", "corners", "()I").Println("I").
+		Op(core.OP_ALOAD_1).Type(core.OP_CHECKCAST, "Polygon").Op(core.OP_ASTORE_3).
+		Op(core.OP_ALOAD_3).Invoke(core.OP_INVOKEVIRTUAL, "Polygon", "scale", "()I").Println("I").
+		Type(core.OP_NEW, "Triangle").Op(core.OP_DUP).Invoke(core.OP_INVOKESPECIAL, "Triangle", "<init>", "()V").
+		Invoke(core.OP_INVOKEVIRTUAL, "Triangle", "scale", "()I").Println("I").
+		Op(core.OP_ALOAD_3).Invoke(core.OP_INVOKEVIRTUAL, "Polygon", "name", "()I").Println("I").
+		Op(core.OP_ALOAD_3).Invoke(core.OP_INVOKEVIRTUAL, "Polygon", "reveal", "()I").Println("I").
+		Op(core.OP_RETURN)
+
+	dispatch.Method(core.ACC_PUBLIC|core.ACC_STATIC, "main", "([Ljava/lang/String;)V", main)
+
+	return []*Class{shape, named, labeled, polygon, square, triangle, dispatch}
+}
@@ -0,0 +1,224 @@
+package fixtures
+
+import "github.com/Gustrb/jbm/src/core"
+
+// Exceptions throws exceptions of its own and of the JVM, printing what catches them:
+//
+//	class Boom extends RuntimeException {
+//	}
+//
+//	class Fatal extends Error {
+//	}
+//
+//	public class Exceptions {
+//	    static int finallyRuns = 0;
+//
+//	    static int divide(int a, int b) {
+//	        try {
+//	            return a / b;
+//	        } catch (ArithmeticException e) {
+//	            return -1;
+//	        }
+//	    }
+//
+//	    static void explode() {
+//	        throw new Boom();
+//	    }
+//
+//	    static int unwind() {
+//	        try {
+//	            explode();
+//	            return 0;
+//	        } finally {
+//	            finallyRuns++;
+//	        }
+//	    }
+//
+//	    public static void main(String[] args) {
+//	        System.out.println(divide(6, 3));                           // 2
+//	        System.out.println(divide(1, 0));                           // -1
+//
+//	        try {
+//	            unwind();
+//	            System.out.println("unwound");
+//	        } catch (Boom e) {
+//	            System.out.println(finallyRuns);                        // 1
+//	        }
+//
+//	        try {
+//	            int[] empty = null;
+//	            System.out.println(empty.length);
+//	        } catch (NullPointerException e) {
+//	            System.out.println("NullPointerException");
+//	        }
+//
+//	        try {
+//	            Object boom = new Boom();
+//	            System.out.println((Fatal) boom);
+//	        } catch (ClassCastException e) {
+//	            System.out.println("ClassCastException");
+//	        }
+//
+//	        try {
+//	            int[] values = new int[1];
+//	            values[2] = 0;
+//	        } catch (IndexOutOfBoundsException e) {
+//	            System.out.println("IndexOutOfBoundsException");
+//	        }
+//
+//	        try {
+//	            try {
+//	                throw new Fatal();
+//	            } catch (RuntimeException e) {
+//	                System.out.println("RuntimeException");
+//	            }
+//	        } catch (Error e) {
+//	            System.out.println("Error");
+//	        }
+//
+//	        Boom boom = new Boom();
+//	        try {
+//	            throw boom;
+//	        } catch (Boom e) {
+//	            System.out.println(e == boom);                          // true
+//	        }
+//	    }
+//	}
+func Exceptions() []*Class {
+	boom := NewClass(core.ACC_SUPER, "Boom", "java/lang/RuntimeException")
+	boom.Constructor(0)
+
+	fatal := NewClass(core.ACC_SUPER, "Fatal", "java/lang/Error")
+	fatal.Constructor(0)
+
+	c := NewClass(core.ACC_PUBLIC|core.ACC_SUPER, "Exceptions", "java/lang/Object")
+	c.Constructor(core.ACC_PUBLIC)
+	c.Field(core.ACC_STATIC, "finallyRuns", "I")
+
+	c.Method(core.ACC_STATIC, "divide", "(II)I", c.Code(2, 3).
+		Label("start").
+		Op(core.OP_ILOAD_0, core.OP_ILOAD_1, core.OP_IDIV, core.OP_IRETURN).
+		Label("end").
+		Op(core.OP_ASTORE_2, core.OP_ICONST_M1, core.OP_IRETURN).
+		Try("start", "end", "end", "java/lang/ArithmeticException"),
+	)
+
+	c.Method(core.ACC_STATIC, "explode", "()V", c.Code(2, 0).
+		Type(core.OP_NEW, "Boom").Op(core.OP_DUP).Invoke(core.OP_INVOKESPECIAL, "Boom", "<init>", "()V").
+		Op(core.OP_ATHROW),
+	)
+
+	// the finally block is copied after the return and in the handler of any exception, like javac does
+	c.Method(core.ACC_STATIC, "unwind", "()I", c.Code(2, 2).
+		Label("start").
+		Invoke(core.OP_INVOKESTATIC, "Exceptions", "explode", "()V").
+		Op(core.OP_ICONST_0, core.OP_ISTORE_0).
+		Label("end").
+		Field(core.OP_GETSTATIC, "Exceptions", "finallyRuns", "I").Op(core.OP_ICONST_1, core.OP_IADD).
+		Field(core.OP_PUTSTATIC, "Exceptions", "finallyRuns", "I").
+		Op(core.OP_ILOAD_0, core.OP_IRETURN).
+		Label("finally").
+		Op(core.OP_ASTORE_1).
+		Field(core.OP_GETSTATIC, "Exceptions", "finallyRuns", "I").Op(core.OP_ICONST_1, core.OP_IADD).
+		Field(core.OP_PUTSTATIC, "Exceptions", "finallyRuns", "I").
+		Op(core.OP_ALOAD_1, core.OP_ATHROW).
+		Try("start", "end", "finally", ""),
+	)
+
+	main := c.Code(4, 3)
+
+	main.Int(6).Op(core.OP_ICONST_3).Invoke(core.OP_INVOKESTATIC, "Exceptions", "divide", "(II)I").Println("I").
+		Op(core.OP_ICONST_1, core.OP_ICONST_0).Invoke(core.OP_INVOKESTATIC, "Exceptions", "divide", "(II)I").Println("I")
+
+	main.Label("unwind").
+		Invoke(core.OP_INVOKESTATIC, "Exceptions", "unwind", "()I").Op(core.OP_POP).
+		String("unwound").Println("Ljava/lang/String;").
+		Label("unwound").
+		Jump(core.OP_GOTO, "null").
+		Label("boom").
+		Op(core.OP_ASTORE_1).Field(core.OP_GETSTATIC, "Exceptions", "finallyRuns", "I").Println("I").
+		Try("unwind", "unwound", "boom", "Boom")
+
+	main.Label("null").
+		Op(core.OP_ACONST_NULL, core.OP_ASTORE_1, core.OP_ALOAD_1, core.OP_ARRAYLENGTH).Println("I").
+		Label("dereferenced").
+		Jump(core.OP_GOTO, "cast").
+		Label("null pointer").
+		Op(core.OP_ASTORE_1).String("NullPointerException").Println("Ljava/lang/String;").
+		Try("null", "dereferenced", "null pointer", "java/lang/NullPointerException")
+
+	main.Label("cast").
+		Type(core.OP_NEW, "Boom").Op(core.OP_DUP).Invoke(core.OP_INVOKESPECIAL, "Boom", "<init>", "()V").
+		Op(core.OP_ASTORE_1, core.OP_ALOAD_1).Type(core.OP_CHECKCAST, "Fatal").Println("Ljava/lang/Object;").
+		Label("casted").
+		Jump(core.OP_GOTO, "index").
+		Label("class cast").
+		Op(core.OP_ASTORE_1).String("ClassCastException").Println("Ljava/lang/String;").
+		Try("cast", "casted", "class cast", "java/lang/ClassCastException")
+
+	main.Label("index").
+		Op(core.OP_ICONST_1).NewArray('I').Op(core.OP_ASTORE_1).
+		Op(core.OP_ALOAD_1, core.OP_ICONST_2, core.OP_ICONST_0, core.OP_IASTORE).
+		Label("indexed").
+		Jump(core.OP_GOTO, "fatal").
+		Label("out of bounds").
+		Op(core.OP_ASTORE_1).String("IndexOutOfBoundsException").Println("Ljava/lang/String;").
+		Try("index", "indexed", "out of bounds", "java/lang/IndexOutOfBoundsException")
+
+	main.Label("fatal").
+		Type(core.OP_NEW, "Fatal").Op(core.OP_DUP).Invoke(core.OP_INVOKESPECIAL, "Fatal", "<init>", "()V").
+		Op(core.OP_ATHROW).
+		Label("thrown").
+		Op(core.OP_ASTORE_1).String("RuntimeException").Println("Ljava/lang/String;").
+		Label("caught").
+		Jump(core.OP_GOTO, "rethrow").
+		Label("error").
+		Op(core.OP_ASTORE_1).String("Error").Println("Ljava/lang/String;").
+		Try("fatal", "thrown", "thrown", "java/lang/RuntimeException").
+		Try("fatal", "caught", "error", "java/lang/Error")
+
+	main.Label("rethrow").
+		Type(core.OP_NEW, "Boom").Op(core.OP_DUP).Invoke(core.OP_INVOKESPECIAL, "Boom", "<init>", "()V").
+		Op(core.OP_ASTORE_2).
+		Label("throw").
+		Op(core.OP_ALOAD_2, core.OP_ATHROW).
+		Label("same").
+		Op(core.OP_ASTORE_1, core.OP_ALOAD_1, core.OP_ALOAD_2).Jump(core.OP_IF_ACMPNE, "other").
+		Op(core.OP_ICONST_1).Jump(core.OP_GOTO, "print").
+		Label("other").
+		Op(core.OP_ICONST_0).
+		Label("print").
+		Println("Z").
+		Op(core.OP_RETURN).
+		Try("throw", "same", "same", "Boom")
+
+	c.Method(core.ACC_PUBLIC|core.ACC_STATIC, "main", "([Ljava/lang/String;)V", main)
+
+	return []*Class{boom, fatal, c}
+}
+
+// Uncaught exits with a division by zero, which nothing catches:
+//
+//	public class Uncaught {
+//	    static int zero() {
+//	        return 0;
+//	    }
+//
+//	    public static void main(String[] args) {
+//	        System.exit(1 / zero());
+//	    }
+//	}
+func Uncaught() *Class {
+	c := NewClass(core.ACC_PUBLIC|core.ACC_SUPER, "Uncaught", "java/lang/Object")
+	c.Constructor(core.ACC_PUBLIC)
+
+	c.Method(core.ACC_STATIC, "zero", "()I", c.Code(1, 0).Op(core.OP_ICONST_0, core.OP_IRETURN))
+
+	c.Method(core.ACC_PUBLIC|core.ACC_STATIC, "main", "([Ljava/lang/String;)V", c.Code(2, 1).
+		Op(core.OP_ICONST_1).Invoke(core.OP_INVOKESTATIC, "Uncaught", "zero", "()I").Op(core.OP_IDIV).
+		Invoke(core.OP_INVOKESTATIC, "java/lang/System", "exit", "(I)V").
+		Op(core.OP_RETURN),
+	)
+
+	return c
+}
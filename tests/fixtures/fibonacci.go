@@ -0,0 +1,84 @@
+package fixtures
+
+import "github.com/Gustrb/jbm/src/core"
+
+// Fibonacci exits with the 10th Fibonacci number, computed recursively and checked against the iterative
+// version unless a tableswitch skips the check:
+//
+//	public class Fibonacci {
+//	    static int fib(int n) {
+//	        if (n < 2) {
+//	            return n;
+//	        }
+//
+//	        return fib(n - 1) + fib(n - 2);
+//	    }
+//
+//	    static int iterativeFib(int n) {
+//	        int previous = 0, current = 1;
+//	        for (int i = 0; i < n; i++) {
+//	            int next = previous + current;
+//	            previous = current;
+//	            current = next;
+//	        }
+//
+//	        return previous;
+//	    }
+//
+//	    public static void main(String[] args) {
+//	        int result = fib(10);
+//
+//	        switch (result % 4) {
+//	            case 0:
+//	            case 1:
+//	            case 2:
+//	                break;
+//	            default:
+//	                if (result != iterativeFib(10)) {
+//	                    System.exit(1);
+//	                }
+//	        }
+//
+//	        System.exit(result);
+//	    }
+//	}
+func Fibonacci() *Class {
+	c := NewClass(core.ACC_PUBLIC|core.ACC_SUPER, "Fibonacci", "java/lang/Object")
+	c.Constructor(core.ACC_PUBLIC)
+
+	c.Method(core.ACC_STATIC, "fib", "(I)I", c.Code(3, 1).
+		Op(core.OP_ILOAD_0, core.OP_ICONST_2).Jump(core.OP_IF_ICMPGE, "recurse").
+		Op(core.OP_ILOAD_0, core.OP_IRETURN).
+		Label("recurse").
+		Op(core.OP_ILOAD_0, core.OP_ICONST_1, core.OP_ISUB).Invoke(core.OP_INVOKESTATIC, "Fibonacci", "fib", "(I)I").
+		Op(core.OP_ILOAD_0, core.OP_ICONST_2, core.OP_ISUB).Invoke(core.OP_INVOKESTATIC, "Fibonacci", "fib", "(I)I").
+		Op(core.OP_IADD, core.OP_IRETURN),
+	)
+
+	c.Method(core.ACC_STATIC, "iterativeFib", "(I)I", c.Code(2, 5).
+		Op(core.OP_ICONST_0, core.OP_ISTORE_1, core.OP_ICONST_1, core.OP_ISTORE_2, core.OP_ICONST_0, core.OP_ISTORE_3).
+		Label("loop").
+		Op(core.OP_ILOAD_3, core.OP_ILOAD_0).Jump(core.OP_IF_ICMPGE, "done").
+		Op(core.OP_ILOAD_1, core.OP_ILOAD_2, core.OP_IADD).Local(core.OP_ISTORE, 4).
+		Op(core.OP_ILOAD_2, core.OP_ISTORE_1).Local(core.OP_ILOAD, 4).Op(core.OP_ISTORE_2).
+		Iinc(3, 1).
+		Jump(core.OP_GOTO, "loop").
+		Label("done").
+		Op(core.OP_ILOAD_1, core.OP_IRETURN),
+	)
+
+	c.Method(core.ACC_PUBLIC|core.ACC_STATIC, "main", "([Ljava/lang/String;)V", c.Code(2, 2).
+		Int(10).Invoke(core.OP_INVOKESTATIC, "Fibonacci", "fib", "(I)I").Op(core.OP_ISTORE_1).
+		Op(core.OP_ILOAD_1, core.OP_ICONST_4, core.OP_IREM).
+		TableSwitch(0, "check", "exit", "exit", "exit").
+		Label("check").
+		Op(core.OP_ILOAD_1).Int(10).Invoke(core.OP_INVOKESTATIC, "Fibonacci", "iterativeFib", "(I)I").
+		Jump(core.OP_IF_ICMPEQ, "exit").
+		Op(core.OP_ICONST_1).Invoke(core.OP_INVOKESTATIC, "java/lang/System", "exit", "(I)V").
+		Label("exit").
+		Op(core.OP_ILOAD_1).Invoke(core.OP_INVOKESTATIC, "java/lang/System", "exit", "(I)V").
+		Op(core.OP_RETURN),
+	)
+
+	return c
+}
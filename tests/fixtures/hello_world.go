@@ -0,0 +1,22 @@
+package fixtures
+
+import "github.com/Gustrb/jbm/src/core"
+
+// HelloWorld prints a greeting:
+//
+//	public class HelloWorld {
+//	    public static void main(String[] args) {
+//	        System.out.println("Hello, World!");
+//	    }
+//	}
+func HelloWorld() *Class {
+	c := NewClass(core.ACC_PUBLIC|core.ACC_SUPER, "HelloWorld", "java/lang/Object")
+	c.Constructor(core.ACC_PUBLIC)
+
+	c.Method(core.ACC_PUBLIC|core.ACC_STATIC, "main", "([Ljava/lang/String;)V", c.Code(2, 1).
+		String("Hello, World!").Println("Ljava/lang/String;").
+		Op(core.OP_RETURN),
+	)
+
+	return c
+}
@@ -0,0 +1,111 @@
+package fixtures
+
+import "github.com/Gustrb/jbm/src/core"
+
+// Instances allocates objects, checks their classes and reads the fields a subclass shadows, printing
+// true, true, true, false, 3, -3, 4 and 0.5. It uses the classes of the Person fixture:
+//
+//	class Point {
+//	    int x;
+//	    long y;
+//
+//	    Point(int x, long y) {
+//	        this.x = x;
+//	        this.y = y;
+//	    }
+//	}
+//
+//	class Point3 extends Point {
+//	    // shadows the field of Point
+//	    int x;
+//	    double z;
+//
+//	    Point3(int x, long y, double z) {
+//	        super(x, y);
+//	        this.x = -x;
+//	        this.z = z;
+//	    }
+//	}
+//
+//	public class Instances {
+//	    static Object identity(Object o) {
+//	        return o;
+//	    }
+//
+//	    public static void main(String[] args) {
+//	        System.out.println(identity(new Person()) instanceof Mammal);
+//	        System.out.println(identity(new Person()) instanceof Animal);
+//
+//	        Object person = identity(new Person());
+//	        Animal animal = (Animal) (Mammal) person;
+//	        System.out.println(animal == person);
+//
+//	        System.out.println(identity(null) instanceof Person);
+//
+//	        Point3 point = new Point3(3, 4L, 0.5);
+//	        Point base = point;
+//	        System.out.println(base.x);
+//	        System.out.println(point.x);
+//	        System.out.println(base.y);
+//	        System.out.println(point.z);
+//	    }
+//	}
+func Instances() []*Class {
+	point := NewClass(core.ACC_SUPER, "Point", "java/lang/Object")
+	point.Field(0, "x", "I").Field(0, "y", "J")
+	point.Method(0, "<init>", "(IJ)V", point.Code(3, 4).
+		Op(core.OP_ALOAD_0).Invoke(core.OP_INVOKESPECIAL, "java/lang/Object", "<init>", "()V").
+		Op(core.OP_ALOAD_0, core.OP_ILOAD_1).Field(core.OP_PUTFIELD, "Point", "x", "I").
+		Op(core.OP_ALOAD_0, core.OP_LLOAD_2).Field(core.OP_PUTFIELD, "Point", "y", "J").
+		Op(core.OP_RETURN),
+	)
+
+	point3 := NewClass(core.ACC_SUPER, "Point3", "Point")
+	point3.Field(0, "x", "I").Field(0, "z", "D")
+	point3.Method(0, "<init>", "(IJD)V", point3.Code(4, 6).
+		Op(core.OP_ALOAD_0, core.OP_ILOAD_1, core.OP_LLOAD_2).Invoke(core.OP_INVOKESPECIAL, "Point", "<init>", "(IJ)V").
+		Op(core.OP_ALOAD_0, core.OP_ILOAD_1, core.OP_INEG).Field(core.OP_PUTFIELD, "Point3", "x", "I").
+		Op(core.OP_ALOAD_0).Local(core.OP_DLOAD, 4).Field(core.OP_PUTFIELD, "Point3", "z", "D").
+		Op(core.OP_RETURN),
+	)
+
+	instances := NewClass(core.ACC_PUBLIC|core.ACC_SUPER, "Instances", "java/lang/Object")
+	instances.Constructor(core.ACC_PUBLIC)
+	instances.Method(core.ACC_STATIC, "identity", "(Ljava/lang/Object;)Ljava/lang/Object;", instances.Code(1, 1).
+		Op(core.OP_ALOAD_0, core.OP_ARETURN),
+	)
+
+	newPerson := func(c *Code) *Code {
+		return c.Type(core.OP_NEW, "Person").Op(core.OP_DUP).Invoke(core.OP_INVOKESPECIAL, "Person", "<init>", "()V").
+			Invoke(core.OP_INVOKESTATIC, "Instances", "identity", "(Ljava/lang/Object;)Ljava/lang/Object;")
+	}
+
+	main := instances.Code(8, 5)
+	newPerson(main).Type(core.OP_INSTANCEOF, "Mammal").Println("Z")
+	newPerson(main).Type(core.OP_INSTANCEOF, "Animal").Println("Z")
+
+	newPerson(main).Op(core.OP_ASTORE_1).
+		Op(core.OP_ALOAD_1).Type(core.OP_CHECKCAST, "Mammal").Type(core.OP_CHECKCAST, "Animal").Op(core.OP_ASTORE_2).
+		Op(core.OP_ALOAD_2, core.OP_ALOAD_1).Jump(core.OP_IF_ACMPNE, "other").
+		Op(core.OP_ICONST_1).Jump(core.OP_GOTO, "same").
+		Label("other").
+		Op(core.OP_ICONST_0).
+		Label("same").
+		Println("Z")
+
+	main.Op(core.OP_ACONST_NULL).Invoke(core.OP_INVOKESTATIC, "Instances", "identity", "(Ljava/lang/Object;)Ljava/lang/Object;").
+		Type(core.OP_INSTANCEOF, "Person").Println("Z")
+
+	main.Type(core.OP_NEW, "Point3").Op(core.OP_DUP, core.OP_ICONST_3).Long(4).Double(0.5).
+		Invoke(core.OP_INVOKESPECIAL, "Point3", "<init>", "(IJD)V").Op(core.OP_ASTORE_3).
+		Op(core.OP_ALOAD_3).Local(core.OP_ASTORE, 4).
+		Local(core.OP_ALOAD, 4).Field(core.OP_GETFIELD, "Point", "x", "I").Println("I").
+		Op(core.OP_ALOAD_3).Field(core.OP_GETFIELD, "Point3", "x", "I").Println("I").
+		Local(core.OP_ALOAD, 4).Field(core.OP_GETFIELD, "Point", "y", "J").Println("J").
+		Op(core.OP_ALOAD_3).Field(core.OP_GETFIELD, "Point3", "z", "D").Println("D").
+		Op(core.OP_RETURN)
+
+	instances.Method(core.ACC_PUBLIC|core.ACC_STATIC, "main", "([Ljava/lang/String;)V", main)
+
+	return []*Class{point, point3, instances}
+}
@@ -0,0 +1,133 @@
+package fixtures
+
+import "github.com/Gustrb/jbm/src/core"
+
+// Lambdas links lambdas and method references with invokedynamic, printing what they return:
+//
+//	interface IntOperation {
+//	    int apply(int value);
+//	}
+//
+//	interface Supplier {
+//	    Object get();
+//	}
+//
+//	interface Counter {
+//	    int count(Lambdas lambdas);
+//	}
+//
+//	public class Lambdas {
+//	    int total;
+//
+//	    Lambdas() {
+//	        this.total = 5;
+//	    }
+//
+//	    int total() {
+//	        return total;
+//	    }
+//
+//	    static int twice(int value) {
+//	        return value * 2;
+//	    }
+//
+//	    static int compose(IntOperation first, IntOperation second, int value) {
+//	        return second.apply(first.apply(value));
+//	    }
+//
+//	    public static void main(String[] args) {
+//	        int offset = 3;
+//	        IntOperation add = value -> value + offset;
+//	        System.out.println(add.apply(4));                          // 7
+//	        System.out.println(compose(Lambdas::twice, add, 5));       // 13
+//
+//	        Supplier supplier = Lambdas::new;
+//	        System.out.println(supplier.get() instanceof Lambdas);     // true
+//
+//	        Counter counter = Lambdas::total;
+//	        System.out.println(counter.count(new Lambdas()));          // 5
+//
+//	        Lambdas lambdas = new Lambdas();
+//	        IntOperation bound = value -> lambdas.total + value;
+//	        lambdas.total = 10;
+//	        System.out.println(bound.apply(1));                        // 11
+//	    }
+//	}
+//
+// Like javac, the bodies of the lambdas are the synthetic methods lambda$main$0 and lambda$main$1, which take
+// what the lambdas capture before their arguments.
+func Lambdas() []*Class {
+	operation := Interface("IntOperation")
+	operation.Method(core.ACC_PUBLIC|core.ACC_ABSTRACT, "apply", "(I)I", nil)
+
+	supplier := Interface("Supplier")
+	supplier.Method(core.ACC_PUBLIC|core.ACC_ABSTRACT, "get", "()Ljava/lang/Object;", nil)
+
+	counter := Interface("Counter")
+	counter.Method(core.ACC_PUBLIC|core.ACC_ABSTRACT, "count", "(LLambdas;)I", nil)
+
+	c := NewClass(core.ACC_PUBLIC|core.ACC_SUPER, "Lambdas", "java/lang/Object")
+	c.Field(0, "total", "I")
+
+	c.Method(0, "<init>", "()V", c.Code(2, 1).
+		Op(core.OP_ALOAD_0).Invoke(core.OP_INVOKESPECIAL, "java/lang/Object", "<init>", "()V").
+		Op(core.OP_ALOAD_0, core.OP_ICONST_5).Field(core.OP_PUTFIELD, "Lambdas", "total", "I").
+		Op(core.OP_RETURN),
+	)
+
+	c.Method(0, "total", "()I", c.Code(1, 1).
+		Op(core.OP_ALOAD_0).Field(core.OP_GETFIELD, "Lambdas", "total", "I").
+		Op(core.OP_IRETURN),
+	)
+
+	c.Method(core.ACC_STATIC, "twice", "(I)I", c.Code(2, 1).Op(core.OP_ILOAD_0, core.OP_ICONST_2, core.OP_IMUL, core.OP_IRETURN))
+
+	c.Method(core.ACC_STATIC, "compose", "(LIntOperation;LIntOperation;I)I", c.Code(3, 3).
+		Op(core.OP_ALOAD_1, core.OP_ALOAD_0, core.OP_ILOAD_2).
+		InvokeInterface(core.OP_INVOKEINTERFACE, "IntOperation", "apply", "(I)I").
+		InvokeInterface(core.OP_INVOKEINTERFACE, "IntOperation", "apply", "(I)I").
+		Op(core.OP_IRETURN),
+	)
+
+	const lambda = core.ACC_PRIVATE | core.ACC_STATIC | core.ACC_SYNTHETIC
+
+	c.Method(lambda, "lambda$main$0", "(II)I", c.Code(2, 2).Op(core.OP_ILOAD_1, core.OP_ILOAD_0, core.OP_IADD, core.OP_IRETURN))
+
+	c.Method(lambda, "lambda$main$1", "(LLambdas;I)I", c.Code(2, 2).
+		Op(core.OP_ALOAD_0).Field(core.OP_GETFIELD, "Lambdas", "total", "I").
+		Op(core.OP_ILOAD_1, core.OP_IADD, core.OP_IRETURN),
+	)
+
+	add := c.Metafactory("(I)I", c.MethodHandle(core.REF_invokeStatic, c.Methodref("Lambdas", "lambda$main$0", "(II)I")), "(I)I")
+	twice := c.Metafactory("(I)I", c.MethodHandle(core.REF_invokeStatic, c.Methodref("Lambdas", "twice", "(I)I")), "(I)I")
+	constructor := c.Metafactory("()Ljava/lang/Object;", c.MethodHandle(core.REF_newInvokeSpecial, c.Methodref("Lambdas", "<init>", "()V")), "()LLambdas;")
+	total := c.Metafactory("(LLambdas;)I", c.MethodHandle(core.REF_invokeVirtual, c.Methodref("Lambdas", "total", "()I")), "(LLambdas;)I")
+	bound := c.Metafactory("(I)I", c.MethodHandle(core.REF_invokeStatic, c.Methodref("Lambdas", "lambda$main$1", "(LLambdas;I)I")), "(I)I")
+
+	// the locals are offset, add, supplier, counter, lambdas and bound, after args
+	main := c.Code(4, 7).
+		Op(core.OP_ICONST_3).Local(core.OP_ISTORE, 1).
+		Local(core.OP_ILOAD, 1).InvokeDynamic(add, "apply", "(I)LIntOperation;").Local(core.OP_ASTORE, 2).
+		Local(core.OP_ALOAD, 2).Op(core.OP_ICONST_4).InvokeInterface(core.OP_INVOKEINTERFACE, "IntOperation", "apply", "(I)I").
+		Println("I").
+		InvokeDynamic(twice, "apply", "()LIntOperation;").Local(core.OP_ALOAD, 2).Op(core.OP_ICONST_5).
+		Invoke(core.OP_INVOKESTATIC, "Lambdas", "compose", "(LIntOperation;LIntOperation;I)I").Println("I").
+		InvokeDynamic(constructor, "get", "()LSupplier;").Local(core.OP_ASTORE, 3).
+		Local(core.OP_ALOAD, 3).InvokeInterface(core.OP_INVOKEINTERFACE, "Supplier", "get", "()Ljava/lang/Object;").
+		Type(core.OP_INSTANCEOF, "Lambdas").Println("Z").
+		InvokeDynamic(total, "count", "()LCounter;").Local(core.OP_ASTORE, 4).
+		Local(core.OP_ALOAD, 4).
+		Type(core.OP_NEW, "Lambdas").Op(core.OP_DUP).Invoke(core.OP_INVOKESPECIAL, "Lambdas", "<init>", "()V").
+		InvokeInterface(core.OP_INVOKEINTERFACE, "Counter", "count", "(LLambdas;)I").Println("I").
+		Type(core.OP_NEW, "Lambdas").Op(core.OP_DUP).Invoke(core.OP_INVOKESPECIAL, "Lambdas", "<init>", "()V").
+		Local(core.OP_ASTORE, 5).
+		Local(core.OP_ALOAD, 5).InvokeDynamic(bound, "apply", "(LLambdas;)LIntOperation;").Local(core.OP_ASTORE, 6).
+		Local(core.OP_ALOAD, 5).Int(10).Field(core.OP_PUTFIELD, "Lambdas", "total", "I").
+		Local(core.OP_ALOAD, 6).Op(core.OP_ICONST_1).InvokeInterface(core.OP_INVOKEINTERFACE, "IntOperation", "apply", "(I)I").
+		Println("I").
+		Op(core.OP_RETURN)
+
+	c.Method(core.ACC_PUBLIC|core.ACC_STATIC, "main", "([Ljava/lang/String;)V", main)
+
+	return []*Class{operation, supplier, counter, c}
+}
@@ -0,0 +1,37 @@
+package fixtures
+
+import "github.com/Gustrb/jbm/src/core"
+
+// NullPointers reads a field of the null returned by a method, which nothing catches:
+//
+//	public class NullPointers {
+//	    int[] values;
+//	    NullPointers next;
+//
+//	    static NullPointers find(int id) {
+//	        return null;
+//	    }
+//
+//	    public static void main(String[] args) {
+//	        System.exit(find(1).next.values.length);
+//	    }
+//	}
+func NullPointers() *Class {
+	c := NewClass(core.ACC_PUBLIC|core.ACC_SUPER, "NullPointers", "java/lang/Object")
+	c.Constructor(core.ACC_PUBLIC)
+	c.Field(0, "values", "[I")
+	c.Field(0, "next", "LNullPointers;")
+
+	c.Method(core.ACC_STATIC, "find", "(I)LNullPointers;", c.Code(1, 1).Op(core.OP_ACONST_NULL, core.OP_ARETURN))
+
+	c.Method(core.ACC_PUBLIC|core.ACC_STATIC, "main", "([Ljava/lang/String;)V", c.Code(1, 1).
+		Op(core.OP_ICONST_1).Invoke(core.OP_INVOKESTATIC, "NullPointers", "find", "(I)LNullPointers;").
+		Field(core.OP_GETFIELD, "NullPointers", "next", "LNullPointers;").
+		Field(core.OP_GETFIELD, "NullPointers", "values", "[I").
+		Op(core.OP_ARRAYLENGTH).
+		Invoke(core.OP_INVOKESTATIC, "java/lang/System", "exit", "(I)V").
+		Op(core.OP_RETURN),
+	)
+
+	return c
+}
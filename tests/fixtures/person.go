@@ -0,0 +1,38 @@
+package fixtures
+
+import "github.com/Gustrb/jbm/src/core"
+
+// Person implements an interface that extends another one, printing what it does:
+//
+//	interface Animal {
+//	    void eat();
+//	}
+//
+//	interface Mammal extends Animal {
+//	    void move();
+//	}
+//
+//	public class Person implements Mammal {
+//	    public void eat() {
+//	        System.out.println("Eating");
+//	    }
+//
+//	    public void move() {
+//	        System.out.println("Moving");
+//	    }
+//	}
+func Person() []*Class {
+	animal := Interface("Animal")
+	animal.Method(core.ACC_PUBLIC|core.ACC_ABSTRACT, "eat", "()V", nil)
+
+	mammal := Interface("Mammal", "Animal")
+	mammal.Method(core.ACC_PUBLIC|core.ACC_ABSTRACT, "move", "()V", nil)
+
+	person := NewClass(core.ACC_PUBLIC|core.ACC_SUPER, "Person", "java/lang/Object", "Mammal")
+	person.Constructor(core.ACC_PUBLIC)
+
+	person.Method(core.ACC_PUBLIC, "eat", "()V", person.Code(3, 1).String("Eating").Println("Ljava/lang/String;").Op(core.OP_RETURN))
+	person.Method(core.ACC_PUBLIC, "move", "()V", person.Code(3, 1).String("Moving").Println("Ljava/lang/String;").Op(core.OP_RETURN))
+
+	return []*Class{animal, mammal, person}
+}
@@ -0,0 +1,73 @@
+package fixtures
+
+import "github.com/Gustrb/jbm/src/core"
+
+// StackTraces throws an exception with a cause and a suppressed exception, with the lines of this source:
+//
+//	 1  class Failure extends RuntimeException {
+//	 2      Failure(Throwable cause) {
+//	 3          super(cause);
+//	 4      }
+//	 5  }
+//	 6
+//	 7  public class StackTraces {
+//	 8      static int divide(int a, int b) {
+//	 9          return a / b;
+//	10      }
+//	11
+//	12      static void compute() {
+//	13          try {
+//	14              divide(1, 0);
+//	15          } catch (ArithmeticException e) {
+//	16              Failure failure = new Failure(e);
+//	17              failure.addSuppressed(new IllegalStateException());
+//	18              throw failure;
+//	19          }
+//	20      }
+//	21
+//	22      public static void main(String[] args) {
+//	23          compute();
+//	24      }
+//	25  }
+func StackTraces() []*Class {
+	failure := NewClass(core.ACC_SUPER, "Failure", "java/lang/RuntimeException").SourceFile("StackTraces.java")
+	failure.Method(0, "<init>", "(Ljava/lang/Throwable;)V", failure.Code(2, 2).
+		Line(3).Op(core.OP_ALOAD_0, core.OP_ALOAD_1).
+		Invoke(core.OP_INVOKESPECIAL, "java/lang/RuntimeException", "<init>", "(Ljava/lang/Throwable;)V").
+		Line(4).Op(core.OP_RETURN),
+	)
+
+	c := NewClass(core.ACC_PUBLIC|core.ACC_SUPER, "StackTraces", "java/lang/Object").SourceFile("StackTraces.java")
+	c.Constructor(core.ACC_PUBLIC)
+
+	c.Method(core.ACC_STATIC, "divide", "(II)I", c.Code(2, 2).
+		Line(9).Op(core.OP_ILOAD_0, core.OP_ILOAD_1, core.OP_IDIV, core.OP_IRETURN),
+	)
+
+	c.Method(core.ACC_STATIC, "compute", "()V", c.Code(3, 2).
+		Label("start").
+		Line(14).Op(core.OP_ICONST_1, core.OP_ICONST_0).Invoke(core.OP_INVOKESTATIC, "StackTraces", "divide", "(II)I").
+		Op(core.OP_POP).
+		Label("end").
+		Jump(core.OP_GOTO, "return").
+		Label("handler").
+		Line(15).Op(core.OP_ASTORE_0).
+		Line(16).Type(core.OP_NEW, "Failure").Op(core.OP_DUP, core.OP_ALOAD_0).
+		Invoke(core.OP_INVOKESPECIAL, "Failure", "<init>", "(Ljava/lang/Throwable;)V").Op(core.OP_ASTORE_1).
+		Line(17).Op(core.OP_ALOAD_1).
+		Type(core.OP_NEW, "java/lang/IllegalStateException").Op(core.OP_DUP).
+		Invoke(core.OP_INVOKESPECIAL, "java/lang/IllegalStateException", "<init>", "()V").
+		Invoke(core.OP_INVOKEVIRTUAL, "Failure", "addSuppressed", "(Ljava/lang/Throwable;)V").
+		Line(18).Op(core.OP_ALOAD_1, core.OP_ATHROW).
+		Label("return").
+		Line(20).Op(core.OP_RETURN).
+		Try("start", "end", "handler", "java/lang/ArithmeticException"),
+	)
+
+	c.Method(core.ACC_PUBLIC|core.ACC_STATIC, "main", "([Ljava/lang/String;)V", c.Code(0, 1).
+		Line(23).Invoke(core.OP_INVOKESTATIC, "StackTraces", "compute", "()V").
+		Line(24).Op(core.OP_RETURN),
+	)
+
+	return []*Class{failure, c}
+}
@@ -0,0 +1,29 @@
+package interpreter_test
+
+import (
+	"testing"
+
+	"github.com/Gustrb/jbm/tests/fixtures"
+)
+
+func TestShouldAllocateAndAccessArrays(t *testing.T) {
+	out, err := runFixture(t, writeFixture(t, fixtures.Person(), fixtures.Arrays()), "Arrays")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := "30\n" +
+		// long[3][4] with its last element set
+		"3\n4\n0\n9223372036854775807\n" +
+		// the elements narrowed by the stores
+		"-56\nb\n-25536\n1.0\n" +
+		// Person[] is an instance of the arrays of its superinterfaces
+		"true\ntrue\nfalse\n" +
+		// int[] is Cloneable and Serializable but not an Object[]
+		"true\ntrue\nfalse\n" +
+		// the inner arrays of int[2][] are null until they are allocated
+		"true\n7\n"
+	if out != expected {
+		t.Errorf("Expected %q, got %q", expected, out)
+	}
+}
@@ -0,0 +1,67 @@
+package interpreter_test
+
+import (
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+	"github.com/Gustrb/jbm/tests/fixtures"
+)
+
+func TestShouldDispatchVirtualInterfaceAndSuperCalls(t *testing.T) {
+	out, err := runFixture(t, writeFixture(t, fixtures.Dispatch()), "Dispatch")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := "4\n3\n" +
+		// the default method of Shape, and the one of Triangle calling it
+		"4\n30\n" +
+		// Square calls the method of Polygon it overrides
+		"2\n1\n" +
+		// the default method of Labeled is more specific than the one of Named
+		"2\n" +
+		// Polygon calls its private method
+		"10\n"
+	if out != expected {
+		t.Errorf("Expected %q, got %q", expected, out)
+	}
+}
+
+func TestShouldSelectThePersonMethodsThroughInterfaces(t *testing.T) {
+	cp, err := core.ParseClassPath(writeFixture(t, fixtures.Person()))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	loaders := core.NewClassLoaders(nil, nil, cp)
+	defer loaders.Close()
+
+	person, err := loaders.Application.LoadClass("Person")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	animal, _ := loaders.Application.LoadClass("Animal")
+	mammal, _ := loaders.Application.LoadClass("Mammal")
+
+	for _, resolved := range []*core.Method{animal.DeclaredMethod("eat", "()V"), mammal.DeclaredMethod("move", "()V")} {
+		selected, err := person.SelectMethod(resolved)
+		if err != nil || selected != person.DeclaredMethod(resolved.Name, "()V") {
+			t.Errorf("Expected %s to select the method of Person, got %v (%v)", resolved, selected, err)
+		}
+	}
+}
+
+func TestShouldLinkLambdasAndMethodReferences(t *testing.T) {
+	out, err := runFixture(t, writeFixture(t, fixtures.Lambdas()), "Lambdas")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// a lambda capturing a local, composed with a reference to a static method, a reference to a constructor, to
+	// an unbound instance method and a lambda capturing an object whose field changes after
+	expected := "7\n13\ntrue\n5\n11\n"
+	if out != expected {
+		t.Errorf("Expected %q, got %q", expected, out)
+	}
+}
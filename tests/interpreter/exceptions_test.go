@@ -0,0 +1,55 @@
+package interpreter_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+	"github.com/Gustrb/jbm/tests/fixtures"
+)
+
+func TestShouldThrowAndCatchExceptions(t *testing.T) {
+	out, err := runFixture(t, writeFixture(t, fixtures.Exceptions()), "Exceptions")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := "2\n-1\n" +
+		// the finally block of unwind ran once before Boom was caught
+		"1\n" +
+		"NullPointerException\nClassCastException\nIndexOutOfBoundsException\n" +
+		// the handler of RuntimeException does not catch Fatal
+		"Error\n" +
+		// the handler gets the thrown object
+		"true\n"
+	if out != expected {
+		t.Errorf("Expected %q, got %q", expected, out)
+	}
+}
+
+func TestShouldReportUncaughtExceptions(t *testing.T) {
+	_, err := runFixture(t, writeFixture(t, []*fixtures.Class{fixtures.Uncaught()}), "Uncaught")
+
+	var uncaught *core.UncaughtException
+	if !errors.As(err, &uncaught) {
+		t.Fatalf("Expected an uncaught exception, got %v", err)
+	}
+
+	if err.Error() != `Exception in thread "main" java.lang.ArithmeticException: / by zero` {
+		t.Errorf("Expected the uncaught ArithmeticException to be reported, got %q", err.Error())
+	}
+}
+
+func TestShouldDescribeWhatIsNullInUncaughtNullPointerExceptions(t *testing.T) {
+	_, err := runFixture(t, writeFixture(t, []*fixtures.Class{fixtures.NullPointers()}), "NullPointers")
+
+	var uncaught *core.UncaughtException
+	if !errors.As(err, &uncaught) {
+		t.Fatalf("Expected an uncaught exception, got %v", err)
+	}
+
+	expected := `Exception in thread "main" java.lang.NullPointerException: Cannot read field "next" because the return value of "NullPointers.find(int)" is null`
+	if err.Error() != expected {
+		t.Errorf("Expected the uncaught NullPointerException to be reported, got %q", err.Error())
+	}
+}
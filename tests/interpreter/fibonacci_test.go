@@ -0,0 +1,23 @@
+package interpreter_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+	"github.com/Gustrb/jbm/tests/fixtures"
+)
+
+func TestShouldExitWithTheFibonacciNumberComputed(t *testing.T) {
+	err := core.ExecuteClassFile(bytes.NewReader(fixtures.Fibonacci().Bytes()))
+
+	var exit *core.ExitError
+	if !errors.As(err, &exit) {
+		t.Fatalf("Expected the program to call System.exit, got %v", err)
+	}
+
+	if exit.Code != 55 {
+		t.Errorf("Expected exit code 55, got %d", exit.Code)
+	}
+}
@@ -0,0 +1,49 @@
+package interpreter_test
+
+import (
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+	"github.com/Gustrb/jbm/tests/fixtures"
+)
+
+func TestShouldPrintHelloWorldWithoutAJDK(t *testing.T) {
+	out, err := runFixture(t, writeFixture(t, []*fixtures.Class{fixtures.HelloWorld()}), "HelloWorld")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if out != "Hello, World!\n" {
+		t.Errorf("Expected \"Hello, World!\\n\", got %q", out)
+	}
+}
+
+func TestShouldPrintWhatAPersonDoesWithoutAJDK(t *testing.T) {
+	out := captureSystemOut(t)
+
+	cp, err := core.ParseClassPath(writeFixture(t, fixtures.Person()))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	loaders := core.NewClassLoaders(nil, nil, cp)
+	defer loaders.Close()
+
+	person, err := loaders.Application.LoadClass("Person")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	thread := core.NewThread(core.MainThreadName)
+	obj := core.NewObject(person)
+
+	for _, name := range []string{"<init>", "eat", "move"} {
+		if _, err := thread.Invoke(person.DeclaredMethod(name, "()V"), []core.Slot{{Ref: obj}}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if out.String() != "Eating\nMoving\n" {
+		t.Errorf("Expected \"Eating\\nMoving\\n\", got %q", out.String())
+	}
+}
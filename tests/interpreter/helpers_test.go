@@ -0,0 +1,40 @@
+package interpreter_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+	"github.com/Gustrb/jbm/tests/fixtures"
+)
+
+// captureSystemOut redirects `System.out` of the built-in class library to a buffer for the test.
+func captureSystemOut(t *testing.T) *bytes.Buffer {
+	var out bytes.Buffer
+
+	previous := core.SystemOut
+	core.SystemOut = &out
+	t.Cleanup(func() { core.SystemOut = previous })
+
+	return &out
+}
+
+// writeFixture writes the classes of a fixture in a directory of the test, returning it.
+func writeFixture(t *testing.T, classes ...[]*fixtures.Class) string {
+	dir := t.TempDir()
+	for _, c := range classes {
+		if err := fixtures.Write(dir, c...); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	return dir
+}
+
+// runFixture runs the main class of the fixture written in the directory, returning what it prints.
+func runFixture(t *testing.T, dir string, mainClass string) (string, error) {
+	out := captureSystemOut(t)
+	err := core.RunJBM([]string{"-cp", dir, mainClass})
+
+	return out.String(), err
+}
@@ -0,0 +1,20 @@
+package interpreter_test
+
+import (
+	"testing"
+
+	"github.com/Gustrb/jbm/tests/fixtures"
+)
+
+func TestShouldInstantiateAndCastObjects(t *testing.T) {
+	out, err := runFixture(t, writeFixture(t, fixtures.Person(), fixtures.Instances()), "Instances")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// instanceof of interfaces and superinterfaces, casts, null and the fields of Point3 shadowing the ones of Point
+	expected := "true\ntrue\ntrue\nfalse\n3\n-3\n4\n0.5\n"
+	if out != expected {
+		t.Errorf("Expected %q, got %q", expected, out)
+	}
+}
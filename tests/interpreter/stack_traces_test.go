@@ -0,0 +1,37 @@
+package interpreter_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/Gustrb/jbm/src/core"
+	"github.com/Gustrb/jbm/tests/fixtures"
+)
+
+func TestShouldPrintTheStackTraceOfUncaughtExceptions(t *testing.T) {
+	_, err := runFixture(t, writeFixture(t, fixtures.StackTraces()), "StackTraces")
+
+	var uncaught *core.UncaughtException
+	if !errors.As(err, &uncaught) {
+		t.Fatalf("Expected an uncaught exception, got %v", err)
+	}
+
+	var out bytes.Buffer
+	uncaught.PrintStackTrace(&out)
+
+	// the same output as the reference implementation
+	expected := "Exception in thread \"main\" Failure: java.lang.ArithmeticException: / by zero\n" +
+		"\tat StackTraces.compute(StackTraces.java:16)\n" +
+		"\tat StackTraces.main(StackTraces.java:23)\n" +
+		"\tSuppressed: java.lang.IllegalStateException\n" +
+		"\t\tat StackTraces.compute(StackTraces.java:17)\n" +
+		"\t\t... 1 more\n" +
+		"Caused by: java.lang.ArithmeticException: / by zero\n" +
+		"\tat StackTraces.divide(StackTraces.java:9)\n" +
+		"\tat StackTraces.compute(StackTraces.java:14)\n" +
+		"\t... 1 more\n"
+	if out.String() != expected {
+		t.Errorf("Expected the stack trace\n%s\ngot\n%s", expected, out.String())
+	}
+}